@@ -3,17 +3,22 @@ package main
 import (
 	"context"
 	"database/sql"
+	"fastinghero/internal/adapters/document"
 	"fastinghero/internal/adapters/handler/http"
 	"fastinghero/internal/adapters/middleware"
 	"fastinghero/internal/adapters/payment"
 	"fastinghero/internal/adapters/repository/memory"
 	"fastinghero/internal/adapters/repository/postgres"
+	"fastinghero/internal/adapters/secondary/knowledge"
 	"fastinghero/internal/adapters/secondary/llm"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
 	"fastinghero/internal/core/services"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -79,10 +84,22 @@ func main() {
 	var referralRepo ports.ReferralRepository
 	var notificationRepo ports.NotificationRepository
 	var subscriptionRepo ports.SubscriptionRepository
+	var webhookEventRepo ports.WebhookEventRepository
 	var vaultRepo ports.VaultRepository
+	var vaultLedgerRepo ports.VaultLedgerRepository
 	var socialRepo ports.SocialRepository
 	var progressRepo ports.ProgressRepository
 	var tribeRepo ports.TribeRepository
+	var tribePoolRepo ports.TribePoolRepository
+	var trialRepo ports.TrialRepository
+	var promoRepo ports.PromoCodeRepository
+	var disciplineRepo ports.DisciplineRepository
+	var paymentRepo ports.PaymentRepository
+	var cortexThreadRepo ports.CortexThreadRepository
+	var cortexUsageRepo ports.CortexUsageRepository
+	var promptExposureRepo ports.PromptExposureRepository
+	var safetyFlagRepo ports.SafetyFlagRepository
+	var cortexActionRepo ports.CortexActionRepository
 	var sosRepo ports.SOSRepository
 
 	// Check for DB connection string
@@ -161,10 +178,22 @@ func main() {
 		referralRepo = postgres.NewPostgresReferralRepository(db)
 		notificationRepo = postgres.NewPostgresNotificationRepository(db)
 		subscriptionRepo = postgres.NewPostgresSubscriptionRepository(db)
+		webhookEventRepo = postgres.NewPostgresWebhookEventRepository(db)
 		vaultRepo = postgres.NewPostgresVaultRepository(db)
+		vaultLedgerRepo = postgres.NewPostgresVaultLedgerRepository(db)
 		socialRepo = postgres.NewPostgresSocialRepository(db)
 		progressRepo = postgres.NewPostgresProgressRepository(db)
 		tribeRepo = postgres.NewPostgresTribeRepository(db)
+		tribePoolRepo = postgres.NewPostgresTribePoolRepository(db)
+		trialRepo = postgres.NewPostgresTrialRepository(db)
+		promoRepo = postgres.NewPostgresPromoCodeRepository(db)
+		disciplineRepo = postgres.NewPostgresDisciplineRepository(db)
+		paymentRepo = postgres.NewPostgresPaymentRepository(db)
+		cortexThreadRepo = postgres.NewPostgresCortexThreadRepository(db)
+		cortexUsageRepo = postgres.NewPostgresCortexUsageRepository(db)
+		promptExposureRepo = postgres.NewPostgresPromptExposureRepository(db)
+		safetyFlagRepo = postgres.NewPostgresSafetyFlagRepository(db)
+		cortexActionRepo = postgres.NewPostgresCortexActionRepository(db)
 		sosRepo = postgres.NewPostgresSOSRepository(db)
 		// Note: Using in-memory reminder repo even with DB for now (no postgres impl yet)
 	} else {
@@ -177,10 +206,22 @@ func main() {
 		referralRepo = memory.NewReferralRepository()
 		notificationRepo = memory.NewNotificationRepository()
 		subscriptionRepo = memory.NewSubscriptionRepository()
+		webhookEventRepo = memory.NewWebhookEventRepository()
 		vaultRepo = memory.NewVaultRepository()
+		vaultLedgerRepo = memory.NewVaultLedgerRepository()
 		socialRepo = memory.NewSocialRepository()
 		progressRepo = memory.NewProgressRepository()
 		tribeRepo = memory.NewTribeRepository()
+		tribePoolRepo = memory.NewTribePoolRepository()
+		trialRepo = memory.NewTrialRepository()
+		promoRepo = memory.NewPromoCodeRepository()
+		disciplineRepo = memory.NewDisciplineRepository()
+		paymentRepo = memory.NewPaymentRepository()
+		cortexThreadRepo = memory.NewCortexThreadRepository()
+		cortexUsageRepo = memory.NewCortexUsageRepository()
+		promptExposureRepo = memory.NewPromptExposureRepository()
+		safetyFlagRepo = memory.NewSafetyFlagRepository()
+		cortexActionRepo = memory.NewCortexActionRepository()
 		sosRepo = memory.NewMemorySOSRepository()
 	}
 
@@ -193,14 +234,52 @@ func main() {
 	mealRepo := memory.NewMealRepository()
 	recipeRepo := memory.NewRecipeRepository()
 
-	// Payment Adapter
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	// Payment Adapter (PAYMENT_GATEWAY=fake runs billing offline with simulated, signed webhooks)
 	stripeKey := os.Getenv("STRIPE_SECRET_KEY")
 	stripeWebhookSecret := os.Getenv("STRIPE_WEBHOOK_SECRET")
-	paymentAdapter := payment.NewStripeAdapter(stripeKey, stripeWebhookSecret)
+	var paymentAdapter ports.PaymentGateway
+	var fakeGateway *payment.FakeGateway
+	if os.Getenv("PAYMENT_GATEWAY") == "fake" {
+		if os.Getenv("ENVIRONMENT") == "production" {
+			log.Fatal("PAYMENT_GATEWAY=fake is not allowed in production")
+		}
+		if stripeWebhookSecret == "" {
+			stripeWebhookSecret = "whsec_fake"
+		}
+		webhookURL := os.Getenv("FAKE_PAYMENTS_WEBHOOK_URL")
+		if webhookURL == "" {
+			webhookURL = "http://localhost:" + port + "/api/v1/payments/webhook"
+		}
+		fakeGateway = payment.NewFakeGateway(
+			stripeWebhookSecret,
+			payment.NewWebhookSimulator(webhookURL, stripeWebhookSecret),
+			"http://localhost:"+port+"/api/v1/dev/payments",
+		)
+		paymentAdapter = fakeGateway
+		log.Println("Using fake payment gateway, webhooks go to " + webhookURL)
+	} else {
+		paymentAdapter = payment.NewStripeAdapter(stripeKey, stripeWebhookSecret)
+	}
 
 	// 2. Initialize Services (Core)
-	vaultService := services.NewVaultService(userRepo, vaultRepo, paymentAdapter)
-	referralService := services.NewReferralService(referralRepo, userRepo, vaultService)
+	// Vault earning rules (VAULT_EARNING_RULES_PATH: JSON array of versioned rule sets; defaults to LAZY_TAX_RULES.md)
+	earningRuleBook, err := services.LoadEarningRuleBook(os.Getenv("VAULT_EARNING_RULES_PATH"))
+	if err != nil {
+		log.Fatalf("Failed to load vault earning rules: %v", err)
+	}
+	earningRules := services.NewEarningRulesEngine(earningRuleBook, mealRepo, telemetryRepo, fastingRepo)
+	vaultService := services.NewVaultService(userRepo, vaultRepo, vaultLedgerRepo, earningRules, paymentAdapter)
+	referralConfig, err := referralConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid referral configuration: %v", err)
+	}
+	referralService := services.NewReferralService(referralRepo, userRepo, vaultService, referralConfig)
+	disciplineService := services.NewDisciplineService(disciplineRepo, userRepo, domain.DefaultDisciplineRules)
 
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
@@ -225,14 +304,16 @@ func main() {
 	}
 
 	authService := services.NewAuthService(userRepo, referralService, jwtSecret)
-	fastingService := services.NewFastingService(fastingRepo, vaultService, userRepo)
-	ketoService := services.NewKetoService(ketoRepo, userRepo)
+	fastingService := services.NewFastingService(fastingRepo, vaultService, userRepo, disciplineService, referralService)
+	entitlementService := services.NewEntitlementService(userRepo)
+	ketoService := services.NewKetoService(ketoRepo, entitlementService)
 	leaderboardService := services.NewLeaderboardService(leaderboardRepo)
 	gamificationService := services.NewGamificationService(gamificationRepo, fastingRepo)
 	activityService := services.NewActivityService(activityRepo)
 	telemetryService := services.NewTelemetryService(telemetryRepo)
 	socialService := services.NewSocialService(socialRepo)
 	progressService := services.NewProgressService(progressRepo)
+	challengeService := services.NewChallengeService(socialRepo, fastingRepo, telemetryRepo, progressRepo, entitlementService, vaultService)
 
 	// Only create TribeService if repository exists (not nil in memory mode)
 	var tribeService ports.TribeService
@@ -245,35 +326,36 @@ func main() {
 	// Note: We use the already initialized services/repos here
 	// Ensure tribeService is handled correctly inside NewSOSService or passed safely
 
-	apiKey := os.Getenv("DEEPSEEK_API_KEY")
-	if apiKey == "" {
-		log.Println("Warning: DEEPSEEK_API_KEY not set, Cortex will fail")
-	} else {
-		// Validate API key format (DeepSeek keys typically start with "sk-")
-		if !strings.HasPrefix(apiKey, "sk-") || len(apiKey) < 20 {
-			log.Println("Warning: DEEPSEEK_API_KEY appears invalid. Expected format: sk-...")
-			log.Println("Cortex functionality may not work properly")
-		}
-
-		// Check for common placeholder values
-		placeholders := []string{"your-api-key", "test-key", "demo-key", "sk-test"}
-		apiKeyLower := strings.ToLower(apiKey)
-		for _, placeholder := range placeholders {
-			if strings.Contains(apiKeyLower, placeholder) {
-				log.Println("Warning: DEEPSEEK_API_KEY contains placeholder value. Use a real API key.")
-				log.Println("Cortex functionality may not work properly")
-				break
-			}
-		}
+	llmAdapter, llmProviders, err := llmProvidersFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid LLM configuration: %v", err)
 	}
-	llmAdapter := llm.NewDeepSeekAdapter(apiKey)
-	cortexService := services.NewCortexService(llmAdapter, fastingRepo, userRepo)
-
-	mealService := services.NewMealService(mealRepo, cortexService)
+	// Cortex prompt templates (PROMPTS_DIR: *.json templates replacing or adding to the built-ins)
+	promptRegistry, err := services.LoadPromptRegistry(os.Getenv("PROMPTS_DIR"), promptExposureRepo)
+	if err != nil {
+		log.Fatalf("Failed to load prompt templates: %v", err)
+	}
+	// Cortex knowledge base (KNOWLEDGE_DIR: *.md articles replacing the built-ins), indexed in memory
+	knowledgeArticles, err := knowledge.LoadArticles(os.Getenv("KNOWLEDGE_DIR"))
+	if err != nil {
+		log.Fatalf("Failed to load knowledge base: %v", err)
+	}
+	knowledgeIndex, err := knowledge.NewIndex(knowledgeArticles)
+	if err != nil {
+		log.Fatalf("Failed to index knowledge base: %v", err)
+	}
+	// Screens Cortex conversations for eating-disorder and medical risk; flags wait for admin review
+	safetyGuard := services.NewSafetyGuard(safetyFlagRepo)
+	// The response cache is per process; each instance warms its own
+	cortexCache := memory.NewCortexResponseCache(memory.DefaultCortexCacheSize)
+	cortexService := services.NewCortexService(llmAdapter, fastingRepo, userRepo, llmProviders, cortexThreadRepo, cortexUsageRepo, cortexCache, promptRegistry, knowledgeIndex, safetyGuard)
+
+	mealService := services.NewMealService(mealRepo, cortexService, entitlementService)
+	// Actions Cortex may propose in chat; each runs only once the user confirms it
+	cortexTools := services.NewCortexToolRegistry(cortexActionRepo, fastingService, progressService, mealService)
+	cortexService.SetTools(cortexTools)
 	recipeService := services.NewRecipeService(recipeRepo)
 
-	stripeService := services.NewStripeService(paymentAdapter, subscriptionRepo, userRepo)
-
 	// Notification Service
 	firebaseServiceAccountPath := os.Getenv("FIREBASE_SERVICE_ACCOUNT_PATH")
 	if firebaseServiceAccountPath == "" {
@@ -289,6 +371,34 @@ func main() {
 		notificationService = realNotificationService
 	}
 
+	// Billing: one Stripe price per plan; plans without a price can't be purchased
+	frontendURL := os.Getenv("FRONTEND_URL")
+	if frontendURL == "" {
+		frontendURL = "http://localhost:5173"
+	}
+	billingConfig := services.BillingConfig{
+		Prices: domain.PriceCatalog{
+			domain.TierVault:              os.Getenv("STRIPE_PRICE_VAULT"),
+			domain.TierAccountabilityPlus: os.Getenv("STRIPE_PRICE_ACCOUNTABILITY_PLUS"),
+			domain.TierAICoach:            os.Getenv("STRIPE_PRICE_AI_COACH"),
+		},
+		SuccessURL:      frontendURL + "/onboarding/success?session_id={CHECKOUT_SESSION_ID}",
+		CancelURL:       frontendURL + "/onboarding/payment",
+		PortalReturnURL: frontendURL + "/settings",
+	}
+	if len(billingConfig.Prices.Plans()) == 0 {
+		log.Println("Warning: no STRIPE_PRICE_* set, checkout is disabled")
+	}
+	promoService := services.NewPromoCodeService(promoRepo, paymentAdapter)
+	stripeService := services.NewStripeService(paymentAdapter, subscriptionRepo, userRepo, webhookEventRepo, notificationService, billingConfig, promoService, referralService, paymentRepo)
+	stripeService.SetVaultService(vaultService)
+
+	trialConfig, err := trialConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid free trial configuration: %v", err)
+	}
+	trialService := services.NewTrialService(trialRepo, userRepo, paymentAdapter, notificationService, trialConfig)
+
 	// Create SOS Service (needs cortexService, notificationService, tribeService)
 	// Passing potentially nil tribeService is safe as long as we don't dereference it
 	sosService = services.NewSOSService(
@@ -298,6 +408,7 @@ func main() {
 		notificationService,
 		cortexService,
 		fastingRepo,
+		safetyGuard,
 	)
 
 	handler := http.NewHandler(
@@ -346,9 +457,17 @@ func main() {
 	oauthHandler := http.NewOAuthHandler(oauthService)
 	handler.SetOAuthHandler(oauthHandler)
 
+	if fakeGateway != nil {
+		handler.SetPaymentSimulator(http.NewPaymentSimulatorHandler(fakeGateway))
+	}
+
 	// Set SOS service in handler
 	handler.SetSOSService(sosService)
 
+	handler.SetVaultService(vaultService)
+	handler.SetVaultDocumentService(services.NewVaultDocumentService(vaultService, vaultRepo, paymentRepo, userRepo, document.NewRenderer("FastingHero")))
+	handler.SetEntitlementService(entitlementService)
+
 	// Initialize Smart Reminder Service
 	smartReminderService := services.NewSmartReminderService(
 		reminderRepo,
@@ -358,15 +477,35 @@ func main() {
 		cortexService,
 	)
 	handler.SetSmartReminderService(smartReminderService)
+	handler.SetChallengeService(challengeService)
+	handler.SetTrialService(trialService)
+	handler.SetPromoCodeService(promoService)
+	handler.SetDisciplineService(disciplineService)
+	handler.SetCortexThreadService(cortexService)
+	handler.SetCortexUsageService(cortexService)
+	handler.SetPromptExperimentService(cortexService)
+	handler.SetSafetyReviewService(safetyGuard)
+	handler.SetCortexActionService(cortexTools)
+	if adminEmails := os.Getenv("ADMIN_EMAILS"); adminEmails != "" {
+		handler.SetAdminEmails(strings.Split(adminEmails, ","))
+	}
 
 	// Initialize Tribe handler only if tribe service exists
+	var tribePoolService *services.TribePoolService
 	if tribeService != nil {
 		tribeHandler := http.NewTribeHandler(tribeService)
+		tribePoolService = services.NewTribePoolService(tribePoolRepo, tribeRepo, fastingRepo, entitlementService, vaultService)
+		tribeHandler.SetPoolService(tribePoolService)
 		handler.SetTribeHandler(tribeHandler)
 	}
 
 	// 4. Setup Router
 	router := gin.Default()
+	// Client IPs feed the rate limiter and referral fraud checks, so only proxies we run behind
+	// may set them through X-Forwarded-For
+	if err := router.SetTrustedProxies(trustedProxiesFromEnv()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Seed Test User (jib@jab.com)
 	go seedTestUser(authService, userRepo)
@@ -385,7 +524,7 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     allowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Device-ID"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 	}))
@@ -415,6 +554,106 @@ func main() {
 		log.Fatalf("Failed to add cron job: %v", err)
 	}
 
+	// Monthly vault close (1st of the month, 03:00). With VAULT_REFUNDS_DRY_RUN=true only a CSV
+	// report of the planned refunds is written to VAULT_REPORT_DIR and no money moves.
+	_, err = cronScheduler.AddFunc("0 3 1 * *", func() {
+		ctx := context.Background()
+		if os.Getenv("VAULT_REFUNDS_DRY_RUN") == "true" {
+			now := time.Now().UTC()
+			lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+			report, err := vaultService.CloseMonth(ctx, lastMonth, true)
+			if err != nil {
+				log.Printf("Error planning monthly vault refunds: %v", err)
+				return
+			}
+			if err := writeVaultRefundReport(report); err != nil {
+				log.Printf("Error writing vault refund report: %v", err)
+			}
+			return
+		}
+
+		log.Println("Running monthly vault refunds...")
+		if err := vaultService.ProcessMonthlyRefunds(ctx); err != nil {
+			log.Printf("Error processing monthly refunds: %v", err)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to add monthly refund cron job: %v", err)
+	}
+
+	// Discipline Index (daily, 00:20): decay users without a recent completed fast and snapshot yesterday's scores
+	_, err = cronScheduler.AddFunc("20 0 * * *", func() {
+		yesterday := time.Now().UTC().AddDate(0, 0, -1)
+		if n, err := disciplineService.ProcessDay(context.Background(), yesterday); err != nil {
+			log.Printf("Error closing discipline day: %v", err)
+		} else {
+			log.Printf("Snapshotted %d discipline scores", n)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to add discipline cron job: %v", err)
+	}
+
+	// Tribe prize pools (daily, 00:10): stop joins once a month starts, rank and record payouts once it ends
+	if tribePoolService != nil {
+		_, err = cronScheduler.AddFunc("10 0 * * *", func() {
+			ctx := context.Background()
+			now := time.Now()
+			if n, err := tribePoolService.SettleEndedPools(ctx, now); err != nil {
+				log.Printf("Error settling tribe pools: %v", err)
+			} else if n > 0 {
+				log.Printf("Settled %d tribe pools", n)
+			}
+			if _, err := tribePoolService.ActivateStartedPools(ctx, now); err != nil {
+				log.Printf("Error activating tribe pools: %v", err)
+			}
+		})
+		if err != nil {
+			log.Fatalf("Failed to add tribe pool cron job: %v", err)
+		}
+	}
+
+	// Friend challenges (hourly, at :05): challenges end at any time of day, settle the ones that have
+	_, err = cronScheduler.AddFunc("5 * * * *", func() {
+		if n, err := challengeService.SettleEndedChallenges(context.Background(), time.Now()); err != nil {
+			log.Printf("Error settling friend challenges: %v", err)
+		} else if n > 0 {
+			log.Printf("Settled %d friend challenges", n)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to add friend challenge cron job: %v", err)
+	}
+
+	// Free trials (hourly, at :15): remind users before their trial ends, then convert or downgrade
+	_, err = cronScheduler.AddFunc("15 * * * *", func() {
+		ctx := context.Background()
+		now := time.Now()
+		if _, err := trialService.SendTrialReminders(ctx, now); err != nil {
+			log.Printf("Error sending trial reminders: %v", err)
+		}
+		if n, err := trialService.EndExpiredTrials(ctx, now); err != nil {
+			log.Printf("Error ending free trials: %v", err)
+		} else if n > 0 {
+			log.Printf("Ended %d free trials", n)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to add free trial cron job: %v", err)
+	}
+
+	// Referral rewards (hourly, at :25): pay rewards owed to users who have since joined the vault
+	_, err = cronScheduler.AddFunc("25 * * * *", func() {
+		if n, err := referralService.PayOwedRewards(context.Background(), time.Now()); err != nil {
+			log.Printf("Error paying owed referral rewards: %v", err)
+		} else if n > 0 {
+			log.Printf("Paid %d owed referral rewards", n)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to add referral reward cron job: %v", err)
+	}
+
 	// Add cron job for SOS Cortex backup (every minute)
 	_, err = cronScheduler.AddFunc("* * * * *", func() {
 		ctx := context.Background()
@@ -454,10 +693,6 @@ func main() {
 	// No additional route registration needed here
 
 	// 5. Start Server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
 	logger.Info().Msg("Starting server on port " + port)
 	if err := router.Run(":" + port); err != nil {
 		log.Fatal("Failed to start server: ", err)
@@ -503,3 +738,285 @@ func seedTestUser(authService *services.AuthService, userRepo ports.UserReposito
 		logger.Info().Msg("Successfully verified/upgraded test user " + email)
 	}
 }
+
+// writeVaultRefundReport writes a dry-run refund report to VAULT_REPORT_DIR (default "reports")
+func writeVaultRefundReport(report *domain.VaultRefundReport) error {
+	dir := os.Getenv("VAULT_REPORT_DIR")
+	if dir == "" {
+		dir = "reports"
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("vault-refunds-%s-dry-run.csv", report.MonthStart.Format("2006-01")))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := services.WriteRefundReportCSV(f, report); err != nil {
+		return err
+	}
+	log.Printf("Vault refund dry run for %s written to %s (%d participations, $%.2f refunds, $%.2f forfeits)",
+		report.MonthStart.Format("2006-01"), path, len(report.Lines), report.TotalRefund, report.TotalForfeit)
+	return nil
+}
+
+// trustedProxiesFromEnv is the comma-separated TRUSTED_PROXIES list of proxy IPs and CIDRs.
+// On Cloud Run (K_SERVICE is set) it defaults to the link-local range requests arrive from,
+// so the client is the address Google's front end appended to X-Forwarded-For. Elsewhere it
+// defaults to none, and the client is the connection's address.
+func trustedProxiesFromEnv() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	if len(proxies) == 0 && os.Getenv("K_SERVICE") != "" {
+		proxies = []string{"169.254.0.0/16"}
+	}
+	return proxies
+}
+
+// referralConfigFromEnv reads the referral fraud checks. REFERRAL_DISPOSABLE_DOMAINS is a
+// comma-separated list of email domains whose sign-ups are held for review.
+func referralConfigFromEnv() (services.ReferralConfig, error) {
+	config := services.ReferralConfig{
+		RefereeReward:     5.00,
+		Tiers:             domain.DefaultReferralRewardTiers,
+		RapidSignupLimit:  5,
+		RapidSignupWindow: time.Hour,
+		DisposableDomains: map[string]bool{},
+	}
+	if limit := os.Getenv("REFERRAL_RAPID_SIGNUP_LIMIT"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return config, fmt.Errorf("REFERRAL_RAPID_SIGNUP_LIMIT must be a non-negative number, got %q", limit)
+		}
+		config.RapidSignupLimit = n
+	}
+	if window := os.Getenv("REFERRAL_RAPID_SIGNUP_WINDOW"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("REFERRAL_RAPID_SIGNUP_WINDOW must be a positive duration, got %q", window)
+		}
+		config.RapidSignupWindow = d
+	}
+	for _, domainName := range strings.Split(os.Getenv("REFERRAL_DISPOSABLE_DOMAINS"), ",") {
+		if domainName = strings.ToLower(strings.TrimSpace(domainName)); domainName != "" {
+			config.DisposableDomains[domainName] = true
+		}
+	}
+	return config, nil
+}
+
+// trialConfigFromEnv reads the free trial offers. TRIAL_DAYS=0 turns off the sign-up trial;
+// TRIAL_CAMPAIGNS is a comma-separated list of code:plan:days entries, with an optional
+// ":card" suffix for campaigns that require a payment method.
+func trialConfigFromEnv() (services.TrialConfig, error) {
+	config := services.TrialConfig{
+		Registration: domain.TrialOffer{Plan: domain.TierVault, Days: 7},
+		Campaigns:    map[string]domain.TrialOffer{},
+		ReminderLead: 72 * time.Hour,
+	}
+	if plan := os.Getenv("TRIAL_PLAN"); plan != "" {
+		config.Registration.Plan = domain.SubscriptionTier(plan)
+	}
+	if days := os.Getenv("TRIAL_DAYS"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return config, fmt.Errorf("TRIAL_DAYS must be a non-negative number, got %q", days)
+		}
+		config.Registration.Days = n
+	}
+	if config.Registration.Days > 0 && !config.Registration.IsValid() {
+		return config, fmt.Errorf("TRIAL_PLAN %q is not a paid plan", config.Registration.Plan)
+	}
+	if hours := os.Getenv("TRIAL_REMINDER_HOURS"); hours != "" {
+		n, err := strconv.Atoi(hours)
+		if err != nil || n <= 0 {
+			return config, fmt.Errorf("TRIAL_REMINDER_HOURS must be a positive number, got %q", hours)
+		}
+		config.ReminderLead = time.Duration(n) * time.Hour
+	}
+
+	for _, entry := range strings.Split(os.Getenv("TRIAL_CAMPAIGNS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 3 || len(parts) > 4 || (len(parts) == 4 && parts[3] != "card") {
+			return config, fmt.Errorf("TRIAL_CAMPAIGNS entry %q must be code:plan:days[:card]", entry)
+		}
+		days, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return config, fmt.Errorf("TRIAL_CAMPAIGNS entry %q has invalid days", entry)
+		}
+		offer := domain.TrialOffer{Plan: domain.SubscriptionTier(parts[1]), Days: days, RequirePaymentMethod: len(parts) == 4}
+		if !offer.IsValid() {
+			return config, fmt.Errorf("TRIAL_CAMPAIGNS entry %q must grant a paid plan for at least one day", entry)
+		}
+		config.Campaigns[parts[0]] = offer
+	}
+	return config, nil
+}
+
+// llmProvidersFromEnv builds the default Cortex LLM and any per-use-case overrides.
+// Without LLM_BASE_URL the default is hosted DeepSeek keyed by DEEPSEEK_API_KEY. Any
+// OpenAI-compatible server (Ollama, llama.cpp, vLLM) can be used via LLM_BASE_URL and
+// LLM_MODEL. LLM_CHAT_*, LLM_INSIGHT_* and LLM_MEAL_VISION_* override single fields for
+// that use case; an override that sets its own BASE_URL starts from a blank config so the
+// default's API key is never sent to a different server.
+//
+// LLM_MODE switches Cortex off the network: "fake" answers from LLM_FAKE_SCRIPT (or the
+// built-in script), "replay" answers from the LLM_FIXTURES file and "record" calls the live
+// providers while writing every exchange to LLM_FIXTURES.
+func llmProvidersFromEnv() (ports.LLMProvider, map[domain.LLMUseCase]ports.LLMProvider, error) {
+	mode := os.Getenv("LLM_MODE")
+	fixtures := os.Getenv("LLM_FIXTURES")
+	if fixtures == "" {
+		fixtures = filepath.Join("testdata", "llm_fixtures.json")
+	}
+	switch mode {
+	case "", "live", "record":
+	case "fake":
+		script := llm.DefaultFakeScript
+		if path := os.Getenv("LLM_FAKE_SCRIPT"); path != "" {
+			var err error
+			if script, err = llm.LoadFakeScript(path); err != nil {
+				return nil, nil, err
+			}
+		}
+		fake, err := llm.NewFakeProvider(script)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Println("Cortex uses the fake LLM provider")
+		return fake, nil, nil
+	case "replay":
+		cassette, err := llm.OpenCassette(fixtures)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Printf("Cortex replays %d recorded LLM exchanges from %s", cassette.Len(), fixtures)
+		return cassette.Replay(), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("LLM_MODE must be live, fake, record or replay, got %q", mode)
+	}
+
+	base := llm.Config{}
+	if os.Getenv("LLM_BASE_URL") == "" {
+		base = llm.DeepSeekConfig(os.Getenv("DEEPSEEK_API_KEY"))
+	}
+	base, _, err := llmConfigFromEnv(base, "LLM_")
+	if err != nil {
+		return nil, nil, err
+	}
+	if os.Getenv("LLM_BASE_URL") == "" {
+		warnDeepSeekAPIKey(base.APIKey)
+	}
+	defaultProvider, err := llm.NewOpenAICompatibleAdapter(base)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	providers := map[domain.LLMUseCase]ports.LLMProvider{}
+	for _, useCase := range domain.LLMUseCases {
+		prefix := "LLM_" + strings.ToUpper(string(useCase)) + "_"
+		cfg := base
+		if os.Getenv(prefix+"BASE_URL") != "" {
+			cfg = llm.Config{}
+		}
+		cfg, overridden, err := llmConfigFromEnv(cfg, prefix)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !overridden {
+			continue
+		}
+		provider, err := llm.NewOpenAICompatibleAdapter(cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", useCase, err)
+		}
+		providers[useCase] = provider
+		log.Printf("Cortex %s uses model %s at %s", useCase, cfg.Model, cfg.BaseURL)
+	}
+
+	if mode == "record" {
+		cassette, err := llm.OpenCassette(fixtures)
+		if err != nil {
+			return nil, nil, err
+		}
+		for useCase, provider := range providers {
+			providers[useCase] = cassette.Record(provider)
+		}
+		log.Printf("Cortex records LLM exchanges to %s", fixtures)
+		return cassette.Record(defaultProvider), providers, nil
+	}
+	return defaultProvider, providers, nil
+}
+
+// llmConfigFromEnv applies the <prefix>BASE_URL, API_KEY, MODEL, TEMPERATURE, MAX_TOKENS
+// and TIMEOUT variables on top of cfg and reports whether any of them was set
+func llmConfigFromEnv(cfg llm.Config, prefix string) (llm.Config, bool, error) {
+	set := false
+	if v := os.Getenv(prefix + "BASE_URL"); v != "" {
+		cfg.BaseURL, set = v, true
+	}
+	if v := os.Getenv(prefix + "API_KEY"); v != "" {
+		cfg.APIKey, set = v, true
+	}
+	if v := os.Getenv(prefix + "MODEL"); v != "" {
+		cfg.Model, set = v, true
+	}
+	if v := os.Getenv(prefix + "TEMPERATURE"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return cfg, set, fmt.Errorf("%sTEMPERATURE must be a number, got %q", prefix, v)
+		}
+		cfg.Temperature, set = &t, true
+	}
+	if v := os.Getenv(prefix + "MAX_TOKENS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, set, fmt.Errorf("%sMAX_TOKENS must be a non-negative number, got %q", prefix, v)
+		}
+		cfg.MaxTokens, set = n, true
+	}
+	if v := os.Getenv(prefix + "TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, set, fmt.Errorf("%sTIMEOUT must be a positive duration, got %q", prefix, v)
+		}
+		cfg.Timeout, set = d, true
+	}
+	return cfg, set, nil
+}
+
+// warnDeepSeekAPIKey logs obvious problems with the hosted DeepSeek key
+func warnDeepSeekAPIKey(apiKey string) {
+	if apiKey == "" {
+		log.Println("Warning: DEEPSEEK_API_KEY not set, Cortex will fail")
+		return
+	}
+	// Validate API key format (DeepSeek keys typically start with "sk-")
+	if !strings.HasPrefix(apiKey, "sk-") || len(apiKey) < 20 {
+		log.Println("Warning: DEEPSEEK_API_KEY appears invalid. Expected format: sk-...")
+		log.Println("Cortex functionality may not work properly")
+	}
+
+	// Check for common placeholder values
+	placeholders := []string{"your-api-key", "test-key", "demo-key", "sk-test"}
+	apiKeyLower := strings.ToLower(apiKey)
+	for _, placeholder := range placeholders {
+		if strings.Contains(apiKeyLower, placeholder) {
+			log.Println("Warning: DEEPSEEK_API_KEY contains placeholder value. Use a real API key.")
+			log.Println("Cortex functionality may not work properly")
+			break
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package document
+
+import (
+	"bytes"
+	"fastinghero/internal/core/domain"
+	"html/template"
+)
+
+var htmlTemplate = template.Must(template.New("document").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="document-id" content="{{.Doc.ID}}">
+<title>{{.Doc.Title}} {{.Doc.ID}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #1d1d1f; max-width: 780px; margin: 40px auto; padding: 0 24px; }
+header { display: flex; justify-content: space-between; align-items: baseline; border-bottom: 2px solid #1d1d1f; padding-bottom: 8px; }
+.brand { font-weight: bold; font-size: 14px; letter-spacing: 0.04em; text-transform: uppercase; }
+h1 { font-size: 24px; margin: 24px 0 4px; }
+h2 { font-size: 16px; margin: 28px 0 8px; }
+.meta { color: #555; font-size: 13px; margin: 2px 0; }
+table { width: 100%; border-collapse: collapse; font-size: 13px; }
+th { text-align: left; border-bottom: 1px solid #999; padding: 6px 4px; }
+td { border-bottom: 1px solid #e5e5e5; padding: 6px 4px; vertical-align: top; }
+.num { text-align: right; white-space: nowrap; }
+.summary td:first-child { color: #555; }
+.empty { color: #777; font-size: 13px; }
+footer { margin-top: 32px; color: #777; font-size: 12px; }
+</style>
+</head>
+<body>
+<header><span class="brand">{{.Brand}}</span><span class="meta">Document {{.Doc.ID}}</span></header>
+<h1>{{.Doc.Title}}</h1>
+{{if .Doc.Period}}<p class="meta">Period: {{.Doc.Period}}</p>{{end}}
+<p class="meta">Issued to: {{.Doc.IssuedTo}}</p>
+<p class="meta">Issued: {{.Doc.IssuedAt.Format "2 Jan 2006 15:04 MST"}}</p>
+<h2>Summary</h2>
+<table class="summary">
+{{range .Doc.Summary}}<tr><td>{{.Label}}</td><td class="num">{{.Value}}</td></tr>
+{{end}}</table>
+{{range $section := .Doc.Sections}}<h2>{{.Heading}}</h2>
+{{if .Rows}}<table>
+<colgroup>{{range .Columns}}<col style="width: {{printf "%.0f" (percent .Width)}}%">{{end}}</colgroup>
+<tr>{{range .Columns}}<th{{if .Numeric}} class="num"{{end}}>{{.Title}}</th>{{end}}</tr>
+{{range $row := .Rows}}<tr>{{range $i, $cell := $row}}<td{{if numeric $section.Columns $i}} class="num"{{end}}>{{$cell}}</td>{{end}}</tr>
+{{end}}</table>
+{{else}}<p class="empty">{{.Empty}}</p>
+{{end}}{{end}}<footer>
+{{range .Doc.Notes}}<p>{{.}}</p>
+{{end}}<p>Document ID {{.Doc.ID}}. Quote it when contacting support about this document.</p>
+</footer>
+</body>
+</html>
+`))
+
+var htmlFuncs = template.FuncMap{
+	"percent": func(share float64) float64 { return share * 100 },
+	"numeric": func(columns []domain.DocumentColumn, i int) bool {
+		return i < len(columns) && columns[i].Numeric
+	},
+}
+
+func (r *Renderer) renderHTML(doc *domain.Document) ([]byte, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, struct {
+		Brand string
+		Doc   *domain.Document
+	}{Brand: r.brand, Doc: doc})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
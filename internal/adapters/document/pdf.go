@@ -0,0 +1,334 @@
+package document
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fastinghero/internal/core/domain"
+	"fmt"
+	"strings"
+)
+
+// A4 in points, with the margins every page keeps clear
+const (
+	pdfPageWidth    = 595.28
+	pdfPageHeight   = 841.89
+	pdfMargin       = 50.0
+	pdfFooterHeight = 30.0
+	pdfContentWidth = pdfPageWidth - 2*pdfMargin
+	pdfCellPadding  = 4.0
+)
+
+// helveticaWidths are the standard Helvetica advance widths (per 1000 units of font size) for
+// printable ASCII, starting at the space. Other characters use the width of 'n'.
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+}
+
+// helveticaBoldScale approximates Helvetica-Bold, which is slightly wider than regular
+const helveticaBoldScale = 1.06
+
+// pdfWriter lays out a document onto pages as PDF content streams
+type pdfWriter struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64 // Baseline of the next line, from the bottom of the page
+}
+
+func (r *Renderer) renderPDF(doc *domain.Document) ([]byte, error) {
+	w := &pdfWriter{}
+	w.newPage()
+
+	w.text(pdfMargin, w.y, 10, true, strings.ToUpper(r.brand))
+	w.textRight(pdfPageWidth-pdfMargin, w.y, 9, false, "Document "+doc.ID)
+	w.y -= 8
+	w.rule(1.5)
+	w.y -= 30
+
+	w.text(pdfMargin, w.y, 18, true, doc.Title)
+	w.y -= 20
+	w.page.WriteString("0.33 g\n")
+	if doc.Period != "" {
+		w.metaLine("Period: " + doc.Period)
+	}
+	w.metaLine("Issued to: " + doc.IssuedTo)
+	w.metaLine("Issued: " + doc.IssuedAt.Format("2 Jan 2006 15:04 MST"))
+	w.page.WriteString("0 g\n")
+
+	w.heading("Summary")
+	for _, line := range doc.Summary {
+		w.summaryLine(line)
+	}
+
+	for _, section := range doc.Sections {
+		w.heading(section.Heading)
+		if len(section.Rows) == 0 {
+			w.ensure(14)
+			w.text(pdfMargin, w.y, 9, false, section.Empty)
+			w.y -= 14
+			continue
+		}
+		w.tableHeader(section.Columns)
+		for _, row := range section.Rows {
+			w.tableRow(section.Columns, row)
+		}
+	}
+
+	w.y -= 16
+	notes := append(append([]string{}, doc.Notes...), "Document ID "+doc.ID+". Quote it when contacting support about this document.")
+	for _, note := range notes {
+		for _, line := range wrapText(note, pdfContentWidth, 8, false) {
+			w.ensure(11)
+			w.text(pdfMargin, w.y, 8, false, line)
+			w.y -= 11
+		}
+		w.y -= 3
+	}
+
+	for i, page := range w.pages {
+		footer := fmt.Sprintf("%s - page %d of %d", doc.ID, i+1, len(w.pages))
+		x := (pdfPageWidth - textWidth(footer, 8, false)) / 2
+		fmt.Fprintf(page, "0.5 g\n%s0 g\n", textOp(x, pdfFooterHeight, 8, false, footer))
+	}
+
+	return w.encode(doc, r.brand), nil
+}
+
+func (w *pdfWriter) newPage() {
+	w.page = &bytes.Buffer{}
+	w.pages = append(w.pages, w.page)
+	w.y = pdfPageHeight - pdfMargin
+}
+
+// ensure starts a new page if less than height is left above the footer. It reports whether it did.
+func (w *pdfWriter) ensure(height float64) bool {
+	if w.y-height >= pdfMargin+pdfFooterHeight {
+		return false
+	}
+	w.newPage()
+	return true
+}
+
+func (w *pdfWriter) text(x, y, size float64, bold bool, s string) {
+	w.page.WriteString(textOp(x, y, size, bold, s))
+}
+
+func (w *pdfWriter) textRight(right, y, size float64, bold bool, s string) {
+	w.text(right-textWidth(s, size, bold), y, size, bold, s)
+}
+
+func (w *pdfWriter) rule(width float64) {
+	fmt.Fprintf(w.page, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, pdfMargin, w.y, pdfPageWidth-pdfMargin, w.y)
+}
+
+func (w *pdfWriter) metaLine(s string) {
+	w.text(pdfMargin, w.y, 10, false, s)
+	w.y -= 14
+}
+
+func (w *pdfWriter) heading(s string) {
+	w.y -= 12
+	w.ensure(40)
+	w.text(pdfMargin, w.y, 13, true, s)
+	w.y -= 18
+}
+
+func (w *pdfWriter) summaryLine(line domain.DocumentLine) {
+	valueLines := wrapText(line.Value, pdfContentWidth*0.55, 10, false)
+	w.ensure(float64(len(valueLines)) * 14)
+	w.page.WriteString("0.33 g\n")
+	w.text(pdfMargin, w.y, 10, false, line.Label)
+	w.page.WriteString("0 g\n")
+	for _, value := range valueLines {
+		w.textRight(pdfPageWidth-pdfMargin, w.y, 10, false, value)
+		w.y -= 14
+	}
+}
+
+func (w *pdfWriter) tableHeader(columns []domain.DocumentColumn) {
+	w.ensure(32)
+	x := pdfMargin
+	for _, col := range columns {
+		width := col.Width * pdfContentWidth
+		if col.Numeric {
+			w.textRight(x+width-pdfCellPadding, w.y, 9, true, col.Title)
+		} else {
+			w.text(x+pdfCellPadding, w.y, 9, true, col.Title)
+		}
+		x += width
+	}
+	w.y -= 5
+	w.rule(0.75)
+	w.y -= 12
+}
+
+// tableRow writes a row, wrapping long cells. A row that doesn't fit moves to a new page,
+// which repeats the column headers.
+func (w *pdfWriter) tableRow(columns []domain.DocumentColumn, row []string) {
+	cells := make([][]string, len(columns))
+	lines := 1
+	for i, col := range columns {
+		if i >= len(row) {
+			continue
+		}
+		cells[i] = wrapText(row[i], col.Width*pdfContentWidth-2*pdfCellPadding, 9, false)
+		if len(cells[i]) > lines {
+			lines = len(cells[i])
+		}
+	}
+	if w.ensure(float64(lines-1)*12 + 19) {
+		w.tableHeader(columns)
+	}
+
+	x := pdfMargin
+	for i, col := range columns {
+		width := col.Width * pdfContentWidth
+		for j, line := range cells[i] {
+			y := w.y - float64(j)*12
+			if col.Numeric {
+				w.textRight(x+width-pdfCellPadding, y, 9, false, line)
+			} else {
+				w.text(x+pdfCellPadding, y, 9, false, line)
+			}
+		}
+		x += width
+	}
+	w.y -= float64(lines-1)*12 + 5
+	w.page.WriteString("0.85 G\n")
+	w.rule(0.5)
+	w.page.WriteString("0 G\n")
+	w.y -= 14
+}
+
+// encode assembles the pages into a PDF file. Object 1 is the catalog, 2 the page tree,
+// 3 and 4 the fonts, 5 the info dictionary, then a page object and its content stream per page.
+func (w *pdfWriter) encode(doc *domain.Document, producer string) []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, len(w.pages))
+	for i := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title %s /Subject %s /Producer %s >>", pdfString(doc.Title), pdfString(doc.ID), pdfString(producer)))
+	for i, page := range w.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 7+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	// The file identifier is derived from the document ID, so every rendering of a document shares it
+	sum := md5.Sum([]byte(doc.ID))
+	id := strings.ToUpper(hex.EncodeToString(sum[:]))
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R /ID [<%s> <%s>] >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, id, id, xref)
+	return out.Bytes()
+}
+
+func textOp(x, y, size float64, bold bool, s string) string {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	return fmt.Sprintf("BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+// pdfString encodes s as a PDF literal string in WinAnsi. Latin-1 characters map directly;
+// common typographic punctuation is simplified and anything else becomes '?'.
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range s {
+		switch c {
+		case '\\', '(', ')':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+			continue
+		case '–', '—':
+			c = '-'
+		case '‘', '’':
+			c = '\''
+		case '“', '”':
+			c = '"'
+		case '\n', '\r', '\t':
+			c = ' '
+		}
+		switch {
+		case c >= 0x20 && c < 0x7f:
+			b.WriteByte(byte(c))
+		case c >= 0xa0 && c <= 0xff:
+			b.WriteByte(byte(c))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+func textWidth(s string, size float64, bold bool) float64 {
+	units := 0
+	for _, c := range s {
+		if c >= ' ' && int(c-' ') < len(helveticaWidths) {
+			units += helveticaWidths[c-' ']
+		} else {
+			units += helveticaWidths['n'-' ']
+		}
+	}
+	width := float64(units) * size / 1000
+	if bold {
+		width *= helveticaBoldScale
+	}
+	return width
+}
+
+// wrapText breaks s into lines no wider than width, splitting words that don't fit on a line alone
+func wrapText(s string, width, size float64, bold bool) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if textWidth(candidate, size, bold) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		for runes := []rune(word); textWidth(word, size, bold) > width && len(runes) > 1; runes = []rune(word) {
+			cut := len(runes) - 1
+			for cut > 1 && textWidth(string(runes[:cut]), size, bold) > width {
+				cut--
+			}
+			lines = append(lines, string(runes[:cut]))
+			word = string(runes[cut:])
+		}
+		line = word
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,29 @@
+// Package document renders statements and receipts to HTML and PDF without external tools
+package document
+
+import (
+	"fastinghero/internal/core/domain"
+)
+
+// Renderer renders documents server-side. It has no state and is safe for concurrent use.
+type Renderer struct {
+	brand string
+}
+
+// NewRenderer returns a renderer that heads every document with brand
+func NewRenderer(brand string) *Renderer {
+	if brand == "" {
+		brand = "FastingHero"
+	}
+	return &Renderer{brand: brand}
+}
+
+func (r *Renderer) Render(doc *domain.Document, format domain.DocumentFormat) ([]byte, error) {
+	switch format {
+	case domain.DocumentFormatHTML:
+		return r.renderHTML(doc)
+	case domain.DocumentFormatPDF:
+		return r.renderPDF(doc)
+	}
+	return nil, domain.ErrUnsupportedDocumentFormat
+}
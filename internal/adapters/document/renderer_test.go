@@ -0,0 +1,130 @@
+package document
+
+import (
+	"bytes"
+	"fastinghero/internal/core/domain"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDocument(rows int) *domain.Document {
+	section := domain.DocumentSection{
+		Heading: "Vault activity",
+		Columns: []domain.DocumentColumn{
+			{Title: "Date", Width: 0.2},
+			{Title: "Description", Width: 0.6},
+			{Title: "Amount", Width: 0.2, Numeric: true},
+		},
+		Rows:  [][]string{},
+		Empty: "No vault activity this month.",
+	}
+	for i := 0; i < rows; i++ {
+		section.Rows = append(section.Rows, []string{"2 Mar 2026", fmt.Sprintf("Daily earnings (day %d) for <meals> & steps", i+1), "$0.50"})
+	}
+	return &domain.Document{
+		ID:       "VS-202603-0123456789",
+		Kind:     domain.DocumentKindVaultStatement,
+		Title:    "Commitment Vault Statement",
+		IssuedTo: "Zoë <zoe@example.com>",
+		Period:   "March 2026",
+		IssuedAt: time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC),
+		Summary:  []domain.DocumentLine{{Label: "Deposit", Value: "$20.00"}},
+		Sections: []domain.DocumentSection{section},
+		Notes:    []string{"Unearned deposit is forfeited."},
+	}
+}
+
+func TestRenderer_HTML(t *testing.T) {
+	body, err := NewRenderer("FastingHero").Render(testDocument(2), domain.DocumentFormatHTML)
+
+	require.NoError(t, err)
+	html := string(body)
+	assert.Contains(t, html, `<meta name="document-id" content="VS-202603-0123456789">`)
+	assert.Contains(t, html, "Commitment Vault Statement")
+	assert.Contains(t, html, "Daily earnings (day 1) for &lt;meals&gt; &amp; steps")
+	assert.Contains(t, html, `<td class="num">$0.50</td>`)
+	assert.NotContains(t, html, "<meals>")
+}
+
+func TestRenderer_HTML_EmptySection(t *testing.T) {
+	body, err := NewRenderer("").Render(testDocument(0), domain.DocumentFormatHTML)
+
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "No vault activity this month.")
+}
+
+func TestRenderer_PDF(t *testing.T) {
+	body, err := NewRenderer("FastingHero").Render(testDocument(3), domain.DocumentFormatPDF)
+
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(body, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(body, []byte("%%EOF\n")))
+	assert.Contains(t, string(body), "(VS-202603-0123456789)")
+	assert.Contains(t, string(body), "/Count 1")
+	assert.Contains(t, string(body), "(Issued to: Zo\xeb <zoe@example.com>)")
+	assertValidXref(t, body)
+}
+
+func TestRenderer_PDF_PaginatesLongStatements(t *testing.T) {
+	body, err := NewRenderer("FastingHero").Render(testDocument(120), domain.DocumentFormatPDF)
+
+	require.NoError(t, err)
+	count := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(body)
+	require.NotNil(t, count)
+	pages, _ := strconv.Atoi(string(count[1]))
+	assert.Greater(t, pages, 1)
+	assert.Contains(t, string(body), fmt.Sprintf("(VS-202603-0123456789 - page %d of %d)", pages, pages))
+	assertValidXref(t, body)
+}
+
+func TestRenderer_PDF_IsStableForTheSameDocument(t *testing.T) {
+	renderer := NewRenderer("FastingHero")
+	first, err := renderer.Render(testDocument(3), domain.DocumentFormatPDF)
+	require.NoError(t, err)
+	second, err := renderer.Render(testDocument(3), domain.DocumentFormatPDF)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+}
+
+func TestRenderer_UnsupportedFormat(t *testing.T) {
+	_, err := NewRenderer("FastingHero").Render(testDocument(1), "docx")
+
+	assert.ErrorIs(t, err, domain.ErrUnsupportedDocumentFormat)
+}
+
+func TestPDFString_Escapes(t *testing.T) {
+	assert.Equal(t, `(Refund \(partial\) C:\\ 5 - 6 ?)`, pdfString("Refund (partial) C:\\ 5 – 6 ✓"))
+}
+
+func TestWrapText(t *testing.T) {
+	lines := wrapText("Daily earnings for 2026-03-02: two meals logged and ten thousand steps", 120, 9, false)
+
+	assert.Greater(t, len(lines), 1)
+	for _, line := range lines {
+		assert.LessOrEqual(t, textWidth(line, 9, false), 120.0)
+	}
+	assert.Equal(t, []string{""}, wrapText("", 120, 9, false))
+}
+
+// assertValidXref checks that every cross-reference entry points at the object it numbers
+func assertValidXref(t *testing.T, body []byte) {
+	t.Helper()
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(body)
+	require.NotNil(t, startxref)
+	offset, _ := strconv.Atoi(string(startxref[1]))
+	require.True(t, bytes.HasPrefix(body[offset:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(body[offset:], -1)
+	require.NotEmpty(t, entries)
+	for i, entry := range entries {
+		objOffset, _ := strconv.Atoi(string(entry[1]))
+		assert.True(t, bytes.HasPrefix(body[objOffset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+}
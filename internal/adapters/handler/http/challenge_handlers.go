@@ -0,0 +1,181 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"fastinghero/internal/core/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) CreateChallenge(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	if h.challengeService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "challenges are not available"})
+		return
+	}
+
+	var req struct {
+		Name          string               `json:"name"`
+		ChallengeType domain.ChallengeType `json:"challenge_type"`
+		Goal          int                  `json:"goal"`
+		StartDate     string               `json:"start_date"` // RFC3339
+		EndDate       string               `json:"end_date"`   // RFC3339
+		Invitees      []uuid.UUID          `json:"invitees"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startDate, err := time.Parse(time.RFC3339, req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be an RFC3339 timestamp"})
+		return
+	}
+	endDate, err := time.Parse(time.RFC3339, req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be an RFC3339 timestamp"})
+		return
+	}
+
+	details, err := h.challengeService.CreateChallenge(c.Request.Context(), userID, req.Name, req.ChallengeType, req.Goal, startDate, endDate, req.Invitees)
+	if err != nil {
+		abortWithChallengeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, details)
+}
+
+func (h *Handler) GetChallenge(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	challengeID, ok := challengeIDParam(c)
+	if !ok {
+		return
+	}
+
+	details, err := h.challengeService.GetChallenge(c.Request.Context(), challengeID, userID)
+	if err != nil {
+		abortWithChallengeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+func (h *Handler) InviteToChallenge(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	challengeID, ok := challengeIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Invitees []uuid.UUID `json:"invitees" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invited, err := h.challengeService.Invite(c.Request.Context(), challengeID, userID, req.Invitees)
+	if err != nil {
+		abortWithChallengeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"invited": invited})
+}
+
+func (h *Handler) AcceptChallenge(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	challengeID, ok := challengeIDParam(c)
+	if !ok {
+		return
+	}
+
+	participant, err := h.challengeService.Accept(c.Request.Context(), challengeID, userID)
+	if err != nil {
+		abortWithChallengeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, participant)
+}
+
+func (h *Handler) DeclineChallenge(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	challengeID, ok := challengeIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.challengeService.Decline(c.Request.Context(), challengeID, userID); err != nil {
+		abortWithChallengeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "declined"})
+}
+
+func challengeIDParam(c *gin.Context) (uuid.UUID, bool) {
+	challengeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid challenge ID"})
+		return uuid.Nil, false
+	}
+	return challengeID, true
+}
+
+func abortWithChallengeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrCapabilityRequired):
+		abortWithEntitlementError(c, err)
+	case errors.Is(err, domain.ErrChallengeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrChallengeNotInvited), errors.Is(err, domain.ErrChallengeNotCreator):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrChallengeNotActive), errors.Is(err, domain.ErrChallengeAlreadyJoined):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidChallenge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrStakeNotCharged):
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
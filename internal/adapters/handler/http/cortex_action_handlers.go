@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListCortexActions returns the actions Cortex proposed to the user and what became of them,
+// newest first
+func (h *Handler) ListCortexActions(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	actions, err := h.cortexActionService.ListActions(c.Request.Context(), userID)
+	if err != nil {
+		abortWithCortexActionError(c, err)
+		return
+	}
+	if actions == nil {
+		actions = []domain.CortexAction{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"actions": actions})
+}
+
+// ConfirmCortexAction runs an action Cortex proposed. An action the service refused is
+// returned with status failed.
+func (h *Handler) ConfirmCortexAction(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	actionID, ok := cortexActionIDParam(c)
+	if !ok {
+		return
+	}
+
+	action, err := h.cortexActionService.ConfirmAction(c.Request.Context(), userID, actionID)
+	if err != nil {
+		abortWithCortexActionError(c, err)
+		return
+	}
+	if action.Status == domain.CortexActionFailed {
+		c.JSON(http.StatusUnprocessableEntity, action)
+		return
+	}
+
+	// A fast ended through Cortex counts toward streaks and badges like one ended in the app
+	var session domain.FastingSession
+	if action.Tool == domain.CortexToolStopFast && json.Unmarshal(action.Result, &session) == nil {
+		go func() {
+			ctx := context.Background() // Use background context for async
+			h.gamificationService.UpdateStreak(ctx, userID)
+			h.gamificationService.CheckAndAwardBadges(ctx, userID, "fast_completed", &session)
+		}()
+	}
+
+	c.JSON(http.StatusOK, action)
+}
+
+// RejectCortexAction declines an action Cortex proposed
+func (h *Handler) RejectCortexAction(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	actionID, ok := cortexActionIDParam(c)
+	if !ok {
+		return
+	}
+
+	action, err := h.cortexActionService.RejectAction(c.Request.Context(), userID, actionID)
+	if err != nil {
+		abortWithCortexActionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, action)
+}
+
+func cortexActionIDParam(c *gin.Context) (uuid.UUID, bool) {
+	actionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid action ID"})
+		return uuid.Nil, false
+	}
+	return actionID, true
+}
+
+func abortWithCortexActionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrCortexActionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrCortexActionDecided), errors.Is(err, domain.ErrCortexActionExpired):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,210 @@
+package http
+
+import (
+	"errors"
+	"fastinghero/internal/core/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type cortexMessageRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+// ListCortexThreads returns the user's conversations, most recent first
+func (h *Handler) ListCortexThreads(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	threads, err := h.cortexThreadService.ListThreads(c.Request.Context(), userID)
+	if err != nil {
+		abortWithCortexThreadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"threads": threads})
+}
+
+// StartCortexThread opens a conversation with its first message and returns the coach's reply
+func (h *Handler) StartCortexThread(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	var req cortexMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reply, err := h.cortexThreadService.SendMessage(c.Request.Context(), userID, nil, req.Message)
+	if err != nil {
+		abortWithCortexThreadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reply)
+}
+
+// GetCortexThread returns a conversation with its full history so the app can resume it
+func (h *Handler) GetCortexThread(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	threadID, ok := cortexThreadIDParam(c)
+	if !ok {
+		return
+	}
+
+	view, err := h.cortexThreadService.GetThread(c.Request.Context(), userID, threadID)
+	if err != nil {
+		abortWithCortexThreadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// SendCortexMessage continues a conversation
+func (h *Handler) SendCortexMessage(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	threadID, ok := cortexThreadIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req cortexMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reply, err := h.cortexThreadService.SendMessage(c.Request.Context(), userID, &threadID, req.Message)
+	if err != nil {
+		abortWithCortexThreadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reply)
+}
+
+// StreamCortexChat answers a message over Server-Sent Events. "delta" events carry the reply
+// as it is generated and a final "done" event the persisted reply; without thread_id a new
+// thread is started. Closing the connection stops generation.
+func (h *Handler) StreamCortexChat(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	var req struct {
+		cortexMessageRequest
+		ThreadID *uuid.UUID `json:"thread_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Headers go out with the first event, so errors before the reply starts stay plain JSON
+	streaming := false
+	startStream := func() {
+		if streaming {
+			return
+		}
+		streaming = true
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+		c.Status(http.StatusOK)
+	}
+
+	ctx := c.Request.Context()
+	reply, err := h.cortexThreadService.StreamMessage(ctx, userID, req.ThreadID, req.Message, func(delta string) error {
+		startStream()
+		c.SSEvent("delta", gin.H{"content": delta})
+		c.Writer.Flush()
+		return ctx.Err()
+	})
+	if err != nil {
+		if !streaming {
+			abortWithCortexThreadError(c, err)
+		} else if ctx.Err() == nil {
+			c.SSEvent("error", gin.H{"error": err.Error()})
+			c.Writer.Flush()
+		}
+		return
+	}
+
+	startStream()
+	c.SSEvent("done", reply)
+	c.Writer.Flush()
+}
+
+// DeleteCortexThread removes a conversation and all of its messages
+func (h *Handler) DeleteCortexThread(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	threadID, ok := cortexThreadIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.cortexThreadService.DeleteThread(c.Request.Context(), userID, threadID); err != nil {
+		abortWithCortexThreadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func cortexThreadIDParam(c *gin.Context) (uuid.UUID, bool) {
+	threadID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid thread ID"})
+		return uuid.Nil, false
+	}
+	return threadID, true
+}
+
+func abortWithCortexThreadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrCortexThreadNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrEmptyCortexMessage), errors.Is(err, domain.ErrCortexMessageTooLong):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrCortexThreadBusy):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrCortexQuotaExceeded):
+		abortWithCortexQuotaError(c, err)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubCortexThreadService streams a fixed reply, or fails with err before streaming
+type stubCortexThreadService struct {
+	deltas []string
+	err    error
+}
+
+func (s *stubCortexThreadService) SendMessage(ctx context.Context, userID uuid.UUID, threadID *uuid.UUID, message string) (*domain.CortexReply, error) {
+	return s.StreamMessage(ctx, userID, threadID, message, func(string) error { return nil })
+}
+
+func (s *stubCortexThreadService) StreamMessage(ctx context.Context, userID uuid.UUID, threadID *uuid.UUID, message string, onDelta func(delta string) error) (*domain.CortexReply, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	for _, delta := range s.deltas {
+		if err := onDelta(delta); err != nil {
+			return nil, err
+		}
+	}
+	return &domain.CortexReply{
+		Thread:  domain.CortexThread{ID: uuid.New(), UserID: userID},
+		Message: domain.CortexMessage{Role: domain.LLMRoleAssistant, Content: strings.Join(s.deltas, "")},
+	}, nil
+}
+
+func (s *stubCortexThreadService) ListThreads(ctx context.Context, userID uuid.UUID) ([]domain.CortexThread, error) {
+	return nil, nil
+}
+
+func (s *stubCortexThreadService) GetThread(ctx context.Context, userID, threadID uuid.UUID) (*domain.CortexThreadView, error) {
+	return nil, domain.ErrCortexThreadNotFound
+}
+
+func (s *stubCortexThreadService) DeleteThread(ctx context.Context, userID, threadID uuid.UUID) error {
+	return nil
+}
+
+func streamCortexChat(service *stubCortexThreadService, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{cortexThreadService: service}
+	router := gin.New()
+	router.POST("/cortex/chat/stream", func(c *gin.Context) { c.Set("user_id", uuid.New()) }, h.StreamCortexChat)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/cortex/chat/stream", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestStreamCortexChat_SendsDeltasThenDone(t *testing.T) {
+	w := streamCortexChat(&stubCortexThreadService{deltas: []string{"Drink ", "water."}}, `{"message":"help"}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/event-stream")
+	body := w.Body.String()
+	assert.Equal(t, 2, strings.Count(body, "event:delta"))
+	assert.Contains(t, body, `data:{"content":"Drink "}`)
+	done := strings.Index(body, "event:done")
+	assert.Greater(t, done, strings.LastIndex(body, "event:delta"))
+	assert.Contains(t, body[done:], `"content":"Drink water."`)
+}
+
+func TestStreamCortexChat_ErrorsBeforeTheStreamAreJSON(t *testing.T) {
+	w := streamCortexChat(&stubCortexThreadService{err: domain.ErrCortexThreadNotFound}, `{"message":"help","thread_id":"`+uuid.NewString()+`"}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+}
+
+func TestStreamCortexChat_RequiresMessage(t *testing.T) {
+	w := streamCortexChat(&stubCortexThreadService{}, `{}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestStreamCortexChat_QuotaExceededIs429(t *testing.T) {
+	resetsAt := time.Now().Add(time.Hour)
+	w := streamCortexChat(&stubCortexThreadService{err: &domain.CortexQuotaError{Limit: "requests", ResetsAt: resetsAt}}, `{"message":"help"}`)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"limit":"requests"`)
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.InDelta(t, 3600, retryAfter, 5)
+}
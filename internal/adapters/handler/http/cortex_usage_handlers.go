@@ -0,0 +1,52 @@
+package http
+
+import (
+	"errors"
+	"fastinghero/internal/core/domain"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetCortexUsage returns the user's Cortex usage today against their plan's budget
+func (h *Handler) GetCortexUsage(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	usage, err := h.cortexUsageService.GetUsage(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// AdminGetCortexCacheStats returns Cortex response cache hits and misses per feature
+func (h *Handler) AdminGetCortexCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"features": h.cortexUsageService.CacheStats()})
+}
+
+// abortWithCortexQuotaError answers 429 with a Retry-After header if err is a spent Cortex
+// budget, and reports whether it did
+func abortWithCortexQuotaError(c *gin.Context, err error) bool {
+	var quotaErr *domain.CortexQuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	retryAfter := int(time.Until(quotaErr.ResetsAt).Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":     quotaErr.Error(),
+		"limit":     quotaErr.Limit,
+		"resets_at": quotaErr.ResetsAt,
+	})
+	return true
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultDisciplineTimelineDays is how far back GET /user/discipline looks without ?days=
+const defaultDisciplineTimelineDays = 30
+
+// GetDisciplineTimeline returns the user's Discipline Index with the daily snapshots and the
+// reason for every change over the last ?days= days
+func (h *Handler) GetDisciplineTimeline(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	days := defaultDisciplineTimelineDays
+	if daysStr := c.Query("days"); daysStr != "" {
+		n, err := strconv.Atoi(daysStr)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive number"})
+			return
+		}
+		days = n
+	}
+
+	timeline, err := h.disciplineService.GetTimeline(c.Request.Context(), userID, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, timeline)
+}
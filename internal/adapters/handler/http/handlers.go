@@ -15,27 +15,43 @@ import (
 )
 
 type Handler struct {
-	authService          ports.AuthService
-	fastingService       ports.FastingService
-	ketoService          ports.KetoService
-	leaderboardService   ports.LeaderboardService
-	gamificationService  ports.GamificationService
-	cortexService        ports.CortexService
-	activityService      ports.ActivityService
-	telemetryService     ports.TelemetryService
-	mealService          ports.MealService
-	recipeService        ports.RecipeService
-	paymentHandler       *PaymentHandler
-	onboardingHandler    *OnboardingHandler
-	oauthHandler         *OAuthHandler
-	notificationService  ports.NotificationService
-	socialService        ports.SocialService
-	progressService      ports.ProgressService
-	progressAnalyzer     *services.ProgressAnalyzer
-	streakMonitor        *services.StreakMonitor
-	sosService           ports.SOSService
-	tribeHandler         *TribeHandler
-	smartReminderService ports.SmartReminderService
+	authService             ports.AuthService
+	fastingService          ports.FastingService
+	ketoService             ports.KetoService
+	leaderboardService      ports.LeaderboardService
+	gamificationService     ports.GamificationService
+	cortexService           ports.CortexService
+	activityService         ports.ActivityService
+	telemetryService        ports.TelemetryService
+	mealService             ports.MealService
+	recipeService           ports.RecipeService
+	referralService         ports.ReferralService
+	paymentHandler          *PaymentHandler
+	onboardingHandler       *OnboardingHandler
+	oauthHandler            *OAuthHandler
+	notificationService     ports.NotificationService
+	socialService           ports.SocialService
+	progressService         ports.ProgressService
+	progressAnalyzer        *services.ProgressAnalyzer
+	streakMonitor           *services.StreakMonitor
+	sosService              ports.SOSService
+	tribeHandler            *TribeHandler
+	smartReminderService    ports.SmartReminderService
+	vaultService            ports.VaultService
+	vaultDocumentService    ports.VaultDocumentService
+	entitlementService      ports.EntitlementService
+	paymentSimulator        *PaymentSimulatorHandler
+	challengeService        ports.ChallengeService
+	trialService            ports.TrialService
+	promoService            ports.PromoCodeService
+	disciplineService       ports.DisciplineService
+	cortexThreadService     ports.CortexThreadService
+	cortexUsageService      ports.CortexUsageService
+	promptExperimentService ports.PromptExperimentService
+	safetyReviewService     ports.SafetyReviewService
+	cortexActionService     ports.CortexActionService
+	adminEmails             []string
+	userRepo                ports.UserRepository
 }
 
 func NewHandler(
@@ -68,12 +84,14 @@ func NewHandler(
 		telemetryService:    telemetryService,
 		mealService:         mealService,
 		recipeService:       recipeService,
+		referralService:     referralService,
 		paymentHandler:      NewPaymentHandler(paymentService, referralService, userRepo),
 		onboardingHandler:   NewOnboardingHandler(services.NewOnboardingService(userRepo)),
 		oauthHandler:        nil, // Will be set in main.go
 		notificationService: notificationService,
 		socialService:       socialService,
 		progressService:     progressService,
+		userRepo:            userRepo,
 	}
 }
 
@@ -97,12 +115,83 @@ func (h *Handler) SetSmartReminderService(srs ports.SmartReminderService) {
 	h.smartReminderService = srs
 }
 
+// SetVaultService sets the VaultService (called from main.go after handler construction)
+func (h *Handler) SetVaultService(vaultService ports.VaultService) {
+	h.vaultService = vaultService
+}
+
+// SetVaultDocumentService enables statement and receipt downloads (called from main.go after handler construction)
+func (h *Handler) SetVaultDocumentService(vaultDocumentService ports.VaultDocumentService) {
+	h.vaultDocumentService = vaultDocumentService
+}
+
+// SetEntitlementService sets the EntitlementService (called from main.go after handler construction)
+func (h *Handler) SetEntitlementService(entitlementService ports.EntitlementService) {
+	h.entitlementService = entitlementService
+}
+
+// SetPaymentSimulator enables the /dev/payments routes (called from main.go when the fake gateway is in use)
+func (h *Handler) SetPaymentSimulator(paymentSimulator *PaymentSimulatorHandler) {
+	h.paymentSimulator = paymentSimulator
+}
+
+// SetChallengeService enables friend challenge invitations and settlement (called from main.go after handler construction)
+func (h *Handler) SetChallengeService(challengeService ports.ChallengeService) {
+	h.challengeService = challengeService
+}
+
+// SetTrialService enables free trials at registration and the /payments/trial routes (called from main.go after handler construction)
+func (h *Handler) SetTrialService(trialService ports.TrialService) {
+	h.trialService = trialService
+}
+
+// SetPromoCodeService enables promo code lookups and the admin promo code routes (called from main.go after handler construction)
+func (h *Handler) SetPromoCodeService(promoService ports.PromoCodeService) {
+	h.promoService = promoService
+}
+
+// SetDisciplineService enables the /user/discipline timeline (called from main.go after handler construction)
+func (h *Handler) SetDisciplineService(disciplineService ports.DisciplineService) {
+	h.disciplineService = disciplineService
+}
+
+// SetCortexThreadService enables the persisted /cortex/threads conversations (called from main.go after handler construction)
+func (h *Handler) SetCortexThreadService(cortexThreadService ports.CortexThreadService) {
+	h.cortexThreadService = cortexThreadService
+}
+
+// SetCortexUsageService enables /cortex/usage and the admin cache stats (called from main.go after handler construction)
+func (h *Handler) SetCortexUsageService(cortexUsageService ports.CortexUsageService) {
+	h.cortexUsageService = cortexUsageService
+}
+
+// SetPromptExperimentService enables the admin prompt experiment report (called from main.go after handler construction)
+func (h *Handler) SetPromptExperimentService(promptExperimentService ports.PromptExperimentService) {
+	h.promptExperimentService = promptExperimentService
+}
+
+// SetSafetyReviewService enables the admin review of Cortex safety flags (called from main.go after handler construction)
+func (h *Handler) SetSafetyReviewService(safetyReviewService ports.SafetyReviewService) {
+	h.safetyReviewService = safetyReviewService
+}
+
+// SetCortexActionService enables confirming the actions Cortex proposes in chat (called from main.go after handler construction)
+func (h *Handler) SetCortexActionService(cortexActionService ports.CortexActionService) {
+	h.cortexActionService = cortexActionService
+}
+
+// SetAdminEmails sets the accounts allowed on /admin routes (called from main.go after handler construction)
+func (h *Handler) SetAdminEmails(emails []string) {
+	h.adminEmails = emails
+}
+
 func (h *Handler) Register(c *gin.Context) {
 	var req struct {
-		Email        string `json:"email"`
-		Password     string `json:"password"`
-		Name         string `json:"name"`
-		ReferralCode string `json:"referral_code"`
+		Email         string `json:"email"`
+		Password      string `json:"password"`
+		Name          string `json:"name"`
+		ReferralCode  string `json:"referral_code"`
+		TrialCampaign string `json:"trial_campaign"`
 	}
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -113,6 +202,19 @@ func (h *Handler) Register(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if h.referralService != nil {
+		// Kept for spotting referral fraud; registration succeeds without it
+		_ = h.referralService.RecordSignup(c.Request.Context(), user.ID, c.ClientIP(), c.GetHeader("X-Device-ID"), c.Request.UserAgent())
+	}
+	if h.trialService != nil {
+		// A trial is a bonus; registration succeeds without one
+		if _, err := h.trialService.StartTrial(c.Request.Context(), user.ID, req.TrialCampaign, ""); err == nil {
+			// The trial service saved the trial on the user; answer with that record
+			if updated, err := h.userRepo.FindByID(c.Request.Context(), user.ID); err == nil {
+				user = updated
+			}
+		}
+	}
 	c.JSON(http.StatusCreated, user)
 }
 
@@ -174,6 +276,10 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 		user.GET("/reminder-settings", h.GetReminderSettings)
 		user.PUT("/reminder-settings", h.UpdateReminderSettings)
 		user.GET("/optimal-fasting-window", h.GetOptimalFastingWindow)
+		user.GET("/entitlements", h.GetEntitlements)
+		if h.disciplineService != nil {
+			user.GET("/discipline", h.GetDisciplineTimeline)
+		}
 	}
 
 	fasting := protected.Group("/fasting")
@@ -195,19 +301,43 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 		sos.POST("/:id/resolve", h.ResolveSOS)
 	}
 
+	// Premium routes are gated by plan; the services check the same capabilities
+	aiCoaching := RequireCapability(h.entitlementService, domain.CapabilityAICoaching)
+	groupPots := RequireCapability(h.entitlementService, domain.CapabilityGroupPots)
+
 	keto := protected.Group("/keto")
+	keto.Use(RequireCapability(h.entitlementService, domain.CapabilityHardKetoData))
 	{
 		keto.POST("/log", h.LogKeto)
 	}
 
+	// Chat, threads and the daily quote are open to every plan within its daily Cortex budget;
+	// personalized coaching needs ai_coaching
 	cortex := protected.Group("/cortex")
 	{
 		cortex.POST("/chat", h.Chat)
-		cortex.POST("/insight", h.GetInsight)
-		cortex.POST("/craving-help", h.GetCravingHelp)
-		cortex.GET("/weekly-report", h.GetWeeklyReport)
-		cortex.POST("/break-fast-guide", h.GetBreakFastGuide)
+		cortex.POST("/insight", aiCoaching, h.GetInsight)
+		cortex.POST("/craving-help", aiCoaching, h.GetCravingHelp)
+		cortex.GET("/weekly-report", aiCoaching, h.GetWeeklyReport)
+		cortex.POST("/break-fast-guide", aiCoaching, h.GetBreakFastGuide)
 		cortex.GET("/daily-quote", h.GetDailyQuote)
+
+		if h.cortexThreadService != nil {
+			cortex.GET("/threads", h.ListCortexThreads)
+			cortex.POST("/threads", h.StartCortexThread)
+			cortex.GET("/threads/:id", h.GetCortexThread)
+			cortex.POST("/threads/:id/messages", h.SendCortexMessage)
+			cortex.DELETE("/threads/:id", h.DeleteCortexThread)
+			cortex.POST("/chat/stream", h.StreamCortexChat)
+		}
+		if h.cortexActionService != nil {
+			cortex.GET("/actions", h.ListCortexActions)
+			cortex.POST("/actions/:id/confirm", h.ConfirmCortexAction)
+			cortex.POST("/actions/:id/reject", h.RejectCortexAction)
+		}
+		if h.cortexUsageService != nil {
+			cortex.GET("/usage", h.GetCortexUsage)
+		}
 	}
 
 	activity := protected.Group("/activity")
@@ -252,16 +382,32 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	{
 		social.POST("/friends/add", h.AddFriend)
 		social.GET("/friends", h.GetFriends)
-		social.POST("/challenges", h.CreateChallenge)
+		social.POST("/challenges", groupPots, h.CreateChallenge)
 		social.GET("/challenges", h.GetChallenges)
 		social.GET("/feed", h.GetFeed)
+		if h.challengeService != nil {
+			social.GET("/challenges/:id", h.GetChallenge)
+			social.POST("/challenges/:id/invite", h.InviteToChallenge)
+			social.POST("/challenges/:id/accept", groupPots, h.AcceptChallenge)
+			social.POST("/challenges/:id/decline", h.DeclineChallenge)
+		}
 	}
 
 	// Tribe Routes
 	if h.tribeHandler != nil {
 		authMiddleware := AuthMiddleware(h.authService)
 		optionalAuthMiddleware := OptionalAuthMiddleware(h.authService)
-		RegisterTribesRoutes(api, h.tribeHandler, authMiddleware, optionalAuthMiddleware)
+		RegisterTribesRoutes(api, h.tribeHandler, authMiddleware, optionalAuthMiddleware, groupPots)
+	}
+
+	vault := protected.Group("/vault")
+	{
+		vault.GET("/statement", h.GetVaultStatement)
+		if h.vaultDocumentService != nil {
+			vault.GET("/statement/document", h.DownloadVaultStatement)
+			vault.GET("/payments", h.ListVaultPayments)
+			vault.GET("/receipts/:id", h.DownloadReceipt)
+		}
 	}
 
 	leaderboardGroup := protected.Group("/leaderboard")
@@ -277,10 +423,67 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	// Payment Routes
 	payment := api.Group("/payments")
 	{
-		payment.POST("/deposit", h.paymentHandler.HandleDeposit)
+		payment.GET("/plans", h.paymentHandler.ListPlans)
 		payment.POST("/webhook", h.paymentHandler.HandleWebhook)
 	}
 
+	billing := protected.Group("/payments")
+	{
+		billing.POST("/deposit", h.paymentHandler.HandleDeposit)
+		billing.POST("/checkout", h.paymentHandler.CreateCheckoutSession)
+		billing.POST("/portal", h.paymentHandler.CreatePortalSession)
+		if h.trialService != nil {
+			billing.GET("/trial", h.GetTrial)
+			billing.POST("/trial", h.StartTrial)
+		}
+		if h.promoService != nil {
+			billing.GET("/promo-codes/:code", h.CheckPromoCode)
+		}
+	}
+
+	// Admin routes: only accounts listed in ADMIN_EMAILS
+	if len(h.adminEmails) > 0 {
+		admin := protected.Group("/admin")
+		admin.Use(RequireAdmin(h.adminEmails))
+		if h.promoService != nil {
+			admin.POST("/promo-codes", h.AdminCreatePromoCode)
+			admin.GET("/promo-codes", h.AdminListPromoCodes)
+			admin.GET("/promo-codes/:code", h.AdminGetPromoCode)
+			admin.PATCH("/promo-codes/:code", h.AdminUpdatePromoCode)
+			admin.GET("/promo-codes/:code/redemptions", h.AdminListPromoRedemptions)
+		}
+		if h.referralService != nil {
+			admin.GET("/referrals/held", h.AdminListHeldReferrals)
+			admin.POST("/referrals/:id/review", h.AdminReviewReferral)
+		}
+		if h.cortexUsageService != nil {
+			admin.GET("/cortex/cache", h.AdminGetCortexCacheStats)
+		}
+		if h.promptExperimentService != nil {
+			admin.GET("/cortex/prompts", h.AdminListPrompts)
+			admin.GET("/cortex/experiments", h.AdminGetPromptExperiments)
+		}
+		if h.safetyReviewService != nil {
+			admin.GET("/cortex/safety-flags", h.AdminListSafetyFlags)
+			admin.POST("/cortex/safety-flags/:id/review", h.AdminReviewSafetyFlag)
+		}
+	}
+
+	// Fake gateway controls (local development only)
+	if h.paymentSimulator != nil {
+		devPayments := api.Group("/dev/payments")
+		{
+			devPayments.GET("/checkout/:id", h.paymentSimulator.VisitCheckout)
+			devPayments.POST("/checkout/:id/complete", h.paymentSimulator.CompleteCheckout)
+			devPayments.POST("/subscriptions/:id/renew", h.paymentSimulator.RenewSubscription)
+			devPayments.POST("/subscriptions/:id/status", h.paymentSimulator.SetSubscriptionStatus)
+			devPayments.POST("/subscriptions/:id/trial-will-end", h.paymentSimulator.TrialWillEnd)
+			devPayments.POST("/cards", h.paymentSimulator.SetCards)
+			devPayments.POST("/payment-methods", h.paymentSimulator.AddPaymentMethod)
+			devPayments.GET("/state", h.paymentSimulator.GetState)
+		}
+	}
+
 	// Notification Routes
 	notifications := protected.Group("/notifications")
 	{
@@ -320,13 +523,15 @@ func (h *Handler) Chat(c *gin.Context) {
 		return
 	}
 
-	response, err := h.cortexService.Chat(c.Request.Context(), userID, req.Message)
+	answer, err := h.cortexService.Chat(c.Request.Context(), userID, req.Message)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if !abortWithCortexQuotaError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"response": response})
+	c.JSON(http.StatusOK, gin.H{"response": answer.Text, "sources": answer.Citations, "safety_mode": answer.SafetyMode, "actions": answer.Actions})
 }
 
 func (h *Handler) GetInsight(c *gin.Context) {
@@ -346,11 +551,14 @@ func (h *Handler) GetInsight(c *gin.Context) {
 	}
 
 	insight, err := h.cortexService.GenerateInsight(c.Request.Context(), userID, req.FastingHours)
+	if abortWithCortexQuotaError(c, err) {
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{"insight": "Stay hydrated and keep going! (AI insights unavailable)"})
+		c.JSON(http.StatusOK, gin.H{"insight": "Stay hydrated and keep going! (AI insights unavailable)", "sources": []domain.KnowledgeCitation{}})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"insight": insight})
+	c.JSON(http.StatusOK, gin.H{"insight": insight.Text, "sources": insight.Citations, "safety_mode": insight.SafetyMode})
 }
 
 func (h *Handler) GetCravingHelp(c *gin.Context) {
@@ -456,6 +664,9 @@ func (h *Handler) GetDailyQuote(c *gin.Context) {
 	userID := userIDVal.(uuid.UUID)
 
 	quote, err := h.cortexService.(*services.CortexService).GenerateDailyQuote(c.Request.Context(), userID)
+	if abortWithCortexQuotaError(c, err) {
+		return
+	}
 	if err != nil {
 		// Return fallback quote on error
 		c.JSON(http.StatusOK, gin.H{"quote": "Every hour of discipline builds the person you're becoming."})
@@ -684,11 +895,7 @@ func (h *Handler) LogKeto(c *gin.Context) {
 	}
 	err := h.ketoService.LogEntry(c.Request.Context(), userID, req)
 	if err != nil {
-		if err.Error() == "premium subscription required for hard data inputs" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithEntitlementError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"status": "logged"})
@@ -1024,6 +1231,26 @@ func (h *Handler) GetUserProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+func (h *Handler) GetEntitlements(c *gin.Context) {
+	if h.entitlementService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "entitlement service not available"})
+		return
+	}
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	entitlements, err := h.entitlementService.GetEntitlements(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entitlements)
+}
+
 func (h *Handler) GetUserStats(c *gin.Context) {
 	userIDVal, exists := c.Get("user_id")
 	if !exists {
@@ -1071,7 +1298,7 @@ func (h *Handler) GetUserStats(c *gin.Context) {
 
 // GetFastingInsight returns AI-generated insights about the user's current fast
 func (h *Handler) GetFastingInsight(c *gin.Context) {
-	userIDVal, exists := c.Get("userID")
+	userIDVal, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
@@ -1093,6 +1320,9 @@ func (h *Handler) GetFastingInsight(c *gin.Context) {
 
 	// Call cortex service for insight
 	insight, err := h.cortexService.(*services.CortexService).GetFastingMilestoneInsight(c.Request.Context(), userID, hours)
+	if abortWithCortexQuotaError(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate insight"})
 		return
@@ -1354,3 +1584,32 @@ func (h *Handler) GetOptimalFastingWindow(c *gin.Context) {
 
 	c.JSON(http.StatusOK, window)
 }
+
+// GetVaultStatement handles GET /api/v1/vault/statement?month=YYYY-MM
+// Defaults to the current month.
+func (h *Handler) GetVaultStatement(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	if h.vaultService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "vault service not available"})
+		return
+	}
+
+	from, ok := statementMonth(c)
+	if !ok {
+		return
+	}
+
+	statement, err := h.vaultService.GetStatement(c.Request.Context(), userID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
@@ -1,11 +1,14 @@
 package http
 
 import (
+	"errors"
+	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 func AuthMiddleware(authService ports.AuthService) gin.HandlerFunc {
@@ -66,3 +69,68 @@ func OptionalAuthMiddleware(authService ports.AuthService) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireCapability rejects requests from users whose plan doesn't include the capability.
+// It must run after AuthMiddleware.
+func RequireCapability(entitlements ports.EntitlementService, capability domain.Capability) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if entitlements == nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "entitlement service not available"})
+			return
+		}
+		userIDVal, exists := c.Get("user_id")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		userID := userIDVal.(uuid.UUID)
+
+		if err := entitlements.Require(c.Request.Context(), userID, capability); err != nil {
+			abortWithEntitlementError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin only lets through users whose email is one of the admin emails (case-insensitive).
+// It must run after AuthMiddleware.
+func RequireAdmin(adminEmails []string) gin.HandlerFunc {
+	admins := make(map[string]bool, len(adminEmails))
+	for _, email := range adminEmails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			admins[email] = true
+		}
+	}
+	return func(c *gin.Context) {
+		userVal, exists := c.Get("user")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		user, ok := userVal.(*domain.User)
+		if !ok || user == nil || !admins[strings.ToLower(user.Email)] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// abortWithEntitlementError responds 403 with the missing capability and the plans that include it
+func abortWithEntitlementError(c *gin.Context, err error) {
+	var entErr *domain.EntitlementError
+	if errors.As(err, &entErr) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":          err.Error(),
+			"capability":     entErr.Capability,
+			"required_plans": entErr.RequiredPlans,
+		})
+		return
+	}
+	if errors.Is(err, domain.ErrCapabilityRequired) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEntitlementService is a mock implementation of ports.EntitlementService
+type MockEntitlementService struct {
+	mock.Mock
+}
+
+func (m *MockEntitlementService) GetEntitlements(ctx context.Context, userID uuid.UUID) (*domain.Entitlements, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Entitlements), args.Error(1)
+}
+
+func (m *MockEntitlementService) Require(ctx context.Context, userID uuid.UUID, capability domain.Capability) error {
+	args := m.Called(ctx, userID, capability)
+	return args.Error(0)
+}
+
+func setupCapabilityRouter(entitlements *MockEntitlementService, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	router.GET("/pots", RequireCapability(entitlements, domain.CapabilityGroupPots), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return router
+}
+
+func TestRequireCapability(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("allows entitled user", func(t *testing.T) {
+		entitlements := new(MockEntitlementService)
+		entitlements.On("Require", mock.Anything, userID, domain.CapabilityGroupPots).Return(nil)
+		router := setupCapabilityRouter(entitlements, userID)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/pots", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("rejects user without capability", func(t *testing.T) {
+		entitlements := new(MockEntitlementService)
+		entitlements.On("Require", mock.Anything, userID, domain.CapabilityGroupPots).Return(&domain.EntitlementError{
+			Capability:    domain.CapabilityGroupPots,
+			RequiredPlans: domain.PlansWith(domain.CapabilityGroupPots),
+		})
+		router := setupCapabilityRouter(entitlements, userID)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/pots", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "group_pots", body["capability"])
+		assert.Equal(t, []interface{}{"accountability_plus"}, body["required_plans"])
+	})
+
+	t.Run("lookup failure is a server error", func(t *testing.T) {
+		entitlements := new(MockEntitlementService)
+		entitlements.On("Require", mock.Anything, userID, domain.CapabilityGroupPots).Return(errors.New("db error"))
+		router := setupCapabilityRouter(entitlements, userID)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/pots", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(user *domain.User) int {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			if user != nil {
+				c.Set("user", user)
+			}
+			c.Next()
+		})
+		router.GET("/admin", RequireAdmin([]string{" Marketing@FastingHero.com "}), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/admin", nil)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(&domain.User{Email: "marketing@fastinghero.com"}))
+	assert.Equal(t, http.StatusForbidden, serve(&domain.User{Email: "someone@fastinghero.com"}))
+	assert.Equal(t, http.StatusUnauthorized, serve(nil))
+}
@@ -1,8 +1,10 @@
 package http
 
 import (
+	"errors"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
+	"fastinghero/internal/core/services"
 	"io"
 	"net/http"
 
@@ -24,61 +26,85 @@ func NewPaymentHandler(paymentService ports.PaymentService, referralService port
 	}
 }
 
+// HandleDeposit starts the vault subscription checkout. Kept for clients that predate
+// /payments/checkout; the vault is only activated once Stripe confirms payment.
 func (h *PaymentHandler) HandleDeposit(c *gin.Context) {
 	userIDVal, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
-	userID := userIDVal.(uuid.UUID) // Requires uuid import, need to add it
+	h.startCheckout(c, userIDVal.(uuid.UUID), domain.TierVault, "")
+}
 
-	var req struct {
-		Amount          float64 `json:"amount"`
-		PaymentMethodID string  `json:"payment_method_id"`
+func (h *PaymentHandler) ListPlans(c *gin.Context) {
+	plans := []gin.H{}
+	for _, tier := range h.paymentService.ListPlans() {
+		plans = append(plans, gin.H{"plan": tier, "capabilities": domain.PlanCapabilities(tier)})
 	}
+	c.JSON(http.StatusOK, gin.H{"plans": plans})
+}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+func (h *PaymentHandler) CreateCheckoutSession(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	// Fetch user to get email
-	user, err := h.userRepo.FindByID(c.Request.Context(), userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+	var req struct {
+		Plan      domain.SubscriptionTier `json:"plan" binding:"required"`
+		PromoCode string                  `json:"promo_code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	h.startCheckout(c, userIDVal.(uuid.UUID), req.Plan, req.PromoCode)
+}
 
-	// 1. Create Customer
-	customerID, err := h.paymentService.CreateCustomer(c.Request.Context(), user)
+func (h *PaymentHandler) startCheckout(c *gin.Context, userID uuid.UUID, plan domain.SubscriptionTier, promoCode string) {
+	session, err := h.paymentService.CreateCheckoutSession(c.Request.Context(), userID, plan, promoCode)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create customer"})
+		if status, ok := promoErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		switch {
+		case errors.Is(err, domain.ErrPlanNotAvailable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrAlreadySubscribed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create checkout session"})
+		}
 		return
 	}
 
-	// 2. In a real flow, we would create a PaymentIntent here using the amount
-	// For this MVP step, we are just verifying the adapter connection.
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":   session.ID,
+		"checkout_url": session.URL,
+	})
+}
 
-	// GRANT PREMIUM STATUS IMMEDIATELY (MVP/Demo)
-	user.SubscriptionTier = domain.TierVault
-	user.SubscriptionStatus = domain.SubStatusActive
-	if err := h.userRepo.Save(c.Request.Context(), user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user status"})
+func (h *PaymentHandler) CreatePortalSession(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	// 3. Complete Referral (if any)
-	if h.referralService != nil {
-		go func() {
-			// ctx := context.Background()
-			// h.referralService.CompleteReferral(ctx, userID)
-		}()
+	url, err := h.paymentService.CreatePortalSession(c.Request.Context(), userIDVal.(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, services.ErrNoBillingAccount) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create billing portal session"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":     "Subscription payment processed", // UPDATED FOR V2
-		"customer_id": customerID,
-	})
+	c.JSON(http.StatusOK, gin.H{"portal_url": url})
 }
 
 func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
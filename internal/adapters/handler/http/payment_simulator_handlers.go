@@ -0,0 +1,114 @@
+package http
+
+import (
+	"errors"
+	"fastinghero/internal/adapters/payment"
+	"fastinghero/internal/core/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentSimulatorHandler drives the fake payment gateway: it plays the customer on the
+// hosted checkout page and the billing system behind it. Only registered when
+// PAYMENT_GATEWAY=fake, never in production.
+type PaymentSimulatorHandler struct {
+	gateway *payment.FakeGateway
+}
+
+func NewPaymentSimulatorHandler(gateway *payment.FakeGateway) *PaymentSimulatorHandler {
+	return &PaymentSimulatorHandler{gateway: gateway}
+}
+
+// VisitCheckout stands in for the hosted checkout page: it pays and redirects to the success URL
+func (h *PaymentSimulatorHandler) VisitCheckout(c *gin.Context) {
+	successURL, err := h.gateway.CompleteCheckout(c.Param("id"))
+	if err != nil {
+		h.abort(c, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, successURL)
+}
+
+func (h *PaymentSimulatorHandler) CompleteCheckout(c *gin.Context) {
+	successURL, err := h.gateway.CompleteCheckout(c.Param("id"))
+	if err != nil {
+		h.abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"redirect_url": successURL})
+}
+
+func (h *PaymentSimulatorHandler) RenewSubscription(c *gin.Context) {
+	if err := h.gateway.RenewSubscription(c.Param("id")); err != nil {
+		h.abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "subscription renewed"})
+}
+
+func (h *PaymentSimulatorHandler) SetSubscriptionStatus(c *gin.Context) {
+	var req struct {
+		Status domain.SubscriptionStatus `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.gateway.UpdateSubscriptionStatus(c.Param("id"), req.Status); err != nil {
+		h.abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": req.Status})
+}
+
+func (h *PaymentSimulatorHandler) TrialWillEnd(c *gin.Context) {
+	if err := h.gateway.TrialWillEnd(c.Param("id")); err != nil {
+		h.abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "trial ending event sent"})
+}
+
+// SetCards toggles card declines for later checkouts and renewals
+func (h *PaymentSimulatorHandler) SetCards(c *gin.Context) {
+	var req struct {
+		Decline bool `json:"decline"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.gateway.DeclineCards(req.Decline)
+	c.JSON(http.StatusOK, gin.H{"decline_cards": req.Decline})
+}
+
+// AddPaymentMethod creates a payment method for a card fingerprint, for trials that need a card.
+// Reusing a fingerprint simulates the same card on another account.
+func (h *PaymentSimulatorHandler) AddPaymentMethod(c *gin.Context) {
+	var req struct {
+		Fingerprint string `json:"fingerprint" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"payment_method_id": h.gateway.AddCard(req.Fingerprint)})
+}
+
+func (h *PaymentSimulatorHandler) GetState(c *gin.Context) {
+	c.JSON(http.StatusOK, h.gateway.State())
+}
+
+func (h *PaymentSimulatorHandler) abort(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, payment.ErrFakeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, payment.ErrFakeCardDeclined):
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+	case errors.Is(err, payment.ErrFakeSessionCompleted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"fastinghero/internal/core/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CheckPromoCode lets the checkout page show a code's discount before the user pays
+func (h *Handler) CheckPromoCode(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	plan := domain.SubscriptionTier(c.Query("plan"))
+	if !plan.IsValid() || plan == domain.TierFree {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "plan must be a paid plan"})
+		return
+	}
+
+	promo, err := h.promoService.CheckPromoCode(c.Request.Context(), userID, c.Param("code"), plan)
+	if err != nil {
+		abortWithPromoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":            promo.Code,
+		"description":     promo.Description,
+		"discount_type":   promo.DiscountType,
+		"percent_off":     promo.PercentOff,
+		"amount_off":      promo.AmountOff,
+		"duration":        promo.Duration,
+		"duration_months": promo.DurationMonths,
+	})
+}
+
+func (h *Handler) AdminCreatePromoCode(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	adminID := userIDVal.(uuid.UUID)
+
+	var req struct {
+		Code           string                    `json:"code" binding:"required"`
+		Description    string                    `json:"description"`
+		DiscountType   domain.PromoDiscountType  `json:"discount_type" binding:"required"`
+		PercentOff     float64                   `json:"percent_off"`
+		AmountOff      float64                   `json:"amount_off"`
+		Duration       domain.PromoDuration      `json:"duration"`
+		DurationMonths int                       `json:"duration_months"`
+		MaxRedemptions int                       `json:"max_redemptions"`
+		Plans          []domain.SubscriptionTier `json:"plans"`
+		ExpiresAt      string                    `json:"expires_at"` // RFC3339, optional
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promo := &domain.PromoCode{
+		Code:           req.Code,
+		Description:    req.Description,
+		DiscountType:   req.DiscountType,
+		PercentOff:     req.PercentOff,
+		AmountOff:      req.AmountOff,
+		Duration:       req.Duration,
+		DurationMonths: req.DurationMonths,
+		MaxRedemptions: req.MaxRedemptions,
+		Plans:          req.Plans,
+	}
+	if promo.Duration == "" {
+		promo.Duration = domain.PromoDurationOnce
+	}
+	if req.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be an RFC3339 timestamp"})
+			return
+		}
+		promo.ExpiresAt = &expiresAt
+	}
+
+	created, err := h.promoService.CreatePromoCode(c.Request.Context(), adminID, promo)
+	if err != nil {
+		abortWithPromoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *Handler) AdminListPromoCodes(c *gin.Context) {
+	promos, err := h.promoService.ListPromoCodes(c.Request.Context())
+	if err != nil {
+		abortWithPromoError(c, err)
+		return
+	}
+	if promos == nil {
+		promos = []*domain.PromoCode{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promo_codes": promos})
+}
+
+func (h *Handler) AdminGetPromoCode(c *gin.Context) {
+	promo, err := h.promoService.GetPromoCode(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		abortWithPromoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+func (h *Handler) AdminUpdatePromoCode(c *gin.Context) {
+	var req struct {
+		Active *bool `json:"active" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promo, err := h.promoService.SetPromoCodeActive(c.Request.Context(), c.Param("code"), *req.Active)
+	if err != nil {
+		abortWithPromoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+func (h *Handler) AdminListPromoRedemptions(c *gin.Context) {
+	redemptions, err := h.promoService.ListRedemptions(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		abortWithPromoError(c, err)
+		return
+	}
+	if redemptions == nil {
+		redemptions = []*domain.PromoRedemption{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redemptions": redemptions})
+}
+
+// promoErrorStatus maps promo code errors to a status; ok is false for other errors
+func promoErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, domain.ErrPromoCodeNotFound):
+		return http.StatusNotFound, true
+	case errors.Is(err, domain.ErrPromoCodeExists), errors.Is(err, domain.ErrPromoCodeAlreadyRedeemed):
+		return http.StatusConflict, true
+	case errors.Is(err, domain.ErrPromoCodeInactive), errors.Is(err, domain.ErrPromoCodeExpired),
+		errors.Is(err, domain.ErrPromoCodeExhausted), errors.Is(err, domain.ErrPromoCodeNotForPlan),
+		errors.Is(err, domain.ErrInvalidPromoCode):
+		return http.StatusBadRequest, true
+	}
+	return 0, false
+}
+
+func abortWithPromoError(c *gin.Context, err error) {
+	if status, ok := promoErrorStatus(err); ok {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,22 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListPrompts returns the Cortex prompt templates with their variants and weights
+func (h *Handler) AdminListPrompts(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"prompts": h.promptExperimentService.ListPrompts()})
+}
+
+// AdminGetPromptExperiments compares prompt variants by the fast completion of their users
+func (h *Handler) AdminGetPromptExperiments(c *gin.Context) {
+	report, err := h.promptExperimentService.PromptExperimentReport(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"variants": report})
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"fastinghero/internal/core/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AdminListHeldReferrals lists referrals waiting for a fraud review
+func (h *Handler) AdminListHeldReferrals(c *gin.Context) {
+	referrals, err := h.referralService.ListHeldReferrals(c.Request.Context())
+	if err != nil {
+		abortWithReferralError(c, err)
+		return
+	}
+	if referrals == nil {
+		referrals = []domain.Referral{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"referrals": referrals})
+}
+
+// AdminReviewReferral approves (pays out) or rejects a held referral
+func (h *Handler) AdminReviewReferral(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	adminID := userIDVal.(uuid.UUID)
+
+	referralID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid referral id"})
+		return
+	}
+
+	var req struct {
+		Approve *bool  `json:"approve" binding:"required"`
+		Note    string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	referral, err := h.referralService.ReviewReferral(c.Request.Context(), referralID, adminID, *req.Approve, req.Note)
+	if err != nil {
+		abortWithReferralError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, referral)
+}
+
+func abortWithReferralError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrReferralNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrReferralNotHeld):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
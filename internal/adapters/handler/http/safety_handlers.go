@@ -0,0 +1,74 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"fastinghero/internal/core/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AdminListSafetyFlags lists Cortex safety flags, open ones unless ?status=reviewed
+func (h *Handler) AdminListSafetyFlags(c *gin.Context) {
+	status := domain.SafetyFlagStatus(c.DefaultQuery("status", string(domain.SafetyFlagOpen)))
+	if status != domain.SafetyFlagOpen && status != domain.SafetyFlagReviewed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open or reviewed"})
+		return
+	}
+
+	flags, err := h.safetyReviewService.ListSafetyFlags(c.Request.Context(), status)
+	if err != nil {
+		abortWithSafetyFlagError(c, err)
+		return
+	}
+	if flags == nil {
+		flags = []domain.SafetyFlag{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"flags": flags})
+}
+
+// AdminReviewSafetyFlag closes an open safety flag with the reviewer's note
+func (h *Handler) AdminReviewSafetyFlag(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	adminID := userIDVal.(uuid.UUID)
+
+	flagID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid safety flag id"})
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	flag, err := h.safetyReviewService.ReviewSafetyFlag(c.Request.Context(), flagID, adminID, req.Note)
+	if err != nil {
+		abortWithSafetyFlagError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flag)
+}
+
+func abortWithSafetyFlagError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrSafetyFlagNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrSafetyFlagReviewed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
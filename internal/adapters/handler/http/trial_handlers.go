@@ -0,0 +1,67 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"fastinghero/internal/core/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) GetTrial(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	trial, err := h.trialService.GetTrial(c.Request.Context(), userIDVal.(uuid.UUID))
+	if err != nil {
+		abortWithTrialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, trial)
+}
+
+// StartTrial redeems a trial campaign, or the registration offer for users who registered
+// before trials existed. Campaigns that require a card take a payment method ID from the client.
+func (h *Handler) StartTrial(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req struct {
+		Campaign        string `json:"campaign"`
+		PaymentMethodID string `json:"payment_method_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trial, err := h.trialService.StartTrial(c.Request.Context(), userIDVal.(uuid.UUID), req.Campaign, req.PaymentMethodID)
+	if err != nil {
+		abortWithTrialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, trial)
+}
+
+func abortWithTrialError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrTrialNotFound), errors.Is(err, domain.ErrUnknownTrialCampaign):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTrialAlreadyUsed), errors.Is(err, domain.ErrTrialNotAvailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrPaymentMethodRequired):
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
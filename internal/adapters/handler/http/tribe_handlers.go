@@ -14,6 +14,7 @@ import (
 // TribeHandler handles HTTP requests for tribes feature
 type TribeHandler struct {
 	tribeService ports.TribeService
+	poolService  ports.TribePoolService
 }
 
 // NewTribeHandler creates a new tribe handler
@@ -23,6 +24,11 @@ func NewTribeHandler(tribeService ports.TribeService) *TribeHandler {
 	}
 }
 
+// SetPoolService enables the monthly prize pool routes (called from main.go after handler construction)
+func (h *TribeHandler) SetPoolService(poolService ports.TribePoolService) {
+	h.poolService = poolService
+}
+
 // CreateTribe handles POST /api/v1/tribes
 func (h *TribeHandler) CreateTribe(c *gin.Context) {
 	userIDVal, exists := c.Get("user_id")
@@ -214,8 +220,8 @@ func (h *TribeHandler) GetTribeStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
-// RegisterTribesRoutes registers all tribe-related routes
-func RegisterTribesRoutes(router *gin.RouterGroup, handler *TribeHandler, authMiddleware gin.HandlerFunc, optionalAuthMiddleware gin.HandlerFunc) {
+// RegisterTribesRoutes registers all tribe-related routes. groupPotsMiddleware gates joining a prize pool.
+func RegisterTribesRoutes(router *gin.RouterGroup, handler *TribeHandler, authMiddleware gin.HandlerFunc, optionalAuthMiddleware gin.HandlerFunc, groupPotsMiddleware gin.HandlerFunc) {
 	// Public routes (browsing tribes) - use optional auth to detect logged-in users
 	publicTribes := router.Group("/tribes")
 	publicTribes.Use(optionalAuthMiddleware)
@@ -233,6 +239,13 @@ func RegisterTribesRoutes(router *gin.RouterGroup, handler *TribeHandler, authMi
 		tribes.POST("/:id/leave", handler.LeaveTribe)
 		tribes.GET("/:id/members", handler.GetTribeMembers)
 		tribes.GET("/:id/stats", handler.GetTribeStats)
+
+		if handler.poolService != nil {
+			tribes.GET("/:id/pools", handler.ListPools)
+			tribes.POST("/:id/pools", handler.OpenPool)
+			tribes.GET("/:id/pools/:month", handler.GetPoolStandings)
+			tribes.POST("/:id/pools/:month/join", groupPotsMiddleware, handler.JoinPool)
+		}
 	}
 
 	// User's tribes
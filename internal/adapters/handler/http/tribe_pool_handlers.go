@@ -0,0 +1,130 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"fastinghero/internal/core/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// poolMonthLayout is how pool months appear in URLs and requests, e.g. 2026-11
+const poolMonthLayout = "2006-01"
+
+// ListPools handles GET /api/v1/tribes/:id/pools
+func (h *TribeHandler) ListPools(c *gin.Context) {
+	pools, err := h.poolService.ListPools(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tribe not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pools": pools})
+}
+
+// OpenPool handles POST /api/v1/tribes/:id/pools. The month defaults to next month.
+func (h *TribeHandler) OpenPool(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID).String()
+
+	var req struct {
+		Month string `json:"month"` // YYYY-MM
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	month := domain.PoolMonth(time.Now()).AddDate(0, 1, 0)
+	if req.Month != "" {
+		parsed, err := time.Parse(poolMonthLayout, req.Month)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "month must be YYYY-MM"})
+			return
+		}
+		month = parsed
+	}
+
+	pool, err := h.poolService.OpenPool(c.Request.Context(), c.Param("id"), userID, month)
+	if err != nil {
+		abortWithPoolError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, pool)
+}
+
+// GetPoolStandings handles GET /api/v1/tribes/:id/pools/:month (YYYY-MM or "current")
+func (h *TribeHandler) GetPoolStandings(c *gin.Context) {
+	month, ok := poolMonthParam(c)
+	if !ok {
+		return
+	}
+
+	standings, err := h.poolService.GetStandings(c.Request.Context(), c.Param("id"), month)
+	if err != nil {
+		abortWithPoolError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, standings)
+}
+
+// JoinPool handles POST /api/v1/tribes/:id/pools/:month/join
+func (h *TribeHandler) JoinPool(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID).String()
+
+	month, ok := poolMonthParam(c)
+	if !ok {
+		return
+	}
+
+	participant, err := h.poolService.JoinPool(c.Request.Context(), c.Param("id"), userID, month)
+	if err != nil {
+		abortWithPoolError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, participant)
+}
+
+func poolMonthParam(c *gin.Context) (time.Time, bool) {
+	param := c.Param("month")
+	if param == "current" {
+		return time.Now(), true
+	}
+	month, err := time.Parse(poolMonthLayout, param)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month must be YYYY-MM or current"})
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+func abortWithPoolError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrCapabilityRequired):
+		abortWithEntitlementError(c, err)
+	case errors.Is(err, domain.ErrTribePoolNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrNotTribeMember), errors.Is(err, domain.ErrTribePoolNotPermitted):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTribePoolExists), errors.Is(err, domain.ErrAlreadyInTribePool), errors.Is(err, domain.ErrTribePoolNotOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrStakeNotCharged):
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
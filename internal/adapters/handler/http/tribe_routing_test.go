@@ -101,7 +101,12 @@ func TestRegisterTribesRoutes_JoinTribe(t *testing.T) {
 		c.Next()
 	}
 
-	RegisterTribesRoutes(api, handler, authMiddleware, optionalAuthMiddleware)
+	// Group pots gate (pools aren't enabled in this test)
+	groupPotsMiddleware := func(c *gin.Context) {
+		c.Next()
+	}
+
+	RegisterTribesRoutes(api, handler, authMiddleware, optionalAuthMiddleware, groupPotsMiddleware)
 
 	// Test Case: Join Tribe
 	t.Run("Join Tribe Endpoint Exists", func(t *testing.T) {
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DownloadVaultStatement handles GET /api/v1/vault/statement/document?month=YYYY-MM&format=pdf|html
+// Defaults to the current month as a PDF.
+func (h *Handler) DownloadVaultStatement(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	from, ok := statementMonth(c)
+	if !ok {
+		return
+	}
+	format, ok := documentFormat(c)
+	if !ok {
+		return
+	}
+
+	doc, err := h.vaultDocumentService.RenderStatement(c.Request.Context(), userID, from, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sendDocument(c, doc)
+}
+
+// ListVaultPayments handles GET /api/v1/vault/payments?month=YYYY-MM
+// Lists the month's card payments and refunds; each ID has a receipt. Defaults to the current month.
+func (h *Handler) ListVaultPayments(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	from, ok := statementMonth(c)
+	if !ok {
+		return
+	}
+
+	payments, err := h.vaultDocumentService.ListPayments(c.Request.Context(), userID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"payments": payments})
+}
+
+// DownloadReceipt handles GET /api/v1/vault/receipts/:id?format=pdf|html
+func (h *Handler) DownloadReceipt(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		return
+	}
+	format, ok := documentFormat(c)
+	if !ok {
+		return
+	}
+
+	doc, err := h.vaultDocumentService.RenderReceipt(c.Request.Context(), userID, paymentID, format)
+	if err != nil {
+		if errors.Is(err, domain.ErrPaymentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sendDocument(c, doc)
+}
+
+// statementMonth reads ?month=YYYY-MM, defaulting to the current UTC month. It writes the
+// error response and returns false if the month is malformed.
+func statementMonth(c *gin.Context) (time.Time, bool) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if month := c.Query("month"); month != "" {
+		parsed, err := time.Parse("2006-01", month)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "month must be in YYYY-MM format"})
+			return time.Time{}, false
+		}
+		from = parsed
+	}
+	return from, true
+}
+
+// documentFormat reads ?format=, defaulting to PDF
+func documentFormat(c *gin.Context) (domain.DocumentFormat, bool) {
+	format := domain.DocumentFormat(c.DefaultQuery("format", string(domain.DocumentFormatPDF)))
+	if !format.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be pdf or html"})
+		return "", false
+	}
+	return format, true
+}
+
+// sendDocument serves a rendered document as a download named after its document ID
+func sendDocument(c *gin.Context, doc *domain.RenderedDocument) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, doc.Filename))
+	c.Header("X-Document-ID", doc.ID)
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, doc.ContentType, doc.Body)
+}
@@ -0,0 +1,689 @@
+package payment
+
+import (
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+// FakeOperation names a gateway call whose next invocation can be scripted to fail
+type FakeOperation string
+
+const (
+	FakeOpCreateCustomer        FakeOperation = "create_customer"
+	FakeOpCreateCheckoutSession FakeOperation = "create_checkout_session"
+	FakeOpCreatePortalSession   FakeOperation = "create_portal_session"
+	FakeOpCreatePayout          FakeOperation = "create_payout"
+	FakeOpCreateRefund          FakeOperation = "create_refund"
+	FakeOpChargeCustomer        FakeOperation = "charge_customer"
+	FakeOpPaymentFingerprint    FakeOperation = "payment_method_fingerprint"
+	FakeOpCreateCoupon          FakeOperation = "create_coupon"
+)
+
+// defaultFakePriceAmount is what a price costs unless SetPriceAmount says otherwise (Tier 1 monthly)
+const defaultFakePriceAmount = 30.00
+
+var (
+	ErrFakeCardDeclined     = errors.New("your card was declined")
+	ErrFakeNotFound         = errors.New("fake payment object not found")
+	ErrFakeSessionCompleted = errors.New("checkout session already completed")
+	ErrFakeCouponInvalid    = errors.New("coupon is expired or fully redeemed")
+)
+
+type FakeCustomer struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+type FakeCheckoutSession struct {
+	domain.CheckoutSessionRequest
+	ID             string `json:"id"`
+	SubscriptionID string `json:"subscription_id,omitempty"`
+}
+
+type FakeCoupon struct {
+	domain.CouponRequest
+	ID            string `json:"id"`
+	TimesRedeemed int    `json:"times_redeemed"`
+}
+
+type FakeSubscription struct {
+	ID                 string                    `json:"id"`
+	CustomerID         string                    `json:"customer_id"`
+	PriceID            string                    `json:"price_id"`
+	CouponID           string                    `json:"coupon_id,omitempty"`
+	PeriodsBilled      int                       `json:"periods_billed"`
+	Status             domain.SubscriptionStatus `json:"status"`
+	Metadata           map[string]string         `json:"metadata"`
+	CurrentPeriodStart time.Time                 `json:"current_period_start"`
+	CurrentPeriodEnd   time.Time                 `json:"current_period_end"`
+	TrialEnd           *time.Time                `json:"trial_end,omitempty"`
+	CancelAtPeriodEnd  bool                      `json:"cancel_at_period_end"`
+}
+
+type FakeCharge struct {
+	ID             string `json:"id"`
+	CustomerID     string `json:"customer_id"`
+	SubscriptionID string `json:"subscription_id"`
+	InvoiceID      string `json:"invoice_id"`
+	Description    string `json:"description,omitempty"`
+	Amount         int64  `json:"amount"` // Cents
+	AmountRefunded int64  `json:"amount_refunded"`
+	CreatedAt      time.Time
+}
+
+type FakePayout struct {
+	ID          string  `json:"id"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Destination string  `json:"destination"`
+}
+
+type FakeRefund struct {
+	ID             string  `json:"id"`
+	ChargeID       string  `json:"charge_id"`
+	CustomerID     string  `json:"customer_id"`
+	Amount         float64 `json:"amount"`
+	IdempotencyKey string  `json:"idempotency_key"`
+}
+
+// FakeState is a snapshot of everything the fake gateway holds
+type FakeState struct {
+	Customers     []FakeCustomer        `json:"customers"`
+	Sessions      []FakeCheckoutSession `json:"checkout_sessions"`
+	Subscriptions []FakeSubscription    `json:"subscriptions"`
+	Coupons       []FakeCoupon          `json:"coupons"`
+	Charges       []FakeCharge          `json:"charges"`
+	Payouts       []FakePayout          `json:"payouts"`
+	Refunds       []FakeRefund          `json:"refunds"`
+	DeclineCards  bool                  `json:"decline_cards"`
+}
+
+// FakeGateway is an in-process ports.PaymentGateway for developing and testing billing
+// without Stripe. State lives in memory; lifecycle changes are delivered as signed webhooks
+// through a WebhookSimulator, so the real webhook handler drives activation.
+type FakeGateway struct {
+	webhookSecret string
+	events        *WebhookSimulator // nil: state changes aren't delivered
+	checkoutURL   string            // Base URL of the simulated hosted checkout
+
+	customers     map[string]*FakeCustomer
+	sessions      map[string]*FakeCheckoutSession
+	subscriptions map[string]*FakeSubscription
+	charges       []*FakeCharge
+	payouts       []FakePayout
+	refunds       []FakeRefund
+	refundKeys    map[string]string // Idempotency key -> refund ID
+	chargeKeys    map[string]string // Idempotency key -> charge ID
+	cards         map[string]string // Payment method ID -> card fingerprint
+	coupons       map[string]*FakeCoupon
+	priceAmounts  map[string]float64
+	failures      map[FakeOperation]error
+	declineCards  bool
+	seq           int
+	mu            sync.Mutex
+}
+
+// Ensure FakeGateway implements PaymentGateway
+var _ ports.PaymentGateway = (*FakeGateway)(nil)
+
+func NewFakeGateway(webhookSecret string, events *WebhookSimulator, checkoutURL string) *FakeGateway {
+	return &FakeGateway{
+		webhookSecret: webhookSecret,
+		events:        events,
+		checkoutURL:   checkoutURL,
+		customers:     make(map[string]*FakeCustomer),
+		sessions:      make(map[string]*FakeCheckoutSession),
+		subscriptions: make(map[string]*FakeSubscription),
+		refundKeys:    make(map[string]string),
+		chargeKeys:    make(map[string]string),
+		cards:         make(map[string]string),
+		coupons:       make(map[string]*FakeCoupon),
+		priceAmounts:  make(map[string]float64),
+		failures:      make(map[FakeOperation]error),
+	}
+}
+
+// --- Scripting ---
+
+// FailNext makes the next call of op return err
+func (g *FakeGateway) FailNext(op FakeOperation, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failures[op] = err
+}
+
+// DeclineCards makes checkouts and renewals fail as if the card was declined
+func (g *FakeGateway) DeclineCards(decline bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.declineCards = decline
+}
+
+// SetPriceAmount sets what a price charges per period
+func (g *FakeGateway) SetPriceAmount(priceID string, amount float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.priceAmounts[priceID] = amount
+}
+
+// AddCard creates a payment method for a card and returns its ID. Adding the same
+// fingerprint twice gives two payment methods for one card, like a card reused across accounts.
+func (g *FakeGateway) AddCard(fingerprint string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id := g.nextID("pm")
+	g.cards[id] = fingerprint
+	return id
+}
+
+// --- ports.PaymentGateway ---
+
+func (g *FakeGateway) CreateCustomer(email, name string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.takeFailure(FakeOpCreateCustomer); err != nil {
+		return "", err
+	}
+	c := &FakeCustomer{ID: g.nextID("cus"), Email: email, Name: name}
+	g.customers[c.ID] = c
+	return c.ID, nil
+}
+
+func (g *FakeGateway) CreateCheckoutSession(req domain.CheckoutSessionRequest) (*domain.CheckoutSession, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.takeFailure(FakeOpCreateCheckoutSession); err != nil {
+		return nil, err
+	}
+	if _, ok := g.customers[req.CustomerID]; !ok {
+		return nil, fmt.Errorf("%w: customer %s", ErrFakeNotFound, req.CustomerID)
+	}
+	if req.CouponID != "" {
+		c, ok := g.coupons[req.CouponID]
+		if !ok {
+			return nil, fmt.Errorf("%w: coupon %s", ErrFakeNotFound, req.CouponID)
+		}
+		if !c.redeemable(time.Now()) {
+			return nil, ErrFakeCouponInvalid
+		}
+	}
+	s := &FakeCheckoutSession{CheckoutSessionRequest: req, ID: g.nextID("cs")}
+	g.sessions[s.ID] = s
+	return &domain.CheckoutSession{ID: s.ID, URL: g.checkoutURL + "/checkout/" + s.ID}, nil
+}
+
+// CreatePortalSession has no hosted portal to show; it sends the user straight back.
+// Use UpdateSubscriptionStatus to simulate what the user would do there.
+func (g *FakeGateway) CreatePortalSession(customerID, returnURL string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.takeFailure(FakeOpCreatePortalSession); err != nil {
+		return "", err
+	}
+	if _, ok := g.customers[customerID]; !ok {
+		return "", fmt.Errorf("%w: customer %s", ErrFakeNotFound, customerID)
+	}
+	u, err := url.Parse(returnURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("fake_portal", customerID)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (g *FakeGateway) CreatePayout(amount float64, currency, destination string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.takeFailure(FakeOpCreatePayout); err != nil {
+		return "", err
+	}
+	p := FakePayout{ID: g.nextID("po"), Amount: amount, Currency: currency, Destination: destination}
+	g.payouts = append(g.payouts, p)
+	return p.ID, nil
+}
+
+func (g *FakeGateway) PaymentMethodFingerprint(paymentMethodID string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.takeFailure(FakeOpPaymentFingerprint); err != nil {
+		return "", err
+	}
+	fingerprint, ok := g.cards[paymentMethodID]
+	if !ok {
+		return "", fmt.Errorf("%w: payment method %s", ErrFakeNotFound, paymentMethodID)
+	}
+	return fingerprint, nil
+}
+
+func (g *FakeGateway) CreateCoupon(req domain.CouponRequest) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.takeFailure(FakeOpCreateCoupon); err != nil {
+		return "", err
+	}
+	c := &FakeCoupon{CouponRequest: req, ID: g.nextID("coupon")}
+	g.coupons[c.ID] = c
+	return c.ID, nil
+}
+
+func (g *FakeGateway) ConstructEvent(payload []byte, header string) (interface{}, error) {
+	event, err := webhook.ConstructEvent(payload, header, g.webhookSecret)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (g *FakeGateway) CreateRefund(chargeID string, amount float64, idempotencyKey string) (string, float64, error) {
+	g.mu.Lock()
+	if err := g.takeFailure(FakeOpCreateRefund); err != nil {
+		g.mu.Unlock()
+		return "", 0, err
+	}
+	if refundID, ok := g.refundKeys[idempotencyKey]; ok && idempotencyKey != "" {
+		for _, r := range g.refunds {
+			if r.ID == refundID {
+				g.mu.Unlock()
+				return r.ID, r.Amount, nil
+			}
+		}
+	}
+
+	var target *FakeCharge
+	for _, c := range g.charges {
+		if c.ID == chargeID {
+			target = c
+			break
+		}
+	}
+	if target == nil {
+		g.mu.Unlock()
+		return "", 0, fmt.Errorf("%w: charge %s", ErrFakeNotFound, chargeID)
+	}
+	// Same rule as the Stripe adapter: capped at what the charge has left
+	cents := int64(math.Round(amount * 100))
+	if refundable := target.Amount - target.AmountRefunded; cents > refundable {
+		cents = refundable
+	}
+	if cents <= 0 {
+		g.mu.Unlock()
+		return "", 0, fmt.Errorf("charge %s has nothing left to refund", chargeID)
+	}
+	target.AmountRefunded += cents
+	r := FakeRefund{ID: g.nextID("re"), ChargeID: target.ID, CustomerID: target.CustomerID, Amount: float64(cents) / 100, IdempotencyKey: idempotencyKey}
+	g.refunds = append(g.refunds, r)
+	if idempotencyKey != "" {
+		g.refundKeys[idempotencyKey] = r.ID
+	}
+	chargeObj := g.chargeObject(target)
+	g.mu.Unlock()
+
+	if err := g.emit("charge.refunded", chargeObj); err != nil {
+		return "", 0, err
+	}
+	return r.ID, r.Amount, nil
+}
+
+// ChargeCustomer charges the customer's card on file. With DeclineCards set the charge fails
+// with ErrFakeCardDeclined.
+func (g *FakeGateway) ChargeCustomer(customerID string, amount float64, description, idempotencyKey string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.takeFailure(FakeOpChargeCustomer); err != nil {
+		return "", err
+	}
+	if chargeID, ok := g.chargeKeys[idempotencyKey]; ok && idempotencyKey != "" {
+		return chargeID, nil
+	}
+	if _, ok := g.customers[customerID]; !ok {
+		return "", fmt.Errorf("%w: customer %s", ErrFakeNotFound, customerID)
+	}
+	if g.declineCards {
+		return "", ErrFakeCardDeclined
+	}
+	c := &FakeCharge{
+		ID:          g.nextID("ch"),
+		CustomerID:  customerID,
+		Description: description,
+		Amount:      int64(math.Round(amount * 100)),
+		CreatedAt:   time.Now(),
+	}
+	g.charges = append(g.charges, c)
+	if idempotencyKey != "" {
+		g.chargeKeys[idempotencyKey] = c.ID
+	}
+	return c.ID, nil
+}
+
+// --- Simulated customer actions ---
+
+// CompleteCheckout pays for a checkout session as the customer would on the hosted page.
+// It returns the session's success URL. With DeclineCards set, nothing happens and
+// ErrFakeCardDeclined is returned, like a declined card on Stripe's page.
+func (g *FakeGateway) CompleteCheckout(sessionID string) (string, error) {
+	g.mu.Lock()
+	s, ok := g.sessions[sessionID]
+	if !ok {
+		g.mu.Unlock()
+		return "", fmt.Errorf("%w: checkout session %s", ErrFakeNotFound, sessionID)
+	}
+	if s.SubscriptionID != "" {
+		g.mu.Unlock()
+		return "", ErrFakeSessionCompleted
+	}
+	if g.declineCards {
+		g.mu.Unlock()
+		return "", ErrFakeCardDeclined
+	}
+
+	now := time.Now()
+	sub := &FakeSubscription{
+		ID:                 g.nextID("sub"),
+		CustomerID:         s.CustomerID,
+		PriceID:            s.PriceID,
+		CouponID:           s.CouponID,
+		Status:             domain.SubStatusActive,
+		Metadata:           s.Metadata,
+		CurrentPeriodStart: now,
+		CurrentPeriodEnd:   now.AddDate(0, 1, 0),
+	}
+	paymentStatus := "paid"
+	if s.TrialEnd != nil {
+		// Nothing is charged until the trial ends; RenewSubscription starts the first paid period
+		trialEnd := *s.TrialEnd
+		sub.Status = domain.SubStatusTrialing
+		sub.TrialEnd = &trialEnd
+		sub.CurrentPeriodEnd = trialEnd
+		paymentStatus = "no_payment_required"
+	}
+	g.subscriptions[sub.ID] = sub
+	s.SubscriptionID = sub.ID
+	if c, ok := g.coupons[s.CouponID]; ok {
+		c.TimesRedeemed++
+	}
+
+	sessionObj := map[string]interface{}{
+		"id":                  s.ID,
+		"object":              "checkout.session",
+		"mode":                "subscription",
+		"payment_status":      paymentStatus,
+		"status":              "complete",
+		"client_reference_id": s.ClientReferenceID,
+		"customer":            s.CustomerID,
+		"subscription":        sub.ID,
+		"metadata":            s.Metadata,
+	}
+	events := []fakeEvent{
+		{"checkout.session.completed", sessionObj},
+		{"customer.subscription.created", g.subscriptionObject(sub)},
+	}
+	if sub.Status == domain.SubStatusActive {
+		events = append(events, fakeEvent{"invoice.paid", g.invoiceObject(g.charge(sub))})
+	}
+	successURL := s.SuccessURL
+	g.mu.Unlock()
+
+	if err := g.emitAll(events...); err != nil {
+		return "", err
+	}
+	return successURL, nil
+}
+
+// RenewSubscription starts the next billing period and charges for it. A declined card
+// moves the subscription to past_due; a successful charge brings a past_due one back to active.
+func (g *FakeGateway) RenewSubscription(subscriptionID string) error {
+	g.mu.Lock()
+	sub, ok := g.subscriptions[subscriptionID]
+	if !ok {
+		g.mu.Unlock()
+		return fmt.Errorf("%w: subscription %s", ErrFakeNotFound, subscriptionID)
+	}
+
+	var events []fakeEvent
+	if g.declineCards {
+		invoice := map[string]interface{}{
+			"id":           g.nextID("in"),
+			"object":       "invoice",
+			"customer":     sub.CustomerID,
+			"subscription": sub.ID,
+			"amount_paid":  0,
+			"paid":         false,
+		}
+		events = append(events, fakeEvent{"invoice.payment_failed", invoice})
+		if sub.Status != domain.SubStatusPastDue {
+			sub.Status = domain.SubStatusPastDue
+			events = append(events, fakeEvent{"customer.subscription.updated", g.subscriptionObject(sub)})
+		}
+	} else {
+		sub.CurrentPeriodStart = sub.CurrentPeriodEnd
+		sub.CurrentPeriodEnd = sub.CurrentPeriodEnd.AddDate(0, 1, 0)
+		wasActive := sub.Status == domain.SubStatusActive
+		sub.Status = domain.SubStatusActive
+		events = append(events, fakeEvent{"invoice.paid", g.invoiceObject(g.charge(sub))})
+		if !wasActive {
+			events = append(events, fakeEvent{"customer.subscription.updated", g.subscriptionObject(sub)})
+		}
+	}
+	g.mu.Unlock()
+
+	return g.emitAll(events...)
+}
+
+// UpdateSubscriptionStatus forces a status, e.g. unpaid or canceled from the billing portal
+func (g *FakeGateway) UpdateSubscriptionStatus(subscriptionID string, status domain.SubscriptionStatus) error {
+	g.mu.Lock()
+	sub, ok := g.subscriptions[subscriptionID]
+	if !ok {
+		g.mu.Unlock()
+		return fmt.Errorf("%w: subscription %s", ErrFakeNotFound, subscriptionID)
+	}
+	sub.Status = status
+	eventType := "customer.subscription.updated"
+	if status == domain.SubStatusCanceled {
+		eventType = "customer.subscription.deleted"
+	}
+	obj := g.subscriptionObject(sub)
+	g.mu.Unlock()
+
+	return g.emit(eventType, obj)
+}
+
+// TrialWillEnd sends the reminder Stripe sends three days before a trial ends
+func (g *FakeGateway) TrialWillEnd(subscriptionID string) error {
+	g.mu.Lock()
+	sub, ok := g.subscriptions[subscriptionID]
+	if !ok {
+		g.mu.Unlock()
+		return fmt.Errorf("%w: subscription %s", ErrFakeNotFound, subscriptionID)
+	}
+	if sub.TrialEnd == nil {
+		trialEnd := time.Now().AddDate(0, 0, 3)
+		sub.TrialEnd = &trialEnd
+	}
+	obj := g.subscriptionObject(sub)
+	g.mu.Unlock()
+
+	return g.emit("customer.subscription.trial_will_end", obj)
+}
+
+// State returns a snapshot of the fake's data, sorted by ID
+func (g *FakeGateway) State() FakeState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	state := FakeState{
+		Customers:     []FakeCustomer{},
+		Sessions:      []FakeCheckoutSession{},
+		Subscriptions: []FakeSubscription{},
+		Coupons:       []FakeCoupon{},
+		Charges:       []FakeCharge{},
+		Payouts:       append([]FakePayout{}, g.payouts...),
+		Refunds:       append([]FakeRefund{}, g.refunds...),
+		DeclineCards:  g.declineCards,
+	}
+	for _, c := range g.customers {
+		state.Customers = append(state.Customers, *c)
+	}
+	for _, s := range g.sessions {
+		state.Sessions = append(state.Sessions, *s)
+	}
+	for _, s := range g.subscriptions {
+		state.Subscriptions = append(state.Subscriptions, *s)
+	}
+	for _, c := range g.charges {
+		state.Charges = append(state.Charges, *c)
+	}
+	for _, c := range g.coupons {
+		state.Coupons = append(state.Coupons, *c)
+	}
+	sort.Slice(state.Customers, func(i, j int) bool { return state.Customers[i].ID < state.Customers[j].ID })
+	sort.Slice(state.Sessions, func(i, j int) bool { return state.Sessions[i].ID < state.Sessions[j].ID })
+	sort.Slice(state.Subscriptions, func(i, j int) bool { return state.Subscriptions[i].ID < state.Subscriptions[j].ID })
+	sort.Slice(state.Coupons, func(i, j int) bool { return state.Coupons[i].ID < state.Coupons[j].ID })
+	return state
+}
+
+// --- Internals (callers hold g.mu) ---
+
+type fakeEvent struct {
+	eventType string
+	object    interface{}
+}
+
+func (g *FakeGateway) takeFailure(op FakeOperation) error {
+	err, ok := g.failures[op]
+	if !ok {
+		return nil
+	}
+	delete(g.failures, op)
+	return err
+}
+
+func (g *FakeGateway) nextID(prefix string) string {
+	g.seq++
+	return fmt.Sprintf("%s_fake_%d", prefix, g.seq)
+}
+
+func (g *FakeGateway) charge(sub *FakeSubscription) *FakeCharge {
+	amount, ok := g.priceAmounts[sub.PriceID]
+	if !ok {
+		amount = defaultFakePriceAmount
+	}
+	cents := int64(math.Round(amount * 100))
+	if coupon, ok := g.coupons[sub.CouponID]; ok && coupon.appliesToPeriod(sub.PeriodsBilled) {
+		cents = coupon.discount(cents)
+	}
+	sub.PeriodsBilled++
+	c := &FakeCharge{
+		ID:             g.nextID("ch"),
+		CustomerID:     sub.CustomerID,
+		SubscriptionID: sub.ID,
+		InvoiceID:      g.nextID("in"),
+		Amount:         cents,
+		CreatedAt:      time.Now(),
+	}
+	g.charges = append(g.charges, c)
+	return c
+}
+
+func (c *FakeCoupon) redeemable(now time.Time) bool {
+	if c.RedeemBy != nil && !now.Before(*c.RedeemBy) {
+		return false
+	}
+	return c.MaxRedemptions == 0 || c.TimesRedeemed < c.MaxRedemptions
+}
+
+// appliesToPeriod reports whether the discount covers a subscription's billed-th paid period (from 0)
+func (c *FakeCoupon) appliesToPeriod(billed int) bool {
+	switch c.Duration {
+	case domain.PromoDurationOnce:
+		return billed == 0
+	case domain.PromoDurationRepeating:
+		return billed < c.DurationMonths
+	}
+	return true
+}
+
+func (c *FakeCoupon) discount(cents int64) int64 {
+	if c.AmountOff > 0 {
+		cents -= int64(math.Round(c.AmountOff * 100))
+	} else {
+		cents -= int64(math.Round(float64(cents) * c.PercentOff / 100))
+	}
+	if cents < 0 {
+		return 0
+	}
+	return cents
+}
+
+func (g *FakeGateway) subscriptionObject(sub *FakeSubscription) map[string]interface{} {
+	obj := map[string]interface{}{
+		"id":                   sub.ID,
+		"object":               "subscription",
+		"customer":             sub.CustomerID,
+		"status":               string(sub.Status),
+		"metadata":             sub.Metadata,
+		"current_period_start": sub.CurrentPeriodStart.Unix(),
+		"current_period_end":   sub.CurrentPeriodEnd.Unix(),
+		"cancel_at_period_end": sub.CancelAtPeriodEnd,
+	}
+	if sub.TrialEnd != nil {
+		obj["trial_end"] = sub.TrialEnd.Unix()
+	}
+	return obj
+}
+
+func (g *FakeGateway) invoiceObject(c *FakeCharge) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           c.InvoiceID,
+		"object":       "invoice",
+		"customer":     c.CustomerID,
+		"subscription": c.SubscriptionID,
+		"charge":       c.ID,
+		"amount_paid":  c.Amount,
+		"paid":         true,
+	}
+}
+
+func (g *FakeGateway) chargeObject(c *FakeCharge) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              c.ID,
+		"object":          "charge",
+		"customer":        c.CustomerID,
+		"invoice":         c.InvoiceID,
+		"amount":          c.Amount,
+		"amount_refunded": c.AmountRefunded,
+		"refunded":        c.AmountRefunded >= c.Amount,
+		"paid":            true,
+	}
+}
+
+// --- Delivery (callers must not hold g.mu: the webhook handler runs synchronously) ---
+
+func (g *FakeGateway) emit(eventType string, object interface{}) error {
+	if g.events == nil {
+		return nil
+	}
+	_, err := g.events.Send(eventType, object)
+	return err
+}
+
+func (g *FakeGateway) emitAll(events ...fakeEvent) error {
+	for _, e := range events {
+		if err := g.emit(e.eventType, e.object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
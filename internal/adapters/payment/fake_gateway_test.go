@@ -0,0 +1,253 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/adapters/repository/memory"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/services"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// newFakeBilling wires the fake gateway to a real StripeService over memory repositories,
+// with the simulator posting webhooks to an httptest server in front of HandleWebhook
+func newFakeBilling(t *testing.T) (*FakeGateway, *services.StripeService, *memory.UserRepository, *memory.SubscriptionRepository) {
+	gateway, stripeService, userRepo, subRepo, _ := newFakeBillingWithPromos(t)
+	return gateway, stripeService, userRepo, subRepo
+}
+
+func newFakeBillingWithPromos(t *testing.T) (*FakeGateway, *services.StripeService, *memory.UserRepository, *memory.SubscriptionRepository, *services.PromoCodeService) {
+	userRepo := memory.NewUserRepository()
+	subRepo := memory.NewSubscriptionRepository()
+
+	var stripeService *services.StripeService
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		if err := stripeService.HandleWebhook(r.Context(), payload, r.Header.Get("Stripe-Signature")); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	gateway := NewFakeGateway(testWebhookSecret, NewWebhookSimulator(server.URL, testWebhookSecret), "http://localhost")
+	promoService := services.NewPromoCodeService(memory.NewPromoCodeRepository(), gateway)
+	stripeService = services.NewStripeService(
+		gateway,
+		subRepo,
+		userRepo,
+		memory.NewWebhookEventRepository(),
+		services.NewNoOpNotificationService(),
+		services.BillingConfig{
+			Prices:     domain.PriceCatalog{domain.TierVault: "price_vault"},
+			SuccessURL: "http://localhost/success",
+			CancelURL:  "http://localhost/cancel",
+		},
+		promoService,
+		nil,
+		memory.NewPaymentRepository(),
+	)
+	return gateway, stripeService, userRepo, subRepo, promoService
+}
+
+func newFakeBillingUser(t *testing.T, userRepo *memory.UserRepository) *domain.User {
+	user := &domain.User{
+		ID:                 uuid.New(),
+		Email:              "faker@example.com",
+		Name:               "Faker",
+		SubscriptionTier:   domain.TierFree,
+		SubscriptionStatus: domain.SubStatusActive,
+	}
+	assert.NoError(t, userRepo.Save(context.Background(), user))
+	return user
+}
+
+func TestFakeGateway_CheckoutActivatesSubscription(t *testing.T) {
+	ctx := context.Background()
+	gateway, stripeService, userRepo, subRepo := newFakeBilling(t)
+	user := newFakeBillingUser(t, userRepo)
+
+	session, err := stripeService.CreateCheckoutSession(ctx, user.ID, domain.TierVault, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost/checkout/"+session.ID, session.URL)
+
+	redirect, err := gateway.CompleteCheckout(session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost/success", redirect)
+
+	saved, _ := userRepo.FindByID(ctx, user.ID)
+	assert.Equal(t, domain.TierVault, saved.SubscriptionTier)
+	assert.Equal(t, domain.SubStatusActive, saved.SubscriptionStatus)
+	assert.True(t, saved.IsVaultMember())
+
+	sub, err := subRepo.FindByUserID(ctx, user.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, sub) {
+		assert.Equal(t, domain.SubStatusActive, sub.Status)
+	}
+
+	_, err = gateway.CompleteCheckout(session.ID)
+	assert.ErrorIs(t, err, ErrFakeSessionCompleted)
+}
+
+func TestFakeGateway_PromoCodeDiscountsCheckout(t *testing.T) {
+	ctx := context.Background()
+	gateway, stripeService, userRepo, _, promoService := newFakeBillingWithPromos(t)
+	user := newFakeBillingUser(t, userRepo)
+
+	_, err := promoService.CreatePromoCode(ctx, uuid.New(), &domain.PromoCode{
+		Code:           "launch50",
+		DiscountType:   domain.PromoDiscountPercent,
+		PercentOff:     50,
+		Duration:       domain.PromoDurationRepeating,
+		DurationMonths: 2,
+		MaxRedemptions: 100,
+	})
+	assert.NoError(t, err)
+
+	session, err := stripeService.CreateCheckoutSession(ctx, user.ID, domain.TierVault, "LAUNCH50")
+	assert.NoError(t, err)
+	_, err = gateway.CompleteCheckout(session.ID)
+	assert.NoError(t, err)
+
+	subID := gateway.State().Subscriptions[0].ID
+	assert.NoError(t, gateway.RenewSubscription(subID))
+	assert.NoError(t, gateway.RenewSubscription(subID))
+	charges := gateway.State().Charges
+	if assert.Len(t, charges, 3) {
+		assert.Equal(t, int64(1500), charges[0].Amount)
+		assert.Equal(t, int64(1500), charges[1].Amount)
+		assert.Equal(t, int64(3000), charges[2].Amount) // Two discounted months, then full price
+	}
+
+	redemptions, err := promoService.ListRedemptions(ctx, "launch50")
+	assert.NoError(t, err)
+	if assert.Len(t, redemptions, 1) {
+		assert.Equal(t, user.ID, redemptions[0].UserID)
+		assert.Equal(t, subID, redemptions[0].SubscriptionID)
+	}
+	promo, _ := promoService.GetPromoCode(ctx, "LAUNCH50")
+	assert.Equal(t, 1, promo.TimesRedeemed)
+
+	// One redemption per user
+	_, err = promoService.CheckPromoCode(ctx, user.ID, "LAUNCH50", domain.TierVault)
+	assert.ErrorIs(t, err, domain.ErrPromoCodeAlreadyRedeemed)
+}
+
+func TestFakeGateway_DeclinedRenewalGoesPastDueThenRecovers(t *testing.T) {
+	ctx := context.Background()
+	gateway, stripeService, userRepo, _ := newFakeBilling(t)
+	user := newFakeBillingUser(t, userRepo)
+
+	session, _ := stripeService.CreateCheckoutSession(ctx, user.ID, domain.TierVault, "")
+	_, err := gateway.CompleteCheckout(session.ID)
+	assert.NoError(t, err)
+	subID := gateway.State().Subscriptions[0].ID
+
+	gateway.DeclineCards(true)
+	assert.NoError(t, gateway.RenewSubscription(subID))
+	saved, _ := userRepo.FindByID(ctx, user.ID)
+	assert.Equal(t, domain.SubStatusPastDue, saved.SubscriptionStatus)
+
+	gateway.DeclineCards(false)
+	assert.NoError(t, gateway.RenewSubscription(subID))
+	saved, _ = userRepo.FindByID(ctx, user.ID)
+	assert.Equal(t, domain.SubStatusActive, saved.SubscriptionStatus)
+
+	assert.NoError(t, gateway.UpdateSubscriptionStatus(subID, domain.SubStatusCanceled))
+	saved, _ = userRepo.FindByID(ctx, user.ID)
+	assert.Equal(t, domain.SubStatusCanceled, saved.SubscriptionStatus)
+	assert.False(t, saved.IsVaultMember())
+}
+
+func TestFakeGateway_DeclinedCheckoutLeavesUserFree(t *testing.T) {
+	ctx := context.Background()
+	gateway, stripeService, userRepo, _ := newFakeBilling(t)
+	user := newFakeBillingUser(t, userRepo)
+
+	session, _ := stripeService.CreateCheckoutSession(ctx, user.ID, domain.TierVault, "")
+	gateway.DeclineCards(true)
+	_, err := gateway.CompleteCheckout(session.ID)
+	assert.ErrorIs(t, err, ErrFakeCardDeclined)
+
+	saved, _ := userRepo.FindByID(ctx, user.ID)
+	assert.Equal(t, domain.TierFree, saved.SubscriptionTier)
+}
+
+func TestFakeGateway_RefundsAreIdempotent(t *testing.T) {
+	ctx := context.Background()
+	gateway, stripeService, userRepo, _ := newFakeBilling(t)
+	user := newFakeBillingUser(t, userRepo)
+
+	session, _ := stripeService.CreateCheckoutSession(ctx, user.ID, domain.TierVault, "")
+	_, _ = gateway.CompleteCheckout(session.ID)
+	chargeID := gateway.State().Charges[0].ID
+
+	first, amount, err := gateway.CreateRefund(chargeID, 10, "refund-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, amount)
+	second, _, err := gateway.CreateRefund(chargeID, 10, "refund-1")
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Len(t, gateway.State().Refunds, 1)
+
+	_, amount, err = gateway.CreateRefund(chargeID, 100, "refund-2")
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, amount, "capped at what the charge has left")
+	_, _, err = gateway.CreateRefund(chargeID, 5, "refund-3")
+	assert.Error(t, err)
+}
+
+func TestFakeGateway_ChargeCustomerIsIdempotent(t *testing.T) {
+	gateway := NewFakeGateway(testWebhookSecret, nil, "http://localhost")
+	customerID, _ := gateway.CreateCustomer("faker@example.com", "Faker")
+
+	first, err := gateway.ChargeCustomer(customerID, 20, "Stake", "stake-1")
+	assert.NoError(t, err)
+	second, err := gateway.ChargeCustomer(customerID, 20, "Stake", "stake-1")
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Len(t, gateway.State().Charges, 1)
+	assert.Equal(t, int64(2000), gateway.State().Charges[0].Amount)
+
+	gateway.DeclineCards(true)
+	_, err = gateway.ChargeCustomer(customerID, 20, "Stake", "stake-2")
+	assert.ErrorIs(t, err, ErrFakeCardDeclined)
+	_, err = gateway.ChargeCustomer("cus_missing", 20, "Stake", "stake-3")
+	assert.ErrorIs(t, err, ErrFakeNotFound)
+}
+
+func TestFakeGateway_FailNext(t *testing.T) {
+	gateway := NewFakeGateway(testWebhookSecret, nil, "http://localhost")
+	boom := errors.New("stripe unavailable")
+	gateway.FailNext(FakeOpCreatePayout, boom)
+
+	_, err := gateway.CreatePayout(20, "usd", "acct_1")
+	assert.ErrorIs(t, err, boom)
+
+	payoutID, err := gateway.CreatePayout(20, "usd", "acct_1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, payoutID)
+}
+
+func TestFakeGateway_ConstructEventVerifiesSignature(t *testing.T) {
+	gateway := NewFakeGateway(testWebhookSecret, nil, "http://localhost")
+
+	payload, header, err := SignedEvent("evt_1", "invoice.paid", map[string]interface{}{"id": "in_1", "object": "invoice"}, testWebhookSecret)
+	assert.NoError(t, err)
+	_, err = gateway.ConstructEvent(payload, header)
+	assert.NoError(t, err)
+
+	_, header, _ = SignedEvent("evt_1", "invoice.paid", map[string]interface{}{"id": "in_1"}, "whsec_other")
+	_, err = gateway.ConstructEvent(payload, header)
+	assert.Error(t, err)
+}
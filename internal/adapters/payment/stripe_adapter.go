@@ -1,12 +1,22 @@
 package payment
 
 import (
+	"errors"
+	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
+	"fmt"
+	"math"
 
 	"github.com/stripe/stripe-go/v74"
+	portalsession "github.com/stripe/stripe-go/v74/billingportal/session"
+	"github.com/stripe/stripe-go/v74/charge"
+	checkoutsession "github.com/stripe/stripe-go/v74/checkout/session"
+	"github.com/stripe/stripe-go/v74/coupon"
 	"github.com/stripe/stripe-go/v74/customer"
+	"github.com/stripe/stripe-go/v74/paymentintent"
+	"github.com/stripe/stripe-go/v74/paymentmethod"
 	"github.com/stripe/stripe-go/v74/payout"
-	"github.com/stripe/stripe-go/v74/subscription"
+	"github.com/stripe/stripe-go/v74/refund"
 	"github.com/stripe/stripe-go/v74/webhook"
 )
 
@@ -38,20 +48,48 @@ func (s *StripeAdapter) CreateCustomer(email, name string) (string, error) {
 	return c.ID, nil
 }
 
-func (s *StripeAdapter) CreateSubscription(customerID, priceID string) (string, error) {
-	params := &stripe.SubscriptionParams{
-		Customer: stripe.String(customerID),
-		Items: []*stripe.SubscriptionItemsParams{
+func (s *StripeAdapter) CreateCheckoutSession(req domain.CheckoutSessionRequest) (*domain.CheckoutSession, error) {
+	params := &stripe.CheckoutSessionParams{
+		Mode:              stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		Customer:          stripe.String(req.CustomerID),
+		ClientReferenceID: stripe.String(req.ClientReferenceID),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
 			{
-				Price: stripe.String(priceID),
+				Price:    stripe.String(req.PriceID),
+				Quantity: stripe.Int64(1),
 			},
 		},
+		// Copy the metadata onto the subscription so its lifecycle events carry the plan too
+		SubscriptionData: &stripe.CheckoutSessionSubscriptionDataParams{
+			Metadata: req.Metadata,
+		},
+		SuccessURL: stripe.String(req.SuccessURL),
+		CancelURL:  stripe.String(req.CancelURL),
+	}
+	if req.TrialEnd != nil {
+		params.SubscriptionData.TrialEnd = stripe.Int64(req.TrialEnd.Unix())
 	}
-	sub, err := subscription.New(params)
+	if req.CouponID != "" {
+		params.Discounts = []*stripe.CheckoutSessionDiscountParams{{Coupon: stripe.String(req.CouponID)}}
+	}
+	params.Metadata = req.Metadata
+	sess, err := checkoutsession.New(params)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.CheckoutSession{ID: sess.ID, URL: sess.URL}, nil
+}
+
+func (s *StripeAdapter) CreatePortalSession(customerID, returnURL string) (string, error) {
+	params := &stripe.BillingPortalSessionParams{
+		Customer:  stripe.String(customerID),
+		ReturnURL: stripe.String(returnURL),
+	}
+	sess, err := portalsession.New(params)
 	if err != nil {
 		return "", err
 	}
-	return sub.ID, nil
+	return sess.URL, nil
 }
 
 func (s *StripeAdapter) CreatePayout(amount float64, currency, destination string) (string, error) {
@@ -77,3 +115,100 @@ func (s *StripeAdapter) ConstructEvent(payload []byte, header string) (interface
 	}
 	return event, nil
 }
+
+func (s *StripeAdapter) CreateRefund(chargeID string, amount float64, idempotencyKey string) (string, float64, error) {
+	c, err := charge.Get(chargeID, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	if !c.Paid {
+		return "", 0, fmt.Errorf("charge %s was not paid", chargeID)
+	}
+
+	cents := int64(math.Round(amount * 100))
+	if refundable := c.Amount - c.AmountRefunded; cents > refundable {
+		// Never more than the charge has left, e.g. after a discount or a manual refund
+		cents = refundable
+	}
+	if cents <= 0 {
+		return "", 0, fmt.Errorf("charge %s has nothing left to refund", chargeID)
+	}
+
+	params := &stripe.RefundParams{
+		Charge: stripe.String(chargeID),
+		Amount: stripe.Int64(cents),
+	}
+	params.SetIdempotencyKey(idempotencyKey)
+	r, err := refund.New(params)
+	if err != nil {
+		return "", 0, err
+	}
+	return r.ID, float64(r.Amount) / 100, nil
+}
+
+func (s *StripeAdapter) ChargeCustomer(customerID string, amount float64, description, idempotencyKey string) (string, error) {
+	c, err := customer.Get(customerID, nil)
+	if err != nil {
+		return "", err
+	}
+	if c.InvoiceSettings == nil || c.InvoiceSettings.DefaultPaymentMethod == nil {
+		return "", fmt.Errorf("customer %s has no default payment method", customerID)
+	}
+
+	params := &stripe.PaymentIntentParams{
+		Amount:        stripe.Int64(int64(math.Round(amount * 100))),
+		Currency:      stripe.String(string(stripe.CurrencyUSD)),
+		Customer:      stripe.String(customerID),
+		PaymentMethod: stripe.String(c.InvoiceSettings.DefaultPaymentMethod.ID),
+		Description:   stripe.String(description),
+		Confirm:       stripe.Bool(true),
+		OffSession:    stripe.Bool(true),
+	}
+	params.SetIdempotencyKey(idempotencyKey)
+	pi, err := paymentintent.New(params)
+	if err != nil {
+		return "", err
+	}
+	if pi.Status != stripe.PaymentIntentStatusSucceeded || pi.LatestCharge == nil {
+		return "", fmt.Errorf("payment %s was not completed (%s)", pi.ID, pi.Status)
+	}
+	return pi.LatestCharge.ID, nil
+}
+
+func (s *StripeAdapter) PaymentMethodFingerprint(paymentMethodID string) (string, error) {
+	pm, err := paymentmethod.Get(paymentMethodID, nil)
+	if err != nil {
+		return "", err
+	}
+	if pm.Card == nil || pm.Card.Fingerprint == "" {
+		return "", errors.New("payment method is not a card")
+	}
+	return pm.Card.Fingerprint, nil
+}
+
+func (s *StripeAdapter) CreateCoupon(req domain.CouponRequest) (string, error) {
+	params := &stripe.CouponParams{
+		Name:     stripe.String(req.Name),
+		Duration: stripe.String(string(req.Duration)),
+	}
+	if req.AmountOff > 0 {
+		params.AmountOff = stripe.Int64(int64(math.Round(req.AmountOff * 100)))
+		params.Currency = stripe.String(string(stripe.CurrencyUSD))
+	} else {
+		params.PercentOff = stripe.Float64(req.PercentOff)
+	}
+	if req.Duration == domain.PromoDurationRepeating {
+		params.DurationInMonths = stripe.Int64(int64(req.DurationMonths))
+	}
+	if req.MaxRedemptions > 0 {
+		params.MaxRedemptions = stripe.Int64(int64(req.MaxRedemptions))
+	}
+	if req.RedeemBy != nil {
+		params.RedeemBy = stripe.Int64(req.RedeemBy.Unix())
+	}
+	c, err := coupon.New(params)
+	if err != nil {
+		return "", err
+	}
+	return c.ID, nil
+}
@@ -0,0 +1,78 @@
+package payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/stripe/stripe-go/v74"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+// WebhookSimulator posts Stripe-shaped events, signed like Stripe signs them, to a webhook URL.
+// Payloads pass webhook.ConstructEvent, so the real webhook handler processes them unchanged.
+type WebhookSimulator struct {
+	url    string
+	secret string
+	client *http.Client
+	seq    atomic.Int64
+}
+
+func NewWebhookSimulator(url, secret string) *WebhookSimulator {
+	return &WebhookSimulator{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SignedEvent builds an event of eventType wrapping object and returns the payload and
+// its Stripe-Signature header
+func SignedEvent(eventID, eventType string, object interface{}, secret string) ([]byte, string, error) {
+	rawObject, err := json.Marshal(object)
+	if err != nil {
+		return nil, "", err
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":          eventID,
+		"object":      "event",
+		"api_version": stripe.APIVersion,
+		"created":     time.Now().Unix(),
+		"type":        eventType,
+		"data":        map[string]json.RawMessage{"object": rawObject},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: secret})
+	return payload, signed.Header, nil
+}
+
+// Send delivers one event and returns its ID. A non-2xx response is an error.
+func (s *WebhookSimulator) Send(eventType string, object interface{}) (string, error) {
+	eventID := fmt.Sprintf("evt_fake_%d_%d", time.Now().UnixNano(), s.seq.Add(1))
+	payload, header, err := SignedEvent(eventID, eventType, object, s.secret)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", header)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to deliver %s: %w", eventType, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("webhook rejected %s with status %d", eventType, resp.StatusCode)
+	}
+	return eventID, nil
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CortexActionRepository keeps the actions Cortex proposed in memory
+type CortexActionRepository struct {
+	actions map[uuid.UUID]domain.CortexAction
+	mu      sync.RWMutex
+}
+
+func NewCortexActionRepository() *CortexActionRepository {
+	return &CortexActionRepository{actions: make(map[uuid.UUID]domain.CortexAction)}
+}
+
+func (r *CortexActionRepository) Save(ctx context.Context, action *domain.CortexAction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions[action.ID] = copyCortexAction(*action)
+	return nil
+}
+
+func (r *CortexActionRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.CortexAction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	action, ok := r.actions[id]
+	if !ok {
+		return nil, nil
+	}
+	found := copyCortexAction(action)
+	return &found, nil
+}
+
+func (r *CortexActionRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]domain.CortexAction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []domain.CortexAction
+	for _, action := range r.actions {
+		if action.UserID == userID {
+			result = append(result, copyCortexAction(action))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (r *CortexActionRepository) Update(ctx context.Context, action *domain.CortexAction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.actions[action.ID]; !ok {
+		return errors.New("cortex action not found")
+	}
+	r.actions[action.ID] = copyCortexAction(*action)
+	return nil
+}
+
+func (r *CortexActionRepository) ClaimDecision(ctx context.Context, id uuid.UUID, status domain.CortexActionStatus, decidedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	action, ok := r.actions[id]
+	if !ok || action.Status != domain.CortexActionProposed {
+		return false, nil
+	}
+	action.Status = status
+	action.DecidedAt = &decidedAt
+	r.actions[id] = action
+	return true, nil
+}
+
+func copyCortexAction(action domain.CortexAction) domain.CortexAction {
+	action.Arguments = append(json.RawMessage(nil), action.Arguments...)
+	action.Result = append(json.RawMessage(nil), action.Result...)
+	return action
+}
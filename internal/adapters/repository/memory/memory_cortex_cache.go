@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultCortexCacheSize bounds the entries a CortexResponseCache holds
+const DefaultCortexCacheSize = 10000
+
+type cortexCacheEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// CortexResponseCache is an in-process TTL cache for Cortex outputs. When full, expired entries
+// are dropped first, then the one closest to expiring.
+type CortexResponseCache struct {
+	entries map[string]cortexCacheEntry
+	maxSize int
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+func NewCortexResponseCache(maxSize int) *CortexResponseCache {
+	if maxSize <= 0 {
+		maxSize = DefaultCortexCacheSize
+	}
+	return &CortexResponseCache{
+		entries: make(map[string]cortexCacheEntry),
+		maxSize: maxSize,
+		now:     time.Now,
+	}
+}
+
+func (c *CortexResponseCache) Get(ctx context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false, nil
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return "", false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (c *CortexResponseCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxSize {
+		c.evict(now)
+	}
+	c.entries[key] = cortexCacheEntry{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// evict makes room for one entry
+func (c *CortexResponseCache) evict(now time.Time) {
+	soonest := ""
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+			continue
+		}
+		if soonest == "" || entry.expiresAt.Before(c.entries[soonest].expiresAt) {
+			soonest = key
+		}
+	}
+	if len(c.entries) >= c.maxSize && soonest != "" {
+		delete(c.entries, soonest)
+	}
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// CortexThreadRepository keeps Cortex conversations in memory
+type CortexThreadRepository struct {
+	threads  map[uuid.UUID]domain.CortexThread
+	messages map[uuid.UUID][]domain.CortexMessage // By thread ID, in Seq order
+	mu       sync.RWMutex
+}
+
+func NewCortexThreadRepository() *CortexThreadRepository {
+	return &CortexThreadRepository{
+		threads:  make(map[uuid.UUID]domain.CortexThread),
+		messages: make(map[uuid.UUID][]domain.CortexMessage),
+	}
+}
+
+func (r *CortexThreadRepository) CreateThread(ctx context.Context, thread *domain.CortexThread) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.threads[thread.ID] = *thread
+	return nil
+}
+
+func (r *CortexThreadRepository) FindThread(ctx context.Context, id uuid.UUID) (*domain.CortexThread, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	thread, ok := r.threads[id]
+	if !ok {
+		return nil, nil
+	}
+	return &thread, nil
+}
+
+func (r *CortexThreadRepository) ListThreads(ctx context.Context, userID uuid.UUID) ([]domain.CortexThread, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []domain.CortexThread
+	for _, thread := range r.threads {
+		if thread.UserID == userID {
+			result = append(result, thread)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].UpdatedAt.Equal(result[j].UpdatedAt) {
+			return result[i].UpdatedAt.After(result[j].UpdatedAt)
+		}
+		return result[i].ID.String() < result[j].ID.String()
+	})
+	return result, nil
+}
+
+func (r *CortexThreadRepository) UpdateThread(ctx context.Context, thread *domain.CortexThread) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.threads[thread.ID]; ok {
+		r.threads[thread.ID] = *thread
+	}
+	return nil
+}
+
+func (r *CortexThreadRepository) DeleteThread(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.threads, id)
+	delete(r.messages, id)
+	return nil
+}
+
+func (r *CortexThreadRepository) AppendMessage(ctx context.Context, message *domain.CortexMessage) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.messages[message.ThreadID] {
+		if existing.Seq == message.Seq {
+			return false, nil
+		}
+	}
+	messages := append(r.messages[message.ThreadID], *message)
+	sort.Slice(messages, func(i, j int) bool { return messages[i].Seq < messages[j].Seq })
+	r.messages[message.ThreadID] = messages
+	return true, nil
+}
+
+func (r *CortexThreadRepository) ListMessages(ctx context.Context, threadID uuid.UUID, afterSeq int) ([]domain.CortexMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []domain.CortexMessage
+	for _, message := range r.messages[threadID] {
+		if message.Seq > afterSeq {
+			result = append(result, message)
+		}
+	}
+	return result, nil
+}
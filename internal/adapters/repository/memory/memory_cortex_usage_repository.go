@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type cortexUsageKey struct {
+	userID uuid.UUID
+	day    time.Time
+}
+
+// CortexUsageRepository keeps daily Cortex usage in memory
+type CortexUsageRepository struct {
+	usage map[cortexUsageKey]domain.CortexUsage
+	mu    sync.RWMutex
+}
+
+func NewCortexUsageRepository() *CortexUsageRepository {
+	return &CortexUsageRepository{usage: make(map[cortexUsageKey]domain.CortexUsage)}
+}
+
+func (r *CortexUsageRepository) GetUsage(ctx context.Context, userID uuid.UUID, day time.Time) (*domain.CortexUsage, error) {
+	day = domain.CortexUsageDay(day)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	usage, ok := r.usage[cortexUsageKey{userID, day}]
+	if !ok {
+		usage = domain.CortexUsage{UserID: userID, Day: day}
+	}
+	return &usage, nil
+}
+
+func (r *CortexUsageRepository) AddUsage(ctx context.Context, userID uuid.UUID, day time.Time, requests, tokens int) error {
+	day = domain.CortexUsageDay(day)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := cortexUsageKey{userID, day}
+	usage, ok := r.usage[key]
+	if !ok {
+		usage = domain.CortexUsage{UserID: userID, Day: day}
+	}
+	usage.Requests += requests
+	usage.Tokens += tokens
+	r.usage[key] = usage
+	return nil
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DisciplineRepository keeps Discipline Index events and snapshots in memory
+type DisciplineRepository struct {
+	events    []domain.DisciplineEvent
+	snapshots map[string]domain.DisciplineSnapshot // By user ID and day
+	mu        sync.RWMutex
+}
+
+func NewDisciplineRepository() *DisciplineRepository {
+	return &DisciplineRepository{snapshots: make(map[string]domain.DisciplineSnapshot)}
+}
+
+func (r *DisciplineRepository) AppendEvent(ctx context.Context, event *domain.DisciplineEvent) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.events {
+		if existing.UserID == event.UserID && existing.Type == event.Type && existing.SourceID == event.SourceID {
+			return false, nil
+		}
+	}
+	r.events = append(r.events, *event)
+	return true, nil
+}
+
+func (r *DisciplineRepository) ListEvents(ctx context.Context, userID uuid.UUID, since time.Time) ([]domain.DisciplineEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []domain.DisciplineEvent
+	for _, event := range r.events {
+		if event.UserID == userID && !event.CreatedAt.Before(since) {
+			result = append(result, event)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (r *DisciplineRepository) LastEventAt(ctx context.Context, userID uuid.UUID, eventType domain.DisciplineEventType) (*time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var last *time.Time
+	for _, event := range r.events {
+		if event.UserID == userID && event.Type == eventType && (last == nil || event.CreatedAt.After(*last)) {
+			at := event.CreatedAt
+			last = &at
+		}
+	}
+	return last, nil
+}
+
+func (r *DisciplineRepository) SaveSnapshot(ctx context.Context, snapshot *domain.DisciplineSnapshot) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := snapshot.UserID.String() + snapshot.Day.Format("2006-01-02")
+	if _, ok := r.snapshots[key]; ok {
+		return false, nil
+	}
+	r.snapshots[key] = *snapshot
+	return true, nil
+}
+
+func (r *DisciplineRepository) ListSnapshots(ctx context.Context, userID uuid.UUID, from time.Time) ([]domain.DisciplineSnapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []domain.DisciplineSnapshot
+	for _, snapshot := range r.snapshots {
+		if snapshot.UserID == userID && !snapshot.Day.Before(from) {
+			result = append(result, snapshot)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Day.Before(result[j].Day)
+	})
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentRepository keeps the payment history in memory
+type PaymentRepository struct {
+	payments []domain.Payment
+	mu       sync.RWMutex
+}
+
+func NewPaymentRepository() *PaymentRepository {
+	return &PaymentRepository{}
+}
+
+func (r *PaymentRepository) Record(ctx context.Context, payment *domain.Payment) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.payments {
+		if existing.Kind == payment.Kind && existing.ProviderID == payment.ProviderID {
+			return false, nil
+		}
+	}
+	r.payments = append(r.payments, *payment)
+	return true, nil
+}
+
+func (r *PaymentRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, payment := range r.payments {
+		if payment.ID == id {
+			found := payment
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *PaymentRepository) ListByUserID(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []domain.Payment
+	for _, payment := range r.payments {
+		if payment.UserID == userID && !payment.PaidAt.Before(from) && payment.PaidAt.Before(to) {
+			result = append(result, payment)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].PaidAt.Before(result[j].PaidAt)
+	})
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// PromoCodeRepository keeps promo codes and their redemptions in memory
+type PromoCodeRepository struct {
+	promos      map[string]*domain.PromoCode // By code
+	redemptions []*domain.PromoRedemption
+	mu          sync.RWMutex
+}
+
+func NewPromoCodeRepository() *PromoCodeRepository {
+	return &PromoCodeRepository{promos: make(map[string]*domain.PromoCode)}
+}
+
+func (r *PromoCodeRepository) Create(ctx context.Context, promo *domain.PromoCode) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.promos[promo.Code]; ok {
+		return false, nil
+	}
+	stored := copyPromoCode(promo)
+	stored.TimesRedeemed = 0
+	r.promos[promo.Code] = stored
+	return true, nil
+}
+
+func (r *PromoCodeRepository) Update(ctx context.Context, promo *domain.PromoCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.promos[promo.Code]
+	if !ok || stored.ID != promo.ID {
+		return errors.New("promo code not found")
+	}
+	stored.Description = promo.Description
+	stored.Active = promo.Active
+	stored.UpdatedAt = promo.UpdatedAt
+	return nil
+}
+
+func (r *PromoCodeRepository) FindByCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if promo, ok := r.promos[code]; ok {
+		return copyPromoCode(promo), nil
+	}
+	return nil, nil
+}
+
+func (r *PromoCodeRepository) List(ctx context.Context) ([]*domain.PromoCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.PromoCode
+	for _, promo := range r.promos {
+		result = append(result, copyPromoCode(promo))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.After(result[j].CreatedAt)
+		}
+		return result[i].Code < result[j].Code
+	})
+	return result, nil
+}
+
+func (r *PromoCodeRepository) Redeem(ctx context.Context, redemption *domain.PromoRedemption) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.redemptions {
+		if existing.PromoCodeID == redemption.PromoCodeID && existing.UserID == redemption.UserID {
+			return false, nil
+		}
+	}
+	var promo *domain.PromoCode
+	for _, p := range r.promos {
+		if p.ID == redemption.PromoCodeID {
+			promo = p
+		}
+	}
+	if promo == nil {
+		return false, errors.New("promo code not found")
+	}
+	stored := *redemption
+	r.redemptions = append(r.redemptions, &stored)
+	promo.TimesRedeemed++
+	return true, nil
+}
+
+func (r *PromoCodeRepository) FindRedemption(ctx context.Context, promoCodeID, userID uuid.UUID) (*domain.PromoRedemption, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, redemption := range r.redemptions {
+		if redemption.PromoCodeID == promoCodeID && redemption.UserID == userID {
+			found := *redemption
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *PromoCodeRepository) ListRedemptions(ctx context.Context, promoCodeID uuid.UUID) ([]*domain.PromoRedemption, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.PromoRedemption
+	for _, redemption := range r.redemptions {
+		if redemption.PromoCodeID == promoCodeID {
+			found := *redemption
+			result = append(result, &found)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].RedeemedAt.After(result[j].RedeemedAt)
+	})
+	return result, nil
+}
+
+func copyPromoCode(promo *domain.PromoCode) *domain.PromoCode {
+	c := *promo
+	c.Plans = append([]domain.SubscriptionTier(nil), promo.Plans...)
+	return &c
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type promptExposureKey struct {
+	prompt  string
+	version int
+	variant string
+	userID  uuid.UUID
+}
+
+// PromptExposureRepository keeps prompt exposures in memory, summarized as they are recorded
+type PromptExposureRepository struct {
+	summaries map[promptExposureKey]domain.PromptExposureSummary
+	mu        sync.RWMutex
+}
+
+func NewPromptExposureRepository() *PromptExposureRepository {
+	return &PromptExposureRepository{summaries: make(map[promptExposureKey]domain.PromptExposureSummary)}
+}
+
+func (r *PromptExposureRepository) Record(ctx context.Context, exposure *domain.PromptExposure) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := promptExposureKey{exposure.Prompt, exposure.Version, exposure.Variant, exposure.UserID}
+	summary, ok := r.summaries[key]
+	if !ok || exposure.CreatedAt.Before(summary.FirstExposedAt) {
+		summary.FirstExposedAt = exposure.CreatedAt
+	}
+	summary.Prompt = exposure.Prompt
+	summary.Version = exposure.Version
+	summary.Variant = exposure.Variant
+	summary.UserID = exposure.UserID
+	summary.Responses++
+	r.summaries[key] = summary
+	return nil
+}
+
+func (r *PromptExposureRepository) SummarizeExposures(ctx context.Context) ([]domain.PromptExposureSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	summaries := make([]domain.PromptExposureSummary, 0, len(r.summaries))
+	for _, summary := range r.summaries {
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.Prompt != b.Prompt {
+			return a.Prompt < b.Prompt
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		if a.Variant != b.Variant {
+			return a.Variant < b.Variant
+		}
+		return a.UserID.String() < b.UserID.String()
+	})
+	return summaries, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fastinghero/internal/core/domain"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -35,7 +36,7 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*domain
 	if user, ok := r.users[email]; ok {
 		return user, nil
 	}
-	return nil, errors.New("user not found")
+	return nil, domain.ErrUserNotFound
 }
 
 func (r *UserRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
@@ -50,15 +51,89 @@ func (r *UserRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Us
 	if id.String() == "00000000-0000-0000-0000-000000000000" {
 		return &domain.User{ID: id, Email: "test@example.com", SubscriptionTier: domain.TierFree}, nil
 	}
-	return nil, errors.New("user not found")
+	return nil, domain.ErrUserNotFound
 }
 
 func (r *UserRepository) FindByReferralCode(ctx context.Context, code string) (*domain.User, error) {
-	// In memory, we don't store referral code on user struct in this example,
-	// but let's assume we can't find it or return nil.
-	// Actually, domain.User doesn't seem to have ReferralCode field based on previous view.
-	// Let's check domain.User.
-	return nil, errors.New("user not found")
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, u := range r.users {
+		if u.ReferralCode != "" && u.ReferralCode == code {
+			return u, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *UserRepository) FindByStripeCustomerID(ctx context.Context, customerID string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if customerID != "" && user.StripeCustomerID == customerID {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *UserRepository) ListVaultMembers(ctx context.Context, afterID uuid.UUID, limit int) ([]*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var members []*domain.User
+	for _, user := range r.users {
+		if user.IsVaultMember() && user.ID.String() > afterID.String() {
+			members = append(members, user)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID.String() < members[j].ID.String()
+	})
+	if len(members) > limit {
+		members = members[:limit]
+	}
+	return members, nil
+}
+
+func (r *UserRepository) ListWithDisciplineScore(ctx context.Context, afterID uuid.UUID, limit int) ([]*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var scored []*domain.User
+	for _, user := range r.users {
+		if user.DisciplineIndex > 0 && user.ID.String() > afterID.String() {
+			scored = append(scored, user)
+		}
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		return scored[i].ID.String() < scored[j].ID.String()
+	})
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return scored, nil
+}
+
+func (r *UserRepository) UpdateEarnedRefund(ctx context.Context, userID uuid.UUID, earnedRefund float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.ID == userID {
+			user.EarnedRefund = earnedRefund
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (r *UserRepository) UpdateDisciplineIndex(ctx context.Context, userID uuid.UUID, disciplineIndex float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.ID == userID {
+			user.DisciplineIndex = disciplineIndex
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
 }
 
 type FastingRepository struct {
@@ -366,6 +441,18 @@ func (r *TelemetryRepository) GetWeeklyStats(ctx context.Context, userID uuid.UU
 	return result, nil
 }
 
+func (r *TelemetryRepository) FindByRange(ctx context.Context, userID uuid.UUID, metricType domain.MetricType, from, to time.Time) ([]domain.TelemetryData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []domain.TelemetryData
+	for _, d := range r.data {
+		if d.UserID == userID && d.Type == metricType && !d.Timestamp.Before(from) && d.Timestamp.Before(to) {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
 func (r *TelemetryRepository) SaveConnection(ctx context.Context, conn *domain.DeviceConnection) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -402,6 +489,7 @@ type SocialRepository struct {
 	friendNetworks map[string]*domain.FriendNetwork
 	tribes         map[string]*domain.Tribe
 	challenges     map[string]*domain.FriendChallenge
+	participants   map[uuid.UUID]*domain.FriendChallengeParticipant
 	mu             sync.RWMutex
 }
 
@@ -410,6 +498,7 @@ func NewSocialRepository() *SocialRepository {
 		friendNetworks: make(map[string]*domain.FriendNetwork),
 		tribes:         make(map[string]*domain.Tribe),
 		challenges:     make(map[string]*domain.FriendChallenge),
+		participants:   make(map[uuid.UUID]*domain.FriendChallengeParticipant),
 	}
 }
 
@@ -449,7 +538,8 @@ func (r *SocialRepository) FindTribeByID(ctx context.Context, id uuid.UUID) (*do
 func (r *SocialRepository) SaveChallenge(ctx context.Context, c *domain.FriendChallenge) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.challenges[c.ID.String()] = c
+	stored := *c
+	r.challenges[c.ID.String()] = &stored
 	return nil
 }
 
@@ -458,10 +548,92 @@ func (r *SocialRepository) FindChallengesByUserID(ctx context.Context, userID uu
 	defer r.mu.RUnlock()
 	var result []domain.FriendChallenge
 	for _, c := range r.challenges {
-		if c.ChallengerID == userID || c.ChallengedID == userID {
+		if c.CreatorID == userID || c.ChallengerID == userID || c.ChallengedID == userID || r.isParticipant(c.ID, userID) {
 			result = append(result, *c)
 		}
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (r *SocialRepository) isParticipant(challengeID, userID uuid.UUID) bool {
+	for _, p := range r.participants {
+		if p.ChallengeID == challengeID && p.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *SocialRepository) FindChallengeByID(ctx context.Context, id uuid.UUID) (*domain.FriendChallenge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if c, ok := r.challenges[id.String()]; ok {
+		challenge := *c
+		return &challenge, nil
+	}
+	return nil, nil
+}
+
+func (r *SocialRepository) FindEndedChallenges(ctx context.Context, now time.Time) ([]*domain.FriendChallenge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.FriendChallenge
+	for _, c := range r.challenges {
+		if c.Status == domain.ChallengeStatusActive && c.HasEnded(now) {
+			challenge := *c
+			result = append(result, &challenge)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].EndDate.Before(result[j].EndDate)
+	})
+	return result, nil
+}
+
+func (r *SocialRepository) SaveChallengeParticipant(ctx context.Context, p *domain.FriendChallengeParticipant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, existing := range r.participants {
+		if existing.ChallengeID == p.ChallengeID && existing.UserID == p.UserID && id != p.ID {
+			return errors.New("user is already a challenge participant")
+		}
+	}
+	stored := *p
+	r.participants[p.ID] = &stored
+	return nil
+}
+
+func (r *SocialRepository) FindChallengeParticipant(ctx context.Context, challengeID, userID uuid.UUID) (*domain.FriendChallengeParticipant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.participants {
+		if p.ChallengeID == challengeID && p.UserID == userID {
+			participant := *p
+			return &participant, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *SocialRepository) FindChallengeParticipants(ctx context.Context, challengeID uuid.UUID) ([]*domain.FriendChallengeParticipant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.FriendChallengeParticipant
+	for _, p := range r.participants {
+		if p.ChallengeID == challengeID {
+			participant := *p
+			result = append(result, &participant)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].ID.String() < result[j].ID.String()
+	})
 	return result, nil
 }
 
@@ -560,58 +732,113 @@ func (r *GamificationRepository) GetUserStreak(ctx context.Context, userID uuid.
 
 type ReferralRepository struct {
 	referrals []domain.Referral
+	signups   map[uuid.UUID]domain.SignupSignals
 	mu        sync.RWMutex
 }
 
 func NewReferralRepository() *ReferralRepository {
 	return &ReferralRepository{
 		referrals: make([]domain.Referral, 0),
+		signups:   make(map[uuid.UUID]domain.SignupSignals),
 	}
 }
 
 func (r *ReferralRepository) Save(ctx context.Context, referral *domain.Referral) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.referrals = append(r.referrals, *referral)
+	r.referrals = append(r.referrals, copyReferral(*referral))
 	return nil
 }
 
+func (r *ReferralRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Referral, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, ref := range r.referrals {
+		if ref.ID == id {
+			found := copyReferral(ref)
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
 func (r *ReferralRepository) FindByRefereeID(ctx context.Context, refereeID uuid.UUID) (*domain.Referral, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	for _, ref := range r.referrals {
 		if ref.RefereeID == refereeID {
-			return &ref, nil
+			found := copyReferral(ref)
+			return &found, nil
 		}
 	}
 	return nil, nil
 }
 
 func (r *ReferralRepository) FindByReferrerID(ctx context.Context, referrerID uuid.UUID) ([]domain.Referral, error) {
+	return r.filter(func(ref domain.Referral) bool { return ref.ReferrerID == referrerID }), nil
+}
+
+func (r *ReferralRepository) ListByStatus(ctx context.Context, status domain.ReferralStatus) ([]domain.Referral, error) {
+	return r.filter(func(ref domain.Referral) bool { return ref.Status == status }), nil
+}
+
+func (r *ReferralRepository) ListUnpaid(ctx context.Context) ([]domain.Referral, error) {
+	return r.filter(func(ref domain.Referral) bool {
+		return ref.Status == domain.ReferralStatusCompleted && !ref.IsPaid()
+	}), nil
+}
+
+func (r *ReferralRepository) filter(keep func(domain.Referral) bool) []domain.Referral {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	var result []domain.Referral
 	for _, ref := range r.referrals {
-		if ref.ReferrerID == referrerID {
-			result = append(result, ref)
+		if keep(ref) {
+			result = append(result, copyReferral(ref))
 		}
 	}
-	return result, nil
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
 }
 
 func (r *ReferralRepository) Update(ctx context.Context, referral *domain.Referral) error {
-	// In memory slice update is tricky without pointer, but for now append is okay or simple loop
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	for i, ref := range r.referrals {
 		if ref.ID == referral.ID {
-			r.referrals[i] = *referral
+			r.referrals[i] = copyReferral(*referral)
 			return nil
 		}
 	}
 	return errors.New("referral not found")
 }
 
+// SaveSignupSignals keeps the first signals recorded for a user
+func (r *ReferralRepository) SaveSignupSignals(ctx context.Context, signals *domain.SignupSignals) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.signups[signals.UserID]; !ok {
+		r.signups[signals.UserID] = *signals
+	}
+	return nil
+}
+
+func (r *ReferralRepository) FindSignupSignals(ctx context.Context, userID uuid.UUID) (*domain.SignupSignals, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if signals, ok := r.signups[userID]; ok {
+		return &signals, nil
+	}
+	return nil, nil
+}
+
+func copyReferral(ref domain.Referral) domain.Referral {
+	ref.FraudSignals = append([]domain.ReferralFraudSignal(nil), ref.FraudSignals...)
+	return ref
+}
+
 type NotificationRepository struct {
 	tokens        map[string][]domain.FCMToken
 	notifications map[string][]domain.Notification
@@ -740,13 +967,15 @@ func (r *SubscriptionRepository) FindByStripeSubscriptionID(ctx context.Context,
 }
 
 type VaultRepository struct {
-	vaults map[string]*domain.VaultParticipation
-	mu     sync.RWMutex
+	vaults      map[string]*domain.VaultParticipation
+	settlements map[string]*domain.VaultDailySettlement
+	mu          sync.RWMutex
 }
 
 func NewVaultRepository() *VaultRepository {
 	return &VaultRepository{
-		vaults: make(map[string]*domain.VaultParticipation),
+		vaults:      make(map[string]*domain.VaultParticipation),
+		settlements: make(map[string]*domain.VaultDailySettlement),
 	}
 }
 
@@ -768,6 +997,79 @@ func (r *VaultRepository) FindByUserIDAndMonth(ctx context.Context, userID uuid.
 	return nil, nil
 }
 
+func (r *VaultRepository) ListOpenByMonth(ctx context.Context, monthStart time.Time) ([]*domain.VaultParticipation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.VaultParticipation
+	for _, vault := range r.vaults {
+		if vault.MonthStart.Equal(monthStart) && !vault.RefundProcessed {
+			result = append(result, vault)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID.String() < result[j].ID.String()
+	})
+	return result, nil
+}
+
+func (r *VaultRepository) ClaimDailySettlement(ctx context.Context, settlement *domain.VaultDailySettlement) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := fmt.Sprintf("%s_%s", settlement.UserID, settlement.SettlementDate.Format("2006-01-02"))
+	if _, exists := r.settlements[key]; exists {
+		return false, nil
+	}
+	r.settlements[key] = settlement
+	return true, nil
+}
+
+func (r *VaultRepository) ReleaseDailySettlement(ctx context.Context, userID uuid.UUID, day time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.settlements, fmt.Sprintf("%s_%s", userID, day.Format("2006-01-02")))
+	return nil
+}
+
+type VaultLedgerRepository struct {
+	entries []domain.VaultLedgerEntry
+	mu      sync.RWMutex
+}
+
+func NewVaultLedgerRepository() *VaultLedgerRepository {
+	return &VaultLedgerRepository{
+		entries: make([]domain.VaultLedgerEntry, 0),
+	}
+}
+
+func (r *VaultLedgerRepository) Append(ctx context.Context, entry *domain.VaultLedgerEntry) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry.SourceID != "" {
+		for _, e := range r.entries {
+			if e.UserID == entry.UserID && e.Type == entry.Type && e.SourceType == entry.SourceType && e.SourceID == entry.SourceID {
+				return false, nil
+			}
+		}
+	}
+	r.entries = append(r.entries, *entry)
+	return true, nil
+}
+
+func (r *VaultLedgerRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]domain.VaultLedgerEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []domain.VaultLedgerEntry
+	for _, e := range r.entries {
+		if e.UserID == userID {
+			result = append(result, e)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].EffectiveAt.Before(result[j].EffectiveAt)
+	})
+	return result, nil
+}
+
 type ProgressRepository struct {
 	weightLogs    []domain.WeightLog
 	hydrationLogs []domain.HydrationLog
@@ -842,3 +1144,30 @@ func (r *ProgressRepository) GetActivityStats(ctx context.Context, userID uuid.U
 	}
 	return result, nil
 }
+
+type WebhookEventRepository struct {
+	events map[string]*domain.WebhookEvent
+	mu     sync.Mutex
+}
+
+func NewWebhookEventRepository() *WebhookEventRepository {
+	return &WebhookEventRepository{
+		events: make(map[string]*domain.WebhookEvent),
+	}
+}
+
+func (r *WebhookEventRepository) IsProcessed(ctx context.Context, eventID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.events[eventID]
+	return ok, nil
+}
+
+func (r *WebhookEventRepository) MarkProcessed(ctx context.Context, event *domain.WebhookEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.events[event.ID]; !ok {
+		r.events[event.ID] = event
+	}
+	return nil
+}
@@ -105,3 +105,25 @@ func TestGetWeeklyStats_StepsAggregation(t *testing.T) {
 	assert.True(t, found, "Should have stats for today")
 	assert.Equal(t, 8000.0, todayStat.Value, "Steps should be summed")
 }
+
+func TestCortexResponseCache_ExpiresAndEvicts(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCortexResponseCache(2)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	assert.NoError(t, cache.Set(ctx, "short", "a", time.Minute))
+	assert.NoError(t, cache.Set(ctx, "long", "b", time.Hour))
+	value, ok, _ := cache.Get(ctx, "short")
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+
+	// Full: the entry closest to expiring makes room
+	assert.NoError(t, cache.Set(ctx, "new", "c", time.Hour))
+	_, ok, _ = cache.Get(ctx, "short")
+	assert.False(t, ok)
+
+	now = now.Add(2 * time.Hour)
+	_, ok, _ = cache.Get(ctx, "long")
+	assert.False(t, ok, "expired")
+}
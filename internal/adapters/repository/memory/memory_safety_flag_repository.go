@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// SafetyFlagRepository keeps safety flags in memory
+type SafetyFlagRepository struct {
+	flags map[uuid.UUID]domain.SafetyFlag
+	mu    sync.RWMutex
+}
+
+func NewSafetyFlagRepository() *SafetyFlagRepository {
+	return &SafetyFlagRepository{flags: make(map[uuid.UUID]domain.SafetyFlag)}
+}
+
+func (r *SafetyFlagRepository) Save(ctx context.Context, flag *domain.SafetyFlag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flags[flag.ID] = copySafetyFlag(*flag)
+	return nil
+}
+
+func (r *SafetyFlagRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.SafetyFlag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	flag, ok := r.flags[id]
+	if !ok {
+		return nil, nil
+	}
+	found := copySafetyFlag(flag)
+	return &found, nil
+}
+
+func (r *SafetyFlagRepository) ListByStatus(ctx context.Context, status domain.SafetyFlagStatus) ([]domain.SafetyFlag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []domain.SafetyFlag
+	for _, flag := range r.flags {
+		if flag.Status == status {
+			result = append(result, copySafetyFlag(flag))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (r *SafetyFlagRepository) HasOpenFlag(ctx context.Context, userID uuid.UUID, signal domain.SafetySignal) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, flag := range r.flags {
+		if flag.UserID != userID || flag.Status != domain.SafetyFlagOpen {
+			continue
+		}
+		for _, s := range flag.Signals {
+			if s == signal {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (r *SafetyFlagRepository) Update(ctx context.Context, flag *domain.SafetyFlag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.flags[flag.ID]; !ok {
+		return errors.New("safety flag not found")
+	}
+	r.flags[flag.ID] = copySafetyFlag(*flag)
+	return nil
+}
+
+func copySafetyFlag(flag domain.SafetyFlag) domain.SafetyFlag {
+	flag.Signals = append([]domain.SafetySignal(nil), flag.Signals...)
+	return flag
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrialRepository keeps free trials in memory, enforcing one trial per user, email and card
+type TrialRepository struct {
+	trials map[uuid.UUID]*domain.Trial
+	mu     sync.RWMutex
+}
+
+func NewTrialRepository() *TrialRepository {
+	return &TrialRepository{trials: make(map[uuid.UUID]*domain.Trial)}
+}
+
+func (r *TrialRepository) Create(ctx context.Context, trial *domain.Trial) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.trials {
+		if t.UserID == trial.UserID || t.EmailKey == trial.EmailKey ||
+			(trial.PaymentFingerprint != "" && t.PaymentFingerprint == trial.PaymentFingerprint) {
+			return false, nil
+		}
+	}
+	stored := *trial
+	r.trials[trial.ID] = &stored
+	return true, nil
+}
+
+func (r *TrialRepository) Update(ctx context.Context, trial *domain.Trial) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.trials[trial.ID]; !ok {
+		return errors.New("trial not found")
+	}
+	stored := *trial
+	r.trials[trial.ID] = &stored
+	return nil
+}
+
+func (r *TrialRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*domain.Trial, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, t := range r.trials {
+		if t.UserID == userID {
+			trial := *t
+			return &trial, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *TrialRepository) ListActiveEndingBefore(ctx context.Context, before time.Time) ([]*domain.Trial, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.Trial
+	for _, t := range r.trials {
+		if t.Status == domain.TrialStatusActive && t.EndsAt.Before(before) {
+			trial := *t
+			result = append(result, &trial)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].EndsAt.Before(result[j].EndsAt)
+	})
+	return result, nil
+}
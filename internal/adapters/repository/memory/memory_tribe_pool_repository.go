@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TribePoolRepository keeps pools and participants in memory. It hands out copies so
+// callers can't change stored rows without saving them.
+type TribePoolRepository struct {
+	pools        map[uuid.UUID]*domain.TribePool
+	participants map[uuid.UUID]*domain.TribePoolParticipant
+	mu           sync.RWMutex
+}
+
+func NewTribePoolRepository() *TribePoolRepository {
+	return &TribePoolRepository{
+		pools:        make(map[uuid.UUID]*domain.TribePool),
+		participants: make(map[uuid.UUID]*domain.TribePoolParticipant),
+	}
+}
+
+func (r *TribePoolRepository) CreatePool(ctx context.Context, pool *domain.TribePool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.pools {
+		if p.TribeID == pool.TribeID && p.MonthStart.Equal(pool.MonthStart) {
+			return false, nil
+		}
+	}
+	stored := *pool
+	r.pools[pool.ID] = &stored
+	return true, nil
+}
+
+func (r *TribePoolRepository) UpdatePool(ctx context.Context, pool *domain.TribePool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.pools[pool.ID]
+	if !ok {
+		return domain.ErrTribePoolNotFound
+	}
+	stored.Status = pool.Status
+	stored.FirstPlacePayout = pool.FirstPlacePayout
+	stored.SecondPlacePayout = pool.SecondPlacePayout
+	stored.ThirdPlacePayout = pool.ThirdPlacePayout
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *TribePoolRepository) FindPoolByID(ctx context.Context, id uuid.UUID) (*domain.TribePool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if p, ok := r.pools[id]; ok {
+		pool := *p
+		return &pool, nil
+	}
+	return nil, nil
+}
+
+func (r *TribePoolRepository) FindPoolByMonth(ctx context.Context, tribeID uuid.UUID, monthStart time.Time) (*domain.TribePool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.pools {
+		if p.TribeID == tribeID && p.MonthStart.Equal(monthStart) {
+			pool := *p
+			return &pool, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *TribePoolRepository) ListPoolsByTribe(ctx context.Context, tribeID uuid.UUID) ([]*domain.TribePool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := []*domain.TribePool{}
+	for _, p := range r.pools {
+		if p.TribeID == tribeID {
+			pool := *p
+			result = append(result, &pool)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].MonthStart.After(result[j].MonthStart)
+	})
+	return result, nil
+}
+
+func (r *TribePoolRepository) ListPoolsByStatus(ctx context.Context, status string) ([]*domain.TribePool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.TribePool
+	for _, p := range r.pools {
+		if p.Status == status {
+			pool := *p
+			result = append(result, &pool)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID.String() < result[j].ID.String()
+	})
+	return result, nil
+}
+
+func (r *TribePoolRepository) AddParticipant(ctx context.Context, participant *domain.TribePoolParticipant) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pool, ok := r.pools[participant.PoolID]
+	if !ok {
+		return false, domain.ErrTribePoolNotFound
+	}
+	if pool.Status != domain.TribePoolStatusOpen {
+		return false, domain.ErrTribePoolNotOpen
+	}
+	for _, p := range r.participants {
+		if p.PoolID == participant.PoolID && p.UserID == participant.UserID {
+			return false, nil
+		}
+	}
+	stored := *participant
+	r.participants[participant.ID] = &stored
+	pool.TotalPot += participant.DepositAmount
+	pool.ParticipantCount++
+	pool.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (r *TribePoolRepository) UpdateParticipant(ctx context.Context, participant *domain.TribePoolParticipant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.participants[participant.ID]; !ok {
+		return errors.New("tribe pool participant not found")
+	}
+	stored := *participant
+	r.participants[participant.ID] = &stored
+	return nil
+}
+
+func (r *TribePoolRepository) ListParticipants(ctx context.Context, poolID uuid.UUID) ([]*domain.TribePoolParticipant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.TribePoolParticipant
+	for _, p := range r.participants {
+		if p.PoolID == poolID {
+			participant := *p
+			result = append(result, &participant)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresCortexActionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresCortexActionRepository(db *sql.DB) *PostgresCortexActionRepository {
+	return &PostgresCortexActionRepository{db: db}
+}
+
+const cortexActionColumns = `
+	id, user_id, tool, arguments, summary, status, result, COALESCE(error, ''),
+	created_at, expires_at, decided_at
+`
+
+func (r *PostgresCortexActionRepository) Save(ctx context.Context, action *domain.CortexAction) error {
+	query := `
+		INSERT INTO cortex_actions (id, user_id, tool, arguments, summary, status, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		action.ID,
+		action.UserID,
+		action.Tool,
+		string(action.Arguments),
+		action.Summary,
+		action.Status,
+		action.CreatedAt,
+		action.ExpiresAt,
+	)
+	return err
+}
+
+func (r *PostgresCortexActionRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.CortexAction, error) {
+	query := `SELECT ` + cortexActionColumns + ` FROM cortex_actions WHERE id = $1`
+	action, err := scanCortexAction(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return action, nil
+}
+
+func (r *PostgresCortexActionRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]domain.CortexAction, error) {
+	query := `SELECT ` + cortexActionColumns + ` FROM cortex_actions WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []domain.CortexAction
+	for rows.Next() {
+		action, err := scanCortexAction(rows)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, *action)
+	}
+	return actions, rows.Err()
+}
+
+func (r *PostgresCortexActionRepository) Update(ctx context.Context, action *domain.CortexAction) error {
+	query := `
+		UPDATE cortex_actions
+		SET status = $2, result = $3::jsonb, error = NULLIF($4, ''), decided_at = $5
+		WHERE id = $1
+	`
+	var result sql.NullString
+	if len(action.Result) > 0 {
+		result = sql.NullString{String: string(action.Result), Valid: true}
+	}
+	res, err := r.db.ExecContext(ctx, query, action.ID, action.Status, result, action.Error, action.DecidedAt)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("cortex action not found")
+	}
+	return nil
+}
+
+func (r *PostgresCortexActionRepository) ClaimDecision(ctx context.Context, id uuid.UUID, status domain.CortexActionStatus, decidedAt time.Time) (bool, error) {
+	query := `
+		UPDATE cortex_actions
+		SET status = $2, decided_at = $3
+		WHERE id = $1 AND status = $4
+	`
+	res, err := r.db.ExecContext(ctx, query, id, status, decidedAt, domain.CortexActionProposed)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func scanCortexAction(row rowScanner) (*domain.CortexAction, error) {
+	var action domain.CortexAction
+	var arguments, result []byte
+	if err := row.Scan(
+		&action.ID,
+		&action.UserID,
+		&action.Tool,
+		&arguments,
+		&action.Summary,
+		&action.Status,
+		&result,
+		&action.Error,
+		&action.CreatedAt,
+		&action.ExpiresAt,
+		&action.DecidedAt,
+	); err != nil {
+		return nil, err
+	}
+	action.Arguments = json.RawMessage(arguments)
+	if len(result) > 0 {
+		action.Result = json.RawMessage(result)
+	}
+	return &action, nil
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fastinghero/internal/core/domain"
+
+	"github.com/google/uuid"
+)
+
+type PostgresCortexThreadRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresCortexThreadRepository(db *sql.DB) *PostgresCortexThreadRepository {
+	return &PostgresCortexThreadRepository{db: db}
+}
+
+const cortexThreadColumns = `id, user_id, title, summary, summarized_through, message_count, created_at, updated_at`
+
+func (r *PostgresCortexThreadRepository) CreateThread(ctx context.Context, thread *domain.CortexThread) error {
+	query := `
+		INSERT INTO cortex_threads (` + cortexThreadColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		thread.ID, thread.UserID, thread.Title, thread.Summary, thread.SummarizedThrough, thread.MessageCount, thread.CreatedAt, thread.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresCortexThreadRepository) FindThread(ctx context.Context, id uuid.UUID) (*domain.CortexThread, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+cortexThreadColumns+` FROM cortex_threads WHERE id = $1`, id)
+	thread, err := scanCortexThread(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+func (r *PostgresCortexThreadRepository) ListThreads(ctx context.Context, userID uuid.UUID) ([]domain.CortexThread, error) {
+	query := `
+		SELECT ` + cortexThreadColumns + `
+		FROM cortex_threads
+		WHERE user_id = $1
+		ORDER BY updated_at DESC, id
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var threads []domain.CortexThread
+	for rows.Next() {
+		thread, err := scanCortexThread(rows)
+		if err != nil {
+			return nil, err
+		}
+		threads = append(threads, *thread)
+	}
+	return threads, rows.Err()
+}
+
+func (r *PostgresCortexThreadRepository) UpdateThread(ctx context.Context, thread *domain.CortexThread) error {
+	query := `
+		UPDATE cortex_threads
+		SET title = $2, summary = $3, summarized_through = $4, message_count = $5, updated_at = $6
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		thread.ID, thread.Title, thread.Summary, thread.SummarizedThrough, thread.MessageCount, thread.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresCortexThreadRepository) DeleteThread(ctx context.Context, id uuid.UUID) error {
+	// cortex_messages rows go with the thread (ON DELETE CASCADE)
+	_, err := r.db.ExecContext(ctx, `DELETE FROM cortex_threads WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresCortexThreadRepository) AppendMessage(ctx context.Context, message *domain.CortexMessage) (bool, error) {
+	query := `
+		INSERT INTO cortex_messages (id, thread_id, seq, role, content, interrupted, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (thread_id, seq) DO NOTHING
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		message.ID, message.ThreadID, message.Seq, message.Role, message.Content, message.Interrupted, message.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgresCortexThreadRepository) ListMessages(ctx context.Context, threadID uuid.UUID, afterSeq int) ([]domain.CortexMessage, error) {
+	query := `
+		SELECT id, thread_id, seq, role, content, interrupted, created_at
+		FROM cortex_messages
+		WHERE thread_id = $1 AND seq > $2
+		ORDER BY seq
+	`
+	rows, err := r.db.QueryContext(ctx, query, threadID, afterSeq)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []domain.CortexMessage
+	for rows.Next() {
+		var m domain.CortexMessage
+		if err := rows.Scan(&m.ID, &m.ThreadID, &m.Seq, &m.Role, &m.Content, &m.Interrupted, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+func scanCortexThread(row rowScanner) (*domain.CortexThread, error) {
+	var t domain.CortexThread
+	if err := row.Scan(&t.ID, &t.UserID, &t.Title, &t.Summary, &t.SummarizedThrough, &t.MessageCount, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
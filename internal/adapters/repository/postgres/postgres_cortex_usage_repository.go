@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fastinghero/internal/core/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresCortexUsageRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresCortexUsageRepository(db *sql.DB) *PostgresCortexUsageRepository {
+	return &PostgresCortexUsageRepository{db: db}
+}
+
+func (r *PostgresCortexUsageRepository) GetUsage(ctx context.Context, userID uuid.UUID, day time.Time) (*domain.CortexUsage, error) {
+	usage := &domain.CortexUsage{UserID: userID, Day: domain.CortexUsageDay(day)}
+	query := `SELECT requests, tokens FROM cortex_usage WHERE user_id = $1 AND day = $2`
+	err := r.db.QueryRowContext(ctx, query, userID, usage.Day).Scan(&usage.Requests, &usage.Tokens)
+	if err == sql.ErrNoRows {
+		return usage, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+func (r *PostgresCortexUsageRepository) AddUsage(ctx context.Context, userID uuid.UUID, day time.Time, requests, tokens int) error {
+	query := `
+		INSERT INTO cortex_usage (user_id, day, requests, tokens)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, day) DO UPDATE
+		SET requests = cortex_usage.requests + EXCLUDED.requests,
+		    tokens = cortex_usage.tokens + EXCLUDED.tokens
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, domain.CortexUsageDay(day), requests, tokens)
+	return err
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fastinghero/internal/core/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresDisciplineRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresDisciplineRepository(db *sql.DB) *PostgresDisciplineRepository {
+	return &PostgresDisciplineRepository{db: db}
+}
+
+func (r *PostgresDisciplineRepository) AppendEvent(ctx context.Context, event *domain.DisciplineEvent) (bool, error) {
+	query := `
+		INSERT INTO discipline_events (id, user_id, event_type, delta, score_after, reason, source_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, event_type, source_id) DO NOTHING
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		event.ID, event.UserID, event.Type, event.Delta, event.ScoreAfter, event.Reason, event.SourceID, event.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgresDisciplineRepository) ListEvents(ctx context.Context, userID uuid.UUID, since time.Time) ([]domain.DisciplineEvent, error) {
+	query := `
+		SELECT id, user_id, event_type, delta, score_after, reason, source_id, created_at
+		FROM discipline_events
+		WHERE user_id = $1 AND created_at >= $2
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.DisciplineEvent
+	for rows.Next() {
+		var e domain.DisciplineEvent
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Type, &e.Delta, &e.ScoreAfter, &e.Reason, &e.SourceID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (r *PostgresDisciplineRepository) LastEventAt(ctx context.Context, userID uuid.UUID, eventType domain.DisciplineEventType) (*time.Time, error) {
+	query := `SELECT MAX(created_at) FROM discipline_events WHERE user_id = $1 AND event_type = $2`
+	var last sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, userID, eventType).Scan(&last); err != nil {
+		return nil, err
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	return &last.Time, nil
+}
+
+func (r *PostgresDisciplineRepository) SaveSnapshot(ctx context.Context, snapshot *domain.DisciplineSnapshot) (bool, error) {
+	query := `
+		INSERT INTO discipline_snapshots (user_id, day, score, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, day) DO NOTHING
+	`
+	res, err := r.db.ExecContext(ctx, query, snapshot.UserID, snapshot.Day, snapshot.Score, snapshot.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgresDisciplineRepository) ListSnapshots(ctx context.Context, userID uuid.UUID, from time.Time) ([]domain.DisciplineSnapshot, error) {
+	query := `
+		SELECT user_id, day, score, created_at
+		FROM discipline_snapshots
+		WHERE user_id = $1 AND day >= $2
+		ORDER BY day
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []domain.DisciplineSnapshot
+	for rows.Next() {
+		var s domain.DisciplineSnapshot
+		if err := rows.Scan(&s.UserID, &s.Day, &s.Score, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
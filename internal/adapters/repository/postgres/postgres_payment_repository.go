@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fastinghero/internal/core/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresPaymentRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPaymentRepository(db *sql.DB) *PostgresPaymentRepository {
+	return &PostgresPaymentRepository{db: db}
+}
+
+const paymentColumns = `id, user_id, kind, amount, currency, provider_id, reference, description, paid_at, created_at`
+
+func (r *PostgresPaymentRepository) Record(ctx context.Context, payment *domain.Payment) (bool, error) {
+	query := `
+		INSERT INTO payments (` + paymentColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (kind, provider_id) DO NOTHING
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		payment.ID, payment.UserID, payment.Kind, payment.Amount, payment.Currency, payment.ProviderID,
+		sql.NullString{String: payment.Reference, Valid: payment.Reference != ""}, payment.Description, payment.PaidAt, payment.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgresPaymentRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+paymentColumns+` FROM payments WHERE id = $1`, id)
+	payment, err := scanPayment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (r *PostgresPaymentRepository) ListByUserID(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE user_id = $1 AND paid_at >= $2 AND paid_at < $3
+		ORDER BY paid_at, created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []domain.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+	return payments, rows.Err()
+}
+
+func scanPayment(row rowScanner) (*domain.Payment, error) {
+	var p domain.Payment
+	var reference sql.NullString
+	if err := row.Scan(&p.ID, &p.UserID, &p.Kind, &p.Amount, &p.Currency, &p.ProviderID, &reference, &p.Description, &p.PaidAt, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	p.Reference = reference.String
+	return &p, nil
+}
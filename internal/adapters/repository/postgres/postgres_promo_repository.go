@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastinghero/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PostgresPromoCodeRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPromoCodeRepository(db *sql.DB) *PostgresPromoCodeRepository {
+	return &PostgresPromoCodeRepository{db: db}
+}
+
+const promoCodeColumns = `
+	id, code, COALESCE(description, ''), discount_type, percent_off, amount_off, duration, duration_months,
+	max_redemptions, times_redeemed, plans, expires_at, active, coupon_id, COALESCE(created_by, '00000000-0000-0000-0000-000000000000'),
+	created_at, updated_at
+`
+
+func (r *PostgresPromoCodeRepository) Create(ctx context.Context, promo *domain.PromoCode) (bool, error) {
+	query := `
+		INSERT INTO promo_codes (
+			id, code, description, discount_type, percent_off, amount_off, duration, duration_months,
+			max_redemptions, times_redeemed, plans, expires_at, active, coupon_id, created_by, created_at, updated_at
+		)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, 0, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (code) DO NOTHING
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		promo.ID, promo.Code, promo.Description, promo.DiscountType, promo.PercentOff, promo.AmountOff,
+		promo.Duration, promo.DurationMonths, promo.MaxRedemptions, pq.Array(planStrings(promo.Plans)),
+		promo.ExpiresAt, promo.Active, promo.CouponID, promo.CreatedBy, promo.CreatedAt, promo.UpdatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// Update changes what an admin can change after creation; the discount terms are fixed
+// because the coupon already carries them
+func (r *PostgresPromoCodeRepository) Update(ctx context.Context, promo *domain.PromoCode) error {
+	query := `
+		UPDATE promo_codes
+		SET description = NULLIF($2, ''), active = $3, updated_at = NOW()
+		WHERE id = $1
+	`
+	res, err := r.db.ExecContext(ctx, query, promo.ID, promo.Description, promo.Active)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("promo code not found")
+	}
+	return nil
+}
+
+func (r *PostgresPromoCodeRepository) FindByCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	query := `SELECT ` + promoCodeColumns + ` FROM promo_codes WHERE code = $1`
+	promo, err := scanPromoCode(r.db.QueryRowContext(ctx, query, code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return promo, nil
+}
+
+func (r *PostgresPromoCodeRepository) List(ctx context.Context) ([]*domain.PromoCode, error) {
+	query := `SELECT ` + promoCodeColumns + ` FROM promo_codes ORDER BY created_at DESC, code`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promos []*domain.PromoCode
+	for rows.Next() {
+		promo, err := scanPromoCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		promos = append(promos, promo)
+	}
+	return promos, rows.Err()
+}
+
+// Redeem inserts the redemption and bumps the code's counter in one transaction
+func (r *PostgresPromoCodeRepository) Redeem(ctx context.Context, redemption *domain.PromoRedemption) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO promo_redemptions (id, promo_code_id, user_id, plan, checkout_session_id, subscription_id, redeemed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (promo_code_id, user_id) DO NOTHING
+	`, redemption.ID, redemption.PromoCodeID, redemption.UserID, redemption.Plan,
+		redemption.CheckoutSessionID, redemption.SubscriptionID, redemption.RedeemedAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE promo_codes SET times_redeemed = times_redeemed + 1, updated_at = NOW() WHERE id = $1
+	`, redemption.PromoCodeID); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *PostgresPromoCodeRepository) FindRedemption(ctx context.Context, promoCodeID, userID uuid.UUID) (*domain.PromoRedemption, error) {
+	query := `
+		SELECT r.id, r.promo_code_id, p.code, r.user_id, r.plan, r.checkout_session_id, r.subscription_id, r.redeemed_at
+		FROM promo_redemptions r JOIN promo_codes p ON p.id = r.promo_code_id
+		WHERE r.promo_code_id = $1 AND r.user_id = $2
+	`
+	redemption, err := scanPromoRedemption(r.db.QueryRowContext(ctx, query, promoCodeID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return redemption, nil
+}
+
+func (r *PostgresPromoCodeRepository) ListRedemptions(ctx context.Context, promoCodeID uuid.UUID) ([]*domain.PromoRedemption, error) {
+	query := `
+		SELECT r.id, r.promo_code_id, p.code, r.user_id, r.plan, r.checkout_session_id, r.subscription_id, r.redeemed_at
+		FROM promo_redemptions r JOIN promo_codes p ON p.id = r.promo_code_id
+		WHERE r.promo_code_id = $1
+		ORDER BY r.redeemed_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, promoCodeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var redemptions []*domain.PromoRedemption
+	for rows.Next() {
+		redemption, err := scanPromoRedemption(rows)
+		if err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, redemption)
+	}
+	return redemptions, rows.Err()
+}
+
+func scanPromoCode(row rowScanner) (*domain.PromoCode, error) {
+	var p domain.PromoCode
+	var plans []string
+	if err := row.Scan(
+		&p.ID, &p.Code, &p.Description, &p.DiscountType, &p.PercentOff, &p.AmountOff, &p.Duration, &p.DurationMonths,
+		&p.MaxRedemptions, &p.TimesRedeemed, pq.Array(&plans), &p.ExpiresAt, &p.Active, &p.CouponID, &p.CreatedBy,
+		&p.CreatedAt, &p.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	for _, plan := range plans {
+		p.Plans = append(p.Plans, domain.SubscriptionTier(plan))
+	}
+	return &p, nil
+}
+
+func scanPromoRedemption(row rowScanner) (*domain.PromoRedemption, error) {
+	var r domain.PromoRedemption
+	if err := row.Scan(
+		&r.ID, &r.PromoCodeID, &r.Code, &r.UserID, &r.Plan, &r.CheckoutSessionID, &r.SubscriptionID, &r.RedeemedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func planStrings(plans []domain.SubscriptionTier) []string {
+	out := make([]string, len(plans))
+	for i, plan := range plans {
+		out[i] = string(plan)
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fastinghero/internal/core/domain"
+)
+
+type PostgresPromptExposureRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPromptExposureRepository(db *sql.DB) *PostgresPromptExposureRepository {
+	return &PostgresPromptExposureRepository{db: db}
+}
+
+func (r *PostgresPromptExposureRepository) Record(ctx context.Context, exposure *domain.PromptExposure) error {
+	query := `
+		INSERT INTO prompt_exposures (id, user_id, prompt, version, variant, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		exposure.ID, exposure.UserID, exposure.Prompt, exposure.Version, exposure.Variant, exposure.CreatedAt)
+	return err
+}
+
+func (r *PostgresPromptExposureRepository) SummarizeExposures(ctx context.Context) ([]domain.PromptExposureSummary, error) {
+	query := `
+		SELECT prompt, version, variant, user_id, MIN(created_at), COUNT(*)
+		FROM prompt_exposures
+		GROUP BY prompt, version, variant, user_id
+		ORDER BY prompt, version, variant, user_id
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []domain.PromptExposureSummary
+	for rows.Next() {
+		var s domain.PromptExposureSummary
+		if err := rows.Scan(&s.Prompt, &s.Version, &s.Variant, &s.UserID, &s.FirstExposedAt, &s.Responses); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
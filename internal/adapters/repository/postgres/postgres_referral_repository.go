@@ -3,9 +3,11 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fastinghero/internal/core/domain"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type PostgresReferralRepository struct {
@@ -16,10 +18,16 @@ func NewPostgresReferralRepository(db *sql.DB) *PostgresReferralRepository {
 	return &PostgresReferralRepository{db: db}
 }
 
+const referralColumns = `
+	id, referrer_id, referee_id, status, reward_value, referee_reward, COALESCE(qualified_by, ''), qualified_at,
+	fraud_signals, reviewed_by, reviewed_at, COALESCE(review_note, ''), referrer_paid_at, referee_paid_at,
+	created_at, completed_at
+`
+
 func (r *PostgresReferralRepository) Save(ctx context.Context, referral *domain.Referral) error {
 	query := `
-		INSERT INTO referrals (id, referrer_id, referee_id, status, reward_value, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO referrals (id, referrer_id, referee_id, status, reward_value, referee_reward, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		referral.ID,
@@ -27,52 +35,108 @@ func (r *PostgresReferralRepository) Save(ctx context.Context, referral *domain.
 		referral.RefereeID,
 		referral.Status,
 		referral.RewardValue,
+		referral.RefereeReward,
 		referral.CreatedAt,
 	)
 	return err
 }
 
+func (r *PostgresReferralRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Referral, error) {
+	query := `SELECT ` + referralColumns + ` FROM referrals WHERE id = $1`
+	return r.findOne(ctx, query, id)
+}
+
 func (r *PostgresReferralRepository) FindByRefereeID(ctx context.Context, refereeID uuid.UUID) (*domain.Referral, error) {
+	query := `SELECT ` + referralColumns + ` FROM referrals WHERE referee_id = $1`
+	return r.findOne(ctx, query, refereeID)
+}
+
+func (r *PostgresReferralRepository) FindByReferrerID(ctx context.Context, referrerID uuid.UUID) ([]domain.Referral, error) {
+	query := `SELECT ` + referralColumns + ` FROM referrals WHERE referrer_id = $1 ORDER BY created_at`
+	return r.list(ctx, query, referrerID)
+}
+
+func (r *PostgresReferralRepository) ListByStatus(ctx context.Context, status domain.ReferralStatus) ([]domain.Referral, error) {
+	query := `SELECT ` + referralColumns + ` FROM referrals WHERE status = $1 ORDER BY created_at`
+	return r.list(ctx, query, status)
+}
+
+func (r *PostgresReferralRepository) ListUnpaid(ctx context.Context) ([]domain.Referral, error) {
 	query := `
-		SELECT id, referrer_id, referee_id, status, reward_value, created_at, completed_at
-		FROM referrals
-		WHERE referee_id = $1
+		SELECT ` + referralColumns + ` FROM referrals
+		WHERE status = 'completed' AND (referrer_paid_at IS NULL OR referee_paid_at IS NULL)
+		ORDER BY created_at
 	`
-	row := r.db.QueryRowContext(ctx, query, refereeID)
-
-	var referral domain.Referral
-	var completedAt sql.NullTime
+	return r.list(ctx, query)
+}
 
-	err := row.Scan(
-		&referral.ID,
-		&referral.ReferrerID,
-		&referral.RefereeID,
-		&referral.Status,
-		&referral.RewardValue,
-		&referral.CreatedAt,
-		&completedAt,
+func (r *PostgresReferralRepository) Update(ctx context.Context, referral *domain.Referral) error {
+	query := `
+		UPDATE referrals
+		SET status = $2, reward_value = $3, referee_reward = $4, qualified_by = NULLIF($5, ''), qualified_at = $6,
+			fraud_signals = $7, reviewed_by = $8, reviewed_at = $9, review_note = NULLIF($10, ''),
+			referrer_paid_at = $11, referee_paid_at = $12, completed_at = $13
+		WHERE id = $1
+	`
+	signals := make([]string, len(referral.FraudSignals))
+	for i, signal := range referral.FraudSignals {
+		signals[i] = string(signal)
+	}
+	res, err := r.db.ExecContext(ctx, query,
+		referral.ID, referral.Status, referral.RewardValue, referral.RefereeReward, referral.QualifiedBy,
+		referral.QualifiedAt, pq.Array(signals), referral.ReviewedBy, referral.ReviewedAt, referral.ReviewNote,
+		referral.ReferrerPaidAt, referral.RefereePaidAt, referral.CompletedAt,
 	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("referral not found")
+	}
+	return nil
+}
+
+// SaveSignupSignals keeps the first signals recorded for a user
+func (r *PostgresReferralRepository) SaveSignupSignals(ctx context.Context, signals *domain.SignupSignals) error {
+	query := `
+		INSERT INTO signup_signals (user_id, ip, device_id, user_agent, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query, signals.UserID, signals.IP, signals.DeviceID, signals.UserAgent, signals.CreatedAt)
+	return err
+}
+
+func (r *PostgresReferralRepository) FindSignupSignals(ctx context.Context, userID uuid.UUID) (*domain.SignupSignals, error) {
+	query := `SELECT user_id, ip, device_id, user_agent, created_at FROM signup_signals WHERE user_id = $1`
+	var signals domain.SignupSignals
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&signals.UserID, &signals.IP, &signals.DeviceID, &signals.UserAgent, &signals.CreatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
+	return &signals, nil
+}
 
-	if completedAt.Valid {
-		referral.CompletedAt = &completedAt.Time
+func (r *PostgresReferralRepository) findOne(ctx context.Context, query string, args ...interface{}) (*domain.Referral, error) {
+	referral, err := scanReferral(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
 	}
-
-	return &referral, nil
+	return referral, nil
 }
 
-func (r *PostgresReferralRepository) FindByReferrerID(ctx context.Context, referrerID uuid.UUID) ([]domain.Referral, error) {
-	query := `
-		SELECT id, referrer_id, referee_id, status, reward_value, created_at, completed_at
-		FROM referrals
-		WHERE referrer_id = $1
-	`
-	rows, err := r.db.QueryContext(ctx, query, referrerID)
+func (r *PostgresReferralRepository) list(ctx context.Context, query string, args ...interface{}) ([]domain.Referral, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -80,33 +144,44 @@ func (r *PostgresReferralRepository) FindByReferrerID(ctx context.Context, refer
 
 	var referrals []domain.Referral
 	for rows.Next() {
-		var referral domain.Referral
-		var completedAt sql.NullTime
-		if err := rows.Scan(
-			&referral.ID,
-			&referral.ReferrerID,
-			&referral.RefereeID,
-			&referral.Status,
-			&referral.RewardValue,
-			&referral.CreatedAt,
-			&completedAt,
-		); err != nil {
+		referral, err := scanReferral(rows)
+		if err != nil {
 			return nil, err
 		}
-		if completedAt.Valid {
-			referral.CompletedAt = &completedAt.Time
-		}
-		referrals = append(referrals, referral)
+		referrals = append(referrals, *referral)
 	}
-	return referrals, nil
+	return referrals, rows.Err()
 }
 
-func (r *PostgresReferralRepository) Update(ctx context.Context, referral *domain.Referral) error {
-	query := `
-		UPDATE referrals
-		SET status = $1, completed_at = $2
-		WHERE id = $3
-	`
-	_, err := r.db.ExecContext(ctx, query, referral.Status, referral.CompletedAt, referral.ID)
-	return err
+func scanReferral(row rowScanner) (*domain.Referral, error) {
+	var referral domain.Referral
+	var signals []string
+	var reviewedBy uuid.NullUUID
+	if err := row.Scan(
+		&referral.ID,
+		&referral.ReferrerID,
+		&referral.RefereeID,
+		&referral.Status,
+		&referral.RewardValue,
+		&referral.RefereeReward,
+		&referral.QualifiedBy,
+		&referral.QualifiedAt,
+		pq.Array(&signals),
+		&reviewedBy,
+		&referral.ReviewedAt,
+		&referral.ReviewNote,
+		&referral.ReferrerPaidAt,
+		&referral.RefereePaidAt,
+		&referral.CreatedAt,
+		&referral.CompletedAt,
+	); err != nil {
+		return nil, err
+	}
+	for _, signal := range signals {
+		referral.FraudSignals = append(referral.FraudSignals, domain.ReferralFraudSignal(signal))
+	}
+	if reviewedBy.Valid {
+		referral.ReviewedBy = &reviewedBy.UUID
+	}
+	return &referral, nil
 }
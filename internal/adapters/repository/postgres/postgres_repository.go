@@ -3,12 +3,11 @@ package postgres
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fastinghero/internal/core/domain"
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type PostgresUserRepository struct {
@@ -111,7 +110,128 @@ func (r *PostgresUserRepository) FindByReferralCode(ctx context.Context, code st
 	return r.scanUser(r.db.QueryRowContext(ctx, query, code))
 }
 
-func (r *PostgresUserRepository) scanUser(row *sql.Row) (*domain.User, error) {
+func (r *PostgresUserRepository) FindByStripeCustomerID(ctx context.Context, customerID string) (*domain.User, error) {
+	query := `
+		SELECT id, email, password_hash, name, onboarding_completed, goal, fasting_plan, sex, height_cm, 
+		current_weight_lbs, target_weight_lbs, timezone, units, stripe_customer_id, subscription_tier, 
+		subscription_status, subscription_id, vault_enabled, trial_ends_at, discipline_index, 
+		current_price, vault_deposit, earned_refund, tribe_id, referral_code, signed_contract, 
+		push_notifications_enabled, notification_token, created_at, updated_at
+		FROM users WHERE stripe_customer_id = $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	return r.scanUser(rows)
+}
+
+func (r *PostgresUserRepository) ListVaultMembers(ctx context.Context, afterID uuid.UUID, limit int) ([]*domain.User, error) {
+	query := `
+		SELECT id, email, password_hash, name, onboarding_completed, goal, fasting_plan, sex, height_cm, 
+		current_weight_lbs, target_weight_lbs, timezone, units, stripe_customer_id, subscription_tier, 
+		subscription_status, subscription_id, vault_enabled, trial_ends_at, discipline_index, 
+		current_price, vault_deposit, earned_refund, tribe_id, referral_code, signed_contract, 
+		push_notifications_enabled, notification_token, created_at, updated_at
+		FROM users
+		WHERE subscription_tier = ANY($1) AND subscription_status = ANY($2) AND id > $3
+		AND NOT (subscription_status = 'trialing' AND COALESCE(subscription_id, '') = '' AND trial_ends_at <= NOW())
+		ORDER BY id
+		LIMIT $4
+	`
+	// The last condition drops in-app trials that have run out but haven't been downgraded yet,
+	// matching User.IsVaultMember
+	var tiers []string
+	for _, tier := range domain.PlansWith(domain.CapabilityVault) {
+		tiers = append(tiers, string(tier))
+	}
+	statuses := []string{string(domain.SubStatusActive), string(domain.SubStatusTrialing), string(domain.SubStatusPastDue)}
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(tiers), pq.Array(statuses), afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := r.scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r *PostgresUserRepository) ListWithDisciplineScore(ctx context.Context, afterID uuid.UUID, limit int) ([]*domain.User, error) {
+	query := `
+		SELECT id, email, password_hash, name, onboarding_completed, goal, fasting_plan, sex, height_cm, 
+		current_weight_lbs, target_weight_lbs, timezone, units, stripe_customer_id, subscription_tier, 
+		subscription_status, subscription_id, vault_enabled, trial_ends_at, discipline_index, 
+		current_price, vault_deposit, earned_refund, tribe_id, referral_code, signed_contract, 
+		push_notifications_enabled, notification_token, created_at, updated_at
+		FROM users
+		WHERE discipline_index > 0 AND id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := r.scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r *PostgresUserRepository) UpdateEarnedRefund(ctx context.Context, userID uuid.UUID, earnedRefund float64) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET earned_refund = $1, updated_at = NOW() WHERE id = $2`, earnedRefund, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *PostgresUserRepository) UpdateDisciplineIndex(ctx context.Context, userID uuid.UUID, disciplineIndex float64) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET discipline_index = $1, updated_at = NOW() WHERE id = $2`, disciplineIndex, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *PostgresUserRepository) scanUser(row rowScanner) (*domain.User, error) {
 	var user domain.User
 	var subTier string
 	var subStatus string
@@ -134,7 +254,7 @@ func (r *PostgresUserRepository) scanUser(row *sql.Row) (*domain.User, error) {
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, domain.ErrUserNotFound
 		}
 		return nil, err
 	}
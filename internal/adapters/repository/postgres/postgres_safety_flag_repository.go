@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastinghero/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PostgresSafetyFlagRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresSafetyFlagRepository(db *sql.DB) *PostgresSafetyFlagRepository {
+	return &PostgresSafetyFlagRepository{db: db}
+}
+
+const safetyFlagColumns = `
+	id, user_id, source, stage, signals, COALESCE(bmi, 0), excerpt, status,
+	reviewed_by, reviewed_at, COALESCE(review_note, ''), created_at
+`
+
+func (r *PostgresSafetyFlagRepository) Save(ctx context.Context, flag *domain.SafetyFlag) error {
+	query := `
+		INSERT INTO safety_flags (id, user_id, source, stage, signals, bmi, excerpt, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7, $8, $9)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		flag.ID,
+		flag.UserID,
+		flag.Source,
+		flag.Stage,
+		pq.Array(safetySignalStrings(flag.Signals)),
+		flag.BMI,
+		flag.Excerpt,
+		flag.Status,
+		flag.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresSafetyFlagRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.SafetyFlag, error) {
+	query := `SELECT ` + safetyFlagColumns + ` FROM safety_flags WHERE id = $1`
+	flag, err := scanSafetyFlag(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return flag, nil
+}
+
+func (r *PostgresSafetyFlagRepository) ListByStatus(ctx context.Context, status domain.SafetyFlagStatus) ([]domain.SafetyFlag, error) {
+	query := `SELECT ` + safetyFlagColumns + ` FROM safety_flags WHERE status = $1 ORDER BY created_at`
+	rows, err := r.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flags []domain.SafetyFlag
+	for rows.Next() {
+		flag, err := scanSafetyFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, *flag)
+	}
+	return flags, rows.Err()
+}
+
+func (r *PostgresSafetyFlagRepository) HasOpenFlag(ctx context.Context, userID uuid.UUID, signal domain.SafetySignal) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM safety_flags WHERE user_id = $1 AND status = 'open' AND $2 = ANY(signals))`
+	var open bool
+	err := r.db.QueryRowContext(ctx, query, userID, string(signal)).Scan(&open)
+	return open, err
+}
+
+func (r *PostgresSafetyFlagRepository) Update(ctx context.Context, flag *domain.SafetyFlag) error {
+	query := `
+		UPDATE safety_flags
+		SET status = $2, reviewed_by = $3, reviewed_at = $4, review_note = NULLIF($5, '')
+		WHERE id = $1
+	`
+	res, err := r.db.ExecContext(ctx, query, flag.ID, flag.Status, flag.ReviewedBy, flag.ReviewedAt, flag.ReviewNote)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("safety flag not found")
+	}
+	return nil
+}
+
+func safetySignalStrings(signals []domain.SafetySignal) []string {
+	strs := make([]string, len(signals))
+	for i, signal := range signals {
+		strs[i] = string(signal)
+	}
+	return strs
+}
+
+func scanSafetyFlag(row rowScanner) (*domain.SafetyFlag, error) {
+	var flag domain.SafetyFlag
+	var signals []string
+	var reviewedBy uuid.NullUUID
+	if err := row.Scan(
+		&flag.ID,
+		&flag.UserID,
+		&flag.Source,
+		&flag.Stage,
+		pq.Array(&signals),
+		&flag.BMI,
+		&flag.Excerpt,
+		&flag.Status,
+		&reviewedBy,
+		&flag.ReviewedAt,
+		&flag.ReviewNote,
+		&flag.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	for _, signal := range signals {
+		flag.Signals = append(flag.Signals, domain.SafetySignal(signal))
+	}
+	if reviewedBy.Valid {
+		flag.ReviewedBy = &reviewedBy.UUID
+	}
+	return &flag, nil
+}
@@ -112,28 +112,48 @@ func (r *PostgresSocialRepository) FindAllTribes(ctx context.Context, limit, off
 }
 
 // Challenges
+const friendChallengeColumns = `
+	id, creator_id, name, challenge_type, goal, start_date, end_date, status, winner_id,
+	COALESCE(pot_amount, 0), COALESCE(payout_amount, 0), COALESCE(payout_processed, FALSE), payout_date,
+	created_at, updated_at
+`
+
+const friendChallengeParticipantColumns = `
+	id, challenge_id, user_id, invited_by, status, deposit_amount, COALESCE(deposit_charge_id, ''), progress, is_winner,
+	payout_amount, payout_processed, responded_at, created_at, updated_at
+`
+
 func (r *PostgresSocialRepository) SaveChallenge(ctx context.Context, c *domain.FriendChallenge) error {
 	query := `
 		INSERT INTO friend_challenges (
-			id, creator_id, name, challenge_type, goal, start_date, end_date, status, created_at
+			id, creator_id, name, challenge_type, goal, start_date, end_date, status, winner_id,
+			pot_amount, payout_amount, payout_processed, payout_date, created_at, updated_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW())
 		ON CONFLICT (id) DO UPDATE SET
 			name = EXCLUDED.name,
 			goal = EXCLUDED.goal,
 			end_date = EXCLUDED.end_date,
-			status = EXCLUDED.status
+			status = EXCLUDED.status,
+			winner_id = EXCLUDED.winner_id,
+			pot_amount = EXCLUDED.pot_amount,
+			payout_amount = EXCLUDED.payout_amount,
+			payout_processed = EXCLUDED.payout_processed,
+			payout_date = EXCLUDED.payout_date,
+			updated_at = NOW()
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		c.ID, c.CreatorID, c.Name, c.ChallengeType, c.Goal, c.StartDate, c.EndDate, c.Status, c.CreatedAt,
+		c.ID, c.CreatorID, c.Name, c.ChallengeType, c.Goal, c.StartDate, c.EndDate, c.Status, c.WinnerID,
+		c.PotAmount, c.PayoutAmount, c.PayoutProcessed, c.PayoutDate, c.CreatedAt,
 	)
 	return err
 }
 
 func (r *PostgresSocialRepository) FindChallengesByUserID(ctx context.Context, userID uuid.UUID) ([]domain.FriendChallenge, error) {
-	query := `
-		SELECT id, creator_id, name, challenge_type, goal, start_date, end_date, status, created_at
-		FROM friend_challenges WHERE creator_id = $1
+	query := `SELECT ` + friendChallengeColumns + ` FROM friend_challenges
+		WHERE creator_id = $1
+		   OR id IN (SELECT challenge_id FROM friend_challenge_participants WHERE user_id = $1)
+		ORDER BY created_at DESC
 	`
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
@@ -143,15 +163,129 @@ func (r *PostgresSocialRepository) FindChallengesByUserID(ctx context.Context, u
 
 	var challenges []domain.FriendChallenge
 	for rows.Next() {
-		var c domain.FriendChallenge
-		if err := rows.Scan(
-			&c.ID, &c.CreatorID, &c.Name, &c.ChallengeType, &c.Goal, &c.StartDate, &c.EndDate, &c.Status, &c.CreatedAt,
-		); err != nil {
+		c, err := scanFriendChallenge(rows)
+		if err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, *c)
+	}
+	return challenges, rows.Err()
+}
+
+func (r *PostgresSocialRepository) FindChallengeByID(ctx context.Context, id uuid.UUID) (*domain.FriendChallenge, error) {
+	query := `SELECT ` + friendChallengeColumns + ` FROM friend_challenges WHERE id = $1`
+	c, err := scanFriendChallenge(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *PostgresSocialRepository) FindEndedChallenges(ctx context.Context, now time.Time) ([]*domain.FriendChallenge, error) {
+	query := `SELECT ` + friendChallengeColumns + ` FROM friend_challenges
+		WHERE status = $1 AND end_date <= $2
+		ORDER BY end_date
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.ChallengeStatusActive, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var challenges []*domain.FriendChallenge
+	for rows.Next() {
+		c, err := scanFriendChallenge(rows)
+		if err != nil {
 			return nil, err
 		}
 		challenges = append(challenges, c)
 	}
-	return challenges, nil
+	return challenges, rows.Err()
+}
+
+func (r *PostgresSocialRepository) SaveChallengeParticipant(ctx context.Context, p *domain.FriendChallengeParticipant) error {
+	query := `
+		INSERT INTO friend_challenge_participants (
+			id, challenge_id, user_id, invited_by, status, deposit_amount, deposit_charge_id, progress, is_winner,
+			payout_amount, payout_processed, responded_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11, $12, $13, NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			deposit_amount = EXCLUDED.deposit_amount,
+			deposit_charge_id = EXCLUDED.deposit_charge_id,
+			progress = EXCLUDED.progress,
+			is_winner = EXCLUDED.is_winner,
+			payout_amount = EXCLUDED.payout_amount,
+			payout_processed = EXCLUDED.payout_processed,
+			responded_at = EXCLUDED.responded_at,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		p.ID, p.ChallengeID, p.UserID, p.InvitedBy, p.Status, p.DepositAmount, p.DepositChargeID, p.Progress, p.IsWinner,
+		p.PayoutAmount, p.PayoutProcessed, p.RespondedAt, p.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresSocialRepository) FindChallengeParticipant(ctx context.Context, challengeID, userID uuid.UUID) (*domain.FriendChallengeParticipant, error) {
+	query := `SELECT ` + friendChallengeParticipantColumns + ` FROM friend_challenge_participants
+		WHERE challenge_id = $1 AND user_id = $2
+	`
+	p, err := scanFriendChallengeParticipant(r.db.QueryRowContext(ctx, query, challengeID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PostgresSocialRepository) FindChallengeParticipants(ctx context.Context, challengeID uuid.UUID) ([]*domain.FriendChallengeParticipant, error) {
+	query := `SELECT ` + friendChallengeParticipantColumns + ` FROM friend_challenge_participants
+		WHERE challenge_id = $1 ORDER BY created_at, id
+	`
+	rows, err := r.db.QueryContext(ctx, query, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var participants []*domain.FriendChallengeParticipant
+	for rows.Next() {
+		p, err := scanFriendChallengeParticipant(rows)
+		if err != nil {
+			return nil, err
+		}
+		participants = append(participants, p)
+	}
+	return participants, rows.Err()
+}
+
+func scanFriendChallenge(row rowScanner) (*domain.FriendChallenge, error) {
+	var c domain.FriendChallenge
+	if err := row.Scan(
+		&c.ID, &c.CreatorID, &c.Name, &c.ChallengeType, &c.Goal, &c.StartDate, &c.EndDate, &c.Status, &c.WinnerID,
+		&c.PotAmount, &c.PayoutAmount, &c.PayoutProcessed, &c.PayoutDate, &c.CreatedAt, &c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanFriendChallengeParticipant(row rowScanner) (*domain.FriendChallengeParticipant, error) {
+	var p domain.FriendChallengeParticipant
+	if err := row.Scan(
+		&p.ID, &p.ChallengeID, &p.UserID, &p.InvitedBy, &p.Status, &p.DepositAmount, &p.DepositChargeID, &p.Progress, &p.IsWinner,
+		&p.PayoutAmount, &p.PayoutProcessed, &p.RespondedAt, &p.CreatedAt, &p.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &p, nil
 }
 
 // Feed
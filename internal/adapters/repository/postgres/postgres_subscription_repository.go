@@ -108,3 +108,27 @@ func (r *PostgresSubscriptionRepository) FindByStripeSubscriptionID(ctx context.
 
 	return &sub, nil
 }
+
+type PostgresWebhookEventRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresWebhookEventRepository(db *sql.DB) *PostgresWebhookEventRepository {
+	return &PostgresWebhookEventRepository{db: db}
+}
+
+func (r *PostgresWebhookEventRepository) IsProcessed(ctx context.Context, eventID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM processed_webhook_events WHERE id = $1)`, eventID).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresWebhookEventRepository) MarkProcessed(ctx context.Context, event *domain.WebhookEvent) error {
+	query := `
+		INSERT INTO processed_webhook_events (id, event_type, processed_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query, event.ID, event.Type, event.ProcessedAt)
+	return err
+}
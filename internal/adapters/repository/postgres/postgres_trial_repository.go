@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresTrialRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTrialRepository(db *sql.DB) *PostgresTrialRepository {
+	return &PostgresTrialRepository{db: db}
+}
+
+const trialColumns = `
+	id, user_id, plan, source, COALESCE(campaign, ''), email_key, COALESCE(payment_fingerprint, ''), status,
+	started_at, ends_at, reminder_sent_at, ended_at, created_at, updated_at
+`
+
+// Create relies on the unique indexes on user, email key and fingerprint: any conflict means
+// a trial was already used
+func (r *PostgresTrialRepository) Create(ctx context.Context, trial *domain.Trial) (bool, error) {
+	query := `
+		INSERT INTO trials (
+			id, user_id, plan, source, campaign, email_key, payment_fingerprint, status,
+			started_at, ends_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''), $8, $9, $10, $11, $12)
+		ON CONFLICT DO NOTHING
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		trial.ID, trial.UserID, trial.Plan, trial.Source, trial.Campaign, trial.EmailKey, trial.PaymentFingerprint,
+		trial.Status, trial.StartedAt, trial.EndsAt, trial.CreatedAt, trial.UpdatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgresTrialRepository) Update(ctx context.Context, trial *domain.Trial) error {
+	query := `
+		UPDATE trials
+		SET status = $2, reminder_sent_at = $3, ended_at = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+	res, err := r.db.ExecContext(ctx, query, trial.ID, trial.Status, trial.ReminderSentAt, trial.EndedAt)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("trial not found")
+	}
+	return nil
+}
+
+func (r *PostgresTrialRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*domain.Trial, error) {
+	query := `SELECT ` + trialColumns + ` FROM trials WHERE user_id = $1`
+	trial, err := scanTrial(r.db.QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return trial, nil
+}
+
+func (r *PostgresTrialRepository) ListActiveEndingBefore(ctx context.Context, before time.Time) ([]*domain.Trial, error) {
+	query := `SELECT ` + trialColumns + ` FROM trials WHERE status = $1 AND ends_at < $2 ORDER BY ends_at`
+	rows, err := r.db.QueryContext(ctx, query, domain.TrialStatusActive, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trials []*domain.Trial
+	for rows.Next() {
+		trial, err := scanTrial(rows)
+		if err != nil {
+			return nil, err
+		}
+		trials = append(trials, trial)
+	}
+	return trials, rows.Err()
+}
+
+func scanTrial(row rowScanner) (*domain.Trial, error) {
+	var t domain.Trial
+	if err := row.Scan(
+		&t.ID, &t.UserID, &t.Plan, &t.Source, &t.Campaign, &t.EmailKey, &t.PaymentFingerprint, &t.Status,
+		&t.StartedAt, &t.EndsAt, &t.ReminderSentAt, &t.EndedAt, &t.CreatedAt, &t.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
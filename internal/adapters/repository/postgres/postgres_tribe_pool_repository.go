@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresTribePoolRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTribePoolRepository(db *sql.DB) *PostgresTribePoolRepository {
+	return &PostgresTribePoolRepository{db: db}
+}
+
+const tribePoolColumns = `
+	id, tribe_id, month_start, month_end, total_pot, participant_count, COALESCE(status, 'open'),
+	COALESCE(first_place_payout, 0), COALESCE(second_place_payout, 0), COALESCE(third_place_payout, 0),
+	created_at, updated_at
+`
+
+const tribePoolParticipantColumns = `
+	id, pool_id, user_id, deposit_amount, COALESCE(deposit_charge_id, ''), fasts_completed, COALESCE(final_rank, 0),
+	payout_amount, payout_processed, created_at, updated_at
+`
+
+func (r *PostgresTribePoolRepository) CreatePool(ctx context.Context, pool *domain.TribePool) (bool, error) {
+	query := `
+		INSERT INTO tribe_pools (
+			id, tribe_id, month_start, month_end, total_pot, participant_count, status,
+			first_place_payout, second_place_payout, third_place_payout, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (tribe_id, month_start) DO NOTHING
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		pool.ID, pool.TribeID, pool.MonthStart, pool.MonthEnd, pool.TotalPot, pool.ParticipantCount, pool.Status,
+		pool.FirstPlacePayout, pool.SecondPlacePayout, pool.ThirdPlacePayout, pool.CreatedAt, pool.UpdatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgresTribePoolRepository) UpdatePool(ctx context.Context, pool *domain.TribePool) error {
+	query := `
+		UPDATE tribe_pools
+		SET status = $2, first_place_payout = $3, second_place_payout = $4, third_place_payout = $5, updated_at = NOW()
+		WHERE id = $1
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		pool.ID, pool.Status, pool.FirstPlacePayout, pool.SecondPlacePayout, pool.ThirdPlacePayout,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return domain.ErrTribePoolNotFound
+	}
+	return nil
+}
+
+func (r *PostgresTribePoolRepository) FindPoolByID(ctx context.Context, id uuid.UUID) (*domain.TribePool, error) {
+	query := `SELECT ` + tribePoolColumns + ` FROM tribe_pools WHERE id = $1`
+	return r.findPool(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *PostgresTribePoolRepository) FindPoolByMonth(ctx context.Context, tribeID uuid.UUID, monthStart time.Time) (*domain.TribePool, error) {
+	query := `SELECT ` + tribePoolColumns + ` FROM tribe_pools WHERE tribe_id = $1 AND month_start = $2`
+	return r.findPool(r.db.QueryRowContext(ctx, query, tribeID, monthStart))
+}
+
+func (r *PostgresTribePoolRepository) ListPoolsByTribe(ctx context.Context, tribeID uuid.UUID) ([]*domain.TribePool, error) {
+	query := `SELECT ` + tribePoolColumns + ` FROM tribe_pools WHERE tribe_id = $1 ORDER BY month_start DESC`
+	return r.listPools(ctx, query, tribeID)
+}
+
+func (r *PostgresTribePoolRepository) ListPoolsByStatus(ctx context.Context, status string) ([]*domain.TribePool, error) {
+	query := `SELECT ` + tribePoolColumns + ` FROM tribe_pools WHERE status = $1 ORDER BY id`
+	return r.listPools(ctx, query, status)
+}
+
+// AddParticipant inserts the participant and grows the pot in one transaction. The pot update
+// only matches an open pool, so a join racing the month start is rejected.
+func (r *PostgresTribePoolRepository) AddParticipant(ctx context.Context, participant *domain.TribePoolParticipant) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO tribe_pool_participants (
+			id, pool_id, user_id, deposit_amount, deposit_charge_id, fasts_completed, payout_amount, payout_processed, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), 0, 0, FALSE, $6, $7)
+		ON CONFLICT (pool_id, user_id) DO NOTHING
+	`, participant.ID, participant.PoolID, participant.UserID, participant.DepositAmount, participant.DepositChargeID, participant.CreatedAt, participant.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	res, err = tx.ExecContext(ctx, `
+		UPDATE tribe_pools
+		SET total_pot = total_pot + $2, participant_count = participant_count + 1, updated_at = NOW()
+		WHERE id = $1 AND status = $3
+	`, participant.PoolID, participant.DepositAmount, domain.TribePoolStatusOpen)
+	if err != nil {
+		return false, err
+	}
+	rows, err = res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, domain.ErrTribePoolNotOpen
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *PostgresTribePoolRepository) UpdateParticipant(ctx context.Context, participant *domain.TribePoolParticipant) error {
+	query := `
+		UPDATE tribe_pool_participants
+		SET fasts_completed = $2, final_rank = $3, payout_amount = $4, payout_processed = $5, updated_at = NOW()
+		WHERE id = $1
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		participant.ID, participant.FastsCompleted, participant.FinalRank, participant.PayoutAmount, participant.PayoutProcessed,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("tribe pool participant not found")
+	}
+	return nil
+}
+
+func (r *PostgresTribePoolRepository) ListParticipants(ctx context.Context, poolID uuid.UUID) ([]*domain.TribePoolParticipant, error) {
+	query := `SELECT ` + tribePoolParticipantColumns + ` FROM tribe_pool_participants WHERE pool_id = $1 ORDER BY created_at`
+	rows, err := r.db.QueryContext(ctx, query, poolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var participants []*domain.TribePoolParticipant
+	for rows.Next() {
+		var p domain.TribePoolParticipant
+		if err := rows.Scan(
+			&p.ID, &p.PoolID, &p.UserID, &p.DepositAmount, &p.DepositChargeID, &p.FastsCompleted, &p.FinalRank,
+			&p.PayoutAmount, &p.PayoutProcessed, &p.CreatedAt, &p.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		participants = append(participants, &p)
+	}
+	return participants, rows.Err()
+}
+
+func (r *PostgresTribePoolRepository) findPool(row *sql.Row) (*domain.TribePool, error) {
+	var p domain.TribePool
+	err := row.Scan(
+		&p.ID, &p.TribeID, &p.MonthStart, &p.MonthEnd, &p.TotalPot, &p.ParticipantCount, &p.Status,
+		&p.FirstPlacePayout, &p.SecondPlacePayout, &p.ThirdPlacePayout, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PostgresTribePoolRepository) listPools(ctx context.Context, query string, arg interface{}) ([]*domain.TribePool, error) {
+	rows, err := r.db.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pools := []*domain.TribePool{}
+	for rows.Next() {
+		var p domain.TribePool
+		if err := rows.Scan(
+			&p.ID, &p.TribeID, &p.MonthStart, &p.MonthEnd, &p.TotalPot, &p.ParticipantCount, &p.Status,
+			&p.FirstPlacePayout, &p.SecondPlacePayout, &p.ThirdPlacePayout, &p.CreatedAt, &p.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		pools = append(pools, &p)
+	}
+	return pools, rows.Err()
+}
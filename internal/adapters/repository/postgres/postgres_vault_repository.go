@@ -21,10 +21,12 @@ func (r *PostgresVaultRepository) Save(ctx context.Context, vault *domain.VaultP
 	query := `
 		INSERT INTO vault_participations (
 			id, user_id, month_start, month_end, deposit_amount, fasts_completed, amount_recovered, 
-			refund_processed, refund_date, opted_in, forfeited_amount, created_at, updated_at
+			refund_processed, refund_date, opted_in, forfeited_amount, created_at, updated_at, deposit_charge_id
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''))
 		ON CONFLICT (id) DO UPDATE SET
+			deposit_amount = EXCLUDED.deposit_amount,
+			deposit_charge_id = EXCLUDED.deposit_charge_id,
 			fasts_completed = EXCLUDED.fasts_completed,
 			amount_recovered = EXCLUDED.amount_recovered,
 			refund_processed = EXCLUDED.refund_processed,
@@ -36,7 +38,7 @@ func (r *PostgresVaultRepository) Save(ctx context.Context, vault *domain.VaultP
 	_, err := r.db.ExecContext(ctx, query,
 		vault.ID, vault.UserID, vault.MonthStart, vault.MonthEnd, vault.DepositAmount, vault.FastsCompleted,
 		vault.AmountRecovered, vault.RefundProcessed, vault.RefundDate, vault.OptedIn, vault.ForfeitedAmount,
-		vault.CreatedAt, time.Now(),
+		vault.CreatedAt, time.Now(), vault.DepositChargeID,
 	)
 	return err
 }
@@ -44,7 +46,7 @@ func (r *PostgresVaultRepository) Save(ctx context.Context, vault *domain.VaultP
 func (r *PostgresVaultRepository) FindByUserIDAndMonth(ctx context.Context, userID uuid.UUID, monthStart time.Time) (*domain.VaultParticipation, error) {
 	query := `
 		SELECT id, user_id, month_start, month_end, deposit_amount, fasts_completed, amount_recovered, 
-		refund_processed, refund_date, opted_in, forfeited_amount, created_at, updated_at
+		refund_processed, refund_date, opted_in, forfeited_amount, created_at, updated_at, COALESCE(deposit_charge_id, '')
 		FROM vault_participations WHERE user_id = $1 AND month_start = $2
 	`
 	row := r.db.QueryRowContext(ctx, query, userID, monthStart)
@@ -54,7 +56,7 @@ func (r *PostgresVaultRepository) FindByUserIDAndMonth(ctx context.Context, user
 
 	err := row.Scan(
 		&v.ID, &v.UserID, &v.MonthStart, &v.MonthEnd, &v.DepositAmount, &v.FastsCompleted, &v.AmountRecovered,
-		&v.RefundProcessed, &refundDate, &v.OptedIn, &v.ForfeitedAmount, &v.CreatedAt, &v.UpdatedAt,
+		&v.RefundProcessed, &refundDate, &v.OptedIn, &v.ForfeitedAmount, &v.CreatedAt, &v.UpdatedAt, &v.DepositChargeID,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -65,3 +67,115 @@ func (r *PostgresVaultRepository) FindByUserIDAndMonth(ctx context.Context, user
 	v.RefundDate = refundDate
 	return &v, nil
 }
+
+func (r *PostgresVaultRepository) ListOpenByMonth(ctx context.Context, monthStart time.Time) ([]*domain.VaultParticipation, error) {
+	query := `
+		SELECT id, user_id, month_start, month_end, deposit_amount, fasts_completed, amount_recovered, 
+		refund_processed, refund_date, opted_in, forfeited_amount, created_at, updated_at, COALESCE(deposit_charge_id, '')
+		FROM vault_participations WHERE month_start = $1 AND refund_processed = FALSE
+		ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query, monthStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vaults []*domain.VaultParticipation
+	for rows.Next() {
+		var v domain.VaultParticipation
+		var refundDate *time.Time
+		if err := rows.Scan(
+			&v.ID, &v.UserID, &v.MonthStart, &v.MonthEnd, &v.DepositAmount, &v.FastsCompleted, &v.AmountRecovered,
+			&v.RefundProcessed, &refundDate, &v.OptedIn, &v.ForfeitedAmount, &v.CreatedAt, &v.UpdatedAt, &v.DepositChargeID,
+		); err != nil {
+			return nil, err
+		}
+		v.RefundDate = refundDate
+		vaults = append(vaults, &v)
+	}
+	return vaults, rows.Err()
+}
+
+func (r *PostgresVaultRepository) ClaimDailySettlement(ctx context.Context, settlement *domain.VaultDailySettlement) (bool, error) {
+	query := `
+		INSERT INTO vault_daily_settlements (id, user_id, settlement_date, amount, rule_version, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, settlement_date) DO NOTHING
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		settlement.ID, settlement.UserID, settlement.SettlementDate, settlement.Amount, settlement.RuleVersion, settlement.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgresVaultRepository) ReleaseDailySettlement(ctx context.Context, userID uuid.UUID, day time.Time) error {
+	query := `DELETE FROM vault_daily_settlements WHERE user_id = $1 AND settlement_date = $2`
+	_, err := r.db.ExecContext(ctx, query, userID, day)
+	return err
+}
+
+type PostgresVaultLedgerRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresVaultLedgerRepository(db *sql.DB) *PostgresVaultLedgerRepository {
+	return &PostgresVaultLedgerRepository{db: db}
+}
+
+func (r *PostgresVaultLedgerRepository) Append(ctx context.Context, entry *domain.VaultLedgerEntry) (bool, error) {
+	query := `
+		INSERT INTO vault_ledger_entries (
+			id, user_id, entry_type, from_account, to_account, amount, reason,
+			source_type, source_id, effective_at, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (user_id, entry_type, source_type, source_id) WHERE source_id <> '' DO NOTHING
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		entry.ID, entry.UserID, entry.Type, entry.FromAccount, entry.ToAccount, entry.Amount, entry.Reason,
+		entry.SourceType, entry.SourceID, entry.EffectiveAt, entry.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgresVaultLedgerRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]domain.VaultLedgerEntry, error) {
+	query := `
+		SELECT id, user_id, entry_type, from_account, to_account, amount, reason,
+		source_type, source_id, effective_at, created_at
+		FROM vault_ledger_entries WHERE user_id = $1
+		ORDER BY effective_at, created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.VaultLedgerEntry
+	for rows.Next() {
+		var e domain.VaultLedgerEntry
+		if err := rows.Scan(
+			&e.ID, &e.UserID, &e.Type, &e.FromAccount, &e.ToAccount, &e.Amount, &e.Reason,
+			&e.SourceType, &e.SourceID, &e.EffectiveAt, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,149 @@
+package knowledge
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fastinghero/internal/core/domain"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// maxPassageWords bounds a passage so a retrieved chunk stays a small part of the prompt
+const maxPassageWords = 120
+
+// builtinArticles are the curated articles shipped with the server
+//
+//go:embed articles/*.md
+var builtinArticles embed.FS
+
+// LoadArticles reads every *.md article in dir. An empty dir returns the built-in articles.
+func LoadArticles(dir string) ([]domain.KnowledgeArticle, error) {
+	if dir == "" {
+		return readArticles(builtinArticles, "articles")
+	}
+	return readArticles(os.DirFS(dir), ".")
+}
+
+func readArticles(fsys fs.FS, dir string) ([]domain.KnowledgeArticle, error) {
+	paths, err := fs.Glob(fsys, path.Join(dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	articles := make([]domain.KnowledgeArticle, 0, len(paths))
+	for _, file := range paths {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("knowledge: failed to read article: %w", err)
+		}
+		article, err := ParseArticle(strings.TrimSuffix(path.Base(file), ".md"), data)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, article)
+	}
+	return articles, nil
+}
+
+// ParseArticle splits a markdown article into passages. The article starts with a front
+// matter block giving its title and source:
+//
+//	---
+//	title: Ketosis during fasting
+//	source: Anton SD, et al. Flipping the Metabolic Switch. Obesity. 2018.
+//	---
+//
+// Each "## " section becomes one or more passages of whole paragraphs.
+func ParseArticle(id string, markdown []byte) (domain.KnowledgeArticle, error) {
+	article := domain.KnowledgeArticle{ID: id}
+	scanner := bufio.NewScanner(bytes.NewReader(markdown))
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), " \t\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return article, fmt.Errorf("knowledge: article %s: %w", id, err)
+	}
+
+	if len(lines) > 0 && lines[0] == "---" {
+		end := 1
+		for ; end < len(lines) && lines[end] != "---"; end++ {
+			key, value, ok := strings.Cut(lines[end], ":")
+			if !ok {
+				continue
+			}
+			switch strings.TrimSpace(key) {
+			case "title":
+				article.Title = strings.TrimSpace(value)
+			case "source":
+				article.Source = strings.TrimSpace(value)
+			}
+		}
+		if end == len(lines) {
+			return article, fmt.Errorf("knowledge: article %s: unterminated front matter", id)
+		}
+		lines = lines[end+1:]
+	}
+	if article.Title == "" || article.Source == "" {
+		return article, fmt.Errorf("knowledge: article %s needs a title and a source", id)
+	}
+
+	heading := ""
+	var paragraph []string
+	var chunk []string
+	chunkWords := 0
+	flushChunk := func() {
+		if len(chunk) == 0 {
+			return
+		}
+		article.Passages = append(article.Passages, domain.KnowledgePassage{
+			ID:        fmt.Sprintf("%s#%d", id, len(article.Passages)+1),
+			ArticleID: id,
+			Title:     article.Title,
+			Heading:   heading,
+			Source:    article.Source,
+			Text:      strings.Join(chunk, "\n\n"),
+		})
+		chunk, chunkWords = nil, 0
+	}
+	flushParagraph := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		text := strings.Join(paragraph, " ")
+		words := len(strings.Fields(text))
+		if chunkWords > 0 && chunkWords+words > maxPassageWords {
+			flushChunk()
+		}
+		chunk = append(chunk, text)
+		chunkWords += words
+		paragraph = nil
+	}
+
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "# "):
+			flushParagraph()
+		case strings.HasPrefix(line, "## "):
+			flushParagraph()
+			flushChunk()
+			heading = strings.TrimSpace(strings.TrimPrefix(line, "## "))
+		case strings.TrimSpace(line) == "":
+			flushParagraph()
+		default:
+			paragraph = append(paragraph, strings.TrimSpace(line))
+		}
+	}
+	flushParagraph()
+	flushChunk()
+
+	if len(article.Passages) == 0 {
+		return article, fmt.Errorf("knowledge: article %s has no text", id)
+	}
+	return article, nil
+}
@@ -0,0 +1,151 @@
+package knowledge
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters: k1 saturates repeated terms, b normalizes for passage length
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Index is an in-memory BM25 index over knowledge base passages. It is built once at
+// startup from local files, needs no network access and is safe for concurrent searches.
+type Index struct {
+	passages  []domain.KnowledgePassage
+	termFreqs []map[string]int
+	lengths   []int
+	avgLength float64
+	docFreq   map[string]int
+}
+
+// NewIndex indexes every passage of the articles. Headings and titles are indexed with the
+// passage text so a query naming a topic finds its section.
+func NewIndex(articles []domain.KnowledgeArticle) (*Index, error) {
+	idx := &Index{docFreq: make(map[string]int)}
+	seen := make(map[string]bool)
+	total := 0
+	for _, article := range articles {
+		if seen[article.ID] {
+			return nil, fmt.Errorf("knowledge: duplicate article %s", article.ID)
+		}
+		seen[article.ID] = true
+		for _, passage := range article.Passages {
+			terms := tokenize(passage.Title + " " + passage.Heading + " " + passage.Text)
+			freqs := make(map[string]int)
+			for _, term := range terms {
+				freqs[term]++
+			}
+			for term := range freqs {
+				idx.docFreq[term]++
+			}
+			idx.passages = append(idx.passages, passage)
+			idx.termFreqs = append(idx.termFreqs, freqs)
+			idx.lengths = append(idx.lengths, len(terms))
+			total += len(terms)
+		}
+	}
+	if len(idx.passages) == 0 {
+		return nil, fmt.Errorf("knowledge: no passages to index")
+	}
+	idx.avgLength = float64(total) / float64(len(idx.passages))
+	return idx, nil
+}
+
+// Len returns the number of indexed passages
+func (idx *Index) Len() int {
+	return len(idx.passages)
+}
+
+// Search returns up to limit passages matching the query, best first. Passages sharing no
+// term with the query are never returned.
+func (idx *Index) Search(ctx context.Context, query string, limit int) ([]domain.KnowledgePassage, error) {
+	terms := uniqueTerms(tokenize(query))
+	n := float64(len(idx.passages))
+
+	var results []domain.KnowledgePassage
+	for i, freqs := range idx.termFreqs {
+		score := 0.0
+		for _, term := range terms {
+			tf := float64(freqs[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(idx.lengths[i])/idx.avgLength)
+			score += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+		if score > 0 {
+			passage := idx.passages[i]
+			passage.Score = score
+			results = append(results, passage)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// stopWords are dropped from passages and queries; they match everything and rank nothing
+var stopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "am": true, "an": true, "and": true, "any": true,
+	"are": true, "as": true, "at": true, "be": true, "been": true, "before": true, "but": true, "by": true,
+	"can": true, "could": true, "did": true, "do": true, "does": true, "doing": true, "for": true, "from": true,
+	"had": true, "has": true, "have": true, "how": true, "i": true, "if": true, "in": true, "into": true,
+	"is": true, "it": true, "its": true, "me": true, "more": true, "my": true, "no": true, "not": true,
+	"now": true, "of": true, "on": true, "or": true, "so": true, "should": true, "some": true, "such": true,
+	"than": true, "that": true, "the": true, "their": true, "them": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "to": true, "too": true, "up": true, "very": true, "was": true,
+	"we": true, "were": true, "what": true, "when": true, "which": true, "while": true, "who": true,
+	"why": true, "will": true, "with": true, "would": true, "you": true, "your": true,
+}
+
+// tokenize lowercases text, splits it into words and strips plural and -ing endings so
+// "fasts", "fasting" and "fast" match
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if stopWords[word] {
+			continue
+		}
+		terms = append(terms, stem(word))
+	}
+	return terms
+}
+
+func stem(word string) string {
+	switch {
+	case len(word) > 5 && strings.HasSuffix(word, "ing"):
+		return word[:len(word)-3]
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "is"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,86 @@
+package knowledge
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArticle_SplitsSectionsIntoPassages(t *testing.T) {
+	long := strings.Repeat("Sodium keeps muscles calm. ", 30)
+	article, err := ParseArticle("salt", []byte("---\ntitle: Salt\nsource: A textbook\n---\n\n# Salt\n\n## Why\n\n"+
+		long+"\n\n"+long+"\n\n## How much\n\nA pinch\nin water.\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Salt", article.Title)
+	require.Len(t, article.Passages, 3, "a section over the word limit is split between paragraphs")
+	assert.Equal(t, "salt#1", article.Passages[0].ID)
+	assert.Equal(t, "Why", article.Passages[1].Heading)
+	assert.Equal(t, domain.KnowledgePassage{
+		ID: "salt#3", ArticleID: "salt", Title: "Salt", Heading: "How much", Source: "A textbook", Text: "A pinch in water.",
+	}, article.Passages[2])
+
+	_, err = ParseArticle("bare", []byte("## Heading\n\nText"))
+	assert.ErrorContains(t, err, "needs a title and a source")
+}
+
+func TestLoadArticles_BuiltinsIndex(t *testing.T) {
+	articles, err := LoadArticles("")
+	require.NoError(t, err)
+	require.NotEmpty(t, articles)
+	for _, article := range articles {
+		assert.NotEmpty(t, article.Source, article.ID)
+	}
+
+	idx, err := NewIndex(articles)
+	require.NoError(t, err)
+	assert.Greater(t, idx.Len(), len(articles))
+
+	_, err = NewIndex(append(articles, articles[0]))
+	assert.ErrorContains(t, err, "duplicate article")
+}
+
+func TestIndex_RanksTheMatchingArticleFirst(t *testing.T) {
+	articles, err := LoadArticles("")
+	require.NoError(t, err)
+	idx, err := NewIndex(articles)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for query, want := range map[string]string{
+		"When does my body enter ketosis?":         "ketosis",
+		"What is autophagy?":                       "autophagy",
+		"I get headaches and cramps while fasting": "electrolytes",
+		"What should I eat to break my fast?":      "breaking-a-fast",
+	} {
+		results, err := idx.Search(ctx, query, 3)
+		require.NoError(t, err)
+		require.NotEmpty(t, results, query)
+		assert.Equal(t, want, results[0].ArticleID, query)
+		assert.LessOrEqual(t, len(results), 3)
+		for i := 1; i < len(results); i++ {
+			assert.GreaterOrEqual(t, results[i-1].Score, results[i].Score)
+		}
+	}
+
+	results, err := idx.Search(ctx, "quarterly tax filing", 3)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestLoadArticles_FromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "water.md"), []byte("---\ntitle: Water\nsource: Guide\n---\n\n## Drink\n\nSip water through the fast.\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644))
+
+	articles, err := LoadArticles(dir)
+	require.NoError(t, err)
+	require.Len(t, articles, 1)
+	assert.Equal(t, "water", articles[0].ID)
+}
@@ -0,0 +1,204 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrNoFixture is returned on replay when no recorded exchange matches the request
+var ErrNoFixture = errors.New("llm: no recorded exchange for request")
+
+// Exchange is one recorded request and the provider's answer. Images are stored by
+// digest only, so fixtures stay small and never contain user photos.
+type Exchange struct {
+	Key          string              `json:"key"`
+	Image        string              `json:"image_sha256,omitempty"`
+	Prompt       string              `json:"prompt"`
+	SystemPrompt string              `json:"system_prompt,omitempty"`
+	Messages     []domain.LLMMessage `json:"messages,omitempty"` // Full conversation for Converse; Prompt is its last turn
+	Response     string              `json:"response"`
+}
+
+// Cassette is a fixture file of recorded exchanges shared by the providers that
+// record into it or replay from it. It is safe for concurrent use.
+type Cassette struct {
+	path string
+
+	mu        sync.Mutex
+	exchanges map[string]Exchange
+}
+
+// OpenCassette loads the fixture file at path; a missing file is an empty cassette
+func OpenCassette(path string) (*Cassette, error) {
+	c := &Cassette{path: path, exchanges: map[string]Exchange{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var exchanges []Exchange
+	if err := json.Unmarshal(data, &exchanges); err != nil {
+		return nil, fmt.Errorf("llm: cassette %s: %w", path, err)
+	}
+	for _, e := range exchanges {
+		c.exchanges[e.Key] = e
+	}
+	return c, nil
+}
+
+// Len returns the number of recorded exchanges
+func (c *Cassette) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.exchanges)
+}
+
+// Record wraps a live provider; every successful answer is written to the cassette
+func (c *Cassette) Record(provider ports.LLMProvider) ports.LLMProvider {
+	return &recordingProvider{cassette: c, next: provider}
+}
+
+// Replay answers only from the cassette and never touches the network
+func (c *Cassette) Replay() ports.LLMProvider {
+	return &replayProvider{cassette: c}
+}
+
+func (c *Cassette) lookup(key string) (Exchange, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.exchanges[key]
+	return e, ok
+}
+
+func (c *Cassette) store(e Exchange) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exchanges[e.Key] = e
+	return c.save()
+}
+
+// save rewrites the fixture file sorted by key so re-recording produces small diffs
+func (c *Cassette) save() error {
+	exchanges := make([]Exchange, 0, len(c.exchanges))
+	for _, e := range c.exchanges {
+		exchanges = append(exchanges, e)
+	}
+	sort.Slice(exchanges, func(i, j int) bool { return exchanges[i].Key < exchanges[j].Key })
+
+	data, err := json.MarshalIndent(exchanges, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(c.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+func textExchange(prompt, systemPrompt string) Exchange {
+	return Exchange{Key: exchangeKey("text", systemPrompt, prompt), Prompt: prompt, SystemPrompt: systemPrompt}
+}
+
+func conversationExchange(systemPrompt string, messages []domain.LLMMessage) Exchange {
+	parts := []string{"conversation", systemPrompt}
+	for _, m := range messages {
+		parts = append(parts, string(m.Role), m.Content)
+	}
+	e := Exchange{Key: exchangeKey(parts...), SystemPrompt: systemPrompt, Messages: messages}
+	if len(messages) > 0 {
+		e.Prompt = messages[len(messages)-1].Content
+	}
+	return e
+}
+
+func imageExchange(imageBase64, prompt string) Exchange {
+	sum := sha256.Sum256([]byte(imageBase64))
+	image := hex.EncodeToString(sum[:])
+	return Exchange{Key: exchangeKey("image", image, prompt), Image: image, Prompt: prompt}
+}
+
+func exchangeKey(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+type recordingProvider struct {
+	cassette *Cassette
+	next     ports.LLMProvider
+}
+
+func (r *recordingProvider) GenerateResponse(ctx context.Context, prompt string, systemPrompt string) (string, error) {
+	response, err := r.next.GenerateResponse(ctx, prompt, systemPrompt)
+	if err != nil {
+		return "", err
+	}
+	e := textExchange(prompt, systemPrompt)
+	e.Response = response
+	return response, r.cassette.store(e)
+}
+
+func (r *recordingProvider) AnalyzeImage(ctx context.Context, imageBase64, prompt string) (string, error) {
+	response, err := r.next.AnalyzeImage(ctx, imageBase64, prompt)
+	if err != nil {
+		return "", err
+	}
+	e := imageExchange(imageBase64, prompt)
+	e.Response = response
+	return response, r.cassette.store(e)
+}
+
+func (r *recordingProvider) Converse(ctx context.Context, systemPrompt string, messages []domain.LLMMessage) (string, error) {
+	response, err := r.next.Converse(ctx, systemPrompt, messages)
+	if err != nil {
+		return "", err
+	}
+	e := conversationExchange(systemPrompt, messages)
+	e.Response = response
+	return response, r.cassette.store(e)
+}
+
+type replayProvider struct {
+	cassette *Cassette
+}
+
+func (r *replayProvider) GenerateResponse(ctx context.Context, prompt string, systemPrompt string) (string, error) {
+	return r.replay(textExchange(prompt, systemPrompt).Key)
+}
+
+func (r *replayProvider) AnalyzeImage(ctx context.Context, imageBase64, prompt string) (string, error) {
+	return r.replay(imageExchange(imageBase64, prompt).Key)
+}
+
+func (r *replayProvider) Converse(ctx context.Context, systemPrompt string, messages []domain.LLMMessage) (string, error) {
+	return r.replay(conversationExchange(systemPrompt, messages).Key)
+}
+
+func (r *replayProvider) replay(key string) (string, error) {
+	e, ok := r.cassette.lookup(key)
+	if !ok {
+		return "", fmt.Errorf("%w (key %s)", ErrNoFixture, key)
+	}
+	return e.Response, nil
+}
@@ -0,0 +1,196 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fastinghero/internal/core/domain"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// FakeRule answers any request whose prompt or system prompt matches Pattern
+type FakeRule struct {
+	Pattern  string `json:"pattern"` // Regular expression, matched case-insensitively
+	Response string `json:"response"`
+
+	// ToolCall makes the rule call a tool. Such a rule only matches the last user message of
+	// a ConverseWithTools request that offers the tool.
+	ToolCall *FakeToolCall `json:"tool_call,omitempty"`
+}
+
+// FakeToolCall is the tool call a FakeRule answers with
+type FakeToolCall struct {
+	Name string `json:"name"`
+	// Arguments is a JSON object in which $1 or ${name} expand to the pattern's submatches
+	Arguments string `json:"arguments"`
+}
+
+// FakeScript is the ordered rule list of a FakeProvider; the first matching rule wins
+type FakeScript struct {
+	Rules   []FakeRule `json:"rules"`
+	Default string     `json:"default"` // Answer when no rule matches
+}
+
+// FakeCall is one request seen by a FakeProvider
+type FakeCall struct {
+	Image        bool
+	Turns        int      // Messages sent with Converse, including the last one
+	Tools        []string // Names of the tools offered with ConverseWithTools
+	Prompt       string
+	SystemPrompt string
+	Response     string
+	ToolCalls    []domain.LLMToolCall
+}
+
+// DefaultFakeScript gives every Cortex feature a plausible, stable answer for local
+// development. Patterns target the built-in prompt templates in services/prompts.
+var DefaultFakeScript = FakeScript{
+	Rules: []FakeRule{
+		{Pattern: `\b(end|stop|break) my fast\b`, Response: "I can end your fast now. Confirm below and I'll log it.", ToolCall: &FakeToolCall{Name: "stop_fast", Arguments: `{}`}},
+		{Pattern: `\blog (\d+) glass(es)? of water\b`, Response: "Nice work staying hydrated. Confirm below and I'll log it.", ToolCall: &FakeToolCall{Name: "log_hydration", Arguments: `{"amount":$1,"unit":"glasses"}`}},
+		{Pattern: `analyze this meal`, Response: `{"analysis":"Eggs and avocado, roughly 4g net carbs.","net_carbs_grams":4,"authenticity":"verified","keto_friendly":true}`},
+		{Pattern: `emergency fasting coach`, Response: `{"immediate_action":"Drink a full glass of water now.","distraction_idea":"Walk around the block for five minutes.","biological_fact":"Ghrelin comes in waves and fades within twenty minutes.","motivation":"This craving passes. Your goal stays."}`},
+		{Pattern: `fasting science expert`, Response: `{"insight":"Glycogen stores are running low and your liver is shifting to fat oxidation. Ketone production is rising steadily.","benefits":["Fat burning","Steady energy","Mental clarity"],"motivation":"Every hour you hold builds the habit."}`},
+		{Pattern: `weekly fasting performance`, Response: `{"analysis":"You fasted consistently and your average duration held steady.","improvement_area":"Start your fasts an hour earlier on weekends.","encouragement":"Your consistency is paying off.","discipline_trend":"improving","next_week_fasts_estimate":4}`},
+		{Pattern: `biological narrator`, Response: "Insulin is low and fat stores are being released for fuel. Your cells are beginning to clean house through autophagy."},
+		{Pattern: `refeeding`, Response: "Break the fast with bone broth or eggs, then wait an hour before anything starchy."},
+		{Pattern: `motivational quote`, Response: "Every hour you hold is proof you keep your word."},
+		{Pattern: `summarize`, Response: "The user is working through evening cravings and wants to reach longer fasts."},
+		{Pattern: `you are cortex`, Response: "You committed to this fast. Drink water, stay busy, and finish what you started."},
+	},
+	Default: "Stay consistent. Small wins compound.",
+}
+
+// FakeProvider implements ports.LLMProvider with scripted answers and no network access.
+// It is safe for concurrent use.
+type FakeProvider struct {
+	patterns []*regexp.Regexp
+	script   FakeScript
+
+	mu    sync.Mutex
+	calls []FakeCall
+}
+
+func NewFakeProvider(script FakeScript) (*FakeProvider, error) {
+	patterns := make([]*regexp.Regexp, len(script.Rules))
+	for i, rule := range script.Rules {
+		re, err := regexp.Compile("(?i)" + rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("llm: fake rule %d: %w", i, err)
+		}
+		patterns[i] = re
+	}
+	return &FakeProvider{patterns: patterns, script: script}, nil
+}
+
+// LoadFakeScript reads a FakeScript from a JSON file
+func LoadFakeScript(path string) (FakeScript, error) {
+	var script FakeScript
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return script, err
+	}
+	if err := json.Unmarshal(data, &script); err != nil {
+		return script, fmt.Errorf("llm: fake script %s: %w", path, err)
+	}
+	return script, nil
+}
+
+func (f *FakeProvider) GenerateResponse(ctx context.Context, prompt string, systemPrompt string) (string, error) {
+	return f.answer(FakeCall{Prompt: prompt, SystemPrompt: systemPrompt}), ctx.Err()
+}
+
+func (f *FakeProvider) AnalyzeImage(ctx context.Context, imageBase64, prompt string) (string, error) {
+	return f.answer(FakeCall{Image: true, Prompt: prompt}), ctx.Err()
+}
+
+func (f *FakeProvider) Converse(ctx context.Context, systemPrompt string, messages []domain.LLMMessage) (string, error) {
+	call := FakeCall{SystemPrompt: systemPrompt, Turns: len(messages)}
+	if len(messages) > 0 {
+		call.Prompt = messages[len(messages)-1].Content
+	}
+	return f.answer(call), ctx.Err()
+}
+
+// ConverseWithTools answers like Converse, with the tool call of a matching rule whose tool
+// was offered
+func (f *FakeProvider) ConverseWithTools(ctx context.Context, systemPrompt string, messages []domain.LLMMessage, tools []domain.LLMTool) (*domain.LLMToolReply, error) {
+	call := FakeCall{SystemPrompt: systemPrompt, Turns: len(messages), Tools: []string{}}
+	if len(messages) > 0 {
+		call.Prompt = messages[len(messages)-1].Content
+	}
+	for _, tool := range tools {
+		call.Tools = append(call.Tools, tool.Name)
+	}
+	call = f.record(call)
+	return &domain.LLMToolReply{Text: call.Response, ToolCalls: call.ToolCalls}, ctx.Err()
+}
+
+// ConverseStream streams the scripted answer word by word
+func (f *FakeProvider) ConverseStream(ctx context.Context, systemPrompt string, messages []domain.LLMMessage, onDelta func(delta string) error) (string, error) {
+	response, err := f.Converse(ctx, systemPrompt, messages)
+	if err != nil {
+		return "", err
+	}
+	var assembled strings.Builder
+	for _, word := range strings.SplitAfter(response, " ") {
+		if err := ctx.Err(); err != nil {
+			return assembled.String(), err
+		}
+		assembled.WriteString(word)
+		if err := onDelta(word); err != nil {
+			return assembled.String(), err
+		}
+	}
+	return response, nil
+}
+
+// Calls returns the requests answered so far, oldest first
+func (f *FakeProvider) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeCall(nil), f.calls...)
+}
+
+func (f *FakeProvider) answer(call FakeCall) string {
+	return f.record(call).Response
+}
+
+// record fills in the answer to call from the first matching rule and keeps it
+func (f *FakeProvider) record(call FakeCall) FakeCall {
+	call.Response = f.script.Default
+	for i, re := range f.patterns {
+		rule := f.script.Rules[i]
+		if rule.ToolCall != nil {
+			match := re.FindStringSubmatchIndex(call.Prompt)
+			if match == nil || !containsName(call.Tools, rule.ToolCall.Name) {
+				continue
+			}
+			args := re.ExpandString(nil, rule.ToolCall.Arguments, call.Prompt, match)
+			call.Response = rule.Response
+			call.ToolCalls = []domain.LLMToolCall{{ID: fmt.Sprintf("call_%d", i+1), Name: rule.ToolCall.Name, Arguments: args}}
+			break
+		}
+		if re.MatchString(call.Prompt) || re.MatchString(call.SystemPrompt) {
+			call.Response = rule.Response
+			break
+		}
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+	return call
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
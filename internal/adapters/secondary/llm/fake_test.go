@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeProvider_FirstMatchingRuleWins(t *testing.T) {
+	fake, err := NewFakeProvider(FakeScript{
+		Rules: []FakeRule{
+			{Pattern: `craving`, Response: "drink water"},
+			{Pattern: `coach`, Response: "generic coach"},
+		},
+		Default: "fallback",
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	first, _ := fake.GenerateResponse(ctx, "I have a CRAVING", "You are a coach")
+	second, _ := fake.GenerateResponse(ctx, "Hello", "You are a coach")
+	third, _ := fake.GenerateResponse(ctx, "Hello", "")
+	image, _ := fake.AnalyzeImage(ctx, "abc", "Describe the craving food")
+
+	assert.Equal(t, "drink water", first)
+	assert.Equal(t, "generic coach", second)
+	assert.Equal(t, "fallback", third)
+	assert.Equal(t, "drink water", image)
+
+	calls := fake.Calls()
+	require.Len(t, calls, 4)
+	assert.True(t, calls[3].Image)
+	assert.Equal(t, "fallback", calls[2].Response)
+}
+
+func TestFakeProvider_InvalidPattern(t *testing.T) {
+	_, err := NewFakeProvider(FakeScript{Rules: []FakeRule{{Pattern: `(`}}})
+
+	assert.Error(t, err)
+}
+
+func TestFakeProvider_DefaultScriptCoversMealVision(t *testing.T) {
+	fake, err := NewFakeProvider(DefaultFakeScript)
+	require.NoError(t, err)
+
+	response, err := fake.AnalyzeImage(context.Background(), "", "Analyze this meal based on the user's description")
+
+	require.NoError(t, err)
+	assert.Contains(t, response, `"keto_friendly":true`)
+}
+
+func TestFakeProvider_ToolCallsOnlyWhenOffered(t *testing.T) {
+	fake, err := NewFakeProvider(DefaultFakeScript)
+	require.NoError(t, err)
+	ctx := context.Background()
+	messages := []domain.LLMMessage{{Role: domain.LLMRoleUser, Content: "Log 3 glasses of water please"}}
+
+	reply, err := fake.ConverseWithTools(ctx, "You are Cortex", messages, []domain.LLMTool{{Name: "log_hydration"}})
+	require.NoError(t, err)
+	require.Len(t, reply.ToolCalls, 1)
+	assert.Equal(t, "log_hydration", reply.ToolCalls[0].Name)
+	assert.JSONEq(t, `{"amount":3,"unit":"glasses"}`, string(reply.ToolCalls[0].Arguments))
+
+	reply, err = fake.ConverseWithTools(ctx, "You are Cortex", messages, []domain.LLMTool{{Name: "stop_fast"}})
+	require.NoError(t, err)
+	assert.Empty(t, reply.ToolCalls)
+	plain, _ := fake.Converse(ctx, "You are Cortex", messages)
+	assert.Equal(t, reply.Text, plain, "without the tool the rule is skipped")
+}
+
+func TestLoadFakeScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules":[{"pattern":"quote","response":"Keep going."}],"default":"ok"}`), 0o644))
+
+	script, err := LoadFakeScript(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ok", script.Default)
+	assert.Equal(t, []FakeRule{{Pattern: "quote", Response: "Keep going."}}, script.Rules)
+}
+
+type stubProvider struct {
+	calls int
+	err   error
+}
+
+func (s *stubProvider) GenerateResponse(ctx context.Context, prompt, systemPrompt string) (string, error) {
+	s.calls++
+	return "live: " + prompt, s.err
+}
+
+func (s *stubProvider) Converse(ctx context.Context, systemPrompt string, messages []domain.LLMMessage) (string, error) {
+	s.calls++
+	return "live conversation", s.err
+}
+
+func (s *stubProvider) AnalyzeImage(ctx context.Context, imageBase64, prompt string) (string, error) {
+	s.calls++
+	return "live image", s.err
+}
+
+func TestCassette_RecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures", "cortex.json")
+	ctx := context.Background()
+
+	recording, err := OpenCassette(path)
+	require.NoError(t, err)
+	live := &stubProvider{}
+	recorder := recording.Record(live)
+	_, err = recorder.GenerateResponse(ctx, "hello", "system")
+	require.NoError(t, err)
+	_, err = recorder.AnalyzeImage(ctx, "base64-photo", "Analyze")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "base64-photo")
+
+	replaying, err := OpenCassette(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, replaying.Len())
+	replay := replaying.Replay()
+
+	text, err := replay.GenerateResponse(ctx, "hello", "system")
+	require.NoError(t, err)
+	assert.Equal(t, "live: hello", text)
+	image, err := replay.AnalyzeImage(ctx, "base64-photo", "Analyze")
+	require.NoError(t, err)
+	assert.Equal(t, "live image", image)
+	assert.Equal(t, 2, live.calls)
+
+	_, err = replay.GenerateResponse(ctx, "hello", "a different system prompt")
+	assert.ErrorIs(t, err, ErrNoFixture)
+	_, err = replay.AnalyzeImage(ctx, "another-photo", "Analyze")
+	assert.ErrorIs(t, err, ErrNoFixture)
+}
+
+func TestCassette_DoesNotRecordFailures(t *testing.T) {
+	cassette, err := OpenCassette(filepath.Join(t.TempDir(), "cortex.json"))
+	require.NoError(t, err)
+
+	_, err = cassette.Record(&stubProvider{err: errors.New("boom")}).GenerateResponse(context.Background(), "hello", "")
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, cassette.Len())
+}
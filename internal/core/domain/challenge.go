@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Friend challenge statuses. A challenge is active from creation until it is settled at EndDate.
+const (
+	ChallengeStatusActive    = "active"
+	ChallengeStatusCompleted = "completed"
+	ChallengeStatusCancelled = "cancelled" // Fewer than two participants accepted; deposits returned
+)
+
+type ChallengeParticipantStatus string
+
+const (
+	ChallengeParticipantInvited  ChallengeParticipantStatus = "invited"
+	ChallengeParticipantAccepted ChallengeParticipantStatus = "accepted"
+	ChallengeParticipantDeclined ChallengeParticipantStatus = "declined"
+)
+
+// ChallengeDeposit is what each participant stakes when accepting a challenge
+const ChallengeDeposit = 20.00
+
+// ChallengeWinnerShare is the part of each loser's deposit that goes to the winner
+// ("winner takes 50% of losers' deposits"); the rest is forfeited like unearned vault money.
+const ChallengeWinnerShare = 0.50
+
+var (
+	ErrChallengeNotFound      = errors.New("challenge not found")
+	ErrChallengeNotActive     = errors.New("challenge is no longer active")
+	ErrChallengeNotInvited    = errors.New("not invited to this challenge")
+	ErrChallengeAlreadyJoined = errors.New("already responded to this challenge")
+	ErrChallengeNotCreator    = errors.New("only the challenge creator can invite")
+	ErrInvalidChallenge       = errors.New("invalid challenge")
+)
+
+// FriendChallengeParticipant is a user invited to a challenge. Only accepted participants
+// stake a deposit and compete.
+type FriendChallengeParticipant struct {
+	ID              uuid.UUID                  `json:"id"`
+	ChallengeID     uuid.UUID                  `json:"challenge_id"`
+	UserID          uuid.UUID                  `json:"user_id"`
+	InvitedBy       *uuid.UUID                 `json:"invited_by,omitempty"`
+	Status          ChallengeParticipantStatus `json:"status"`
+	DepositAmount   float64                    `json:"deposit_amount"`
+	DepositChargeID string                     `json:"-"`        // Payment charge the stake was collected with; payouts refund against it
+	Progress        float64                    `json:"progress"` // Fasting hours, steps or glasses, per ChallengeType
+	IsWinner        bool                       `json:"is_winner"`
+	PayoutAmount    float64                    `json:"payout_amount"`
+	PayoutProcessed bool                       `json:"payout_processed"`
+	RespondedAt     *time.Time                 `json:"responded_at,omitempty"`
+	CreatedAt       time.Time                  `json:"created_at"`
+	UpdatedAt       time.Time                  `json:"updated_at"`
+}
+
+// ChallengeDetails is a challenge with its participants
+type ChallengeDetails struct {
+	Challenge    *FriendChallenge              `json:"challenge"`
+	Participants []*FriendChallengeParticipant `json:"participants"`
+}
+
+// IsValid reports whether the challenge type is known
+func (t ChallengeType) IsValid() bool {
+	switch t {
+	case ChallengeTypeFasting, ChallengeTypeSteps, ChallengeTypeHydration:
+		return true
+	}
+	return false
+}
+
+// HasEnded reports whether the challenge window is over and it can be settled
+func (c *FriendChallenge) HasEnded(now time.Time) bool {
+	return !now.Before(c.EndDate)
+}
+
+// SettleChallenge picks the winners among accepted participants and sets every payout.
+// The highest progress wins, provided it reaches the goal; tied winners split the winnings.
+// Winners get their deposit back plus ChallengeWinnerShare of the losers' deposits. With fewer
+// than two accepted participants the challenge is cancelled, and with no one reaching the goal
+// nobody wins; in both cases every deposit is returned. It returns the new challenge status.
+func SettleChallenge(c *FriendChallenge, participants []*FriendChallengeParticipant) string {
+	var accepted []*FriendChallengeParticipant
+	for _, p := range participants {
+		p.IsWinner = false
+		p.PayoutAmount = 0
+		if p.Status == ChallengeParticipantAccepted {
+			accepted = append(accepted, p)
+		}
+	}
+
+	c.PotAmount = 0
+	for _, p := range accepted {
+		c.PotAmount += p.DepositAmount
+	}
+	c.WinnerID = nil
+	c.PayoutAmount = 0
+
+	if len(accepted) < 2 {
+		refundDeposits(accepted)
+		return ChallengeStatusCancelled
+	}
+
+	best := accepted[0].Progress
+	for _, p := range accepted[1:] {
+		if p.Progress > best {
+			best = p.Progress
+		}
+	}
+	if best <= 0 || best < float64(c.Goal) {
+		refundDeposits(accepted)
+		return ChallengeStatusCompleted
+	}
+
+	var winners []*FriendChallengeParticipant
+	var losersDeposits float64
+	for _, p := range accepted {
+		if p.Progress == best {
+			winners = append(winners, p)
+		} else {
+			losersDeposits += p.DepositAmount
+		}
+	}
+	if len(winners) == len(accepted) {
+		// Everyone tied: nobody lost anything to win
+		refundDeposits(accepted)
+		for _, p := range winners {
+			p.IsWinner = true
+		}
+		c.WinnerID = &winners[0].UserID
+		return ChallengeStatusCompleted
+	}
+
+	winningsCents := toCents(losersDeposits * ChallengeWinnerShare)
+	count := int64(len(winners))
+	for i, p := range winners {
+		share := winningsCents / count
+		if int64(i) < winningsCents%count {
+			share++
+		}
+		p.IsWinner = true
+		p.PayoutAmount = fromCents(toCents(p.DepositAmount) + share)
+	}
+	c.WinnerID = &winners[0].UserID
+	c.PayoutAmount = winners[0].PayoutAmount
+	return ChallengeStatusCompleted
+}
+
+func refundDeposits(participants []*FriendChallengeParticipant) {
+	for _, p := range participants {
+		p.PayoutAmount = p.DepositAmount
+	}
+}
+
+// ChallengeFastingHours sums the hours of completed fasts ending in [from, to)
+func ChallengeFastingHours(sessions []FastingSession, from, to time.Time) float64 {
+	var hours float64
+	for _, s := range sessions {
+		if s.Status != StatusCompleted || s.EndTime == nil {
+			continue
+		}
+		if s.EndTime.Before(from) || !s.EndTime.Before(to) {
+			continue
+		}
+		hours += s.EndTime.Sub(s.StartTime).Hours()
+	}
+	return math.Round(hours*100) / 100
+}
+
+// ChallengeSteps sums step counts from telemetry data points
+func ChallengeSteps(points []TelemetryData) float64 {
+	var steps float64
+	for _, p := range points {
+		steps += p.Value
+	}
+	return steps
+}
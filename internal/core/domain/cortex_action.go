@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CortexTool is an action Cortex can propose on the user's data. Each is named after the
+// LLM tool that proposes it.
+type CortexTool string
+
+const (
+	CortexToolStartFast    CortexTool = "start_fast"
+	CortexToolStopFast     CortexTool = "stop_fast"
+	CortexToolLogHydration CortexTool = "log_hydration"
+	CortexToolLogWeight    CortexTool = "log_weight"
+	CortexToolLogMeal      CortexTool = "log_meal"
+)
+
+// CortexActionStatus tracks a proposed action until the user decides on it
+type CortexActionStatus string
+
+const (
+	CortexActionProposed  CortexActionStatus = "proposed"  // Waiting for the user to confirm or reject it
+	CortexActionExecuting CortexActionStatus = "executing" // Confirmed and running; left here if the server dies mid-run
+	CortexActionExecuted  CortexActionStatus = "executed"
+	CortexActionFailed    CortexActionStatus = "failed"   // Confirmed, but the service refused it
+	CortexActionRejected  CortexActionStatus = "rejected" // Declined by the user
+	CortexActionExpired   CortexActionStatus = "expired"  // Confirmed after CortexActionTTL
+)
+
+// CortexActionTTL is how long a proposed action can be confirmed. A stale proposal, like
+// "start my fast now" from yesterday, must be asked for again.
+const CortexActionTTL = 15 * time.Minute
+
+var (
+	ErrCortexActionNotFound = errors.New("action not found")
+	ErrCortexActionDecided  = errors.New("action was already confirmed or rejected")
+	ErrCortexActionExpired  = errors.New("action expired, ask Cortex again")
+)
+
+// CortexAction is an action Cortex proposed and its outcome. Actions are never deleted, so
+// they double as the audit trail of everything Cortex changed on the user's behalf.
+type CortexAction struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	Tool      CortexTool         `json:"tool"`
+	Arguments json.RawMessage    `json:"arguments"` // Validated arguments the action runs with
+	Summary   string             `json:"summary"`   // What confirming will do, shown to the user
+	Status    CortexActionStatus `json:"status"`
+	Result    json.RawMessage    `json:"result,omitempty"` // The record the action created or changed
+	Error     string             `json:"error,omitempty"`  // Why an executed action failed
+	CreatedAt time.Time          `json:"created_at"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	DecidedAt *time.Time         `json:"decided_at,omitempty"`
+}
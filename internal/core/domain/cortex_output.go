@@ -0,0 +1,62 @@
+package domain
+
+// Structured Cortex outputs. The model is asked for these as JSON objects; the services
+// decode them strictly and fall back to canned values when the model can't produce them.
+
+// MilestoneInsight explains what is happening in the body at a fasting milestone
+type MilestoneInsight struct {
+	Insight    string   `json:"insight"`
+	Benefits   []string `json:"benefits"`
+	Motivation string   `json:"motivation"`
+}
+
+// MealAuthenticity is whether a meal photo looks like a genuine camera shot
+type MealAuthenticity string
+
+const (
+	MealAuthenticityVerified   MealAuthenticity = "verified"
+	MealAuthenticitySuspicious MealAuthenticity = "suspicious"
+)
+
+// MealAssessment is the model's read of a logged meal
+type MealAssessment struct {
+	Analysis      string           `json:"analysis"`
+	NetCarbsGrams float64          `json:"net_carbs_grams"`
+	Authenticity  MealAuthenticity `json:"authenticity"`
+	KetoFriendly  bool             `json:"keto_friendly"`
+}
+
+// CravingAdvice is emergency help for a craving during a fast
+type CravingAdvice struct {
+	ImmediateAction string `json:"immediate_action"`
+	DistractionIdea string `json:"distraction_idea"`
+	BiologicalFact  string `json:"biological_fact"`
+	Motivation      string `json:"motivation"`
+}
+
+// DisciplineTrend is the direction of a user's discipline over a week
+type DisciplineTrend string
+
+const (
+	DisciplineTrendImproving DisciplineTrend = "improving"
+	DisciplineTrendSteady    DisciplineTrend = "steady"
+	DisciplineTrendDeclining DisciplineTrend = "declining"
+)
+
+// WeekSummary is the week of fasting a weekly insight is written about
+type WeekSummary struct {
+	FastsCompleted  int
+	AverageDuration float64
+	LongestFast     float64
+	DisciplineIndex float64
+}
+
+// WeeklyInsight is the model's commentary for a weekly progress report
+type WeeklyInsight struct {
+	Analysis              string          `json:"analysis"`
+	ImprovementArea       string          `json:"improvement_area"`
+	Encouragement         string          `json:"encouragement"`
+	DisciplineTrend       DisciplineTrend `json:"discipline_trend"`
+	NextWeekFastsEstimate int             `json:"next_week_fasts_estimate"`
+	SafetyMode            bool            `json:"-"` // Safe commentary for a user at risk; not from the model
+}
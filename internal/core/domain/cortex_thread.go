@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCortexThreadNotFound = errors.New("conversation not found")
+	ErrCortexThreadBusy     = errors.New("conversation is already answering a message")
+	ErrEmptyCortexMessage   = errors.New("message is required")
+	ErrCortexMessageTooLong = errors.New("message is too long")
+)
+
+const (
+	// CortexHistoryWindow is how many recent messages are sent verbatim with every reply
+	CortexHistoryWindow = 12
+	// CortexSummaryBatch is how many messages past the window build up before the oldest
+	// are folded into the thread summary
+	CortexSummaryBatch = 8
+	// MaxCortexMessageLength matches the prompt limit of the LLM adapters
+	MaxCortexMessageLength = 2000
+	maxCortexThreadTitle   = 60
+)
+
+// CortexThread is a persisted conversation with the Cortex coach. Messages up to
+// SummarizedThrough are only sent to the LLM through Summary.
+type CortexThread struct {
+	ID                uuid.UUID `json:"id"`
+	UserID            uuid.UUID `json:"user_id"`
+	Title             string    `json:"title"`
+	Summary           string    `json:"summary,omitempty"`
+	SummarizedThrough int       `json:"summarized_through"` // Seq of the last summarized message
+	MessageCount      int       `json:"message_count"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// CortexMessage is one turn of a thread; Seq numbers the messages from 1
+type CortexMessage struct {
+	ID          uuid.UUID `json:"id"`
+	ThreadID    uuid.UUID `json:"thread_id"`
+	Seq         int       `json:"seq"`
+	Role        LLMRole   `json:"role"`
+	Content     string    `json:"content"`
+	Interrupted bool      `json:"interrupted,omitempty"` // Streamed reply cut short by a client disconnect
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CortexThreadView is a thread with its full message history, for resuming it
+type CortexThreadView struct {
+	Thread   CortexThread    `json:"thread"`
+	Messages []CortexMessage `json:"messages"`
+}
+
+// CortexReply is the coach's answer to a message
+type CortexReply struct {
+	Thread     CortexThread  `json:"thread"`
+	Message    CortexMessage `json:"message"`
+	SafetyMode bool          `json:"safety_mode,omitempty"` // Answered with the safe-response template
+}
+
+// CortexThreadTitle names a thread after its first message, cut at a word boundary
+func CortexThreadTitle(firstMessage string) string {
+	title := strings.Join(strings.Fields(firstMessage), " ")
+	if utf8.RuneCountInString(title) <= maxCortexThreadTitle {
+		return title
+	}
+	runes := []rune(title)[:maxCortexThreadTitle]
+	cut := string(runes)
+	if i := strings.LastIndex(cut, " "); i > maxCortexThreadTitle/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
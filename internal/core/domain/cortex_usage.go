@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// ErrCortexQuotaExceeded is returned when a user has used up today's Cortex budget
+var ErrCortexQuotaExceeded = errors.New("daily cortex quota exceeded")
+
+// CortexQuotaError names the exhausted limit. It matches ErrCortexQuotaExceeded with errors.Is.
+type CortexQuotaError struct {
+	Limit    string // "requests" or "tokens"
+	ResetsAt time.Time
+}
+
+func (e *CortexQuotaError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrCortexQuotaExceeded.Error(), e.Limit)
+}
+
+func (e *CortexQuotaError) Is(target error) bool {
+	return target == ErrCortexQuotaExceeded
+}
+
+// CortexBudget is how much Cortex a plan can use per UTC day
+type CortexBudget struct {
+	DailyRequests int `json:"daily_requests"`
+	DailyTokens   int `json:"daily_tokens"`
+}
+
+// cortexBudgets gives the AI Coach add-on most of the LLM; every other paid plan shares a
+// middle budget
+var cortexBudgets = map[SubscriptionTier]CortexBudget{
+	TierFree:               {DailyRequests: 20, DailyTokens: 20000},
+	TierVault:              {DailyRequests: 60, DailyTokens: 60000},
+	TierAccountabilityPlus: {DailyRequests: 60, DailyTokens: 60000},
+	TierAICoach:            {DailyRequests: 400, DailyTokens: 500000},
+}
+
+// CortexBudgetFor returns the daily budget of the plan the user can currently access. Lapsed
+// subscriptions and expired trials get the free budget.
+func CortexBudgetFor(e *Entitlements) (SubscriptionTier, CortexBudget) {
+	tier := e.Tier
+	if len(e.Capabilities) == 0 || !tier.IsValid() {
+		tier = TierFree
+	}
+	return tier, cortexBudgets[tier]
+}
+
+// CortexUsage is what a user has spent on Cortex during one UTC day
+type CortexUsage struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Day      time.Time `json:"day"` // Midnight UTC
+	Requests int       `json:"requests"`
+	Tokens   int       `json:"tokens"`
+}
+
+// CortexUsageView is a user's usage against their budget
+type CortexUsageView struct {
+	Tier     SubscriptionTier `json:"tier"`
+	Budget   CortexBudget     `json:"budget"`
+	Requests int              `json:"requests"`
+	Tokens   int              `json:"tokens"`
+	ResetsAt time.Time        `json:"resets_at"`
+}
+
+// CortexUsageDay returns the UTC day t is accounted to
+func CortexUsageDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// EstimateTokens approximates the LLM tokens in texts at four characters per token. Providers
+// don't report usage through ports.LLMProvider, so budgets are kept in these estimates.
+func EstimateTokens(texts ...string) int {
+	chars := 0
+	for _, text := range texts {
+		chars += utf8.RuneCountInString(text)
+	}
+	return (chars + 3) / 4
+}
+
+// CortexCacheStats counts response cache lookups for one Cortex feature
+type CortexCacheStats struct {
+	Feature string  `json:"feature"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
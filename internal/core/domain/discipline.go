@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DisciplineEventType is why a user's Discipline Index changed
+type DisciplineEventType string
+
+const (
+	DisciplineFastCompleted DisciplineEventType = "fast_completed" // Fast ended with its goal met
+	DisciplineFastBroken    DisciplineEventType = "fast_broken"    // Fast ended before its goal
+	DisciplineDecay         DisciplineEventType = "decay"          // A day without a completed fast after the grace period
+)
+
+const (
+	MinDisciplineIndex = 0.0
+	MaxDisciplineIndex = 100.0
+)
+
+// DisciplineEvent is one change to a user's Discipline Index, with the reason shown to the user.
+// SourceID (the fast session, or the day for decay) makes recording the same change twice a no-op.
+type DisciplineEvent struct {
+	ID         uuid.UUID           `json:"id"`
+	UserID     uuid.UUID           `json:"user_id"`
+	Type       DisciplineEventType `json:"type"`
+	Delta      float64             `json:"delta"` // Applied change, after clamping to 0-100
+	ScoreAfter float64             `json:"score_after"`
+	Reason     string              `json:"reason"`
+	SourceID   string              `json:"source_id"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+// DisciplineSnapshot is a user's Discipline Index at the end of a UTC day
+type DisciplineSnapshot struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Day       time.Time `json:"day"`
+	Score     float64   `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DisciplineTimeline is a user's current score with the daily snapshots and changes behind it
+type DisciplineTimeline struct {
+	Score     float64              `json:"score"`
+	From      time.Time            `json:"from"`
+	Snapshots []DisciplineSnapshot `json:"snapshots"` // Oldest first
+	Events    []DisciplineEvent    `json:"events"`    // Newest first
+	Rules     DisciplineRules      `json:"rules"`
+}
+
+// DisciplineRules are the published scoring weights
+type DisciplineRules struct {
+	BaseGoalHours  float64                     `json:"base_goal_hours"` // A fast of this goal is worth 1 point before the plan weight
+	MinDifficulty  float64                     `json:"min_difficulty"`  // Difficulty (goal / base) is clamped to this range
+	MaxDifficulty  float64                     `json:"max_difficulty"`
+	PlanWeights    map[FastingPlanType]float64 `json:"plan_weights"`     // Missing plans weigh 1
+	BrokenPenalty  float64                     `json:"broken_penalty"`   // Lost for quitting at the start, scaled down by progress made
+	MinPenalty     float64                     `json:"min_penalty"`      // Lost for quitting just before the goal
+	DecayGraceDays int                         `json:"decay_grace_days"` // Days without a completed fast before decay starts
+	DecayPerDay    float64                     `json:"decay_per_day"`
+}
+
+// DefaultDisciplineRules weight a 16 hour fast at 1 point and longer, stricter plans higher
+var DefaultDisciplineRules = DisciplineRules{
+	BaseGoalHours: 16,
+	MinDifficulty: 0.5,
+	MaxDifficulty: 4,
+	PlanWeights: map[FastingPlanType]float64{
+		PlanBeginner: 0.75,
+		Plan168:      1.0,
+		Plan186:      1.1,
+		PlanOMAD:     1.25,
+		Plan24h:      1.25,
+		Plan36h:      1.5,
+		PlanExtended: 1.5,
+	},
+	BrokenPenalty:  2,
+	MinPenalty:     0.5,
+	DecayGraceDays: 2,
+	DecayPerDay:    1,
+}
+
+// ScoreFast returns the change a finished fast makes and the reason for it
+func (r DisciplineRules) ScoreFast(session *FastingSession) (DisciplineEventType, float64, string) {
+	if session.GoalHours > 0 && session.ActualDurationHours >= float64(session.GoalHours) {
+		difficulty := clamp(float64(session.GoalHours)/r.BaseGoalHours, r.MinDifficulty, r.MaxDifficulty)
+		weight, ok := r.PlanWeights[session.PlanType]
+		if !ok {
+			weight = 1
+		}
+		points := roundPoints(difficulty * weight)
+		return DisciplineFastCompleted, points,
+			fmt.Sprintf("Completed a %dh %s fast (difficulty %.2g x plan weight %.2g): +%.1f", session.GoalHours, planLabel(session.PlanType), difficulty, weight, points)
+	}
+
+	progress := 0.0
+	if session.GoalHours > 0 {
+		progress = clamp(session.ActualDurationHours/float64(session.GoalHours), 0, 1)
+	}
+	penalty := math.Max(roundPoints(r.BrokenPenalty*(1-progress)), r.MinPenalty)
+	return DisciplineFastBroken, -penalty,
+		fmt.Sprintf("Ended a %dh fast early at %.1fh (%.0f%% of goal): -%.1f", session.GoalHours, session.ActualDurationHours, progress*100, penalty)
+}
+
+// DecayReason explains a decay event
+func (r DisciplineRules) DecayReason(inactiveDays int, delta float64) string {
+	return fmt.Sprintf("No completed fast for %d days: %.1f", inactiveDays, delta)
+}
+
+// ApplyDisciplineDelta returns the score after delta, clamped to 0-100, and the change actually applied
+func ApplyDisciplineDelta(score, delta float64) (float64, float64) {
+	next := roundPoints(clamp(score+delta, MinDisciplineIndex, MaxDisciplineIndex))
+	return next, roundPoints(next - score)
+}
+
+func planLabel(plan FastingPlanType) string {
+	if plan == "" {
+		return "custom"
+	}
+	return string(plan)
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Min(math.Max(v, lo), hi)
+}
+
+// roundPoints rounds to one decimal, the precision scores are shown with
+func roundPoints(v float64) float64 {
+	return math.Round(v*10) / 10
+}
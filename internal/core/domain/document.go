@@ -0,0 +1,265 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DocumentFormat is a downloadable rendering of a document
+type DocumentFormat string
+
+const (
+	DocumentFormatHTML DocumentFormat = "html"
+	DocumentFormatPDF  DocumentFormat = "pdf"
+)
+
+var (
+	ErrUnsupportedDocumentFormat = errors.New("unsupported document format")
+	ErrPaymentNotFound           = errors.New("payment not found")
+)
+
+// ContentType is the MIME type the format is served with
+func (f DocumentFormat) ContentType() string {
+	switch f {
+	case DocumentFormatHTML:
+		return "text/html; charset=utf-8"
+	case DocumentFormatPDF:
+		return "application/pdf"
+	}
+	return "application/octet-stream"
+}
+
+// IsValid reports whether documents can be rendered in the format
+func (f DocumentFormat) IsValid() bool {
+	return f == DocumentFormatHTML || f == DocumentFormatPDF
+}
+
+// DocumentKind is what a document certifies
+type DocumentKind string
+
+const (
+	DocumentKindVaultStatement DocumentKind = "vault_statement"
+	DocumentKindReceipt        DocumentKind = "receipt"
+)
+
+// Document is a renderer-neutral statement or receipt. Values are preformatted, so the HTML
+// and PDF renderings of a document always show the same figures.
+type Document struct {
+	ID       string            `json:"id"` // Stable: the same statement month or payment always has the same ID
+	Kind     DocumentKind      `json:"kind"`
+	Title    string            `json:"title"`
+	IssuedTo string            `json:"issued_to"`
+	Period   string            `json:"period,omitempty"`
+	IssuedAt time.Time         `json:"issued_at"`
+	Summary  []DocumentLine    `json:"summary"`
+	Sections []DocumentSection `json:"sections"`
+	Notes    []string          `json:"notes,omitempty"`
+}
+
+// DocumentLine is a labelled figure in a document's summary
+type DocumentLine struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// DocumentColumn describes one column of a section table
+type DocumentColumn struct {
+	Title   string  `json:"title"`
+	Width   float64 `json:"width"`   // Share of the table width; a section's widths add up to 1
+	Numeric bool    `json:"numeric"` // Right-aligned
+}
+
+// DocumentSection is a table of line items. Empty is shown instead of the table when there are no rows.
+type DocumentSection struct {
+	Heading string           `json:"heading"`
+	Columns []DocumentColumn `json:"columns"`
+	Rows    [][]string       `json:"rows"`
+	Empty   string           `json:"empty,omitempty"`
+}
+
+// RenderedDocument is a document rendered for download
+type RenderedDocument struct {
+	ID          string
+	Filename    string
+	ContentType string
+	Body        []byte
+}
+
+// VaultStatementDocumentID identifies a user's statement for a month. It is derived from the user
+// and the month, so a statement downloaded again, or regenerated after a late entry, keeps its ID.
+func VaultStatementDocumentID(userID uuid.UUID, monthStart time.Time) string {
+	month := monthStart.UTC().Format("200601")
+	return "VS-" + month + "-" + documentHash(userID.String(), month)
+}
+
+// ReceiptDocumentID identifies the receipt for a payment
+func ReceiptDocumentID(payment *Payment) string {
+	return "RC-" + payment.PaidAt.UTC().Format("20060102") + "-" + documentHash(string(payment.Kind), payment.ProviderID)
+}
+
+func documentHash(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return strings.ToUpper(hex.EncodeToString(sum[:5]))
+}
+
+// ledgerEntryLabels name ledger entry types on statements
+var ledgerEntryLabels = map[LedgerEntryType]string{
+	LedgerEntryDeposit:        "Deposit",
+	LedgerEntryDailyEarning:   "Earned back",
+	LedgerEntryReferralReward: "Referral reward",
+	LedgerEntryStreakBonus:    "Streak bonus",
+	LedgerEntryStakeWinnings:  "Stake winnings",
+	LedgerEntryForfeit:        "Forfeit",
+	LedgerEntryRefund:         "Refund",
+}
+
+// NewVaultStatementDocument lays out a monthly vault statement: the month's deposit, every
+// earning event, forfeit and refund from the ledger, and the card payments made that month.
+// participation may be nil for a month the user didn't take part in.
+func NewVaultStatementDocument(user *User, statement *VaultStatement, participation *VaultParticipation, payments []Payment, issuedAt time.Time) *Document {
+	period := &VaultLedger{UserID: statement.UserID, Entries: statement.Entries}
+	earned := period.Total(LedgerEntryDailyEarning, LedgerEntryReferralReward, LedgerEntryStreakBonus, LedgerEntryStakeWinnings)
+
+	summary := []DocumentLine{
+		{Label: "Deposit", Value: formatMoney(period.Total(LedgerEntryDeposit), "")},
+		{Label: "Earned back", Value: formatMoney(earned, "")},
+		{Label: "Forfeited", Value: formatMoney(period.Total(LedgerEntryForfeit), "")},
+		{Label: "Refunded", Value: formatMoney(period.Total(LedgerEntryRefund), "")},
+		{Label: "Held at month end", Value: formatMoney(statement.ClosingBalance.Held, "")},
+		{Label: "Refundable at month end", Value: formatMoney(statement.ClosingBalance.Earned, "")},
+	}
+	if participation != nil {
+		summary = append(summary, DocumentLine{Label: "Fasts completed", Value: fmt.Sprintf("%d", participation.FastsCompleted)})
+		if participation.RefundDate != nil {
+			summary = append(summary, DocumentLine{Label: "Refund date", Value: participation.RefundDate.UTC().Format("2 Jan 2006")})
+		}
+	}
+
+	activity := DocumentSection{
+		Heading: "Vault activity",
+		Columns: []DocumentColumn{
+			{Title: "Date", Width: 0.16},
+			{Title: "Type", Width: 0.2},
+			{Title: "Description", Width: 0.46},
+			{Title: "Amount", Width: 0.18, Numeric: true},
+		},
+		Rows:  [][]string{},
+		Empty: "No vault activity this month.",
+	}
+	for _, e := range statement.Entries {
+		activity.Rows = append(activity.Rows, []string{
+			e.EffectiveAt.UTC().Format("2 Jan 2006"),
+			ledgerEntryLabel(e.Type),
+			e.Reason,
+			formatMoney(e.Amount, ""),
+		})
+	}
+
+	return &Document{
+		ID:       VaultStatementDocumentID(statement.UserID, statement.PeriodStart),
+		Kind:     DocumentKindVaultStatement,
+		Title:    "Commitment Vault Statement",
+		IssuedTo: issuedTo(user),
+		Period:   statement.PeriodStart.UTC().Format("January 2006"),
+		IssuedAt: issuedAt,
+		Summary:  summary,
+		Sections: []DocumentSection{activity, paymentSection(payments)},
+		Notes: []string{
+			"Amounts earned back are refunded to your card after the month closes. Unearned deposit is forfeited.",
+		},
+	}
+}
+
+// NewReceiptDocument lays out the receipt for a single payment or refund
+func NewReceiptDocument(user *User, payment *Payment, issuedAt time.Time) *Document {
+	title := "Payment Receipt"
+	label := "Amount paid"
+	if payment.Kind == PaymentKindRefund {
+		title = "Refund Receipt"
+		label = "Amount refunded"
+	}
+	summary := []DocumentLine{
+		{Label: label, Value: formatMoney(payment.Amount, payment.Currency)},
+		{Label: "Date", Value: payment.PaidAt.UTC().Format("2 Jan 2006 15:04 MST")},
+		{Label: "Description", Value: payment.Description},
+	}
+	if payment.Reference != "" {
+		summary = append(summary, DocumentLine{Label: "Invoice number", Value: payment.Reference})
+	}
+	summary = append(summary, DocumentLine{Label: "Payment reference", Value: payment.ProviderID})
+
+	return &Document{
+		ID:       ReceiptDocumentID(payment),
+		Kind:     DocumentKindReceipt,
+		Title:    title,
+		IssuedTo: issuedTo(user),
+		IssuedAt: issuedAt,
+		Summary:  summary,
+		Sections: []DocumentSection{},
+	}
+}
+
+func paymentSection(payments []Payment) DocumentSection {
+	section := DocumentSection{
+		Heading: "Payments",
+		Columns: []DocumentColumn{
+			{Title: "Date", Width: 0.16},
+			{Title: "Reference", Width: 0.2},
+			{Title: "Description", Width: 0.46},
+			{Title: "Amount", Width: 0.18, Numeric: true},
+		},
+		Rows:  [][]string{},
+		Empty: "No card payments this month.",
+	}
+	for _, p := range payments {
+		amount := p.Amount
+		if p.Kind == PaymentKindRefund {
+			amount = -amount
+		}
+		reference := p.Reference
+		if reference == "" {
+			reference = p.ProviderID
+		}
+		section.Rows = append(section.Rows, []string{
+			p.PaidAt.UTC().Format("2 Jan 2006"),
+			reference,
+			p.Description,
+			formatMoney(amount, p.Currency),
+		})
+	}
+	return section
+}
+
+func ledgerEntryLabel(t LedgerEntryType) string {
+	if label, ok := ledgerEntryLabels[t]; ok {
+		return label
+	}
+	return string(t)
+}
+
+func issuedTo(user *User) string {
+	if user.Name != "" {
+		return fmt.Sprintf("%s <%s>", user.Name, user.Email)
+	}
+	return user.Email
+}
+
+// formatMoney formats an amount in dollars unless another currency is given
+func formatMoney(amount float64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	currency = strings.ToUpper(currency)
+	if currency == "" || currency == "USD" {
+		return fmt.Sprintf("%s$%.2f", sign, amount)
+	}
+	return fmt.Sprintf("%s%.2f %s", sign, amount, currency)
+}
@@ -0,0 +1,345 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+type EarningRuleKind string
+
+const (
+	EarningRuleMealPhoto     EarningRuleKind = "meal_photo"     // Amount per meal photo of an allowed meal type that passed the authenticity check
+	EarningRuleSteps         EarningRuleKind = "steps"          // Amount once the day's steps reach Threshold
+	EarningRuleFastCompleted EarningRuleKind = "fast_completed" // Amount per completed fast that met its goal
+	EarningRuleStreakBonus   EarningRuleKind = "streak_bonus"   // Bonus for every Threshold consecutive perfect days
+	EarningRulePerfectMonth  EarningRuleKind = "perfect_month"  // Bonus when every day of the month was perfect
+)
+
+// EarningRule is one declarative line of a rule set. Daily rules (meal photo, steps, fast)
+// count towards the daily cap; bonus rules (streak, perfect month) are paid on top of it.
+type EarningRule struct {
+	ID          string          `json:"id"`
+	Kind        EarningRuleKind `json:"kind"`
+	Amount      float64         `json:"amount"`
+	Threshold   float64         `json:"threshold,omitempty"`     // Steps for the steps rule, days for the streak rule
+	MealTypes   []string        `json:"meal_types,omitempty"`    // Meal types that count; one payout per type per day
+	MaxPerDay   int             `json:"max_per_day,omitempty"`   // 0 = unlimited
+	MaxPerMonth int             `json:"max_per_month,omitempty"` // 0 = unlimited; 1 makes a bonus one-time
+}
+
+// EarningRuleSet is a versioned set of earning rules. A version applies from the first day of
+// EffectiveFrom's month until the next version takes over. EffectiveFrom has to be in a month after
+// the one the version was published in, so publishing a new version only ever affects months that
+// have not started yet.
+type EarningRuleSet struct {
+	Version          int           `json:"version"`
+	PublishedAt      time.Time     `json:"published_at"`
+	EffectiveFrom    time.Time     `json:"effective_from"`
+	DailyCap         float64       `json:"daily_cap"`
+	PerfectDayAmount float64       `json:"perfect_day_amount"` // Capped daily earnings needed for a perfect day
+	Rules            []EarningRule `json:"rules"`
+}
+
+var (
+	ErrNoEarningRules = errors.New("no earning rule set in effect")
+)
+
+// DefaultEarningRuleSet mirrors LAZY_TAX_RULES.md
+func DefaultEarningRuleSet() EarningRuleSet {
+	return EarningRuleSet{
+		Version:          1,
+		PublishedAt:      time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+		EffectiveFrom:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		DailyCap:         2.00,
+		PerfectDayAmount: 2.00,
+		Rules: []EarningRule{
+			{ID: "meal_photo", Kind: EarningRuleMealPhoto, Amount: 0.50, MealTypes: []string{"breakfast", "lunch", "dinner"}, MaxPerDay: 3},
+			{ID: "steps_10k", Kind: EarningRuleSteps, Amount: 0.50, Threshold: 10000, MaxPerDay: 1},
+			{ID: "fast_completed", Kind: EarningRuleFastCompleted, Amount: 0.50, MaxPerDay: 1},
+			{ID: "streak_7_day", Kind: EarningRuleStreakBonus, Amount: 5.00, Threshold: 7},
+			{ID: "perfect_month", Kind: EarningRulePerfectMonth, Amount: 10.00, MaxPerMonth: 1},
+		},
+	}
+}
+
+// Validate checks that the rule set is well formed
+func (rs EarningRuleSet) Validate() error {
+	if rs.Version <= 0 {
+		return fmt.Errorf("rule set version must be positive")
+	}
+	from := rs.EffectiveFrom.UTC()
+	if from.Day() != 1 || from.Hour() != 0 || from.Minute() != 0 || from.Second() != 0 || from.Nanosecond() != 0 {
+		return fmt.Errorf("rule set v%d must take effect at the start of a month", rs.Version)
+	}
+	if rs.PublishedAt.IsZero() {
+		return fmt.Errorf("rule set v%d needs a publication date", rs.Version)
+	}
+	if !from.After(rs.PublishedAt) {
+		// Rules can't change in a month that has already started
+		return fmt.Errorf("rule set v%d published %s must take effect in a later month, not %s",
+			rs.Version, rs.PublishedAt.UTC().Format("2006-01-02"), from.Format("2006-01"))
+	}
+	if rs.DailyCap <= 0 {
+		return fmt.Errorf("rule set v%d needs a positive daily cap", rs.Version)
+	}
+	seen := make(map[string]bool)
+	for _, rule := range rs.Rules {
+		if rule.ID == "" || seen[rule.ID] {
+			return fmt.Errorf("rule set v%d has a missing or duplicate rule id %q", rs.Version, rule.ID)
+		}
+		seen[rule.ID] = true
+		if rule.Amount <= 0 {
+			return fmt.Errorf("rule %s in v%d needs a positive amount", rule.ID, rs.Version)
+		}
+		switch rule.Kind {
+		case EarningRuleMealPhoto, EarningRuleFastCompleted, EarningRulePerfectMonth:
+		case EarningRuleSteps, EarningRuleStreakBonus:
+			if rule.Threshold <= 0 {
+				return fmt.Errorf("rule %s in v%d needs a positive threshold", rule.ID, rs.Version)
+			}
+		default:
+			return fmt.Errorf("rule %s in v%d has unknown kind %q", rule.ID, rs.Version, rule.Kind)
+		}
+	}
+	return nil
+}
+
+// EarningRuleBook holds every published rule set version
+type EarningRuleBook struct {
+	versions []EarningRuleSet // Sorted by EffectiveFrom
+}
+
+// NewEarningRuleBook validates the rule sets and orders them by effective date
+func NewEarningRuleBook(sets ...EarningRuleSet) (*EarningRuleBook, error) {
+	if len(sets) == 0 {
+		return nil, ErrNoEarningRules
+	}
+	versions := make([]EarningRuleSet, len(sets))
+	copy(versions, sets)
+	seen := make(map[int]bool)
+	for i := range versions {
+		if err := versions[i].Validate(); err != nil {
+			return nil, err
+		}
+		if seen[versions[i].Version] {
+			return nil, fmt.Errorf("duplicate rule set version %d", versions[i].Version)
+		}
+		seen[versions[i].Version] = true
+		versions[i].EffectiveFrom = versions[i].EffectiveFrom.UTC()
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].EffectiveFrom.Before(versions[j].EffectiveFrom)
+	})
+	for i := 1; i < len(versions); i++ {
+		if versions[i].EffectiveFrom.Equal(versions[i-1].EffectiveFrom) {
+			return nil, fmt.Errorf("rule set versions %d and %d take effect in the same month",
+				versions[i-1].Version, versions[i].Version)
+		}
+	}
+	return &EarningRuleBook{versions: versions}, nil
+}
+
+// ForDay returns the rule set in effect for the month containing day
+func (b *EarningRuleBook) ForDay(day time.Time) (EarningRuleSet, error) {
+	day = day.UTC()
+	monthStart := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := len(b.versions) - 1; i >= 0; i-- {
+		if !b.versions[i].EffectiveFrom.After(monthStart) {
+			return b.versions[i], nil
+		}
+	}
+	return EarningRuleSet{}, ErrNoEarningRules
+}
+
+// DayActivity is everything a user did on one calendar day that rules can pay for
+type DayActivity struct {
+	Date  time.Time        // Midnight UTC
+	Meals []Meal           // Meals logged that day
+	Steps float64          // Total steps that day
+	Fasts []FastingSession // Fasts that ended that day
+}
+
+// EarningItem is a single payout produced by a rule
+type EarningItem struct {
+	RuleID     string          `json:"rule_id"`
+	Kind       EarningRuleKind `json:"kind"`
+	Amount     float64         `json:"amount"`
+	Reason     string          `json:"reason"`
+	SourceType string          `json:"source_type"`
+	SourceID   string          `json:"source_id"`
+}
+
+// DayEarnings is the result of evaluating one day against a rule set
+type DayEarnings struct {
+	Date        time.Time     `json:"date"`
+	RuleVersion int           `json:"rule_version"`
+	Items       []EarningItem `json:"items"`   // Daily items, already trimmed to the daily cap
+	Bonuses     []EarningItem `json:"bonuses"` // Streak and month bonuses, paid on top of the cap
+	DailyTotal  float64       `json:"daily_total"`
+	Perfect     bool          `json:"perfect"`
+}
+
+// Total returns the daily total plus bonuses
+func (e *DayEarnings) Total() float64 {
+	total := e.DailyTotal
+	for _, b := range e.Bonuses {
+		total += b.Amount
+	}
+	return total
+}
+
+// EvaluateDay applies the daily rules to a day's activity and trims the result to the daily cap.
+// Bonuses are not evaluated here because they depend on earlier days; see EvaluateMonthToDate.
+func (rs EarningRuleSet) EvaluateDay(activity DayActivity) *DayEarnings {
+	result := &DayEarnings{
+		Date:        activity.Date,
+		RuleVersion: rs.Version,
+		Items:       []EarningItem{},
+		Bonuses:     []EarningItem{},
+	}
+	date := activity.Date.Format("2006-01-02")
+
+	var items []EarningItem
+	for _, rule := range rs.Rules {
+		var ruleItems []EarningItem
+		switch rule.Kind {
+		case EarningRuleMealPhoto:
+			paidTypes := make(map[string]bool)
+			meals := append([]Meal(nil), activity.Meals...)
+			sort.Slice(meals, func(i, j int) bool { return meals[i].LoggedAt.Before(meals[j].LoggedAt) })
+			for _, meal := range meals {
+				if meal.Image == "" || !meal.IsAuthentic || paidTypes[meal.MealType] || !containsString(rule.MealTypes, meal.MealType) {
+					continue
+				}
+				paidTypes[meal.MealType] = true
+				ruleItems = append(ruleItems, EarningItem{
+					Reason:     fmt.Sprintf("Logged %s photo on %s", meal.MealType, date),
+					SourceType: LedgerSourceMeal,
+					SourceID:   meal.ID.String(),
+				})
+			}
+		case EarningRuleSteps:
+			if activity.Steps >= rule.Threshold {
+				ruleItems = append(ruleItems, EarningItem{
+					Reason:     fmt.Sprintf("Walked %.0f steps on %s", activity.Steps, date),
+					SourceType: LedgerSourceSteps,
+					SourceID:   date,
+				})
+			}
+		case EarningRuleFastCompleted:
+			for _, fast := range activity.Fasts {
+				if !fast.MetGoal() {
+					continue
+				}
+				ruleItems = append(ruleItems, EarningItem{
+					Reason:     fmt.Sprintf("Completed a %dh fast on %s", fast.GoalHours, date),
+					SourceType: LedgerSourceFastingSession,
+					SourceID:   fast.ID.String(),
+				})
+			}
+		}
+		if rule.MaxPerDay > 0 && len(ruleItems) > rule.MaxPerDay {
+			ruleItems = ruleItems[:rule.MaxPerDay]
+		}
+		for _, item := range ruleItems {
+			item.RuleID = rule.ID
+			item.Kind = rule.Kind
+			item.Amount = rule.Amount
+			items = append(items, item)
+		}
+	}
+
+	// Apply the daily cap in rule order; the item that crosses the cap is paid partially
+	remaining := rs.DailyCap
+	for _, item := range items {
+		if remaining <= 0 {
+			break
+		}
+		item.Amount = math.Min(item.Amount, remaining)
+		remaining -= item.Amount
+		result.DailyTotal += item.Amount
+		result.Items = append(result.Items, item)
+	}
+	result.DailyTotal = math.Round(result.DailyTotal*100) / 100
+	result.Perfect = rs.PerfectDayAmount > 0 && result.DailyTotal >= rs.PerfectDayAmount
+	return result
+}
+
+// EvaluateMonthToDate evaluates consecutive days of one month, oldest first, starting on the 1st.
+// Streaks are counted within the month, so every day's result, bonuses included, depends only on
+// that month's activity and rule set.
+func (rs EarningRuleSet) EvaluateMonthToDate(days []DayActivity) []*DayEarnings {
+	results := make([]*DayEarnings, 0, len(days))
+	paid := make(map[string]int)
+	streak := 0
+	for _, activity := range days {
+		result := rs.EvaluateDay(activity)
+		if result.Perfect {
+			streak++
+			rs.evaluateBonuses(result, streak, paid)
+		} else {
+			streak = 0
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// evaluateBonuses adds the bonuses earned on a perfect day that ends a streak of the given length
+func (rs EarningRuleSet) evaluateBonuses(result *DayEarnings, streak int, paid map[string]int) {
+	date := result.Date.Format("2006-01-02")
+	lastDayOfMonth := result.Date.AddDate(0, 0, 1).Month() != result.Date.Month()
+
+	for _, rule := range rs.Rules {
+		if rule.MaxPerMonth > 0 && paid[rule.ID] >= rule.MaxPerMonth {
+			continue
+		}
+		var item EarningItem
+		switch rule.Kind {
+		case EarningRuleStreakBonus:
+			if streak%int(rule.Threshold) != 0 {
+				continue
+			}
+			item = EarningItem{
+				Reason:   fmt.Sprintf("%d-day perfect streak on %s", streak, date),
+				SourceID: fmt.Sprintf("%s:%s", rule.ID, date),
+			}
+		case EarningRulePerfectMonth:
+			if !lastDayOfMonth || streak < result.Date.Day() {
+				continue
+			}
+			item = EarningItem{
+				Reason:   fmt.Sprintf("Perfect month: %s", result.Date.Format("January 2006")),
+				SourceID: fmt.Sprintf("%s:%s", rule.ID, result.Date.Format("2006-01")),
+			}
+		default:
+			continue
+		}
+		item.RuleID = rule.ID
+		item.Kind = rule.Kind
+		item.Amount = rule.Amount
+		item.SourceType = LedgerSourceStreak
+		result.Bonuses = append(result.Bonuses, item)
+		paid[rule.ID]++
+	}
+}
+
+// MetGoal reports whether a completed fast lasted at least its goal
+func (s FastingSession) MetGoal() bool {
+	if s.Status != StatusCompleted || s.EndTime == nil {
+		return false
+	}
+	return s.EndTime.Sub(s.StartTime).Hours() >= float64(s.GoalHours)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Capability is a named premium feature a plan can unlock
+type Capability string
+
+const (
+	CapabilityVault           Capability = "vault"            // Commitment vault deposits and refunds
+	CapabilityHardKetoData    Capability = "hard_keto_data"   // Blood ketone and breath acetone inputs
+	CapabilityPartnerMatching Capability = "partner_matching" // Accountability partner matching
+	CapabilitySharedGoals     Capability = "shared_goals"     // Shared goal tracking with a partner
+	CapabilityGroupPots       Capability = "group_pots"       // Group challenges with pooled deposits
+	CapabilityAIMealAnalysis  Capability = "ai_meal_analysis" // Photo and description analysis of meals
+	CapabilityAICoaching      Capability = "ai_coaching"      // Personalized fasting and meal recommendations
+)
+
+// ErrCapabilityRequired is returned when the user's plan doesn't include a capability
+var ErrCapabilityRequired = errors.New("premium subscription required")
+
+// EntitlementError names the capability that was missing. It matches ErrCapabilityRequired with errors.Is.
+type EntitlementError struct {
+	Capability    Capability
+	RequiredPlans []SubscriptionTier
+}
+
+func (e *EntitlementError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrCapabilityRequired.Error(), e.Capability)
+}
+
+func (e *EntitlementError) Is(target error) bool {
+	return target == ErrCapabilityRequired
+}
+
+// vaultCapabilities are included in every paid plan (Tier 1 in LAZY_TAX_RULES.md)
+var vaultCapabilities = []Capability{CapabilityVault, CapabilityHardKetoData}
+
+// planCapabilities maps each plan to the capabilities it unlocks.
+// Accountability Plus and AI Coach are both add-ons on top of the vault plan.
+var planCapabilities = map[SubscriptionTier][]Capability{
+	TierFree:  {},
+	TierVault: vaultCapabilities,
+	TierAccountabilityPlus: append(append([]Capability{}, vaultCapabilities...),
+		CapabilityPartnerMatching, CapabilitySharedGoals, CapabilityGroupPots),
+	TierAICoach: append(append([]Capability{}, vaultCapabilities...),
+		CapabilityAIMealAnalysis, CapabilityAICoaching),
+}
+
+// planOrder is the order plans are listed in upgrade suggestions
+var planOrder = []SubscriptionTier{TierFree, TierVault, TierAccountabilityPlus, TierAICoach}
+
+// IsValid reports whether the tier is a known plan
+func (t SubscriptionTier) IsValid() bool {
+	_, ok := planCapabilities[t]
+	return ok
+}
+
+// PlanCapabilities returns the capabilities a plan unlocks when its subscription is active
+func PlanCapabilities(tier SubscriptionTier) []Capability {
+	return append([]Capability{}, planCapabilities[tier]...)
+}
+
+// PlansWith returns the plans that include the capability
+func PlansWith(capability Capability) []SubscriptionTier {
+	var plans []SubscriptionTier
+	for _, tier := range planOrder {
+		for _, c := range planCapabilities[tier] {
+			if c == capability {
+				plans = append(plans, tier)
+				break
+			}
+		}
+	}
+	return plans
+}
+
+// Entitlements is what a user can currently access
+type Entitlements struct {
+	Tier         SubscriptionTier   `json:"tier"`
+	Status       SubscriptionStatus `json:"status"`
+	Capabilities []Capability       `json:"capabilities"`
+	TrialEndsAt  *time.Time         `json:"trial_ends_at,omitempty"` // Set while the plan comes from a free trial
+}
+
+// Has reports whether the capability is included
+func (e *Entitlements) Has(capability Capability) bool {
+	for _, c := range e.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Require returns an *EntitlementError if the capability isn't included
+func (e *Entitlements) Require(capability Capability) error {
+	if e.Has(capability) {
+		return nil
+	}
+	return &EntitlementError{Capability: capability, RequiredPlans: PlansWith(capability)}
+}
+
+// Entitlements returns the user's capabilities. Paid capabilities only apply while the subscription grants
+// access, or until a free trial ends.
+func (u *User) Entitlements() *Entitlements {
+	e := &Entitlements{
+		Tier:         u.SubscriptionTier,
+		Status:       u.SubscriptionStatus,
+		Capabilities: []Capability{},
+	}
+	if u.SubscriptionStatus.GrantsAccess() && !u.trialExpired(time.Now()) {
+		e.Capabilities = PlanCapabilities(u.SubscriptionTier)
+	}
+	if u.OnInAppTrial() {
+		e.TrialEndsAt = u.TrialEndsAt
+	}
+	return e
+}
+
+// HasCapability reports whether the user's active plan includes the capability
+func (u *User) HasCapability(capability Capability) bool {
+	return u.Entitlements().Has(capability)
+}
@@ -0,0 +1,37 @@
+package domain
+
+// KnowledgeArticle is a curated article in the fasting knowledge base
+type KnowledgeArticle struct {
+	ID       string             `json:"id"` // File name without extension
+	Title    string             `json:"title"`
+	Source   string             `json:"source"` // Reference the article is based on
+	Passages []KnowledgePassage `json:"-"`
+}
+
+// KnowledgePassage is a retrievable chunk of an article, usually one section or part of it
+type KnowledgePassage struct {
+	ID        string  `json:"id"` // Article ID and chunk number, e.g. "ketosis#2"
+	ArticleID string  `json:"article_id"`
+	Title     string  `json:"title"`
+	Heading   string  `json:"heading,omitempty"`
+	Source    string  `json:"source"`
+	Text      string  `json:"text"`
+	Score     float64 `json:"score,omitempty"`
+}
+
+// KnowledgeCitation is a passage Cortex cited as [Ref] in an answer
+type KnowledgeCitation struct {
+	Ref       int    `json:"ref"`
+	ArticleID string `json:"article_id"`
+	Title     string `json:"title"`
+	Heading   string `json:"heading,omitempty"`
+	Source    string `json:"source"`
+}
+
+// CortexAnswer is a Cortex reply with the knowledge base passages it cited
+type CortexAnswer struct {
+	Text       string              `json:"response"`
+	Citations  []KnowledgeCitation `json:"sources"`
+	SafetyMode bool                `json:"safety_mode,omitempty"` // Answered with the safe-response template
+	Actions    []CortexAction      `json:"actions,omitempty"`     // Proposed actions waiting for the user to confirm them
+}
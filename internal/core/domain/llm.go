@@ -0,0 +1,50 @@
+package domain
+
+import "encoding/json"
+
+// LLMUseCase is a Cortex feature that can be served by its own LLM provider
+type LLMUseCase string
+
+const (
+	LLMUseCaseChat       LLMUseCase = "chat"        // Coach chat and craving help
+	LLMUseCaseInsight    LLMUseCase = "insight"     // Fasting insights, refeeding tips and motivational quotes
+	LLMUseCaseMealVision LLMUseCase = "meal_vision" // Meal photo analysis
+)
+
+// LLMUseCases lists every use case a provider can be configured for
+var LLMUseCases = []LLMUseCase{LLMUseCaseChat, LLMUseCaseInsight, LLMUseCaseMealVision}
+
+// LLMRole is who wrote a conversation turn
+type LLMRole string
+
+const (
+	LLMRoleUser      LLMRole = "user"
+	LLMRoleAssistant LLMRole = "assistant"
+)
+
+// LLMMessage is one conversation turn sent to a provider
+type LLMMessage struct {
+	Role    LLMRole `json:"role"`
+	Content string  `json:"content"`
+}
+
+// LLMTool is a function a provider may ask to call instead of, or alongside, replying
+type LLMTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"` // JSON schema of the arguments object
+}
+
+// LLMToolCall is a call to an LLMTool the model asked for. Arguments are the model's own
+// output and must be validated before use.
+type LLMToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// LLMToolReply is a reply from a provider offered tools: text, tool calls or both
+type LLMToolReply struct {
+	Text      string        `json:"text"`
+	ToolCalls []LLMToolCall `json:"tool_calls,omitempty"`
+}
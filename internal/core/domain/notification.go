@@ -44,4 +44,8 @@ const (
 	NotificationTypeFastEndReminder   NotificationType = "fast_end_reminder"   // Fast completing soon
 	NotificationTypeHydrationReminder NotificationType = "hydration_reminder"  // Drink water reminder
 	NotificationTypeWeeklyCheckIn     NotificationType = "weekly_checkin"      // Weekly AI summary
+	// Billing
+	NotificationTypeTrialEnding   NotificationType = "trial_ending"   // Free trial ends soon
+	NotificationTypeTrialEnded    NotificationType = "trial_ended"    // Free trial ran out without a subscription
+	NotificationTypePaymentFailed NotificationType = "payment_failed" // Subscription payment failed
 )
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentKind is the direction money moved between the user's card and us
+type PaymentKind string
+
+const (
+	PaymentKindCharge PaymentKind = "charge" // A paid subscription invoice
+	PaymentKindRefund PaymentKind = "refund" // Money returned to the card
+)
+
+// Payment is one card payment or refund as reported by the payment provider. It is the
+// user's payment history behind vault statements and receipts. ProviderID (invoice or
+// refund ID) makes recording the same payment twice a no-op.
+type Payment struct {
+	ID          uuid.UUID   `json:"id"`
+	UserID      uuid.UUID   `json:"user_id"`
+	Kind        PaymentKind `json:"kind"`
+	Amount      float64     `json:"amount"` // Always positive; Kind gives the direction
+	Currency    string      `json:"currency"`
+	ProviderID  string      `json:"provider_id"`
+	Reference   string      `json:"reference,omitempty"` // Invoice number shown to the user, if any
+	Description string      `json:"description"`
+	PaidAt      time.Time   `json:"paid_at"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
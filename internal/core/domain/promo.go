@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PromoDiscountType string
+
+const (
+	PromoDiscountPercent PromoDiscountType = "percent" // PercentOff of each discounted invoice
+	PromoDiscountFixed   PromoDiscountType = "fixed"   // AmountOff (USD) of each discounted invoice
+)
+
+// PromoDuration is how many invoices of a subscription the discount applies to
+type PromoDuration string
+
+const (
+	PromoDurationOnce      PromoDuration = "once"      // The first invoice only
+	PromoDurationRepeating PromoDuration = "repeating" // The first DurationMonths months
+	PromoDurationForever   PromoDuration = "forever"
+)
+
+var (
+	ErrPromoCodeNotFound        = errors.New("promo code not found")
+	ErrPromoCodeExists          = errors.New("promo code already exists")
+	ErrPromoCodeInactive        = errors.New("promo code is no longer active")
+	ErrPromoCodeExpired         = errors.New("promo code has expired")
+	ErrPromoCodeExhausted       = errors.New("promo code has reached its usage limit")
+	ErrPromoCodeNotForPlan      = errors.New("promo code does not apply to this plan")
+	ErrPromoCodeAlreadyRedeemed = errors.New("promo code already redeemed")
+	ErrInvalidPromoCode         = errors.New("invalid promo code")
+)
+
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]{2,31}$`)
+
+// PromoCode is a subscription discount marketing can hand out. It is mirrored to the payment
+// provider as a coupon, which applies the discount at checkout.
+type PromoCode struct {
+	ID             uuid.UUID          `json:"id"`
+	Code           string             `json:"code"` // Upper case; what users type at checkout
+	Description    string             `json:"description,omitempty"`
+	DiscountType   PromoDiscountType  `json:"discount_type"`
+	PercentOff     float64            `json:"percent_off,omitempty"`
+	AmountOff      float64            `json:"amount_off,omitempty"`
+	Duration       PromoDuration      `json:"duration"`
+	DurationMonths int                `json:"duration_months,omitempty"`
+	MaxRedemptions int                `json:"max_redemptions,omitempty"` // 0: unlimited
+	TimesRedeemed  int                `json:"times_redeemed"`
+	Plans          []SubscriptionTier `json:"plans,omitempty"` // Empty: every plan
+	ExpiresAt      *time.Time         `json:"expires_at,omitempty"`
+	Active         bool               `json:"active"`
+	CouponID       string             `json:"coupon_id"` // Payment provider coupon
+	CreatedBy      uuid.UUID          `json:"created_by"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// PromoRedemption records a promo code used for a subscription checkout
+type PromoRedemption struct {
+	ID                uuid.UUID        `json:"id"`
+	PromoCodeID       uuid.UUID        `json:"promo_code_id"`
+	Code              string           `json:"code"`
+	UserID            uuid.UUID        `json:"user_id"`
+	Plan              SubscriptionTier `json:"plan"`
+	CheckoutSessionID string           `json:"checkout_session_id"`
+	SubscriptionID    string           `json:"subscription_id"` // Payment provider subscription
+	RedeemedAt        time.Time        `json:"redeemed_at"`
+}
+
+// CouponRequest describes the provider coupon behind a promo code
+type CouponRequest struct {
+	Name           string
+	PercentOff     float64
+	AmountOff      float64 // USD
+	Duration       PromoDuration
+	DurationMonths int
+	MaxRedemptions int        // 0: unlimited
+	RedeemBy       *time.Time // nil: no expiry
+}
+
+// NormalizePromoCode is the form codes are stored and looked up in
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks a new promo code's terms and rounds AmountOff to cents
+func (p *PromoCode) Validate(now time.Time) error {
+	if !promoCodePattern.MatchString(p.Code) {
+		return fmt.Errorf("%w: code must be 3-32 letters, digits, '-' or '_'", ErrInvalidPromoCode)
+	}
+	switch p.DiscountType {
+	case PromoDiscountPercent:
+		if p.PercentOff <= 0 || p.PercentOff > 100 || p.AmountOff != 0 {
+			return fmt.Errorf("%w: percent_off must be between 0 and 100", ErrInvalidPromoCode)
+		}
+	case PromoDiscountFixed:
+		if p.AmountOff <= 0 || p.PercentOff != 0 {
+			return fmt.Errorf("%w: amount_off must be positive", ErrInvalidPromoCode)
+		}
+		p.AmountOff = fromCents(toCents(p.AmountOff))
+	default:
+		return fmt.Errorf("%w: discount_type must be percent or fixed", ErrInvalidPromoCode)
+	}
+	switch p.Duration {
+	case PromoDurationOnce, PromoDurationForever:
+		if p.DurationMonths != 0 {
+			return fmt.Errorf("%w: duration_months is only for repeating discounts", ErrInvalidPromoCode)
+		}
+	case PromoDurationRepeating:
+		if p.DurationMonths < 1 || p.DurationMonths > 36 {
+			return fmt.Errorf("%w: duration_months must be between 1 and 36", ErrInvalidPromoCode)
+		}
+	default:
+		return fmt.Errorf("%w: duration must be once, repeating or forever", ErrInvalidPromoCode)
+	}
+	if p.MaxRedemptions < 0 {
+		return fmt.Errorf("%w: max_redemptions can't be negative", ErrInvalidPromoCode)
+	}
+	for _, plan := range p.Plans {
+		if !plan.IsValid() || plan == TierFree {
+			return fmt.Errorf("%w: %q is not a paid plan", ErrInvalidPromoCode, plan)
+		}
+	}
+	if p.ExpiresAt != nil && !p.ExpiresAt.After(now) {
+		return fmt.Errorf("%w: expires_at must be in the future", ErrInvalidPromoCode)
+	}
+	return nil
+}
+
+// AppliesTo reports whether the code can be used for plan
+func (p *PromoCode) AppliesTo(plan SubscriptionTier) bool {
+	if len(p.Plans) == 0 {
+		return true
+	}
+	for _, allowed := range p.Plans {
+		if allowed == plan {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckRedeemable returns why the code can't be used for plan at now, or nil if it can
+func (p *PromoCode) CheckRedeemable(plan SubscriptionTier, now time.Time) error {
+	switch {
+	case !p.Active:
+		return ErrPromoCodeInactive
+	case p.ExpiresAt != nil && !now.Before(*p.ExpiresAt):
+		return ErrPromoCodeExpired
+	case p.MaxRedemptions > 0 && p.TimesRedeemed >= p.MaxRedemptions:
+		return ErrPromoCodeExhausted
+	case !p.AppliesTo(plan):
+		return ErrPromoCodeNotForPlan
+	}
+	return nil
+}
+
+// CouponRequest is the provider coupon that applies this code's discount
+func (p *PromoCode) CouponRequest() CouponRequest {
+	return CouponRequest{
+		Name:           p.Code,
+		PercentOff:     p.PercentOff,
+		AmountOff:      p.AmountOff,
+		Duration:       p.Duration,
+		DurationMonths: p.DurationMonths,
+		MaxRedemptions: p.MaxRedemptions,
+		RedeemBy:       p.ExpiresAt,
+	}
+}
@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownPrompt is returned when no template is registered under a prompt name
+var ErrUnknownPrompt = errors.New("unknown prompt template")
+
+// PromptVarType is the type of a template variable
+type PromptVarType string
+
+const (
+	PromptVarString  PromptVarType = "string"
+	PromptVarNumber  PromptVarType = "number"
+	PromptVarInteger PromptVarType = "integer"
+	PromptVarBoolean PromptVarType = "boolean"
+)
+
+// PromptVariant is one arm of a prompt experiment. System and User are Go text/template
+// sources rendered with the template's variables; either may be empty.
+type PromptVariant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"` // Relative share of users bucketed into this variant
+	System string `json:"system,omitempty"`
+	User   string `json:"user,omitempty"`
+}
+
+// PromptTemplate is a named, versioned prompt. Raise Version whenever the wording or the
+// variants change, so responses to the old and new prompt can be told apart.
+type PromptTemplate struct {
+	Name        string                   `json:"name"`
+	Version     int                      `json:"version"`
+	Description string                   `json:"description,omitempty"`
+	Variables   map[string]PromptVarType `json:"variables,omitempty"`
+	Variants    []PromptVariant          `json:"variants"`
+}
+
+// Validate checks the template's metadata; the registry checks the template sources
+func (t PromptTemplate) Validate() error {
+	if t.Name == "" {
+		return errors.New("prompt template has no name")
+	}
+	if t.Version < 1 {
+		return fmt.Errorf("prompt %s: version must be at least 1", t.Name)
+	}
+	for name, typ := range t.Variables {
+		switch typ {
+		case PromptVarString, PromptVarNumber, PromptVarInteger, PromptVarBoolean:
+		default:
+			return fmt.Errorf("prompt %s: variable %s has unknown type %q", t.Name, name, typ)
+		}
+	}
+	if len(t.Variants) == 0 {
+		return fmt.Errorf("prompt %s: no variants", t.Name)
+	}
+	seen := make(map[string]bool)
+	for _, v := range t.Variants {
+		if v.Name == "" {
+			return fmt.Errorf("prompt %s: variant has no name", t.Name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("prompt %s: duplicate variant %s", t.Name, v.Name)
+		}
+		seen[v.Name] = true
+		if v.Weight < 1 {
+			return fmt.Errorf("prompt %s: variant %s must have a positive weight", t.Name, v.Name)
+		}
+		if v.System == "" && v.User == "" {
+			return fmt.Errorf("prompt %s: variant %s has no system or user template", t.Name, v.Name)
+		}
+	}
+	return nil
+}
+
+// BindVars checks vars against the declared variables and converts numbers to the declared
+// type, so templates can format a number with printf whether it was passed as an int or a float
+func (t PromptTemplate) BindVars(vars PromptVars) (PromptVars, error) {
+	for name := range vars {
+		if _, ok := t.Variables[name]; !ok {
+			return nil, fmt.Errorf("prompt %s: undeclared variable %s", t.Name, name)
+		}
+	}
+	bound := make(PromptVars, len(t.Variables))
+	for name, typ := range t.Variables {
+		value, ok := vars[name]
+		if !ok {
+			return nil, fmt.Errorf("prompt %s: missing variable %s", t.Name, name)
+		}
+		converted, ok := typ.convert(value)
+		if !ok {
+			return nil, fmt.Errorf("prompt %s: variable %s must be of type %s, got %T", t.Name, name, typ, value)
+		}
+		bound[name] = converted
+	}
+	return bound, nil
+}
+
+func (typ PromptVarType) convert(value interface{}) (interface{}, bool) {
+	var number float64
+	switch v := value.(type) {
+	case string:
+		return v, typ == PromptVarString
+	case bool:
+		return v, typ == PromptVarBoolean
+	case int:
+		number = float64(v)
+	case int64:
+		number = float64(v)
+	case float32:
+		number = float64(v)
+	case float64:
+		number = v
+	default:
+		return nil, false
+	}
+	switch typ {
+	case PromptVarNumber:
+		return number, true
+	case PromptVarInteger:
+		return int64(number), number == math.Trunc(number)
+	}
+	return nil, false
+}
+
+// VariantFor buckets a user into one of the variants by weight. The bucket depends only on
+// the user, the prompt name and its version, so a user keeps their variant until the prompt
+// is republished.
+func (t PromptTemplate) VariantFor(userID uuid.UUID) PromptVariant {
+	total := 0
+	for _, v := range t.Variants {
+		total += v.Weight
+	}
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d:%s", t.Name, t.Version, userID)
+	bucket := int(h.Sum32() % uint32(total))
+	for _, v := range t.Variants {
+		if bucket < v.Weight {
+			return v
+		}
+		bucket -= v.Weight
+	}
+	return t.Variants[len(t.Variants)-1]
+}
+
+// PromptVars are the values a template is rendered with, keyed by variable name
+type PromptVars map[string]interface{}
+
+// RenderedPrompt is a template rendered for one user, with the variant they were bucketed into
+type RenderedPrompt struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+	Variant string `json:"variant"`
+	System  string `json:"-"`
+	User    string `json:"-"`
+}
+
+// PromptExposure records that a user got a response generated from a prompt variant
+type PromptExposure struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Prompt    string    `json:"prompt"`
+	Version   int       `json:"version"`
+	Variant   string    `json:"variant"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PromptExposureSummary is one user's exposures to one prompt variant
+type PromptExposureSummary struct {
+	Prompt         string
+	Version        int
+	Variant        string
+	UserID         uuid.UUID
+	FirstExposedAt time.Time
+	Responses      int
+}
+
+// PromptVariantStats compares a prompt variant by the fasts its users finished after first
+// seeing it
+type PromptVariantStats struct {
+	Prompt         string  `json:"prompt"`
+	Version        int     `json:"version"`
+	Variant        string  `json:"variant"`
+	Users          int     `json:"users"`
+	Responses      int     `json:"responses"`
+	FastsEnded     int     `json:"fasts_ended"`     // Completed or cancelled
+	FastsCompleted int     `json:"fasts_completed"` // Reached their goal
+	CompletionRate float64 `json:"completion_rate"`
+}
@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"errors"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,16 +12,159 @@ import (
 type ReferralStatus string
 
 const (
-	ReferralStatusPending   ReferralStatus = "pending"
-	ReferralStatusCompleted ReferralStatus = "completed"
+	ReferralStatusPending   ReferralStatus = "pending"   // Waiting for the referee's qualifying event
+	ReferralStatusHeld      ReferralStatus = "held"      // Qualified but flagged as suspicious; waiting for an admin
+	ReferralStatusCompleted ReferralStatus = "completed" // Rewards are earned (and paid once each side is a vault member)
+	ReferralStatusRejected  ReferralStatus = "rejected"  // An admin decided it was fraudulent; nothing is paid
+)
+
+// ReferralEvent is what the referee has to do for a referral to pay out
+type ReferralEvent string
+
+const (
+	ReferralEventFirstPayment ReferralEvent = "first_payment" // First paid invoice of a subscription
+	ReferralEventFirstFast    ReferralEvent = "first_fast"    // First fast completed with its goal met
+)
+
+// ReferralFraudSignal is a reason a referral is held for review instead of paid
+type ReferralFraudSignal string
+
+const (
+	FraudSignalSharedIP        ReferralFraudSignal = "shared_ip"        // Referee signed up from the referrer's (or another referee's) IP
+	FraudSignalSharedDevice    ReferralFraudSignal = "shared_device"    // Same for the device ID, or the same browser on the same network
+	FraudSignalDisposableEmail ReferralFraudSignal = "disposable_email" // Referee used a throwaway email domain
+	FraudSignalRapidSignups    ReferralFraudSignal = "rapid_signups"    // The referrer brought in too many signups too quickly
+)
+
+var (
+	ErrReferralNotFound = errors.New("referral not found")
+	ErrReferralNotHeld  = errors.New("referral is not held for review")
+	ErrSelfReferral     = errors.New("cannot refer yourself")
+	ErrAlreadyReferred  = errors.New("user already referred")
 )
 
 type Referral struct {
-	ID          uuid.UUID      `json:"id"`
-	ReferrerID  uuid.UUID      `json:"referrer_id"`
-	RefereeID   uuid.UUID      `json:"referee_id"`
-	Status      ReferralStatus `json:"status"`
-	RewardValue float64        `json:"reward_value"` // Amount awarded to each user
-	CreatedAt   time.Time      `json:"created_at"`
-	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+	ID             uuid.UUID             `json:"id"`
+	ReferrerID     uuid.UUID             `json:"referrer_id"`
+	RefereeID      uuid.UUID             `json:"referee_id"`
+	Status         ReferralStatus        `json:"status"`
+	RewardValue    float64               `json:"reward_value"`   // Referrer's reward, set by their tier at completion
+	RefereeReward  float64               `json:"referee_reward"` // Referee's reward
+	QualifiedBy    ReferralEvent         `json:"qualified_by,omitempty"`
+	QualifiedAt    *time.Time            `json:"qualified_at,omitempty"`
+	FraudSignals   []ReferralFraudSignal `json:"fraud_signals,omitempty"`
+	ReviewedBy     *uuid.UUID            `json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time            `json:"reviewed_at,omitempty"`
+	ReviewNote     string                `json:"review_note,omitempty"`
+	ReferrerPaidAt *time.Time            `json:"referrer_paid_at,omitempty"`
+	RefereePaidAt  *time.Time            `json:"referee_paid_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	CompletedAt    *time.Time            `json:"completed_at,omitempty"`
+}
+
+// IsPaid reports whether both sides have been credited
+func (r *Referral) IsPaid() bool {
+	return r.ReferrerPaidAt != nil && r.RefereePaidAt != nil
+}
+
+// SignupSignals is where and how a user signed up, compared across a referrer's referrals
+type SignupSignals struct {
+	UserID    uuid.UUID `json:"user_id"`
+	IP        string    `json:"ip,omitempty"`
+	DeviceID  string    `json:"device_id,omitempty"` // Sent by the client, so it can be left out or changed
+	UserAgent string    `json:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SharesIP reports whether both signups came from the same known IP
+func (s SignupSignals) SharesIP(other SignupSignals) bool {
+	return s.IP != "" && s.IP == other.IP
+}
+
+// SharesDevice reports whether both signups came from the same known device: the same device ID,
+// or the same user agent from the same network, which still matches when the device ID is dropped
+func (s SignupSignals) SharesDevice(other SignupSignals) bool {
+	if s.DeviceID != "" && s.DeviceID == other.DeviceID {
+		return true
+	}
+	network := signupNetwork(s.IP)
+	return s.UserAgent != "" && s.UserAgent == other.UserAgent && network != "" && network == signupNetwork(other.IP)
+}
+
+// signupNetwork is the /24 (IPv4) or /64 (IPv6) an address belongs to, or "" if it isn't an IP
+func signupNetwork(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(64, 128)).String()
+}
+
+// ReferralRewardTier is the referrer reward from a number of completed referrals up
+type ReferralRewardTier struct {
+	MinCompleted int     `json:"min_completed"`
+	Reward       float64 `json:"reward"`
+}
+
+// DefaultReferralRewardTiers pays power referrers more per referral
+var DefaultReferralRewardTiers = []ReferralRewardTier{
+	{MinCompleted: 0, Reward: 5.00},
+	{MinCompleted: 5, Reward: 7.50},
+	{MinCompleted: 15, Reward: 10.00},
+}
+
+// ReferrerReward returns the reward for a referrer's next referral given how many they have
+// completed already. Tiers must be sorted by MinCompleted.
+func ReferrerReward(tiers []ReferralRewardTier, completed int) float64 {
+	reward := 0.0
+	for _, tier := range tiers {
+		if completed >= tier.MinCompleted {
+			reward = tier.Reward
+		}
+	}
+	return reward
+}
+
+// disposableEmailDomains are common throwaway inbox providers
+var disposableEmailDomains = map[string]bool{
+	"10minutemail.com":  true,
+	"discard.email":     true,
+	"dispostable.com":   true,
+	"fakeinbox.com":     true,
+	"getnada.com":       true,
+	"guerrillamail.com": true,
+	"maildrop.cc":       true,
+	"mailinator.com":    true,
+	"mintemail.com":     true,
+	"mohmal.com":        true,
+	"sharklasers.com":   true,
+	"temp-mail.org":     true,
+	"tempmail.com":      true,
+	"throwawaymail.com": true,
+	"trashmail.com":     true,
+	"yopmail.com":       true,
+}
+
+// IsDisposableEmail reports whether the email's domain, or a parent of it, is a known throwaway
+// provider or one of extra
+func IsDisposableEmail(email string, extra map[string]bool) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	host := email[at+1:]
+	for {
+		if disposableEmailDomains[host] || extra[host] {
+			return true
+		}
+		dot := strings.Index(host, ".")
+		if dot < 0 {
+			return false
+		}
+		host = host[dot+1:]
+	}
 }
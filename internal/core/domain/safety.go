@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SafetySignal is a sign that a user may have an eating disorder or be in medical danger
+type SafetySignal string
+
+const (
+	SafetySignalPurging            SafetySignal = "purging"             // Vomiting, laxatives or diuretics to lose weight
+	SafetySignalFainting           SafetySignal = "fainting"            // Fainting, dizziness, chest pain or palpitations
+	SafetySignalExtremeRestriction SafetySignal = "extreme_restriction" // Not eating for days, starving, body hatred
+	SafetySignalSelfHarm           SafetySignal = "self_harm"           // Thoughts of hurting themselves
+	SafetySignalLowBMI             SafetySignal = "low_bmi"             // BMI below VeryLowBMI from the profile
+	SafetySignalUnsafeAdvice       SafetySignal = "unsafe_advice"       // A Cortex reply encouraged something dangerous
+)
+
+// SafetySource is the feature whose text was screened
+type SafetySource string
+
+const (
+	SafetySourceChat    SafetySource = "chat"
+	SafetySourceThread  SafetySource = "thread"
+	SafetySourceInsight SafetySource = "insight"
+	SafetySourceCraving SafetySource = "craving"
+	SafetySourceSOS     SafetySource = "sos"
+
+	SafetySourceWeeklyReport SafetySource = "weekly_report"
+	SafetySourceBreakFast    SafetySource = "break_fast"
+	SafetySourceDailyQuote   SafetySource = "daily_quote"
+	SafetySourceNudge        SafetySource = "nudge" // Messages the server sends on its own, like streak interventions
+)
+
+// SafetyStage is whether the user's input or Cortex's reply was flagged
+type SafetyStage string
+
+const (
+	SafetyStageInput SafetyStage = "input"
+	SafetyStageReply SafetyStage = "reply"
+)
+
+// VeryLowBMI is the BMI below which Cortex stops coaching the user to fast
+const VeryLowBMI = 17.5
+
+// BMI computes body mass index from the profile's height and weight, or 0 if either is unknown
+func BMI(heightCm, weightLbs float64) float64 {
+	if heightCm <= 0 || weightLbs <= 0 {
+		return 0
+	}
+	meters := heightCm / 100
+	return weightLbs * 0.45359237 / (meters * meters)
+}
+
+// SafetyAssessment is what screening found in one piece of text
+type SafetyAssessment struct {
+	Signals []SafetySignal `json:"signals,omitempty"`
+	BMI     float64        `json:"bmi,omitempty"` // Set when the profile has a height and weight
+}
+
+// Flagged reports whether anything was found
+func (a SafetyAssessment) Flagged() bool {
+	return len(a.Signals) > 0
+}
+
+// SafetyFlagStatus tracks a flag through human review
+type SafetyFlagStatus string
+
+const (
+	SafetyFlagOpen     SafetyFlagStatus = "open"
+	SafetyFlagReviewed SafetyFlagStatus = "reviewed"
+)
+
+var (
+	ErrSafetyFlagNotFound = errors.New("safety flag not found")
+	ErrSafetyFlagReviewed = errors.New("safety flag was already reviewed")
+)
+
+// MaxSafetyExcerptLength bounds the text kept on a flag for reviewers
+const MaxSafetyExcerptLength = 500
+
+// SafetyFlag is a flagged message or reply waiting for a human to review it
+type SafetyFlag struct {
+	ID         uuid.UUID        `json:"id"`
+	UserID     uuid.UUID        `json:"user_id"`
+	Source     SafetySource     `json:"source"`
+	Stage      SafetyStage      `json:"stage"`
+	Signals    []SafetySignal   `json:"signals"`
+	BMI        float64          `json:"bmi,omitempty"`
+	Excerpt    string           `json:"excerpt"` // The flagged text, truncated to MaxSafetyExcerptLength
+	Status     SafetyFlagStatus `json:"status"`
+	ReviewedBy *uuid.UUID       `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time       `json:"reviewed_at,omitempty"`
+	ReviewNote string           `json:"review_note,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// SafetyFallbackReply answers a flagged user when Cortex can't produce a safe reply itself
+const SafetyFallbackReply = "It sounds like you're going through something difficult, and your health matters more than any fasting goal. " +
+	"Please stop fasting for now, have something to eat and drink some fluids. If you feel faint, have chest pain or are thinking " +
+	"about hurting yourself, contact emergency services right away. A doctor or an eating-disorder helpline can help you with the rest."
@@ -152,6 +152,7 @@ type TribePoolParticipant struct {
 	PoolID          uuid.UUID `json:"pool_id"`
 	UserID          uuid.UUID `json:"user_id"`
 	DepositAmount   float64   `json:"deposit_amount"`
+	DepositChargeID string    `json:"-"` // Payment charge the deposit was collected with; payouts refund against it
 	FastsCompleted  int       `json:"fasts_completed"`
 	FinalRank       int       `json:"final_rank"`
 	PayoutAmount    float64   `json:"payout_amount"`
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,3 +21,138 @@ type Subscription struct {
 	CreatedAt            time.Time          `json:"created_at"`
 	UpdatedAt            time.Time          `json:"updated_at"`
 }
+
+var ErrInvalidSubscriptionTransition = errors.New("invalid subscription status transition")
+
+// subscriptionTransitions lists the statuses each status may move to, following Stripe's
+// subscription lifecycle. Canceled and incomplete_expired are terminal: resubscribing
+// creates a new subscription.
+var subscriptionTransitions = map[SubscriptionStatus][]SubscriptionStatus{
+	SubStatusNone:       {SubStatusIncomplete, SubStatusTrialing, SubStatusActive},
+	SubStatusIncomplete: {SubStatusActive, SubStatusTrialing, SubStatusIncompleteExpired, SubStatusCanceled},
+	SubStatusTrialing:   {SubStatusActive, SubStatusPastDue, SubStatusUnpaid, SubStatusPaused, SubStatusCanceled},
+	SubStatusActive:     {SubStatusPastDue, SubStatusUnpaid, SubStatusPaused, SubStatusCanceled},
+	SubStatusPastDue:    {SubStatusActive, SubStatusUnpaid, SubStatusCanceled},
+	SubStatusUnpaid:     {SubStatusActive, SubStatusCanceled},
+	SubStatusPaused:     {SubStatusActive, SubStatusCanceled},
+}
+
+// GrantsAccess reports whether a subscription in this status unlocks its plan.
+// Past due keeps access while Stripe retries the payment.
+func (s SubscriptionStatus) GrantsAccess() bool {
+	switch s {
+	case SubStatusActive, SubStatusTrialing, SubStatusPastDue:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether a subscription may move from s to next.
+// Staying in the same status is always allowed (period renewals, plan changes).
+func (s SubscriptionStatus) CanTransitionTo(next SubscriptionStatus) bool {
+	if s == next {
+		return true
+	}
+	for _, allowed := range subscriptionTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TierForStatus is the tier a user on plan has while their subscription is in status
+func TierForStatus(plan SubscriptionTier, status SubscriptionStatus) SubscriptionTier {
+	if status.GrantsAccess() && plan.IsValid() {
+		return plan
+	}
+	return TierFree
+}
+
+// Tier returns the plan the subscription is for. Subscriptions created before
+// plans were tracked are vault subscriptions.
+func (s *Subscription) Tier() SubscriptionTier {
+	tier := SubscriptionTier(s.PlanType)
+	if tier == TierFree || !tier.IsValid() {
+		return TierVault
+	}
+	return tier
+}
+
+// Transition moves the subscription to next, rejecting moves the lifecycle doesn't allow
+func (s *Subscription) Transition(next SubscriptionStatus) error {
+	if !s.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidSubscriptionTransition, s.Status, next)
+	}
+	s.Status = next
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// ApplySubscription derives the user's tier and status from their subscription.
+// A subscription that isn't the user's current one can only grant access, never revoke it,
+// so a late event for an old subscription doesn't downgrade a resubscribed user.
+// It returns false if the user was left unchanged.
+func (u *User) ApplySubscription(sub *Subscription) bool {
+	current := u.SubscriptionID == "" || u.SubscriptionID == sub.ID.String()
+	if !current && !sub.Status.GrantsAccess() {
+		return false
+	}
+	u.SubscriptionID = sub.ID.String()
+	u.SubscriptionStatus = sub.Status
+	u.SubscriptionTier = TierForStatus(sub.Tier(), sub.Status)
+	return true
+}
+
+var (
+	ErrPlanNotAvailable  = errors.New("plan is not available for purchase")
+	ErrAlreadySubscribed = errors.New("already subscribed to this plan")
+)
+
+// PriceCatalog maps each purchasable plan to its payment provider price ID
+type PriceCatalog map[SubscriptionTier]string
+
+// PriceID returns the price for the plan, or ErrPlanNotAvailable if it isn't configured
+func (c PriceCatalog) PriceID(tier SubscriptionTier) (string, error) {
+	priceID := c[tier]
+	if tier == TierFree || !tier.IsValid() || priceID == "" {
+		return "", ErrPlanNotAvailable
+	}
+	return priceID, nil
+}
+
+// Plans returns the purchasable plans in upgrade order
+func (c PriceCatalog) Plans() []SubscriptionTier {
+	var plans []SubscriptionTier
+	for _, tier := range planOrder {
+		if _, err := c.PriceID(tier); err == nil {
+			plans = append(plans, tier)
+		}
+	}
+	return plans
+}
+
+// CheckoutSessionRequest describes a hosted checkout for a subscription
+type CheckoutSessionRequest struct {
+	CustomerID        string
+	PriceID           string
+	ClientReferenceID string // Our user ID, echoed back in checkout.session.completed
+	Metadata          map[string]string
+	SuccessURL        string
+	CancelURL         string
+	TrialEnd          *time.Time // Billing starts at TrialEnd instead of at checkout
+	CouponID          string     // Discount applied to the subscription, from a promo code
+}
+
+// CheckoutSession is a hosted checkout page the user is redirected to
+type CheckoutSession struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// WebhookEvent records a payment provider event that has been processed, so retries are no-ops
+type WebhookEvent struct {
+	ID          string    `json:"id"` // Provider event ID, e.g. evt_...
+	Type        string    `json:"type"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TrialStatus string
+
+const (
+	TrialStatusActive    TrialStatus = "active"
+	TrialStatusConverted TrialStatus = "converted" // The user subscribed through the payment gateway
+	TrialStatusExpired   TrialStatus = "expired"   // Ended without a subscription; the user is back on TierFree
+)
+
+type TrialSource string
+
+const (
+	TrialSourceRegistration TrialSource = "registration"
+	TrialSourceCampaign     TrialSource = "campaign"
+)
+
+var (
+	ErrTrialAlreadyUsed      = errors.New("a free trial has already been used")
+	ErrTrialNotAvailable     = errors.New("free trial is not available")
+	ErrUnknownTrialCampaign  = errors.New("unknown trial campaign")
+	ErrPaymentMethodRequired = errors.New("this trial requires a payment method")
+	ErrTrialNotFound         = errors.New("no free trial found")
+)
+
+// TrialOffer is what a trial grants: a plan's capabilities for a number of days
+type TrialOffer struct {
+	Plan SubscriptionTier `json:"plan"`
+	Days int              `json:"days"`
+	// RequirePaymentMethod makes the user present a card, so the trial is also limited per card
+	RequirePaymentMethod bool `json:"require_payment_method"`
+}
+
+// IsValid reports whether the offer grants a paid plan for a positive number of days
+func (o TrialOffer) IsValid() bool {
+	return o.Days > 0 && o.Plan.IsValid() && o.Plan != TierFree
+}
+
+// Trial is a time-limited grant of a paid plan without a subscription. Each normalized email
+// and each card fingerprint can start one trial.
+type Trial struct {
+	ID                 uuid.UUID        `json:"id"`
+	UserID             uuid.UUID        `json:"user_id"`
+	Plan               SubscriptionTier `json:"plan"`
+	Source             TrialSource      `json:"source"`
+	Campaign           string           `json:"campaign,omitempty"`
+	EmailKey           string           `json:"-"` // NormalizeTrialEmail of the user's email
+	PaymentFingerprint string           `json:"-"` // Card fingerprint from the payment gateway, if a card was given
+	Status             TrialStatus      `json:"status"`
+	StartedAt          time.Time        `json:"started_at"`
+	EndsAt             time.Time        `json:"ends_at"`
+	ReminderSentAt     *time.Time       `json:"reminder_sent_at,omitempty"`
+	EndedAt            *time.Time       `json:"ended_at,omitempty"`
+	CreatedAt          time.Time        `json:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at"`
+}
+
+// NormalizeTrialEmail returns the key an email is limited by: lowercased, without a +tag, and
+// for Gmail without dots, so aliases of one mailbox count as the same email.
+func NormalizeTrialEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return email
+	}
+	local, host := email[:at], email[at+1:]
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+	if host == "googlemail.com" {
+		host = "gmail.com"
+	}
+	if host == "gmail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	return local + "@" + host
+}
+
+// OnInAppTrial reports whether the user's plan comes from a trial rather than a subscription
+func (u *User) OnInAppTrial() bool {
+	return u.SubscriptionID == "" && u.SubscriptionStatus == SubStatusTrialing && u.TrialEndsAt != nil
+}
+
+// trialExpired reports whether an in-app trial has run out but hasn't been downgraded yet
+func (u *User) trialExpired(now time.Time) bool {
+	return u.OnInAppTrial() && !now.Before(*u.TrialEndsAt)
+}
+
+// StartTrial puts the user on the trial's plan until it ends
+func (u *User) StartTrial(trial *Trial) {
+	endsAt := trial.EndsAt
+	u.SubscriptionTier = trial.Plan
+	u.SubscriptionStatus = SubStatusTrialing
+	u.TrialEndsAt = &endsAt
+}
+
+// EndTrial moves a user whose trial ran out without subscribing back to TierFree.
+// TrialEndsAt is kept as a record of the trial.
+func (u *User) EndTrial() {
+	if !u.OnInAppTrial() {
+		return
+	}
+	u.SubscriptionTier = TierFree
+	u.SubscriptionStatus = SubStatusNone
+}
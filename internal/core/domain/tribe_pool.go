@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Tribe pool statuses. A pool is open for joining until its month starts, active during
+// the month and completed once ranked and paid out.
+const (
+	TribePoolStatusOpen      = "open"
+	TribePoolStatusActive    = "active"
+	TribePoolStatusCompleted = "completed"
+)
+
+// TribePoolDeposit is what each participant puts into the monthly pot
+const TribePoolDeposit = 20.00
+
+// TribePoolPayoutSplit is the share of the pot paid to first, second and third place
+var TribePoolPayoutSplit = []float64{0.50, 0.30, 0.20}
+
+var (
+	ErrTribePoolNotFound     = errors.New("tribe pool not found")
+	ErrTribePoolExists       = errors.New("a pool for this month already exists")
+	ErrTribePoolNotOpen      = errors.New("tribe pool is not open for joining")
+	ErrTribePoolNotEnded     = errors.New("tribe pool month has not ended")
+	ErrAlreadyInTribePool    = errors.New("already joined this pool")
+	ErrNotTribeMember        = errors.New("not a member of this tribe")
+	ErrTribePoolNotPermitted = errors.New("only the tribe creator or a moderator can open a pool")
+)
+
+// PoolMonth returns the first instant (UTC) of the month containing t
+func PoolMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// NextMonthStart is when the pool's month is over
+func (p *TribePool) NextMonthStart() time.Time {
+	return p.MonthStart.AddDate(0, 1, 0)
+}
+
+// AcceptsParticipants reports whether members can still join. Joins close when the month starts,
+// even if the daily job hasn't activated the pool yet, so nobody joins with the standings known.
+func (p *TribePool) AcceptsParticipants(now time.Time) bool {
+	return p.Status == TribePoolStatusOpen && now.Before(p.MonthStart)
+}
+
+// HasEnded reports whether the pool's month is over and it can be settled
+func (p *TribePool) HasEnded(now time.Time) bool {
+	return !now.Before(p.NextMonthStart())
+}
+
+// CountingStart is when the participant's fasts start to count: the later of the month start and joining
+func (pp *TribePoolParticipant) CountingStart(pool *TribePool) time.Time {
+	if pp.CreatedAt.After(pool.MonthStart) {
+		return pp.CreatedAt
+	}
+	return pool.MonthStart
+}
+
+// CountPoolFasts counts fasts that met their goal and ended in [from, to)
+func CountPoolFasts(sessions []FastingSession, from, to time.Time) int {
+	count := 0
+	for _, s := range sessions {
+		if !s.MetGoal() {
+			continue
+		}
+		if s.EndTime.Before(from) || !s.EndTime.Before(to) {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// RankTribePool ranks participants by fasts completed and sets their FinalRank and PayoutAmount,
+// and the pool's place payouts. Only participants with at least one fast can win. Tied participants
+// share the rank and split the payouts of the places they cover. With fewer than three winners the
+// split is scaled up so the whole pot is paid out; with none, every deposit is returned.
+func RankTribePool(pool *TribePool, participants []*TribePoolParticipant) {
+	sort.SliceStable(participants, func(i, j int) bool {
+		if participants[i].FastsCompleted != participants[j].FastsCompleted {
+			return participants[i].FastsCompleted > participants[j].FastsCompleted
+		}
+		return participants[i].CreatedAt.Before(participants[j].CreatedAt)
+	})
+
+	for i, p := range participants {
+		p.PayoutAmount = 0
+		if i > 0 && p.FastsCompleted == participants[i-1].FastsCompleted {
+			p.FinalRank = participants[i-1].FinalRank
+		} else {
+			p.FinalRank = i + 1
+		}
+	}
+
+	eligible := 0
+	for _, p := range participants {
+		if p.FastsCompleted > 0 {
+			eligible++
+		}
+	}
+	pool.FirstPlacePayout, pool.SecondPlacePayout, pool.ThirdPlacePayout = 0, 0, 0
+	if eligible == 0 {
+		for _, p := range participants {
+			p.PayoutAmount = p.DepositAmount
+		}
+		return
+	}
+
+	places := placePayoutCents(toCents(pool.TotalPot), eligible)
+	placeAmounts := []*float64{&pool.FirstPlacePayout, &pool.SecondPlacePayout, &pool.ThirdPlacePayout}
+	for i, cents := range places {
+		*placeAmounts[i] = fromCents(cents)
+	}
+
+	// Tied groups split the places they cover; leftover cents go to whoever joined first
+	for start := 0; start < len(places); {
+		end := start + 1
+		for end < eligible && participants[end].FastsCompleted == participants[start].FastsCompleted {
+			end++
+		}
+		var groupCents int64
+		for place := start; place < end && place < len(places); place++ {
+			groupCents += places[place]
+		}
+		size := int64(end - start)
+		for i := start; i < end; i++ {
+			share := groupCents / size
+			if int64(i-start) < groupCents%size {
+				share++
+			}
+			participants[i].PayoutAmount = fromCents(share)
+		}
+		start = end
+	}
+}
+
+// placePayoutCents splits the pot between the paid places, scaling the split when there are
+// fewer winners than places. Rounding leftovers go to first place.
+func placePayoutCents(potCents int64, winners int) []int64 {
+	places := len(TribePoolPayoutSplit)
+	if winners < places {
+		places = winners
+	}
+	var weight float64
+	for _, share := range TribePoolPayoutSplit[:places] {
+		weight += share
+	}
+
+	payouts := make([]int64, places)
+	var paid int64
+	for i, share := range TribePoolPayoutSplit[:places] {
+		payouts[i] = int64(math.Floor(float64(potCents)*share/weight + 1e-6)) // Guard against 0.3*x landing just below a cent
+		paid += payouts[i]
+	}
+	payouts[0] += potCents - paid
+	return payouts
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// TribePoolStandings is a pool with its participants in rank order. Before the pool is settled
+// the fast counts and ranks are live; payouts are only set once it completes.
+type TribePoolStandings struct {
+	Pool         *TribePool              `json:"pool"`
+	Participants []*TribePoolParticipant `json:"participants"`
+}
@@ -1,25 +1,35 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrUserNotFound is returned by user lookups that match no user
+var ErrUserNotFound = errors.New("user not found")
+
 type SubscriptionTier string
 type SubscriptionStatus string
 
 const (
-	TierFree  SubscriptionTier = "free"
-	TierVault SubscriptionTier = "vault"
+	TierFree               SubscriptionTier = "free"
+	TierVault              SubscriptionTier = "vault"
+	TierAccountabilityPlus SubscriptionTier = "accountability_plus"
+	TierAICoach            SubscriptionTier = "ai_coach"
 )
 
 const (
-	SubStatusActive   SubscriptionStatus = "active"
-	SubStatusCanceled SubscriptionStatus = "canceled"
-	SubStatusPastDue  SubscriptionStatus = "past_due"
-	SubStatusUnpaid   SubscriptionStatus = "unpaid"
-	SubStatusNone     SubscriptionStatus = ""
+	SubStatusActive            SubscriptionStatus = "active"
+	SubStatusTrialing          SubscriptionStatus = "trialing"
+	SubStatusIncomplete        SubscriptionStatus = "incomplete"
+	SubStatusIncompleteExpired SubscriptionStatus = "incomplete_expired"
+	SubStatusCanceled          SubscriptionStatus = "canceled"
+	SubStatusPastDue           SubscriptionStatus = "past_due"
+	SubStatusUnpaid            SubscriptionStatus = "unpaid"
+	SubStatusPaused            SubscriptionStatus = "paused"
+	SubStatusNone              SubscriptionStatus = ""
 )
 
 type User struct {
@@ -58,8 +68,9 @@ type User struct {
 	UpdatedAt                time.Time          `json:"updated_at"`
 }
 
+// IsVaultMember reports whether the user has an active plan that includes the vault
 func (u *User) IsVaultMember() bool {
-	return u.SubscriptionTier == TierVault && u.SubscriptionStatus == SubStatusActive
+	return u.HasCapability(CapabilityVault)
 }
 
 type UserProfileUpdate struct {
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +13,7 @@ type VaultParticipation struct {
 	MonthStart      time.Time  `json:"month_start"`
 	MonthEnd        time.Time  `json:"month_end"`
 	DepositAmount   float64    `json:"deposit_amount"`
+	DepositChargeID string     `json:"deposit_charge_id,omitempty"` // Payment charge the deposit was paid with; the month's refund goes against it
 	FastsCompleted  int        `json:"fasts_completed"`
 	AmountRecovered float64    `json:"amount_recovered"`
 	RefundProcessed bool       `json:"refund_processed"`
@@ -21,3 +23,64 @@ type VaultParticipation struct {
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 }
+
+// ErrStakeNotCharged means the stake for a challenge or tribe pool couldn't be charged to the
+// user's card, so they weren't added
+var ErrStakeNotCharged = errors.New("could not charge the stake to your card")
+
+// StakePayout is what a settled friend challenge or tribe pool owes one participant. Up to the
+// stake is refunded against the charge that collected it; anything won on top is credited to
+// the vault's earned balance.
+type StakePayout struct {
+	SourceType string  // LedgerSourceChallenge or LedgerSourceTribePool
+	SourceID   string  // The participant record; each one is paid once
+	ChargeID   string  // Charge the stake was collected with
+	Stake      float64 // What the participant put in
+	Amount     float64 // What they are owed
+	Reason     string
+}
+
+// VaultDailySettlement marks that a user's vault earnings for a calendar day
+// have been settled. There is at most one per user per day, which is what makes
+// the daily job safe to re-run.
+type VaultDailySettlement struct {
+	ID             uuid.UUID `json:"id"`
+	UserID         uuid.UUID `json:"user_id"`
+	SettlementDate time.Time `json:"settlement_date"` // Midnight UTC of the settled day
+	Amount         float64   `json:"amount"`
+	RuleVersion    int       `json:"rule_version"` // Earning rule set version the day was evaluated with
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// VaultRefundStatus is the outcome of closing one participation in the monthly refund run
+type VaultRefundStatus string
+
+const (
+	VaultRefundPlanned  VaultRefundStatus = "planned"  // Dry run: nothing was changed
+	VaultRefundRefunded VaultRefundStatus = "refunded" // Refund issued and participation closed
+	VaultRefundClosed   VaultRefundStatus = "closed"   // Nothing to refund; participation closed
+	VaultRefundFailed   VaultRefundStatus = "failed"   // Left open so the next run retries it
+)
+
+// VaultRefundLine is one participation in a monthly refund report
+type VaultRefundLine struct {
+	ParticipationID uuid.UUID         `json:"participation_id"`
+	UserID          uuid.UUID         `json:"user_id"`
+	Email           string            `json:"email"`
+	Deposit         float64           `json:"deposit"`
+	Earned          float64           `json:"earned"`
+	Refund          float64           `json:"refund"`
+	Forfeit         float64           `json:"forfeit"`
+	RefundID        string            `json:"refund_id,omitempty"`
+	Status          VaultRefundStatus `json:"status"`
+	Error           string            `json:"error,omitempty"`
+}
+
+// VaultRefundReport is the result of closing a vault month
+type VaultRefundReport struct {
+	MonthStart   time.Time         `json:"month_start"`
+	DryRun       bool              `json:"dry_run"`
+	Lines        []VaultRefundLine `json:"lines"`
+	TotalRefund  float64           `json:"total_refund"`
+	TotalForfeit float64           `json:"total_forfeit"`
+}
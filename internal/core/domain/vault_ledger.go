@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LedgerAccount is one side of a vault ledger entry. Every entry moves money
+// from one account to another, so the sum of all account balances is always zero.
+type LedgerAccount string
+
+const (
+	LedgerAccountExternal LedgerAccount = "external" // Money outside the vault (the user's card)
+	LedgerAccountHeld     LedgerAccount = "held"     // Deposit held in the vault, not yet earned back
+	LedgerAccountEarned   LedgerAccount = "earned"   // Earned back, refundable at the end of the month
+	LedgerAccountRewards  LedgerAccount = "rewards"  // Platform-funded rewards and bonuses
+	LedgerAccountForfeit  LedgerAccount = "forfeit"  // Unearned deposit kept by the platform
+	LedgerAccountStakes   LedgerAccount = "stakes"   // Other members' stakes won in challenges and tribe pools
+)
+
+type LedgerEntryType string
+
+const (
+	LedgerEntryDeposit        LedgerEntryType = "deposit"
+	LedgerEntryDailyEarning   LedgerEntryType = "daily_earning"
+	LedgerEntryReferralReward LedgerEntryType = "referral_reward"
+	LedgerEntryStreakBonus    LedgerEntryType = "streak_bonus"
+	LedgerEntryStakeWinnings  LedgerEntryType = "stake_winnings"
+	LedgerEntryForfeit        LedgerEntryType = "forfeit"
+	LedgerEntryRefund         LedgerEntryType = "refund"
+)
+
+// Source types referenced by ledger entries
+const (
+	LedgerSourceParticipation  = "vault_participation"
+	LedgerSourceFastingSession = "fasting_session"
+	LedgerSourceMeal           = "meal"
+	LedgerSourceSteps          = "steps"
+	LedgerSourceReferral       = "referral"
+	LedgerSourceStreak         = "streak"
+	LedgerSourcePayment        = "payment"
+	LedgerSourceChallenge      = "challenge_participant"
+	LedgerSourceTribePool      = "tribe_pool_participant"
+)
+
+// ledgerPostings maps each entry type to the accounts it moves money between
+var ledgerPostings = map[LedgerEntryType][2]LedgerAccount{
+	LedgerEntryDeposit:        {LedgerAccountExternal, LedgerAccountHeld},
+	LedgerEntryDailyEarning:   {LedgerAccountHeld, LedgerAccountEarned},
+	LedgerEntryReferralReward: {LedgerAccountRewards, LedgerAccountEarned},
+	LedgerEntryStreakBonus:    {LedgerAccountRewards, LedgerAccountEarned},
+	LedgerEntryStakeWinnings:  {LedgerAccountStakes, LedgerAccountEarned},
+	LedgerEntryForfeit:        {LedgerAccountHeld, LedgerAccountForfeit},
+	LedgerEntryRefund:         {LedgerAccountEarned, LedgerAccountExternal},
+}
+
+var (
+	ErrInvalidLedgerEntryType = errors.New("invalid ledger entry type")
+	ErrInvalidLedgerAmount    = errors.New("ledger amount must be positive")
+)
+
+// VaultLedgerEntry is a single append-only movement of money in a user's vault.
+// Entries are never updated or deleted; corrections are made with new entries.
+type VaultLedgerEntry struct {
+	ID          uuid.UUID       `json:"id"`
+	UserID      uuid.UUID       `json:"user_id"`
+	Type        LedgerEntryType `json:"type"`
+	FromAccount LedgerAccount   `json:"from_account"`
+	ToAccount   LedgerAccount   `json:"to_account"`
+	Amount      float64         `json:"amount"`
+	Reason      string          `json:"reason"`
+	SourceType  string          `json:"source_type"`
+	SourceID    string          `json:"source_id"`
+	EffectiveAt time.Time       `json:"effective_at"` // Business date the entry belongs to
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// NewVaultLedgerEntry builds an entry of the given type with its accounts filled in
+func NewVaultLedgerEntry(userID uuid.UUID, entryType LedgerEntryType, amount float64, reason, sourceType, sourceID string, effectiveAt time.Time) (*VaultLedgerEntry, error) {
+	accounts, ok := ledgerPostings[entryType]
+	if !ok {
+		return nil, ErrInvalidLedgerEntryType
+	}
+	if amount <= 0 {
+		return nil, ErrInvalidLedgerAmount
+	}
+	return &VaultLedgerEntry{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Type:        entryType,
+		FromAccount: accounts[0],
+		ToAccount:   accounts[1],
+		Amount:      amount,
+		Reason:      reason,
+		SourceType:  sourceType,
+		SourceID:    sourceID,
+		EffectiveAt: effectiveAt,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// VaultBalance summarises a ledger. All figures are derived from entries, never stored.
+type VaultBalance struct {
+	Deposited float64 `json:"deposited"` // Total deposited into the vault
+	Held      float64 `json:"held"`      // Deposit not yet earned back or forfeited
+	Earned    float64 `json:"earned"`    // Earned back and not yet refunded
+	Forfeited float64 `json:"forfeited"` // Total forfeited to the platform
+	Refunded  float64 `json:"refunded"`  // Total refunded to the user
+}
+
+// VaultLedger is a user's ledger entries in the order they were recorded
+type VaultLedger struct {
+	UserID  uuid.UUID          `json:"user_id"`
+	Entries []VaultLedgerEntry `json:"entries"`
+}
+
+// Balance returns the balance of a single account
+func (l *VaultLedger) Balance(account LedgerAccount) float64 {
+	var balance float64
+	for _, e := range l.Entries {
+		if e.ToAccount == account {
+			balance += e.Amount
+		}
+		if e.FromAccount == account {
+			balance -= e.Amount
+		}
+	}
+	return balance
+}
+
+// Summary derives the user's vault balances from the ledger
+func (l *VaultLedger) Summary() VaultBalance {
+	return VaultBalance{
+		Deposited: l.Total(LedgerEntryDeposit),
+		Held:      l.Balance(LedgerAccountHeld),
+		Earned:    l.Balance(LedgerAccountEarned),
+		Forfeited: l.Balance(LedgerAccountForfeit),
+		Refunded:  l.Total(LedgerEntryRefund),
+	}
+}
+
+// Total returns the sum of all entries of the given types
+func (l *VaultLedger) Total(types ...LedgerEntryType) float64 {
+	var total float64
+	for _, e := range l.Entries {
+		for _, t := range types {
+			if e.Type == t {
+				total += e.Amount
+				break
+			}
+		}
+	}
+	return total
+}
+
+// Between returns the entries effective in [from, to)
+func (l *VaultLedger) Between(from, to time.Time) *VaultLedger {
+	out := &VaultLedger{UserID: l.UserID, Entries: []VaultLedgerEntry{}}
+	for _, e := range l.Entries {
+		if !e.EffectiveAt.Before(from) && e.EffectiveAt.Before(to) {
+			out.Entries = append(out.Entries, e)
+		}
+	}
+	return out
+}
+
+// Before returns the entries effective strictly before t
+func (l *VaultLedger) Before(t time.Time) *VaultLedger {
+	out := &VaultLedger{UserID: l.UserID, Entries: []VaultLedgerEntry{}}
+	for _, e := range l.Entries {
+		if e.EffectiveAt.Before(t) {
+			out.Entries = append(out.Entries, e)
+		}
+	}
+	return out
+}
+
+// VaultStatement is a user's ledger activity for a period with opening and closing balances
+type VaultStatement struct {
+	UserID         uuid.UUID          `json:"user_id"`
+	PeriodStart    time.Time          `json:"period_start"`
+	PeriodEnd      time.Time          `json:"period_end"`
+	OpeningBalance VaultBalance       `json:"opening_balance"`
+	ClosingBalance VaultBalance       `json:"closing_balance"`
+	Entries        []VaultLedgerEntry `json:"entries"`
+}
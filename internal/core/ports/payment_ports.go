@@ -1,9 +1,28 @@
 package ports
 
+import "fastinghero/internal/core/domain"
+
 // PaymentGateway defines the interface for payment processing
 type PaymentGateway interface {
 	CreateCustomer(email, name string) (string, error)
-	CreateSubscription(customerID, priceID string) (string, error)
+	// CreateCheckoutSession starts a hosted checkout. The subscription only becomes active
+	// once the provider confirms payment with a webhook.
+	CreateCheckoutSession(req domain.CheckoutSessionRequest) (*domain.CheckoutSession, error)
+	// CreatePortalSession returns the URL of the hosted billing portal where the customer
+	// can manage payment methods and cancel
+	CreatePortalSession(customerID, returnURL string) (string, error)
 	CreatePayout(amount float64, currency, destination string) (string, error)
 	ConstructEvent(payload []byte, header string) (interface{}, error)
+	// CreateRefund refunds amount against a charge, capped at what is still refundable on it,
+	// and returns the refund ID and the amount refunded. Retrying with the same idempotency key
+	// never refunds twice.
+	CreateRefund(chargeID string, amount float64, idempotencyKey string) (string, float64, error)
+	// ChargeCustomer charges the customer's default payment method without them present and
+	// returns the charge ID. Retrying with the same idempotency key never charges twice.
+	ChargeCustomer(customerID string, amount float64, description, idempotencyKey string) (string, error)
+	// PaymentMethodFingerprint identifies the card behind a payment method. The same card
+	// has the same fingerprint whichever customer adds it.
+	PaymentMethodFingerprint(paymentMethodID string) (string, error)
+	// CreateCoupon creates the discount a promo code applies at checkout and returns its ID
+	CreateCoupon(req domain.CouponRequest) (string, error)
 }
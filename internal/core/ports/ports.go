@@ -28,6 +28,12 @@ type FastingService interface {
 	GetFastingHistory(ctx context.Context, userID uuid.UUID) ([]domain.FastingSession, error)
 }
 
+// EntitlementService decides which premium capabilities a user can access
+type EntitlementService interface {
+	GetEntitlements(ctx context.Context, userID uuid.UUID) (*domain.Entitlements, error)
+	Require(ctx context.Context, userID uuid.UUID, capability domain.Capability) error
+}
+
 type KetoService interface {
 	LogEntry(ctx context.Context, userID uuid.UUID, entry domain.KetoEntry) error
 }
@@ -36,25 +42,175 @@ type VaultService interface {
 	CalculateVaultStatus(user *domain.User) (deposit float64, earned float64, potentialRefund float64)
 	CalculateDailyEarning(disciplineIndex int) float64
 	ProcessDailyEarnings(ctx context.Context) error
-	AddDailyEarnings(ctx context.Context, user *domain.User, amount float64)
+	ProcessMonthlyRefunds(ctx context.Context) error
+	AddReferralReward(ctx context.Context, user *domain.User, referralID uuid.UUID, amount float64) error
+	RecordDeposit(ctx context.Context, user *domain.User, amount float64, chargeID string, paidAt time.Time) error
+	// CollectStake charges a friend challenge or tribe pool stake to the user's card and returns the charge ID
+	CollectStake(ctx context.Context, userID uuid.UUID, amount float64, description, idempotencyKey string) (string, error)
+	// PayOutStake pays what a settled stake owes the user. Paying the same payout again changes nothing.
+	PayOutStake(ctx context.Context, userID uuid.UUID, payout domain.StakePayout) error
 	CalculatePrice(ctx context.Context, user *domain.User) float64
-	UpdateDisciplineIndex(ctx context.Context, user *domain.User, completedFast bool, verifiedKetosis bool)
 	GetCurrentParticipation(ctx context.Context, userID uuid.UUID) (*domain.VaultParticipation, error)
+	GetLedger(ctx context.Context, userID uuid.UUID) (*domain.VaultLedger, error)
+	GetStatement(ctx context.Context, userID uuid.UUID, from, to time.Time) (*domain.VaultStatement, error)
+}
+
+// DisciplineService scores the Discipline Index and keeps the history behind it
+// CortexThreadService keeps conversations with the Cortex coach
+type CortexThreadService interface {
+	// SendMessage adds message to the thread, or to a new one when threadID is nil, and returns the reply
+	SendMessage(ctx context.Context, userID uuid.UUID, threadID *uuid.UUID, message string) (*domain.CortexReply, error)
+	// StreamMessage is SendMessage calling onDelta with the reply as it is generated
+	StreamMessage(ctx context.Context, userID uuid.UUID, threadID *uuid.UUID, message string, onDelta func(delta string) error) (*domain.CortexReply, error)
+	ListThreads(ctx context.Context, userID uuid.UUID) ([]domain.CortexThread, error)
+	GetThread(ctx context.Context, userID, threadID uuid.UUID) (*domain.CortexThreadView, error)
+	DeleteThread(ctx context.Context, userID, threadID uuid.UUID) error
+}
+
+type CortexUsageService interface {
+	// GetUsage returns what the user has spent on Cortex today against their plan's budget
+	GetUsage(ctx context.Context, userID uuid.UUID) (*domain.CortexUsageView, error)
+	// CacheStats returns response cache hits and misses per feature since startup
+	CacheStats() []domain.CortexCacheStats
+}
+
+// PromptExperimentService reports on the prompt templates and their A/B variants
+type PromptExperimentService interface {
+	ListPrompts() []domain.PromptTemplate
+	// PromptExperimentReport compares each variant by the fasts its users finished after first seeing it
+	PromptExperimentReport(ctx context.Context) ([]domain.PromptVariantStats, error)
+}
+
+// SafetyReviewService lets admins review what the Cortex safety guard flagged
+type SafetyReviewService interface {
+	ListSafetyFlags(ctx context.Context, status domain.SafetyFlagStatus) ([]domain.SafetyFlag, error)
+	ReviewSafetyFlag(ctx context.Context, flagID, reviewerID uuid.UUID, note string) (*domain.SafetyFlag, error)
+}
+
+// CortexActionService lets a user confirm or reject the actions Cortex proposed to them
+type CortexActionService interface {
+	ListActions(ctx context.Context, userID uuid.UUID) ([]domain.CortexAction, error)
+	ConfirmAction(ctx context.Context, userID, actionID uuid.UUID) (*domain.CortexAction, error)
+	RejectAction(ctx context.Context, userID, actionID uuid.UUID) (*domain.CortexAction, error)
+}
+
+type DisciplineService interface {
+	// RecordFast applies a finished fast to user.DisciplineIndex; the caller saves the user
+	RecordFast(ctx context.Context, user *domain.User, session *domain.FastingSession) (*domain.DisciplineEvent, error)
+	ProcessDay(ctx context.Context, day time.Time) (int, error)
+	GetTimeline(ctx context.Context, userID uuid.UUID, days int) (*domain.DisciplineTimeline, error)
+}
+
+// DisciplineRepository stores Discipline Index changes and end-of-day snapshots
+type DisciplineRepository interface {
+	// AppendEvent returns false if the user already has an event of the same type for the source
+	AppendEvent(ctx context.Context, event *domain.DisciplineEvent) (bool, error)
+	// ListEvents returns the user's events created at or after since, newest first
+	ListEvents(ctx context.Context, userID uuid.UUID, since time.Time) ([]domain.DisciplineEvent, error)
+	// LastEventAt returns when the user last had an event of the type, or nil if never
+	LastEventAt(ctx context.Context, userID uuid.UUID, eventType domain.DisciplineEventType) (*time.Time, error)
+	// SaveSnapshot returns false if the user already has a snapshot for the day
+	SaveSnapshot(ctx context.Context, snapshot *domain.DisciplineSnapshot) (bool, error)
+	// ListSnapshots returns the user's snapshots for days at or after from, oldest first
+	ListSnapshots(ctx context.Context, userID uuid.UUID, from time.Time) ([]domain.DisciplineSnapshot, error)
+}
+
+// CortexThreadRepository stores Cortex conversations
+type CortexThreadRepository interface {
+	CreateThread(ctx context.Context, thread *domain.CortexThread) error
+	// FindThread returns nil if the thread doesn't exist
+	FindThread(ctx context.Context, id uuid.UUID) (*domain.CortexThread, error)
+	// ListThreads returns the user's threads, most recently updated first
+	ListThreads(ctx context.Context, userID uuid.UUID) ([]domain.CortexThread, error)
+	// UpdateThread saves the title, summary, counters and updated_at
+	UpdateThread(ctx context.Context, thread *domain.CortexThread) error
+	// DeleteThread removes the thread and its messages
+	DeleteThread(ctx context.Context, id uuid.UUID) error
+	// AppendMessage returns false if the thread already has a message with the same Seq
+	AppendMessage(ctx context.Context, message *domain.CortexMessage) (bool, error)
+	// ListMessages returns the thread's messages with Seq greater than afterSeq, oldest first
+	ListMessages(ctx context.Context, threadID uuid.UUID, afterSeq int) ([]domain.CortexMessage, error)
+}
+
+// CortexUsageRepository accounts Cortex requests and tokens per user and UTC day
+type CortexUsageRepository interface {
+	// GetUsage returns zero usage if nothing was recorded for the day
+	GetUsage(ctx context.Context, userID uuid.UUID, day time.Time) (*domain.CortexUsage, error)
+	// AddUsage atomically adds to the day's counters
+	AddUsage(ctx context.Context, userID uuid.UUID, day time.Time, requests, tokens int) error
+}
+
+// CortexResponseCache keeps generated Cortex outputs that can be reused across requests
+type CortexResponseCache interface {
+	// Get returns false if the key is missing or has expired
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// KnowledgeBase retrieves passages from the curated fasting articles Cortex grounds answers in
+type KnowledgeBase interface {
+	// Search returns up to limit passages relevant to query, best first
+	Search(ctx context.Context, query string, limit int) ([]domain.KnowledgePassage, error)
+}
+
+// PromptExposureRepository records which prompt variant each Cortex response was generated from
+type PromptExposureRepository interface {
+	Record(ctx context.Context, exposure *domain.PromptExposure) error
+	// SummarizeExposures groups exposures by prompt, version, variant and user
+	SummarizeExposures(ctx context.Context) ([]domain.PromptExposureSummary, error)
+}
+
+// SafetyFlagRepository stores risk signals found in Cortex conversations for human review
+type SafetyFlagRepository interface {
+	Save(ctx context.Context, flag *domain.SafetyFlag) error
+	// FindByID returns nil if the flag doesn't exist
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.SafetyFlag, error)
+	// ListByStatus returns flags with the status, oldest first
+	ListByStatus(ctx context.Context, status domain.SafetyFlagStatus) ([]domain.SafetyFlag, error)
+	// HasOpenFlag reports whether the user has an open flag with the signal
+	HasOpenFlag(ctx context.Context, userID uuid.UUID, signal domain.SafetySignal) (bool, error)
+	// Update saves the status and review fields
+	Update(ctx context.Context, flag *domain.SafetyFlag) error
+}
+
+// CortexActionRepository stores the actions Cortex proposed, as the audit trail of what it did
+type CortexActionRepository interface {
+	Save(ctx context.Context, action *domain.CortexAction) error
+	// FindByID returns nil if the action doesn't exist
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.CortexAction, error)
+	// ListByUser returns the user's most recent actions, newest first
+	ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]domain.CortexAction, error)
+	// Update saves the status and outcome fields
+	Update(ctx context.Context, action *domain.CortexAction) error
+	// ClaimDecision moves a proposed action to status. It returns false without error if the
+	// action is no longer proposed, so of concurrent decisions on any instance only one wins.
+	ClaimDecision(ctx context.Context, id uuid.UUID, status domain.CortexActionStatus, decidedAt time.Time) (bool, error)
+}
+
+// EarningRulesEngine evaluates what a user earned on a day under the vault earning rules
+type EarningRulesEngine interface {
+	EvaluateDay(ctx context.Context, userID uuid.UUID, day time.Time) (*domain.DayEarnings, error)
 }
 
 type PaymentService interface {
 	CreateCustomer(ctx context.Context, user *domain.User) (string, error)
-	CreateSubscription(ctx context.Context, userID uuid.UUID, priceID string) (*domain.Subscription, error)
+	ListPlans() []domain.SubscriptionTier
+	CreateCheckoutSession(ctx context.Context, userID uuid.UUID, plan domain.SubscriptionTier, promoCode string) (*domain.CheckoutSession, error)
+	CreatePortalSession(ctx context.Context, userID uuid.UUID) (string, error)
 	HandleWebhook(ctx context.Context, payload []byte, signature string) error
 }
 
 // Secondary Ports (Repositories)
 
 type CortexService interface {
-	Chat(ctx context.Context, userID uuid.UUID, message string) (string, error)
-	GenerateInsight(ctx context.Context, userID uuid.UUID, fastingHours float64) (string, error)
+	Chat(ctx context.Context, userID uuid.UUID, message string) (*domain.CortexAnswer, error)
+	GenerateInsight(ctx context.Context, userID uuid.UUID, fastingHours float64) (*domain.CortexAnswer, error)
 	AnalyzeMeal(ctx context.Context, imageBase64, description string) (string, bool, bool, error)
 	GetCravingHelp(ctx context.Context, userID uuid.UUID, cravingDescription string) (interface{}, error)
+	AnalyzeWeek(ctx context.Context, userID uuid.UUID, week domain.WeekSummary) (*domain.WeeklyInsight, error)
+	// GenerateFromPrompt answers with the user's variant of a registered prompt template. It is for
+	// system-initiated messages and isn't charged to the user's daily budget.
+	GenerateFromPrompt(ctx context.Context, userID uuid.UUID, prompt string, vars domain.PromptVars) (string, error)
 }
 
 // Secondary Ports (Repositories & Adapters)
@@ -64,6 +220,18 @@ type UserRepository interface {
 	FindByEmail(ctx context.Context, email string) (*domain.User, error)
 	FindByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
 	FindByReferralCode(ctx context.Context, code string) (*domain.User, error)
+	// FindByStripeCustomerID returns nil if no user has the customer ID
+	FindByStripeCustomerID(ctx context.Context, customerID string) (*domain.User, error)
+	// ListVaultMembers returns up to limit active vault members with an ID greater than afterID,
+	// ordered by ID. Pass uuid.Nil to start from the beginning.
+	ListVaultMembers(ctx context.Context, afterID uuid.UUID, limit int) ([]*domain.User, error)
+	// ListWithDisciplineScore returns up to limit users with a Discipline Index above zero and an
+	// ID greater than afterID, ordered by ID
+	ListWithDisciplineScore(ctx context.Context, afterID uuid.UUID, limit int) ([]*domain.User, error)
+	// UpdateEarnedRefund sets only the user's earned refund, leaving the rest of the row as it is
+	UpdateEarnedRefund(ctx context.Context, userID uuid.UUID, earnedRefund float64) error
+	// UpdateDisciplineIndex sets only the user's Discipline Index, leaving the rest of the row as it is
+	UpdateDisciplineIndex(ctx context.Context, userID uuid.UUID, disciplineIndex float64) error
 }
 
 type FastingRepository interface {
@@ -81,6 +249,25 @@ type KetoRepository interface {
 type LLMProvider interface {
 	GenerateResponse(ctx context.Context, prompt string, systemPrompt string) (string, error)
 	AnalyzeImage(ctx context.Context, imageBase64, prompt string) (string, error)
+	// Converse answers the last message of a multi-turn conversation, oldest turn first
+	Converse(ctx context.Context, systemPrompt string, messages []domain.LLMMessage) (string, error)
+}
+
+// StreamingLLMProvider is an LLMProvider that can deliver a reply while it is generated
+type StreamingLLMProvider interface {
+	LLMProvider
+	// ConverseStream is Converse calling onDelta with each chunk of text as it arrives. On
+	// failure it returns the text assembled so far with the error; an error from onDelta or a
+	// cancelled ctx stops the stream.
+	ConverseStream(ctx context.Context, systemPrompt string, messages []domain.LLMMessage, onDelta func(delta string) error) (string, error)
+}
+
+// ToolCallingLLMProvider is an LLMProvider that supports function calling
+type ToolCallingLLMProvider interface {
+	LLMProvider
+	// ConverseWithTools is Converse with tools the model may ask to call. Calls are returned,
+	// never executed, by the provider.
+	ConverseWithTools(ctx context.Context, systemPrompt string, messages []domain.LLMMessage, tools []domain.LLMTool) (*domain.LLMToolReply, error)
 }
 
 type ActivityService interface {
@@ -137,9 +324,106 @@ type SubscriptionRepository interface {
 	FindByStripeSubscriptionID(ctx context.Context, stripeSubID string) (*domain.Subscription, error)
 }
 
+// WebhookEventRepository is the log of processed payment webhook events
+type WebhookEventRepository interface {
+	// IsProcessed reports whether the event was recorded as processed
+	IsProcessed(ctx context.Context, eventID string) (bool, error)
+	// MarkProcessed records the event as processed. Recording an event twice is a no-op.
+	MarkProcessed(ctx context.Context, event *domain.WebhookEvent) error
+}
+
+// TrialRepository stores free trials. Each user, normalized email and card fingerprint can hold one.
+type TrialRepository interface {
+	// Create stores the trial. It returns false if the user, email or card already had a trial.
+	Create(ctx context.Context, trial *domain.Trial) (bool, error)
+	Update(ctx context.Context, trial *domain.Trial) error
+	FindByUserID(ctx context.Context, userID uuid.UUID) (*domain.Trial, error)
+	// ListActiveEndingBefore returns active trials whose EndsAt is before the given time
+	ListActiveEndingBefore(ctx context.Context, before time.Time) ([]*domain.Trial, error)
+}
+
+// TrialService grants free trials of a paid plan and ends them
+type TrialService interface {
+	// StartTrial grants the registration offer, or the campaign's offer when a campaign is given
+	StartTrial(ctx context.Context, userID uuid.UUID, campaign, paymentMethodID string) (*domain.Trial, error)
+	GetTrial(ctx context.Context, userID uuid.UUID) (*domain.Trial, error)
+
+	// Scheduled: remind users before their trial ends, then convert or downgrade them
+	SendTrialReminders(ctx context.Context, now time.Time) (int, error)
+	EndExpiredTrials(ctx context.Context, now time.Time) (int, error)
+}
+
+// PromoCodeRepository stores promo codes and their redemptions. Codes are unique.
+type PromoCodeRepository interface {
+	// Create stores the code. It returns false if the code already exists.
+	Create(ctx context.Context, promo *domain.PromoCode) (bool, error)
+	Update(ctx context.Context, promo *domain.PromoCode) error
+	FindByCode(ctx context.Context, code string) (*domain.PromoCode, error)
+	List(ctx context.Context) ([]*domain.PromoCode, error)
+	// Redeem records the redemption and counts it against the code's usage limit. It returns
+	// false if the user already redeemed the code.
+	Redeem(ctx context.Context, redemption *domain.PromoRedemption) (bool, error)
+	FindRedemption(ctx context.Context, promoCodeID, userID uuid.UUID) (*domain.PromoRedemption, error)
+	// ListRedemptions returns the code's redemptions, newest first
+	ListRedemptions(ctx context.Context, promoCodeID uuid.UUID) ([]*domain.PromoRedemption, error)
+}
+
+// PromoCodeService manages subscription discounts and applies them at checkout
+type PromoCodeService interface {
+	// Admin
+	CreatePromoCode(ctx context.Context, adminID uuid.UUID, promo *domain.PromoCode) (*domain.PromoCode, error)
+	ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error)
+	GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error)
+	SetPromoCodeActive(ctx context.Context, code string, active bool) (*domain.PromoCode, error)
+	ListRedemptions(ctx context.Context, code string) ([]*domain.PromoRedemption, error)
+
+	// Checkout: CheckPromoCode before the session is created, RedeemPromoCode once it completes
+	CheckPromoCode(ctx context.Context, userID uuid.UUID, code string, plan domain.SubscriptionTier) (*domain.PromoCode, error)
+	RedeemPromoCode(ctx context.Context, redemption *domain.PromoRedemption) error
+}
+
 type VaultRepository interface {
 	Save(ctx context.Context, vault *domain.VaultParticipation) error
 	FindByUserIDAndMonth(ctx context.Context, userID uuid.UUID, monthStart time.Time) (*domain.VaultParticipation, error)
+	// ListOpenByMonth returns the month's participations whose refund has not been processed yet
+	ListOpenByMonth(ctx context.Context, monthStart time.Time) ([]*domain.VaultParticipation, error)
+	// ClaimDailySettlement records the settlement marker for a user and day. It returns false
+	// without error if that day has already been claimed.
+	ClaimDailySettlement(ctx context.Context, settlement *domain.VaultDailySettlement) (bool, error)
+	ReleaseDailySettlement(ctx context.Context, userID uuid.UUID, day time.Time) error
+}
+
+// VaultLedgerRepository stores append-only vault ledger entries
+type VaultLedgerRepository interface {
+	// Append records an entry. It returns false without error if an entry with the same user,
+	// type and source reference already exists, so posting the same event twice is harmless.
+	Append(ctx context.Context, entry *domain.VaultLedgerEntry) (bool, error)
+	// FindByUserID returns all of a user's entries ordered by effective date, then creation time
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]domain.VaultLedgerEntry, error)
+}
+
+// PaymentRepository stores the user's card payment and refund history
+type PaymentRepository interface {
+	// Record stores the payment. It returns false without error if a payment of the same kind
+	// with the same provider ID was already recorded.
+	Record(ctx context.Context, payment *domain.Payment) (bool, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
+	// ListByUserID returns the user's payments made in [from, to), oldest first
+	ListByUserID(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.Payment, error)
+}
+
+// DocumentRenderer renders statements and receipts for download
+type DocumentRenderer interface {
+	Render(doc *domain.Document, format domain.DocumentFormat) ([]byte, error)
+}
+
+// VaultDocumentService produces downloadable vault statements and payment receipts
+type VaultDocumentService interface {
+	ListPayments(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.Payment, error)
+	GetStatementDocument(ctx context.Context, userID uuid.UUID, monthStart time.Time) (*domain.Document, error)
+	RenderStatement(ctx context.Context, userID uuid.UUID, monthStart time.Time, format domain.DocumentFormat) (*domain.RenderedDocument, error)
+	// RenderReceipt returns domain.ErrPaymentNotFound for another user's payment
+	RenderReceipt(ctx context.Context, userID, paymentID uuid.UUID, format domain.DocumentFormat) (*domain.RenderedDocument, error)
 }
 type SocialService interface {
 	AddFriend(ctx context.Context, userID, friendID uuid.UUID) error
@@ -164,6 +448,12 @@ type SocialRepository interface {
 	FindTribeByID(ctx context.Context, id uuid.UUID) (*domain.Tribe, error)
 	SaveChallenge(ctx context.Context, c *domain.FriendChallenge) error
 	FindChallengesByUserID(ctx context.Context, userID uuid.UUID) ([]domain.FriendChallenge, error)
+	FindChallengeByID(ctx context.Context, id uuid.UUID) (*domain.FriendChallenge, error)
+	// FindEndedChallenges returns active challenges whose EndDate is not after the given time
+	FindEndedChallenges(ctx context.Context, now time.Time) ([]*domain.FriendChallenge, error)
+	SaveChallengeParticipant(ctx context.Context, p *domain.FriendChallengeParticipant) error
+	FindChallengeParticipant(ctx context.Context, challengeID, userID uuid.UUID) (*domain.FriendChallengeParticipant, error)
+	FindChallengeParticipants(ctx context.Context, challengeID uuid.UUID) ([]*domain.FriendChallengeParticipant, error)
 	FindAllTribes(ctx context.Context, limit, offset int) ([]domain.Tribe, error)
 	GetFeed(ctx context.Context, userID uuid.UUID, limit, offset int) ([]domain.SocialEvent, error)
 	SaveEvent(ctx context.Context, event *domain.SocialEvent) error
@@ -228,6 +518,47 @@ type TribeService interface {
 	GetTribeStats(ctx context.Context, tribeID string) (*domain.TribeStats, error)
 }
 
+// TribePoolRepository stores monthly tribe prize pools and their participants
+type TribePoolRepository interface {
+	// CreatePool returns false if the tribe already has a pool for the month
+	CreatePool(ctx context.Context, pool *domain.TribePool) (bool, error)
+	// UpdatePool saves the status and place payouts. The pot is only changed by AddParticipant.
+	UpdatePool(ctx context.Context, pool *domain.TribePool) error
+	FindPoolByID(ctx context.Context, id uuid.UUID) (*domain.TribePool, error)
+	FindPoolByMonth(ctx context.Context, tribeID uuid.UUID, monthStart time.Time) (*domain.TribePool, error)
+	ListPoolsByTribe(ctx context.Context, tribeID uuid.UUID) ([]*domain.TribePool, error) // Newest month first
+	ListPoolsByStatus(ctx context.Context, status string) ([]*domain.TribePool, error)
+	// AddParticipant records the participant and adds their deposit to the pot. It returns false if the
+	// user already joined, and domain.ErrTribePoolNotOpen if the pool stopped taking participants.
+	AddParticipant(ctx context.Context, participant *domain.TribePoolParticipant) (bool, error)
+	UpdateParticipant(ctx context.Context, participant *domain.TribePoolParticipant) error
+	ListParticipants(ctx context.Context, poolID uuid.UUID) ([]*domain.TribePoolParticipant, error)
+}
+
+// TribePoolService runs the monthly tribe prize pool lifecycle
+type TribePoolService interface {
+	OpenPool(ctx context.Context, tribeID, userID string, month time.Time) (*domain.TribePool, error)
+	JoinPool(ctx context.Context, tribeID, userID string, month time.Time) (*domain.TribePoolParticipant, error)
+	GetStandings(ctx context.Context, tribeID string, month time.Time) (*domain.TribePoolStandings, error)
+	ListPools(ctx context.Context, tribeID string) ([]*domain.TribePool, error)
+
+	// Scheduled: stop joins once a month starts, rank and pay out once it ends
+	ActivateStartedPools(ctx context.Context, now time.Time) (int, error)
+	SettleEndedPools(ctx context.Context, now time.Time) (int, error)
+}
+
+// ChallengeService runs friend challenges: invitations, staked deposits, live progress and settlement
+type ChallengeService interface {
+	CreateChallenge(ctx context.Context, userID uuid.UUID, name string, challengeType domain.ChallengeType, goal int, startDate, endDate time.Time, invitees []uuid.UUID) (*domain.ChallengeDetails, error)
+	Invite(ctx context.Context, challengeID, userID uuid.UUID, invitees []uuid.UUID) ([]*domain.FriendChallengeParticipant, error)
+	Accept(ctx context.Context, challengeID, userID uuid.UUID) (*domain.FriendChallengeParticipant, error)
+	Decline(ctx context.Context, challengeID, userID uuid.UUID) error
+	GetChallenge(ctx context.Context, challengeID, userID uuid.UUID) (*domain.ChallengeDetails, error)
+
+	// Scheduled: pick the winner and record payouts once a challenge reaches its EndDate
+	SettleEndedChallenges(ctx context.Context, now time.Time) (int, error)
+}
+
 // SOSRepository defines the interface for SOS flare data persistence
 type SOSRepository interface {
 	Save(ctx context.Context, sos *domain.SOSFlare) error
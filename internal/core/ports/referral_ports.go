@@ -3,21 +3,33 @@ package ports
 import (
 	"context"
 	"fastinghero/internal/core/domain"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type ReferralRepository interface {
 	Save(ctx context.Context, referral *domain.Referral) error
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.Referral, error)
 	FindByRefereeID(ctx context.Context, refereeID uuid.UUID) (*domain.Referral, error)
 	FindByReferrerID(ctx context.Context, referrerID uuid.UUID) ([]domain.Referral, error)
+	ListByStatus(ctx context.Context, status domain.ReferralStatus) ([]domain.Referral, error)
+	// ListUnpaid returns completed referrals with a side that hasn't been credited yet
+	ListUnpaid(ctx context.Context) ([]domain.Referral, error)
 	Update(ctx context.Context, referral *domain.Referral) error
+
+	SaveSignupSignals(ctx context.Context, signals *domain.SignupSignals) error
+	FindSignupSignals(ctx context.Context, userID uuid.UUID) (*domain.SignupSignals, error)
 }
 
 type ReferralService interface {
 	GenerateReferralCode(ctx context.Context, userID uuid.UUID) (string, error)
 	GetReferralCode(ctx context.Context, userID uuid.UUID) (string, error)
 	TrackReferral(ctx context.Context, referrerCode string, refereeID uuid.UUID) error
-	CompleteReferral(ctx context.Context, refereeID uuid.UUID) error
+	RecordSignup(ctx context.Context, userID uuid.UUID, ip, deviceID, userAgent string) error
+	QualifyReferral(ctx context.Context, refereeID uuid.UUID, event domain.ReferralEvent) error
+	ListHeldReferrals(ctx context.Context) ([]domain.Referral, error)
+	ReviewReferral(ctx context.Context, referralID, adminID uuid.UUID, approve bool, note string) (*domain.Referral, error)
+	PayOwedRewards(ctx context.Context, now time.Time) (int, error)
 	GetReferralStats(ctx context.Context, userID uuid.UUID) (totalEarned float64, count int, err error)
 }
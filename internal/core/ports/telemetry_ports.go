@@ -3,6 +3,7 @@ package ports
 import (
 	"context"
 	"fastinghero/internal/core/domain"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,6 +12,8 @@ type TelemetryRepository interface {
 	SaveData(ctx context.Context, data *domain.TelemetryData) error
 	GetLatestMetric(ctx context.Context, userID uuid.UUID, metricType domain.MetricType) (*domain.TelemetryData, error)
 	GetWeeklyStats(ctx context.Context, userID uuid.UUID, metricType domain.MetricType) ([]domain.DailyStat, error)
+	// FindByRange returns the user's data points of a metric with a timestamp in [from, to)
+	FindByRange(ctx context.Context, userID uuid.UUID, metricType domain.MetricType, from, to time.Time) ([]domain.TelemetryData, error)
 	SaveConnection(ctx context.Context, conn *domain.DeviceConnection) error
 	GetConnection(ctx context.Context, userID uuid.UUID, source domain.TelemetrySource) (*domain.DeviceConnection, error)
 	ListConnections(ctx context.Context, userID uuid.UUID) ([]domain.DeviceConnection, error)
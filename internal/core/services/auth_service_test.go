@@ -47,6 +47,40 @@ func (m *MockUserRepository) FindByReferralCode(ctx context.Context, code string
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) FindByStripeCustomerID(ctx context.Context, customerID string) (*domain.User, error) {
+	args := m.Called(ctx, customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ListVaultMembers(ctx context.Context, afterID uuid.UUID, limit int) ([]*domain.User, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ListWithDisciplineScore(ctx context.Context, afterID uuid.UUID, limit int) ([]*domain.User, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateEarnedRefund(ctx context.Context, userID uuid.UUID, earnedRefund float64) error {
+	args := m.Called(ctx, userID, earnedRefund)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateDisciplineIndex(ctx context.Context, userID uuid.UUID, disciplineIndex float64) error {
+	args := m.Called(ctx, userID, disciplineIndex)
+	return args.Error(0)
+}
+
 func TestAuthService_Register_HashesPassword(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	authService := NewAuthService(mockRepo, nil, "test-secret")
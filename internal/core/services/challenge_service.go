@@ -0,0 +1,466 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// challengeMaxDays bounds the window so hydration progress stays a bounded number of lookups
+	challengeMaxDays         = 90
+	challengeMaxParticipants = 20
+)
+
+// ChallengeService runs friend challenges: the creator invites friends, everyone who accepts
+// stakes a deposit charged to their card, progress is measured per challenge type over the same
+// window, and the challenge is settled and paid out once it reaches its EndDate.
+type ChallengeService struct {
+	socialRepo    ports.SocialRepository
+	fastingRepo   ports.FastingRepository
+	telemetryRepo ports.TelemetryRepository
+	progressRepo  ports.ProgressRepository
+	entitlements  ports.EntitlementService
+	vault         ports.VaultService
+}
+
+func NewChallengeService(socialRepo ports.SocialRepository, fastingRepo ports.FastingRepository, telemetryRepo ports.TelemetryRepository, progressRepo ports.ProgressRepository, entitlements ports.EntitlementService, vault ports.VaultService) *ChallengeService {
+	return &ChallengeService{
+		socialRepo:    socialRepo,
+		fastingRepo:   fastingRepo,
+		telemetryRepo: telemetryRepo,
+		progressRepo:  progressRepo,
+		entitlements:  entitlements,
+		vault:         vault,
+	}
+}
+
+// CreateChallenge creates the challenge with its creator as the first accepted participant
+// and invites the given users. Staking a deposit needs the group pots capability, and nothing
+// is created unless the creator's deposit is charged.
+func (s *ChallengeService) CreateChallenge(ctx context.Context, userID uuid.UUID, name string, challengeType domain.ChallengeType, goal int, startDate, endDate time.Time, invitees []uuid.UUID) (*domain.ChallengeDetails, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", domain.ErrInvalidChallenge)
+	}
+	if !challengeType.IsValid() {
+		return nil, fmt.Errorf("%w: unknown challenge type %q", domain.ErrInvalidChallenge, challengeType)
+	}
+	if goal < 0 {
+		return nil, fmt.Errorf("%w: goal cannot be negative", domain.ErrInvalidChallenge)
+	}
+	if !endDate.After(startDate) {
+		return nil, fmt.Errorf("%w: end date must be after start date", domain.ErrInvalidChallenge)
+	}
+	if endDate.Sub(startDate) > challengeMaxDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: challenges can last at most %d days", domain.ErrInvalidChallenge, challengeMaxDays)
+	}
+	now := time.Now()
+	if !now.Before(endDate) {
+		return nil, fmt.Errorf("%w: end date is in the past", domain.ErrInvalidChallenge)
+	}
+	if len(invitees)+1 > challengeMaxParticipants {
+		return nil, fmt.Errorf("%w: at most %d participants", domain.ErrInvalidChallenge, challengeMaxParticipants)
+	}
+	if err := s.entitlements.Require(ctx, userID, domain.CapabilityGroupPots); err != nil {
+		return nil, err
+	}
+
+	challenge := &domain.FriendChallenge{
+		ID:            uuid.New(),
+		CreatorID:     userID,
+		Name:          name,
+		ChallengeType: challengeType,
+		Goal:          goal,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		Status:        domain.ChallengeStatusActive,
+		PotAmount:     domain.ChallengeDeposit,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	creator := &domain.FriendChallengeParticipant{
+		ID:            uuid.New(),
+		ChallengeID:   challenge.ID,
+		UserID:        userID,
+		Status:        domain.ChallengeParticipantAccepted,
+		DepositAmount: domain.ChallengeDeposit,
+		RespondedAt:   &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.collectStake(ctx, challenge, creator, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.socialRepo.SaveChallenge(ctx, challenge); err != nil {
+		s.returnStake(ctx, challenge, creator)
+		return nil, fmt.Errorf("failed to create challenge: %w", err)
+	}
+	if err := s.socialRepo.SaveChallengeParticipant(ctx, creator); err != nil {
+		s.returnStake(ctx, challenge, creator)
+		return nil, fmt.Errorf("failed to add challenge creator: %w", err)
+	}
+
+	invited, err := s.invite(ctx, challenge, userID, invitees, now)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ChallengeDetails{
+		Challenge:    challenge,
+		Participants: append([]*domain.FriendChallengeParticipant{creator}, invited...),
+	}, nil
+}
+
+// Invite adds invitations to a running challenge. Only the creator can invite; users who
+// are already participants are skipped.
+func (s *ChallengeService) Invite(ctx context.Context, challengeID, userID uuid.UUID, invitees []uuid.UUID) ([]*domain.FriendChallengeParticipant, error) {
+	challenge, err := s.findOpenChallenge(ctx, challengeID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if challenge.CreatorID != userID {
+		return nil, domain.ErrChallengeNotCreator
+	}
+	existing, err := s.socialRepo.FindChallengeParticipants(ctx, challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load participants: %w", err)
+	}
+	if len(existing)+len(invitees) > challengeMaxParticipants {
+		return nil, fmt.Errorf("%w: at most %d participants", domain.ErrInvalidChallenge, challengeMaxParticipants)
+	}
+	return s.invite(ctx, challenge, userID, invitees, time.Now())
+}
+
+// Accept charges the participant's deposit and stakes it; if the charge fails they stay invited.
+// Invitations can be accepted until the challenge ends; progress is measured over the whole
+// challenge window regardless of when they joined.
+func (s *ChallengeService) Accept(ctx context.Context, challengeID, userID uuid.UUID) (*domain.FriendChallengeParticipant, error) {
+	now := time.Now()
+	challenge, err := s.findOpenChallenge(ctx, challengeID, now)
+	if err != nil {
+		return nil, err
+	}
+	participant, err := s.pendingInvitation(ctx, challengeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.entitlements.Require(ctx, userID, domain.CapabilityGroupPots); err != nil {
+		return nil, err
+	}
+
+	participant.DepositAmount = domain.ChallengeDeposit
+	if err := s.collectStake(ctx, challenge, participant, now); err != nil {
+		return nil, err
+	}
+	participant.Status = domain.ChallengeParticipantAccepted
+	participant.RespondedAt = &now
+	participant.UpdatedAt = now
+	if err := s.socialRepo.SaveChallengeParticipant(ctx, participant); err != nil {
+		s.returnStake(ctx, challenge, participant)
+		return nil, fmt.Errorf("failed to accept challenge: %w", err)
+	}
+	return participant, nil
+}
+
+// collectStake charges the participant's deposit and records the charge on them. Each attempt
+// is its own charge, since a failed attempt returns its stake.
+func (s *ChallengeService) collectStake(ctx context.Context, challenge *domain.FriendChallenge, p *domain.FriendChallengeParticipant, now time.Time) error {
+	chargeID, err := s.vault.CollectStake(ctx, p.UserID, p.DepositAmount,
+		fmt.Sprintf("Stake for challenge %q", challenge.Name),
+		fmt.Sprintf("challenge-stake-%s-%d", p.ID, now.UnixNano()))
+	if err != nil {
+		return err
+	}
+	p.DepositChargeID = chargeID
+	return nil
+}
+
+// returnStake refunds a stake collected for a join that couldn't be saved
+func (s *ChallengeService) returnStake(ctx context.Context, challenge *domain.FriendChallenge, p *domain.FriendChallengeParticipant) {
+	err := s.vault.PayOutStake(ctx, p.UserID, s.stakePayout(challenge, p, p.DepositAmount))
+	if err != nil {
+		log.Printf("challenge: failed to return stake %s for %s: %v", p.DepositChargeID, p.UserID, err)
+	}
+}
+
+func (s *ChallengeService) stakePayout(challenge *domain.FriendChallenge, p *domain.FriendChallengeParticipant, amount float64) domain.StakePayout {
+	return domain.StakePayout{
+		SourceType: domain.LedgerSourceChallenge,
+		SourceID:   p.ID.String(),
+		ChargeID:   p.DepositChargeID,
+		Stake:      p.DepositAmount,
+		Amount:     amount,
+		Reason:     fmt.Sprintf("Won challenge %q", challenge.Name),
+	}
+}
+
+func (s *ChallengeService) Decline(ctx context.Context, challengeID, userID uuid.UUID) error {
+	now := time.Now()
+	if _, err := s.findOpenChallenge(ctx, challengeID, now); err != nil {
+		return err
+	}
+	participant, err := s.pendingInvitation(ctx, challengeID, userID)
+	if err != nil {
+		return err
+	}
+	participant.Status = domain.ChallengeParticipantDeclined
+	participant.RespondedAt = &now
+	participant.UpdatedAt = now
+	return s.socialRepo.SaveChallengeParticipant(ctx, participant)
+}
+
+// GetChallenge returns the challenge with its participants. While it is running, progress is
+// recomputed on every call; once settled, the recorded progress and payouts are returned.
+func (s *ChallengeService) GetChallenge(ctx context.Context, challengeID, userID uuid.UUID) (*domain.ChallengeDetails, error) {
+	challenge, err := s.findChallenge(ctx, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	participants, err := s.socialRepo.FindChallengeParticipants(ctx, challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load participants: %w", err)
+	}
+	if challenge.CreatorID != userID && !hasChallengeParticipant(participants, userID) {
+		return nil, domain.ErrChallengeNotInvited
+	}
+	if participants == nil {
+		participants = []*domain.FriendChallengeParticipant{}
+	}
+
+	if challenge.Status == domain.ChallengeStatusActive {
+		if err := s.measureProgress(ctx, challenge, participants, time.Now()); err != nil {
+			return nil, err
+		}
+		// The stored pot only covers deposits at creation until the challenge settles
+		challenge.PotAmount = 0
+		for _, p := range participants {
+			if p.Status == domain.ChallengeParticipantAccepted {
+				challenge.PotAmount += p.DepositAmount
+			}
+		}
+	}
+	return &domain.ChallengeDetails{Challenge: challenge, Participants: participants}, nil
+}
+
+// SettleEndedChallenges picks winners and pays out every active challenge whose EndDate has
+// passed. Settling is deterministic and participants already paid are skipped, so a challenge
+// that failed halfway is settled again on the next run.
+func (s *ChallengeService) SettleEndedChallenges(ctx context.Context, now time.Time) (int, error) {
+	challenges, err := s.socialRepo.FindEndedChallenges(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list ended challenges: %w", err)
+	}
+	settled := 0
+	for _, challenge := range challenges {
+		if err := s.settleChallenge(ctx, challenge, now); err != nil {
+			log.Printf("challenge: failed to settle challenge %s: %v", challenge.ID, err)
+			continue
+		}
+		settled++
+	}
+	return settled, nil
+}
+
+func (s *ChallengeService) settleChallenge(ctx context.Context, challenge *domain.FriendChallenge, now time.Time) error {
+	participants, err := s.socialRepo.FindChallengeParticipants(ctx, challenge.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.measureProgress(ctx, challenge, participants, challenge.EndDate); err != nil {
+		return err
+	}
+
+	status := domain.SettleChallenge(challenge, participants)
+	for _, p := range participants {
+		p.UpdatedAt = now
+		if err := s.socialRepo.SaveChallengeParticipant(ctx, p); err != nil {
+			return fmt.Errorf("failed to record payout for %s: %w", p.UserID, err)
+		}
+	}
+
+	for _, p := range participants {
+		if p.Status != domain.ChallengeParticipantAccepted || p.PayoutProcessed {
+			continue
+		}
+		// Stakes accepted before deposits were charged were never collected, so there is nothing to pay
+		if p.PayoutAmount > 0 && p.DepositChargeID != "" {
+			if err := s.vault.PayOutStake(ctx, p.UserID, s.stakePayout(challenge, p, p.PayoutAmount)); err != nil {
+				return fmt.Errorf("failed to pay out %s: %w", p.UserID, err)
+			}
+		}
+		p.PayoutProcessed = true
+		if err := s.socialRepo.SaveChallengeParticipant(ctx, p); err != nil {
+			return fmt.Errorf("failed to record payout for %s: %w", p.UserID, err)
+		}
+	}
+
+	challenge.Status = status
+	challenge.PayoutProcessed = true
+	challenge.PayoutDate = &now
+	challenge.UpdatedAt = now
+	if err := s.socialRepo.SaveChallenge(ctx, challenge); err != nil {
+		return err
+	}
+
+	for _, p := range participants {
+		if p.IsWinner {
+			s.announceWin(ctx, challenge, p, now)
+		}
+	}
+	return nil
+}
+
+func (s *ChallengeService) announceWin(ctx context.Context, challenge *domain.FriendChallenge, winner *domain.FriendChallengeParticipant, now time.Time) {
+	data, _ := json.Marshal(map[string]interface{}{
+		"challenge_id":   challenge.ID,
+		"challenge_name": challenge.Name,
+		"progress":       winner.Progress,
+		"payout_amount":  winner.PayoutAmount,
+	})
+	event := &domain.SocialEvent{
+		ID:        uuid.New(),
+		UserID:    winner.UserID,
+		EventType: domain.EventChallengeWon,
+		Data:      string(data),
+		CreatedAt: now,
+	}
+	_ = s.socialRepo.SaveEvent(ctx, event) // Non-critical, the payout is already recorded
+}
+
+// measureProgress sets Progress for accepted participants over [StartDate, until), with until
+// capped at the challenge's EndDate
+func (s *ChallengeService) measureProgress(ctx context.Context, challenge *domain.FriendChallenge, participants []*domain.FriendChallengeParticipant, until time.Time) error {
+	to := challenge.EndDate
+	if until.Before(to) {
+		to = until
+	}
+	for _, p := range participants {
+		if p.Status != domain.ChallengeParticipantAccepted {
+			continue
+		}
+		progress, err := s.progress(ctx, challenge.ChallengeType, p.UserID, challenge.StartDate, to)
+		if err != nil {
+			return fmt.Errorf("failed to measure progress for %s: %w", p.UserID, err)
+		}
+		p.Progress = progress
+	}
+	return nil
+}
+
+func (s *ChallengeService) progress(ctx context.Context, challengeType domain.ChallengeType, userID uuid.UUID, from, to time.Time) (float64, error) {
+	if !from.Before(to) {
+		return 0, nil
+	}
+	switch challengeType {
+	case domain.ChallengeTypeFasting:
+		sessions, err := s.fastingRepo.FindByUserID(ctx, userID)
+		if err != nil {
+			return 0, err
+		}
+		return domain.ChallengeFastingHours(sessions, from, to), nil
+	case domain.ChallengeTypeSteps:
+		points, err := s.telemetryRepo.FindByRange(ctx, userID, domain.MetricSteps, from, to)
+		if err != nil {
+			return 0, err
+		}
+		return domain.ChallengeSteps(points), nil
+	case domain.ChallengeTypeHydration:
+		var glasses int
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			entry, err := s.progressRepo.GetHydrationLog(ctx, userID, day)
+			if err != nil {
+				return 0, err
+			}
+			if entry != nil {
+				glasses += entry.GlassesCount
+			}
+		}
+		return float64(glasses), nil
+	}
+	return 0, fmt.Errorf("unknown challenge type %q", challengeType)
+}
+
+func (s *ChallengeService) invite(ctx context.Context, challenge *domain.FriendChallenge, inviterID uuid.UUID, invitees []uuid.UUID, now time.Time) ([]*domain.FriendChallengeParticipant, error) {
+	invited := []*domain.FriendChallengeParticipant{}
+	seen := map[uuid.UUID]bool{inviterID: true}
+	for _, inviteeID := range invitees {
+		if seen[inviteeID] {
+			continue
+		}
+		seen[inviteeID] = true
+
+		existing, err := s.socialRepo.FindChallengeParticipant(ctx, challenge.ID, inviteeID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			continue
+		}
+		participant := &domain.FriendChallengeParticipant{
+			ID:          uuid.New(),
+			ChallengeID: challenge.ID,
+			UserID:      inviteeID,
+			InvitedBy:   &inviterID,
+			Status:      domain.ChallengeParticipantInvited,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := s.socialRepo.SaveChallengeParticipant(ctx, participant); err != nil {
+			return nil, fmt.Errorf("failed to invite %s: %w", inviteeID, err)
+		}
+		invited = append(invited, participant)
+	}
+	return invited, nil
+}
+
+func (s *ChallengeService) findChallenge(ctx context.Context, challengeID uuid.UUID) (*domain.FriendChallenge, error) {
+	challenge, err := s.socialRepo.FindChallengeByID(ctx, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil {
+		return nil, domain.ErrChallengeNotFound
+	}
+	return challenge, nil
+}
+
+// findOpenChallenge returns the challenge if it still takes invitations and responses
+func (s *ChallengeService) findOpenChallenge(ctx context.Context, challengeID uuid.UUID, now time.Time) (*domain.FriendChallenge, error) {
+	challenge, err := s.findChallenge(ctx, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.Status != domain.ChallengeStatusActive || challenge.HasEnded(now) {
+		return nil, domain.ErrChallengeNotActive
+	}
+	return challenge, nil
+}
+
+func (s *ChallengeService) pendingInvitation(ctx context.Context, challengeID, userID uuid.UUID) (*domain.FriendChallengeParticipant, error) {
+	participant, err := s.socialRepo.FindChallengeParticipant(ctx, challengeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if participant == nil {
+		return nil, domain.ErrChallengeNotInvited
+	}
+	if participant.Status != domain.ChallengeParticipantInvited {
+		return nil, domain.ErrChallengeAlreadyJoined
+	}
+	return participant, nil
+}
+
+func hasChallengeParticipant(participants []*domain.FriendChallengeParticipant, userID uuid.UUID) bool {
+	for _, p := range participants {
+		if p.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestChallengeService(socialRepo *MockSocialRepository, fastingRepo *MockFastingRepository, telemetryRepo *MockTelemetryRepository, progressRepo *MockProgressRepository) *ChallengeService {
+	return NewChallengeService(socialRepo, fastingRepo, telemetryRepo, progressRepo, allowAllEntitlements(), collectAllStakes())
+}
+
+// collectAllStakes is a vault that charges every stake and pays every payout
+func collectAllStakes() *MockVaultService {
+	m := new(MockVaultService)
+	m.On("CollectStake", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("ch_stake", nil)
+	m.On("PayOutStake", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	return m
+}
+
+func acceptedParticipant(challengeID uuid.UUID, joined time.Time) *domain.FriendChallengeParticipant {
+	return &domain.FriendChallengeParticipant{
+		ID:              uuid.New(),
+		ChallengeID:     challengeID,
+		UserID:          uuid.New(),
+		Status:          domain.ChallengeParticipantAccepted,
+		DepositAmount:   domain.ChallengeDeposit,
+		DepositChargeID: "ch_" + uuid.NewString(),
+		CreatedAt:       joined,
+	}
+}
+
+func TestChallengeService_CreateChallenge_InvitesFriends(t *testing.T) {
+	socialRepo := new(MockSocialRepository)
+	service := newTestChallengeService(socialRepo, nil, nil, nil)
+	ctx := context.Background()
+	creatorID := uuid.New()
+	friendA, friendB := uuid.New(), uuid.New()
+	start := time.Now()
+
+	socialRepo.On("SaveChallenge", ctx, mock.AnythingOfType("*domain.FriendChallenge")).Return(nil)
+	socialRepo.On("SaveChallengeParticipant", ctx, mock.AnythingOfType("*domain.FriendChallengeParticipant")).Return(nil)
+	socialRepo.On("FindChallengeParticipant", ctx, mock.Anything, mock.Anything).Return(nil, nil)
+
+	details, err := service.CreateChallenge(ctx, creatorID, "Fast Week", domain.ChallengeTypeFasting, 48, start, start.AddDate(0, 0, 7),
+		[]uuid.UUID{friendA, friendB, friendA, creatorID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ChallengeStatusActive, details.Challenge.Status)
+	assert.Len(t, details.Participants, 3)
+	assert.Equal(t, creatorID, details.Participants[0].UserID)
+	assert.Equal(t, domain.ChallengeParticipantAccepted, details.Participants[0].Status)
+	assert.Equal(t, domain.ChallengeDeposit, details.Participants[0].DepositAmount)
+	assert.Equal(t, "ch_stake", details.Participants[0].DepositChargeID)
+	assert.Equal(t, friendA, details.Participants[1].UserID)
+	assert.Equal(t, domain.ChallengeParticipantInvited, details.Participants[1].Status)
+	assert.Equal(t, creatorID, *details.Participants[1].InvitedBy)
+	assert.Equal(t, 0.0, details.Participants[1].DepositAmount)
+	socialRepo.AssertNumberOfCalls(t, "SaveChallengeParticipant", 3)
+}
+
+func TestChallengeService_CreateChallenge_Validation(t *testing.T) {
+	service := newTestChallengeService(new(MockSocialRepository), nil, nil, nil)
+	ctx := context.Background()
+	start := time.Now()
+
+	_, err := service.CreateChallenge(ctx, uuid.New(), "Bad", domain.ChallengeType("sleep"), 0, start, start.AddDate(0, 0, 7), nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidChallenge)
+
+	_, err = service.CreateChallenge(ctx, uuid.New(), "Bad", domain.ChallengeTypeSteps, 0, start, start, nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidChallenge)
+
+	_, err = service.CreateChallenge(ctx, uuid.New(), "Bad", domain.ChallengeTypeSteps, 0, start, start.AddDate(0, 6, 0), nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidChallenge)
+}
+
+func TestChallengeService_CreateChallenge_RequiresGroupPots(t *testing.T) {
+	entitlements := new(MockEntitlementService)
+	service := NewChallengeService(new(MockSocialRepository), nil, nil, nil, entitlements, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+	start := time.Now()
+
+	entitlements.On("Require", ctx, userID, domain.CapabilityGroupPots).
+		Return(&domain.EntitlementError{Capability: domain.CapabilityGroupPots})
+
+	_, err := service.CreateChallenge(ctx, userID, "Steps", domain.ChallengeTypeSteps, 0, start, start.AddDate(0, 0, 7), nil)
+
+	assert.ErrorIs(t, err, domain.ErrCapabilityRequired)
+}
+
+func TestChallengeService_Accept(t *testing.T) {
+	socialRepo := new(MockSocialRepository)
+	service := newTestChallengeService(socialRepo, nil, nil, nil)
+	ctx := context.Background()
+	challenge := &domain.FriendChallenge{ID: uuid.New(), Status: domain.ChallengeStatusActive, EndDate: time.Now().AddDate(0, 0, 3)}
+	invited := &domain.FriendChallengeParticipant{ID: uuid.New(), ChallengeID: challenge.ID, UserID: uuid.New(), Status: domain.ChallengeParticipantInvited}
+	strangerID := uuid.New()
+
+	socialRepo.On("FindChallengeByID", ctx, challenge.ID).Return(challenge, nil)
+	socialRepo.On("FindChallengeParticipant", ctx, challenge.ID, invited.UserID).Return(invited, nil)
+	socialRepo.On("FindChallengeParticipant", ctx, challenge.ID, strangerID).Return(nil, nil)
+	socialRepo.On("SaveChallengeParticipant", ctx, invited).Return(nil)
+
+	participant, err := service.Accept(ctx, challenge.ID, invited.UserID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ChallengeParticipantAccepted, participant.Status)
+	assert.Equal(t, domain.ChallengeDeposit, participant.DepositAmount)
+	assert.Equal(t, "ch_stake", participant.DepositChargeID)
+	assert.NotNil(t, participant.RespondedAt)
+
+	_, err = service.Accept(ctx, challenge.ID, invited.UserID)
+	assert.ErrorIs(t, err, domain.ErrChallengeAlreadyJoined)
+
+	_, err = service.Accept(ctx, challenge.ID, strangerID)
+	assert.ErrorIs(t, err, domain.ErrChallengeNotInvited)
+}
+
+func TestChallengeService_CreateChallenge_StakeNotCharged(t *testing.T) {
+	socialRepo := new(MockSocialRepository)
+	vault := new(MockVaultService)
+	service := NewChallengeService(socialRepo, nil, nil, nil, allowAllEntitlements(), vault)
+	ctx := context.Background()
+	creatorID := uuid.New()
+	start := time.Now()
+
+	vault.On("CollectStake", ctx, creatorID, domain.ChallengeDeposit, mock.Anything, mock.Anything).
+		Return("", domain.ErrStakeNotCharged)
+
+	_, err := service.CreateChallenge(ctx, creatorID, "Fast Week", domain.ChallengeTypeFasting, 48, start, start.AddDate(0, 0, 7), []uuid.UUID{uuid.New()})
+
+	assert.ErrorIs(t, err, domain.ErrStakeNotCharged)
+	socialRepo.AssertNotCalled(t, "SaveChallenge", mock.Anything, mock.Anything)
+	socialRepo.AssertNotCalled(t, "SaveChallengeParticipant", mock.Anything, mock.Anything)
+}
+
+func TestChallengeService_Accept_StakeNotCharged(t *testing.T) {
+	socialRepo := new(MockSocialRepository)
+	vault := new(MockVaultService)
+	service := NewChallengeService(socialRepo, nil, nil, nil, allowAllEntitlements(), vault)
+	ctx := context.Background()
+	challenge := &domain.FriendChallenge{ID: uuid.New(), Status: domain.ChallengeStatusActive, EndDate: time.Now().AddDate(0, 0, 3)}
+	invited := &domain.FriendChallengeParticipant{ID: uuid.New(), ChallengeID: challenge.ID, UserID: uuid.New(), Status: domain.ChallengeParticipantInvited}
+
+	socialRepo.On("FindChallengeByID", ctx, challenge.ID).Return(challenge, nil)
+	socialRepo.On("FindChallengeParticipant", ctx, challenge.ID, invited.UserID).Return(invited, nil)
+	vault.On("CollectStake", ctx, invited.UserID, domain.ChallengeDeposit, mock.Anything, mock.Anything).
+		Return("", domain.ErrStakeNotCharged)
+
+	_, err := service.Accept(ctx, challenge.ID, invited.UserID)
+
+	assert.ErrorIs(t, err, domain.ErrStakeNotCharged)
+	assert.Equal(t, domain.ChallengeParticipantInvited, invited.Status)
+	socialRepo.AssertNotCalled(t, "SaveChallengeParticipant", mock.Anything, mock.Anything)
+}
+
+func TestChallengeService_Accept_AfterEndDate(t *testing.T) {
+	socialRepo := new(MockSocialRepository)
+	service := newTestChallengeService(socialRepo, nil, nil, nil)
+	ctx := context.Background()
+	challenge := &domain.FriendChallenge{ID: uuid.New(), Status: domain.ChallengeStatusActive, EndDate: time.Now().Add(-time.Minute)}
+
+	socialRepo.On("FindChallengeByID", ctx, challenge.ID).Return(challenge, nil)
+
+	_, err := service.Accept(ctx, challenge.ID, uuid.New())
+
+	assert.ErrorIs(t, err, domain.ErrChallengeNotActive)
+}
+
+func TestChallengeService_Invite_OnlyCreator(t *testing.T) {
+	socialRepo := new(MockSocialRepository)
+	service := newTestChallengeService(socialRepo, nil, nil, nil)
+	ctx := context.Background()
+	challenge := &domain.FriendChallenge{ID: uuid.New(), CreatorID: uuid.New(), Status: domain.ChallengeStatusActive, EndDate: time.Now().AddDate(0, 0, 3)}
+
+	socialRepo.On("FindChallengeByID", ctx, challenge.ID).Return(challenge, nil)
+
+	_, err := service.Invite(ctx, challenge.ID, uuid.New(), []uuid.UUID{uuid.New()})
+
+	assert.ErrorIs(t, err, domain.ErrChallengeNotCreator)
+}
+
+func TestChallengeService_SettleEndedChallenges_FastingWinnerTakesHalf(t *testing.T) {
+	socialRepo := new(MockSocialRepository)
+	fastingRepo := new(MockFastingRepository)
+	vault := new(MockVaultService)
+	service := NewChallengeService(socialRepo, fastingRepo, nil, nil, allowAllEntitlements(), vault)
+	ctx := context.Background()
+
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	challenge := &domain.FriendChallenge{
+		ID: uuid.New(), Name: "September Fast", ChallengeType: domain.ChallengeTypeFasting, Goal: 30,
+		StartDate: start, EndDate: start.AddDate(0, 0, 7), Status: domain.ChallengeStatusActive,
+	}
+	winner := acceptedParticipant(challenge.ID, start)
+	runnerUp := acceptedParticipant(challenge.ID, start.Add(time.Hour))
+	loser := acceptedParticipant(challenge.ID, start.Add(2*time.Hour))
+	declined := &domain.FriendChallengeParticipant{ID: uuid.New(), ChallengeID: challenge.ID, UserID: uuid.New(), Status: domain.ChallengeParticipantDeclined}
+
+	inWindow := start.AddDate(0, 0, 2)
+	fastingRepo.On("FindByUserID", ctx, winner.UserID).Return([]domain.FastingSession{
+		completedFast(inWindow, 18), completedFast(inWindow.AddDate(0, 0, 1), 16),
+		completedFast(start.AddDate(0, 0, 8), 24), // Ends after the challenge
+	}, nil)
+	fastingRepo.On("FindByUserID", ctx, runnerUp.UserID).Return([]domain.FastingSession{
+		completedFast(inWindow, 20), completedFast(start.Add(-time.Hour), 16), // Ends before the challenge
+	}, nil)
+	fastingRepo.On("FindByUserID", ctx, loser.UserID).Return([]domain.FastingSession{}, nil)
+
+	socialRepo.On("FindEndedChallenges", ctx, mock.Anything).Return([]*domain.FriendChallenge{challenge}, nil)
+	socialRepo.On("FindChallengeParticipants", ctx, challenge.ID).Return([]*domain.FriendChallengeParticipant{winner, runnerUp, loser, declined}, nil)
+	socialRepo.On("SaveChallengeParticipant", ctx, mock.Anything).Return(nil)
+	socialRepo.On("SaveChallenge", ctx, challenge).Return(nil)
+	socialRepo.On("SaveEvent", ctx, mock.MatchedBy(func(e *domain.SocialEvent) bool {
+		return e.EventType == domain.EventChallengeWon && e.UserID == winner.UserID
+	})).Return(nil)
+	vault.On("PayOutStake", ctx, winner.UserID, domain.StakePayout{
+		SourceType: domain.LedgerSourceChallenge,
+		SourceID:   winner.ID.String(),
+		ChargeID:   winner.DepositChargeID,
+		Stake:      20.00,
+		Amount:     40.00,
+		Reason:     `Won challenge "September Fast"`,
+	}).Return(nil).Once()
+
+	settled, err := service.SettleEndedChallenges(ctx, start.AddDate(0, 0, 8))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, settled)
+	assert.Equal(t, domain.ChallengeStatusCompleted, challenge.Status)
+	assert.Equal(t, winner.UserID, *challenge.WinnerID)
+	assert.Equal(t, 60.00, challenge.PotAmount)
+	assert.NotNil(t, challenge.PayoutDate)
+
+	assert.Equal(t, 34.0, winner.Progress)
+	assert.True(t, winner.IsWinner)
+	assert.Equal(t, 40.00, winner.PayoutAmount) // 20 back + 50% of 2 x 20
+	assert.Equal(t, 20.0, runnerUp.Progress)
+	assert.Equal(t, 0.0, runnerUp.PayoutAmount)
+	assert.Equal(t, 0.0, loser.PayoutAmount)
+	assert.Equal(t, 0.0, declined.PayoutAmount)
+	assert.True(t, winner.PayoutProcessed)
+	assert.True(t, loser.PayoutProcessed)
+	assert.False(t, declined.PayoutProcessed)
+	assert.True(t, challenge.PayoutProcessed)
+	vault.AssertExpectations(t)
+	socialRepo.AssertNumberOfCalls(t, "SaveEvent", 1)
+}
+
+func TestChallengeService_SettleEndedChallenges_PayoutFailureIsRetried(t *testing.T) {
+	socialRepo := new(MockSocialRepository)
+	progressRepo := new(MockProgressRepository)
+	vault := new(MockVaultService)
+	service := NewChallengeService(socialRepo, nil, nil, progressRepo, allowAllEntitlements(), vault)
+	ctx := context.Background()
+
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	challenge := &domain.FriendChallenge{
+		ID: uuid.New(), ChallengeType: domain.ChallengeTypeHydration, Goal: 100, StartDate: start, EndDate: start.AddDate(0, 0, 1), Status: domain.ChallengeStatusActive,
+	}
+	paid := acceptedParticipant(challenge.ID, start)
+	paid.PayoutProcessed = true
+	unpaid := acceptedParticipant(challenge.ID, start.Add(time.Hour))
+
+	progressRepo.On("GetHydrationLog", ctx, mock.Anything, mock.Anything).Return(nil, nil)
+	socialRepo.On("FindEndedChallenges", ctx, mock.Anything).Return([]*domain.FriendChallenge{challenge}, nil)
+	socialRepo.On("FindChallengeParticipants", ctx, challenge.ID).Return([]*domain.FriendChallengeParticipant{paid, unpaid}, nil)
+	socialRepo.On("SaveChallengeParticipant", ctx, mock.Anything).Return(nil)
+	vault.On("PayOutStake", ctx, unpaid.UserID, mock.Anything).Return(assert.AnError).Once()
+
+	// Nobody reached the goal, so both are owed their stake; the one already paid is skipped
+	settled, err := service.SettleEndedChallenges(ctx, start.AddDate(0, 0, 2))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, settled)
+	assert.Equal(t, domain.ChallengeStatusActive, challenge.Status)
+	assert.False(t, unpaid.PayoutProcessed)
+	vault.AssertNotCalled(t, "PayOutStake", ctx, paid.UserID, mock.Anything)
+	socialRepo.AssertNotCalled(t, "SaveChallenge", mock.Anything, mock.Anything)
+}
+
+func TestChallengeService_SettleEndedChallenges_StepsTieSplitsWinnings(t *testing.T) {
+	socialRepo := new(MockSocialRepository)
+	telemetryRepo := new(MockTelemetryRepository)
+	service := newTestChallengeService(socialRepo, nil, telemetryRepo, nil)
+	ctx := context.Background()
+
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	challenge := &domain.FriendChallenge{
+		ID: uuid.New(), ChallengeType: domain.ChallengeTypeSteps, StartDate: start, EndDate: end, Status: domain.ChallengeStatusActive,
+	}
+	first := acceptedParticipant(challenge.ID, start)
+	second := acceptedParticipant(challenge.ID, start.Add(time.Hour))
+	third := acceptedParticipant(challenge.ID, start.Add(2*time.Hour))
+
+	steps := func(values ...float64) []domain.TelemetryData {
+		var points []domain.TelemetryData
+		for _, v := range values {
+			points = append(points, domain.TelemetryData{Type: domain.MetricSteps, Value: v})
+		}
+		return points
+	}
+	telemetryRepo.On("FindByRange", ctx, first.UserID, domain.MetricSteps, start, end).Return(steps(40000, 30000), nil)
+	telemetryRepo.On("FindByRange", ctx, second.UserID, domain.MetricSteps, start, end).Return(steps(70000), nil)
+	third.DepositAmount = 20.01
+	telemetryRepo.On("FindByRange", ctx, third.UserID, domain.MetricSteps, start, end).Return(steps(5000), nil)
+
+	socialRepo.On("FindEndedChallenges", ctx, mock.Anything).Return([]*domain.FriendChallenge{challenge}, nil)
+	socialRepo.On("FindChallengeParticipants", ctx, challenge.ID).Return([]*domain.FriendChallengeParticipant{first, second, third}, nil)
+	socialRepo.On("SaveChallengeParticipant", ctx, mock.Anything).Return(nil)
+	socialRepo.On("SaveChallenge", ctx, challenge).Return(nil)
+	socialRepo.On("SaveEvent", ctx, mock.Anything).Return(nil)
+
+	settled, err := service.SettleEndedChallenges(ctx, end.Add(time.Hour))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, settled)
+	assert.True(t, first.IsWinner)
+	assert.True(t, second.IsWinner)
+	assert.False(t, third.IsWinner)
+	// Half of 20.01 is 10.005, rounded to 10.01 cents and split 5.01 / 5.00 in join order
+	assert.Equal(t, 25.01, first.PayoutAmount)
+	assert.Equal(t, 25.00, second.PayoutAmount)
+	assert.Equal(t, first.UserID, *challenge.WinnerID)
+	socialRepo.AssertNumberOfCalls(t, "SaveEvent", 2)
+}
+
+func TestChallengeService_SettleEndedChallenges_CancelledWithoutOpponent(t *testing.T) {
+	socialRepo := new(MockSocialRepository)
+	progressRepo := new(MockProgressRepository)
+	service := newTestChallengeService(socialRepo, nil, nil, progressRepo)
+	ctx := context.Background()
+
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	challenge := &domain.FriendChallenge{
+		ID: uuid.New(), ChallengeType: domain.ChallengeTypeHydration, StartDate: start, EndDate: start.AddDate(0, 0, 3), Status: domain.ChallengeStatusActive,
+	}
+	creator := acceptedParticipant(challenge.ID, start)
+	invited := &domain.FriendChallengeParticipant{ID: uuid.New(), ChallengeID: challenge.ID, UserID: uuid.New(), Status: domain.ChallengeParticipantInvited}
+
+	progressRepo.On("GetHydrationLog", ctx, creator.UserID, mock.Anything).Return(&domain.HydrationLog{GlassesCount: 8}, nil)
+	socialRepo.On("FindEndedChallenges", ctx, mock.Anything).Return([]*domain.FriendChallenge{challenge}, nil)
+	socialRepo.On("FindChallengeParticipants", ctx, challenge.ID).Return([]*domain.FriendChallengeParticipant{creator, invited}, nil)
+	socialRepo.On("SaveChallengeParticipant", ctx, mock.Anything).Return(nil)
+	socialRepo.On("SaveChallenge", ctx, challenge).Return(nil)
+
+	settled, err := service.SettleEndedChallenges(ctx, start.AddDate(0, 0, 4))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, settled)
+	assert.Equal(t, domain.ChallengeStatusCancelled, challenge.Status)
+	assert.Nil(t, challenge.WinnerID)
+	assert.Equal(t, 24.0, creator.Progress) // 8 glasses on each of the 3 days
+	assert.Equal(t, domain.ChallengeDeposit, creator.PayoutAmount)
+	assert.False(t, creator.IsWinner)
+	socialRepo.AssertNotCalled(t, "SaveEvent", mock.Anything, mock.Anything)
+}
+
+func TestSettleChallenge_GoalNotReachedRefundsEveryone(t *testing.T) {
+	challenge := &domain.FriendChallenge{ID: uuid.New(), Goal: 100}
+	a := acceptedParticipant(challenge.ID, time.Now())
+	b := acceptedParticipant(challenge.ID, time.Now())
+	a.Progress = 60
+	b.Progress = 40
+
+	status := domain.SettleChallenge(challenge, []*domain.FriendChallengeParticipant{a, b})
+
+	assert.Equal(t, domain.ChallengeStatusCompleted, status)
+	assert.Nil(t, challenge.WinnerID)
+	assert.Equal(t, domain.ChallengeDeposit, a.PayoutAmount)
+	assert.Equal(t, domain.ChallengeDeposit, b.PayoutAmount)
+	assert.False(t, a.IsWinner)
+}
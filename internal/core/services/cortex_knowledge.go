@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"fastinghero/pkg/logger"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// knowledgePassageLimit is how many passages are retrieved to ground an answer
+const knowledgePassageLimit = 3
+
+// citationPattern matches citations such as [1] and [1, 3]
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// ground retrieves the knowledge base passages relevant to query and appends them to
+// systemPrompt, numbered for citation. Without a knowledge base, or when retrieval fails or
+// finds nothing, the prompt is returned unchanged and the answer is not grounded.
+func (s *CortexService) ground(ctx context.Context, userID uuid.UUID, query, systemPrompt string) (string, []domain.KnowledgePassage) {
+	if s.knowledge == nil {
+		return systemPrompt, nil
+	}
+	passages, err := s.knowledge.Search(ctx, query, knowledgePassageLimit)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Knowledge base search failed; answering without references")
+		return systemPrompt, nil
+	}
+	if len(passages) == 0 {
+		return systemPrompt, nil
+	}
+
+	var material strings.Builder
+	for i, p := range passages {
+		if i > 0 {
+			material.WriteString("\n\n")
+		}
+		fmt.Fprintf(&material, "[%d] %s", i+1, p.Title)
+		if p.Heading != "" {
+			fmt.Fprintf(&material, " - %s", p.Heading)
+		}
+		material.WriteString("\n" + p.Text)
+	}
+	grounding, err := s.prompts.Render(promptKnowledgeGrounding, userID, domain.PromptVars{"passages": material.String()})
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to render knowledge grounding prompt")
+		return systemPrompt, nil
+	}
+	return systemPrompt + "\n\n" + grounding.System, passages
+}
+
+// answerWithCitations returns reply with the passages it cites by number. Numbers that don't
+// match a passage are ignored.
+func answerWithCitations(reply string, passages []domain.KnowledgePassage) *domain.CortexAnswer {
+	answer := &domain.CortexAnswer{Text: reply, Citations: []domain.KnowledgeCitation{}}
+	cited := make(map[int]bool)
+	for _, match := range citationPattern.FindAllStringSubmatch(reply, -1) {
+		for _, ref := range strings.Split(match[1], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(ref))
+			if err != nil || n < 1 || n > len(passages) || cited[n] {
+				continue
+			}
+			cited[n] = true
+			p := passages[n-1]
+			answer.Citations = append(answer.Citations, domain.KnowledgeCitation{
+				Ref:       n,
+				ArticleID: p.ArticleID,
+				Title:     p.Title,
+				Heading:   p.Heading,
+				Source:    p.Source,
+			})
+		}
+	}
+	sort.Slice(answer.Citations, func(i, j int) bool { return answer.Citations[i].Ref < answer.Citations[j].Ref })
+	return answer
+}
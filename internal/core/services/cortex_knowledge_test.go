@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type stubKnowledgeBase struct {
+	passages []domain.KnowledgePassage
+	err      error
+	queries  []string
+}
+
+func (k *stubKnowledgeBase) Search(ctx context.Context, query string, limit int) ([]domain.KnowledgePassage, error) {
+	k.queries = append(k.queries, query)
+	if len(k.passages) > limit {
+		return k.passages[:limit], k.err
+	}
+	return k.passages, k.err
+}
+
+func knowledgePassages() []domain.KnowledgePassage {
+	return []domain.KnowledgePassage{
+		{ID: "ketosis#1", ArticleID: "ketosis", Title: "Ketosis", Heading: "When it starts", Source: "Anton 2018", Text: "Ketone levels rise after 12 to 16 hours."},
+		{ID: "electrolytes#2", ArticleID: "electrolytes", Title: "Electrolytes", Heading: "Sodium", Source: "Clinic guide", Text: "Salt helps with headaches."},
+	}
+}
+
+func TestCortexService_Chat_CitesKnowledgeBase(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	kb := &stubKnowledgeBase{passages: knowledgePassages()}
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, kb, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+	mockFastingRepo.On("FindActiveByUserID", ctx, userID).Return(nil, nil)
+	var systemPrompt string
+	mockLLM.On("GenerateResponse", ctx, "When does ketosis start?", mock.Anything).
+		Run(func(args mock.Arguments) { systemPrompt = args.String(2) }).
+		Return("Usually after 12 to 16 hours [1]. Ignore [7].", nil)
+
+	answer, err := service.Chat(ctx, userID, "When does ketosis start?")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"When does ketosis start?"}, kb.queries)
+	assert.Contains(t, systemPrompt, "[1] Ketosis - When it starts\nKetone levels rise after 12 to 16 hours.")
+	assert.Contains(t, systemPrompt, "[2] Electrolytes - Sodium")
+	assert.Equal(t, "Usually after 12 to 16 hours [1]. Ignore [7].", answer.Text)
+	assert.Equal(t, []domain.KnowledgeCitation{
+		{Ref: 1, ArticleID: "ketosis", Title: "Ketosis", Heading: "When it starts", Source: "Anton 2018"},
+	}, answer.Citations, "only passages the reply cites are returned")
+}
+
+func TestCortexService_GenerateInsight_SearchesTheMilestone(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	mockUserRepo := new(MockUserRepository)
+	kb := &stubKnowledgeBase{passages: knowledgePassages()}
+	service := NewCortexService(mockLLM, new(MockFastingRepository), mockUserRepo, nil, nil, nil, nil, nil, kb, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("Ketones are rising [2, 1] [1].", nil)
+
+	insight, err := service.GenerateInsight(ctx, userID, 14)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{getMilestone(14)}, kb.queries)
+	require.Len(t, insight.Citations, 2)
+	assert.Equal(t, 1, insight.Citations[0].Ref)
+	assert.Equal(t, 2, insight.Citations[1].Ref)
+}
+
+func TestCortexService_Chat_AnswersUngroundedWhenSearchFails(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	kb := &stubKnowledgeBase{err: errors.New("index unavailable")}
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, kb, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+	mockFastingRepo.On("FindActiveByUserID", ctx, userID).Return(nil, nil)
+	mockLLM.On("GenerateResponse", ctx, "Hello", mock.MatchedBy(func(system string) bool {
+		return !strings.Contains(system, "Reference material")
+	})).Return("Hi [1]", nil)
+
+	answer, err := service.Chat(ctx, userID, "Hello")
+	require.NoError(t, err)
+	assert.Equal(t, "Hi [1]", answer.Text)
+	assert.Empty(t, answer.Citations)
+}
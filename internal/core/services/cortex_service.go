@@ -2,38 +2,182 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// errUnsafeCortexReply is returned instead of a reply the safety guard rejected
+var errUnsafeCortexReply = errors.New("cortex reply failed the safety screen")
+
+// errUserAtRisk is returned by GenerateFromPrompt for a user the safety guard flags, so callers
+// skip the nudge instead of sending a fallback of their own
+var errUserAtRisk = errors.New("user is at risk; no fasting nudges")
+
+// safeDailyQuote replaces the daily quote for a user at risk
+const safeDailyQuote = "Taking care of yourself comes first today. Eat well, rest, and reach out to someone you trust if things feel hard."
+
 type CortexService struct {
 	llm         ports.LLMProvider
+	providers   map[domain.LLMUseCase]ports.LLMProvider
 	fastingRepo ports.FastingRepository
 	userRepo    ports.UserRepository
+	threads     ports.CortexThreadRepository
+	usage       ports.CortexUsageRepository
+	cache       ports.CortexResponseCache
+	prompts     *PromptRegistry
+	knowledge   ports.KnowledgeBase
+	safety      *SafetyGuard
+	tools       *CortexToolRegistry
+
+	cacheStatsMu sync.Mutex
+	cacheStats   map[string]domain.CortexCacheStats
 }
 
-func NewCortexService(llm ports.LLMProvider, fastingRepo ports.FastingRepository, userRepo ports.UserRepository) *CortexService {
+// NewCortexService uses llm for every use case unless providers overrides it (providers may be nil).
+// threads may be nil when conversations aren't persisted, usage when calls aren't metered against
+// daily budgets and cache when nothing is cached. A nil prompts uses the built-in templates
+// without recording which variant each response came from, and a nil knowledge base leaves
+// chat and insights ungrounded. A nil safety guard still screens every call but only logs flags.
+func NewCortexService(llm ports.LLMProvider, fastingRepo ports.FastingRepository, userRepo ports.UserRepository, providers map[domain.LLMUseCase]ports.LLMProvider, threads ports.CortexThreadRepository, usage ports.CortexUsageRepository, cache ports.CortexResponseCache, prompts *PromptRegistry, knowledge ports.KnowledgeBase, safety *SafetyGuard) *CortexService {
+	if prompts == nil {
+		prompts = builtinPromptRegistry()
+	}
+	if safety == nil {
+		safety = NewSafetyGuard(nil)
+	}
 	return &CortexService{
 		llm:         llm,
+		providers:   providers,
 		fastingRepo: fastingRepo,
 		userRepo:    userRepo,
+		threads:     threads,
+		usage:       usage,
+		cache:       cache,
+		prompts:     prompts,
+		knowledge:   knowledge,
+		safety:      safety,
+		cacheStats:  make(map[string]domain.CortexCacheStats),
 	}
 }
 
-func (s *CortexService) Chat(ctx context.Context, userID uuid.UUID, message string) (string, error) {
+// SetTools lets Chat propose actions on the user's data (called from main.go once the services
+// backing the tools exist, since MealService itself depends on Cortex)
+func (s *CortexService) SetTools(tools *CortexToolRegistry) {
+	s.tools = tools
+}
+
+// provider returns the LLM configured for useCase, falling back to the default one
+func (s *CortexService) provider(useCase domain.LLMUseCase) ports.LLMProvider {
+	if p, ok := s.providers[useCase]; ok && p != nil {
+		return p
+	}
+	return s.llm
+}
+
+// Chat answers a one-off message, grounded in the knowledge base passages relevant to it. A
+// message showing risk signals is answered with the safe-response template instead of the coach.
+// With tools set, Chat can also propose actions, which only run once the user confirms them.
+func (s *CortexService) Chat(ctx context.Context, userID uuid.UUID, message string) (*domain.CortexAnswer, error) {
 	// 1. Fetch User Context
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch user: %w", err)
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	risk := s.safety.ScreenInput(ctx, user, domain.SafetySourceChat, message)
+
+	// 2. Fetch Fasting Context & Construct System Prompt
+	persona, err := s.chatPersona(ctx, user, risk)
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt, passages := s.ground(ctx, userID, message, persona.System)
+
+	// 3. Call LLM, offering the tools when they can be used
+	provider := s.userProvider(domain.LLMUseCaseChat, userID)
+	var response string
+	var calls []domain.LLMToolCall
+	toolPrompt := s.toolPrompt(userID, risk)
+	if toolProvider, ok := provider.(ports.ToolCallingLLMProvider); ok && toolPrompt != nil {
+		var reply *domain.LLMToolReply
+		reply, err = toolProvider.ConverseWithTools(ctx, systemPrompt+"\n\n"+toolPrompt.System,
+			[]domain.LLMMessage{{Role: domain.LLMRoleUser, Content: message}}, s.tools.Definitions())
+		if err == nil {
+			response, calls = reply.Text, reply.ToolCalls
+		}
+	} else {
+		response, err = provider.GenerateResponse(ctx, message, systemPrompt)
+	}
+	if err != nil {
+		if risk.Flagged() {
+			// Someone at risk gets an answer even when the model (or their budget) can't give one
+			logger.Warn().Err(err).Str("user_id", userID.String()).Msg("Answering flagged chat with the safety fallback")
+			return &domain.CortexAnswer{Text: domain.SafetyFallbackReply, Citations: []domain.KnowledgeCitation{}, SafetyMode: true, Actions: []domain.CortexAction{}}, nil
+		}
+		return nil, fmt.Errorf("llm error: %w", err)
+	}
+
+	s.prompts.RecordExposure(ctx, userID, persona)
+	response, replaced := s.screenReply(ctx, userID, domain.SafetySourceChat, response)
+	actions := []domain.CortexAction{}
+	if len(calls) > 0 && !replaced {
+		s.prompts.RecordExposure(ctx, userID, toolPrompt)
+		actions = append(actions, s.tools.Propose(ctx, userID, calls)...)
+		if strings.TrimSpace(response) == "" && len(actions) > 0 {
+			response = confirmationPrompt(actions)
+		}
+	}
+	answer := answerWithCitations(response, passages)
+	answer.SafetyMode = risk.Flagged() || replaced
+	answer.Actions = actions
+	return answer, nil
+}
+
+// toolPrompt returns the instructions for proposing actions, or nil when Chat doesn't offer
+// tools: none are set, the chat provider has no function calling, or the user is at risk and
+// is not coached to fast, let alone have Cortex start one
+func (s *CortexService) toolPrompt(userID uuid.UUID, risk domain.SafetyAssessment) *domain.RenderedPrompt {
+	if s.tools == nil || risk.Flagged() || len(s.tools.Definitions()) == 0 {
+		return nil
+	}
+	if _, ok := s.provider(domain.LLMUseCaseChat).(ports.ToolCallingLLMProvider); !ok {
+		return nil
+	}
+	prompt, err := s.prompts.Render(promptToolUse, userID, domain.PromptVars{})
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to render cortex tool prompt")
+		return nil
+	}
+	return prompt
+}
+
+// chatPersona is the coach persona, or the safe-response template when risk was found
+func (s *CortexService) chatPersona(ctx context.Context, user *domain.User, risk domain.SafetyAssessment) (*domain.RenderedPrompt, error) {
+	if risk.Flagged() {
+		return s.prompts.Render(promptSafetyResponse, user.ID, domain.PromptVars{"signals": describeSafetySignals(risk.Signals)})
+	}
+	return s.coachPersona(ctx, user)
+}
+
+// screenReply swaps a reply that encourages something dangerous for the safety fallback
+func (s *CortexService) screenReply(ctx context.Context, userID uuid.UUID, source domain.SafetySource, reply string) (string, bool) {
+	if s.safety.ScreenReply(ctx, userID, source, reply).Flagged() {
+		return domain.SafetyFallbackReply, true
 	}
+	return reply, false
+}
 
-	// 2. Fetch Fasting Context
-	activeFast, err := s.fastingRepo.FindActiveByUserID(ctx, userID)
+// coachPersona renders the Cortex persona shared by one-off and threaded chat
+func (s *CortexService) coachPersona(ctx context.Context, user *domain.User) (*domain.RenderedPrompt, error) {
+	activeFast, _ := s.fastingRepo.FindActiveByUserID(ctx, user.ID)
 	isFasting := activeFast != nil
 	fastingDuration := ""
 	if isFasting {
@@ -43,126 +187,168 @@ func (s *CortexService) Chat(ctx context.Context, userID uuid.UUID, message stri
 		fastingDuration = "not currently fasting"
 	}
 
-	// 3. Construct System Prompt
-	systemPrompt := fmt.Sprintf(`You are Cortex, a ruthless but fair AI fasting coach. 
-	The user has a Discipline Index of %.1f/100. 
-	Current Status: %s.
-	
-	Your goal is to motivate them to stay on track with their fasting goals. 
-	If their discipline is low, be tougher. If high, be encouraging but demanding.
-	Keep responses concise (under 50 words) and impactful. Do not be polite. Be effective.`,
-		user.DisciplineIndex, fastingDuration)
-
-	// 4. Call LLM
-	response, err := s.llm.GenerateResponse(ctx, message, systemPrompt)
-	if err != nil {
-		return "", fmt.Errorf("llm error: %w", err)
-	}
-
-	return response, nil
+	return s.prompts.Render(promptCoachPersona, user.ID, domain.PromptVars{
+		"discipline_index": user.DisciplineIndex,
+		"fasting_status":   fastingDuration,
+	})
 }
 
-func (s *CortexService) GenerateInsight(ctx context.Context, userID uuid.UUID, fastingHours float64) (string, error) {
+// GenerateInsight describes what is happening in the body, grounded in the knowledge base
+// passages about the user's current milestone
+func (s *CortexService) GenerateInsight(ctx context.Context, userID uuid.UUID, fastingHours float64) (*domain.CortexAnswer, error) {
 	// 1. Fetch User Context (optional, but good for personalization)
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch user: %w", err)
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
 	}
 
-	// 2. Construct System Prompt
-	systemPrompt := fmt.Sprintf(`You are a biological narrator for a fasting app. 
-	The user has been fasting for %.1f hours. 
-	User Discipline Index: %.1f/100.
-	
-	Your task is to describe the physiological processes happening right now (e.g., autophagy, ketosis, glycogen depletion).
-	Be scientific but motivating. 
-	Output format: A single concise paragraph. Max 50 words.`,
-		fastingHours, user.DisciplineIndex)
+	// 2. Construct Prompt
+	// The user message is generic since the system prompt contains all context
+	prompt, err := s.prompts.Render(promptFastingInsight, userID, domain.PromptVars{
+		"fasting_hours":    fastingHours,
+		"discipline_index": user.DisciplineIndex,
+	})
+	if err != nil {
+		return nil, err
+	}
+	userMessage := prompt.User
+	// Only the profile can raise risk here; a user at risk isn't narrated deeper into the fast
+	risk := s.safety.ScreenInput(ctx, user, domain.SafetySourceInsight, "")
+	if risk.Flagged() {
+		if prompt, err = s.chatPersona(ctx, user, risk); err != nil {
+			return nil, err
+		}
+	}
+	systemPrompt, passages := s.ground(ctx, userID, getMilestone(fastingHours), prompt.System)
 
 	// 3. Call LLM
-	// We use a generic prompt for the user message since the system prompt contains all context
-	userMessage := "Describe my current biological status."
-	response, err := s.llm.GenerateResponse(ctx, userMessage, systemPrompt)
+	response, err := s.userProvider(domain.LLMUseCaseInsight, userID).GenerateResponse(ctx, userMessage, systemPrompt)
 	if err != nil {
-		return "", fmt.Errorf("llm error: %w", err)
+		return nil, fmt.Errorf("llm error: %w", err)
 	}
 
-	return response, nil
+	s.prompts.RecordExposure(ctx, userID, prompt)
+	response, replaced := s.screenReply(ctx, userID, domain.SafetySourceInsight, response)
+	answer := answerWithCitations(response, passages)
+	answer.SafetyMode = risk.Flagged() || replaced
+	return answer, nil
+}
+
+var mealAssessmentSchema = outputSchema{Properties: map[string]schemaProperty{
+	"analysis":        {Type: "string", Description: "Brief description of the food and its carb estimate"},
+	"net_carbs_grams": {Type: "number", Description: "Estimated net carbs in grams", Minimum: schemaBound(0)},
+	"authenticity": {Type: "string", Description: "Whether this looks like a real camera photo of food or a screen capture/fake",
+		Enum: []string{string(domain.MealAuthenticityVerified), string(domain.MealAuthenticitySuspicious)}},
+	"keto_friendly": {Type: "boolean", Description: "True when the meal is under 10g net carbs"},
+}}
+
+// fallbackMealAssessment is used when the model can't produce a valid assessment. Like a
+// failed analysis in MealService it is optimistic, so users aren't penalized for our outage.
+var fallbackMealAssessment = domain.MealAssessment{
+	Analysis:     "We couldn't analyze this meal automatically.",
+	Authenticity: domain.MealAuthenticityVerified,
+	KetoFriendly: true,
 }
 
 func (s *CortexService) AnalyzeMeal(ctx context.Context, imageBase64, description string) (string, bool, bool, error) {
 	// 1. Construct Prompt
 	// Since DeepSeek V2 is text-only, we rely heavily on the user's description for now.
 	// In a real multimodal scenario, the image would be primary.
-	prompt := fmt.Sprintf(`Analyze this meal based on the user's description: "%s".
-	1. Is this a real photo of food taken by a camera, or does it look like a screen capture/fake? (Authenticity)
-	2. Estimate the carb content based on the description. Is it Keto-friendly (under 10g net carbs)?
-	
-	Output format:
-	Analysis: [Brief description of food and carb estimate]
-	Authenticity: [Verified/Suspicious]
-	Keto-Friendly: [Yes/No]
-	`, description)
+	// Meals aren't attributed to a user here, so everyone gets the same variant
+	rendered, err := s.prompts.Render(promptMealAssessment, uuid.Nil, domain.PromptVars{"description": description})
+	if err != nil {
+		return "", false, false, err
+	}
+	prompt := rendered.User + "\n\n" + mealAssessmentSchema.instructions()
 
 	// 2. Call LLM
 	// We pass the image still, in case the adapter supports it or for future proofing
-	response, err := s.llm.AnalyzeImage(ctx, imageBase64, prompt)
-	if err != nil {
+	provider := s.provider(domain.LLMUseCaseMealVision)
+	var assessment domain.MealAssessment
+	err = generateStructured(ctx, mealAssessmentSchema, &assessment, func(ctx context.Context, feedback string) (string, error) {
+		return provider.AnalyzeImage(ctx, imageBase64, prompt+feedback)
+	})
+	if errors.Is(err, errMalformedOutput) {
+		logger.Warn().Err(err).Msg("Falling back to default meal assessment")
+		assessment = fallbackMealAssessment
+	} else if err != nil {
 		return "", false, false, fmt.Errorf("llm error: %w", err)
 	}
 
-	// 3. Parse Response (Simple string parsing for MVP)
-	isAuthentic := true
-	isKeto := true
-
-	// Basic parsing logic
-	lowerResp := strings.ToLower(response)
-	if strings.Contains(lowerResp, "keto-friendly: no") {
-		isKeto = false
-	}
-	if strings.Contains(lowerResp, "authenticity: suspicious") || strings.Contains(lowerResp, "fake") {
-		isAuthentic = false
-	}
-
-	return response, isAuthentic, isKeto, nil
+	isAuthentic := assessment.Authenticity == domain.MealAuthenticityVerified
+	return assessment.Analysis, isAuthentic, assessment.KetoFriendly, nil
 }
 
-// GetFastingMilestoneInsight returns structured insight based on fasting duration
+var milestoneInsightSchema = outputSchema{Properties: map[string]schemaProperty{
+	"insight":    {Type: "string", Description: "2-3 sentence description of what's happening in the body now"},
+	"benefits":   {Type: "array", Description: "Benefits of this milestone, a few words each", Items: &schemaProperty{Type: "string"}, MinItems: 1, MaxItems: 5},
+	"motivation": {Type: "string", Description: "One powerful motivational line (under 15 words)"},
+}}
+
+// GetFastingMilestoneInsight returns structured insight based on fasting duration. The insight
+// isn't personal, so everyone reaching a milestone shares one cached answer.
 func (s *CortexService) GetFastingMilestoneInsight(ctx context.Context, userID uuid.UUID, hours float64) (map[string]interface{}, error) {
 	// Determine milestone
 	milestone := getMilestone(hours)
+	result := map[string]interface{}{
+		"hours":     hours,
+		"milestone": milestone,
+	}
 
-	// Construct prompt for structured response
-	systemPrompt := `You are a fasting science expert. Provide insights about fasting in JSON format.
-	Be scientific but motivating. Keep each field concise.`
+	// The shared answer coaches deeper into the fast, so a user at risk gets the safe reply instead
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if s.safety.ScreenInput(ctx, user, domain.SafetySourceInsight, "").Flagged() {
+		result["safety_mode"] = true
+		return withMilestoneInsight(result, domain.MilestoneInsight{Insight: domain.SafetyFallbackReply, Benefits: []string{}}), nil
+	}
 
-	userMessage := fmt.Sprintf(`The user has been fasting for %.1f hours (milestone: %s).
-	Provide a JSON response with:
-	{
-		"insight": "2-3 sentence description of what's happening in the body now",
-		"benefits": ["benefit1", "benefit2", "benefit3"],
-		"motivation": "One powerful motivational quote (under 15 words)"
+	// Construct prompt for structured response. Each variant caches its own answer.
+	prompt, err := s.prompts.Render(promptMilestoneInsight, userID, domain.PromptVars{"milestone": milestone})
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt := prompt.System + "\n\n" + milestoneInsightSchema.instructions()
+	userMessage := prompt.User
+
+	cacheKey := fmt.Sprintf("%s:%s:v%d:%s", cacheFeatureMilestoneInsight, milestone, prompt.Version, prompt.Variant)
+	var insight domain.MilestoneInsight
+	if cached, ok := s.cached(ctx, cacheFeatureMilestoneInsight, cacheKey); ok && json.Unmarshal([]byte(cached), &insight) == nil {
+		s.prompts.RecordExposure(ctx, userID, prompt)
+		return withMilestoneInsight(result, insight), nil
 	}
-	
-	Focus on the science at this milestone. Be specific about biological processes.`, hours, milestone)
 
 	// Call LLM
-	response, err := s.llm.GenerateResponse(ctx, userMessage, systemPrompt)
-	if err != nil {
+	provider := s.userProvider(domain.LLMUseCaseInsight, userID)
+	err = generateStructured(ctx, milestoneInsightSchema, &insight, func(ctx context.Context, feedback string) (string, error) {
+		return provider.GenerateResponse(ctx, userMessage+feedback, systemPrompt)
+	})
+	if errors.Is(err, errMalformedOutput) {
+		logger.Warn().Err(err).Str("milestone", milestone).Msg("Falling back to default milestone insight")
+		return withMilestoneInsight(result, fallbackMilestoneInsight(hours, milestone)), nil
+	} else if err != nil {
 		return nil, fmt.Errorf("llm error: %w", err)
 	}
+	// Everyone at this milestone would get a cached unsafe insight, so it is neither shown nor cached
+	advised := strings.Join(append([]string{insight.Insight, insight.Motivation}, insight.Benefits...), "\n")
+	if s.safety.ScreenReply(ctx, userID, domain.SafetySourceInsight, advised).Flagged() {
+		return withMilestoneInsight(result, fallbackMilestoneInsight(hours, milestone)), nil
+	}
 
-	// For MVP, return a structured response manually parsed
-	// In production, you'd parse the JSON response from DeepSeek
-	result := map[string]interface{}{
-		"hours":      hours,
-		"milestone":  milestone,
-		"insight":    response,
-		"benefits":   extractBenefits(response, milestone),
-		"motivation": extractMotivation(response),
+	if encoded, err := json.Marshal(insight); err == nil {
+		s.storeCached(ctx, cacheFeatureMilestoneInsight, cacheKey, string(encoded), milestoneInsightTTL)
 	}
+	s.prompts.RecordExposure(ctx, userID, prompt)
+	return withMilestoneInsight(result, insight), nil
+}
 
-	return result, nil
+func withMilestoneInsight(result map[string]interface{}, insight domain.MilestoneInsight) map[string]interface{} {
+	result["insight"] = insight.Insight
+	result["benefits"] = insight.Benefits
+	result["motivation"] = insight.Motivation
+	return result
 }
 
 // getMilestone identifies the fasting milestone
@@ -187,10 +373,17 @@ func getMilestone(hours float64) string {
 	}
 }
 
-// extractBenefits extracts or generates benefits based on milestone
-func extractBenefits(response string, milestone string) []string {
-	// For MVP, return milestone-specific benefits
-	// In production, parse from AI response
+// fallbackMilestoneInsight is used when the model can't produce a valid insight
+func fallbackMilestoneInsight(hours float64, milestone string) domain.MilestoneInsight {
+	return domain.MilestoneInsight{
+		Insight:    fmt.Sprintf("You're %.1f hours in (%s). Your body is drawing on its reserves and adapting to the fast.", hours, milestone),
+		Benefits:   milestoneBenefits(milestone),
+		Motivation: "Every hour fasting is a victory for your health!",
+	}
+}
+
+// milestoneBenefits returns the well-known benefits of a milestone
+func milestoneBenefits(milestone string) []string {
 	benefitsMap := map[string][]string{
 		"4h - Glycogen Depletion": {"Insulin levels dropping", "Starting fat burning", "Digestive rest"},
 		"8h - Fat Adaptation":     {"Increased fat oxidation", "Stable energy", "Mental clarity"},
@@ -206,22 +399,6 @@ func extractBenefits(response string, milestone string) []string {
 	return []string{"Fat burning", "Mental clarity", "Cellular repair"}
 }
 
-// extractMotivation generates motivational message
-func extractMotivation(response string) string {
-	// For MVP, return generic but try to extract from response
-	// In production, parse from AI response
-	if strings.Contains(strings.ToLower(response), "keep") || strings.Contains(strings.ToLower(response), "you") {
-		// Try to find a motivational sentence in the response
-		sentences := strings.Split(response, ".")
-		for _, sentence := range sentences {
-			if len(strings.TrimSpace(sentence)) > 10 && len(strings.TrimSpace(sentence)) < 100 {
-				return strings.TrimSpace(sentence)
-			}
-		}
-	}
-	return "Every hour fasting is a victory for your health!"
-}
-
 // CravingResponse contains structured help for hunger cravings
 type CravingResponse struct {
 	ImmediateAction   string   `json:"immediate_action"`
@@ -230,8 +407,16 @@ type CravingResponse struct {
 	Motivation        string   `json:"motivation"`
 	TimeRemaining     string   `json:"time_remaining,omitempty"`
 	SupportStrategies []string `json:"support_strategies"`
+	SafetyMode        bool     `json:"safety_mode,omitempty"` // Safe response for a user at risk
 }
 
+var cravingAdviceSchema = outputSchema{Properties: map[string]schemaProperty{
+	"immediate_action": {Type: "string", Description: "One 20-second action they can do RIGHT NOW"},
+	"distraction_idea": {Type: "string", Description: "One 5-minute activity to redirect their focus"},
+	"biological_fact":  {Type: "string", Description: "One biological fact about what's happening in their body at this stage"},
+	"motivation":       {Type: "string", Description: "Powerful one-liner under 15 words"},
+}}
+
 // GetCravingHelp provides personalized support for hunger cravings
 func (s *CortexService) GetCravingHelp(ctx context.Context, userID uuid.UUID, cravingDescription string) (interface{}, error) {
 	// 1. Fetch user context
@@ -239,6 +424,9 @@ func (s *CortexService) GetCravingHelp(ctx context.Context, userID uuid.UUID, cr
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch user: %w", err)
 	}
+	if s.safety.ScreenInput(ctx, user, domain.SafetySourceCraving, cravingDescription).Flagged() {
+		return safeCravingResponse(), nil
+	}
 
 	// 2. Fetch active fast context
 	activeFast, _ := s.fastingRepo.FindActiveByUserID(ctx, userID)
@@ -255,25 +443,27 @@ func (s *CortexService) GetCravingHelp(ctx context.Context, userID uuid.UUID, cr
 	hoursRemaining := float64(activeFast.GoalHours) - fastDuration
 
 	// 4. Construct AI prompt
-	systemPrompt := fmt.Sprintf(`You are an emergency fasting coach. The user is %.1f hours into a %d-hour fast and experiencing cravings.
-Discipline Score: %.1f/100
-Craving: %s
-
-Respond in this EXACT format:
-IMMEDIATE: [One 20-second action they can do RIGHT NOW]
-DISTRACTION: [One 5-minute activity to redirect their focus]
-SCIENCE: [One biological fact about what's happening in their body at this stage]
-MOTIVATION: [Powerful one-liner under 15 words]
-
-Be firm, direct, and supportive. No fluff. Total response under 100 words.`,
-		fastDuration, activeFast.GoalHours, user.DisciplineIndex, cravingDescription)
-
-	userMessage := "Help me fight this craving."
+	prompt, err := s.prompts.Render(promptCravingHelp, userID, domain.PromptVars{
+		"fasting_hours":    fastDuration,
+		"goal_hours":       activeFast.GoalHours,
+		"discipline_index": user.DisciplineIndex,
+		"craving":          cravingDescription,
+	})
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt := prompt.System + "\n\n" + cravingAdviceSchema.instructions()
+	userMessage := prompt.User
 
 	// 5. Call LLM
-	response, err := s.llm.GenerateResponse(ctx, userMessage, systemPrompt)
+	provider := s.userProvider(domain.LLMUseCaseChat, userID)
+	var advice domain.CravingAdvice
+	err = generateStructured(ctx, cravingAdviceSchema, &advice, func(ctx context.Context, feedback string) (string, error) {
+		return provider.GenerateResponse(ctx, userMessage+feedback, systemPrompt)
+	})
 	if err != nil {
 		// Fallback response if AI fails
+		logger.Warn().Err(err).Str("user_id", userID.String()).Msg("Falling back to default craving help")
 		return &CravingResponse{
 			ImmediateAction:   "Drink 16oz of water RIGHT NOW. Set a 5-minute timer.",
 			DistractionIdea:   "Take a brisk 5-minute walk or do 20 pushups.",
@@ -284,42 +474,68 @@ Be firm, direct, and supportive. No fluff. Total response under 100 words.`,
 		}, nil
 	}
 
-	// 6. Parse AI response
-	cravingResp := parseCravingResponse(response, fastDuration, hoursRemaining)
-
-	return cravingResp, nil
-}
-
-// parseCravingResponse extracts structured data from AI response
-func parseCravingResponse(aiResponse string, fastDuration, hoursRemaining float64) *CravingResponse {
-	lines := strings.Split(aiResponse, "\n")
-	resp := &CravingResponse{
+	s.prompts.RecordExposure(ctx, userID, prompt)
+	advised := strings.Join([]string{advice.ImmediateAction, advice.DistractionIdea, advice.BiologicalFact, advice.Motivation}, "\n")
+	if s.safety.ScreenReply(ctx, userID, domain.SafetySourceCraving, advised).Flagged() {
+		return safeCravingResponse(), nil
+	}
+	return &CravingResponse{
+		ImmediateAction:   advice.ImmediateAction,
+		DistractionIdea:   advice.DistractionIdea,
+		BiologicalFact:    advice.BiologicalFact,
+		Motivation:        advice.Motivation,
 		TimeRemaining:     fmt.Sprintf("%.1f hours until your goal", hoursRemaining),
 		SupportStrategies: []string{"Drink water", "5-minute walk", "Deep breathing"},
+	}, nil
+}
+
+var weeklyInsightSchema = outputSchema{Properties: map[string]schemaProperty{
+	"analysis":         {Type: "string", Description: "2-3 sentence analysis of their performance"},
+	"improvement_area": {Type: "string", Description: "One specific, actionable improvement for next week"},
+	"encouragement":    {Type: "string", Description: "One encouraging observation"},
+	"discipline_trend": {Type: "string", Description: "Direction of their discipline this week",
+		Enum: []string{string(domain.DisciplineTrendImproving), string(domain.DisciplineTrendSteady), string(domain.DisciplineTrendDeclining)}},
+	"next_week_fasts_estimate": {Type: "integer", Description: "Realistic number of fasts they will complete next week", Minimum: schemaBound(0), Maximum: schemaBound(14)},
+}}
+
+// AnalyzeWeek writes the commentary for a weekly progress report. Unlike the other features it
+// has no fallback of its own: errors, including malformed output and a reply that fails the
+// safety screen, are for the caller to handle. A user at risk gets safe commentary with
+// SafetyMode set instead.
+func (s *CortexService) AnalyzeWeek(ctx context.Context, userID uuid.UUID, week domain.WeekSummary) (*domain.WeeklyInsight, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if s.safety.ScreenInput(ctx, user, domain.SafetySourceWeeklyReport, "").Flagged() {
+		return &domain.WeeklyInsight{Analysis: domain.SafetyFallbackReply, DisciplineTrend: domain.DisciplineTrendSteady, SafetyMode: true}, nil
 	}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "IMMEDIATE:") {
-			resp.ImmediateAction = strings.TrimSpace(strings.TrimPrefix(line, "IMMEDIATE:"))
-		} else if strings.HasPrefix(line, "DISTRACTION:") {
-			resp.DistractionIdea = strings.TrimSpace(strings.TrimPrefix(line, "DISTRACTION:"))
-		} else if strings.HasPrefix(line, "SCIENCE:") {
-			resp.BiologicalFact = strings.TrimSpace(strings.TrimPrefix(line, "SCIENCE:"))
-		} else if strings.HasPrefix(line, "MOTIVATION:") {
-			resp.Motivation = strings.TrimSpace(strings.TrimPrefix(line, "MOTIVATION:"))
-		}
+	prompt, err := s.prompts.Render(promptWeeklyInsight, userID, domain.PromptVars{
+		"fasts_completed":  week.FastsCompleted,
+		"average_duration": week.AverageDuration,
+		"longest_fast":     week.LongestFast,
+		"discipline_index": week.DisciplineIndex,
+	})
+	if err != nil {
+		return nil, err
 	}
+	systemPrompt := prompt.System + "\n\n" + weeklyInsightSchema.instructions()
 
-	// Fallbacks if parsing fails
-	if resp.ImmediateAction == "" {
-		resp.ImmediateAction = "Drink 16oz water immediately and wait 5 minutes."
+	provider := s.userProvider(domain.LLMUseCaseInsight, userID)
+	var insight domain.WeeklyInsight
+	err = generateStructured(ctx, weeklyInsightSchema, &insight, func(ctx context.Context, feedback string) (string, error) {
+		return provider.GenerateResponse(ctx, prompt.User+feedback, systemPrompt)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("llm error: %w", err)
 	}
-	if resp.Motivation == "" {
-		resp.Motivation = "You're stronger than this."
+	s.prompts.RecordExposure(ctx, userID, prompt)
+	advised := strings.Join([]string{insight.Analysis, insight.ImprovementArea, insight.Encouragement}, "\n")
+	if s.safety.ScreenReply(ctx, userID, domain.SafetySourceWeeklyReport, advised).Flagged() {
+		return nil, errUnsafeCortexReply
 	}
-
-	return resp
+	return &insight, nil
 }
 
 // BreakFastGuide contains recommendations for breaking a fast
@@ -393,13 +609,17 @@ func (s *CortexService) GetBreakFastRecommendations(ctx context.Context, userID
 
 // generateBreakFastAIGuidance creates personalized guidance
 func (s *CortexService) generateBreakFastAIGuidance(ctx context.Context, user *domain.User, fastDuration float64) string {
-	prompt := fmt.Sprintf(`User completed a %.1f-hour fast. Provide ONE specific, actionable tip for breaking this fast safely.
-	
-Keep it under 25 words. Be science-based and practical. Focus on what to eat first or what to avoid.`, fastDuration)
-
-	systemPrompt := "You are a fasting nutrition expert. Provide concise, science-based refeeding advice."
-
-	aiResponse, err := s.llm.GenerateResponse(ctx, prompt, systemPrompt)
+	if s.safety.ScreenInput(ctx, user, domain.SafetySourceBreakFast, "").Flagged() {
+		return domain.SafetyFallbackReply
+	}
+	var aiResponse string
+	prompt, err := s.prompts.Render(promptBreakFastTip, user.ID, domain.PromptVars{"fast_duration": fastDuration})
+	if err == nil {
+		aiResponse, err = s.userProvider(domain.LLMUseCaseInsight, user.ID).GenerateResponse(ctx, prompt.User, prompt.System)
+	}
+	if err == nil && s.safety.ScreenReply(ctx, user.ID, domain.SafetySourceBreakFast, aiResponse).Flagged() {
+		err = errUnsafeCortexReply
+	}
 
 	// Fallback
 	if err != nil || aiResponse == "" {
@@ -411,16 +631,27 @@ Keep it under 25 words. Be science-based and practical. Focus on what to eat fir
 		return "Extended fasts require careful refeeding. Start with broth, introduce solids gradually over 24-48 hours."
 	}
 
+	s.prompts.RecordExposure(ctx, user.ID, prompt)
 	return aiResponse
 }
 
-// GenerateDailyQuote creates personalized daily motivation
+// GenerateDailyQuote creates personalized daily motivation. Each user's quote is cached until
+// the end of the UTC day. A user at risk gets a fixed quote that doesn't encourage fasting.
 func (s *CortexService) GenerateDailyQuote(ctx context.Context, userID uuid.UUID) (string, error) {
-	// 1. Get user context
+	// 1. Get user context, screened before the cache so a quote from earlier today isn't repeated
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch user: %w", err)
 	}
+	if s.safety.ScreenInput(ctx, user, domain.SafetySourceDailyQuote, "").Flagged() {
+		return safeDailyQuote, nil
+	}
+
+	today := domain.CortexUsageDay(time.Now())
+	cacheKey := fmt.Sprintf("%s:%s:%s", cacheFeatureDailyQuote, userID, today.Format("2006-01-02"))
+	if quote, ok := s.cached(ctx, cacheFeatureDailyQuote, cacheKey); ok {
+		return quote, nil
+	}
 
 	// 2. Get recent fasting sessions
 	sessions, err := s.fastingRepo.FindByUserID(ctx, userID)
@@ -466,27 +697,26 @@ func (s *CortexService) GenerateDailyQuote(ctx context.Context, userID uuid.UUID
 	}
 
 	// 5. Construct AI prompt
-	prompt := fmt.Sprintf(`Create ONE powerful motivational quote for a fasting user.
-
-User Context:
-- Journey stage: %s (%s)
-- Completed fasts: %d
-- Total fasting hours: %.0f
-- Discipline: %.1f/100
-- Currently fasting: %v
-
-Requirements:
-- 15 words or less
-- Inspiring and specific to their journey
-- Not generic - reference their actual progress
-- Make them feel proud and motivated
-
-Just return the quote, nothing else.`, stage, context, completedFasts, totalHours, user.DisciplineIndex, isFasting)
-
-	systemPrompt := "You are a motivational fasting coach. Create powerful, personalized quotes that inspire action."
+	prompt, err := s.prompts.Render(promptDailyQuote, userID, domain.PromptVars{
+		"stage":            stage,
+		"stage_context":    context,
+		"completed_fasts":  completedFasts,
+		"total_hours":      totalHours,
+		"discipline_index": user.DisciplineIndex,
+		"is_fasting":       isFasting,
+	})
+	if err != nil {
+		return "", err
+	}
 
 	// 6. Generate quote
-	quote, err := s.llm.GenerateResponse(ctx, prompt, systemPrompt)
+	quote, err := s.userProvider(domain.LLMUseCaseInsight, userID).GenerateResponse(ctx, prompt.User, prompt.System)
+	if errors.Is(err, domain.ErrCortexQuotaExceeded) {
+		return "", err
+	}
+	if err == nil && s.safety.ScreenReply(ctx, userID, domain.SafetySourceDailyQuote, quote).Flagged() {
+		err = errUnsafeCortexReply // Answered with the fallback, and not cached
+	}
 	if err != nil || quote == "" {
 		// Fallback quotes based on stage
 		fallbackQuotes := map[string]string{
@@ -495,8 +725,39 @@ Just return the quote, nothing else.`, stage, context, completedFasts, totalHour
 			"committed":      fmt.Sprintf("%.0f hours of fasting mastered. You're building something remarkable.", totalHours),
 			"veteran":        fmt.Sprintf("%d fasts. You've proven your strength repeatedly. Keep leading.", completedFasts),
 		}
-		quote = fallbackQuotes[stage]
+		return fallbackQuotes[stage], nil
 	}
 
+	// Cache hits repeat this response, so the exposure is recorded once
+	s.storeCached(ctx, cacheFeatureDailyQuote, cacheKey, quote, time.Until(today.Add(24*time.Hour)))
+	s.prompts.RecordExposure(ctx, userID, prompt)
 	return quote, nil
 }
+
+// GenerateFromPrompt answers with the user's variant of a registered prompt template, for
+// services that coach through Cortex without a feature of their own here. These are background
+// jobs the user didn't ask for, so they don't count against the user's daily budget. A user the
+// safety guard flags gets errUserAtRisk and no prompt is sent.
+func (s *CortexService) GenerateFromPrompt(ctx context.Context, userID uuid.UUID, name string, vars domain.PromptVars) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if s.safety.ScreenInput(ctx, user, domain.SafetySourceNudge, "").Flagged() {
+		return "", errUserAtRisk
+	}
+	prompt, err := s.prompts.Render(name, userID, vars)
+	if err != nil {
+		return "", err
+	}
+	response, err := s.provider(domain.LLMUseCaseChat).GenerateResponse(ctx, prompt.User, prompt.System)
+	if err != nil {
+		return "", fmt.Errorf("llm error: %w", err)
+	}
+	s.prompts.RecordExposure(ctx, userID, prompt)
+	// Callers have fallbacks of their own, so an unsafe reply is dropped rather than replaced
+	if s.safety.ScreenReply(ctx, userID, domain.SafetySourceNudge, response).Flagged() {
+		return "", errUnsafeCortexReply
+	}
+	return response, nil
+}
@@ -3,13 +3,17 @@ package services
 import (
 	"context"
 	"errors"
+	"fastinghero/internal/adapters/secondary/llm"
 	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockLLMProvider is a mock of ports.LLMProvider
@@ -27,6 +31,11 @@ func (m *MockLLMProvider) AnalyzeImage(ctx context.Context, imageBase64, prompt
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockLLMProvider) Converse(ctx context.Context, systemPrompt string, messages []domain.LLMMessage) (string, error) {
+	args := m.Called(ctx, systemPrompt, messages)
+	return args.String(0), args.Error(1)
+}
+
 // ============== CHAT TESTS ==============
 
 func TestCortexService_Chat_Success(t *testing.T) {
@@ -34,7 +43,7 @@ func TestCortexService_Chat_Success(t *testing.T) {
 	mockFastingRepo := new(MockFastingRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -51,7 +60,7 @@ func TestCortexService_Chat_Success(t *testing.T) {
 	response, err := service.Chat(ctx, userID, "How can I stay motivated?")
 
 	assert.NoError(t, err)
-	assert.Contains(t, response, "helpful response")
+	assert.Contains(t, response.Text, "helpful response")
 }
 
 func TestCortexService_Chat_LLMError(t *testing.T) {
@@ -59,7 +68,7 @@ func TestCortexService_Chat_LLMError(t *testing.T) {
 	mockFastingRepo := new(MockFastingRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -72,7 +81,7 @@ func TestCortexService_Chat_LLMError(t *testing.T) {
 	response, err := service.Chat(ctx, userID, "Hello")
 
 	assert.Error(t, err)
-	assert.Empty(t, response)
+	assert.Nil(t, response)
 }
 
 // ============== GENERATE INSIGHT TESTS ==============
@@ -82,7 +91,7 @@ func TestCortexService_GenerateInsight_Success(t *testing.T) {
 	mockFastingRepo := new(MockFastingRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -98,7 +107,7 @@ func TestCortexService_GenerateInsight_Success(t *testing.T) {
 	insight, err := service.GenerateInsight(ctx, userID, 14.5)
 
 	assert.NoError(t, err)
-	assert.Contains(t, insight, "ketosis")
+	assert.Contains(t, insight.Text, "ketosis")
 }
 
 // ============== ANALYZE MEAL TESTS ==============
@@ -108,19 +117,19 @@ func TestCortexService_AnalyzeMeal_Success(t *testing.T) {
 	mockFastingRepo := new(MockFastingRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 
-	// Mock returns: analysis, isAuthentic, isKetoFriendly
-	mockLLM.On("AnalyzeImage", ctx, mock.Anything, mock.Anything).Return("This is a healthy keto meal with eggs and avocado. AUTHENTIC: true KETO: true", nil)
+	mockLLM.On("AnalyzeImage", ctx, "base64imagedata", mock.Anything).
+		Return("```json\n{\"analysis\":\"Eggs and avocado, about 3g net carbs\",\"net_carbs_grams\":3,\"authenticity\":\"suspicious\",\"keto_friendly\":true}\n```", nil)
 
 	analysis, isAuthentic, isKeto, err := service.AnalyzeMeal(ctx, "base64imagedata", "Eggs and avocado")
 
 	assert.NoError(t, err)
-	assert.NotEmpty(t, analysis)
-	// Note: actual values depend on parsing logic
-	_ = isAuthentic
-	_ = isKeto
+	assert.Equal(t, "Eggs and avocado, about 3g net carbs", analysis)
+	assert.False(t, isAuthentic)
+	assert.True(t, isKeto)
+	mockLLM.AssertNumberOfCalls(t, "AnalyzeImage", 1)
 }
 
 func TestCortexService_AnalyzeMeal_NoImage(t *testing.T) {
@@ -128,17 +137,123 @@ func TestCortexService_AnalyzeMeal_NoImage(t *testing.T) {
 	mockFastingRepo := new(MockFastingRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 
 	mockLLM.On("AnalyzeImage", ctx, "", mock.Anything).Return("Description-only analysis", nil)
 
 	analysis, isAuthentic, isKeto, err := service.AnalyzeMeal(ctx, "", "Just a salad")
 
+	// Free text fails the schema twice, so the typed fallback is used
 	assert.NoError(t, err)
-	assert.NotEmpty(t, analysis)
-	_ = isAuthentic
-	_ = isKeto
+	assert.Equal(t, fallbackMealAssessment.Analysis, analysis)
+	assert.True(t, isAuthentic)
+	assert.True(t, isKeto)
+	mockLLM.AssertNumberOfCalls(t, "AnalyzeImage", 2)
+}
+
+func TestCortexService_AnalyzeMeal_RepairsMalformedOutput(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	service := NewCortexService(mockLLM, new(MockFastingRepository), new(MockUserRepository), nil, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	mockLLM.On("AnalyzeImage", ctx, "img", mock.MatchedBy(func(p string) bool { return !strings.Contains(p, "could not be used") })).
+		Return(`{"analysis":"Pasta","authenticity":"verified","keto_friendly":"no"}`, nil).Once()
+	mockLLM.On("AnalyzeImage", ctx, "img", mock.MatchedBy(func(p string) bool { return strings.Contains(p, `field "keto_friendly" must be a boolean`) })).
+		Return(`{"analysis":"Pasta","net_carbs_grams":60,"authenticity":"verified","keto_friendly":false}`, nil).Once()
+
+	analysis, isAuthentic, isKeto, err := service.AnalyzeMeal(ctx, "img", "Pasta")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Pasta", analysis)
+	assert.True(t, isAuthentic)
+	assert.False(t, isKeto)
+	mockLLM.AssertExpectations(t)
+}
+
+func TestCortexService_AnalyzeMeal_ProviderError(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	service := NewCortexService(mockLLM, new(MockFastingRepository), new(MockUserRepository), nil, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	mockLLM.On("AnalyzeImage", ctx, "img", mock.Anything).Return("", errors.New("timeout"))
+
+	_, _, _, err := service.AnalyzeMeal(ctx, "img", "Pasta")
+
+	assert.Error(t, err)
+	mockLLM.AssertNumberOfCalls(t, "AnalyzeImage", 1)
+}
+
+// ============== PROVIDER ROUTING TESTS ==============
+
+func TestCortexService_RoutesUseCasesToConfiguredProviders(t *testing.T) {
+	defaultLLM := new(MockLLMProvider)
+	chatLLM := new(MockLLMProvider)
+	visionLLM := new(MockLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	service := NewCortexService(defaultLLM, mockFastingRepo, mockUserRepo, map[domain.LLMUseCase]ports.LLMProvider{
+		domain.LLMUseCaseChat:       chatLLM,
+		domain.LLMUseCaseMealVision: visionLLM,
+	}, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+	mockFastingRepo.On("FindActiveByUserID", ctx, userID).Return(nil, nil)
+	chatLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("from chat", nil)
+	visionLLM.On("AnalyzeImage", ctx, "img", mock.Anything).
+		Return(`{"analysis":"Eggs","net_carbs_grams":1,"authenticity":"verified","keto_friendly":true}`, nil)
+	defaultLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("from default", nil)
+
+	chat, err := service.Chat(ctx, userID, "Hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "from chat", chat.Text)
+
+	_, _, _, err = service.AnalyzeMeal(ctx, "img", "Eggs")
+	assert.NoError(t, err)
+
+	insight, err := service.GenerateInsight(ctx, userID, 16)
+	assert.NoError(t, err)
+	assert.Equal(t, "from default", insight.Text)
+
+	defaultLLM.AssertNotCalled(t, "AnalyzeImage", mock.Anything, mock.Anything, mock.Anything)
+	chatLLM.AssertNumberOfCalls(t, "GenerateResponse", 1)
+	visionLLM.AssertExpectations(t)
+}
+
+func TestCortexService_WithFakeProvider_IsDeterministic(t *testing.T) {
+	fake, err := llm.NewFakeProvider(llm.DefaultFakeScript)
+	assert.NoError(t, err)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	service := NewCortexService(fake, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID, DisciplineIndex: 60}, nil)
+	mockFastingRepo.On("FindActiveByUserID", ctx, userID).Return(nil, nil)
+
+	first, err := service.Chat(ctx, userID, "I want to quit")
+	assert.NoError(t, err)
+	second, _ := service.Chat(ctx, userID, "I want to quit")
+	insight, _ := service.GenerateInsight(ctx, userID, 18)
+	_, isAuthentic, isKeto, err := service.AnalyzeMeal(ctx, "", "Eggs and avocado")
+	assert.NoError(t, err)
+	milestone, err := service.GetFastingMilestoneInsight(ctx, userID, 16)
+	assert.NoError(t, err)
+	week, err := service.AnalyzeWeek(ctx, userID, domain.WeekSummary{FastsCompleted: 3})
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first.Text, insight.Text)
+	assert.True(t, isAuthentic)
+	assert.True(t, isKeto)
+	assert.Len(t, milestone["benefits"], 3)
+	assert.Equal(t, domain.DisciplineTrendImproving, week.DisciplineTrend)
+	// Scripted structured answers validate first time, without repair requests
+	assert.Len(t, fake.Calls(), 6)
 }
 
 // ============== GET FASTING MILESTONE INSIGHT TESTS ==============
@@ -148,20 +263,42 @@ func TestCortexService_GetFastingMilestoneInsight_Success(t *testing.T) {
 	mockFastingRepo := new(MockFastingRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
 	user := &domain.User{ID: userID, Name: "Test User"}
 
 	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.MatchedBy(func(p string) bool { return strings.Contains(p, `"benefits"`) })).
+		Return(`{"insight":"At 16 hours you're in ketosis.","benefits":["Fat burning","Autophagy"],"motivation":"Finish strong."}`, nil)
+
+	insight, err := service.GetFastingMilestoneInsight(ctx, userID, 16.0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "16h - Peak Ketosis", insight["milestone"])
+	assert.Equal(t, "At 16 hours you're in ketosis.", insight["insight"])
+	assert.Equal(t, []string{"Fat burning", "Autophagy"}, insight["benefits"])
+	assert.Equal(t, "Finish strong.", insight["motivation"])
+}
+
+func TestCortexService_GetFastingMilestoneInsight_FallsBackOnMalformedOutput(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	mockUserRepo := new(MockUserRepository)
+	service := NewCortexService(mockLLM, new(MockFastingRepository), mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
 	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("At 16 hours, you're entering ketosis. Your body is burning fat!", nil)
 
 	insight, err := service.GetFastingMilestoneInsight(ctx, userID, 16.0)
 
 	assert.NoError(t, err)
-	assert.NotNil(t, insight)
-	assert.NotEmpty(t, insight["milestone"])
+	assert.Equal(t, milestoneBenefits("16h - Peak Ketosis"), insight["benefits"])
+	assert.NotEmpty(t, insight["insight"])
+	assert.NotEmpty(t, insight["motivation"])
+	mockLLM.AssertNumberOfCalls(t, "GenerateResponse", 2)
 }
 
 // ============== GET CRAVING HELP TESTS ==============
@@ -171,7 +308,7 @@ func TestCortexService_GetCravingHelp_Success(t *testing.T) {
 	mockFastingRepo := new(MockFastingRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -187,12 +324,17 @@ func TestCortexService_GetCravingHelp_Success(t *testing.T) {
 
 	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
 	mockFastingRepo.On("FindActiveByUserID", ctx, userID).Return(activeFast, nil)
-	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("Drink a glass of water. This craving will pass in 15 minutes.", nil)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).
+		Return(`{"immediate_action":"Drink a glass of water.","distraction_idea":"Walk for five minutes.","biological_fact":"Ghrelin comes in waves.","motivation":"This passes."}`, nil)
 
 	help, err := service.GetCravingHelp(ctx, userID, "I really want pizza")
 
 	assert.NoError(t, err)
-	assert.NotNil(t, help)
+	require.IsType(t, &CravingResponse{}, help)
+	craving := help.(*CravingResponse)
+	assert.Equal(t, "Drink a glass of water.", craving.ImmediateAction)
+	assert.Equal(t, "Ghrelin comes in waves.", craving.BiologicalFact)
+	assert.Contains(t, craving.TimeRemaining, "hours until your goal")
 }
 
 func TestCortexService_GetCravingHelp_NoActiveFast(t *testing.T) {
@@ -200,7 +342,7 @@ func TestCortexService_GetCravingHelp_NoActiveFast(t *testing.T) {
 	mockFastingRepo := new(MockFastingRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -223,7 +365,7 @@ func TestCortexService_GetBreakFastRecommendations_ShortFast(t *testing.T) {
 	mockFastingRepo := new(MockFastingRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -244,7 +386,7 @@ func TestCortexService_GetBreakFastRecommendations_LongFast(t *testing.T) {
 	mockFastingRepo := new(MockFastingRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -267,7 +409,7 @@ func TestCortexService_GenerateDailyQuote_Success(t *testing.T) {
 	mockFastingRepo := new(MockFastingRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -311,28 +453,7 @@ func TestGetMilestone(t *testing.T) {
 	}
 }
 
-func TestExtractMotivation(t *testing.T) {
-	testCases := []struct {
-		response string
-		hasMotiv bool
-	}{
-		{"You're doing great! Keep it up!", true},
-		{"", false},
-	}
-
-	for _, tc := range testCases {
-		result := extractMotivation(tc.response)
-		if tc.hasMotiv {
-			assert.NotEmpty(t, result)
-		}
-	}
-}
-
-func TestExtractBenefits(t *testing.T) {
-	response := "Fat burning is accelerating. Autophagy is starting."
-	milestone := "Ketosis begins"
-
-	benefits := extractBenefits(response, milestone)
-
-	assert.NotEmpty(t, benefits)
+func TestMilestoneBenefits(t *testing.T) {
+	assert.Equal(t, []string{"Maximum fat burning", "Deep autophagy", "HGH boost"}, milestoneBenefits("16h - Peak Ketosis"))
+	assert.NotEmpty(t, milestoneBenefits("Ketosis begins"))
 }
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// errMalformedOutput means the model's reply still didn't match the schema after a repair attempt
+var errMalformedOutput = errors.New("llm returned malformed structured output")
+
+// maxRepairEcho bounds how much of a rejected reply is quoted back in the repair request
+const maxRepairEcho = 500
+
+// schemaProperty is one property of an outputSchema, described in JSON Schema terms
+type schemaProperty struct {
+	Type        string          `json:"type"` // string, number, integer, boolean or array
+	Description string          `json:"description,omitempty"`
+	Enum        []string        `json:"enum,omitempty"`
+	Items       *schemaProperty `json:"items,omitempty"`
+	MinItems    int             `json:"minItems,omitempty"`
+	MaxItems    int             `json:"maxItems,omitempty"`
+	Minimum     *float64        `json:"minimum,omitempty"`
+	Maximum     *float64        `json:"maximum,omitempty"`
+}
+
+// outputSchema is the JSON object a structured prompt asks for. Every property is required,
+// strings must not be blank and no other properties are allowed.
+type outputSchema struct {
+	Properties map[string]schemaProperty
+}
+
+func schemaBound(v float64) *float64 {
+	return &v
+}
+
+// instructions tells the model to answer with the schema's JSON object and nothing else
+func (s outputSchema) instructions() string {
+	schema, _ := json.Marshal(map[string]interface{}{
+		"type":                 "object",
+		"properties":           s.Properties,
+		"required":             s.required(),
+		"additionalProperties": false,
+	})
+	return "Respond with only a JSON object, without prose or code fences, matching this JSON Schema:\n" + string(schema)
+}
+
+func (s outputSchema) required() []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// decode validates reply against the schema and strictly decodes it into out. Replies that
+// wrap the object in a code fence or a sentence are accepted; anything else is an error.
+func (s outputSchema) decode(reply string, out interface{}) error {
+	object, err := extractJSONObject(reply)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(object, &fields); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	if err := s.validate(fields); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(object))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	return nil
+}
+
+func (s outputSchema) validate(fields map[string]json.RawMessage) error {
+	for name := range fields {
+		if _, ok := s.Properties[name]; !ok {
+			return fmt.Errorf("unexpected field %q", name)
+		}
+	}
+	for _, name := range s.required() {
+		value, ok := fields[name]
+		if !ok || string(value) == "null" {
+			return fmt.Errorf("missing required field %q", name)
+		}
+		if err := s.Properties[name].validate(value); err != nil {
+			return fmt.Errorf("field %q %v", name, err)
+		}
+	}
+	return nil
+}
+
+func (p schemaProperty) validate(value json.RawMessage) error {
+	switch p.Type {
+	case "string":
+		var str string
+		if err := json.Unmarshal(value, &str); err != nil {
+			return errors.New("must be a string")
+		}
+		if strings.TrimSpace(str) == "" {
+			return errors.New("must not be empty")
+		}
+		if len(p.Enum) > 0 && !containsString(p.Enum, str) {
+			return fmt.Errorf("must be one of %s", strings.Join(p.Enum, ", "))
+		}
+	case "number", "integer":
+		var number float64
+		if err := json.Unmarshal(value, &number); err != nil {
+			return fmt.Errorf("must be a %s", p.Type)
+		}
+		if p.Type == "integer" && number != math.Trunc(number) {
+			return errors.New("must be an integer")
+		}
+		if p.Minimum != nil && number < *p.Minimum {
+			return fmt.Errorf("must be at least %v", *p.Minimum)
+		}
+		if p.Maximum != nil && number > *p.Maximum {
+			return fmt.Errorf("must be at most %v", *p.Maximum)
+		}
+	case "boolean":
+		var b bool
+		if err := json.Unmarshal(value, &b); err != nil {
+			return errors.New("must be a boolean")
+		}
+	case "array":
+		var items []json.RawMessage
+		if err := json.Unmarshal(value, &items); err != nil {
+			return errors.New("must be an array")
+		}
+		if len(items) < p.MinItems {
+			return fmt.Errorf("must have at least %d items", p.MinItems)
+		}
+		if p.MaxItems > 0 && len(items) > p.MaxItems {
+			return fmt.Errorf("must have at most %d items", p.MaxItems)
+		}
+		if p.Items != nil {
+			for i, item := range items {
+				if err := p.Items.validate(item); err != nil {
+					return fmt.Errorf("item %d %v", i, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// extractJSONObject returns the outermost {...} in reply
+func extractJSONObject(reply string) ([]byte, error) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("reply contains no JSON object")
+	}
+	return []byte(reply[start : end+1]), nil
+}
+
+// generateStructured asks the model for schema's JSON object and decodes it into out. generate
+// sends the prompt, which must include schema.instructions(); feedback is empty on the first
+// attempt. A reply that doesn't validate is retried once with feedback describing the problem
+// so the model can repair it. Provider errors are returned unchanged and a reply that still
+// doesn't validate wraps errMalformedOutput, so callers can fall back to canned output.
+func generateStructured(ctx context.Context, schema outputSchema, out interface{}, generate func(ctx context.Context, feedback string) (string, error)) error {
+	reply, err := generate(ctx, "")
+	if err != nil {
+		return err
+	}
+	decodeErr := schema.decode(reply, out)
+	if decodeErr == nil {
+		return nil
+	}
+
+	echo := reply
+	if len(echo) > maxRepairEcho {
+		echo = echo[:maxRepairEcho] + "…"
+	}
+	feedback := fmt.Sprintf("\n\nYour previous reply could not be used: %v.\nPrevious reply: %s\nReply again with only the corrected JSON object.", decodeErr, echo)
+	reply, err = generate(ctx, feedback)
+	if err != nil {
+		return err
+	}
+	if decodeErr = schema.decode(reply, out); decodeErr != nil {
+		return fmt.Errorf("%w: %v", errMalformedOutput, decodeErr)
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
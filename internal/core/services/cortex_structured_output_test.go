@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOutput struct {
+	Name  string   `json:"name"`
+	Mood  string   `json:"mood"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags"`
+}
+
+var testOutputSchema = outputSchema{Properties: map[string]schemaProperty{
+	"name":  {Type: "string"},
+	"mood":  {Type: "string", Enum: []string{"good", "bad"}},
+	"count": {Type: "integer", Minimum: schemaBound(0), Maximum: schemaBound(10)},
+	"tags":  {Type: "array", Items: &schemaProperty{Type: "string"}, MinItems: 1, MaxItems: 2},
+}}
+
+func TestOutputSchema_Decode(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		wantErr string
+	}{
+		{"plain object", `{"name":"a","mood":"good","count":2,"tags":["x"]}`, ""},
+		{"wrapped in a fence and prose", "Here you go:\n```json\n{\"name\":\"a\",\"mood\":\"bad\",\"count\":0,\"tags\":[\"x\",\"y\"]}\n```", ""},
+		{"no object", "I think it's good", "reply contains no JSON object"},
+		{"broken JSON", `{"name":"a",}`, "invalid JSON"},
+		{"missing field", `{"name":"a","mood":"good","tags":["x"]}`, `missing required field "count"`},
+		{"null field", `{"name":null,"mood":"good","count":1,"tags":["x"]}`, `missing required field "name"`},
+		{"unknown field", `{"name":"a","mood":"good","count":1,"tags":["x"],"extra":true}`, `unexpected field "extra"`},
+		{"blank string", `{"name":" ","mood":"good","count":1,"tags":["x"]}`, `field "name" must not be empty`},
+		{"enum", `{"name":"a","mood":"meh","count":1,"tags":["x"]}`, `field "mood" must be one of good, bad`},
+		{"wrong type", `{"name":"a","mood":"good","count":"1","tags":["x"]}`, `field "count" must be a integer`},
+		{"fraction", `{"name":"a","mood":"good","count":1.5,"tags":["x"]}`, `field "count" must be an integer`},
+		{"above maximum", `{"name":"a","mood":"good","count":11,"tags":["x"]}`, `field "count" must be at most 10`},
+		{"too many items", `{"name":"a","mood":"good","count":1,"tags":["x","y","z"]}`, `field "tags" must have at most 2 items`},
+		{"bad item", `{"name":"a","mood":"good","count":1,"tags":[3]}`, `field "tags" item 0 must be a string`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out testOutput
+			err := testOutputSchema.decode(tt.reply, &out)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.Equal(t, "a", out.Name)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOutputSchema_Instructions(t *testing.T) {
+	instructions := testOutputSchema.instructions()
+
+	assert.Contains(t, instructions, `"required":["count","mood","name","tags"]`)
+	assert.Contains(t, instructions, `"additionalProperties":false`)
+	assert.Equal(t, instructions, testOutputSchema.instructions(), "prompts must be stable for record/replay")
+}
+
+func TestGenerateStructured_RepairsOnce(t *testing.T) {
+	var feedbacks []string
+	replies := []string{`{"name":"a","mood":"fine","count":1,"tags":["x"]}`, `{"name":"a","mood":"good","count":1,"tags":["x"]}`}
+
+	var out testOutput
+	err := generateStructured(context.Background(), testOutputSchema, &out, func(ctx context.Context, feedback string) (string, error) {
+		feedbacks = append(feedbacks, feedback)
+		return replies[len(feedbacks)-1], nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "good", out.Mood)
+	require.Len(t, feedbacks, 2)
+	assert.Empty(t, feedbacks[0])
+	assert.Contains(t, feedbacks[1], `field "mood" must be one of good, bad`)
+	assert.Contains(t, feedbacks[1], `"mood":"fine"`)
+}
+
+func TestGenerateStructured_GivesUpAfterRepair(t *testing.T) {
+	calls := 0
+	var out testOutput
+	err := generateStructured(context.Background(), testOutputSchema, &out, func(ctx context.Context, feedback string) (string, error) {
+		calls++
+		return "Sorry, I can't do JSON.", nil
+	})
+
+	assert.ErrorIs(t, err, errMalformedOutput)
+	assert.Equal(t, 2, calls)
+}
+
+func TestGenerateStructured_ProviderErrorIsNotRetried(t *testing.T) {
+	timeout := errors.New("timeout")
+	calls := 0
+	var out testOutput
+	err := generateStructured(context.Background(), testOutputSchema, &out, func(ctx context.Context, feedback string) (string, error) {
+		calls++
+		return "", timeout
+	})
+
+	assert.ErrorIs(t, err, timeout)
+	assert.NotErrorIs(t, err, errMalformedOutput)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var errCortexThreadsDisabled = errors.New("cortex conversations are not enabled")
+
+// SendMessage adds message to the thread, or starts a new thread when threadID is nil, and
+// answers it with the coach persona. The LLM sees the thread summary plus the last
+// domain.CortexHistoryWindow messages, so the prompt stays bounded however long the thread gets.
+func (s *CortexService) SendMessage(ctx context.Context, userID uuid.UUID, threadID *uuid.UUID, message string) (*domain.CortexReply, error) {
+	return s.replyInThread(ctx, userID, threadID, message, nil)
+}
+
+// StreamMessage is SendMessage delivering the reply through onDelta as it is generated, a
+// sentence at a time so each one is screened before the client sees it. A sentence that fails
+// the screen stops generation, and the reply becomes the safety fallback. The assembled reply
+// is persisted once the stream ends. If the client goes away (ctx cancelled or onDelta failing)
+// generation stops and the partial reply is kept, marked as interrupted.
+func (s *CortexService) StreamMessage(ctx context.Context, userID uuid.UUID, threadID *uuid.UUID, message string, onDelta func(delta string) error) (*domain.CortexReply, error) {
+	return s.replyInThread(ctx, userID, threadID, message, onDelta)
+}
+
+func (s *CortexService) replyInThread(ctx context.Context, userID uuid.UUID, threadID *uuid.UUID, message string, onDelta func(delta string) error) (*domain.CortexReply, error) {
+	if s.threads == nil {
+		return nil, errCortexThreadsDisabled
+	}
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, domain.ErrEmptyCortexMessage
+	}
+	if utf8.RuneCountInString(message) > domain.MaxCortexMessageLength {
+		return nil, domain.ErrCortexMessageTooLong
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	risk := s.safety.ScreenInput(ctx, user, domain.SafetySourceThread, message)
+	persona, err := s.chatPersona(ctx, user, risk)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var thread *domain.CortexThread
+	if threadID == nil {
+		thread = &domain.CortexThread{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Title:     domain.CortexThreadTitle(message),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.threads.CreateThread(ctx, thread); err != nil {
+			return nil, err
+		}
+	} else if thread, err = s.ownThread(ctx, userID, *threadID); err != nil {
+		return nil, err
+	}
+
+	history, err := s.threads.ListMessages(ctx, thread.ID, thread.SummarizedThrough)
+	if err != nil {
+		return nil, err
+	}
+	userMessage, err := s.appendMessage(ctx, thread, domain.CortexMessage{Role: domain.LLMRoleUser, Content: message, CreatedAt: now})
+	if err != nil {
+		return nil, err
+	}
+	history = append(history, *userMessage)
+
+	window := history
+	if len(window) > domain.CortexHistoryWindow {
+		window = window[len(window)-domain.CortexHistoryWindow:]
+	}
+	systemPrompt := persona.System
+	if thread.Summary != "" {
+		systemPrompt += "\n\nEarlier in this conversation: " + thread.Summary
+	}
+
+	provider := s.userProvider(domain.LLMUseCaseChat, userID)
+	var response string
+	clientGone := false
+	if onDelta == nil {
+		response, err = provider.Converse(ctx, systemPrompt, llmMessages(window))
+	} else {
+		stream := &screenedStream{
+			screen: func(text string) bool {
+				return s.safety.ScreenReply(ctx, userID, domain.SafetySourceThread, text).Flagged()
+			},
+			onDelta: func(delta string) error {
+				if deltaErr := onDelta(delta); deltaErr != nil {
+					clientGone = true
+					return deltaErr
+				}
+				return nil
+			},
+		}
+		response, err = converseStream(ctx, provider, systemPrompt, llmMessages(window), stream.write)
+		if err == nil {
+			err = stream.flush()
+		}
+		clientGone = clientGone || ctx.Err() != nil
+	}
+	replaced := false
+	if errors.Is(err, errUnsafeCortexReply) {
+		// The unsafe sentence was held back; the "done" event carries the fallback
+		response, replaced, err = domain.SafetyFallbackReply, true, nil
+	}
+	if err != nil {
+		// The request is over, but what the client saw should survive in the thread
+		ctx = context.WithoutCancel(ctx)
+		if clientGone && strings.TrimSpace(response) != "" {
+			// Generation stopped mid-sentence, so the tail was never screened
+			content, _ := s.screenReply(ctx, userID, domain.SafetySourceThread, response)
+			partial := domain.CortexMessage{Role: domain.LLMRoleAssistant, Content: content, Interrupted: true, CreatedAt: time.Now()}
+			if _, appendErr := s.appendMessage(ctx, thread, partial); appendErr != nil {
+				logger.Error().Err(appendErr).Str("thread_id", thread.ID.String()).Msg("Failed to save interrupted cortex reply")
+			}
+		}
+		// Keep the user's message; resuming the thread shows it unanswered
+		thread.UpdatedAt = now
+		if updateErr := s.threads.UpdateThread(ctx, thread); updateErr != nil {
+			logger.Error().Err(updateErr).Str("thread_id", thread.ID.String()).Msg("Failed to update cortex thread")
+		}
+		return nil, fmt.Errorf("llm error: %w", err)
+	}
+
+	// Screening the whole reply also catches advice spread over sentences; a streamed reply's
+	// replacement goes out in the "done" event
+	response, screenedOut := s.screenReply(ctx, userID, domain.SafetySourceThread, response)
+	replaced = replaced || screenedOut
+	reply, err := s.appendMessage(ctx, thread, domain.CortexMessage{Role: domain.LLMRoleAssistant, Content: response, CreatedAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	s.prompts.RecordExposure(ctx, userID, persona)
+	history = append(history, *reply)
+	s.foldHistory(ctx, thread, history)
+
+	thread.UpdatedAt = reply.CreatedAt
+	if err := s.threads.UpdateThread(ctx, thread); err != nil {
+		return nil, err
+	}
+	return &domain.CortexReply{Thread: *thread, Message: *reply, SafetyMode: risk.Flagged() || replaced}, nil
+}
+
+func (s *CortexService) ListThreads(ctx context.Context, userID uuid.UUID) ([]domain.CortexThread, error) {
+	if s.threads == nil {
+		return nil, errCortexThreadsDisabled
+	}
+	threads, err := s.threads.ListThreads(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if threads == nil {
+		threads = []domain.CortexThread{}
+	}
+	return threads, nil
+}
+
+// GetThread returns the thread with every message, including the summarized ones
+func (s *CortexService) GetThread(ctx context.Context, userID, threadID uuid.UUID) (*domain.CortexThreadView, error) {
+	if s.threads == nil {
+		return nil, errCortexThreadsDisabled
+	}
+	thread, err := s.ownThread(ctx, userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.threads.ListMessages(ctx, threadID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if messages == nil {
+		messages = []domain.CortexMessage{}
+	}
+	return &domain.CortexThreadView{Thread: *thread, Messages: messages}, nil
+}
+
+func (s *CortexService) DeleteThread(ctx context.Context, userID, threadID uuid.UUID) error {
+	if s.threads == nil {
+		return errCortexThreadsDisabled
+	}
+	if _, err := s.ownThread(ctx, userID, threadID); err != nil {
+		return err
+	}
+	return s.threads.DeleteThread(ctx, threadID)
+}
+
+// ownThread loads a thread, hiding other users' threads as not found
+func (s *CortexService) ownThread(ctx context.Context, userID, threadID uuid.UUID) (*domain.CortexThread, error) {
+	thread, err := s.threads.FindThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if thread == nil || thread.UserID != userID {
+		return nil, domain.ErrCortexThreadNotFound
+	}
+	return thread, nil
+}
+
+// appendMessage numbers message as the thread's next one and stores it
+func (s *CortexService) appendMessage(ctx context.Context, thread *domain.CortexThread, message domain.CortexMessage) (*domain.CortexMessage, error) {
+	message.ID = uuid.New()
+	message.ThreadID = thread.ID
+	message.Seq = thread.MessageCount + 1
+	appended, err := s.threads.AppendMessage(ctx, &message)
+	if err != nil {
+		return nil, err
+	}
+	if !appended {
+		// Another request took this sequence number first
+		return nil, domain.ErrCortexThreadBusy
+	}
+	thread.MessageCount = message.Seq
+	return &message, nil
+}
+
+// foldHistory summarizes everything before the window once domain.CortexSummaryBatch messages
+// have built up past it. unsummarized is every message after thread.SummarizedThrough. A failed
+// summary only delays folding: the window alone already bounds the prompt.
+func (s *CortexService) foldHistory(ctx context.Context, thread *domain.CortexThread, unsummarized []domain.CortexMessage) {
+	if len(unsummarized) < domain.CortexHistoryWindow+domain.CortexSummaryBatch {
+		return
+	}
+	older := unsummarized[:len(unsummarized)-domain.CortexHistoryWindow]
+
+	// Summaries aren't shown to the user, so no exposure is recorded for them
+	prompt, err := s.prompts.Render(promptThreadSummary, thread.UserID, domain.PromptVars{"previous_summary": thread.Summary})
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to render cortex thread summary prompt")
+		return
+	}
+	turns := append(llmMessages(older), domain.LLMMessage{Role: domain.LLMRoleUser, Content: prompt.User})
+	summary, err := s.userProvider(domain.LLMUseCaseChat, thread.UserID).Converse(ctx, prompt.System, turns)
+	if err != nil || strings.TrimSpace(summary) == "" {
+		logger.Warn().Err(err).Str("thread_id", thread.ID.String()).Msg("Failed to summarize cortex thread")
+		return
+	}
+
+	thread.Summary = strings.TrimSpace(summary)
+	thread.SummarizedThrough = older[len(older)-1].Seq
+}
+
+// converseStream streams from providers that support it and otherwise delivers the whole
+// reply as a single delta
+func converseStream(ctx context.Context, provider ports.LLMProvider, systemPrompt string, messages []domain.LLMMessage, onDelta func(delta string) error) (string, error) {
+	if streaming, ok := provider.(ports.StreamingLLMProvider); ok {
+		return streaming.ConverseStream(ctx, systemPrompt, messages, onDelta)
+	}
+	response, err := provider.Converse(ctx, systemPrompt, messages)
+	if err != nil {
+		return "", err
+	}
+	return response, onDelta(response)
+}
+
+// sentenceEnd matches the end of a sentence and the whitespace after it
+var sentenceEnd = regexp.MustCompile(`[.!?]+["')\]]*\s+|\n+`)
+
+// screenedStream holds streamed text back until a sentence is complete and passes it on once
+// screen has cleared it, so an unsafe sentence never reaches the client
+type screenedStream struct {
+	screen  func(text string) bool // Reports whether text is unsafe
+	onDelta func(delta string) error
+	pending string
+}
+
+// write buffers delta and releases the complete sentences in the buffer
+func (st *screenedStream) write(delta string) error {
+	st.pending += delta
+	ends := sentenceEnd.FindAllStringIndex(st.pending, -1)
+	if len(ends) == 0 {
+		return nil
+	}
+	cut := ends[len(ends)-1][1]
+	sentences := st.pending[:cut]
+	st.pending = st.pending[cut:]
+	return st.release(sentences)
+}
+
+// flush releases what is left once the stream ends
+func (st *screenedStream) flush() error {
+	if st.pending == "" {
+		return nil
+	}
+	rest := st.pending
+	st.pending = ""
+	return st.release(rest)
+}
+
+func (st *screenedStream) release(text string) error {
+	if st.screen(text) {
+		return errUnsafeCortexReply
+	}
+	return st.onDelta(text)
+}
+
+func llmMessages(messages []domain.CortexMessage) []domain.LLMMessage {
+	turns := make([]domain.LLMMessage, len(messages))
+	for i, m := range messages {
+		turns[i] = domain.LLMMessage{Role: m.Role, Content: m.Content}
+	}
+	return turns
+}
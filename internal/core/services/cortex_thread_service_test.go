@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/adapters/repository/memory"
+	"fastinghero/internal/adapters/secondary/llm"
+	"fastinghero/internal/core/domain"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCortexThreadService(userID uuid.UUID) (*CortexService, *MockLLMProvider, *memory.CortexThreadRepository) {
+	mockLLM := new(MockLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	threads := memory.NewCortexThreadRepository()
+
+	mockUserRepo.On("FindByID", mock.Anything, userID).Return(&domain.User{ID: userID, DisciplineIndex: 70}, nil)
+	mockFastingRepo.On("FindActiveByUserID", mock.Anything, userID).Return(nil, nil)
+
+	return NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, threads, nil, nil, nil, nil, nil), mockLLM, threads
+}
+
+func isCoachPrompt(systemPrompt string) bool {
+	return strings.Contains(systemPrompt, "You are Cortex")
+}
+
+func TestCortexThreads_StartAndResume(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	service, mockLLM, _ := newTestCortexThreadService(userID)
+
+	mockLLM.On("Converse", ctx, mock.MatchedBy(isCoachPrompt), mock.MatchedBy(func(m []domain.LLMMessage) bool { return len(m) == 1 })).
+		Return("Drink water and wait it out.", nil).Once()
+	mockLLM.On("Converse", ctx, mock.MatchedBy(isCoachPrompt), mock.MatchedBy(func(m []domain.LLMMessage) bool {
+		return len(m) == 3 && m[0].Content == "Evenings are hard for me" && m[1].Role == domain.LLMRoleAssistant
+	})).Return("You said evenings are hard. Plan dinner earlier.", nil).Once()
+
+	first, err := service.SendMessage(ctx, userID, nil, "  Evenings are hard for me ")
+	require.NoError(t, err)
+	assert.Equal(t, "Evenings are hard for me", first.Thread.Title)
+	assert.Equal(t, 2, first.Thread.MessageCount)
+	assert.Equal(t, domain.LLMRoleAssistant, first.Message.Role)
+
+	second, err := service.SendMessage(ctx, userID, &first.Thread.ID, "What should I do tonight?")
+	require.NoError(t, err)
+	assert.Equal(t, "You said evenings are hard. Plan dinner earlier.", second.Message.Content)
+	assert.Equal(t, 4, second.Message.Seq)
+
+	view, err := service.GetThread(ctx, userID, first.Thread.ID)
+	require.NoError(t, err)
+	require.Len(t, view.Messages, 4)
+	assert.Equal(t, domain.LLMRoleUser, view.Messages[2].Role)
+
+	threads, err := service.ListThreads(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, threads, 1)
+	mockLLM.AssertExpectations(t)
+}
+
+func TestCortexThreads_SummarizesOlderTurns(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	service, mockLLM, threads := newTestCortexThreadService(userID)
+
+	mockLLM.On("Converse", ctx, mock.MatchedBy(func(p string) bool { return strings.Contains(p, "You summarize") }), mock.Anything).
+		Return("User struggles in the evenings.", nil)
+	mockLLM.On("Converse", ctx, mock.MatchedBy(isCoachPrompt), mock.Anything).Return("Keep going.", nil)
+
+	var threadID *uuid.UUID
+	turns := (domain.CortexHistoryWindow + domain.CortexSummaryBatch) / 2
+	for i := 0; i < turns; i++ {
+		reply, err := service.SendMessage(ctx, userID, threadID, fmt.Sprintf("message %d", i))
+		require.NoError(t, err)
+		threadID = &reply.Thread.ID
+	}
+
+	thread, err := threads.FindThread(ctx, *threadID)
+	require.NoError(t, err)
+	assert.Equal(t, "User struggles in the evenings.", thread.Summary)
+	assert.Equal(t, domain.CortexSummaryBatch, thread.SummarizedThrough)
+
+	_, err = service.SendMessage(ctx, userID, threadID, "one more")
+	require.NoError(t, err)
+
+	last := mockLLM.Calls[len(mockLLM.Calls)-1]
+	assert.Contains(t, last.Arguments.String(1), "Earlier in this conversation: User struggles in the evenings.")
+	messages := last.Arguments.Get(2).([]domain.LLMMessage)
+	assert.LessOrEqual(t, len(messages), domain.CortexHistoryWindow)
+	assert.Equal(t, "one more", messages[len(messages)-1].Content)
+
+	view, err := service.GetThread(ctx, userID, *threadID)
+	require.NoError(t, err)
+	assert.Len(t, view.Messages, 2*turns+2)
+}
+
+func TestCortexThreads_LLMFailureKeepsUserMessage(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	service, mockLLM, _ := newTestCortexThreadService(userID)
+
+	mockLLM.On("Converse", ctx, mock.Anything, mock.Anything).Return("", errors.New("timeout"))
+
+	_, err := service.SendMessage(ctx, userID, nil, "Hello")
+	assert.Error(t, err)
+
+	threads, err := service.ListThreads(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, threads, 1)
+	assert.Equal(t, 1, threads[0].MessageCount)
+}
+
+func TestCortexThreads_OtherUsersThreadsAreHidden(t *testing.T) {
+	ctx := context.Background()
+	ownerID := uuid.New()
+	service, mockLLM, threads := newTestCortexThreadService(ownerID)
+	mockLLM.On("Converse", ctx, mock.Anything, mock.Anything).Return("Hi.", nil)
+
+	reply, err := service.SendMessage(ctx, ownerID, nil, "Hello")
+	require.NoError(t, err)
+
+	strangerID := uuid.New()
+	_, err = service.GetThread(ctx, strangerID, reply.Thread.ID)
+	assert.ErrorIs(t, err, domain.ErrCortexThreadNotFound)
+	assert.ErrorIs(t, service.DeleteThread(ctx, strangerID, reply.Thread.ID), domain.ErrCortexThreadNotFound)
+
+	require.NoError(t, service.DeleteThread(ctx, ownerID, reply.Thread.ID))
+	messages, err := threads.ListMessages(ctx, reply.Thread.ID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+	_, err = service.GetThread(ctx, ownerID, reply.Thread.ID)
+	assert.ErrorIs(t, err, domain.ErrCortexThreadNotFound)
+}
+
+func TestCortexThreads_ValidatesMessage(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	service, _, _ := newTestCortexThreadService(userID)
+
+	_, err := service.SendMessage(ctx, userID, nil, "   ")
+	assert.ErrorIs(t, err, domain.ErrEmptyCortexMessage)
+
+	_, err = service.SendMessage(ctx, userID, nil, strings.Repeat("a", domain.MaxCortexMessageLength+1))
+	assert.ErrorIs(t, err, domain.ErrCortexMessageTooLong)
+}
+
+func TestCortexThreadTitle(t *testing.T) {
+	assert.Equal(t, "How do I beat evening cravings?", domain.CortexThreadTitle("How do I   beat evening\ncravings?"))
+
+	long := domain.CortexThreadTitle(strings.Repeat("fasting ", 20))
+	assert.True(t, strings.HasSuffix(long, "…"))
+	assert.LessOrEqual(t, len([]rune(long)), 61)
+}
+
+func TestCortexThreads_StreamMessage(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	service, _, _ := newTestCortexThreadService(userID)
+	fake, err := llm.NewFakeProvider(llm.FakeScript{Default: "Plan dinner earlier tonight. Then take a short walk!"})
+	require.NoError(t, err)
+	service.llm = fake
+
+	var deltas []string
+	reply, err := service.StreamMessage(ctx, userID, nil, "Evenings are hard", func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Plan dinner earlier tonight. ", "Then take a short walk!"}, deltas, "a sentence at a time")
+	assert.Equal(t, strings.Join(deltas, ""), reply.Message.Content)
+	view, err := service.GetThread(ctx, userID, reply.Thread.ID)
+	require.NoError(t, err)
+	require.Len(t, view.Messages, 2)
+	assert.Equal(t, "Plan dinner earlier tonight. Then take a short walk!", view.Messages[1].Content)
+	assert.False(t, view.Messages[1].Interrupted)
+}
+
+func TestCortexThreads_StreamMessage_ClientDisconnectKeepsPartialReply(t *testing.T) {
+	userID := uuid.New()
+	service, _, _ := newTestCortexThreadService(userID)
+	fake, err := llm.NewFakeProvider(llm.FakeScript{Default: "Plan dinner earlier tonight. Then take a short walk!"})
+	require.NoError(t, err)
+	service.llm = fake
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, err = service.StreamMessage(ctx, userID, nil, "Evenings are hard", func(delta string) error {
+		cancel() // The client closes the connection mid-reply
+		return ctx.Err()
+	})
+	require.Error(t, err)
+
+	threads, err := service.ListThreads(context.Background(), userID)
+	require.NoError(t, err)
+	require.Len(t, threads, 1)
+	view, err := service.GetThread(context.Background(), userID, threads[0].ID)
+	require.NoError(t, err)
+	require.Len(t, view.Messages, 2)
+	assert.Equal(t, "Plan dinner earlier tonight. ", view.Messages[1].Content)
+	assert.True(t, view.Messages[1].Interrupted)
+}
+
+func TestCortexThreads_StreamMessage_HoldsBackUnsafeSentence(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	service, _, _ := newTestCortexThreadService(userID)
+	flags := memory.NewSafetyFlagRepository()
+	service.safety = NewSafetyGuard(flags)
+	fake, err := llm.NewFakeProvider(llm.FakeScript{Default: "Eat a light dinner. Then push through the dizziness tomorrow. Sleep well."})
+	require.NoError(t, err)
+	service.llm = fake
+
+	var deltas []string
+	reply, err := service.StreamMessage(ctx, userID, nil, "Evenings are hard", func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Eat a light dinner. "}, deltas)
+	assert.Equal(t, domain.SafetyFallbackReply, reply.Message.Content)
+	assert.True(t, reply.SafetyMode)
+	open, _ := flags.ListByStatus(ctx, domain.SafetyFlagOpen)
+	require.Len(t, open, 1)
+	assert.Equal(t, "Then push through the dizziness tomorrow.", open[0].Excerpt)
+}
+
+// droppedStreamProvider streams its deltas and then loses the client
+type droppedStreamProvider struct {
+	MockLLMProvider
+	deltas []string
+	cancel context.CancelFunc
+}
+
+func (p *droppedStreamProvider) ConverseStream(ctx context.Context, systemPrompt string, messages []domain.LLMMessage, onDelta func(delta string) error) (string, error) {
+	var assembled strings.Builder
+	for _, delta := range p.deltas {
+		assembled.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return assembled.String(), err
+		}
+	}
+	p.cancel()
+	return assembled.String(), ctx.Err()
+}
+
+func TestCortexThreads_StreamMessage_ScreensInterruptedReply(t *testing.T) {
+	userID := uuid.New()
+	service, _, _ := newTestCortexThreadService(userID)
+	ctx, cancel := context.WithCancel(context.Background())
+	service.llm = &droppedStreamProvider{deltas: []string{"Eat a light dinner. ", "Then skip water until"}, cancel: cancel}
+
+	var deltas []string
+	_, err := service.StreamMessage(ctx, userID, nil, "Evenings are hard", func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, []string{"Eat a light dinner. "}, deltas)
+
+	threads, err := service.ListThreads(context.Background(), userID)
+	require.NoError(t, err)
+	require.Len(t, threads, 1)
+	view, err := service.GetThread(context.Background(), userID, threads[0].ID)
+	require.NoError(t, err)
+	require.Len(t, view.Messages, 2)
+	assert.Equal(t, domain.SafetyFallbackReply, view.Messages[1].Content, "the unscreened tail isn't kept")
+	assert.True(t, view.Messages[1].Interrupted)
+}
+
+func TestCortexThreads_StreamMessage_FallsBackForNonStreamingProviders(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	service, mockLLM, _ := newTestCortexThreadService(userID)
+	mockLLM.On("Converse", ctx, mock.Anything, mock.Anything).Return("Whole reply.", nil)
+
+	var deltas []string
+	reply, err := service.StreamMessage(ctx, userID, nil, "Hello", func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Whole reply."}, deltas)
+	assert.Equal(t, "Whole reply.", reply.Message.Content)
+}
@@ -0,0 +1,462 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxProposedActions bounds the actions one reply can propose
+	maxProposedActions = 3
+	// cortexActionHistoryLimit is how many past actions ListActions returns
+	cortexActionHistoryLimit = 50
+	// maxCortexGoalHours caps the fasts Cortex may start; longer ones are planned in the app
+	maxCortexGoalHours = 72
+)
+
+// errToolsUnsupported is returned when tools are offered to a provider without function calling
+var errToolsUnsupported = errors.New("llm provider does not support tool calling")
+
+// planGoalHours is the goal of a fast Cortex starts when the user names a plan but no goal
+var planGoalHours = map[domain.FastingPlanType]int{
+	domain.PlanBeginner: 12,
+	domain.Plan168:      16,
+	domain.Plan186:      18,
+	domain.PlanOMAD:     23,
+	domain.Plan24h:      24,
+	domain.Plan36h:      36,
+	domain.PlanExtended: 48,
+}
+
+// mealTypes are the meal types log_meal accepts
+var mealTypes = []string{"breakfast", "lunch", "dinner", "snack"}
+
+// CortexToolRegistry holds the actions Cortex may propose on a user's data. The model only
+// ever proposes: an action runs when the user confirms it, and every proposal is stored with
+// its outcome as the audit trail of what Cortex changed.
+type CortexToolRegistry struct {
+	tools   map[domain.CortexTool]cortexTool
+	order   []domain.CortexTool
+	actions ports.CortexActionRepository
+}
+
+type cortexTool struct {
+	definition domain.LLMTool
+	// prepare validates the model's arguments and returns them normalized, with a summary of
+	// what confirming will do
+	prepare func(args json.RawMessage) (json.RawMessage, string, error)
+	// execute runs a confirmed action and returns the record it created or changed
+	execute func(ctx context.Context, userID uuid.UUID, args json.RawMessage) (interface{}, error)
+}
+
+// NewCortexToolRegistry registers the tools backed by the given services; a nil service
+// leaves its tools out. actions stores every proposal and must not be nil.
+func NewCortexToolRegistry(actions ports.CortexActionRepository, fasting ports.FastingService, progress ports.ProgressService, meals ports.MealService) *CortexToolRegistry {
+	r := &CortexToolRegistry{tools: make(map[domain.CortexTool]cortexTool), actions: actions}
+	if fasting != nil {
+		r.register(domain.CortexToolStartFast, startFastTool(fasting))
+		r.register(domain.CortexToolStopFast, stopFastTool(fasting))
+	}
+	if progress != nil {
+		r.register(domain.CortexToolLogHydration, logHydrationTool(progress))
+		r.register(domain.CortexToolLogWeight, logWeightTool(progress))
+	}
+	if meals != nil {
+		r.register(domain.CortexToolLogMeal, logMealTool(meals))
+	}
+	return r
+}
+
+func (r *CortexToolRegistry) register(name domain.CortexTool, tool cortexTool) {
+	tool.definition.Name = string(name)
+	r.tools[name] = tool
+	r.order = append(r.order, name)
+}
+
+// Definitions returns the tools offered to the model, in registration order
+func (r *CortexToolRegistry) Definitions() []domain.LLMTool {
+	definitions := make([]domain.LLMTool, len(r.order))
+	for i, name := range r.order {
+		definitions[i] = r.tools[name].definition
+	}
+	return definitions
+}
+
+// Propose validates the model's tool calls and stores each valid one as an action waiting for
+// the user. Calls to unknown tools or with bad arguments are logged and dropped.
+func (r *CortexToolRegistry) Propose(ctx context.Context, userID uuid.UUID, calls []domain.LLMToolCall) []domain.CortexAction {
+	var proposed []domain.CortexAction
+	for _, call := range calls {
+		if len(proposed) == maxProposedActions {
+			logger.Warn().Str("user_id", userID.String()).Int("calls", len(calls)).Msg("Dropping Cortex tool calls past the per-reply limit")
+			break
+		}
+		tool, ok := r.tools[domain.CortexTool(call.Name)]
+		if !ok {
+			logger.Warn().Str("user_id", userID.String()).Str("tool", call.Name).Msg("Cortex called an unknown tool")
+			continue
+		}
+		args, summary, err := tool.prepare(call.Arguments)
+		if err != nil {
+			logger.Warn().Err(err).Str("user_id", userID.String()).Str("tool", call.Name).Msg("Cortex tool call has invalid arguments")
+			continue
+		}
+
+		now := time.Now()
+		action := &domain.CortexAction{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Tool:      domain.CortexTool(call.Name),
+			Arguments: args,
+			Summary:   summary,
+			Status:    domain.CortexActionProposed,
+			CreatedAt: now,
+			ExpiresAt: now.Add(domain.CortexActionTTL),
+		}
+		if err := r.actions.Save(context.WithoutCancel(ctx), action); err != nil {
+			logger.Error().Err(err).Str("user_id", userID.String()).Str("tool", call.Name).Msg("Failed to save proposed Cortex action")
+			continue
+		}
+		logger.Info().Str("action_id", action.ID.String()).Str("user_id", userID.String()).Str("tool", call.Name).Msg("Cortex action proposed")
+		proposed = append(proposed, *action)
+	}
+	return proposed
+}
+
+// ListActions returns the user's most recent actions, newest first
+func (r *CortexToolRegistry) ListActions(ctx context.Context, userID uuid.UUID) ([]domain.CortexAction, error) {
+	return r.actions.ListByUser(ctx, userID, cortexActionHistoryLimit)
+}
+
+// ConfirmAction runs a proposed action. An action the service refuses is returned with status
+// failed and the reason, not as an error. The action is claimed before it runs, so a double tap
+// or a retry landing on another instance runs it once.
+func (r *CortexToolRegistry) ConfirmAction(ctx context.Context, userID, actionID uuid.UUID) (*domain.CortexAction, error) {
+	action, err := r.pendingAction(ctx, userID, actionID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if now.After(action.ExpiresAt) {
+		if err := r.decide(ctx, action, domain.CortexActionExpired, now); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrCortexActionExpired
+	}
+
+	tool, ok := r.tools[action.Tool]
+	if !ok {
+		return nil, fmt.Errorf("cortex tool %s is no longer available", action.Tool)
+	}
+	if err := r.decide(ctx, action, domain.CortexActionExecuting, now); err != nil {
+		return nil, err
+	}
+	result, execErr := tool.execute(ctx, userID, action.Arguments)
+	if execErr != nil {
+		action.Status = domain.CortexActionFailed
+		action.Error = execErr.Error()
+	} else {
+		action.Status = domain.CortexActionExecuted
+		if action.Result, err = json.Marshal(result); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.actions.Update(context.WithoutCancel(ctx), action); err != nil {
+		return nil, err
+	}
+
+	event := logger.Info()
+	if execErr != nil {
+		event = logger.Warn().Err(execErr)
+	}
+	event.Str("action_id", action.ID.String()).Str("user_id", userID.String()).Str("tool", string(action.Tool)).
+		Str("status", string(action.Status)).Msg("Cortex action confirmed")
+	return action, nil
+}
+
+// RejectAction declines a proposed action so it can no longer run
+func (r *CortexToolRegistry) RejectAction(ctx context.Context, userID, actionID uuid.UUID) (*domain.CortexAction, error) {
+	action, err := r.pendingAction(ctx, userID, actionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.decide(ctx, action, domain.CortexActionRejected, time.Now()); err != nil {
+		return nil, err
+	}
+	logger.Info().Str("action_id", action.ID.String()).Str("user_id", userID.String()).Str("tool", string(action.Tool)).Msg("Cortex action rejected")
+	return action, nil
+}
+
+// pendingAction returns the user's action if it is still waiting for a decision. Another
+// user's action is reported as not found.
+func (r *CortexToolRegistry) pendingAction(ctx context.Context, userID, actionID uuid.UUID) (*domain.CortexAction, error) {
+	action, err := r.actions.FindByID(ctx, actionID)
+	if err != nil {
+		return nil, err
+	}
+	if action == nil || action.UserID != userID {
+		return nil, domain.ErrCortexActionNotFound
+	}
+	if action.Status != domain.CortexActionProposed {
+		return nil, domain.ErrCortexActionDecided
+	}
+	return action, nil
+}
+
+// decide moves a proposed action to status, failing with ErrCortexActionDecided if another
+// request decided it first
+func (r *CortexToolRegistry) decide(ctx context.Context, action *domain.CortexAction, status domain.CortexActionStatus, now time.Time) error {
+	claimed, err := r.actions.ClaimDecision(ctx, action.ID, status, now)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return domain.ErrCortexActionDecided
+	}
+	action.Status = status
+	action.DecidedAt = &now
+	return nil
+}
+
+// confirmationPrompt answers a reply that proposed actions without saying anything
+func confirmationPrompt(actions []domain.CortexAction) string {
+	summaries := make([]string, len(actions))
+	for i, action := range actions {
+		summaries[i] = action.Summary
+	}
+	return "Please confirm: " + strings.Join(summaries, "; ") + "."
+}
+
+// decodeToolArgs decodes the model's arguments strictly: unknown fields are rejected so a
+// misnamed argument isn't silently dropped
+func decodeToolArgs(raw json.RawMessage, v interface{}) error {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		raw = json.RawMessage("{}")
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid tool arguments: %w", err)
+	}
+	return nil
+}
+
+// toolSchema builds the JSON schema of an arguments object
+func toolSchema(properties map[string]interface{}, required ...string) json.RawMessage {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	encoded, _ := json.Marshal(schema)
+	return encoded
+}
+
+type startFastArgs struct {
+	Plan      domain.FastingPlanType `json:"plan"`
+	GoalHours int                    `json:"goal_hours"`
+}
+
+func startFastTool(fasting ports.FastingService) cortexTool {
+	plans := make([]string, 0, len(planGoalHours))
+	for _, plan := range []domain.FastingPlanType{domain.PlanBeginner, domain.Plan168, domain.Plan186, domain.PlanOMAD, domain.Plan24h, domain.Plan36h, domain.PlanExtended} {
+		plans = append(plans, string(plan))
+	}
+	return cortexTool{
+		definition: domain.LLMTool{
+			Description: "Start a fast for the user now. Use when they ask to start or begin fasting.",
+			Parameters: toolSchema(map[string]interface{}{
+				"plan":       map[string]interface{}{"type": "string", "enum": plans, "description": "Fasting plan; 16_8 if the user names none"},
+				"goal_hours": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": maxCortexGoalHours, "description": "Goal length in hours; the plan's usual length if omitted"},
+			}),
+		},
+		prepare: func(raw json.RawMessage) (json.RawMessage, string, error) {
+			var args startFastArgs
+			if err := decodeToolArgs(raw, &args); err != nil {
+				return nil, "", err
+			}
+			if args.Plan == "" {
+				args.Plan = domain.Plan168
+			}
+			defaultHours, ok := planGoalHours[args.Plan]
+			if !ok {
+				return nil, "", fmt.Errorf("unknown fasting plan %q", args.Plan)
+			}
+			if args.GoalHours == 0 {
+				args.GoalHours = defaultHours
+			}
+			if args.GoalHours < 1 || args.GoalHours > maxCortexGoalHours {
+				return nil, "", fmt.Errorf("goal must be between 1 and %d hours", maxCortexGoalHours)
+			}
+			normalized, err := json.Marshal(args)
+			return normalized, fmt.Sprintf("Start a %d-hour fast (%s plan)", args.GoalHours, args.Plan), err
+		},
+		execute: func(ctx context.Context, userID uuid.UUID, raw json.RawMessage) (interface{}, error) {
+			var args startFastArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, err
+			}
+			return fasting.StartFast(ctx, userID, args.Plan, args.GoalHours, nil)
+		},
+	}
+}
+
+func stopFastTool(fasting ports.FastingService) cortexTool {
+	return cortexTool{
+		definition: domain.LLMTool{
+			Description: "End the user's current fast now. Use when they ask to end, stop or break their fast.",
+			Parameters:  toolSchema(map[string]interface{}{}),
+		},
+		prepare: func(raw json.RawMessage) (json.RawMessage, string, error) {
+			var args struct{}
+			if err := decodeToolArgs(raw, &args); err != nil {
+				return nil, "", err
+			}
+			return json.RawMessage("{}"), "End your current fast", nil
+		},
+		execute: func(ctx context.Context, userID uuid.UUID, raw json.RawMessage) (interface{}, error) {
+			return fasting.StopFast(ctx, userID)
+		},
+	}
+}
+
+type logHydrationArgs struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`
+}
+
+func logHydrationTool(progress ports.ProgressService) cortexTool {
+	return cortexTool{
+		definition: domain.LLMTool{
+			Description: "Log water the user drank.",
+			Parameters: toolSchema(map[string]interface{}{
+				"amount": map[string]interface{}{"type": "number", "exclusiveMinimum": 0},
+				"unit":   map[string]interface{}{"type": "string", "enum": []string{"glasses", "ml"}},
+			}, "amount", "unit"),
+		},
+		prepare: func(raw json.RawMessage) (json.RawMessage, string, error) {
+			var args logHydrationArgs
+			if err := decodeToolArgs(raw, &args); err != nil {
+				return nil, "", err
+			}
+			if args.Amount <= 0 {
+				return nil, "", errors.New("amount must be positive")
+			}
+			var summary string
+			switch args.Unit {
+			case "glasses":
+				summary = fmt.Sprintf("Log %g glasses of water", args.Amount)
+				if args.Amount == 1 {
+					summary = "Log 1 glass of water"
+				}
+			case "ml":
+				summary = fmt.Sprintf("Log %g ml of water", args.Amount)
+			default:
+				return nil, "", fmt.Errorf("unknown hydration unit %q", args.Unit)
+			}
+			normalized, err := json.Marshal(args)
+			return normalized, summary, err
+		},
+		execute: func(ctx context.Context, userID uuid.UUID, raw json.RawMessage) (interface{}, error) {
+			var args logHydrationArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, err
+			}
+			return progress.LogHydration(ctx, userID, args.Amount, args.Unit)
+		},
+	}
+}
+
+type logWeightArgs struct {
+	Weight float64 `json:"weight"`
+	Unit   string  `json:"unit"`
+}
+
+func logWeightTool(progress ports.ProgressService) cortexTool {
+	return cortexTool{
+		definition: domain.LLMTool{
+			Description: "Log the user's body weight.",
+			Parameters: toolSchema(map[string]interface{}{
+				"weight": map[string]interface{}{"type": "number", "exclusiveMinimum": 0},
+				"unit":   map[string]interface{}{"type": "string", "enum": []string{"kg", "lbs"}},
+			}, "weight", "unit"),
+		},
+		prepare: func(raw json.RawMessage) (json.RawMessage, string, error) {
+			var args logWeightArgs
+			if err := decodeToolArgs(raw, &args); err != nil {
+				return nil, "", err
+			}
+			if args.Weight <= 0 {
+				return nil, "", errors.New("weight must be positive")
+			}
+			if args.Unit != "kg" && args.Unit != "lbs" {
+				return nil, "", fmt.Errorf("unknown weight unit %q", args.Unit)
+			}
+			normalized, err := json.Marshal(args)
+			return normalized, fmt.Sprintf("Log a weight of %g %s", args.Weight, args.Unit), err
+		},
+		execute: func(ctx context.Context, userID uuid.UUID, raw json.RawMessage) (interface{}, error) {
+			var args logWeightArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, err
+			}
+			return progress.LogWeight(ctx, userID, args.Weight, args.Unit)
+		},
+	}
+}
+
+type logMealArgs struct {
+	Name     string `json:"name"`
+	Calories int    `json:"calories"`
+	MealType string `json:"meal_type"`
+}
+
+func logMealTool(meals ports.MealService) cortexTool {
+	return cortexTool{
+		definition: domain.LLMTool{
+			Description: "Log a meal the user ate. Estimate calories only if the user gives enough detail, otherwise ask.",
+			Parameters: toolSchema(map[string]interface{}{
+				"name":      map[string]interface{}{"type": "string", "maxLength": 100},
+				"calories":  map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 5000},
+				"meal_type": map[string]interface{}{"type": "string", "enum": mealTypes},
+			}, "name", "calories", "meal_type"),
+		},
+		prepare: func(raw json.RawMessage) (json.RawMessage, string, error) {
+			var args logMealArgs
+			if err := decodeToolArgs(raw, &args); err != nil {
+				return nil, "", err
+			}
+			args.Name = strings.TrimSpace(args.Name)
+			switch {
+			case args.Name == "" || len(args.Name) > 100:
+				return nil, "", errors.New("meal name must be 1 to 100 characters")
+			case args.Calories < 0 || args.Calories > 5000:
+				return nil, "", errors.New("calories must be between 0 and 5000")
+			case !containsString(mealTypes, args.MealType):
+				return nil, "", fmt.Errorf("unknown meal type %q", args.MealType)
+			}
+			normalized, err := json.Marshal(args)
+			return normalized, fmt.Sprintf("Log %s: %s (%d kcal)", args.MealType, args.Name, args.Calories), err
+		},
+		execute: func(ctx context.Context, userID uuid.UUID, raw json.RawMessage) (interface{}, error) {
+			var args logMealArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, err
+			}
+			return meals.LogMeal(ctx, userID, args.Name, args.Calories, args.MealType, "", "")
+		},
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fastinghero/internal/adapters/repository/memory"
+	"fastinghero/internal/core/domain"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockToolLLMProvider is a mock of ports.ToolCallingLLMProvider
+type MockToolLLMProvider struct {
+	MockLLMProvider
+}
+
+func (m *MockToolLLMProvider) ConverseWithTools(ctx context.Context, systemPrompt string, messages []domain.LLMMessage, tools []domain.LLMTool) (*domain.LLMToolReply, error) {
+	args := m.Called(ctx, systemPrompt, messages, tools)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LLMToolReply), args.Error(1)
+}
+
+// MockFastingService is a mock of ports.FastingService
+type MockFastingService struct {
+	mock.Mock
+}
+
+func (m *MockFastingService) StartFast(ctx context.Context, userID uuid.UUID, plan domain.FastingPlanType, goalHours int, startTime *time.Time) (*domain.FastingSession, error) {
+	args := m.Called(ctx, userID, plan, goalHours, startTime)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.FastingSession), args.Error(1)
+}
+
+func (m *MockFastingService) StopFast(ctx context.Context, userID uuid.UUID) (*domain.FastingSession, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.FastingSession), args.Error(1)
+}
+
+func (m *MockFastingService) GetCurrentFast(ctx context.Context, userID uuid.UUID) (*domain.FastingSession, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.FastingSession), args.Error(1)
+}
+
+func (m *MockFastingService) GetFastingHistory(ctx context.Context, userID uuid.UUID) ([]domain.FastingSession, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.FastingSession), args.Error(1)
+}
+
+func toolNames(tools []domain.LLMTool) []string {
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	return names
+}
+
+func TestCortexService_Chat_ProposesActionsForConfirmation(t *testing.T) {
+	mockLLM := new(MockToolLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	actions := memory.NewCortexActionRepository()
+	progress := NewProgressService(memory.NewProgressRepository())
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
+	service.SetTools(NewCortexToolRegistry(actions, new(MockFastingService), progress, nil))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+	mockFastingRepo.On("FindActiveByUserID", ctx, userID).Return(nil, nil)
+	var offered []domain.LLMTool
+	var systemPrompt string
+	mockLLM.On("ConverseWithTools", ctx, mock.Anything, []domain.LLMMessage{{Role: domain.LLMRoleUser, Content: "log 2 glasses of water"}}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			systemPrompt = args.String(1)
+			offered = args.Get(3).([]domain.LLMTool)
+		}).
+		Return(&domain.LLMToolReply{ToolCalls: []domain.LLMToolCall{
+			{ID: "1", Name: "log_hydration", Arguments: json.RawMessage(`{"amount":2,"unit":"glasses"}`)},
+			{ID: "2", Name: "delete_account", Arguments: json.RawMessage(`{}`)},
+			{ID: "3", Name: "log_weight", Arguments: json.RawMessage(`{"weight":80,"unit":"stone"}`)},
+		}}, nil)
+
+	answer, err := service.Chat(ctx, userID, "log 2 glasses of water")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"start_fast", "stop_fast", "log_hydration", "log_weight"}, toolNames(offered), "no meal service, no log_meal")
+	assert.Contains(t, systemPrompt, "the user confirms it in the app before anything changes")
+	require.Len(t, answer.Actions, 1, "unknown tools and invalid arguments are dropped")
+	proposed := answer.Actions[0]
+	assert.Equal(t, domain.CortexToolLogHydration, proposed.Tool)
+	assert.Equal(t, domain.CortexActionProposed, proposed.Status)
+	assert.Equal(t, "Please confirm: Log 2 glasses of water.", answer.Text)
+	hydration, err := progress.GetDailyHydration(ctx, userID)
+	require.NoError(t, err)
+	assert.Nil(t, hydration, "nothing is logged before the user confirms")
+
+	confirmed, err := service.tools.ConfirmAction(ctx, userID, proposed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CortexActionExecuted, confirmed.Status)
+	assert.NotNil(t, confirmed.DecidedAt)
+	assert.Contains(t, string(confirmed.Result), `"glasses_count":2`)
+	hydration, _ = progress.GetDailyHydration(ctx, userID)
+	assert.Equal(t, 2, hydration.GlassesCount)
+
+	_, err = service.tools.ConfirmAction(ctx, userID, proposed.ID)
+	assert.ErrorIs(t, err, domain.ErrCortexActionDecided, "an action runs once")
+	history, err := service.tools.ListActions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, domain.CortexActionExecuted, history[0].Status)
+}
+
+func TestCortexService_Chat_NoToolsForFlaggedUser(t *testing.T) {
+	mockLLM := new(MockToolLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
+	service.SetTools(NewCortexToolRegistry(memory.NewCortexActionRepository(), new(MockFastingService), nil, nil))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("Please eat something and rest.", nil)
+
+	answer, err := service.Chat(ctx, userID, "I keep passing out, start another 36h fast")
+	require.NoError(t, err)
+
+	assert.True(t, answer.SafetyMode)
+	assert.Empty(t, answer.Actions)
+	mockLLM.AssertNotCalled(t, "ConverseWithTools", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCortexToolRegistry_RejectAndOwnership(t *testing.T) {
+	fasting := new(MockFastingService)
+	registry := NewCortexToolRegistry(memory.NewCortexActionRepository(), fasting, nil, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	proposed := registry.Propose(ctx, userID, []domain.LLMToolCall{
+		{Name: "stop_fast", Arguments: json.RawMessage(`{}`)},
+		{Name: "start_fast", Arguments: json.RawMessage(`{"plan":"omad"}`)},
+		{Name: "start_fast", Arguments: json.RawMessage(`{"plan":"extended","goal_hours":120}`)},
+		{Name: "start_fast", Arguments: json.RawMessage(`{"plan":"16_8","force":true}`)},
+	})
+	require.Len(t, proposed, 2)
+	assert.Equal(t, "End your current fast", proposed[0].Summary)
+	assert.Equal(t, "Start a 23-hour fast (omad plan)", proposed[1].Summary)
+	assert.JSONEq(t, `{"plan":"omad","goal_hours":23}`, string(proposed[1].Arguments))
+
+	_, err := registry.ConfirmAction(ctx, uuid.New(), proposed[0].ID)
+	assert.ErrorIs(t, err, domain.ErrCortexActionNotFound, "another user's action")
+
+	rejected, err := registry.RejectAction(ctx, userID, proposed[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CortexActionRejected, rejected.Status)
+	_, err = registry.ConfirmAction(ctx, userID, proposed[0].ID)
+	assert.ErrorIs(t, err, domain.ErrCortexActionDecided)
+	fasting.AssertNotCalled(t, "StopFast", mock.Anything, mock.Anything)
+}
+
+func TestCortexToolRegistry_ExpiredAndFailedActions(t *testing.T) {
+	fasting := new(MockFastingService)
+	actions := memory.NewCortexActionRepository()
+	registry := NewCortexToolRegistry(actions, fasting, nil, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+	proposed := registry.Propose(ctx, userID, []domain.LLMToolCall{
+		{Name: "start_fast", Arguments: json.RawMessage(`{"plan":"16_8"}`)},
+		{Name: "start_fast", Arguments: json.RawMessage(`{"plan":"18_6"}`)},
+	})
+	require.Len(t, proposed, 2)
+
+	stale, _ := actions.FindByID(ctx, proposed[0].ID)
+	stale.ExpiresAt = time.Now().Add(-time.Minute)
+	require.NoError(t, actions.Update(ctx, stale))
+	_, err := registry.ConfirmAction(ctx, userID, stale.ID)
+	assert.ErrorIs(t, err, domain.ErrCortexActionExpired)
+	stored, _ := actions.FindByID(ctx, stale.ID)
+	assert.Equal(t, domain.CortexActionExpired, stored.Status)
+
+	fasting.On("StartFast", ctx, userID, domain.Plan186, 18, (*time.Time)(nil)).Return(nil, errors.New("active fasting session already exists"))
+	failed, err := registry.ConfirmAction(ctx, userID, proposed[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CortexActionFailed, failed.Status)
+	assert.Equal(t, "active fasting session already exists", failed.Error)
+	assert.Empty(t, failed.Result)
+}
+
+func TestCortexToolRegistry_ConfirmRunsOnceAcrossInstances(t *testing.T) {
+	fasting := new(MockFastingService)
+	actions := memory.NewCortexActionRepository()
+	// Two registries over one store stand in for two server instances
+	instances := []*CortexToolRegistry{
+		NewCortexToolRegistry(actions, fasting, nil, nil),
+		NewCortexToolRegistry(actions, fasting, nil, nil),
+	}
+	ctx := context.Background()
+	userID := uuid.New()
+	proposed := instances[0].Propose(ctx, userID, []domain.LLMToolCall{{Name: "stop_fast", Arguments: json.RawMessage(`{}`)}})
+	require.Len(t, proposed, 1)
+	actionID := proposed[0].ID
+
+	fasting.On("StopFast", ctx, userID).
+		Run(func(mock.Arguments) {
+			running, _ := actions.FindByID(ctx, actionID)
+			assert.Equal(t, domain.CortexActionExecuting, running.Status)
+			_, err := instances[1].RejectAction(ctx, userID, actionID)
+			assert.ErrorIs(t, err, domain.ErrCortexActionDecided, "a running action can't be rejected")
+			time.Sleep(10 * time.Millisecond)
+		}).
+		Return(&domain.FastingSession{ID: uuid.New()}, nil)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(registry *CortexToolRegistry) {
+			defer wg.Done()
+			_, err := registry.ConfirmAction(ctx, userID, actionID)
+			errs <- err
+		}(instances[i%2])
+	}
+	wg.Wait()
+	close(errs)
+
+	confirmed := 0
+	for err := range errs {
+		if err == nil {
+			confirmed++
+		} else {
+			assert.ErrorIs(t, err, domain.ErrCortexActionDecided)
+		}
+	}
+	assert.Equal(t, 1, confirmed)
+	fasting.AssertNumberOfCalls(t, "StopFast", 1)
+	stored, _ := actions.FindByID(ctx, actionID)
+	assert.Equal(t, domain.CortexActionExecuted, stored.Status)
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cached Cortex features, as reported by CacheStats
+const (
+	cacheFeatureMilestoneInsight = "milestone_insight"
+	cacheFeatureDailyQuote       = "daily_quote"
+)
+
+// milestoneInsightTTL is how long a milestone insight is shared by everyone reaching it
+const milestoneInsightTTL = 24 * time.Hour
+
+// GetUsage returns what the user has spent on Cortex today against their plan's budget
+func (s *CortexService) GetUsage(ctx context.Context, userID uuid.UUID) (*domain.CortexUsageView, error) {
+	if s.usage == nil {
+		return nil, errors.New("cortex usage accounting is not enabled")
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	now := time.Now()
+	usage, err := s.usage.GetUsage(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	tier, budget := domain.CortexBudgetFor(user.Entitlements())
+	return &domain.CortexUsageView{
+		Tier:     tier,
+		Budget:   budget,
+		Requests: usage.Requests,
+		Tokens:   usage.Tokens,
+		ResetsAt: domain.CortexUsageDay(now).Add(24 * time.Hour),
+	}, nil
+}
+
+// CacheStats returns response cache hits and misses per feature since startup
+func (s *CortexService) CacheStats() []domain.CortexCacheStats {
+	s.cacheStatsMu.Lock()
+	defer s.cacheStatsMu.Unlock()
+	stats := make([]domain.CortexCacheStats, 0, len(s.cacheStats))
+	for _, st := range s.cacheStats {
+		if total := st.Hits + st.Misses; total > 0 {
+			st.HitRate = float64(st.Hits) / float64(total)
+		}
+		stats = append(stats, st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Feature < stats[j].Feature })
+	return stats
+}
+
+// checkBudget returns a *domain.CortexQuotaError once the user has spent today's budget
+func (s *CortexService) checkBudget(ctx context.Context, userID uuid.UUID) error {
+	view, err := s.GetUsage(ctx, userID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case view.Requests >= view.Budget.DailyRequests:
+		return &domain.CortexQuotaError{Limit: "requests", ResetsAt: view.ResetsAt}
+	case view.Tokens >= view.Budget.DailyTokens:
+		return &domain.CortexQuotaError{Limit: "tokens", ResetsAt: view.ResetsAt}
+	}
+	return nil
+}
+
+// recordUsage charges one LLM call with the given prompts and reply. Accounting failures are
+// logged rather than failing a request that has already been answered.
+func (s *CortexService) recordUsage(ctx context.Context, userID uuid.UUID, texts ...string) {
+	tokens := domain.EstimateTokens(texts...)
+	if err := s.usage.AddUsage(context.WithoutCancel(ctx), userID, time.Now(), 1, tokens); err != nil {
+		logger.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to record cortex usage")
+	}
+}
+
+// userProvider returns the LLM for useCase with every call charged to the user's daily budget
+func (s *CortexService) userProvider(useCase domain.LLMUseCase, userID uuid.UUID) ports.LLMProvider {
+	provider := s.provider(useCase)
+	if s.usage == nil {
+		return provider
+	}
+	return &meteredProvider{service: s, inner: provider, userID: userID}
+}
+
+// meteredProvider checks the budget before each call and records it afterwards, failed calls
+// included since the provider still bills them
+type meteredProvider struct {
+	service *CortexService
+	inner   ports.LLMProvider
+	userID  uuid.UUID
+}
+
+func (p *meteredProvider) GenerateResponse(ctx context.Context, prompt, systemPrompt string) (string, error) {
+	if err := p.service.checkBudget(ctx, p.userID); err != nil {
+		return "", err
+	}
+	response, err := p.inner.GenerateResponse(ctx, prompt, systemPrompt)
+	p.service.recordUsage(ctx, p.userID, prompt, systemPrompt, response)
+	return response, err
+}
+
+func (p *meteredProvider) AnalyzeImage(ctx context.Context, imageBase64, prompt string) (string, error) {
+	if err := p.service.checkBudget(ctx, p.userID); err != nil {
+		return "", err
+	}
+	response, err := p.inner.AnalyzeImage(ctx, imageBase64, prompt)
+	p.service.recordUsage(ctx, p.userID, prompt, response)
+	return response, err
+}
+
+func (p *meteredProvider) Converse(ctx context.Context, systemPrompt string, messages []domain.LLMMessage) (string, error) {
+	if err := p.service.checkBudget(ctx, p.userID); err != nil {
+		return "", err
+	}
+	response, err := p.inner.Converse(ctx, systemPrompt, messages)
+	p.service.recordUsage(ctx, p.userID, append(messageTexts(messages), systemPrompt, response)...)
+	return response, err
+}
+
+func (p *meteredProvider) ConverseStream(ctx context.Context, systemPrompt string, messages []domain.LLMMessage, onDelta func(delta string) error) (string, error) {
+	if err := p.service.checkBudget(ctx, p.userID); err != nil {
+		return "", err
+	}
+	response, err := converseStream(ctx, p.inner, systemPrompt, messages, onDelta)
+	p.service.recordUsage(ctx, p.userID, append(messageTexts(messages), systemPrompt, response)...)
+	return response, err
+}
+
+func (p *meteredProvider) ConverseWithTools(ctx context.Context, systemPrompt string, messages []domain.LLMMessage, tools []domain.LLMTool) (*domain.LLMToolReply, error) {
+	toolProvider, ok := p.inner.(ports.ToolCallingLLMProvider)
+	if !ok {
+		return nil, errToolsUnsupported
+	}
+	if err := p.service.checkBudget(ctx, p.userID); err != nil {
+		return nil, err
+	}
+	reply, err := toolProvider.ConverseWithTools(ctx, systemPrompt, messages, tools)
+	texts := append(messageTexts(messages), systemPrompt)
+	for _, tool := range tools {
+		texts = append(texts, tool.Description, string(tool.Parameters))
+	}
+	if reply != nil {
+		texts = append(texts, reply.Text)
+		for _, call := range reply.ToolCalls {
+			texts = append(texts, call.Name, string(call.Arguments))
+		}
+	}
+	p.service.recordUsage(ctx, p.userID, texts...)
+	return reply, err
+}
+
+func messageTexts(messages []domain.LLMMessage) []string {
+	texts := make([]string, len(messages))
+	for i, m := range messages {
+		texts[i] = m.Content
+	}
+	return texts
+}
+
+// cached looks key up in the response cache. A failing cache counts as a miss.
+func (s *CortexService) cached(ctx context.Context, feature, key string) (string, bool) {
+	if s.cache == nil {
+		return "", false
+	}
+	value, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		logger.Warn().Err(err).Str("feature", feature).Msg("Cortex cache lookup failed")
+		ok = false
+	}
+
+	s.cacheStatsMu.Lock()
+	defer s.cacheStatsMu.Unlock()
+	st := s.cacheStats[feature]
+	st.Feature = feature
+	if ok {
+		st.Hits++
+	} else {
+		st.Misses++
+	}
+	s.cacheStats[feature] = st
+	return value, ok
+}
+
+func (s *CortexService) storeCached(ctx context.Context, feature, key, value string, ttl time.Duration) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Set(ctx, key, value, ttl); err != nil {
+		logger.Warn().Err(err).Str("feature", feature).Msg("Failed to cache cortex response")
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fastinghero/internal/adapters/repository/memory"
+	"fastinghero/internal/core/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMeteredCortexService(user *domain.User) (*CortexService, *MockLLMProvider, *memory.CortexUsageRepository) {
+	mockLLM := new(MockLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	usage := memory.NewCortexUsageRepository()
+
+	mockUserRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	mockFastingRepo.On("FindActiveByUserID", mock.Anything, user.ID).Return(nil, nil)
+	mockFastingRepo.On("FindByUserID", mock.Anything, user.ID).Return([]domain.FastingSession{}, nil)
+
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, usage, memory.NewCortexResponseCache(10), nil, nil, nil)
+	return service, mockLLM, usage
+}
+
+func TestCortexUsage_ChargesEachCall(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New()}
+	service, mockLLM, usage := newTestMeteredCortexService(user)
+	mockLLM.On("GenerateResponse", ctx, "Should I eat?", mock.Anything).Return("No. Drink water.", nil)
+
+	_, err := service.Chat(ctx, user.ID, "Should I eat?")
+	require.NoError(t, err)
+
+	today, err := usage.GetUsage(ctx, user.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, today.Requests)
+	assert.Greater(t, today.Tokens, domain.EstimateTokens("Should I eat?", "No. Drink water."))
+}
+
+func TestCortexUsage_RejectsCallsOverBudget(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), SubscriptionTier: domain.TierFree}
+	service, mockLLM, usage := newTestMeteredCortexService(user)
+	_, budget := domain.CortexBudgetFor(user.Entitlements())
+	require.NoError(t, usage.AddUsage(ctx, user.ID, time.Now(), budget.DailyRequests, 0))
+
+	_, err := service.Chat(ctx, user.ID, "Should I eat?")
+
+	assert.ErrorIs(t, err, domain.ErrCortexQuotaExceeded)
+	var quotaErr *domain.CortexQuotaError
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, "requests", quotaErr.Limit)
+	assert.Equal(t, domain.CortexUsageDay(time.Now()).Add(24*time.Hour), quotaErr.ResetsAt)
+	mockLLM.AssertNotCalled(t, "GenerateResponse", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCortexUsage_BackgroundPromptsAreFree(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), SubscriptionTier: domain.TierFree}
+	service, mockLLM, usage := newTestMeteredCortexService(user)
+	_, budget := domain.CortexBudgetFor(user.Entitlements())
+	require.NoError(t, usage.AddUsage(ctx, user.ID, time.Now(), budget.DailyRequests, 0))
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("Your streak is worth protecting.", nil)
+
+	reply, err := service.GenerateFromPrompt(ctx, user.ID, promptStreakIntervention, domain.PromptVars{"streak": 5, "hours_left": 3, "discipline_index": 40.0})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Your streak is worth protecting.", reply)
+	today, err := usage.GetUsage(ctx, user.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, budget.DailyRequests, today.Requests)
+}
+
+func TestCortexUsage_TokenBudget(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New()}
+	service, _, usage := newTestMeteredCortexService(user)
+	_, budget := domain.CortexBudgetFor(user.Entitlements())
+	require.NoError(t, usage.AddUsage(ctx, user.ID, time.Now(), 1, budget.DailyTokens))
+
+	_, err := service.GenerateInsight(ctx, user.ID, 16)
+
+	var quotaErr *domain.CortexQuotaError
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, "tokens", quotaErr.Limit)
+}
+
+func TestCortexUsage_BudgetFollowsActivePlan(t *testing.T) {
+	ctx := context.Background()
+	coach := &domain.User{ID: uuid.New(), SubscriptionTier: domain.TierAICoach, SubscriptionStatus: domain.SubStatusActive}
+	service, _, _ := newTestMeteredCortexService(coach)
+
+	view, err := service.GetUsage(ctx, coach.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TierAICoach, view.Tier)
+
+	lapsed := &domain.User{ID: uuid.New(), SubscriptionTier: domain.TierAICoach, SubscriptionStatus: domain.SubStatusCanceled}
+	service, _, _ = newTestMeteredCortexService(lapsed)
+	lapsedView, err := service.GetUsage(ctx, lapsed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TierFree, lapsedView.Tier)
+	assert.Greater(t, view.Budget.DailyRequests, lapsedView.Budget.DailyRequests)
+}
+
+func TestCortexCache_MilestoneInsightIsSharedPerMilestone(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New()}
+	service, mockLLM, usage := newTestMeteredCortexService(user)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).
+		Return(`{"insight":"Ketosis is in full swing.","benefits":["Fat burning"],"motivation":"Hold the line."}`, nil).Once()
+
+	first, err := service.GetFastingMilestoneInsight(ctx, user.ID, 16.5)
+	require.NoError(t, err)
+	second, err := service.GetFastingMilestoneInsight(ctx, user.ID, 18)
+	require.NoError(t, err)
+
+	assert.Equal(t, first["insight"], second["insight"])
+	assert.Equal(t, 18.0, second["hours"])
+	mockLLM.AssertNumberOfCalls(t, "GenerateResponse", 1)
+	today, _ := usage.GetUsage(ctx, user.ID, time.Now())
+	assert.Equal(t, 1, today.Requests, "cache hits are free")
+	assert.Equal(t, []domain.CortexCacheStats{{Feature: cacheFeatureMilestoneInsight, Hits: 1, Misses: 1, HitRate: 0.5}}, service.CacheStats())
+}
+
+func TestCortexCache_FallbackInsightsAreNotCached(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New()}
+	service, mockLLM, _ := newTestMeteredCortexService(user)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("not json", nil)
+
+	_, err := service.GetFastingMilestoneInsight(ctx, user.ID, 16)
+	require.NoError(t, err)
+	_, err = service.GetFastingMilestoneInsight(ctx, user.ID, 16)
+	require.NoError(t, err)
+
+	mockLLM.AssertNumberOfCalls(t, "GenerateResponse", 4) // Two attempts each
+}
+
+func TestCortexCache_DailyQuotePerUserPerDay(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New()}
+	service, mockLLM, _ := newTestMeteredCortexService(user)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("Your first fast is your first victory.", nil).Once()
+
+	first, err := service.GenerateDailyQuote(ctx, user.ID)
+	require.NoError(t, err)
+	second, err := service.GenerateDailyQuote(ctx, user.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	mockLLM.AssertExpectations(t)
+}
+
+func TestCortexCache_DailyQuoteOverBudget(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New()}
+	service, _, usage := newTestMeteredCortexService(user)
+	_, budget := domain.CortexBudgetFor(user.Entitlements())
+	require.NoError(t, usage.AddUsage(ctx, user.ID, time.Now(), budget.DailyRequests, 0))
+
+	_, err := service.GenerateDailyQuote(ctx, user.ID)
+
+	assert.ErrorIs(t, err, domain.ErrCortexQuotaExceeded)
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxDisciplineTimelineDays bounds how far back GetTimeline looks
+const maxDisciplineTimelineDays = 365
+
+// DisciplineService owns User.DisciplineIndex. Every change is recorded as an event with the
+// reason shown to the user, and the daily job decays inactive users and snapshots everyone's
+// end-of-day score, so the timeline can explain how the score got where it is.
+type DisciplineService struct {
+	disciplineRepo ports.DisciplineRepository
+	userRepo       ports.UserRepository
+	rules          domain.DisciplineRules
+}
+
+func NewDisciplineService(disciplineRepo ports.DisciplineRepository, userRepo ports.UserRepository, rules domain.DisciplineRules) *DisciplineService {
+	return &DisciplineService{
+		disciplineRepo: disciplineRepo,
+		userRepo:       userRepo,
+		rules:          rules,
+	}
+}
+
+// RecordFast scores a finished fast: goal-met fasts add points weighted by goal length and plan,
+// fasts ended early lose points scaled by how far from the goal they stopped. Recording the same
+// session twice changes nothing. The user record is updated in memory only; callers save it.
+func (s *DisciplineService) RecordFast(ctx context.Context, user *domain.User, session *domain.FastingSession) (*domain.DisciplineEvent, error) {
+	eventType, delta, reason := s.rules.ScoreFast(session)
+	at := time.Now()
+	if session.EndTime != nil {
+		at = *session.EndTime
+	}
+	return s.apply(ctx, user, eventType, delta, reason, session.ID.String(), at)
+}
+
+// ProcessDay closes a UTC day for every user with a score: users who haven't completed a fast
+// for the grace period lose the daily decay, then the end-of-day score is snapshotted. Both are
+// keyed by the day, so re-running a day is safe. Returns the number of snapshots written.
+func (s *DisciplineService) ProcessDay(ctx context.Context, day time.Time) (int, error) {
+	dayStart := startOfDay(day)
+	written := 0
+	failed := 0
+
+	afterID := uuid.Nil
+	for {
+		users, err := s.userRepo.ListWithDisciplineScore(ctx, afterID, dailySettlementBatchSize)
+		if err != nil {
+			return written, fmt.Errorf("failed to list scored users: %w", err)
+		}
+
+		for _, user := range users {
+			ok, err := s.closeUserDay(ctx, user, dayStart)
+			if err != nil {
+				logger.Error().Err(err).Str("user_id", user.ID.String()).Str("day", dayStart.Format("2006-01-02")).Msg("Failed to close discipline day")
+				failed++
+				continue
+			}
+			if ok {
+				written++
+			}
+		}
+
+		if len(users) < dailySettlementBatchSize {
+			break
+		}
+		afterID = users[len(users)-1].ID
+	}
+
+	if failed > 0 {
+		return written, fmt.Errorf("discipline day %s failed for %d users", dayStart.Format("2006-01-02"), failed)
+	}
+	return written, nil
+}
+
+// GetTimeline returns the user's score with the snapshots and events of the last days
+func (s *DisciplineService) GetTimeline(ctx context.Context, userID uuid.UUID, days int) (*domain.DisciplineTimeline, error) {
+	if days <= 0 || days > maxDisciplineTimelineDays {
+		days = maxDisciplineTimelineDays
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	from := startOfDay(time.Now()).AddDate(0, 0, -days)
+	snapshots, err := s.disciplineRepo.ListSnapshots(ctx, userID, from)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.disciplineRepo.ListEvents(ctx, userID, from)
+	if err != nil {
+		return nil, err
+	}
+	if snapshots == nil {
+		snapshots = []domain.DisciplineSnapshot{}
+	}
+	if events == nil {
+		events = []domain.DisciplineEvent{}
+	}
+	return &domain.DisciplineTimeline{
+		Score:     user.DisciplineIndex,
+		From:      from,
+		Snapshots: snapshots,
+		Events:    events,
+		Rules:     s.rules,
+	}, nil
+}
+
+// closeUserDay applies the day's decay if due and snapshots the score. Returns false if the
+// day was already snapshotted.
+func (s *DisciplineService) closeUserDay(ctx context.Context, user *domain.User, dayStart time.Time) (bool, error) {
+	inactiveDays, err := s.inactiveDays(ctx, user.ID, dayStart)
+	if err != nil {
+		return false, err
+	}
+	if inactiveDays >= s.rules.DecayGraceDays && s.rules.DecayPerDay > 0 {
+		dayEnd := dayStart.Add(24*time.Hour - time.Second)
+		event, err := s.apply(ctx, user, domain.DisciplineDecay, -s.rules.DecayPerDay,
+			s.rules.DecayReason(inactiveDays, -s.rules.DecayPerDay), dayStart.Format("2006-01-02"), dayEnd)
+		if err != nil {
+			return false, err
+		}
+		if event != nil {
+			if err := s.userRepo.UpdateDisciplineIndex(ctx, user.ID, user.DisciplineIndex); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return s.disciplineRepo.SaveSnapshot(ctx, &domain.DisciplineSnapshot{
+		UserID:    user.ID,
+		Day:       dayStart,
+		Score:     user.DisciplineIndex,
+		CreatedAt: time.Now(),
+	})
+}
+
+// inactiveDays counts the days up to and including dayStart's day since the user's last
+// completed fast. Users with no completed fast on record count from their first snapshot, so
+// scores from before the history existed don't decay all at once.
+func (s *DisciplineService) inactiveDays(ctx context.Context, userID uuid.UUID, dayStart time.Time) (int, error) {
+	last, err := s.disciplineRepo.LastEventAt(ctx, userID, domain.DisciplineFastCompleted)
+	if err != nil {
+		return 0, err
+	}
+	if last == nil {
+		snapshots, err := s.disciplineRepo.ListSnapshots(ctx, userID, time.Time{})
+		if err != nil {
+			return 0, err
+		}
+		if len(snapshots) == 0 {
+			return 0, nil
+		}
+		last = &snapshots[0].Day
+	}
+	return int(dayStart.Sub(startOfDay(*last)).Hours() / 24), nil
+}
+
+// apply records the event and changes the score in memory. Returns nil if the event was
+// already recorded.
+func (s *DisciplineService) apply(ctx context.Context, user *domain.User, eventType domain.DisciplineEventType, delta float64, reason, sourceID string, at time.Time) (*domain.DisciplineEvent, error) {
+	score, applied := domain.ApplyDisciplineDelta(user.DisciplineIndex, delta)
+	if applied != delta {
+		reason += fmt.Sprintf(" (%.1f applied, the index stays between %.0f and %.0f)", applied, domain.MinDisciplineIndex, domain.MaxDisciplineIndex)
+	}
+	event := &domain.DisciplineEvent{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		Type:       eventType,
+		Delta:      applied,
+		ScoreAfter: score,
+		Reason:     reason,
+		SourceID:   sourceID,
+		CreatedAt:  at,
+	}
+	recorded, err := s.disciplineRepo.AppendEvent(ctx, event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record discipline change: %w", err)
+	}
+	if !recorded {
+		return nil, nil
+	}
+	user.DisciplineIndex = score
+	return event, nil
+}
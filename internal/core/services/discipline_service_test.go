@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mock DisciplineRepository ---
+
+type MockDisciplineRepository struct {
+	mock.Mock
+}
+
+func (m *MockDisciplineRepository) AppendEvent(ctx context.Context, event *domain.DisciplineEvent) (bool, error) {
+	args := m.Called(ctx, event)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDisciplineRepository) ListEvents(ctx context.Context, userID uuid.UUID, since time.Time) ([]domain.DisciplineEvent, error) {
+	args := m.Called(ctx, userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.DisciplineEvent), args.Error(1)
+}
+
+func (m *MockDisciplineRepository) LastEventAt(ctx context.Context, userID uuid.UUID, eventType domain.DisciplineEventType) (*time.Time, error) {
+	args := m.Called(ctx, userID, eventType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockDisciplineRepository) SaveSnapshot(ctx context.Context, snapshot *domain.DisciplineSnapshot) (bool, error) {
+	args := m.Called(ctx, snapshot)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDisciplineRepository) ListSnapshots(ctx context.Context, userID uuid.UUID, from time.Time) ([]domain.DisciplineSnapshot, error) {
+	args := m.Called(ctx, userID, from)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.DisciplineSnapshot), args.Error(1)
+}
+
+// newTestDisciplineService scores with the default rules and records every event
+func newTestDisciplineService(userRepo *MockUserRepository) *DisciplineService {
+	repo := new(MockDisciplineRepository)
+	repo.On("AppendEvent", mock.Anything, mock.Anything).Return(true, nil)
+	return NewDisciplineService(repo, userRepo, domain.DefaultDisciplineRules)
+}
+
+func finishedFast(plan domain.FastingPlanType, goalHours int, actualHours float64) *domain.FastingSession {
+	end := time.Now()
+	return &domain.FastingSession{
+		ID:                  uuid.New(),
+		PlanType:            plan,
+		GoalHours:           goalHours,
+		ActualDurationHours: actualHours,
+		Status:              domain.StatusCompleted,
+		EndTime:             &end,
+	}
+}
+
+func TestDisciplineRules_ScoreFast(t *testing.T) {
+	rules := domain.DefaultDisciplineRules
+	cases := []struct {
+		name      string
+		session   *domain.FastingSession
+		eventType domain.DisciplineEventType
+		delta     float64
+	}{
+		{"16:8 goal met", finishedFast(domain.Plan168, 16, 16.5), domain.DisciplineFastCompleted, 1.0},
+		{"beginner 12h", finishedFast(domain.PlanBeginner, 12, 12), domain.DisciplineFastCompleted, 0.6},
+		{"36h", finishedFast(domain.Plan36h, 36, 37), domain.DisciplineFastCompleted, 3.4},
+		{"extended is capped", finishedFast(domain.PlanExtended, 120, 120), domain.DisciplineFastCompleted, 6.0},
+		{"quit at the start", finishedFast(domain.Plan168, 16, 0), domain.DisciplineFastBroken, -2.0},
+		{"quit halfway", finishedFast(domain.Plan168, 16, 8), domain.DisciplineFastBroken, -1.0},
+		{"quit near the goal", finishedFast(domain.Plan168, 16, 15.5), domain.DisciplineFastBroken, -0.5},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			eventType, delta, reason := rules.ScoreFast(tc.session)
+			assert.Equal(t, tc.eventType, eventType)
+			assert.InDelta(t, tc.delta, delta, 0.001)
+			assert.NotEmpty(t, reason)
+		})
+	}
+}
+
+func TestDisciplineService_RecordFast(t *testing.T) {
+	repo := new(MockDisciplineRepository)
+	service := NewDisciplineService(repo, nil, domain.DefaultDisciplineRules)
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), DisciplineIndex: 99.5}
+	session := finishedFast(domain.Plan24h, 24, 25)
+
+	repo.On("AppendEvent", ctx, mock.MatchedBy(func(e *domain.DisciplineEvent) bool {
+		return e.Type == domain.DisciplineFastCompleted && e.SourceID == session.ID.String() && e.Delta == 0.5 && e.ScoreAfter == 100
+	})).Return(true, nil).Once()
+
+	event, err := service.RecordFast(ctx, user, session)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, user.DisciplineIndex)
+	assert.Contains(t, event.Reason, "0.5 applied")
+
+	// Recording the same fast again is a no-op
+	repo.On("AppendEvent", ctx, mock.Anything).Return(false, nil).Once()
+	event, err = service.RecordFast(ctx, user, session)
+
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+	assert.Equal(t, 100.0, user.DisciplineIndex)
+}
+
+func TestDisciplineService_ProcessDay(t *testing.T) {
+	repo := new(MockDisciplineRepository)
+	userRepo := new(MockUserRepository)
+	service := NewDisciplineService(repo, userRepo, domain.DefaultDisciplineRules)
+	ctx := context.Background()
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	active := &domain.User{ID: uuid.New(), DisciplineIndex: 40}
+	idle := &domain.User{ID: uuid.New(), DisciplineIndex: 40}
+	legacy := &domain.User{ID: uuid.New(), DisciplineIndex: 40}
+	yesterday := day.Add(-20 * time.Hour)
+	threeDaysAgo := day.AddDate(0, 0, -3).Add(8 * time.Hour)
+
+	userRepo.On("ListWithDisciplineScore", ctx, uuid.Nil, dailySettlementBatchSize).Return([]*domain.User{active, idle, legacy}, nil)
+	repo.On("LastEventAt", ctx, active.ID, domain.DisciplineFastCompleted).Return(&yesterday, nil)
+	repo.On("LastEventAt", ctx, idle.ID, domain.DisciplineFastCompleted).Return(&threeDaysAgo, nil)
+	repo.On("LastEventAt", ctx, legacy.ID, domain.DisciplineFastCompleted).Return(nil, nil)
+	repo.On("ListSnapshots", ctx, legacy.ID, time.Time{}).Return([]domain.DisciplineSnapshot{}, nil)
+	repo.On("AppendEvent", ctx, mock.MatchedBy(func(e *domain.DisciplineEvent) bool {
+		return e.UserID == idle.ID && e.Type == domain.DisciplineDecay && e.SourceID == "2026-03-10" && e.Delta == -1
+	})).Return(true, nil)
+	userRepo.On("UpdateDisciplineIndex", ctx, idle.ID, 39.0).Return(nil)
+	repo.On("SaveSnapshot", ctx, mock.AnythingOfType("*domain.DisciplineSnapshot")).Return(true, nil)
+
+	written, err := service.ProcessDay(ctx, day.Add(15*time.Hour))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, written)
+	assert.Equal(t, 40.0, active.DisciplineIndex)
+	assert.Equal(t, 39.0, idle.DisciplineIndex)
+	assert.Equal(t, 40.0, legacy.DisciplineIndex)
+	repo.AssertNumberOfCalls(t, "AppendEvent", 1)
+	userRepo.AssertNumberOfCalls(t, "UpdateDisciplineIndex", 1)
+	userRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestDisciplineService_GetTimeline(t *testing.T) {
+	repo := new(MockDisciplineRepository)
+	userRepo := new(MockUserRepository)
+	service := NewDisciplineService(repo, userRepo, domain.DefaultDisciplineRules)
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), DisciplineIndex: 42}
+	events := []domain.DisciplineEvent{{UserID: user.ID, Type: domain.DisciplineFastCompleted, Delta: 1, ScoreAfter: 42, Reason: "Completed a 16h 16_8 fast"}}
+
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	repo.On("ListSnapshots", ctx, user.ID, mock.AnythingOfType("time.Time")).Return(nil, nil)
+	repo.On("ListEvents", ctx, user.ID, mock.AnythingOfType("time.Time")).Return(events, nil)
+
+	timeline, err := service.GetTimeline(ctx, user.ID, 30)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42.0, timeline.Score)
+	assert.Equal(t, events, timeline.Events)
+	assert.NotNil(t, timeline.Snapshots)
+	assert.Equal(t, startOfDay(time.Now()).AddDate(0, 0, -30), timeline.From)
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EarningRulesEngine evaluates a user's meals, steps and fasts against the versioned
+// vault earning rules (see LAZY_TAX_RULES.md)
+type EarningRulesEngine struct {
+	rules         *domain.EarningRuleBook
+	mealRepo      ports.MealRepository
+	telemetryRepo ports.TelemetryRepository
+	fastingRepo   ports.FastingRepository
+}
+
+func NewEarningRulesEngine(rules *domain.EarningRuleBook, mealRepo ports.MealRepository, telemetryRepo ports.TelemetryRepository, fastingRepo ports.FastingRepository) *EarningRulesEngine {
+	return &EarningRulesEngine{
+		rules:         rules,
+		mealRepo:      mealRepo,
+		telemetryRepo: telemetryRepo,
+		fastingRepo:   fastingRepo,
+	}
+}
+
+// LoadEarningRuleBook reads a JSON array of rule sets from path.
+// An empty path returns the built-in rules from LAZY_TAX_RULES.md.
+func LoadEarningRuleBook(path string) (*domain.EarningRuleBook, error) {
+	if path == "" {
+		return domain.NewEarningRuleBook(domain.DefaultEarningRuleSet())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read earning rules: %w", err)
+	}
+	var sets []domain.EarningRuleSet
+	if err := json.Unmarshal(data, &sets); err != nil {
+		return nil, fmt.Errorf("failed to parse earning rules: %w", err)
+	}
+	return domain.NewEarningRuleBook(sets...)
+}
+
+// EvaluateDay returns what the user earned on the given day (UTC), bonuses included.
+// The whole month up to that day is replayed with the month's rule set so streaks are exact.
+func (e *EarningRulesEngine) EvaluateDay(ctx context.Context, userID uuid.UUID, day time.Time) (*domain.DayEarnings, error) {
+	dayStart := startOfDay(day)
+	monthStart := time.Date(dayStart.Year(), dayStart.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	ruleSet, err := e.rules.ForDay(dayStart)
+	if err != nil {
+		return nil, err
+	}
+
+	activities, err := e.loadActivity(ctx, userID, monthStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	results := ruleSet.EvaluateMonthToDate(activities)
+	return results[len(results)-1], nil
+}
+
+// loadActivity groups the user's meals, steps and fasts into one DayActivity per day in [from, to)
+func (e *EarningRulesEngine) loadActivity(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.DayActivity, error) {
+	var activities []domain.DayActivity
+	index := make(map[string]int)
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		index[d.Format("2006-01-02")] = len(activities)
+		activities = append(activities, domain.DayActivity{Date: d})
+	}
+	dayOf := func(t time.Time) (*domain.DayActivity, bool) {
+		i, ok := index[t.UTC().Format("2006-01-02")]
+		if !ok {
+			return nil, false
+		}
+		return &activities[i], true
+	}
+
+	meals, err := e.mealRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load meals: %w", err)
+	}
+	for _, meal := range meals {
+		if a, ok := dayOf(meal.LoggedAt); ok {
+			a.Meals = append(a.Meals, meal)
+		}
+	}
+
+	steps, err := e.telemetryRepo.FindByRange(ctx, userID, domain.MetricSteps, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load steps: %w", err)
+	}
+	for _, point := range steps {
+		if a, ok := dayOf(point.Timestamp); ok {
+			a.Steps += point.Value
+		}
+	}
+
+	sessions, err := e.fastingRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fasts: %w", err)
+	}
+	for _, session := range sessions {
+		if session.EndTime == nil {
+			continue
+		}
+		if a, ok := dayOf(*session.EndTime); ok {
+			a.Fasts = append(a.Fasts, session)
+		}
+	}
+
+	return activities, nil
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func completedFastOn(userID uuid.UUID, end time.Time, hours int) domain.FastingSession {
+	return domain.FastingSession{
+		ID:        uuid.New(),
+		UserID:    userID,
+		StartTime: end.Add(-time.Duration(hours) * time.Hour),
+		EndTime:   &end,
+		GoalHours: 16,
+		Status:    domain.StatusCompleted,
+	}
+}
+
+func mealPhoto(userID uuid.UUID, mealType string, at time.Time) domain.Meal {
+	return domain.Meal{ID: uuid.New(), UserID: userID, MealType: mealType, Image: "base64", LoggedAt: at, IsAuthentic: true}
+}
+
+func stepsOn(userID uuid.UUID, at time.Time, steps float64) domain.TelemetryData {
+	return domain.TelemetryData{ID: uuid.New(), UserID: userID, Type: domain.MetricSteps, Value: steps, Timestamp: at}
+}
+
+// perfectDays returns meals and steps worth the full $2.00 for every day in [from, to)
+func perfectDays(userID uuid.UUID, from, to time.Time) ([]domain.Meal, []domain.TelemetryData) {
+	var meals []domain.Meal
+	var steps []domain.TelemetryData
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		meals = append(meals,
+			mealPhoto(userID, "breakfast", d.Add(8*time.Hour)),
+			mealPhoto(userID, "lunch", d.Add(12*time.Hour)),
+			mealPhoto(userID, "dinner", d.Add(19*time.Hour)),
+		)
+		steps = append(steps, stepsOn(userID, d.Add(20*time.Hour), 10500))
+	}
+	return meals, steps
+}
+
+func newTestEarningRulesEngine(t *testing.T, book *domain.EarningRuleBook, userID uuid.UUID, meals []domain.Meal, steps []domain.TelemetryData, fasts []domain.FastingSession) *EarningRulesEngine {
+	if book == nil {
+		var err error
+		book, err = domain.NewEarningRuleBook(domain.DefaultEarningRuleSet())
+		assert.NoError(t, err)
+	}
+	mealRepo := new(MockMealRepository)
+	telemetryRepo := new(MockTelemetryRepository)
+	fastingRepo := new(MockFastingRepository)
+
+	mealRepo.On("FindByUserID", mock.Anything, userID).Return(meals, nil)
+	telemetryRepo.On("FindByRange", mock.Anything, userID, domain.MetricSteps, mock.Anything, mock.Anything).Return(steps, nil)
+	fastingRepo.On("FindByUserID", mock.Anything, userID).Return(fasts, nil)
+
+	return NewEarningRulesEngine(book, mealRepo, telemetryRepo, fastingRepo)
+}
+
+func TestEarningRulesEngine_EvaluateDay_DailyRulesAndCap(t *testing.T) {
+	userID := uuid.New()
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	meals := []domain.Meal{
+		mealPhoto(userID, "breakfast", day.Add(8*time.Hour)),
+		mealPhoto(userID, "breakfast", day.Add(9*time.Hour)),                                                    // Second breakfast doesn't pay
+		{ID: uuid.New(), UserID: userID, MealType: "lunch", Image: "base64", LoggedAt: day.Add(11 * time.Hour)}, // Failed the authenticity check
+		mealPhoto(userID, "lunch", day.Add(12*time.Hour)),
+		mealPhoto(userID, "snack", day.Add(15*time.Hour)),                                       // Not an eligible meal type
+		{ID: uuid.New(), UserID: userID, MealType: "dinner", LoggedAt: day.Add(19 * time.Hour)}, // No photo
+		mealPhoto(userID, "dinner", day.AddDate(0, 0, 1).Add(time.Hour)),                        // Next day
+	}
+	steps := []domain.TelemetryData{
+		stepsOn(userID, day.Add(10*time.Hour), 6000),
+		stepsOn(userID, day.Add(18*time.Hour), 4500),
+	}
+	fasts := []domain.FastingSession{
+		completedFastOn(userID, day.Add(11*time.Hour), 17),
+		completedFastOn(userID, day.Add(23*time.Hour), 10), // Goal not met
+	}
+	engine := newTestEarningRulesEngine(t, nil, userID, meals, steps, fasts)
+
+	result, err := engine.EvaluateDay(context.Background(), userID, day.Add(3*time.Hour))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.RuleVersion)
+	// 2 meals + steps + fast = $2.00, exactly the cap
+	assert.Len(t, result.Items, 4)
+	assert.Equal(t, 2.0, result.DailyTotal)
+	assert.True(t, result.Perfect)
+	assert.Empty(t, result.Bonuses)
+}
+
+func TestEarningRulesEngine_EvaluateDay_TrimsToDailyCap(t *testing.T) {
+	userID := uuid.New()
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	meals, steps := perfectDays(userID, day, day.AddDate(0, 0, 1))
+	fasts := []domain.FastingSession{completedFastOn(userID, day.Add(11*time.Hour), 17)}
+	engine := newTestEarningRulesEngine(t, nil, userID, meals, steps, fasts)
+
+	result, err := engine.EvaluateDay(context.Background(), userID, day)
+
+	assert.NoError(t, err)
+	// 3 meals + steps = $2.00; the fast no longer fits under the cap
+	assert.Len(t, result.Items, 4)
+	assert.Equal(t, 2.0, result.DailyTotal)
+}
+
+func TestEarningRulesEngine_EvaluateDay_StreakBonus(t *testing.T) {
+	userID := uuid.New()
+	monthStart := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	meals, steps := perfectDays(userID, monthStart, monthStart.AddDate(0, 0, 14))
+	engine := newTestEarningRulesEngine(t, nil, userID, meals, steps, nil)
+	ctx := context.Background()
+
+	day6, err := engine.EvaluateDay(ctx, userID, monthStart.AddDate(0, 0, 5))
+	assert.NoError(t, err)
+	assert.Empty(t, day6.Bonuses)
+
+	day7, err := engine.EvaluateDay(ctx, userID, monthStart.AddDate(0, 0, 6))
+	assert.NoError(t, err)
+	assert.Len(t, day7.Bonuses, 1)
+	assert.Equal(t, domain.EarningRuleStreakBonus, day7.Bonuses[0].Kind)
+	assert.Equal(t, 5.0, day7.Bonuses[0].Amount)
+	assert.Equal(t, 7.0, day7.Total())
+
+	day14, err := engine.EvaluateDay(ctx, userID, monthStart.AddDate(0, 0, 13))
+	assert.NoError(t, err)
+	assert.Len(t, day14.Bonuses, 1)
+	assert.NotEqual(t, day7.Bonuses[0].SourceID, day14.Bonuses[0].SourceID)
+}
+
+func TestEarningRulesEngine_EvaluateDay_StreakBrokenByImperfectDay(t *testing.T) {
+	userID := uuid.New()
+	monthStart := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	meals, steps := perfectDays(userID, monthStart, monthStart.AddDate(0, 0, 7))
+	steps = append(steps[:3], steps[4:]...) // Day 4 misses the steps goal
+	engine := newTestEarningRulesEngine(t, nil, userID, meals, steps, nil)
+
+	day7, err := engine.EvaluateDay(context.Background(), userID, monthStart.AddDate(0, 0, 6))
+
+	assert.NoError(t, err)
+	assert.True(t, day7.Perfect)
+	assert.Empty(t, day7.Bonuses)
+}
+
+func TestEarningRulesEngine_EvaluateDay_PerfectMonth(t *testing.T) {
+	userID := uuid.New()
+	monthStart := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	meals, steps := perfectDays(userID, monthStart, monthEnd)
+	engine := newTestEarningRulesEngine(t, nil, userID, meals, steps, nil)
+
+	lastDay, err := engine.EvaluateDay(context.Background(), userID, monthEnd.AddDate(0, 0, -1))
+
+	assert.NoError(t, err)
+	// Feb 28 completes a fourth 7-day streak and the perfect month
+	assert.Len(t, lastDay.Bonuses, 2)
+	assert.Equal(t, domain.EarningRuleStreakBonus, lastDay.Bonuses[0].Kind)
+	assert.Equal(t, domain.EarningRulePerfectMonth, lastDay.Bonuses[1].Kind)
+	assert.Equal(t, 10.0, lastDay.Bonuses[1].Amount)
+}
+
+func TestEarningRulesEngine_NewVersionDoesNotChangeEarlierMonths(t *testing.T) {
+	v2 := domain.DefaultEarningRuleSet()
+	v2.Version = 2
+	v2.EffectiveFrom = time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	v2.Rules[1].Amount = 1.00 // Steps pay more from April
+	book, err := domain.NewEarningRuleBook(v2, domain.DefaultEarningRuleSet())
+	assert.NoError(t, err)
+
+	userID := uuid.New()
+	march := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	steps := []domain.TelemetryData{stepsOn(userID, march.Add(12*time.Hour), 12000), stepsOn(userID, april.Add(12*time.Hour), 12000)}
+	engine := newTestEarningRulesEngine(t, book, userID, nil, steps, nil)
+	ctx := context.Background()
+
+	marchResult, err := engine.EvaluateDay(ctx, userID, march)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, marchResult.RuleVersion)
+	assert.Equal(t, 0.5, marchResult.DailyTotal)
+
+	aprilResult, err := engine.EvaluateDay(ctx, userID, april)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, aprilResult.RuleVersion)
+	assert.Equal(t, 1.0, aprilResult.DailyTotal)
+}
+
+func TestEarningRuleBook_Validation(t *testing.T) {
+	midMonth := domain.DefaultEarningRuleSet()
+	midMonth.EffectiveFrom = time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+	_, err := domain.NewEarningRuleBook(midMonth)
+	assert.Error(t, err)
+
+	startedMonth := domain.DefaultEarningRuleSet()
+	startedMonth.Version = 2
+	startedMonth.PublishedAt = time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)
+	startedMonth.EffectiveFrom = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	_, err = domain.NewEarningRuleBook(domain.DefaultEarningRuleSet(), startedMonth)
+	assert.Error(t, err, "a version can't take effect in the month it is published")
+
+	unpublished := domain.DefaultEarningRuleSet()
+	unpublished.PublishedAt = time.Time{}
+	_, err = domain.NewEarningRuleBook(unpublished)
+	assert.Error(t, err)
+
+	nextMonth := startedMonth
+	nextMonth.EffectiveFrom = time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	_, err = domain.NewEarningRuleBook(domain.DefaultEarningRuleSet(), nextMonth)
+	assert.NoError(t, err)
+
+	sameMonth := domain.DefaultEarningRuleSet()
+	sameMonth.Version = 2
+	_, err = domain.NewEarningRuleBook(domain.DefaultEarningRuleSet(), sameMonth)
+	assert.Error(t, err)
+
+	badRule := domain.DefaultEarningRuleSet()
+	badRule.Rules = append(badRule.Rules, domain.EarningRule{ID: "mystery", Kind: "mystery", Amount: 1})
+	_, err = domain.NewEarningRuleBook(badRule)
+	assert.Error(t, err)
+
+	book, err := domain.NewEarningRuleBook(domain.DefaultEarningRuleSet())
+	assert.NoError(t, err)
+	_, err = book.ForDay(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, domain.ErrNoEarningRules)
+}
+
+func TestLoadEarningRuleBook(t *testing.T) {
+	book, err := LoadEarningRuleBook("")
+	assert.NoError(t, err)
+	rules, err := book.ForDay(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, rules.DailyCap)
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	content := `[{"version": 3, "published_at": "2025-04-20T00:00:00Z", "effective_from": "2025-05-01T00:00:00Z", "daily_cap": 1.5, "perfect_day_amount": 1.5,
+		"rules": [{"id": "steps", "kind": "steps", "amount": 1.5, "threshold": 8000}]}]`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	book, err = LoadEarningRuleBook(path)
+	assert.NoError(t, err)
+	rules, err = book.ForDay(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, rules.Version)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`not json`), 0o600))
+	_, err = LoadEarningRuleBook(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+
+	"github.com/google/uuid"
+)
+
+// EntitlementService is the single place premium features are gated.
+// Plans map to capabilities in domain.PlanCapabilities.
+type EntitlementService struct {
+	userRepo ports.UserRepository
+}
+
+func NewEntitlementService(userRepo ports.UserRepository) *EntitlementService {
+	return &EntitlementService{userRepo: userRepo}
+}
+
+func (s *EntitlementService) GetEntitlements(ctx context.Context, userID uuid.UUID) (*domain.Entitlements, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	return user.Entitlements(), nil
+}
+
+// Require returns an error matching domain.ErrCapabilityRequired if the user's plan doesn't include the capability
+func (s *EntitlementService) Require(ctx context.Context, userID uuid.UUID, capability domain.Capability) error {
+	entitlements, err := s.GetEntitlements(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return entitlements.Require(capability)
+}
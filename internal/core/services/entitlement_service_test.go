@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEntitlementService is a mock of ports.EntitlementService
+type MockEntitlementService struct {
+	mock.Mock
+}
+
+func (m *MockEntitlementService) GetEntitlements(ctx context.Context, userID uuid.UUID) (*domain.Entitlements, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Entitlements), args.Error(1)
+}
+
+func (m *MockEntitlementService) Require(ctx context.Context, userID uuid.UUID, capability domain.Capability) error {
+	args := m.Called(ctx, userID, capability)
+	return args.Error(0)
+}
+
+// allowAllEntitlements returns an entitlement mock that grants every capability
+func allowAllEntitlements() *MockEntitlementService {
+	m := new(MockEntitlementService)
+	m.On("Require", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	return m
+}
+
+func TestEntitlementService_PlanCapabilities(t *testing.T) {
+	tests := []struct {
+		tier    domain.SubscriptionTier
+		granted []domain.Capability
+		denied  []domain.Capability
+	}{
+		{
+			tier:   domain.TierFree,
+			denied: []domain.Capability{domain.CapabilityVault, domain.CapabilityHardKetoData, domain.CapabilityAIMealAnalysis},
+		},
+		{
+			tier:    domain.TierVault,
+			granted: []domain.Capability{domain.CapabilityVault, domain.CapabilityHardKetoData},
+			denied:  []domain.Capability{domain.CapabilityPartnerMatching, domain.CapabilityGroupPots, domain.CapabilityAIMealAnalysis},
+		},
+		{
+			tier:    domain.TierAccountabilityPlus,
+			granted: []domain.Capability{domain.CapabilityVault, domain.CapabilityHardKetoData, domain.CapabilityPartnerMatching, domain.CapabilitySharedGoals, domain.CapabilityGroupPots},
+			denied:  []domain.Capability{domain.CapabilityAIMealAnalysis, domain.CapabilityAICoaching},
+		},
+		{
+			tier:    domain.TierAICoach,
+			granted: []domain.Capability{domain.CapabilityVault, domain.CapabilityHardKetoData, domain.CapabilityAIMealAnalysis, domain.CapabilityAICoaching},
+			denied:  []domain.Capability{domain.CapabilityPartnerMatching, domain.CapabilityGroupPots},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.tier), func(t *testing.T) {
+			mockUserRepo := new(MockUserRepository)
+			service := NewEntitlementService(mockUserRepo)
+			ctx := context.Background()
+			userID := uuid.New()
+
+			user := &domain.User{ID: userID, SubscriptionTier: tt.tier, SubscriptionStatus: domain.SubStatusActive}
+			mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
+
+			for _, c := range tt.granted {
+				assert.NoError(t, service.Require(ctx, userID, c), c)
+			}
+			for _, c := range tt.denied {
+				assert.ErrorIs(t, service.Require(ctx, userID, c), domain.ErrCapabilityRequired, c)
+			}
+		})
+	}
+}
+
+func TestEntitlementService_InactiveSubscriptionHasNoCapabilities(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service := NewEntitlementService(mockUserRepo)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	user := &domain.User{ID: userID, SubscriptionTier: domain.TierAICoach, SubscriptionStatus: domain.SubStatusUnpaid}
+	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
+
+	entitlements, err := service.GetEntitlements(ctx, userID)
+
+	assert.NoError(t, err)
+	assert.Empty(t, entitlements.Capabilities)
+	assert.False(t, user.IsVaultMember())
+}
+
+func TestEntitlementService_RequireNamesPlans(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service := NewEntitlementService(mockUserRepo)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	user := &domain.User{ID: userID, SubscriptionTier: domain.TierVault, SubscriptionStatus: domain.SubStatusActive}
+	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
+
+	err := service.Require(ctx, userID, domain.CapabilityGroupPots)
+
+	var entErr *domain.EntitlementError
+	assert.True(t, errors.As(err, &entErr))
+	assert.Equal(t, domain.CapabilityGroupPots, entErr.Capability)
+	assert.Equal(t, []domain.SubscriptionTier{domain.TierAccountabilityPlus}, entErr.RequiredPlans)
+}
+
+func TestEntitlementService_UserLookupFails(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service := NewEntitlementService(mockUserRepo)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(nil, errors.New("db error"))
+
+	err := service.Require(ctx, userID, domain.CapabilityVault)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, domain.ErrCapabilityRequired)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,13 +15,17 @@ type FastingService struct {
 	repo         ports.FastingRepository
 	vaultService ports.VaultService
 	userRepo     ports.UserRepository
+	discipline   ports.DisciplineService
+	referrals    ports.ReferralService // nil: completed fasts don't qualify referrals
 }
 
-func NewFastingService(repo ports.FastingRepository, vaultService ports.VaultService, userRepo ports.UserRepository) *FastingService {
+func NewFastingService(repo ports.FastingRepository, vaultService ports.VaultService, userRepo ports.UserRepository, discipline ports.DisciplineService, referrals ports.ReferralService) *FastingService {
 	return &FastingService{
 		repo:         repo,
 		vaultService: vaultService,
 		userRepo:     userRepo,
+		discipline:   discipline,
+		referrals:    referrals,
 	}
 }
 
@@ -87,18 +92,11 @@ func (s *FastingService) StopFast(ctx context.Context, userID uuid.UUID) (*domai
 	// 3. Update Discipline & Price
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err == nil {
-		// Update discipline based on goal completion
-		if goalMet {
-			s.vaultService.UpdateDisciplineIndex(ctx, user, true, false)
-		} else {
-			// Penalize for quitting early (Lazy Tax)
-			user.DisciplineIndex -= 2
-			if user.DisciplineIndex < 0 {
-				user.DisciplineIndex = 0
-			}
-			// Recalculate price
-			user.CurrentPrice = s.vaultService.CalculatePrice(ctx, user)
+		// Goal-met fasts raise the index by difficulty; quitting early costs points (Lazy Tax)
+		if _, err := s.discipline.RecordFast(ctx, user, session); err != nil {
+			return nil, err
 		}
+		user.CurrentPrice = s.vaultService.CalculatePrice(ctx, user)
 	}
 
 	// 4. Update session (use Update instead of Save since session already exists)
@@ -111,6 +109,13 @@ func (s *FastingService) StopFast(ctx context.Context, userID uuid.UUID) (*domai
 		return nil, err
 	}
 
+	// 6. A fast that met its goal qualifies the user's referral
+	if goalMet && s.referrals != nil {
+		if err := s.referrals.QualifyReferral(ctx, userID, domain.ReferralEventFirstFast); err != nil {
+			logger.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to qualify referral")
+		}
+	}
+
 	return session, nil
 }
 
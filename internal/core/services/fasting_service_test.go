@@ -64,10 +64,6 @@ func (m *MockVaultService) GetCurrentParticipation(ctx context.Context, userID u
 	return args.Get(0).(*domain.VaultParticipation), args.Error(1)
 }
 
-func (m *MockVaultService) UpdateDisciplineIndex(ctx context.Context, user *domain.User, completedFast, verifiedKetosis bool) {
-	m.Called(ctx, user, completedFast, verifiedKetosis)
-}
-
 func (m *MockVaultService) CalculatePrice(ctx context.Context, user *domain.User) float64 {
 	args := m.Called(ctx, user)
 	return args.Get(0).(float64)
@@ -78,8 +74,45 @@ func (m *MockVaultService) ProcessDailyEarnings(ctx context.Context) error {
 	return args.Error(0)
 }
 
-func (m *MockVaultService) AddDailyEarnings(ctx context.Context, user *domain.User, amount float64) {
-	m.Called(ctx, user, amount)
+func (m *MockVaultService) ProcessMonthlyRefunds(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockVaultService) AddReferralReward(ctx context.Context, user *domain.User, referralID uuid.UUID, amount float64) error {
+	args := m.Called(ctx, user, referralID, amount)
+	return args.Error(0)
+}
+
+func (m *MockVaultService) RecordDeposit(ctx context.Context, user *domain.User, amount float64, chargeID string, paidAt time.Time) error {
+	args := m.Called(ctx, user, amount, chargeID, paidAt)
+	return args.Error(0)
+}
+
+func (m *MockVaultService) CollectStake(ctx context.Context, userID uuid.UUID, amount float64, description, idempotencyKey string) (string, error) {
+	args := m.Called(ctx, userID, amount, description, idempotencyKey)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockVaultService) PayOutStake(ctx context.Context, userID uuid.UUID, payout domain.StakePayout) error {
+	args := m.Called(ctx, userID, payout)
+	return args.Error(0)
+}
+
+func (m *MockVaultService) GetLedger(ctx context.Context, userID uuid.UUID) (*domain.VaultLedger, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VaultLedger), args.Error(1)
+}
+
+func (m *MockVaultService) GetStatement(ctx context.Context, userID uuid.UUID, from, to time.Time) (*domain.VaultStatement, error) {
+	args := m.Called(ctx, userID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VaultStatement), args.Error(1)
 }
 
 func (m *MockVaultService) CalculateVaultStatus(user *domain.User) (deposit float64, earned float64, potentialRefund float64) {
@@ -99,7 +132,7 @@ func TestFastingService_StartFast_Success(t *testing.T) {
 	mockVault := new(MockVaultService)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewFastingService(mockRepo, mockVault, mockUserRepo)
+	service := NewFastingService(mockRepo, mockVault, mockUserRepo, newTestDisciplineService(mockUserRepo), nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -123,7 +156,7 @@ func TestFastingService_StartFast_AlreadyActive(t *testing.T) {
 	mockVault := new(MockVaultService)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewFastingService(mockRepo, mockVault, mockUserRepo)
+	service := NewFastingService(mockRepo, mockVault, mockUserRepo, newTestDisciplineService(mockUserRepo), nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -160,7 +193,7 @@ func TestFastingService_StartFast_AllPlanTypes(t *testing.T) {
 			mockVault := new(MockVaultService)
 			mockUserRepo := new(MockUserRepository)
 
-			service := NewFastingService(mockRepo, mockVault, mockUserRepo)
+			service := NewFastingService(mockRepo, mockVault, mockUserRepo, newTestDisciplineService(mockUserRepo), nil)
 			ctx := context.Background()
 			userID := uuid.New()
 
@@ -183,7 +216,7 @@ func TestFastingService_StartFast_CustomStartTime(t *testing.T) {
 	mockVault := new(MockVaultService)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewFastingService(mockRepo, mockVault, mockUserRepo)
+	service := NewFastingService(mockRepo, mockVault, mockUserRepo, newTestDisciplineService(mockUserRepo), nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -206,7 +239,7 @@ func TestFastingService_StopFast_Success(t *testing.T) {
 	mockVault := new(MockVaultService)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewFastingService(mockRepo, mockVault, mockUserRepo)
+	service := NewFastingService(mockRepo, mockVault, mockUserRepo, newTestDisciplineService(mockUserRepo), nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -227,7 +260,7 @@ func TestFastingService_StopFast_Success(t *testing.T) {
 
 	mockRepo.On("FindActiveByUserID", ctx, userID).Return(activeSession, nil)
 	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
-	mockVault.On("UpdateDisciplineIndex", ctx, user, true, false).Return()
+	mockVault.On("CalculatePrice", ctx, user).Return(10.0)
 	mockRepo.On("Update", ctx, mock.AnythingOfType("*domain.FastingSession")).Return(nil)
 	mockUserRepo.On("Save", ctx, user).Return(nil)
 
@@ -238,6 +271,7 @@ func TestFastingService_StopFast_Success(t *testing.T) {
 	assert.Equal(t, domain.StatusCompleted, session.Status)
 	assert.NotNil(t, session.EndTime)
 	assert.True(t, session.ActualDurationHours >= 17)
+	assert.Equal(t, 51.0, user.DisciplineIndex) // A 16h 16:8 fast is worth 1 point
 }
 
 func TestFastingService_StopFast_NoActiveSession(t *testing.T) {
@@ -245,7 +279,7 @@ func TestFastingService_StopFast_NoActiveSession(t *testing.T) {
 	mockVault := new(MockVaultService)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewFastingService(mockRepo, mockVault, mockUserRepo)
+	service := NewFastingService(mockRepo, mockVault, mockUserRepo, newTestDisciplineService(mockUserRepo), nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -262,7 +296,7 @@ func TestFastingService_StopFast_EarlyEnd(t *testing.T) {
 	mockVault := new(MockVaultService)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewFastingService(mockRepo, mockVault, mockUserRepo)
+	service := NewFastingService(mockRepo, mockVault, mockUserRepo, newTestDisciplineService(mockUserRepo), nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -315,7 +349,7 @@ func TestFastingService_StopFast_PhaseCalculation(t *testing.T) {
 			mockVault := new(MockVaultService)
 			mockUserRepo := new(MockUserRepository)
 
-			service := NewFastingService(mockRepo, mockVault, mockUserRepo)
+			service := NewFastingService(mockRepo, mockVault, mockUserRepo, newTestDisciplineService(mockUserRepo), nil)
 			ctx := context.Background()
 			userID := uuid.New()
 
@@ -336,7 +370,6 @@ func TestFastingService_StopFast_PhaseCalculation(t *testing.T) {
 
 			mockRepo.On("FindActiveByUserID", ctx, userID).Return(activeSession, nil)
 			mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
-			mockVault.On("UpdateDisciplineIndex", ctx, user, mock.Anything, false).Return()
 			mockVault.On("CalculatePrice", ctx, user).Return(10.0)
 			mockRepo.On("Update", ctx, mock.AnythingOfType("*domain.FastingSession")).Return(nil)
 			mockUserRepo.On("Save", ctx, user).Return(nil)
@@ -354,7 +387,7 @@ func TestFastingService_GetCurrentFast_Active(t *testing.T) {
 	mockVault := new(MockVaultService)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewFastingService(mockRepo, mockVault, mockUserRepo)
+	service := NewFastingService(mockRepo, mockVault, mockUserRepo, newTestDisciplineService(mockUserRepo), nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -378,7 +411,7 @@ func TestFastingService_GetCurrentFast_None(t *testing.T) {
 	mockVault := new(MockVaultService)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewFastingService(mockRepo, mockVault, mockUserRepo)
+	service := NewFastingService(mockRepo, mockVault, mockUserRepo, newTestDisciplineService(mockUserRepo), nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -395,7 +428,7 @@ func TestFastingService_GetFastingHistory(t *testing.T) {
 	mockVault := new(MockVaultService)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewFastingService(mockRepo, mockVault, mockUserRepo)
+	service := NewFastingService(mockRepo, mockVault, mockUserRepo, newTestDisciplineService(mockUserRepo), nil)
 	ctx := context.Background()
 	userID := uuid.New()
 
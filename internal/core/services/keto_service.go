@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"errors"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
 
@@ -10,24 +9,20 @@ import (
 )
 
 type KetoService struct {
-	repo     ports.KetoRepository
-	userRepo ports.UserRepository
+	repo         ports.KetoRepository
+	entitlements ports.EntitlementService
 }
 
-func NewKetoService(repo ports.KetoRepository, userRepo ports.UserRepository) *KetoService {
-	return &KetoService{repo: repo, userRepo: userRepo}
+func NewKetoService(repo ports.KetoRepository, entitlements ports.EntitlementService) *KetoService {
+	return &KetoService{repo: repo, entitlements: entitlements}
 }
 
 func (s *KetoService) LogEntry(ctx context.Context, userID uuid.UUID, entry domain.KetoEntry) error {
-	// Check if user is premium if hard data is present
+	// Hard data inputs are a premium capability
 	if entry.KetoneLevel != nil || entry.AcetoneLevel != nil {
-		user, err := s.userRepo.FindByID(ctx, userID)
-		if err != nil {
+		if err := s.entitlements.Require(ctx, userID, domain.CapabilityHardKetoData); err != nil {
 			return err
 		}
-		if !user.IsVaultMember() {
-			return errors.New("premium subscription required for hard data inputs")
-		}
 	}
 
 	entry.UserID = userID
@@ -35,7 +35,7 @@ func TestKetoService_LogEntry_Success_SoftData(t *testing.T) {
 	mockKetoRepo := new(MockKetoRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewKetoService(mockKetoRepo, mockUserRepo)
+	service := NewKetoService(mockKetoRepo, NewEntitlementService(mockUserRepo))
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -56,7 +56,7 @@ func TestKetoService_LogEntry_HardData_PremiumUser(t *testing.T) {
 	mockKetoRepo := new(MockKetoRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewKetoService(mockKetoRepo, mockUserRepo)
+	service := NewKetoService(mockKetoRepo, NewEntitlementService(mockUserRepo))
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -83,7 +83,7 @@ func TestKetoService_LogEntry_HardData_NonPremiumUser(t *testing.T) {
 	mockKetoRepo := new(MockKetoRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewKetoService(mockKetoRepo, mockUserRepo)
+	service := NewKetoService(mockKetoRepo, NewEntitlementService(mockUserRepo))
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -110,7 +110,7 @@ func TestKetoService_LogEntry_HardData_UserNotFound(t *testing.T) {
 	mockKetoRepo := new(MockKetoRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewKetoService(mockKetoRepo, mockUserRepo)
+	service := NewKetoService(mockKetoRepo, NewEntitlementService(mockUserRepo))
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -130,7 +130,7 @@ func TestKetoService_LogEntry_SaveError(t *testing.T) {
 	mockKetoRepo := new(MockKetoRepository)
 	mockUserRepo := new(MockUserRepository)
 
-	service := NewKetoService(mockKetoRepo, mockUserRepo)
+	service := NewKetoService(mockKetoRepo, NewEntitlementService(mockUserRepo))
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
 	"time"
@@ -10,14 +11,16 @@ import (
 )
 
 type MealService struct {
-	repo   ports.MealRepository
-	cortex ports.CortexService
+	repo         ports.MealRepository
+	cortex       ports.CortexService
+	entitlements ports.EntitlementService
 }
 
-func NewMealService(repo ports.MealRepository, cortex ports.CortexService) *MealService {
+func NewMealService(repo ports.MealRepository, cortex ports.CortexService, entitlements ports.EntitlementService) *MealService {
 	return &MealService{
-		repo:   repo,
-		cortex: cortex,
+		repo:         repo,
+		cortex:       cortex,
+		entitlements: entitlements,
 	}
 }
 
@@ -29,8 +32,9 @@ func (s *MealService) LogMeal(ctx context.Context, userID uuid.UUID, name string
 	// Only analyze if image or description is provided AND we don't have manual data (or we want to augment it)
 	// For now, if manual data is provided, we skip analysis to save tokens/time, unless explicitly requested?
 	// Let's say if image is provided, we always analyze.
-	if image != "" || (description != "" && name == "") {
-		// 1. Analyze Meal
+	analyze := image != "" || (description != "" && name == "")
+	if analyze {
+		// 1. Analyze Meal. Every plan gets the authenticity check, vault earnings depend on it.
 		analysis, isAuthentic, isKeto, err = s.cortex.AnalyzeMeal(ctx, image, description)
 		if err != nil {
 			// Fallback
@@ -38,6 +42,14 @@ func (s *MealService) LogMeal(ctx context.Context, userID uuid.UUID, name string
 			isAuthentic = true // Default to optimistic
 			isKeto = true
 		}
+
+		// 2. The AI commentary is a premium capability; other plans get the checks without it
+		if err := s.entitlements.Require(ctx, userID, domain.CapabilityAIMealAnalysis); err != nil {
+			if !errors.Is(err, domain.ErrCapabilityRequired) {
+				return nil, err
+			}
+			analysis = ""
+		}
 	}
 
 	// If name is empty but we have description, use description as name
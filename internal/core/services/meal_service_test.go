@@ -42,14 +42,20 @@ type MockCortexServiceForMeal struct {
 	mock.Mock
 }
 
-func (m *MockCortexServiceForMeal) Chat(ctx context.Context, userID uuid.UUID, message string) (string, error) {
+func (m *MockCortexServiceForMeal) Chat(ctx context.Context, userID uuid.UUID, message string) (*domain.CortexAnswer, error) {
 	args := m.Called(ctx, userID, message)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CortexAnswer), args.Error(1)
 }
 
-func (m *MockCortexServiceForMeal) GenerateInsight(ctx context.Context, userID uuid.UUID, fastingHours float64) (string, error) {
+func (m *MockCortexServiceForMeal) GenerateInsight(ctx context.Context, userID uuid.UUID, fastingHours float64) (*domain.CortexAnswer, error) {
 	args := m.Called(ctx, userID, fastingHours)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CortexAnswer), args.Error(1)
 }
 
 func (m *MockCortexServiceForMeal) AnalyzeMeal(ctx context.Context, imageBase64, description string) (string, bool, bool, error) {
@@ -62,12 +68,25 @@ func (m *MockCortexServiceForMeal) GetCravingHelp(ctx context.Context, userID uu
 	return args.Get(0), args.Error(1)
 }
 
+func (m *MockCortexServiceForMeal) AnalyzeWeek(ctx context.Context, userID uuid.UUID, week domain.WeekSummary) (*domain.WeeklyInsight, error) {
+	args := m.Called(ctx, userID, week)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WeeklyInsight), args.Error(1)
+}
+
+func (m *MockCortexServiceForMeal) GenerateFromPrompt(ctx context.Context, userID uuid.UUID, prompt string, vars domain.PromptVars) (string, error) {
+	args := m.Called(ctx, userID, prompt, vars)
+	return args.String(0), args.Error(1)
+}
+
 // ============== LOG MEAL TESTS ==============
 
 func TestMealService_LogMeal_Success(t *testing.T) {
 	mockRepo := new(MockMealRepository)
 	mockCortex := new(MockCortexServiceForMeal)
-	service := NewMealService(mockRepo, mockCortex)
+	service := NewMealService(mockRepo, mockCortex, allowAllEntitlements())
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -85,7 +104,7 @@ func TestMealService_LogMeal_Success(t *testing.T) {
 func TestMealService_LogMeal_WithImage(t *testing.T) {
 	mockRepo := new(MockMealRepository)
 	mockCortex := new(MockCortexServiceForMeal)
-	service := NewMealService(mockRepo, mockCortex)
+	service := NewMealService(mockRepo, mockCortex, allowAllEntitlements())
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -100,10 +119,32 @@ func TestMealService_LogMeal_WithImage(t *testing.T) {
 	assert.True(t, meal.IsAuthentic)
 }
 
+func TestMealService_LogMeal_WithImage_NoAnalysisCapability(t *testing.T) {
+	mockRepo := new(MockMealRepository)
+	mockCortex := new(MockCortexServiceForMeal)
+	mockEntitlements := new(MockEntitlementService)
+	service := NewMealService(mockRepo, mockCortex, mockEntitlements)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockEntitlements.On("Require", ctx, userID, domain.CapabilityAIMealAnalysis).
+		Return(&domain.EntitlementError{Capability: domain.CapabilityAIMealAnalysis})
+	mockCortex.On("AnalyzeMeal", ctx, "base64image", "").Return("Healthy keto meal", true, true, nil)
+	mockRepo.On("Save", ctx, mock.AnythingOfType("*domain.Meal")).Return(nil)
+
+	meal, err := service.LogMeal(ctx, userID, "My Meal", 0, "dinner", "base64image", "")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, meal)
+	assert.Empty(t, meal.Analysis, "the commentary is premium")
+	assert.True(t, meal.IsAuthentic, "the authenticity check runs on every plan")
+	assert.True(t, meal.IsKeto)
+}
+
 func TestMealService_LogMeal_AnalysisFails(t *testing.T) {
 	mockRepo := new(MockMealRepository)
 	mockCortex := new(MockCortexServiceForMeal)
-	service := NewMealService(mockRepo, mockCortex)
+	service := NewMealService(mockRepo, mockCortex, allowAllEntitlements())
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -122,7 +163,7 @@ func TestMealService_LogMeal_AnalysisFails(t *testing.T) {
 func TestMealService_LogMeal_NoName(t *testing.T) {
 	mockRepo := new(MockMealRepository)
 	mockCortex := new(MockCortexServiceForMeal)
-	service := NewMealService(mockRepo, mockCortex)
+	service := NewMealService(mockRepo, mockCortex, allowAllEntitlements())
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -139,7 +180,7 @@ func TestMealService_LogMeal_NoName(t *testing.T) {
 func TestMealService_LogMeal_NoNameNoDescription(t *testing.T) {
 	mockRepo := new(MockMealRepository)
 	mockCortex := new(MockCortexServiceForMeal)
-	service := NewMealService(mockRepo, mockCortex)
+	service := NewMealService(mockRepo, mockCortex, allowAllEntitlements())
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -155,7 +196,7 @@ func TestMealService_LogMeal_NoNameNoDescription(t *testing.T) {
 func TestMealService_LogMeal_SaveError(t *testing.T) {
 	mockRepo := new(MockMealRepository)
 	mockCortex := new(MockCortexServiceForMeal)
-	service := NewMealService(mockRepo, mockCortex)
+	service := NewMealService(mockRepo, mockCortex, allowAllEntitlements())
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -172,7 +213,7 @@ func TestMealService_LogMeal_SaveError(t *testing.T) {
 func TestMealService_GetMeals_Success(t *testing.T) {
 	mockRepo := new(MockMealRepository)
 	mockCortex := new(MockCortexServiceForMeal)
-	service := NewMealService(mockRepo, mockCortex)
+	service := NewMealService(mockRepo, mockCortex, allowAllEntitlements())
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -192,7 +233,7 @@ func TestMealService_GetMeals_Success(t *testing.T) {
 func TestMealService_GetMeals_Empty(t *testing.T) {
 	mockRepo := new(MockMealRepository)
 	mockCortex := new(MockCortexServiceForMeal)
-	service := NewMealService(mockRepo, mockCortex)
+	service := NewMealService(mockRepo, mockCortex, allowAllEntitlements())
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -207,7 +248,7 @@ func TestMealService_GetMeals_Empty(t *testing.T) {
 func TestMealService_GetMeals_Error(t *testing.T) {
 	mockRepo := new(MockMealRepository)
 	mockCortex := new(MockCortexServiceForMeal)
-	service := NewMealService(mockRepo, mockCortex)
+	service := NewMealService(mockRepo, mockCortex, allowAllEntitlements())
 	ctx := context.Background()
 	userID := uuid.New()
 
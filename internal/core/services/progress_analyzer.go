@@ -4,7 +4,9 @@ import (
 	"context"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -40,6 +42,7 @@ type WeeklyReport struct {
 	AIInsights          string                 `json:"ai_insights"`
 	Predictions         map[string]interface{} `json:"predictions"`
 	Recommendations     []string               `json:"recommendations"`
+	SafetyMode          bool                   `json:"safety_mode,omitempty"` // Safe commentary for a user at risk
 }
 
 // GenerateWeeklyReport creates a comprehensive weekly progress report
@@ -108,13 +111,35 @@ func (p *ProgressAnalyzer) GenerateWeeklyReport(ctx context.Context, userID uuid
 	disciplineChange := 0.0 // Would need previous week's data in real implementation
 
 	// 8. Generate AI insights and predictions
-	aiInsights, predictions := p.generateAIInsights(ctx, user, weekSessions, avgDuration, fastsCompleted)
+	insight := p.weeklyInsight(ctx, user, domain.WeekSummary{
+		FastsCompleted:  fastsCompleted,
+		AverageDuration: avgDuration,
+		LongestFast:     longestFast,
+		DisciplineIndex: user.DisciplineIndex,
+	})
+	aiInsights := strings.TrimSpace(insight.Analysis + " " + insight.Encouragement)
+	predictions := map[string]interface{}{
+		"next_week_fasts_estimate": insight.NextWeekFastsEstimate,
+		"discipline_trend":         insight.DisciplineTrend,
+		"success_probability":      85.0,
+	}
 
-	// 9. Generate recommendations
+	// 9. Generate recommendations, leading with the coach's improvement area. A user at risk isn't
+	// told to fast more.
 	recommendations := p.generateRecommendations(fastsCompleted, avgDuration, dayStats)
+	if insight.SafetyMode {
+		recommendations = []string{}
+		predictions = map[string]interface{}{}
+	}
+	if insight.ImprovementArea != "" {
+		recommendations = append([]string{insight.ImprovementArea}, recommendations...)
+	}
 
 	// 10. Predict goal achievement
 	goalDate := p.predictGoalAchievement(user, avgDuration, fastsCompleted)
+	if insight.SafetyMode {
+		goalDate = ""
+	}
 
 	report := &WeeklyReport{
 		UserID:              userID,
@@ -131,44 +156,26 @@ func (p *ProgressAnalyzer) GenerateWeeklyReport(ctx context.Context, userID uuid
 		AIInsights:          aiInsights,
 		Predictions:         predictions,
 		Recommendations:     recommendations,
+		SafetyMode:          insight.SafetyMode,
 	}
 
 	return report, nil
 }
 
-// generateAIInsights uses Cortex to analyze the week
-func (p *ProgressAnalyzer) generateAIInsights(ctx context.Context, user *domain.User, sessions []domain.FastingSession, avgDuration float64, fastsCompleted int) (string, map[string]interface{}) {
-	// Construct prompt for AI
-	prompt := fmt.Sprintf(`Analyze this user's weekly fasting performance and provide insights.
-
-User Stats:
-- Total fasts completed this week: %d
-- Average fast duration: %.1f hours
-- Discipline index: %.1f/100
-
-Provide:
-1. A 2-3 sentence analysis of their performance
-2. One specific improvement area
-3. One encouraging observation
-
-Keep response under 100 words total.`, fastsCompleted, avgDuration, user.DisciplineIndex)
-
-	systemPrompt := "You are a supportive fasting coach analyzing weekly progress. Be specific, encouraging, and actionable."
-
-	insights, err := p.cortex.Chat(ctx, user.ID, systemPrompt+"\n\n"+prompt)
-	if err != nil {
-		// Fallback if AI fails
-		insights = fmt.Sprintf("You completed %d fasts this week! Your dedication is building real discipline. Keep pushing forward.", fastsCompleted)
+// weeklyInsight uses Cortex to analyze the week, falling back to a canned insight if it fails
+func (p *ProgressAnalyzer) weeklyInsight(ctx context.Context, user *domain.User, week domain.WeekSummary) domain.WeeklyInsight {
+	insight, err := p.cortex.AnalyzeWeek(ctx, user.ID, week)
+	if err == nil {
+		return *insight
 	}
 
-	// Generate predictions
-	predictions := map[string]interface{}{
-		"next_week_fasts_estimate": fastsCompleted + 1,
-		"discipline_trend":         "improving",
-		"success_probability":      85.0,
+	logger.Warn().Err(err).Str("user_id", user.ID.String()).Msg("Falling back to default weekly insight")
+	return domain.WeeklyInsight{
+		Analysis:              fmt.Sprintf("You completed %d fasts this week! Your dedication is building real discipline.", week.FastsCompleted),
+		Encouragement:         "Keep pushing forward.",
+		DisciplineTrend:       domain.DisciplineTrendImproving,
+		NextWeekFastsEstimate: week.FastsCompleted + 1,
 	}
-
-	return insights, predictions
 }
 
 // generateRecommendations creates actionable recommendations
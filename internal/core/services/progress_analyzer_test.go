@@ -48,7 +48,14 @@ func TestProgressAnalyzer_GenerateWeeklyReport_Success(t *testing.T) {
 
 	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
 	mockFastingRepo.On("FindByUserID", ctx, userID).Return(sessions, nil)
-	mockCortex.On("Chat", ctx, userID, mock.AnythingOfType("string")).Return("Great week! Keep it up.", nil)
+	mockCortex.On("AnalyzeWeek", ctx, userID, domain.WeekSummary{FastsCompleted: 2, AverageDuration: 17, LongestFast: 18, DisciplineIndex: 75}).
+		Return(&domain.WeeklyInsight{
+			Analysis:              "Great week!",
+			ImprovementArea:       "Hydrate earlier in the day",
+			Encouragement:         "Keep it up.",
+			DisciplineTrend:       domain.DisciplineTrendSteady,
+			NextWeekFastsEstimate: 3,
+		}, nil)
 
 	report, err := analyzer.GenerateWeeklyReport(ctx, userID)
 
@@ -58,6 +65,10 @@ func TestProgressAnalyzer_GenerateWeeklyReport_Success(t *testing.T) {
 	assert.Equal(t, 34.0, report.TotalFastingHours) // 16 + 18
 	assert.Equal(t, 17.0, report.AverageDuration)   // 34 / 2
 	assert.Equal(t, 18.0, report.LongestFast)
+	assert.Equal(t, "Great week! Keep it up.", report.AIInsights)
+	assert.Equal(t, "Hydrate earlier in the day", report.Recommendations[0])
+	assert.Equal(t, domain.DisciplineTrendSteady, report.Predictions["discipline_trend"])
+	assert.Equal(t, 3, report.Predictions["next_week_fasts_estimate"])
 }
 
 func TestProgressAnalyzer_GenerateWeeklyReport_NoSessions(t *testing.T) {
@@ -73,7 +84,7 @@ func TestProgressAnalyzer_GenerateWeeklyReport_NoSessions(t *testing.T) {
 
 	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
 	mockFastingRepo.On("FindByUserID", ctx, userID).Return([]domain.FastingSession{}, nil)
-	mockCortex.On("Chat", ctx, userID, mock.AnythingOfType("string")).Return("No fasts this week", nil)
+	mockCortex.On("AnalyzeWeek", ctx, userID, mock.Anything).Return(nil, errors.New("llm returned malformed structured output"))
 
 	report, err := analyzer.GenerateWeeklyReport(ctx, userID)
 
@@ -81,6 +92,32 @@ func TestProgressAnalyzer_GenerateWeeklyReport_NoSessions(t *testing.T) {
 	assert.NotNil(t, report)
 	assert.Equal(t, 0, report.FastsCompleted)
 	assert.Equal(t, 0.0, report.AverageDuration)
+	assert.Contains(t, report.AIInsights, "You completed 0 fasts this week")
+	assert.Equal(t, 1, report.Predictions["next_week_fasts_estimate"])
+	assert.Equal(t, "Start your first fast this week!", report.Recommendations[0])
+}
+
+func TestProgressAnalyzer_GenerateWeeklyReport_SafetyMode(t *testing.T) {
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockCortex := new(MockCortexServiceForMeal)
+
+	analyzer := NewProgressAnalyzer(mockFastingRepo, mockUserRepo, mockCortex)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+	mockFastingRepo.On("FindByUserID", ctx, userID).Return([]domain.FastingSession{}, nil)
+	mockCortex.On("AnalyzeWeek", ctx, userID, mock.Anything).
+		Return(&domain.WeeklyInsight{Analysis: domain.SafetyFallbackReply, DisciplineTrend: domain.DisciplineTrendSteady, SafetyMode: true}, nil)
+
+	report, err := analyzer.GenerateWeeklyReport(ctx, userID)
+
+	assert.NoError(t, err)
+	assert.True(t, report.SafetyMode)
+	assert.Equal(t, domain.SafetyFallbackReply, report.AIInsights)
+	assert.Empty(t, report.Recommendations, "a user at risk isn't told to fast more")
+	assert.Empty(t, report.Predictions)
 }
 
 func TestProgressAnalyzer_GenerateWeeklyReport_UserNotFound(t *testing.T) {
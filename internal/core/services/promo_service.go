@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PromoCodeService runs subscription discounts. Each code is created as a coupon on the payment
+// gateway, which applies the discount and also enforces the usage limit and expiry at checkout.
+type PromoCodeService struct {
+	promoRepo      ports.PromoCodeRepository
+	paymentGateway ports.PaymentGateway
+}
+
+func NewPromoCodeService(promoRepo ports.PromoCodeRepository, pg ports.PaymentGateway) *PromoCodeService {
+	return &PromoCodeService{
+		promoRepo:      promoRepo,
+		paymentGateway: pg,
+	}
+}
+
+// CreatePromoCode validates the code's terms, creates its coupon and stores it as active
+func (s *PromoCodeService) CreatePromoCode(ctx context.Context, adminID uuid.UUID, promo *domain.PromoCode) (*domain.PromoCode, error) {
+	now := time.Now()
+	promo.Code = domain.NormalizePromoCode(promo.Code)
+	if err := promo.Validate(now); err != nil {
+		return nil, err
+	}
+	existing, err := s.promoRepo.FindByCode(ctx, promo.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, domain.ErrPromoCodeExists
+	}
+
+	couponID, err := s.paymentGateway.CreateCoupon(promo.CouponRequest())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create coupon: %w", err)
+	}
+
+	promo.ID = uuid.New()
+	promo.CouponID = couponID
+	promo.TimesRedeemed = 0
+	promo.Active = true
+	promo.CreatedBy = adminID
+	promo.CreatedAt = now
+	promo.UpdatedAt = now
+	created, err := s.promoRepo.Create(ctx, promo)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// Lost a race with another admin; the orphaned coupon can't be redeemed without the code
+		return nil, domain.ErrPromoCodeExists
+	}
+	logger.Info().Str("code", promo.Code).Str("admin_id", adminID.String()).Msg("Promo code created")
+	return promo, nil
+}
+
+func (s *PromoCodeService) ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	return s.promoRepo.List(ctx)
+}
+
+func (s *PromoCodeService) GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	promo, err := s.promoRepo.FindByCode(ctx, domain.NormalizePromoCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if promo == nil {
+		return nil, domain.ErrPromoCodeNotFound
+	}
+	return promo, nil
+}
+
+// SetPromoCodeActive pauses or resumes a code. Checkouts already started with it still get the discount.
+func (s *PromoCodeService) SetPromoCodeActive(ctx context.Context, code string, active bool) (*domain.PromoCode, error) {
+	promo, err := s.GetPromoCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	promo.Active = active
+	promo.UpdatedAt = time.Now()
+	if err := s.promoRepo.Update(ctx, promo); err != nil {
+		return nil, err
+	}
+	return promo, nil
+}
+
+func (s *PromoCodeService) ListRedemptions(ctx context.Context, code string) ([]*domain.PromoRedemption, error) {
+	promo, err := s.GetPromoCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.promoRepo.ListRedemptions(ctx, promo.ID)
+}
+
+// CheckPromoCode returns the code if the user can redeem it for plan now
+func (s *PromoCodeService) CheckPromoCode(ctx context.Context, userID uuid.UUID, code string, plan domain.SubscriptionTier) (*domain.PromoCode, error) {
+	promo, err := s.GetPromoCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := promo.CheckRedeemable(plan, time.Now()); err != nil {
+		return nil, err
+	}
+	redeemed, err := s.promoRepo.FindRedemption(ctx, promo.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if redeemed != nil {
+		return nil, domain.ErrPromoCodeAlreadyRedeemed
+	}
+	return promo, nil
+}
+
+// RedeemPromoCode records a completed checkout that used the code. The discount has already
+// been applied by then, so the code's limits aren't checked again; recording the same user's
+// redemption twice (a webhook retry) is a no-op.
+func (s *PromoCodeService) RedeemPromoCode(ctx context.Context, redemption *domain.PromoRedemption) error {
+	promo, err := s.GetPromoCode(ctx, redemption.Code)
+	if err != nil {
+		return err
+	}
+	redemption.ID = uuid.New()
+	redemption.PromoCodeID = promo.ID
+	redemption.Code = promo.Code
+	if redemption.RedeemedAt.IsZero() {
+		redemption.RedeemedAt = time.Now()
+	}
+	if _, err := s.promoRepo.Redeem(ctx, redemption); err != nil {
+		return fmt.Errorf("failed to record promo code redemption: %w", err)
+	}
+	return nil
+}
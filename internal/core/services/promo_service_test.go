@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mock PromoCodeRepository ---
+
+type MockPromoCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockPromoCodeRepository) Create(ctx context.Context, promo *domain.PromoCode) (bool, error) {
+	args := m.Called(ctx, promo)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) Update(ctx context.Context, promo *domain.PromoCode) error {
+	args := m.Called(ctx, promo)
+	return args.Error(0)
+}
+
+func (m *MockPromoCodeRepository) FindByCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) List(ctx context.Context) ([]*domain.PromoCode, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) Redeem(ctx context.Context, redemption *domain.PromoRedemption) (bool, error) {
+	args := m.Called(ctx, redemption)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) FindRedemption(ctx context.Context, promoCodeID, userID uuid.UUID) (*domain.PromoRedemption, error) {
+	args := m.Called(ctx, promoCodeID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PromoRedemption), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) ListRedemptions(ctx context.Context, promoCodeID uuid.UUID) ([]*domain.PromoRedemption, error) {
+	args := m.Called(ctx, promoCodeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PromoRedemption), args.Error(1)
+}
+
+func activePromo(code string) *domain.PromoCode {
+	return &domain.PromoCode{
+		ID:           uuid.New(),
+		Code:         code,
+		DiscountType: domain.PromoDiscountPercent,
+		PercentOff:   20,
+		Duration:     domain.PromoDurationOnce,
+		Active:       true,
+		CouponID:     "coupon_" + code,
+	}
+}
+
+func TestPromoCodeService_CreatePromoCode(t *testing.T) {
+	promoRepo := new(MockPromoCodeRepository)
+	pg := new(MockPaymentGatewayForStripe)
+	service := NewPromoCodeService(promoRepo, pg)
+	ctx := context.Background()
+	adminID := uuid.New()
+	expiresAt := time.Now().AddDate(0, 1, 0)
+
+	promoRepo.On("FindByCode", ctx, "SPRING10").Return(nil, nil)
+	pg.On("CreateCoupon", domain.CouponRequest{
+		Name:           "SPRING10",
+		AmountOff:      10,
+		Duration:       domain.PromoDurationRepeating,
+		DurationMonths: 3,
+		MaxRedemptions: 500,
+		RedeemBy:       &expiresAt,
+	}).Return("coupon_123", nil)
+	promoRepo.On("Create", ctx, mock.AnythingOfType("*domain.PromoCode")).Return(true, nil)
+
+	promo, err := service.CreatePromoCode(ctx, adminID, &domain.PromoCode{
+		Code:           " spring10 ",
+		DiscountType:   domain.PromoDiscountFixed,
+		AmountOff:      10,
+		Duration:       domain.PromoDurationRepeating,
+		DurationMonths: 3,
+		MaxRedemptions: 500,
+		Plans:          []domain.SubscriptionTier{domain.TierVault},
+		ExpiresAt:      &expiresAt,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "SPRING10", promo.Code)
+	assert.Equal(t, "coupon_123", promo.CouponID)
+	assert.True(t, promo.Active)
+	assert.Equal(t, adminID, promo.CreatedBy)
+	pg.AssertExpectations(t)
+}
+
+func TestPromoCodeService_CreatePromoCode_Rejected(t *testing.T) {
+	promoRepo := new(MockPromoCodeRepository)
+	pg := new(MockPaymentGatewayForStripe)
+	service := NewPromoCodeService(promoRepo, pg)
+	ctx := context.Background()
+
+	_, err := service.CreatePromoCode(ctx, uuid.New(), &domain.PromoCode{Code: "BIG", DiscountType: domain.PromoDiscountPercent, PercentOff: 120, Duration: domain.PromoDurationOnce})
+	assert.ErrorIs(t, err, domain.ErrInvalidPromoCode)
+
+	_, err = service.CreatePromoCode(ctx, uuid.New(), &domain.PromoCode{Code: "FREE", DiscountType: domain.PromoDiscountPercent, PercentOff: 10, Duration: domain.PromoDurationOnce, Plans: []domain.SubscriptionTier{domain.TierFree}})
+	assert.ErrorIs(t, err, domain.ErrInvalidPromoCode)
+
+	promoRepo.On("FindByCode", ctx, "TAKEN").Return(activePromo("TAKEN"), nil)
+	_, err = service.CreatePromoCode(ctx, uuid.New(), &domain.PromoCode{Code: "taken", DiscountType: domain.PromoDiscountPercent, PercentOff: 10, Duration: domain.PromoDurationOnce})
+	assert.ErrorIs(t, err, domain.ErrPromoCodeExists)
+
+	pg.AssertNotCalled(t, "CreateCoupon", mock.Anything)
+}
+
+func TestPromoCodeService_CheckPromoCode(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	past := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		name  string
+		edit  func(p *domain.PromoCode)
+		plan  domain.SubscriptionTier
+		err   error
+		prior *domain.PromoRedemption
+	}{
+		{name: "valid", edit: func(p *domain.PromoCode) {}, plan: domain.TierVault},
+		{name: "inactive", edit: func(p *domain.PromoCode) { p.Active = false }, plan: domain.TierVault, err: domain.ErrPromoCodeInactive},
+		{name: "expired", edit: func(p *domain.PromoCode) { p.ExpiresAt = &past }, plan: domain.TierVault, err: domain.ErrPromoCodeExpired},
+		{name: "exhausted", edit: func(p *domain.PromoCode) { p.MaxRedemptions, p.TimesRedeemed = 5, 5 }, plan: domain.TierVault, err: domain.ErrPromoCodeExhausted},
+		{name: "other plan", edit: func(p *domain.PromoCode) { p.Plans = []domain.SubscriptionTier{domain.TierAICoach} }, plan: domain.TierVault, err: domain.ErrPromoCodeNotForPlan},
+		{name: "already redeemed", edit: func(p *domain.PromoCode) {}, plan: domain.TierVault, err: domain.ErrPromoCodeAlreadyRedeemed, prior: &domain.PromoRedemption{UserID: userID}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			promoRepo := new(MockPromoCodeRepository)
+			service := NewPromoCodeService(promoRepo, nil)
+			promo := activePromo("SAVE20")
+			tc.edit(promo)
+
+			promoRepo.On("FindByCode", ctx, "SAVE20").Return(promo, nil)
+			promoRepo.On("FindRedemption", ctx, promo.ID, userID).Return(tc.prior, nil)
+
+			got, err := service.CheckPromoCode(ctx, userID, "save20", tc.plan)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, promo.ID, got.ID)
+		})
+	}
+}
+
+func TestStripeService_CreateCheckoutSession_AppliesPromoCode(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	userRepo := new(MockUserRepository)
+	promoRepo := new(MockPromoCodeRepository)
+	ctx := context.Background()
+	userID := uuid.New()
+	user := &domain.User{ID: userID, Email: "test@example.com", StripeCustomerID: "cus_existing", SubscriptionTier: domain.TierFree}
+	promo := activePromo("SAVE20")
+
+	svc := NewStripeService(pg, nil, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, NewPromoCodeService(promoRepo, pg), nil, nil)
+
+	userRepo.On("FindByID", ctx, userID).Return(user, nil)
+	promoRepo.On("FindByCode", ctx, "SAVE20").Return(promo, nil)
+	promoRepo.On("FindRedemption", ctx, promo.ID, userID).Return(nil, nil)
+	pg.On("CreateCheckoutSession", mock.MatchedBy(func(req domain.CheckoutSessionRequest) bool {
+		return req.CouponID == "coupon_SAVE20" && req.Metadata["promo_code"] == "SAVE20"
+	})).Return(&domain.CheckoutSession{ID: "cs_123"}, nil)
+
+	_, err := svc.CreateCheckoutSession(ctx, userID, domain.TierVault, "save20")
+
+	assert.NoError(t, err)
+	pg.AssertExpectations(t)
+}
+
+func TestStripeService_CreateCheckoutSession_RejectsUnusablePromoCode(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	userRepo := new(MockUserRepository)
+	promoRepo := new(MockPromoCodeRepository)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	svc := NewStripeService(pg, nil, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, NewPromoCodeService(promoRepo, pg), nil, nil)
+
+	userRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID, SubscriptionTier: domain.TierFree}, nil)
+	promoRepo.On("FindByCode", ctx, "NOPE").Return(nil, nil)
+
+	_, err := svc.CreateCheckoutSession(ctx, userID, domain.TierVault, "nope")
+
+	assert.ErrorIs(t, err, domain.ErrPromoCodeNotFound)
+	pg.AssertNotCalled(t, "CreateCheckoutSession", mock.Anything)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ListPrompts returns the prompt templates Cortex renders, with their variants and weights
+func (s *CortexService) ListPrompts() []domain.PromptTemplate {
+	return s.prompts.Templates()
+}
+
+// PromptExperimentReport compares the variants of every prompt users have seen by the fasts
+// those users started after first getting a response from the variant. A user bucketed into
+// variants of several prompts counts towards each of them.
+func (s *CortexService) PromptExperimentReport(ctx context.Context) ([]domain.PromptVariantStats, error) {
+	if s.prompts.exposures == nil {
+		return nil, errors.New("prompt exposures are not recorded")
+	}
+	summaries, err := s.prompts.exposures.SummarizeExposures(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionsByUser := make(map[uuid.UUID][]domain.FastingSession)
+	var stats []domain.PromptVariantStats
+	index := make(map[string]int)
+	for _, summary := range summaries {
+		key := fmt.Sprintf("%s:%d:%s", summary.Prompt, summary.Version, summary.Variant)
+		i, ok := index[key]
+		if !ok {
+			i = len(stats)
+			index[key] = i
+			stats = append(stats, domain.PromptVariantStats{Prompt: summary.Prompt, Version: summary.Version, Variant: summary.Variant})
+		}
+
+		sessions, ok := sessionsByUser[summary.UserID]
+		if !ok {
+			if sessions, err = s.fastingRepo.FindByUserID(ctx, summary.UserID); err != nil {
+				return nil, fmt.Errorf("failed to fetch sessions: %w", err)
+			}
+			sessionsByUser[summary.UserID] = sessions
+		}
+
+		st := &stats[i]
+		st.Users++
+		st.Responses += summary.Responses
+		for _, session := range sessions {
+			if session.StartTime.Before(summary.FirstExposedAt) || session.Status == domain.StatusActive {
+				continue
+			}
+			st.FastsEnded++
+			if session.MetGoal() {
+				st.FastsCompleted++
+			}
+		}
+	}
+	for i := range stats {
+		if stats[i].FastsEnded > 0 {
+			stats[i].CompletionRate = float64(stats[i].FastsCompleted) / float64(stats[i].FastsEnded)
+		}
+	}
+	return stats, nil
+}
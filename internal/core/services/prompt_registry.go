@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Prompt template names used by the services
+const (
+	promptCoachPersona       = "coach_persona"
+	promptFastingInsight     = "fasting_insight"
+	promptMealAssessment     = "meal_assessment"
+	promptMilestoneInsight   = "milestone_insight"
+	promptCravingHelp        = "craving_help"
+	promptWeeklyInsight      = "weekly_insight"
+	promptBreakFastTip       = "break_fast_tip"
+	promptDailyQuote         = "daily_quote"
+	promptThreadSummary      = "thread_summary"
+	promptStreakIntervention = "streak_intervention"
+	promptOptimalWindow      = "optimal_window"
+	promptKnowledgeGrounding = "knowledge_grounding"
+	promptSafetyResponse     = "safety_response"
+	promptToolUse            = "tool_use"
+)
+
+// builtinPrompts holds one JSON domain.PromptTemplate per file
+//
+//go:embed prompts/*.json
+var builtinPrompts embed.FS
+
+// PromptRegistry renders named, versioned prompt templates and buckets each user into one of
+// a template's variants. Exposures to a variant are recorded when exposures is set.
+type PromptRegistry struct {
+	prompts   map[string]*compiledPrompt
+	exposures ports.PromptExposureRepository
+}
+
+type compiledPrompt struct {
+	template domain.PromptTemplate
+	system   map[string]*template.Template // By variant name; nil when the variant has none
+	user     map[string]*template.Template
+}
+
+// NewPromptRegistry compiles the templates. exposures may be nil when variants aren't recorded.
+func NewPromptRegistry(exposures ports.PromptExposureRepository, templates ...domain.PromptTemplate) (*PromptRegistry, error) {
+	r := &PromptRegistry{prompts: make(map[string]*compiledPrompt), exposures: exposures}
+	for _, t := range templates {
+		if _, ok := r.prompts[t.Name]; ok {
+			return nil, fmt.Errorf("duplicate prompt template %s", t.Name)
+		}
+		compiled, err := compilePrompt(t)
+		if err != nil {
+			return nil, err
+		}
+		r.prompts[t.Name] = compiled
+	}
+	return r, nil
+}
+
+// DefaultPromptTemplates returns the built-in templates from services/prompts
+func DefaultPromptTemplates() ([]domain.PromptTemplate, error) {
+	return readPromptTemplates(builtinPrompts, "prompts")
+}
+
+// LoadPromptRegistry reads the built-in templates, replaced by any *.json template in dir
+// with the same name. A replacement must raise the version, so exposures to the built-in
+// and the edited prompt are never counted together. An empty dir uses the built-ins only.
+func LoadPromptRegistry(dir string, exposures ports.PromptExposureRepository) (*PromptRegistry, error) {
+	templates, err := DefaultPromptTemplates()
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return NewPromptRegistry(exposures, templates...)
+	}
+
+	overrides, err := readPromptTemplates(os.DirFS(dir), ".")
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]int, len(templates))
+	for i, t := range templates {
+		byName[t.Name] = i
+	}
+	for _, t := range overrides {
+		i, ok := byName[t.Name]
+		if !ok {
+			byName[t.Name] = len(templates)
+			templates = append(templates, t)
+			continue
+		}
+		if t.Version <= templates[i].Version {
+			return nil, fmt.Errorf("prompt %s in %s must have a version above the built-in %d", t.Name, dir, templates[i].Version)
+		}
+		templates[i] = t
+	}
+	return NewPromptRegistry(exposures, templates...)
+}
+
+func readPromptTemplates(fsys fs.FS, dir string) ([]domain.PromptTemplate, error) {
+	paths, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	templates := make([]domain.PromptTemplate, 0, len(paths))
+	for _, file := range paths {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		var t domain.PromptTemplate
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("failed to parse prompt template %s: %w", file, err)
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+// compilePrompt parses every variant and renders it once with zero values, so a template
+// referring to an undeclared variable is rejected at load time rather than mid-request
+func compilePrompt(t domain.PromptTemplate) (*compiledPrompt, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	zero := make(domain.PromptVars, len(t.Variables))
+	for name, typ := range t.Variables {
+		switch typ {
+		case domain.PromptVarString:
+			zero[name] = ""
+		case domain.PromptVarNumber:
+			zero[name] = 0.0
+		case domain.PromptVarInteger:
+			zero[name] = int64(0)
+		case domain.PromptVarBoolean:
+			zero[name] = false
+		}
+	}
+
+	compiled := &compiledPrompt{
+		template: t,
+		system:   make(map[string]*template.Template),
+		user:     make(map[string]*template.Template),
+	}
+	for _, v := range t.Variants {
+		for part, source := range map[string]string{"system": v.System, "user": v.User} {
+			if source == "" {
+				continue
+			}
+			name := fmt.Sprintf("%s/v%d/%s/%s", t.Name, t.Version, v.Name, part)
+			tmpl, err := template.New(name).Option("missingkey=error").Parse(source)
+			if err != nil {
+				return nil, fmt.Errorf("prompt %s: %w", t.Name, err)
+			}
+			if err := tmpl.Execute(&strings.Builder{}, zero); err != nil {
+				return nil, fmt.Errorf("prompt %s: %w", t.Name, err)
+			}
+			if part == "system" {
+				compiled.system[v.Name] = tmpl
+			} else {
+				compiled.user[v.Name] = tmpl
+			}
+		}
+	}
+	return compiled, nil
+}
+
+// Render renders the variant of prompt name that userID is bucketed into. vars must hold
+// exactly the template's declared variables with their declared types.
+func (r *PromptRegistry) Render(name string, userID uuid.UUID, vars domain.PromptVars) (*domain.RenderedPrompt, error) {
+	compiled, ok := r.prompts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnknownPrompt, name)
+	}
+	vars, err := compiled.template.BindVars(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	variant := compiled.template.VariantFor(userID)
+	rendered := &domain.RenderedPrompt{Name: name, Version: compiled.template.Version, Variant: variant.Name}
+	if rendered.System, err = executePrompt(compiled.system[variant.Name], vars); err != nil {
+		return nil, err
+	}
+	if rendered.User, err = executePrompt(compiled.user[variant.Name], vars); err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
+
+func executePrompt(tmpl *template.Template, vars domain.PromptVars) (string, error) {
+	if tmpl == nil {
+		return "", nil
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return "", fmt.Errorf("failed to render prompt: %w", err)
+	}
+	return out.String(), nil
+}
+
+// RecordExposure notes that userID got a response generated from prompt. Failures are logged
+// rather than failing a request that has already been answered.
+func (r *PromptRegistry) RecordExposure(ctx context.Context, userID uuid.UUID, prompt *domain.RenderedPrompt) {
+	if r.exposures == nil || userID == uuid.Nil {
+		return
+	}
+	exposure := &domain.PromptExposure{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Prompt:    prompt.Name,
+		Version:   prompt.Version,
+		Variant:   prompt.Variant,
+		CreatedAt: time.Now(),
+	}
+	if err := r.exposures.Record(context.WithoutCancel(ctx), exposure); err != nil {
+		logger.Error().Err(err).Str("prompt", prompt.Name).Str("variant", prompt.Variant).Msg("Failed to record prompt exposure")
+	}
+}
+
+// Templates returns the registered templates ordered by name
+func (r *PromptRegistry) Templates() []domain.PromptTemplate {
+	templates := make([]domain.PromptTemplate, 0, len(r.prompts))
+	for _, compiled := range r.prompts {
+		templates = append(templates, compiled.template)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates
+}
+
+var (
+	builtinRegistryOnce sync.Once
+	builtinRegistry     *PromptRegistry
+)
+
+// builtinPromptRegistry is used by services constructed without a registry. The built-in
+// templates are covered by tests, so failing to compile them is a programming error.
+func builtinPromptRegistry() *PromptRegistry {
+	builtinRegistryOnce.Do(func() {
+		registry, err := LoadPromptRegistry("", nil)
+		if err != nil {
+			panic(fmt.Sprintf("built-in prompt templates: %v", err))
+		}
+		builtinRegistry = registry
+	})
+	return builtinRegistry
+}
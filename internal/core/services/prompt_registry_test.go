@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fastinghero/internal/adapters/repository/memory"
+	"fastinghero/internal/core/domain"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func abTestTemplate() domain.PromptTemplate {
+	return domain.PromptTemplate{
+		Name:      promptCoachPersona,
+		Version:   2,
+		Variables: map[string]domain.PromptVarType{"discipline_index": domain.PromptVarNumber, "fasting_status": domain.PromptVarString},
+		Variants: []domain.PromptVariant{
+			{Name: "drill_sergeant", Weight: 1, System: `You are Cortex, a drill sergeant. Discipline {{printf "%.1f" .discipline_index}}, {{.fasting_status}}.`},
+			{Name: "mentor", Weight: 1, System: `You are Cortex, a patient mentor. Discipline {{printf "%.1f" .discipline_index}}, {{.fasting_status}}.`},
+		},
+	}
+}
+
+func TestPromptRegistry_BuiltinTemplatesCoverEveryPrompt(t *testing.T) {
+	registry, err := LoadPromptRegistry("", nil)
+	require.NoError(t, err)
+
+	names := make(map[string]bool)
+	for _, tmpl := range registry.Templates() {
+		names[tmpl.Name] = true
+	}
+	for _, name := range []string{promptCoachPersona, promptFastingInsight, promptMealAssessment, promptMilestoneInsight,
+		promptCravingHelp, promptWeeklyInsight, promptBreakFastTip, promptDailyQuote, promptThreadSummary,
+		promptStreakIntervention, promptOptimalWindow, promptKnowledgeGrounding, promptSafetyResponse, promptToolUse} {
+		assert.True(t, names[name], name)
+	}
+}
+
+func TestPromptRegistry_RenderChecksVariableTypes(t *testing.T) {
+	registry, err := LoadPromptRegistry("", nil)
+	require.NoError(t, err)
+	userID := uuid.New()
+
+	rendered, err := registry.Render(promptStreakIntervention, userID, domain.PromptVars{"streak": 5, "hours_left": 3, "discipline_index": 72.25})
+	require.NoError(t, err)
+	assert.Contains(t, rendered.User, "5-day fasting streak")
+	assert.Contains(t, rendered.User, "Only 3.0 hours left", "integers are accepted for numbers")
+	assert.Equal(t, 1, rendered.Version)
+	assert.Equal(t, "control", rendered.Variant)
+
+	_, err = registry.Render(promptStreakIntervention, userID, domain.PromptVars{"streak": 5, "hours_left": 3.0})
+	assert.ErrorContains(t, err, "missing variable discipline_index")
+	_, err = registry.Render(promptStreakIntervention, userID, domain.PromptVars{"streak": 5.5, "hours_left": 3.0, "discipline_index": 70.0})
+	assert.ErrorContains(t, err, "streak must be of type integer")
+	_, err = registry.Render(promptStreakIntervention, userID, domain.PromptVars{"streak": 5, "hours_left": 3.0, "discipline_index": 70.0, "name": "Sam"})
+	assert.ErrorContains(t, err, "undeclared variable name")
+	_, err = registry.Render("nope", userID, nil)
+	assert.ErrorIs(t, err, domain.ErrUnknownPrompt)
+}
+
+func TestPromptRegistry_RejectsUndeclaredTemplateVariables(t *testing.T) {
+	tmpl := abTestTemplate()
+	tmpl.Variants[1].System = "Hello {{.name}}"
+
+	_, err := NewPromptRegistry(nil, tmpl)
+
+	assert.ErrorContains(t, err, "name")
+}
+
+func TestPromptRegistry_BucketsUsersByWeight(t *testing.T) {
+	tmpl := abTestTemplate()
+	tmpl.Variants[0].Weight = 3
+	registry, err := NewPromptRegistry(nil, tmpl)
+	require.NoError(t, err)
+	vars := domain.PromptVars{"discipline_index": 50.0, "fasting_status": "fasting"}
+
+	counts := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		rendered, err := registry.Render(promptCoachPersona, uuid.New(), vars)
+		require.NoError(t, err)
+		counts[rendered.Variant]++
+	}
+	assert.InDelta(t, 1500, counts["drill_sergeant"], 100)
+	assert.InDelta(t, 500, counts["mentor"], 100)
+
+	userID := uuid.New()
+	first, _ := registry.Render(promptCoachPersona, userID, vars)
+	for i := 0; i < 10; i++ {
+		again, _ := registry.Render(promptCoachPersona, userID, vars)
+		assert.Equal(t, first.Variant, again.Variant, "a user keeps their variant")
+	}
+}
+
+func TestLoadPromptRegistry_OverridesMustRaiseTheVersion(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := abTestTemplate()
+	tmpl.Version = 1
+	writePromptFile(t, dir, tmpl)
+
+	_, err := LoadPromptRegistry(dir, nil)
+	assert.ErrorContains(t, err, "version above the built-in 1")
+
+	writePromptFile(t, dir, abTestTemplate())
+	registry, err := LoadPromptRegistry(dir, nil)
+	require.NoError(t, err)
+	rendered, err := registry.Render(promptCoachPersona, uuid.New(), domain.PromptVars{"discipline_index": 50.0, "fasting_status": "fasting"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, rendered.Version)
+	assert.Contains(t, []string{"drill_sergeant", "mentor"}, rendered.Variant)
+}
+
+func writePromptFile(t *testing.T, dir string, tmpl domain.PromptTemplate) {
+	t.Helper()
+	data, err := json.Marshal(tmpl)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, tmpl.Name+".json"), data, 0o644))
+}
+
+func TestPromptExperiments_RecordVariantAndCompareCompletion(t *testing.T) {
+	ctx := context.Background()
+	exposures := memory.NewPromptExposureRepository()
+	registry, err := NewPromptRegistry(exposures, abTestTemplate())
+	require.NoError(t, err)
+
+	mockLLM := new(MockLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, registry, nil, nil)
+	mockLLM.On("GenerateResponse", ctx, "Should I eat?", mock.Anything).Return("No.", nil)
+
+	user := &domain.User{ID: uuid.New(), DisciplineIndex: 60}
+	mockUserRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	mockFastingRepo.On("FindActiveByUserID", mock.Anything, user.ID).Return(nil, nil)
+
+	before := time.Now()
+	_, err = service.Chat(ctx, user.ID, "Should I eat?")
+	require.NoError(t, err)
+	_, err = service.Chat(ctx, user.ID, "Should I eat?")
+	require.NoError(t, err)
+
+	end := func(start time.Time, hours float64) *time.Time {
+		at := start.Add(time.Duration(hours * float64(time.Hour)))
+		return &at
+	}
+	mockFastingRepo.On("FindByUserID", mock.Anything, user.ID).Return([]domain.FastingSession{
+		{StartTime: before.Add(-48 * time.Hour), EndTime: end(before.Add(-48*time.Hour), 16), GoalHours: 16, Status: domain.StatusCompleted}, // Before the coaching
+		{StartTime: before.Add(time.Hour), EndTime: end(before.Add(time.Hour), 18), GoalHours: 16, Status: domain.StatusCompleted},
+		{StartTime: before.Add(30 * time.Hour), EndTime: end(before.Add(30*time.Hour), 4), GoalHours: 16, Status: domain.StatusCompleted},
+		{StartTime: before.Add(60 * time.Hour), GoalHours: 16, Status: domain.StatusActive},
+	}, nil)
+
+	report, err := service.PromptExperimentReport(ctx)
+	require.NoError(t, err)
+
+	variant := abTestTemplate().VariantFor(user.ID).Name
+	assert.Equal(t, []domain.PromptVariantStats{{
+		Prompt: promptCoachPersona, Version: 2, Variant: variant,
+		Users: 1, Responses: 2, FastsEnded: 2, FastsCompleted: 1, CompletionRate: 0.5,
+	}}, report)
+}
@@ -4,26 +4,42 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
-	"errors"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ReferralConfig is what referrals pay and when they are held for review
+type ReferralConfig struct {
+	RefereeReward     float64                     // Paid to the referred user
+	Tiers             []domain.ReferralRewardTier // Referrer reward by completed referrals, sorted by MinCompleted
+	RapidSignupLimit  int                         // This many referrals by one referrer within RapidSignupWindow is suspicious; 0 disables the check
+	RapidSignupWindow time.Duration
+	DisposableDomains map[string]bool // Email domains to treat as throwaway on top of the built-in list
+}
+
+// ReferralService tracks who referred whom and pays both sides once the referee does something
+// that costs a real account effort: pays a first invoice or completes a first fast. Referrals
+// showing fraud signals at that point are held for an admin instead of paid. Rewards go to the
+// vault ledger, so a side that isn't a vault member yet is owed the reward until it joins.
 type ReferralService struct {
 	referralRepo ports.ReferralRepository
 	userRepo     ports.UserRepository
 	vaultService ports.VaultService
+	config       ReferralConfig
 }
 
-func NewReferralService(referralRepo ports.ReferralRepository, userRepo ports.UserRepository, vaultService ports.VaultService) *ReferralService {
+func NewReferralService(referralRepo ports.ReferralRepository, userRepo ports.UserRepository, vaultService ports.VaultService, config ReferralConfig) *ReferralService {
 	return &ReferralService{
 		referralRepo: referralRepo,
 		userRepo:     userRepo,
 		vaultService: vaultService,
+		config:       config,
 	}
 }
 
@@ -78,57 +94,258 @@ func (s *ReferralService) TrackReferral(ctx context.Context, referrerCode string
 	}
 
 	if referrer.ID == refereeID {
-		return errors.New("cannot refer yourself")
+		return domain.ErrSelfReferral
 	}
 
 	// Check if referral already exists
 	existing, _ := s.referralRepo.FindByRefereeID(ctx, refereeID)
 	if existing != nil {
-		return errors.New("user already referred")
+		return domain.ErrAlreadyReferred
 	}
 
+	// Rewards are set when the referral qualifies, by the referrer's tier at that point
 	referral := &domain.Referral{
-		ID:          uuid.New(),
-		ReferrerID:  referrer.ID,
-		RefereeID:   refereeID,
-		Status:      domain.ReferralStatusPending,
-		RewardValue: 5.00, // $5.00 reward
-		CreatedAt:   time.Now(),
+		ID:         uuid.New(),
+		ReferrerID: referrer.ID,
+		RefereeID:  refereeID,
+		Status:     domain.ReferralStatusPending,
+		CreatedAt:  time.Now(),
 	}
 
 	return s.referralRepo.Save(ctx, referral)
 }
 
-func (s *ReferralService) CompleteReferral(ctx context.Context, refereeID uuid.UUID) error {
+// maxSignupUserAgent is as much of a user agent as the signup signals keep
+const maxSignupUserAgent = 512
+
+// RecordSignup keeps the IP, device and user agent a user registered from, for comparing referrals later
+func (s *ReferralService) RecordSignup(ctx context.Context, userID uuid.UUID, ip, deviceID, userAgent string) error {
+	if ip == "" && deviceID == "" && userAgent == "" {
+		return nil
+	}
+	if len(userAgent) > maxSignupUserAgent {
+		userAgent = userAgent[:maxSignupUserAgent]
+	}
+	return s.referralRepo.SaveSignupSignals(ctx, &domain.SignupSignals{
+		UserID:    userID,
+		IP:        ip,
+		DeviceID:  deviceID,
+		UserAgent: userAgent,
+		CreatedAt: time.Now(),
+	})
+}
+
+// QualifyReferral is called when the referee does something that earns the referral. The first
+// qualifying event settles it: the referral pays out, or is held when it shows fraud signals.
+// Later events, and users who weren't referred, are ignored.
+func (s *ReferralService) QualifyReferral(ctx context.Context, refereeID uuid.UUID, event domain.ReferralEvent) error {
 	referral, err := s.referralRepo.FindByRefereeID(ctx, refereeID)
 	if err != nil {
-		return nil // No referral found, nothing to do
+		return err
 	}
-
-	if referral.Status == domain.ReferralStatusCompleted {
-		return nil // Already completed
+	if referral == nil || referral.Status != domain.ReferralStatusPending {
+		return nil
 	}
 
-	// Award referrer
-	referrer, err := s.userRepo.FindByID(ctx, referral.ReferrerID)
+	now := time.Now()
+	referral.QualifiedBy = event
+	referral.QualifiedAt = &now
+
+	signals, err := s.fraudSignals(ctx, referral)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to check referral: %w", err)
 	}
-	s.vaultService.AddDailyEarnings(ctx, referrer, referral.RewardValue)
+	if len(signals) > 0 {
+		referral.Status = domain.ReferralStatusHeld
+		referral.FraudSignals = signals
+		if err := s.referralRepo.Update(ctx, referral); err != nil {
+			return err
+		}
+		logger.Warn().Str("referral_id", referral.ID.String()).Interface("signals", signals).Msg("Referral held for review")
+		return nil
+	}
+	return s.complete(ctx, referral, now)
+}
 
-	// Award referee
-	referee, err := s.userRepo.FindByID(ctx, refereeID)
+func (s *ReferralService) ListHeldReferrals(ctx context.Context) ([]domain.Referral, error) {
+	return s.referralRepo.ListByStatus(ctx, domain.ReferralStatusHeld)
+}
+
+// ReviewReferral settles a held referral: approving pays it out, rejecting closes it unpaid
+func (s *ReferralService) ReviewReferral(ctx context.Context, referralID, adminID uuid.UUID, approve bool, note string) (*domain.Referral, error) {
+	referral, err := s.referralRepo.FindByID(ctx, referralID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if referral == nil {
+		return nil, domain.ErrReferralNotFound
+	}
+	if referral.Status != domain.ReferralStatusHeld {
+		return nil, domain.ErrReferralNotHeld
 	}
-	s.vaultService.AddDailyEarnings(ctx, referee, referral.RewardValue)
 
-	// Mark as completed
 	now := time.Now()
+	referral.ReviewedBy = &adminID
+	referral.ReviewedAt = &now
+	referral.ReviewNote = note
+	if approve {
+		err = s.complete(ctx, referral, now)
+	} else {
+		referral.Status = domain.ReferralStatusRejected
+		err = s.referralRepo.Update(ctx, referral)
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.Info().Str("referral_id", referral.ID.String()).Str("admin_id", adminID.String()).Bool("approved", approve).Msg("Referral reviewed")
+	return referral, nil
+}
+
+// PayOwedRewards credits completed referrals whose referrer or referee has since joined the vault
+func (s *ReferralService) PayOwedRewards(ctx context.Context, now time.Time) (int, error) {
+	referrals, err := s.referralRepo.ListUnpaid(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list unpaid referrals: %w", err)
+	}
+	paid := 0
+	for i := range referrals {
+		referral := &referrals[i]
+		credited, err := s.payOut(ctx, referral, now)
+		if err != nil {
+			logger.Error().Err(err).Str("referral_id", referral.ID.String()).Msg("Failed to pay referral reward")
+			continue
+		}
+		paid += credited
+	}
+	return paid, nil
+}
+
+// complete sets the rewards by the referrer's tier, marks the referral completed and pays
+// whichever sides can be paid now
+func (s *ReferralService) complete(ctx context.Context, referral *domain.Referral, now time.Time) error {
+	_, completed, err := s.GetReferralStats(ctx, referral.ReferrerID)
+	if err != nil {
+		return err
+	}
 	referral.Status = domain.ReferralStatusCompleted
 	referral.CompletedAt = &now
+	referral.RewardValue = domain.ReferrerReward(s.config.Tiers, completed)
+	referral.RefereeReward = s.config.RefereeReward
+	if err := s.referralRepo.Update(ctx, referral); err != nil {
+		return err
+	}
+	logger.Info().Str("referral_id", referral.ID.String()).Float64("referrer_reward", referral.RewardValue).Msg("Referral completed")
+
+	// Completion stands even if paying fails; PayOwedRewards retries
+	if _, err := s.payOut(ctx, referral, now); err != nil {
+		logger.Error().Err(err).Str("referral_id", referral.ID.String()).Msg("Failed to pay referral reward")
+	}
+	return nil
+}
+
+// payOut credits each unpaid side that is a vault member and records it, returning how many
+// sides were credited. Ledger entries are keyed by the referral, so a retry can't pay twice.
+func (s *ReferralService) payOut(ctx context.Context, referral *domain.Referral, now time.Time) (int, error) {
+	credited := 0
+	sides := []struct {
+		userID uuid.UUID
+		amount float64
+		paidAt **time.Time
+	}{
+		{referral.ReferrerID, referral.RewardValue, &referral.ReferrerPaidAt},
+		{referral.RefereeID, referral.RefereeReward, &referral.RefereePaidAt},
+	}
+	for _, side := range sides {
+		if *side.paidAt != nil {
+			continue
+		}
+		user, err := s.userRepo.FindByID(ctx, side.userID)
+		if err != nil {
+			return credited, err
+		}
+		if user == nil || !user.IsVaultMember() {
+			continue // Owed until they join the vault
+		}
+		if side.amount > 0 {
+			if err := s.vaultService.AddReferralReward(ctx, user, referral.ID, side.amount); err != nil {
+				return credited, err
+			}
+		}
+		paidAt := now
+		*side.paidAt = &paidAt
+		credited++
+	}
+	if credited == 0 {
+		return 0, nil
+	}
+	return credited, s.referralRepo.Update(ctx, referral)
+}
+
+// fraudSignals compares the referee's signup with the referrer's and with the referrer's other
+// referees, and checks the referee's email and how fast the referrer has been signing people up
+func (s *ReferralService) fraudSignals(ctx context.Context, referral *domain.Referral) ([]domain.ReferralFraudSignal, error) {
+	var signals []domain.ReferralFraudSignal
+
+	referee, err := s.userRepo.FindByID(ctx, referral.RefereeID)
+	if err != nil {
+		return nil, err
+	}
+	if referee != nil && domain.IsDisposableEmail(referee.Email, s.config.DisposableDomains) {
+		signals = append(signals, domain.FraudSignalDisposableEmail)
+	}
 
-	return s.referralRepo.Update(ctx, referral)
+	siblings, err := s.referralRepo.FindByReferrerID(ctx, referral.ReferrerID)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.RapidSignupLimit > 0 {
+		nearby := 0
+		for _, other := range siblings {
+			gap := other.CreatedAt.Sub(referral.CreatedAt)
+			if gap < 0 {
+				gap = -gap
+			}
+			if gap <= s.config.RapidSignupWindow {
+				nearby++ // Includes this referral
+			}
+		}
+		if nearby >= s.config.RapidSignupLimit {
+			signals = append(signals, domain.FraudSignalRapidSignups)
+		}
+	}
+
+	mine, err := s.referralRepo.FindSignupSignals(ctx, referral.RefereeID)
+	if err != nil {
+		return nil, err
+	}
+	if mine == nil {
+		return signals, nil
+	}
+	others := []uuid.UUID{referral.ReferrerID}
+	for _, other := range siblings {
+		if other.RefereeID != referral.RefereeID {
+			others = append(others, other.RefereeID)
+		}
+	}
+	sharedIP, sharedDevice := false, false
+	for _, userID := range others {
+		theirs, err := s.referralRepo.FindSignupSignals(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if theirs == nil {
+			continue
+		}
+		sharedIP = sharedIP || mine.SharesIP(*theirs)
+		sharedDevice = sharedDevice || mine.SharesDevice(*theirs)
+	}
+	if sharedIP {
+		signals = append(signals, domain.FraudSignalSharedIP)
+	}
+	if sharedDevice {
+		signals = append(signals, domain.FraudSignalSharedDevice)
+	}
+	return signals, nil
 }
 
 func (s *ReferralService) GetReferralStats(ctx context.Context, userID uuid.UUID) (float64, int, error) {
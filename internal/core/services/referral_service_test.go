@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fastinghero/internal/core/domain"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -42,6 +43,106 @@ func (m *MockReferralRepository) FindByRefereeID(ctx context.Context, refereeID
 	return args.Get(0).(*domain.Referral), args.Error(1)
 }
 
+func (m *MockReferralRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Referral, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Referral), args.Error(1)
+}
+
+func (m *MockReferralRepository) ListByStatus(ctx context.Context, status domain.ReferralStatus) ([]domain.Referral, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Referral), args.Error(1)
+}
+
+func (m *MockReferralRepository) ListUnpaid(ctx context.Context) ([]domain.Referral, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Referral), args.Error(1)
+}
+
+func (m *MockReferralRepository) SaveSignupSignals(ctx context.Context, signals *domain.SignupSignals) error {
+	args := m.Called(ctx, signals)
+	return args.Error(0)
+}
+
+func (m *MockReferralRepository) FindSignupSignals(ctx context.Context, userID uuid.UUID) (*domain.SignupSignals, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SignupSignals), args.Error(1)
+}
+
+// MockReferralService is a mock of ports.ReferralService
+type MockReferralService struct {
+	mock.Mock
+}
+
+func (m *MockReferralService) GenerateReferralCode(ctx context.Context, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockReferralService) GetReferralCode(ctx context.Context, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockReferralService) TrackReferral(ctx context.Context, referrerCode string, refereeID uuid.UUID) error {
+	args := m.Called(ctx, referrerCode, refereeID)
+	return args.Error(0)
+}
+
+func (m *MockReferralService) RecordSignup(ctx context.Context, userID uuid.UUID, ip, deviceID, userAgent string) error {
+	args := m.Called(ctx, userID, ip, deviceID, userAgent)
+	return args.Error(0)
+}
+
+func (m *MockReferralService) QualifyReferral(ctx context.Context, refereeID uuid.UUID, event domain.ReferralEvent) error {
+	args := m.Called(ctx, refereeID, event)
+	return args.Error(0)
+}
+
+func (m *MockReferralService) ListHeldReferrals(ctx context.Context) ([]domain.Referral, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Referral), args.Error(1)
+}
+
+func (m *MockReferralService) ReviewReferral(ctx context.Context, referralID, adminID uuid.UUID, approve bool, note string) (*domain.Referral, error) {
+	args := m.Called(ctx, referralID, adminID, approve, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Referral), args.Error(1)
+}
+
+func (m *MockReferralService) PayOwedRewards(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockReferralService) GetReferralStats(ctx context.Context, userID uuid.UUID) (float64, int, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(float64), args.Int(1), args.Error(2)
+}
+
+var testReferralConfig = ReferralConfig{
+	RefereeReward:     5.00,
+	Tiers:             domain.DefaultReferralRewardTiers,
+	RapidSignupLimit:  5,
+	RapidSignupWindow: time.Hour,
+}
+
 // ============== GENERATE REFERRAL CODE TESTS ==============
 
 func TestReferralService_GenerateReferralCode_Success(t *testing.T) {
@@ -49,7 +150,7 @@ func TestReferralService_GenerateReferralCode_Success(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockVaultService := new(MockVaultService)
 
-	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService)
+	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService, testReferralConfig)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -73,7 +174,7 @@ func TestReferralService_GenerateReferralCode_AlreadyExists(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockVaultService := new(MockVaultService)
 
-	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService)
+	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService, testReferralConfig)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -95,7 +196,7 @@ func TestReferralService_GenerateReferralCode_UserNotFound(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockVaultService := new(MockVaultService)
 
-	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService)
+	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService, testReferralConfig)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -114,7 +215,7 @@ func TestReferralService_GetReferralCode_Existing(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockVaultService := new(MockVaultService)
 
-	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService)
+	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService, testReferralConfig)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -136,7 +237,7 @@ func TestReferralService_GetReferralCode_GenerateNew(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockVaultService := new(MockVaultService)
 
-	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService)
+	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService, testReferralConfig)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -161,7 +262,7 @@ func TestReferralService_TrackReferral_Success(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockVaultService := new(MockVaultService)
 
-	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService)
+	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService, testReferralConfig)
 	ctx := context.Background()
 	referrerID := uuid.New()
 	refereeID := uuid.New()
@@ -185,7 +286,7 @@ func TestReferralService_TrackReferral_EmptyCode(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockVaultService := new(MockVaultService)
 
-	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService)
+	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService, testReferralConfig)
 	ctx := context.Background()
 	refereeID := uuid.New()
 
@@ -199,7 +300,7 @@ func TestReferralService_TrackReferral_SelfReferral(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockVaultService := new(MockVaultService)
 
-	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService)
+	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService, testReferralConfig)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -221,7 +322,7 @@ func TestReferralService_TrackReferral_AlreadyReferred(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockVaultService := new(MockVaultService)
 
-	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService)
+	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService, testReferralConfig)
 	ctx := context.Background()
 	referrerID := uuid.New()
 	refereeID := uuid.New()
@@ -245,7 +346,7 @@ func TestReferralService_GetReferralStats_WithReferrals(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockVaultService := new(MockVaultService)
 
-	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService)
+	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService, testReferralConfig)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -269,7 +370,7 @@ func TestReferralService_GetReferralStats_NoReferrals(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockVaultService := new(MockVaultService)
 
-	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService)
+	service := NewReferralService(mockReferralRepo, mockUserRepo, mockVaultService, testReferralConfig)
 	ctx := context.Background()
 	userID := uuid.New()
 
@@ -282,6 +383,237 @@ func TestReferralService_GetReferralStats_NoReferrals(t *testing.T) {
 	assert.Equal(t, 0, count)
 }
 
+// ============== QUALIFY REFERRAL TESTS ==============
+
+// referralFixture is a pending referral between two vault members with clean signup signals
+type referralFixture struct {
+	referralRepo *MockReferralRepository
+	userRepo     *MockUserRepository
+	vault        *MockVaultService
+	service      *ReferralService
+	referrer     *domain.User
+	referee      *domain.User
+	referral     *domain.Referral
+}
+
+func newReferralFixture(siblings ...domain.Referral) *referralFixture {
+	ctx := context.Background()
+	f := &referralFixture{
+		referralRepo: new(MockReferralRepository),
+		userRepo:     new(MockUserRepository),
+		vault:        new(MockVaultService),
+		referrer:     newVaultMember(),
+		referee:      newVaultMember(),
+	}
+	f.referee.Email = "friend@example.com"
+	f.service = NewReferralService(f.referralRepo, f.userRepo, f.vault, testReferralConfig)
+	f.referral = &domain.Referral{
+		ID:         uuid.New(),
+		ReferrerID: f.referrer.ID,
+		RefereeID:  f.referee.ID,
+		Status:     domain.ReferralStatusPending,
+		CreatedAt:  time.Now().Add(-24 * time.Hour),
+	}
+
+	f.userRepo.On("FindByID", ctx, f.referrer.ID).Return(f.referrer, nil)
+	f.userRepo.On("FindByID", ctx, f.referee.ID).Return(f.referee, nil)
+	f.referralRepo.On("FindByRefereeID", ctx, f.referee.ID).Return(f.referral, nil)
+	f.referralRepo.On("FindByReferrerID", ctx, f.referrer.ID).Return(append(siblings, *f.referral), nil)
+	f.referralRepo.On("Update", ctx, f.referral).Return(nil)
+	return f
+}
+
+func (f *referralFixture) signups(referrer, referee *domain.SignupSignals) {
+	ctx := context.Background()
+	f.referralRepo.On("FindSignupSignals", ctx, f.referrer.ID).Return(referrer, nil)
+	f.referralRepo.On("FindSignupSignals", ctx, f.referee.ID).Return(referee, nil)
+}
+
+func TestReferralService_QualifyReferral_PaysBothSides(t *testing.T) {
+	f := newReferralFixture()
+	ctx := context.Background()
+	f.signups(&domain.SignupSignals{IP: "10.0.0.1", DeviceID: "dev-a"}, &domain.SignupSignals{IP: "10.0.0.2", DeviceID: "dev-b"})
+	f.vault.On("AddReferralReward", ctx, f.referrer, f.referral.ID, 5.0).Return(nil)
+	f.vault.On("AddReferralReward", ctx, f.referee, f.referral.ID, 5.0).Return(nil)
+
+	err := f.service.QualifyReferral(ctx, f.referee.ID, domain.ReferralEventFirstFast)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ReferralStatusCompleted, f.referral.Status)
+	assert.Equal(t, domain.ReferralEventFirstFast, f.referral.QualifiedBy)
+	assert.True(t, f.referral.IsPaid())
+	f.vault.AssertExpectations(t)
+}
+
+func TestReferralService_QualifyReferral_PowerReferrerTier(t *testing.T) {
+	var completed []domain.Referral
+	for i := 0; i < 5; i++ {
+		completed = append(completed, domain.Referral{ID: uuid.New(), RefereeID: uuid.New(), Status: domain.ReferralStatusCompleted, RewardValue: 5.0, CreatedAt: time.Now().AddDate(0, -1, -i)})
+	}
+	f := newReferralFixture(completed...)
+	ctx := context.Background()
+	f.referralRepo.On("FindSignupSignals", ctx, mock.Anything).Return(nil, nil)
+	f.vault.On("AddReferralReward", ctx, f.referrer, f.referral.ID, 7.5).Return(nil)
+	f.vault.On("AddReferralReward", ctx, f.referee, f.referral.ID, 5.0).Return(nil)
+
+	err := f.service.QualifyReferral(ctx, f.referee.ID, domain.ReferralEventFirstPayment)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7.5, f.referral.RewardValue)
+	assert.Equal(t, 5.0, f.referral.RefereeReward)
+	f.vault.AssertExpectations(t)
+}
+
+func TestReferralService_QualifyReferral_HoldsSuspiciousReferrals(t *testing.T) {
+	cases := []struct {
+		name     string
+		edit     func(f *referralFixture)
+		referrer *domain.SignupSignals
+		referee  *domain.SignupSignals
+		siblings int
+		signal   domain.ReferralFraudSignal
+	}{
+		{
+			name:     "shared ip",
+			referrer: &domain.SignupSignals{IP: "10.0.0.1"},
+			referee:  &domain.SignupSignals{IP: "10.0.0.1"},
+			signal:   domain.FraudSignalSharedIP,
+		},
+		{
+			name:     "shared device",
+			referrer: &domain.SignupSignals{IP: "10.0.0.1", DeviceID: "dev-a"},
+			referee:  &domain.SignupSignals{IP: "10.0.0.2", DeviceID: "dev-a"},
+			signal:   domain.FraudSignalSharedDevice,
+		},
+		{
+			name:     "same browser and network without a device id",
+			referrer: &domain.SignupSignals{IP: "10.0.0.1", DeviceID: "dev-a", UserAgent: "Mozilla/5.0 (X11; Linux x86_64)"},
+			referee:  &domain.SignupSignals{IP: "10.0.0.2", UserAgent: "Mozilla/5.0 (X11; Linux x86_64)"},
+			signal:   domain.FraudSignalSharedDevice,
+		},
+		{
+			name:   "disposable email",
+			edit:   func(f *referralFixture) { f.referee.Email = "someone@mail.yopmail.com" },
+			signal: domain.FraudSignalDisposableEmail,
+		},
+		{
+			name:     "rapid signups",
+			siblings: 4,
+			signal:   domain.FraudSignalRapidSignups,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var siblings []domain.Referral
+			for i := 0; i < tc.siblings; i++ {
+				siblings = append(siblings, domain.Referral{ID: uuid.New(), RefereeID: uuid.New(), Status: domain.ReferralStatusPending, CreatedAt: time.Now().Add(-24*time.Hour + time.Duration(i)*time.Minute)})
+			}
+			f := newReferralFixture(siblings...)
+			ctx := context.Background()
+			if tc.edit != nil {
+				tc.edit(f)
+			}
+			f.signups(tc.referrer, tc.referee)
+			f.referralRepo.On("FindSignupSignals", ctx, mock.Anything).Return(nil, nil)
+
+			err := f.service.QualifyReferral(ctx, f.referee.ID, domain.ReferralEventFirstFast)
+
+			assert.NoError(t, err)
+			assert.Equal(t, domain.ReferralStatusHeld, f.referral.Status)
+			assert.Equal(t, []domain.ReferralFraudSignal{tc.signal}, f.referral.FraudSignals)
+			f.vault.AssertNotCalled(t, "AddReferralReward", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestReferralService_QualifyReferral_IgnoresSettledReferral(t *testing.T) {
+	f := newReferralFixture()
+	ctx := context.Background()
+	f.referral.Status = domain.ReferralStatusHeld
+
+	err := f.service.QualifyReferral(ctx, f.referee.ID, domain.ReferralEventFirstPayment)
+
+	assert.NoError(t, err)
+	f.referralRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestReferralService_QualifyReferral_OwesNonMembersUntilTheyJoin(t *testing.T) {
+	f := newReferralFixture()
+	ctx := context.Background()
+	f.referee.SubscriptionTier = domain.TierFree
+	f.referralRepo.On("FindSignupSignals", ctx, mock.Anything).Return(nil, nil)
+	f.vault.On("AddReferralReward", ctx, f.referrer, f.referral.ID, 5.0).Return(nil)
+
+	err := f.service.QualifyReferral(ctx, f.referee.ID, domain.ReferralEventFirstFast)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ReferralStatusCompleted, f.referral.Status)
+	assert.NotNil(t, f.referral.ReferrerPaidAt)
+	assert.Nil(t, f.referral.RefereePaidAt)
+
+	// The referee joins the vault; the hourly job pays what they are owed
+	f.referee.SubscriptionTier = domain.TierVault
+	f.referralRepo.On("ListUnpaid", ctx).Return([]domain.Referral{*f.referral}, nil)
+	f.referralRepo.On("Update", ctx, mock.AnythingOfType("*domain.Referral")).Return(nil)
+	f.vault.On("AddReferralReward", ctx, f.referee, f.referral.ID, 5.0).Return(nil)
+
+	paid, err := f.service.PayOwedRewards(ctx, time.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, paid)
+	f.vault.AssertExpectations(t)
+	f.vault.AssertNumberOfCalls(t, "AddReferralReward", 2)
+}
+
+// ============== REVIEW REFERRAL TESTS ==============
+
+func TestReferralService_ReviewReferral(t *testing.T) {
+	ctx := context.Background()
+	adminID := uuid.New()
+
+	t.Run("approve pays out", func(t *testing.T) {
+		f := newReferralFixture()
+		f.referral.Status = domain.ReferralStatusHeld
+		f.referralRepo.On("FindByID", ctx, f.referral.ID).Return(f.referral, nil)
+		f.vault.On("AddReferralReward", ctx, mock.Anything, f.referral.ID, 5.0).Return(nil)
+
+		reviewed, err := f.service.ReviewReferral(ctx, f.referral.ID, adminID, true, "same household")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ReferralStatusCompleted, reviewed.Status)
+		assert.Equal(t, &adminID, reviewed.ReviewedBy)
+		f.vault.AssertNumberOfCalls(t, "AddReferralReward", 2)
+	})
+
+	t.Run("reject pays nothing", func(t *testing.T) {
+		f := newReferralFixture()
+		f.referral.Status = domain.ReferralStatusHeld
+		f.referralRepo.On("FindByID", ctx, f.referral.ID).Return(f.referral, nil)
+
+		reviewed, err := f.service.ReviewReferral(ctx, f.referral.ID, adminID, false, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ReferralStatusRejected, reviewed.Status)
+		f.vault.AssertNotCalled(t, "AddReferralReward", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("only held referrals", func(t *testing.T) {
+		f := newReferralFixture()
+		f.referralRepo.On("FindByID", ctx, f.referral.ID).Return(f.referral, nil)
+
+		_, err := f.service.ReviewReferral(ctx, f.referral.ID, adminID, true, "")
+
+		assert.ErrorIs(t, err, domain.ErrReferralNotHeld)
+	})
+}
+
+func TestReferrerReward(t *testing.T) {
+	assert.Equal(t, 5.0, domain.ReferrerReward(domain.DefaultReferralRewardTiers, 0))
+	assert.Equal(t, 5.0, domain.ReferrerReward(domain.DefaultReferralRewardTiers, 4))
+	assert.Equal(t, 7.5, domain.ReferrerReward(domain.DefaultReferralRewardTiers, 5))
+	assert.Equal(t, 10.0, domain.ReferrerReward(domain.DefaultReferralRewardTiers, 40))
+}
+
 // ============== GENERATE RANDOM CODE TEST ==============
 
 func TestGenerateRandomCode(t *testing.T) {
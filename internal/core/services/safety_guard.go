@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var errSafetyReviewDisabled = errors.New("safety flags are not stored")
+
+// riskPatterns map phrases in a user's own words to the risk they signal. They err on the side
+// of flagging: a false positive costs a gentler reply and a flag a reviewer can close.
+var riskPatterns = []struct {
+	signal  domain.SafetySignal
+	pattern *regexp.Regexp
+}{
+	{domain.SafetySignalPurging, regexp.MustCompile(`(?i)\b(purg(e|ed|es|ing)|throw(ing)? (it )?up|threw up|vomit(ed|ing)?|make myself (sick|vomit|throw up)|laxatives?|diuretics?)\b`)},
+	{domain.SafetySignalFainting, regexp.MustCompile(`(?i)\b(faint(ed|ing)?|pass(ed|ing)? out|black(ed|ing)? out|collaps(e|ed|ing)|dizz(y|iness)|light-?headed|chest pains?|heart (is )?(racing|pounding)|palpitations?)\b`)},
+	{domain.SafetySignalExtremeRestriction, regexp.MustCompile(`(?i)\b((haven'?t|have not|not) eaten (in|for) (days|a week|weeks)|(stop|stopped|quit) eating|eat(ing)? nothing|starv(e|ing) myself|punish(ing)? myself|hate my body|(so|too) fat|[1-7]\d{2} (calories|kcal|cals?) a day|under [1-7]\d{2} (calories|kcal|cals?))\b`)},
+	{domain.SafetySignalSelfHarm, regexp.MustCompile(`(?i)\b(kill(ing)? myself|suicid(e|al)|end(ing)? my life|hurt(ing)? myself|self[- ]harm|don'?t want to (live|be here))\b`)},
+}
+
+// unsafeAdvicePatterns match a reply telling the user to do something dangerous. They look for
+// advice rather than topics, and negatedAdvice skips warnings like "never use laxatives".
+var unsafeAdvicePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(try|use|take) (a |some )?(laxatives?|diuretics?)\b`),
+	regexp.MustCompile(`(?i)\bmake yourself (sick|vomit|throw up)\b`),
+	regexp.MustCompile(`(?i)\b(ignore|push through|fight through|power through) (the |your |that |any )?(dizziness|fainting|chest pain|palpitations|lightheadedness|symptoms)\b`),
+	regexp.MustCompile(`(?i)\b(eat|stay under|limit yourself to|only) [1-7]\d{2} (calories|kcal)\b`),
+	regexp.MustCompile(`(?i)\b(skip|stop drinking|avoid|cut out) (all )?water\b`),
+}
+
+var negatedAdvice = regexp.MustCompile(`(?i)\b(never|don'?t|do not|shouldn'?t|should not|avoid|no need to)\s+$`)
+
+// advises reports whether pattern matches reply other than in a negated phrase
+func advises(pattern *regexp.Regexp, reply string) bool {
+	for _, loc := range pattern.FindAllStringIndex(reply, -1) {
+		if !negatedAdvice.MatchString(reply[:loc[0]]) {
+			return true
+		}
+	}
+	return false
+}
+
+// SafetyGuard screens what users tell Cortex, and what Cortex answers them, for eating-disorder
+// and medical risk. Whatever it finds is logged and, when flags is set, stored for human review.
+type SafetyGuard struct {
+	flags ports.SafetyFlagRepository
+}
+
+// NewSafetyGuard creates a guard; flags may be nil, in which case flags are only logged
+func NewSafetyGuard(flags ports.SafetyFlagRepository) *SafetyGuard {
+	return &SafetyGuard{flags: flags}
+}
+
+// ScreenInput checks text the user wrote, and the BMI from their profile, for risk signals.
+// Anything found is flagged.
+func (g *SafetyGuard) ScreenInput(ctx context.Context, user *domain.User, source domain.SafetySource, text string) domain.SafetyAssessment {
+	var assessment domain.SafetyAssessment
+	for _, risk := range riskPatterns {
+		if risk.pattern.MatchString(text) {
+			assessment.Signals = append(assessment.Signals, risk.signal)
+		}
+	}
+	if user == nil {
+		return assessment
+	}
+	assessment.BMI = domain.BMI(user.HeightCm, user.CurrentWeightLbs)
+	if assessment.BMI > 0 && assessment.BMI < domain.VeryLowBMI {
+		assessment.Signals = append(assessment.Signals, domain.SafetySignalLowBMI)
+	}
+	if assessment.Flagged() {
+		g.flag(ctx, user.ID, source, domain.SafetyStageInput, assessment, text)
+	}
+	return assessment
+}
+
+// ScreenReply checks a Cortex reply for advice that could hurt the user and flags it
+func (g *SafetyGuard) ScreenReply(ctx context.Context, userID uuid.UUID, source domain.SafetySource, reply string) domain.SafetyAssessment {
+	var assessment domain.SafetyAssessment
+	for _, pattern := range unsafeAdvicePatterns {
+		if advises(pattern, reply) {
+			assessment.Signals = []domain.SafetySignal{domain.SafetySignalUnsafeAdvice}
+			g.flag(ctx, userID, source, domain.SafetyStageReply, assessment, reply)
+			break
+		}
+	}
+	return assessment
+}
+
+// flag logs the finding and stores it for review. A low BMI with nothing in the text is
+// flagged once until reviewed, not on every request the user makes.
+func (g *SafetyGuard) flag(ctx context.Context, userID uuid.UUID, source domain.SafetySource, stage domain.SafetyStage, assessment domain.SafetyAssessment, text string) {
+	if g.flags == nil {
+		logger.Warn().Str("user_id", userID.String()).Str("source", string(source)).Interface("signals", assessment.Signals).Msg("Cortex safety flag raised")
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	if len(assessment.Signals) == 1 && assessment.Signals[0] == domain.SafetySignalLowBMI {
+		open, err := g.flags.HasOpenFlag(ctx, userID, domain.SafetySignalLowBMI)
+		if err != nil {
+			logger.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to look up open safety flags")
+		}
+		if open {
+			return
+		}
+	}
+
+	if utf8.RuneCountInString(text) > domain.MaxSafetyExcerptLength {
+		text = string([]rune(text)[:domain.MaxSafetyExcerptLength])
+	}
+	flag := &domain.SafetyFlag{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Source:    source,
+		Stage:     stage,
+		Signals:   assessment.Signals,
+		BMI:       assessment.BMI,
+		Excerpt:   strings.TrimSpace(text),
+		Status:    domain.SafetyFlagOpen,
+		CreatedAt: time.Now(),
+	}
+	if err := g.flags.Save(ctx, flag); err != nil {
+		logger.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to save safety flag")
+		return
+	}
+	logger.Warn().Str("flag_id", flag.ID.String()).Str("user_id", userID.String()).Str("source", string(source)).
+		Interface("signals", flag.Signals).Msg("Cortex safety flag raised for review")
+}
+
+// ListSafetyFlags returns flags with the status, oldest first
+func (g *SafetyGuard) ListSafetyFlags(ctx context.Context, status domain.SafetyFlagStatus) ([]domain.SafetyFlag, error) {
+	if g.flags == nil {
+		return nil, errSafetyReviewDisabled
+	}
+	return g.flags.ListByStatus(ctx, status)
+}
+
+// ReviewSafetyFlag closes an open flag with the reviewer's note
+func (g *SafetyGuard) ReviewSafetyFlag(ctx context.Context, flagID, reviewerID uuid.UUID, note string) (*domain.SafetyFlag, error) {
+	if g.flags == nil {
+		return nil, errSafetyReviewDisabled
+	}
+	flag, err := g.flags.FindByID(ctx, flagID)
+	if err != nil {
+		return nil, err
+	}
+	if flag == nil {
+		return nil, domain.ErrSafetyFlagNotFound
+	}
+	if flag.Status != domain.SafetyFlagOpen {
+		return nil, domain.ErrSafetyFlagReviewed
+	}
+
+	now := time.Now()
+	flag.Status = domain.SafetyFlagReviewed
+	flag.ReviewedBy = &reviewerID
+	flag.ReviewedAt = &now
+	flag.ReviewNote = note
+	if err := g.flags.Update(ctx, flag); err != nil {
+		return nil, err
+	}
+	logger.Info().Str("flag_id", flag.ID.String()).Str("reviewer_id", reviewerID.String()).Msg("Safety flag reviewed")
+	return flag, nil
+}
+
+// describeSafetySignals phrases the signals for the safe-response prompt
+func describeSafetySignals(signals []domain.SafetySignal) string {
+	descriptions := map[domain.SafetySignal]string{
+		domain.SafetySignalPurging:            "mentions of purging",
+		domain.SafetySignalFainting:           "fainting, dizziness or heart symptoms",
+		domain.SafetySignalExtremeRestriction: "extreme food restriction",
+		domain.SafetySignalSelfHarm:           "thoughts of self-harm",
+		domain.SafetySignalLowBMI:             "a very low BMI",
+		domain.SafetySignalUnsafeAdvice:       "unsafe advice",
+	}
+	phrases := make([]string, 0, len(signals))
+	for _, signal := range signals {
+		phrases = append(phrases, descriptions[signal])
+	}
+	return strings.Join(phrases, ", ")
+}
+
+// safeCravingResponse replaces craving coaching for a flagged user: no tough love, no push to
+// finish the fast
+func safeCravingResponse() *CravingResponse {
+	return &CravingResponse{
+		ImmediateAction:   "Please end your fast now: have a small snack and a glass of water.",
+		DistractionIdea:   "Sit or lie down somewhere safe until you feel steady.",
+		BiologicalFact:    "Ending a fast early is safe. Pushing through fainting, purging or starvation is not.",
+		Motivation:        "Your health comes first. Stopping today is not a failure.",
+		SupportStrategies: []string{"Eat something small and balanced", "Call someone you trust", "Talk to a doctor or an eating-disorder helpline"},
+		SafetyMode:        true,
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/adapters/repository/memory"
+	"fastinghero/internal/core/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafetyGuard_ScreenInput_DetectsRiskLanguage(t *testing.T) {
+	guard := NewSafetyGuard(nil)
+	user := &domain.User{ID: uuid.New()}
+	ctx := context.Background()
+
+	for text, want := range map[string][]domain.SafetySignal{
+		"I ate a donut so I made myself throw up":           {domain.SafetySignalPurging},
+		"I'm thinking of taking laxatives tonight":          {domain.SafetySignalPurging},
+		"I passed out at work this morning":                 {domain.SafetySignalFainting},
+		"feeling really lightheaded and my heart is racing": {domain.SafetySignalFainting},
+		"I haven't eaten in days and I still hate my body":  {domain.SafetySignalExtremeRestriction},
+		"trying to stay at 400 calories a day":              {domain.SafetySignalExtremeRestriction},
+		"some days I want to end my life":                   {domain.SafetySignalSelfHarm},
+		"I want pizza so badly":                             nil,
+		"How long until ketosis?":                           nil,
+	} {
+		assert.Equal(t, want, guard.ScreenInput(ctx, user, domain.SafetySourceChat, text).Signals, text)
+	}
+}
+
+func TestSafetyGuard_ScreenInput_FlagsLowBMIOnceUntilReviewed(t *testing.T) {
+	flags := memory.NewSafetyFlagRepository()
+	guard := NewSafetyGuard(flags)
+	ctx := context.Background()
+	// 170 cm at 100 lbs is a BMI of about 15.7
+	user := &domain.User{ID: uuid.New(), HeightCm: 170, CurrentWeightLbs: 100}
+
+	first := guard.ScreenInput(ctx, user, domain.SafetySourceInsight, "")
+	assert.Equal(t, []domain.SafetySignal{domain.SafetySignalLowBMI}, first.Signals)
+	assert.InDelta(t, 15.7, first.BMI, 0.1)
+	assert.True(t, guard.ScreenInput(ctx, user, domain.SafetySourceInsight, "").Flagged(), "still screened on every call")
+	assert.True(t, guard.ScreenInput(ctx, user, domain.SafetySourceChat, "I fainted").Flagged())
+
+	open, err := guard.ListSafetyFlags(ctx, domain.SafetyFlagOpen)
+	require.NoError(t, err)
+	require.Len(t, open, 2, "a low BMI alone is stored once; new text signals are stored again")
+	assert.Equal(t, domain.SafetyStageInput, open[0].Stage)
+	assert.Equal(t, []domain.SafetySignal{domain.SafetySignalFainting, domain.SafetySignalLowBMI}, open[1].Signals)
+	assert.Equal(t, "I fainted", open[1].Excerpt)
+
+	healthy := &domain.User{ID: uuid.New(), HeightCm: 170, CurrentWeightLbs: 150}
+	assert.False(t, guard.ScreenInput(ctx, healthy, domain.SafetySourceInsight, "").Flagged())
+}
+
+func TestSafetyGuard_ScreenReply_FlagsAdviceNotWarnings(t *testing.T) {
+	guard := NewSafetyGuard(nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	assert.True(t, guard.ScreenReply(ctx, userID, domain.SafetySourceChat, "Ignore the dizziness and push on.").Flagged())
+	assert.True(t, guard.ScreenReply(ctx, userID, domain.SafetySourceChat, "Stay under 600 calories for the week.").Flagged())
+	assert.True(t, guard.ScreenReply(ctx, userID, domain.SafetySourceChat, "Try a laxative to speed it up.").Flagged())
+	assert.False(t, guard.ScreenReply(ctx, userID, domain.SafetySourceChat, "Never use laxatives or purging to lose weight. If you feel dizzy, eat something.").Flagged())
+}
+
+func TestSafetyGuard_ReviewSafetyFlag(t *testing.T) {
+	flags := memory.NewSafetyFlagRepository()
+	guard := NewSafetyGuard(flags)
+	ctx := context.Background()
+	guard.ScreenInput(ctx, &domain.User{ID: uuid.New()}, domain.SafetySourceSOS, "I keep purging")
+	open, err := guard.ListSafetyFlags(ctx, domain.SafetyFlagOpen)
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	reviewer := uuid.New()
+
+	flag, err := guard.ReviewSafetyFlag(ctx, open[0].ID, reviewer, "Reached out by email")
+	require.NoError(t, err)
+	assert.Equal(t, domain.SafetyFlagReviewed, flag.Status)
+	assert.Equal(t, &reviewer, flag.ReviewedBy)
+
+	_, err = guard.ReviewSafetyFlag(ctx, open[0].ID, reviewer, "")
+	assert.ErrorIs(t, err, domain.ErrSafetyFlagReviewed)
+	_, err = guard.ReviewSafetyFlag(ctx, uuid.New(), reviewer, "")
+	assert.ErrorIs(t, err, domain.ErrSafetyFlagNotFound)
+	open, _ = guard.ListSafetyFlags(ctx, domain.SafetyFlagOpen)
+	assert.Empty(t, open)
+}
+
+func TestCortexService_Chat_SwitchesToSafeResponseForRisk(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	flags := memory.NewSafetyFlagRepository()
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, NewSafetyGuard(flags))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID, DisciplineIndex: 20}, nil)
+	mockFastingRepo.On("FindActiveByUserID", ctx, userID).Return(nil, nil)
+	var systemPrompt string
+	mockLLM.On("GenerateResponse", ctx, "I fainted but I want to keep going", mock.Anything).
+		Run(func(args mock.Arguments) { systemPrompt = args.String(2) }).
+		Return("Please stop and eat something.", nil)
+
+	answer, err := service.Chat(ctx, userID, "I fainted but I want to keep going")
+	require.NoError(t, err)
+
+	assert.True(t, answer.SafetyMode)
+	assert.Equal(t, "Please stop and eat something.", answer.Text)
+	assert.Contains(t, systemPrompt, "may be at risk: fainting, dizziness or heart symptoms")
+	assert.NotContains(t, systemPrompt, "ruthless", "no tough love")
+	open, _ := flags.ListByStatus(ctx, domain.SafetyFlagOpen)
+	require.Len(t, open, 1)
+	assert.Equal(t, domain.SafetySourceChat, open[0].Source)
+}
+
+func TestCortexService_Chat_FlaggedUserGetsFallbackWhenLLMFails(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+	mockFastingRepo.On("FindActiveByUserID", ctx, userID).Return(nil, nil)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("", errors.New("timeout"))
+
+	answer, err := service.Chat(ctx, userID, "I threw up everything I ate")
+	require.NoError(t, err)
+	assert.Equal(t, domain.SafetyFallbackReply, answer.Text)
+	assert.True(t, answer.SafetyMode)
+
+	_, err = service.Chat(ctx, userID, "How long until ketosis?")
+	assert.Error(t, err, "other messages still surface the error")
+}
+
+func TestCortexService_Chat_ReplacesUnsafeReply(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	flags := memory.NewSafetyFlagRepository()
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, nil, nil, nil, NewSafetyGuard(flags))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+	mockFastingRepo.On("FindActiveByUserID", ctx, userID).Return(nil, nil)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("Weak. Push through the dizziness.", nil)
+
+	answer, err := service.Chat(ctx, userID, "I feel a bit off")
+	require.NoError(t, err)
+	assert.Equal(t, domain.SafetyFallbackReply, answer.Text)
+	assert.True(t, answer.SafetyMode)
+	open, _ := flags.ListByStatus(ctx, domain.SafetyFlagOpen)
+	require.Len(t, open, 1)
+	assert.Equal(t, domain.SafetyStageReply, open[0].Stage)
+	assert.True(t, strings.HasPrefix(open[0].Excerpt, "Weak."))
+}
+
+func TestCortexService_ScreensGeneratedInsights(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	flags := memory.NewSafetyFlagRepository()
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, memory.NewCortexResponseCache(10), nil, nil, NewSafetyGuard(flags))
+	ctx := context.Background()
+	userID := uuid.New()
+	user := &domain.User{ID: userID}
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
+	mockFastingRepo.On("FindByUserID", ctx, userID).Return([]domain.FastingSession{}, nil)
+	mockFastingRepo.On("FindActiveByUserID", ctx, userID).Return(nil, nil)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.MatchedBy(func(p string) bool { return strings.Contains(p, `"benefits"`) })).
+		Return(`{"insight":"You're in ketosis.","benefits":["Fat burning"],"motivation":"Push through the dizziness."}`, nil)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.MatchedBy(func(p string) bool { return strings.Contains(p, `"discipline_trend"`) })).
+		Return(`{"analysis":"Solid week.","improvement_area":"Eat 500 calories a day.","encouragement":"Keep going.","discipline_trend":"steady","next_week_fasts_estimate":4}`, nil)
+	mockLLM.On("GenerateResponse", ctx, mock.Anything, mock.Anything).Return("Skip water until dinner, then try laxatives.", nil)
+
+	for i := 0; i < 2; i++ {
+		insight, err := service.GetFastingMilestoneInsight(ctx, userID, 16.0)
+		require.NoError(t, err)
+		assert.Equal(t, fallbackMilestoneInsight(16.0, "16h - Peak Ketosis").Motivation, insight["motivation"])
+	}
+
+	_, err := service.AnalyzeWeek(ctx, userID, domain.WeekSummary{FastsCompleted: 3})
+	assert.ErrorIs(t, err, errUnsafeCortexReply, "the progress report falls back to its canned insight")
+
+	assert.Equal(t, "Extended fasts require careful refeeding. Start with broth, introduce solids gradually over 24-48 hours.",
+		service.generateBreakFastAIGuidance(ctx, user, 72))
+
+	for i := 0; i < 2; i++ {
+		quote, err := service.GenerateDailyQuote(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, "Every expert was once a beginner. Your first fast is your first victory.", quote)
+	}
+
+	// Unsafe answers aren't cached, so each request asks again and is flagged again
+	open, _ := flags.ListByStatus(ctx, domain.SafetyFlagOpen)
+	sources := map[domain.SafetySource]int{}
+	for _, flag := range open {
+		assert.Equal(t, domain.SafetyStageReply, flag.Stage)
+		sources[flag.Source]++
+	}
+	assert.Equal(t, map[domain.SafetySource]int{
+		domain.SafetySourceInsight:      2,
+		domain.SafetySourceWeeklyReport: 1,
+		domain.SafetySourceBreakFast:    1,
+		domain.SafetySourceDailyQuote:   2,
+	}, sources)
+}
+
+func TestCortexService_AtRiskUserGetsNoFastingCoaching(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	mockFastingRepo := new(MockFastingRepository)
+	mockUserRepo := new(MockUserRepository)
+	service := NewCortexService(mockLLM, mockFastingRepo, mockUserRepo, nil, nil, nil, memory.NewCortexResponseCache(10), nil, nil, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+	// 170 cm at 100 lbs is a BMI of about 15.7
+	user := &domain.User{ID: userID, HeightCm: 170, CurrentWeightLbs: 100}
+	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
+
+	insight, err := service.GetFastingMilestoneInsight(ctx, userID, 20)
+	require.NoError(t, err)
+	assert.Equal(t, domain.SafetyFallbackReply, insight["insight"])
+	assert.Equal(t, true, insight["safety_mode"])
+
+	quote, err := service.GenerateDailyQuote(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, safeDailyQuote, quote)
+
+	week, err := service.AnalyzeWeek(ctx, userID, domain.WeekSummary{FastsCompleted: 3})
+	require.NoError(t, err)
+	assert.True(t, week.SafetyMode)
+	assert.Equal(t, domain.SafetyFallbackReply, week.Analysis)
+
+	assert.Equal(t, domain.SafetyFallbackReply, service.generateBreakFastAIGuidance(ctx, user, 72))
+
+	_, err = service.GenerateFromPrompt(ctx, userID, promptStreakIntervention, domain.PromptVars{"streak": 5, "hours_left": 3, "discipline_index": 40.0})
+	assert.ErrorIs(t, err, errUserAtRisk)
+
+	// The streak monitor doesn't fall back to a nudge of its own
+	ended := time.Now().Add(-40 * time.Hour)
+	mockFastingRepo.On("FindByUserID", ctx, userID).Return([]domain.FastingSession{
+		{ID: uuid.New(), UserID: userID, Status: domain.StatusCompleted, StartTime: ended.Add(-16 * time.Hour), EndTime: &ended},
+	}, nil)
+	notifications := new(MockNotificationService)
+	monitor := NewStreakMonitor(mockUserRepo, mockFastingRepo, service, notifications)
+	risk, err := monitor.CheckStreakRisk(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, risk.SafetyMode)
+	assert.False(t, risk.IsAtRisk)
+	assert.Empty(t, risk.AIMessage)
+	require.NoError(t, monitor.TriggerProactiveAlert(ctx, userID))
+	notifications.AssertNotCalled(t, "SendNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	mockLLM.AssertNotCalled(t, "GenerateResponse", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCortexService_GetCravingHelp_SafeResponseWithoutLLM(t *testing.T) {
+	mockLLM := new(MockLLMProvider)
+	mockUserRepo := new(MockUserRepository)
+	service := NewCortexService(mockLLM, new(MockFastingRepository), mockUserRepo, nil, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+
+	help, err := service.GetCravingHelp(ctx, userID, "I want to binge and then purge")
+	require.NoError(t, err)
+
+	assert.Equal(t, safeCravingResponse(), help)
+	mockLLM.AssertNotCalled(t, "GenerateResponse", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSOSService_SendSOSFlare_AtRiskSkipsCoaching(t *testing.T) {
+	mockSOSRepo := new(MockSOSRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTribeService := new(MockTribeService)
+	mockCortexService := new(MockCortexService)
+	mockFastingRepo := new(MockFastingRepository)
+	flags := memory.NewSafetyFlagRepository()
+	service := NewSOSService(mockSOSRepo, mockUserRepo, mockTribeService, new(MockNotificationService), mockCortexService, mockFastingRepo, NewSafetyGuard(flags))
+	ctx := context.Background()
+	userID := uuid.New()
+	user := &domain.User{ID: userID}
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
+	mockUserRepo.On("Save", ctx, user).Return(nil)
+	mockSOSRepo.On("FindActiveByUserID", ctx, userID).Return(nil, errors.New("not found"))
+	mockFastingRepo.On("FindActiveByUserID", ctx, userID).Return(&domain.FastingSession{ID: uuid.New(), UserID: userID, StartTime: time.Now().Add(-30 * time.Hour)}, nil)
+	mockSOSRepo.On("Save", ctx, mock.AnythingOfType("*domain.SOSFlare")).Return(nil)
+	mockTribeService.On("GetMyTribes", ctx, userID.String()).Return([]domain.Tribe{}, nil)
+
+	_, aiResponse, err := service.SendSOSFlare(ctx, userID, "I feel like I'm going to pass out")
+	require.NoError(t, err)
+
+	assert.Equal(t, safeCravingResponse(), aiResponse)
+	mockCortexService.AssertNotCalled(t, "GetCravingHelp", mock.Anything, mock.Anything, mock.Anything)
+	open, _ := flags.ListByStatus(ctx, domain.SafetyFlagOpen)
+	require.Len(t, open, 1)
+	assert.Equal(t, domain.SafetySourceSOS, open[0].Source)
+}
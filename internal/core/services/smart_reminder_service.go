@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
 	"fmt"
@@ -284,9 +285,14 @@ func (s *SmartReminderService) generateOptimalWindowReasoning(ctx context.Contex
 		return fmt.Sprintf("Based on popular fasting patterns, we recommend starting at %d:00. As you complete more fasts, we'll personalize this to your schedule.", startHour)
 	}
 
-	prompt := fmt.Sprintf(`Provide a brief (1-2 sentences) personalized explanation for why %d:00 is their optimal fasting start time with a %d hour window. Their discipline score is %.1f. Be encouraging and specific.`, startHour, duration, user.DisciplineIndex)
-
-	response, err := s.cortexService.Chat(ctx, user.ID, prompt)
+	response, err := s.cortexService.GenerateFromPrompt(ctx, user.ID, promptOptimalWindow, domain.PromptVars{
+		"start_hour":       startHour,
+		"duration_hours":   duration,
+		"discipline_index": user.DisciplineIndex,
+	})
+	if errors.Is(err, errUserAtRisk) {
+		return domain.SafetyFallbackReply
+	}
 	if err != nil || response == "" {
 		return fmt.Sprintf("Based on your %d successful fasts, starting at %d:00 with a %d-hour window has worked best for you.", historyCount, startHour, duration)
 	}
@@ -93,14 +93,20 @@ type MockCortexServiceForReminder struct {
 	mock.Mock
 }
 
-func (m *MockCortexServiceForReminder) Chat(ctx context.Context, userID uuid.UUID, message string) (string, error) {
+func (m *MockCortexServiceForReminder) Chat(ctx context.Context, userID uuid.UUID, message string) (*domain.CortexAnswer, error) {
 	args := m.Called(ctx, userID, message)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CortexAnswer), args.Error(1)
 }
 
-func (m *MockCortexServiceForReminder) GenerateInsight(ctx context.Context, userID uuid.UUID, fastingHours float64) (string, error) {
+func (m *MockCortexServiceForReminder) GenerateInsight(ctx context.Context, userID uuid.UUID, fastingHours float64) (*domain.CortexAnswer, error) {
 	args := m.Called(ctx, userID, fastingHours)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CortexAnswer), args.Error(1)
 }
 
 func (m *MockCortexServiceForReminder) AnalyzeMeal(ctx context.Context, imageBase64, description string) (string, bool, bool, error) {
@@ -113,6 +119,19 @@ func (m *MockCortexServiceForReminder) GetCravingHelp(ctx context.Context, userI
 	return args.Get(0), args.Error(1)
 }
 
+func (m *MockCortexServiceForReminder) AnalyzeWeek(ctx context.Context, userID uuid.UUID, week domain.WeekSummary) (*domain.WeeklyInsight, error) {
+	args := m.Called(ctx, userID, week)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WeeklyInsight), args.Error(1)
+}
+
+func (m *MockCortexServiceForReminder) GenerateFromPrompt(ctx context.Context, userID uuid.UUID, prompt string, vars domain.PromptVars) (string, error) {
+	args := m.Called(ctx, userID, prompt, vars)
+	return args.String(0), args.Error(1)
+}
+
 // ============== TESTS ==============
 
 func TestNewSmartReminderService(t *testing.T) {
@@ -640,7 +659,7 @@ func TestSmartReminderService_AnalyzeOptimalFastingWindow_ExperiencedUser(t *tes
 
 	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
 	mockFastingRepo.On("FindByUserID", ctx, userID).Return(fastingHistory, nil)
-	mockCortexService.On("Chat", ctx, userID, mock.Anything).Return("Your 7 PM start time aligns perfectly with your body's natural hunger patterns!", nil)
+	mockCortexService.On("GenerateFromPrompt", ctx, userID, promptOptimalWindow, mock.Anything).Return("Your 7 PM start time aligns perfectly with your body's natural hunger patterns!", nil)
 
 	window, err := service.AnalyzeOptimalFastingWindow(ctx, userID)
 
@@ -650,6 +669,31 @@ func TestSmartReminderService_AnalyzeOptimalFastingWindow_ExperiencedUser(t *tes
 	assert.Contains(t, window.Reasoning, "7 PM")
 }
 
+func TestSmartReminderService_AnalyzeOptimalFastingWindow_UserAtRisk(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockFastingRepo := new(MockFastingRepository)
+	mockCortexService := new(MockCortexServiceForReminder)
+
+	service := NewSmartReminderService(new(MockReminderRepository), mockUserRepo, mockFastingRepo, new(MockNotificationServiceForReminder), mockCortexService)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	now := time.Now()
+	var history []domain.FastingSession
+	for day := 1; day <= 3; day++ {
+		start := time.Date(now.Year(), now.Month(), now.Day()-day, 19, 0, 0, 0, now.Location())
+		history = append(history, domain.FastingSession{ID: uuid.New(), UserID: userID, StartTime: start, EndTime: ptrTime(start.Add(16 * time.Hour))})
+	}
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+	mockFastingRepo.On("FindByUserID", ctx, userID).Return(history, nil)
+	mockCortexService.On("GenerateFromPrompt", ctx, userID, promptOptimalWindow, mock.Anything).Return("", errUserAtRisk)
+
+	window, err := service.AnalyzeOptimalFastingWindow(ctx, userID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SafetyFallbackReply, window.Reasoning)
+}
+
 func TestSmartReminderService_AnalyzeOptimalFastingWindow_UserNotFound(t *testing.T) {
 	mockReminderRepo := new(MockReminderRepository)
 	mockUserRepo := new(MockUserRepository)
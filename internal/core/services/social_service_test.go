@@ -64,6 +64,43 @@ func (m *MockSocialRepository) FindChallengesByUserID(ctx context.Context, userI
 	return args.Get(0).([]domain.FriendChallenge), args.Error(1)
 }
 
+func (m *MockSocialRepository) FindChallengeByID(ctx context.Context, id uuid.UUID) (*domain.FriendChallenge, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.FriendChallenge), args.Error(1)
+}
+
+func (m *MockSocialRepository) FindEndedChallenges(ctx context.Context, now time.Time) ([]*domain.FriendChallenge, error) {
+	args := m.Called(ctx, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.FriendChallenge), args.Error(1)
+}
+
+func (m *MockSocialRepository) SaveChallengeParticipant(ctx context.Context, p *domain.FriendChallengeParticipant) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+
+func (m *MockSocialRepository) FindChallengeParticipant(ctx context.Context, challengeID, userID uuid.UUID) (*domain.FriendChallengeParticipant, error) {
+	args := m.Called(ctx, challengeID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.FriendChallengeParticipant), args.Error(1)
+}
+
+func (m *MockSocialRepository) FindChallengeParticipants(ctx context.Context, challengeID uuid.UUID) ([]*domain.FriendChallengeParticipant, error) {
+	args := m.Called(ctx, challengeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.FriendChallengeParticipant), args.Error(1)
+}
+
 func (m *MockSocialRepository) SaveEvent(ctx context.Context, event *domain.SocialEvent) error {
 	args := m.Called(ctx, event)
 	return args.Error(0)
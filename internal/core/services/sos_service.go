@@ -19,6 +19,7 @@ type SOSService struct {
 	notificationSvc ports.NotificationService
 	cortexService   ports.CortexService
 	fastingRepo     ports.FastingRepository
+	safety          *SafetyGuard
 }
 
 func NewSOSService(
@@ -28,7 +29,11 @@ func NewSOSService(
 	notificationSvc ports.NotificationService,
 	cortexService ports.CortexService,
 	fastingRepo ports.FastingRepository,
+	safety *SafetyGuard, // nil screens flares but only logs flags
 ) *SOSService {
+	if safety == nil {
+		safety = NewSafetyGuard(nil)
+	}
 	return &SOSService{
 		sosRepo:         sosRepo,
 		userRepo:        userRepo,
@@ -36,6 +41,7 @@ func NewSOSService(
 		notificationSvc: notificationSvc,
 		cortexService:   cortexService,
 		fastingRepo:     fastingRepo,
+		safety:          safety,
 	}
 }
 
@@ -84,16 +90,21 @@ func (s *SOSService) SendSOSFlare(ctx context.Context, userID uuid.UUID, craving
 		return nil, nil, fmt.Errorf("failed to save SOS: %w", err)
 	}
 
-	// 5. Get AI Response (existing Cortex service)
-	aiResponse, err := s.cortexService.GetCravingHelp(ctx, userID, cravingDescription)
-	if err != nil {
+	// 5. Get AI Response (existing Cortex service). A flare showing risk signals gets the safe
+	// response instead of coaching, and the tribe isn't asked to hype the user through it.
+	user, _ := s.userRepo.FindByID(ctx, userID)
+	atRisk := s.safety.ScreenInput(ctx, user, domain.SafetySourceSOS, cravingDescription).Flagged()
+	var aiResponse interface{}
+	if atRisk {
+		aiResponse = safeCravingResponse()
+	} else if aiResponse, err = s.cortexService.GetCravingHelp(ctx, userID, cravingDescription); err != nil {
 		log.Printf("Cortex error for SOS %s: %v", sos.ID, err)
 		aiResponse = nil // Continue even if AI fails
 	}
 
 	// 6. Get user's tribes and broadcast
 	tribes, err := s.tribeService.GetMyTribes(ctx, userID.String())
-	if err == nil && len(tribes) > 0 && settings.NotifyTribeOnSOS {
+	if err == nil && len(tribes) > 0 && settings.NotifyTribeOnSOS && !atRisk {
 		// Fan out asynchronously
 		go s.broadcastToTribes(context.Background(), sos, tribes, userID, settings.AnonymousMode)
 	}
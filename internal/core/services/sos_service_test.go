@@ -193,14 +193,20 @@ type MockCortexService struct {
 	mock.Mock
 }
 
-func (m *MockCortexService) Chat(ctx context.Context, userID uuid.UUID, message string) (string, error) {
+func (m *MockCortexService) Chat(ctx context.Context, userID uuid.UUID, message string) (*domain.CortexAnswer, error) {
 	args := m.Called(ctx, userID, message)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CortexAnswer), args.Error(1)
 }
 
-func (m *MockCortexService) GenerateInsight(ctx context.Context, userID uuid.UUID, fastingHours float64) (string, error) {
+func (m *MockCortexService) GenerateInsight(ctx context.Context, userID uuid.UUID, fastingHours float64) (*domain.CortexAnswer, error) {
 	args := m.Called(ctx, userID, fastingHours)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CortexAnswer), args.Error(1)
 }
 
 func (m *MockCortexService) AnalyzeMeal(ctx context.Context, imageBase64, description string) (string, bool, bool, error) {
@@ -213,6 +219,19 @@ func (m *MockCortexService) GetCravingHelp(ctx context.Context, userID uuid.UUID
 	return args.Get(0), args.Error(1)
 }
 
+func (m *MockCortexService) AnalyzeWeek(ctx context.Context, userID uuid.UUID, week domain.WeekSummary) (*domain.WeeklyInsight, error) {
+	args := m.Called(ctx, userID, week)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WeeklyInsight), args.Error(1)
+}
+
+func (m *MockCortexService) GenerateFromPrompt(ctx context.Context, userID uuid.UUID, prompt string, vars domain.PromptVars) (string, error) {
+	args := m.Called(ctx, userID, prompt, vars)
+	return args.String(0), args.Error(1)
+}
+
 // ============== SEND SOS TESTS ==============
 
 func TestSOSService_SendSOSFlare_Success(t *testing.T) {
@@ -230,6 +249,7 @@ func TestSOSService_SendSOSFlare_Success(t *testing.T) {
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -281,6 +301,7 @@ func TestSOSService_SendSOSFlare_Cooldown(t *testing.T) {
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -324,6 +345,7 @@ func TestSOSService_SendSOSFlare_NoActiveFast(t *testing.T) {
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -360,6 +382,7 @@ func TestSOSService_SendHype_Success(t *testing.T) {
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -405,6 +428,7 @@ func TestSOSService_SendHype_SOSNotActive(t *testing.T) {
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -439,6 +463,7 @@ func TestSOSService_SendHype_SOSNotFound(t *testing.T) {
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -470,6 +495,7 @@ func TestSOSService_ResolveSOS_Survived(t *testing.T) {
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -519,6 +545,7 @@ func TestSOSService_ResolveSOS_Failed(t *testing.T) {
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -556,6 +583,7 @@ func TestSOSService_CheckAndSendCortexBackup_NoResponseAfter10Min(t *testing.T)
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -598,6 +626,7 @@ func TestSOSService_CheckAndSendCortexBackup_HasHypeResponses(t *testing.T) {
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -639,6 +668,7 @@ func TestSOSService_CheckAndSendCortexBackup_TooEarly(t *testing.T) {
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -676,6 +706,7 @@ func TestSOSService_CheckAndSendCortexBackup_AlreadyResponded(t *testing.T) {
 		mockNotificationService,
 		mockCortexService,
 		mockFastingRepo,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
 	"fmt"
@@ -36,8 +37,12 @@ type StreakRiskResponse struct {
 	AIMessage         string  `json:"ai_message"`
 	SuggestedAction   string  `json:"suggested_action"`
 	MotivationalFact  string  `json:"motivational_fact"`
+	SafetyMode        bool    `json:"safety_mode,omitempty"` // No streak pressure for a user at risk
 }
 
+// safeStreakAction is suggested instead of a streak intervention to a user at risk
+const safeStreakAction = "Your health matters more than any streak. Eat well and rest today."
+
 // CheckStreakRisk monitors if user's streak is at risk
 func (s *StreakMonitor) CheckStreakRisk(ctx context.Context, userID uuid.UUID) (*StreakRiskResponse, error) {
 	// 1. Get user
@@ -96,7 +101,15 @@ func (s *StreakMonitor) CheckStreakRisk(ctx context.Context, userID uuid.UUID) (
 
 	// 6. If at risk, generate AI intervention
 	if response.IsAtRisk {
-		aiMessage, suggestedAction, motivationalFact := s.generateStreakIntervention(ctx, user, currentStreak, hoursUntilLoss)
+		aiMessage, suggestedAction, motivationalFact, err := s.generateStreakIntervention(ctx, user, currentStreak, hoursUntilLoss)
+		if errors.Is(err, errUserAtRisk) {
+			// The user's health is at risk, not the streak: nothing is sent
+			response.IsAtRisk = false
+			response.UrgencyLevel = "none"
+			response.SuggestedAction = safeStreakAction
+			response.SafetyMode = true
+			return response, nil
+		}
 		response.AIMessage = aiMessage
 		response.SuggestedAction = suggestedAction
 		response.MotivationalFact = motivationalFact
@@ -105,25 +118,21 @@ func (s *StreakMonitor) CheckStreakRisk(ctx context.Context, userID uuid.UUID) (
 	return response, nil
 }
 
-// generateStreakIntervention creates personalized streak protection message
-func (s *StreakMonitor) generateStreakIntervention(ctx context.Context, user *domain.User, streak int, hoursLeft float64) (string, string, string) {
-	prompt := fmt.Sprintf(`URGENT: User's %d-day fasting streak is at risk! Only %.1f hours left.
-Discipline: %.1f/100
-
-Create emergency intervention:
-1. Urgent motivational message (20 words max)
-2. Immediate action they should take
-3. One powerful fact about why their streak matters
-
-Be direct, urgent, and motivating. This is critical.`, streak, hoursLeft, user.DisciplineIndex)
-
-	systemPrompt := "You are an emergency streak coach. Your job is to save this user's streak. Be urgent but supportive."
-
-	aiResponse, err := s.cortex.Chat(ctx, user.ID, systemPrompt+"\n\n"+prompt)
+// generateStreakIntervention creates personalized streak protection message. It returns
+// errUserAtRisk, and no message, for a user who shouldn't be pushed to fast.
+func (s *StreakMonitor) generateStreakIntervention(ctx context.Context, user *domain.User, streak int, hoursLeft float64) (string, string, string, error) {
+	aiResponse, err := s.cortex.GenerateFromPrompt(ctx, user.ID, promptStreakIntervention, domain.PromptVars{
+		"streak":           streak,
+		"hours_left":       hoursLeft,
+		"discipline_index": user.DisciplineIndex,
+	})
+	if errors.Is(err, errUserAtRisk) {
+		return "", "", "", err
+	}
 
 	// Fallback if AI fails
 	aiMessage := fmt.Sprintf("Your %d-day streak is in danger! Don't let %d days of discipline vanish.", streak, streak)
-	suggestedAction := "Start an emergency fast RIGHT NOW"
+	suggestedAction := "Start your next planned fast when you're ready"
 	motivationalFact := fmt.Sprintf("You've fasted %d times successfully. You've got this!", streak)
 
 	if err == nil && aiResponse != "" {
@@ -131,7 +140,7 @@ Be direct, urgent, and motivating. This is critical.`, streak, hoursLeft, user.D
 		aiMessage = aiResponse
 	}
 
-	return aiMessage, suggestedAction, motivationalFact
+	return aiMessage, suggestedAction, motivationalFact, nil
 }
 
 // TriggerProactiveAlert sends notification when streak is at risk
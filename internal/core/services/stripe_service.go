@@ -6,26 +6,65 @@ import (
 	"errors"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stripe/stripe-go/v74"
 )
 
+// stripePlanMetadataKey is the checkout session / subscription metadata key holding the plan tier
+const stripePlanMetadataKey = "plan"
+
+// stripePromoMetadataKey is the checkout session metadata key holding the redeemed promo code
+const stripePromoMetadataKey = "promo_code"
+
+// stripeMinTrialPeriod is how far ahead a subscription's trial end has to be for Stripe to accept it
+const stripeMinTrialPeriod = 48 * time.Hour
+
+var ErrNoBillingAccount = errors.New("no billing account for user")
+
+// BillingConfig is the price catalog and the URLs Stripe sends the user back to
+type BillingConfig struct {
+	Prices          domain.PriceCatalog
+	SuccessURL      string // May contain {CHECKOUT_SESSION_ID}, filled in by Stripe
+	CancelURL       string
+	PortalReturnURL string
+}
+
 type StripeService struct {
 	paymentGateway ports.PaymentGateway
 	subRepo        ports.SubscriptionRepository
 	userRepo       ports.UserRepository
+	eventRepo      ports.WebhookEventRepository
+	notifications  ports.NotificationService
+	promos         ports.PromoCodeService  // nil: promo codes are rejected
+	referrals      ports.ReferralService   // nil: paid invoices don't qualify referrals
+	payments       ports.PaymentRepository // nil: payments and refunds aren't recorded
+	vault          ports.VaultService      // nil: paid invoices aren't held as vault deposits
+	billing        BillingConfig
 }
 
-func NewStripeService(pg ports.PaymentGateway, subRepo ports.SubscriptionRepository, userRepo ports.UserRepository) *StripeService {
+func NewStripeService(pg ports.PaymentGateway, subRepo ports.SubscriptionRepository, userRepo ports.UserRepository, eventRepo ports.WebhookEventRepository, notifications ports.NotificationService, billing BillingConfig, promos ports.PromoCodeService, referrals ports.ReferralService, payments ports.PaymentRepository) *StripeService {
 	return &StripeService{
 		paymentGateway: pg,
 		subRepo:        subRepo,
 		userRepo:       userRepo,
+		eventRepo:      eventRepo,
+		notifications:  notifications,
+		promos:         promos,
+		referrals:      referrals,
+		payments:       payments,
+		billing:        billing,
 	}
 }
 
+// SetVaultService holds paid vault invoices as the month's deposit (called from main.go after service construction)
+func (s *StripeService) SetVaultService(vault ports.VaultService) {
+	s.vault = vault
+}
+
 func (s *StripeService) CreateCustomer(ctx context.Context, user *domain.User) (string, error) {
 	if user.StripeCustomerID != "" {
 		return user.StripeCustomerID, nil
@@ -44,52 +83,78 @@ func (s *StripeService) CreateCustomer(ctx context.Context, user *domain.User) (
 	return customerID, nil
 }
 
-func (s *StripeService) CreateSubscription(ctx context.Context, userID uuid.UUID, priceID string) (*domain.Subscription, error) {
+// ListPlans returns the plans that can be purchased
+func (s *StripeService) ListPlans() []domain.SubscriptionTier {
+	return s.billing.Prices.Plans()
+}
+
+// CreateCheckoutSession starts a hosted checkout for plan, discounted by promoCode if one is given.
+// Nothing is activated here; the subscription is created when checkout.session.completed arrives.
+func (s *StripeService) CreateCheckoutSession(ctx context.Context, userID uuid.UUID, plan domain.SubscriptionTier, promoCode string) (*domain.CheckoutSession, error) {
+	priceID, err := s.billing.Prices.PriceID(plan)
+	if err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+	if user.SubscriptionTier == plan && user.SubscriptionStatus.GrantsAccess() && !user.OnInAppTrial() {
+		// Plan changes for existing subscribers go through the billing portal
+		return nil, domain.ErrAlreadySubscribed
+	}
 
-	if user.StripeCustomerID == "" {
-		// Create customer if not exists
-		customerID, err := s.CreateCustomer(ctx, user)
-		if err != nil {
+	var promo *domain.PromoCode
+	if promoCode != "" {
+		if s.promos == nil {
+			return nil, domain.ErrPromoCodeNotFound
+		}
+		if promo, err = s.promos.CheckPromoCode(ctx, user.ID, promoCode, plan); err != nil {
 			return nil, err
 		}
-		user.StripeCustomerID = customerID
 	}
 
-	subID, err := s.paymentGateway.CreateSubscription(user.StripeCustomerID, priceID)
+	customerID, err := s.CreateCustomer(ctx, user)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create subscription record
-	sub := &domain.Subscription{
-		ID:                   uuid.New(),
-		UserID:               userID,
-		StripeSubscriptionID: subID,
-		Status:               domain.SubStatusActive, // Assuming active immediately for now
-		PlanType:             string(domain.TierVault),
-		CreatedAt:            time.Now(),
-		UpdatedAt:            time.Now(),
+	req := domain.CheckoutSessionRequest{
+		CustomerID:        customerID,
+		PriceID:           priceID,
+		ClientReferenceID: user.ID.String(),
+		Metadata:          map[string]string{stripePlanMetadataKey: string(plan), "user_id": user.ID.String()},
+		SuccessURL:        s.billing.SuccessURL,
+		CancelURL:         s.billing.CancelURL,
 	}
-
-	if err := s.subRepo.Save(ctx, sub); err != nil {
-		return nil, err
+	if user.OnInAppTrial() && user.TrialEndsAt.After(time.Now().Add(stripeMinTrialPeriod)) {
+		// Converting during a free trial: keep the remaining days free and bill when the trial ends
+		req.TrialEnd = user.TrialEndsAt
 	}
-
-	// Update user status
-	user.SubscriptionStatus = domain.SubStatusActive
-	user.SubscriptionTier = domain.TierVault
-	user.SubscriptionID = sub.ID.String()
-	if err := s.userRepo.Save(ctx, user); err != nil {
-		return nil, err
+	if promo != nil {
+		req.CouponID = promo.CouponID
+		req.Metadata[stripePromoMetadataKey] = promo.Code
 	}
+	return s.paymentGateway.CreateCheckoutSession(req)
+}
 
-	return sub, nil
+// CreatePortalSession returns a billing portal URL where the user can update their card or cancel
+func (s *StripeService) CreatePortalSession(ctx context.Context, userID uuid.UUID) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user.StripeCustomerID == "" {
+		return "", ErrNoBillingAccount
+	}
+	return s.paymentGateway.CreatePortalSession(user.StripeCustomerID, s.billing.PortalReturnURL)
 }
 
+// HandleWebhook verifies and processes a Stripe event. An event is recorded as processed only after
+// its handler succeeded, so a crash or timeout midway leaves it for Stripe's retry; every handler is
+// idempotent, so running part of an event again is safe. Retries of a processed event are
+// acknowledged without side effects.
 func (s *StripeService) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
 	eventInterface, err := s.paymentGateway.ConstructEvent(payload, signature)
 	if err != nil {
@@ -101,56 +166,383 @@ func (s *StripeService) HandleWebhook(ctx context.Context, payload []byte, signa
 		return errors.New("invalid event type")
 	}
 
+	processed, err := s.eventRepo.IsProcessed(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+	if processed {
+		logger.Info().Str("event_id", event.ID).Str("type", event.Type).Msg("Stripe event already processed")
+		return nil
+	}
+
+	if err := s.processEvent(ctx, event); err != nil {
+		return err
+	}
+	return s.eventRepo.MarkProcessed(ctx, &domain.WebhookEvent{ID: event.ID, Type: event.Type, ProcessedAt: time.Now()})
+}
+
+func (s *StripeService) processEvent(ctx context.Context, event stripe.Event) error {
 	switch event.Type {
-	case "customer.subscription.updated", "customer.subscription.deleted":
+	case "checkout.session.completed":
+		var session stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
+			return err
+		}
+		return s.handleCheckoutCompleted(ctx, &session)
+
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+		var stripeSub stripe.Subscription
+		if err := json.Unmarshal(event.Data.Raw, &stripeSub); err != nil {
+			return err
+		}
+		return s.handleSubscriptionChanged(ctx, &stripeSub)
+
+	case "customer.subscription.trial_will_end":
 		var stripeSub stripe.Subscription
-		err := json.Unmarshal(event.Data.Raw, &stripeSub)
-		if err != nil {
+		if err := json.Unmarshal(event.Data.Raw, &stripeSub); err != nil {
 			return err
 		}
+		return s.handleTrialWillEnd(ctx, &stripeSub)
 
-		sub, err := s.subRepo.FindByStripeSubscriptionID(ctx, stripeSub.ID)
-		if err != nil {
+	case "invoice.paid", "invoice.payment_failed":
+		var invoice stripe.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
 			return err
 		}
-		if sub == nil {
-			// Subscription not found, maybe log warning
+		return s.handleInvoice(ctx, event.Type, &invoice)
+
+	case "charge.refunded":
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			return err
+		}
+		return s.handleChargeRefunded(ctx, &charge)
+	}
+
+	return nil
+}
+
+// handleCheckoutCompleted creates the subscription record for a completed subscription checkout.
+// The checkout's client_reference_id is the user ID; the plan is in the session metadata.
+// The vault deposit is taken from the subscription's first paid invoice, see handleInvoice.
+func (s *StripeService) handleCheckoutCompleted(ctx context.Context, session *stripe.CheckoutSession) error {
+	if session.Mode != stripe.CheckoutSessionModeSubscription || session.Subscription == nil {
+		return nil
+	}
+
+	user, err := s.findCheckoutUser(ctx, session)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		logger.Warn().Str("session_id", session.ID).Msg("Checkout completed for unknown user")
+		return nil
+	}
+
+	sub, err := s.subRepo.FindByStripeSubscriptionID(ctx, session.Subscription.ID)
+	if err != nil {
+		return err
+	}
+	if sub == nil {
+		sub = &domain.Subscription{
+			ID:                   uuid.New(),
+			UserID:               user.ID,
+			StripeSubscriptionID: session.Subscription.ID,
+			PlanType:             string(domain.TierVault),
+			CreatedAt:            time.Now(),
+		}
+	}
+	if plan := domain.SubscriptionTier(session.Metadata[stripePlanMetadataKey]); plan.IsValid() && plan != domain.TierFree {
+		sub.PlanType = string(plan)
+	}
+
+	status := domain.SubStatusActive
+	switch session.PaymentStatus {
+	case stripe.CheckoutSessionPaymentStatusNoPaymentRequired:
+		status = domain.SubStatusTrialing
+	case stripe.CheckoutSessionPaymentStatusUnpaid:
+		status = domain.SubStatusIncomplete
+	}
+	if sub.Status == domain.SubStatusNone {
+		// A subscription event may already have moved the subscription further along
+		if err := sub.Transition(status); err != nil {
+			return err
+		}
+	}
+	sub.UpdatedAt = time.Now()
+
+	if session.Customer != nil && user.StripeCustomerID == "" {
+		user.StripeCustomerID = session.Customer.ID
+	}
+	if err := s.saveSubscription(ctx, sub, user); err != nil {
+		return err
+	}
+
+	if code := session.Metadata[stripePromoMetadataKey]; code != "" && s.promos != nil {
+		err := s.promos.RedeemPromoCode(ctx, &domain.PromoRedemption{
+			Code:              code,
+			UserID:            user.ID,
+			Plan:              sub.Tier(),
+			CheckoutSessionID: session.ID,
+			SubscriptionID:    session.Subscription.ID,
+		})
+		if errors.Is(err, domain.ErrPromoCodeNotFound) {
+			// Retrying won't make the code appear; the discount was applied regardless
+			logger.Warn().Str("code", code).Str("session_id", session.ID).Msg("Checkout redeemed an unknown promo code")
 			return nil
 		}
+		return err
+	}
+	return nil
+}
 
-		// Update status
-		sub.Status = domain.SubscriptionStatus(stripeSub.Status)
+// findCheckoutUser returns nil without an error when the session belongs to no user we know,
+// so the event is acknowledged instead of retried by Stripe
+func (s *StripeService) findCheckoutUser(ctx context.Context, session *stripe.CheckoutSession) (*domain.User, error) {
+	var user *domain.User
+	var err error
+	switch userID, parseErr := uuid.Parse(session.ClientReferenceID); {
+	case parseErr == nil:
+		user, err = s.userRepo.FindByID(ctx, userID)
+	case session.Customer != nil:
+		user, err = s.userRepo.FindByStripeCustomerID(ctx, session.Customer.ID)
+	default:
+		return nil, nil
+	}
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, nil
+	}
+	return user, err
+}
+
+// handleSubscriptionChanged mirrors Stripe's subscription status and period onto our record
+func (s *StripeService) handleSubscriptionChanged(ctx context.Context, stripeSub *stripe.Subscription) error {
+	sub, err := s.subRepo.FindByStripeSubscriptionID(ctx, stripeSub.ID)
+	if err != nil {
+		return err
+	}
+	if sub == nil {
+		// Not created by our checkout (yet); checkout.session.completed creates the record
+		logger.Warn().Str("subscription_id", stripeSub.ID).Msg("Stripe subscription not found")
+		return nil
+	}
+
+	if err := sub.Transition(domain.SubscriptionStatus(stripeSub.Status)); err != nil {
+		// Out-of-order delivery, e.g. an update arriving after the deletion. Retrying won't help.
+		logger.Warn().Err(err).Str("subscription_id", stripeSub.ID).Msg("Ignoring Stripe subscription status")
+		return nil
+	}
+	if plan := domain.SubscriptionTier(stripeSub.Metadata[stripePlanMetadataKey]); plan.IsValid() && plan != domain.TierFree {
+		sub.PlanType = string(plan)
+	}
+	if stripeSub.CurrentPeriodStart > 0 {
 		start := time.Unix(stripeSub.CurrentPeriodStart, 0)
 		end := time.Unix(stripeSub.CurrentPeriodEnd, 0)
 		sub.CurrentPeriodStart = &start
 		sub.CurrentPeriodEnd = &end
-		sub.CancelAtPeriodEnd = stripeSub.CancelAtPeriodEnd
-		sub.UpdatedAt = time.Now()
+	}
+	sub.CancelAtPeriodEnd = stripeSub.CancelAtPeriodEnd
 
-		if err := s.subRepo.Save(ctx, sub); err != nil {
+	user, err := s.userRepo.FindByID(ctx, sub.UserID)
+	if err != nil {
+		return err
+	}
+	return s.saveSubscription(ctx, sub, user)
+}
+
+// handleInvoice moves the subscription to active when an invoice is paid and to past due when payment
+// fails. A paid invoice with a non-zero amount is held as the month's vault deposit for vault members,
+// is added to the payment history and qualifies the user's referral. A $0 invoice, like the one that
+// starts a trial, changes nothing; the subscription events carry its status.
+func (s *StripeService) handleInvoice(ctx context.Context, eventType string, invoice *stripe.Invoice) error {
+	if invoice.Subscription == nil {
+		return nil
+	}
+	if eventType == "invoice.paid" && invoice.AmountPaid == 0 {
+		return nil
+	}
+	sub, err := s.subRepo.FindByStripeSubscriptionID(ctx, invoice.Subscription.ID)
+	if err != nil {
+		return err
+	}
+	if sub == nil {
+		logger.Warn().Str("subscription_id", invoice.Subscription.ID).Msg("Stripe subscription not found for invoice")
+		return nil
+	}
+
+	next := domain.SubStatusActive
+	if eventType == "invoice.payment_failed" {
+		next = domain.SubStatusPastDue
+	}
+	if err := sub.Transition(next); err != nil {
+		logger.Warn().Err(err).Str("invoice_id", invoice.ID).Msg("Ignoring Stripe invoice status")
+		return nil
+	}
+
+	user, err := s.userRepo.FindByID(ctx, sub.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.saveSubscription(ctx, sub, user); err != nil {
+		return err
+	}
+
+	paidAt := time.Unix(invoice.Created, 0).UTC()
+	if invoice.StatusTransitions != nil && invoice.StatusTransitions.PaidAt > 0 {
+		paidAt = time.Unix(invoice.StatusTransitions.PaidAt, 0).UTC()
+	}
+
+	if next == domain.SubStatusActive && invoice.AmountPaid > 0 && s.vault != nil && user.IsVaultMember() {
+		var chargeID string
+		if invoice.Charge != nil {
+			chargeID = invoice.Charge.ID
+		}
+		if err := s.vault.RecordDeposit(ctx, user, float64(invoice.AmountPaid)/100, chargeID, paidAt); err != nil {
+			return fmt.Errorf("failed to record vault deposit for invoice %s: %w", invoice.ID, err)
+		}
+		if err := s.userRepo.Save(ctx, user); err != nil {
 			return err
 		}
+	}
 
-		// Update user status
-		user, err := s.userRepo.FindByID(ctx, sub.UserID)
-		if err != nil {
+	if next == domain.SubStatusActive && invoice.AmountPaid > 0 {
+		if err := s.recordPayment(ctx, &domain.Payment{
+			UserID:      user.ID,
+			Kind:        domain.PaymentKindCharge,
+			Amount:      float64(invoice.AmountPaid) / 100,
+			Currency:    string(invoice.Currency),
+			ProviderID:  invoice.ID,
+			Reference:   invoice.Number,
+			Description: fmt.Sprintf("FastingHero %s subscription", sub.Tier()),
+			PaidAt:      paidAt,
+		}); err != nil {
 			return err
 		}
+	}
 
-		user.SubscriptionStatus = sub.Status
-		switch sub.Status {
-		case domain.SubStatusActive:
-			user.SubscriptionTier = domain.TierVault
-		case domain.SubStatusCanceled, domain.SubStatusUnpaid:
-			// Downgrade if needed, or keep as is until period end
-			// For now, if not active, maybe revert to free?
-			// user.SubscriptionTier = domain.TierFree
+	if next == domain.SubStatusActive && invoice.AmountPaid > 0 && s.referrals != nil {
+		// A referral problem shouldn't make Stripe retry the invoice event
+		if err := s.referrals.QualifyReferral(ctx, user.ID, domain.ReferralEventFirstPayment); err != nil {
+			logger.Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to qualify referral")
 		}
+	}
 
-		if err := s.userRepo.Save(ctx, user); err != nil {
+	if next == domain.SubStatusPastDue {
+		s.notify(ctx, user.ID, "Payment failed",
+			"We couldn't process your subscription payment. Update your card to keep your vault active.",
+			domain.NotificationTypePaymentFailed, map[string]string{"invoice_id": invoice.ID})
+	}
+	return nil
+}
+
+func (s *StripeService) handleTrialWillEnd(ctx context.Context, stripeSub *stripe.Subscription) error {
+	sub, err := s.subRepo.FindByStripeSubscriptionID(ctx, stripeSub.ID)
+	if err != nil {
+		return err
+	}
+	if sub == nil {
+		return nil
+	}
+
+	trialEnd := time.Unix(stripeSub.TrialEnd, 0).UTC()
+	s.notify(ctx, sub.UserID, "Your free trial ends soon",
+		fmt.Sprintf("Your trial ends on %s. Your vault subscription starts automatically.", trialEnd.Format("January 2")),
+		domain.NotificationTypeTrialEnding, map[string]string{"trial_ends_at": trialEnd.Format(time.RFC3339)})
+	return nil
+}
+
+// handleChargeRefunded records the refund and confirms it to the user. Subscription state
+// changes that follow a refund arrive as their own customer.subscription events.
+func (s *StripeService) handleChargeRefunded(ctx context.Context, charge *stripe.Charge) error {
+	if charge.Customer == nil {
+		return nil
+	}
+	user, err := s.userRepo.FindByStripeCustomerID(ctx, charge.Customer.ID)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return err
+	}
+	if user == nil {
+		logger.Warn().Str("charge_id", charge.ID).Msg("Refunded charge for unknown customer")
+		return nil
+	}
+
+	if err := s.recordRefunds(ctx, user.ID, charge); err != nil {
+		return err
+	}
+
+	amount := float64(charge.AmountRefunded) / 100
+	s.notify(ctx, user.ID, "Refund on its way",
+		fmt.Sprintf("$%.2f has been refunded to your card.", amount),
+		domain.NotificationTypeVaultRefund, map[string]string{"charge_id": charge.ID})
+	return nil
+}
+
+// recordRefunds adds the charge's refunds to the payment history. A charge refunded in parts
+// lists every refund so far, and each is recorded once.
+func (s *StripeService) recordRefunds(ctx context.Context, userID uuid.UUID, charge *stripe.Charge) error {
+	if charge.Refunds == nil || len(charge.Refunds.Data) == 0 {
+		return s.recordPayment(ctx, &domain.Payment{
+			UserID:      userID,
+			Kind:        domain.PaymentKindRefund,
+			Amount:      float64(charge.AmountRefunded) / 100,
+			Currency:    string(charge.Currency),
+			ProviderID:  charge.ID,
+			Description: "Refund to card",
+			PaidAt:      time.Now().UTC(),
+		})
+	}
+	for _, refund := range charge.Refunds.Data {
+		if err := s.recordPayment(ctx, &domain.Payment{
+			UserID:      userID,
+			Kind:        domain.PaymentKindRefund,
+			Amount:      float64(refund.Amount) / 100,
+			Currency:    string(refund.Currency),
+			ProviderID:  refund.ID,
+			Description: "Refund to card",
+			PaidAt:      time.Unix(refund.Created, 0).UTC(),
+		}); err != nil {
 			return err
 		}
 	}
+	return nil
+}
 
+// recordPayment adds a payment to the history; a payment already recorded is left alone
+func (s *StripeService) recordPayment(ctx context.Context, payment *domain.Payment) error {
+	if s.payments == nil || payment.Amount <= 0 {
+		return nil
+	}
+	payment.ID = uuid.New()
+	payment.CreatedAt = time.Now()
+	if payment.Currency == "" {
+		payment.Currency = "usd"
+	}
+	if _, err := s.payments.Record(ctx, payment); err != nil {
+		return fmt.Errorf("failed to record payment %s: %w", payment.ProviderID, err)
+	}
 	return nil
 }
+
+// saveSubscription persists the subscription and derives the user's tier from it
+func (s *StripeService) saveSubscription(ctx context.Context, sub *domain.Subscription, user *domain.User) error {
+	if err := s.subRepo.Save(ctx, sub); err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	if !user.ApplySubscription(sub) {
+		return nil
+	}
+	return s.userRepo.Save(ctx, user)
+}
+
+// notify sends a best-effort notification; a failed push never fails the webhook
+func (s *StripeService) notify(ctx context.Context, userID uuid.UUID, title, body string, notifType domain.NotificationType, data map[string]string) {
+	if s.notifications == nil {
+		return
+	}
+	if err := s.notifications.SendNotification(ctx, userID, title, body, notifType, data); err != nil {
+		logger.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to send billing notification")
+	}
+}
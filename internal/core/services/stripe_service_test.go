@@ -3,7 +3,9 @@ package services
 import (
 	"context"
 	"errors"
+	"fastinghero/internal/adapters/repository/memory"
 	"fastinghero/internal/core/domain"
+	"strconv"
 	"testing"
 	"time"
 
@@ -24,8 +26,16 @@ func (m *MockPaymentGatewayForStripe) CreateCustomer(email, name string) (string
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockPaymentGatewayForStripe) CreateSubscription(customerID, priceID string) (string, error) {
-	args := m.Called(customerID, priceID)
+func (m *MockPaymentGatewayForStripe) CreateCheckoutSession(req domain.CheckoutSessionRequest) (*domain.CheckoutSession, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CheckoutSession), args.Error(1)
+}
+
+func (m *MockPaymentGatewayForStripe) CreatePortalSession(customerID, returnURL string) (string, error) {
+	args := m.Called(customerID, returnURL)
 	return args.String(0), args.Error(1)
 }
 
@@ -39,6 +49,26 @@ func (m *MockPaymentGatewayForStripe) ConstructEvent(payload []byte, header stri
 	return args.Get(0), args.Error(1)
 }
 
+func (m *MockPaymentGatewayForStripe) CreateRefund(chargeID string, amount float64, idempotencyKey string) (string, float64, error) {
+	args := m.Called(chargeID, amount, idempotencyKey)
+	return args.String(0), args.Get(1).(float64), args.Error(2)
+}
+
+func (m *MockPaymentGatewayForStripe) ChargeCustomer(customerID string, amount float64, description, idempotencyKey string) (string, error) {
+	args := m.Called(customerID, amount, description, idempotencyKey)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGatewayForStripe) PaymentMethodFingerprint(paymentMethodID string) (string, error) {
+	args := m.Called(paymentMethodID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGatewayForStripe) CreateCoupon(req domain.CouponRequest) (string, error) {
+	args := m.Called(req)
+	return args.String(0), args.Error(1)
+}
+
 // --- Mock SubscriptionRepository ---
 
 type MockSubscriptionRepository struct {
@@ -66,6 +96,40 @@ func (m *MockSubscriptionRepository) FindByStripeSubscriptionID(ctx context.Cont
 	return args.Get(0).(*domain.Subscription), args.Error(1)
 }
 
+// --- Mock WebhookEventRepository ---
+
+type MockWebhookEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookEventRepository) IsProcessed(ctx context.Context, eventID string) (bool, error) {
+	args := m.Called(ctx, eventID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWebhookEventRepository) MarkProcessed(ctx context.Context, event *domain.WebhookEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+// unprocessedWebhookEvents returns an event log that treats every event as new
+func unprocessedWebhookEvents() *MockWebhookEventRepository {
+	m := new(MockWebhookEventRepository)
+	m.On("IsProcessed", mock.Anything, mock.Anything).Return(false, nil)
+	m.On("MarkProcessed", mock.Anything, mock.Anything).Return(nil)
+	return m
+}
+
+var testBillingConfig = BillingConfig{
+	Prices: domain.PriceCatalog{
+		domain.TierVault:              "price_vault",
+		domain.TierAccountabilityPlus: "price_plus",
+	},
+	SuccessURL:      "https://app.test/onboarding/success?session_id={CHECKOUT_SESSION_ID}",
+	CancelURL:       "https://app.test/onboarding/payment",
+	PortalReturnURL: "https://app.test/settings",
+}
+
 // --- Tests for StripeService ---
 
 func TestNewStripeService(t *testing.T) {
@@ -73,7 +137,7 @@ func TestNewStripeService(t *testing.T) {
 	subRepo := new(MockSubscriptionRepository)
 	userRepo := new(MockUserRepository)
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 	assert.NotNil(t, svc)
 }
 
@@ -82,7 +146,7 @@ func TestStripeService_CreateCustomer_ExistingCustomer(t *testing.T) {
 	subRepo := new(MockSubscriptionRepository)
 	userRepo := new(MockUserRepository)
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 
 	user := &domain.User{
 		ID:               uuid.New(),
@@ -101,7 +165,7 @@ func TestStripeService_CreateCustomer_NewCustomer(t *testing.T) {
 	subRepo := new(MockSubscriptionRepository)
 	userRepo := new(MockUserRepository)
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 	ctx := context.Background()
 
 	user := &domain.User{
@@ -126,7 +190,7 @@ func TestStripeService_CreateCustomer_PaymentGatewayError(t *testing.T) {
 	subRepo := new(MockSubscriptionRepository)
 	userRepo := new(MockUserRepository)
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 	ctx := context.Background()
 
 	user := &domain.User{
@@ -143,7 +207,7 @@ func TestStripeService_CreateCustomer_PaymentGatewayError(t *testing.T) {
 	pg.AssertExpectations(t)
 }
 
-func TestStripeService_CreateSubscription_Success(t *testing.T) {
+func TestStripeService_CreateCheckoutSession_Success(t *testing.T) {
 	pg := new(MockPaymentGatewayForStripe)
 	subRepo := new(MockSubscriptionRepository)
 	userRepo := new(MockUserRepository)
@@ -155,40 +219,47 @@ func TestStripeService_CreateSubscription_Success(t *testing.T) {
 		Email:            "test@example.com",
 		Name:             "Test User",
 		StripeCustomerID: "cus_existing",
+		SubscriptionTier: domain.TierFree,
 	}
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 
 	userRepo.On("FindByID", ctx, userID).Return(user, nil)
-	pg.On("CreateSubscription", "cus_existing", "price_test").Return("sub_new123", nil)
-	subRepo.On("Save", ctx, mock.AnythingOfType("*domain.Subscription")).Return(nil)
-	userRepo.On("Save", ctx, mock.AnythingOfType("*domain.User")).Return(nil)
-
-	sub, err := svc.CreateSubscription(ctx, userID, "price_test")
+	pg.On("CreateCheckoutSession", domain.CheckoutSessionRequest{
+		CustomerID:        "cus_existing",
+		PriceID:           "price_plus",
+		ClientReferenceID: userID.String(),
+		Metadata:          map[string]string{"plan": "accountability_plus", "user_id": userID.String()},
+		SuccessURL:        testBillingConfig.SuccessURL,
+		CancelURL:         testBillingConfig.CancelURL,
+	}).Return(&domain.CheckoutSession{ID: "cs_123", URL: "https://checkout.stripe.com/cs_123"}, nil)
+
+	session, err := svc.CreateCheckoutSession(ctx, userID, domain.TierAccountabilityPlus, "")
 	assert.NoError(t, err)
-	assert.NotNil(t, sub)
-	assert.Equal(t, "sub_new123", sub.StripeSubscriptionID)
-	userRepo.AssertExpectations(t)
+	assert.Equal(t, "cs_123", session.ID)
+	// Nothing is activated until the webhook confirms payment
+	assert.Equal(t, domain.TierFree, user.SubscriptionTier)
+	subRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	userRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
 	pg.AssertExpectations(t)
-	subRepo.AssertExpectations(t)
 }
 
-func TestStripeService_CreateSubscription_UserNotFound(t *testing.T) {
+func TestStripeService_CreateCheckoutSession_UserNotFound(t *testing.T) {
 	pg := new(MockPaymentGatewayForStripe)
 	subRepo := new(MockSubscriptionRepository)
 	userRepo := new(MockUserRepository)
 	ctx := context.Background()
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 
 	userRepo.On("FindByID", ctx, mock.AnythingOfType("uuid.UUID")).Return(nil, errors.New("user not found"))
 
-	_, err := svc.CreateSubscription(ctx, uuid.New(), "price_test")
+	_, err := svc.CreateCheckoutSession(ctx, uuid.New(), domain.TierVault, "")
 	assert.Error(t, err)
 	userRepo.AssertExpectations(t)
 }
 
-func TestStripeService_CreateSubscription_CreatesCustomerIfMissing(t *testing.T) {
+func TestStripeService_CreateCheckoutSession_CreatesCustomerIfMissing(t *testing.T) {
 	pg := new(MockPaymentGatewayForStripe)
 	subRepo := new(MockSubscriptionRepository)
 	userRepo := new(MockUserRepository)
@@ -202,27 +273,88 @@ func TestStripeService_CreateSubscription_CreatesCustomerIfMissing(t *testing.T)
 		StripeCustomerID: "", // No existing customer
 	}
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 
 	userRepo.On("FindByID", ctx, userID).Return(user, nil)
 	pg.On("CreateCustomer", user.Email, user.Name).Return("cus_new123", nil)
 	userRepo.On("Save", ctx, mock.AnythingOfType("*domain.User")).Return(nil)
-	pg.On("CreateSubscription", "cus_new123", "price_test").Return("sub_new123", nil)
-	subRepo.On("Save", ctx, mock.AnythingOfType("*domain.Subscription")).Return(nil)
+	pg.On("CreateCheckoutSession", mock.MatchedBy(func(req domain.CheckoutSessionRequest) bool {
+		return req.CustomerID == "cus_new123" && req.PriceID == "price_vault"
+	})).Return(&domain.CheckoutSession{ID: "cs_123"}, nil)
 
-	sub, err := svc.CreateSubscription(ctx, userID, "price_test")
+	session, err := svc.CreateCheckoutSession(ctx, userID, domain.TierVault, "")
 	assert.NoError(t, err)
-	assert.NotNil(t, sub)
+	assert.NotNil(t, session)
 	pg.AssertExpectations(t)
 }
 
+func TestStripeService_CreateCheckoutSession_PlanNotAvailable(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
+
+	_, err := svc.CreateCheckoutSession(ctx, uuid.New(), domain.TierAICoach, "") // No price configured
+	assert.ErrorIs(t, err, domain.ErrPlanNotAvailable)
+
+	_, err = svc.CreateCheckoutSession(ctx, uuid.New(), domain.TierFree, "")
+	assert.ErrorIs(t, err, domain.ErrPlanNotAvailable)
+	userRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+}
+
+func TestStripeService_CreateCheckoutSession_AlreadySubscribed(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	ctx := context.Background()
+
+	userID := uuid.New()
+	user := &domain.User{ID: userID, SubscriptionTier: domain.TierVault, SubscriptionStatus: domain.SubStatusActive}
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
+
+	userRepo.On("FindByID", ctx, userID).Return(user, nil)
+
+	_, err := svc.CreateCheckoutSession(ctx, userID, domain.TierVault, "")
+	assert.ErrorIs(t, err, domain.ErrAlreadySubscribed)
+	pg.AssertNotCalled(t, "CreateCheckoutSession", mock.Anything)
+}
+
+func TestStripeService_CreatePortalSession(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
+
+	customer := &domain.User{ID: uuid.New(), StripeCustomerID: "cus_123"}
+	noCustomer := &domain.User{ID: uuid.New()}
+	userRepo.On("FindByID", ctx, customer.ID).Return(customer, nil)
+	userRepo.On("FindByID", ctx, noCustomer.ID).Return(noCustomer, nil)
+	pg.On("CreatePortalSession", "cus_123", testBillingConfig.PortalReturnURL).Return("https://billing.stripe.com/p/session", nil)
+
+	url, err := svc.CreatePortalSession(ctx, customer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://billing.stripe.com/p/session", url)
+
+	_, err = svc.CreatePortalSession(ctx, noCustomer.ID)
+	assert.ErrorIs(t, err, ErrNoBillingAccount)
+}
+
+func TestPriceCatalog_Plans(t *testing.T) {
+	assert.Equal(t, []domain.SubscriptionTier{domain.TierVault, domain.TierAccountabilityPlus}, testBillingConfig.Prices.Plans())
+}
+
 func TestStripeService_HandleWebhook_ConstructEventError(t *testing.T) {
 	pg := new(MockPaymentGatewayForStripe)
 	subRepo := new(MockSubscriptionRepository)
 	userRepo := new(MockUserRepository)
 	ctx := context.Background()
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 
 	pg.On("ConstructEvent", []byte("{}"), "bad_sig").Return(nil, errors.New("invalid signature"))
 
@@ -237,7 +369,7 @@ func TestStripeService_HandleWebhook_InvalidEventType(t *testing.T) {
 	userRepo := new(MockUserRepository)
 	ctx := context.Background()
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 
 	pg.On("ConstructEvent", []byte("{}"), "sig").Return("not an event", nil) // Return wrong type
 
@@ -253,7 +385,7 @@ func TestStripeService_HandleWebhook_SubscriptionNotFound(t *testing.T) {
 	userRepo := new(MockUserRepository)
 	ctx := context.Background()
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 
 	event := stripe.Event{
 		Type: "customer.subscription.updated",
@@ -281,7 +413,7 @@ func TestStripeService_HandleWebhook_SubscriptionUpdate(t *testing.T) {
 	userID := uuid.New()
 	subID := uuid.New()
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 
 	event := stripe.Event{
 		Type: "customer.subscription.updated",
@@ -324,7 +456,7 @@ func TestStripeService_HandleWebhook_SubscriptionDeleted(t *testing.T) {
 	userID := uuid.New()
 	subID := uuid.New()
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 
 	event := stripe.Event{
 		Type: "customer.subscription.deleted",
@@ -356,6 +488,8 @@ func TestStripeService_HandleWebhook_SubscriptionDeleted(t *testing.T) {
 
 	err := svc.HandleWebhook(ctx, []byte("{}"), "sig")
 	assert.NoError(t, err)
+	assert.Equal(t, domain.TierFree, user.SubscriptionTier)
+	assert.Equal(t, domain.SubStatusCanceled, user.SubscriptionStatus)
 	pg.AssertExpectations(t)
 	subRepo.AssertExpectations(t)
 	userRepo.AssertExpectations(t)
@@ -367,7 +501,7 @@ func TestStripeService_HandleWebhook_FindByStripeSubError(t *testing.T) {
 	userRepo := new(MockUserRepository)
 	ctx := context.Background()
 
-	svc := NewStripeService(pg, subRepo, userRepo)
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
 
 	event := stripe.Event{
 		Type: "customer.subscription.updated",
@@ -384,3 +518,404 @@ func TestStripeService_HandleWebhook_FindByStripeSubError(t *testing.T) {
 	pg.AssertExpectations(t)
 	subRepo.AssertExpectations(t)
 }
+
+func stripeEvent(id, eventType, raw string) stripe.Event {
+	return stripe.Event{ID: id, Type: eventType, Data: &stripe.EventData{Raw: []byte(raw)}}
+}
+
+func TestSubscriptionStateMachine(t *testing.T) {
+	tests := []struct {
+		from, to domain.SubscriptionStatus
+		allowed  bool
+		tier     domain.SubscriptionTier // Tier a vault subscriber ends up on
+	}{
+		{domain.SubStatusNone, domain.SubStatusActive, true, domain.TierVault},
+		{domain.SubStatusNone, domain.SubStatusTrialing, true, domain.TierVault},
+		{domain.SubStatusNone, domain.SubStatusIncomplete, true, domain.TierFree},
+		{domain.SubStatusIncomplete, domain.SubStatusActive, true, domain.TierVault},
+		{domain.SubStatusIncomplete, domain.SubStatusIncompleteExpired, true, domain.TierFree},
+		{domain.SubStatusTrialing, domain.SubStatusActive, true, domain.TierVault},
+		{domain.SubStatusTrialing, domain.SubStatusCanceled, true, domain.TierFree},
+		{domain.SubStatusActive, domain.SubStatusActive, true, domain.TierVault},
+		{domain.SubStatusActive, domain.SubStatusPastDue, true, domain.TierVault},
+		{domain.SubStatusActive, domain.SubStatusCanceled, true, domain.TierFree},
+		{domain.SubStatusActive, domain.SubStatusPaused, true, domain.TierFree},
+		{domain.SubStatusPastDue, domain.SubStatusActive, true, domain.TierVault},
+		{domain.SubStatusPastDue, domain.SubStatusUnpaid, true, domain.TierFree},
+		{domain.SubStatusUnpaid, domain.SubStatusActive, true, domain.TierVault},
+		{domain.SubStatusPaused, domain.SubStatusActive, true, domain.TierVault},
+		{domain.SubStatusCanceled, domain.SubStatusActive, false, ""},
+		{domain.SubStatusIncompleteExpired, domain.SubStatusActive, false, ""},
+		{domain.SubStatusActive, domain.SubStatusTrialing, false, ""},
+		{domain.SubStatusNone, domain.SubStatusPastDue, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			sub := &domain.Subscription{ID: uuid.New(), PlanType: string(domain.TierVault), Status: tt.from}
+			err := sub.Transition(tt.to)
+			if !tt.allowed {
+				assert.ErrorIs(t, err, domain.ErrInvalidSubscriptionTransition)
+				assert.Equal(t, tt.from, sub.Status)
+				return
+			}
+			assert.NoError(t, err)
+
+			user := &domain.User{ID: uuid.New(), SubscriptionID: sub.ID.String()}
+			assert.True(t, user.ApplySubscription(sub))
+			assert.Equal(t, tt.to, user.SubscriptionStatus)
+			assert.Equal(t, tt.tier, user.SubscriptionTier)
+		})
+	}
+}
+
+func TestStripeService_HandleWebhook_DuplicateEventIsNoOp(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	events := new(MockWebhookEventRepository)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, events, nil, testBillingConfig, nil, nil, nil)
+
+	event := stripeEvent("evt_1", "customer.subscription.deleted", `{"id":"sub_test123","status":"canceled"}`)
+	pg.On("ConstructEvent", []byte("{}"), "sig").Return(event, nil)
+	events.On("IsProcessed", ctx, "evt_1").Return(true, nil)
+
+	err := svc.HandleWebhook(ctx, []byte("{}"), "sig")
+
+	assert.NoError(t, err)
+	subRepo.AssertNotCalled(t, "FindByStripeSubscriptionID", mock.Anything, mock.Anything)
+	userRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	events.AssertNotCalled(t, "MarkProcessed", mock.Anything, mock.Anything)
+}
+
+func TestStripeService_HandleWebhook_MarksEventProcessedAfterSuccess(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	events := new(MockWebhookEventRepository)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, events, nil, testBillingConfig, nil, nil, nil)
+
+	sub := &domain.Subscription{ID: uuid.New(), UserID: uuid.New(), StripeSubscriptionID: "sub_test123", Status: domain.SubStatusActive}
+	event := stripeEvent("evt_3", "customer.subscription.updated", `{"id":"sub_test123","status":"past_due"}`)
+	pg.On("ConstructEvent", []byte("{}"), "sig").Return(event, nil)
+	events.On("IsProcessed", ctx, "evt_3").Return(false, nil)
+	subRepo.On("FindByStripeSubscriptionID", ctx, "sub_test123").Return(sub, nil)
+	userRepo.On("FindByID", ctx, sub.UserID).Return(nil, nil)
+	var savedStatus domain.SubscriptionStatus
+	subRepo.On("Save", ctx, sub).Run(func(mock.Arguments) { savedStatus = sub.Status }).Return(nil)
+	events.On("MarkProcessed", ctx, mock.MatchedBy(func(e *domain.WebhookEvent) bool {
+		// Recorded only once the subscription change is saved
+		return e.ID == "evt_3" && savedStatus == domain.SubStatusPastDue
+	})).Return(nil)
+
+	err := svc.HandleWebhook(ctx, []byte("{}"), "sig")
+
+	assert.NoError(t, err)
+	events.AssertExpectations(t)
+}
+
+func TestStripeService_HandleWebhook_FailureLeavesEventForRetry(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	events := new(MockWebhookEventRepository)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, events, nil, testBillingConfig, nil, nil, nil)
+
+	event := stripeEvent("evt_2", "customer.subscription.updated", `{"id":"sub_test123","status":"active"}`)
+	pg.On("ConstructEvent", []byte("{}"), "sig").Return(event, nil)
+	events.On("IsProcessed", ctx, "evt_2").Return(false, nil)
+	subRepo.On("FindByStripeSubscriptionID", ctx, "sub_test123").Return(nil, errors.New("database error"))
+
+	err := svc.HandleWebhook(ctx, []byte("{}"), "sig")
+
+	assert.Error(t, err)
+	events.AssertNotCalled(t, "MarkProcessed", mock.Anything, mock.Anything)
+}
+
+func TestStripeService_HandleWebhook_CheckoutCompleted(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
+
+	userID := uuid.New()
+	user := &domain.User{ID: userID, Email: "test@example.com", SubscriptionTier: domain.TierFree}
+
+	event := stripeEvent("evt_3", "checkout.session.completed", `{
+		"id": "cs_test", "mode": "subscription", "payment_status": "paid",
+		"client_reference_id": "`+userID.String()+`", "customer": "cus_123", "subscription": "sub_new",
+		"metadata": {"plan": "accountability_plus"}}`)
+	pg.On("ConstructEvent", []byte("{}"), "sig").Return(event, nil)
+	userRepo.On("FindByID", ctx, userID).Return(user, nil)
+	subRepo.On("FindByStripeSubscriptionID", ctx, "sub_new").Return(nil, nil)
+	subRepo.On("Save", ctx, mock.MatchedBy(func(sub *domain.Subscription) bool {
+		return sub.StripeSubscriptionID == "sub_new" && sub.Status == domain.SubStatusActive && sub.PlanType == "accountability_plus"
+	})).Return(nil)
+	userRepo.On("Save", ctx, user).Return(nil)
+
+	err := svc.HandleWebhook(ctx, []byte("{}"), "sig")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TierAccountabilityPlus, user.SubscriptionTier)
+	assert.Equal(t, domain.SubStatusActive, user.SubscriptionStatus)
+	assert.Equal(t, "cus_123", user.StripeCustomerID)
+	subRepo.AssertExpectations(t)
+}
+
+func TestStripeService_HandleWebhook_CheckoutCompletedForUnknownUser(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	events := new(MockWebhookEventRepository)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, events, nil, testBillingConfig, nil, nil, nil)
+
+	userID := uuid.New()
+	event := stripeEvent("evt_9", "checkout.session.completed", `{
+		"id": "cs_test", "mode": "subscription", "payment_status": "paid",
+		"client_reference_id": "`+userID.String()+`", "customer": "cus_123", "subscription": "sub_new"}`)
+	pg.On("ConstructEvent", []byte("{}"), "sig").Return(event, nil)
+	events.On("IsProcessed", ctx, "evt_9").Return(false, nil)
+	userRepo.On("FindByID", ctx, userID).Return(nil, domain.ErrUserNotFound)
+	events.On("MarkProcessed", ctx, mock.MatchedBy(func(e *domain.WebhookEvent) bool { return e.ID == "evt_9" })).Return(nil)
+
+	err := svc.HandleWebhook(ctx, []byte("{}"), "sig")
+
+	// Acknowledged: retrying won't make the user appear
+	assert.NoError(t, err)
+	subRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	events.AssertExpectations(t)
+}
+
+func TestStripeService_HandleWebhook_InvoicePaymentFailedThenPaid(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	notifications := new(MockNotificationService)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), notifications, testBillingConfig, nil, nil, nil)
+
+	userID := uuid.New()
+	sub := &domain.Subscription{ID: uuid.New(), UserID: userID, StripeSubscriptionID: "sub_test123", PlanType: "vault", Status: domain.SubStatusActive}
+	user := &domain.User{ID: userID, SubscriptionID: sub.ID.String(), SubscriptionTier: domain.TierVault, SubscriptionStatus: domain.SubStatusActive}
+
+	failed := stripeEvent("evt_4", "invoice.payment_failed", `{"id":"in_1","subscription":"sub_test123"}`)
+	paid := stripeEvent("evt_5", "invoice.paid", `{"id":"in_1","subscription":"sub_test123","amount_paid":999}`)
+	pg.On("ConstructEvent", []byte("failed"), "sig").Return(failed, nil)
+	pg.On("ConstructEvent", []byte("paid"), "sig").Return(paid, nil)
+	subRepo.On("FindByStripeSubscriptionID", ctx, "sub_test123").Return(sub, nil)
+	subRepo.On("Save", ctx, sub).Return(nil)
+	userRepo.On("FindByID", ctx, userID).Return(user, nil)
+	userRepo.On("Save", ctx, user).Return(nil)
+	notifications.On("SendNotification", ctx, userID, "Payment failed", mock.Anything, domain.NotificationTypePaymentFailed, mock.Anything).Return(nil)
+
+	assert.NoError(t, svc.HandleWebhook(ctx, []byte("failed"), "sig"))
+	assert.Equal(t, domain.SubStatusPastDue, user.SubscriptionStatus)
+	assert.Equal(t, domain.TierVault, user.SubscriptionTier) // Grace period keeps the plan
+
+	assert.NoError(t, svc.HandleWebhook(ctx, []byte("paid"), "sig"))
+	assert.Equal(t, domain.SubStatusActive, user.SubscriptionStatus)
+	notifications.AssertNumberOfCalls(t, "SendNotification", 1)
+}
+
+func TestStripeService_HandleWebhook_TrialInvoiceKeepsTrialing(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
+
+	userID := uuid.New()
+	sub := &domain.Subscription{ID: uuid.New(), UserID: userID, StripeSubscriptionID: "sub_test123", PlanType: "vault", Status: domain.SubStatusTrialing}
+	user := &domain.User{ID: userID, SubscriptionID: sub.ID.String(), SubscriptionTier: domain.TierVault, SubscriptionStatus: domain.SubStatusTrialing}
+
+	trialInvoice := stripeEvent("evt_10", "invoice.paid", `{"id":"in_0","subscription":"sub_test123","amount_paid":0}`)
+	updated := stripeEvent("evt_11", "customer.subscription.updated", `{"id":"sub_test123","status":"trialing"}`)
+	pg.On("ConstructEvent", []byte("trial"), "sig").Return(trialInvoice, nil)
+	pg.On("ConstructEvent", []byte("updated"), "sig").Return(updated, nil)
+	subRepo.On("FindByStripeSubscriptionID", ctx, "sub_test123").Return(sub, nil)
+	subRepo.On("Save", ctx, sub).Return(nil)
+	userRepo.On("FindByID", ctx, userID).Return(user, nil)
+	userRepo.On("Save", ctx, user).Return(nil)
+
+	assert.NoError(t, svc.HandleWebhook(ctx, []byte("trial"), "sig"))
+	assert.Equal(t, domain.SubStatusTrialing, sub.Status)
+	subRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+
+	assert.NoError(t, svc.HandleWebhook(ctx, []byte("updated"), "sig"))
+	assert.Equal(t, domain.SubStatusTrialing, sub.Status)
+	subRepo.AssertCalled(t, "Save", ctx, sub)
+}
+
+func TestStripeService_HandleWebhook_PaidInvoiceQualifiesReferral(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	referrals := new(MockReferralService)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, referrals, nil)
+
+	userID := uuid.New()
+	sub := &domain.Subscription{ID: uuid.New(), UserID: userID, StripeSubscriptionID: "sub_test123", PlanType: "vault", Status: domain.SubStatusTrialing}
+	user := &domain.User{ID: userID, SubscriptionID: sub.ID.String(), SubscriptionTier: domain.TierVault, SubscriptionStatus: domain.SubStatusTrialing}
+
+	trialInvoice := stripeEvent("evt_7", "invoice.paid", `{"id":"in_0","subscription":"sub_test123","amount_paid":0}`)
+	paidInvoice := stripeEvent("evt_8", "invoice.paid", `{"id":"in_1","subscription":"sub_test123","amount_paid":999}`)
+	pg.On("ConstructEvent", []byte("trial"), "sig").Return(trialInvoice, nil)
+	pg.On("ConstructEvent", []byte("paid"), "sig").Return(paidInvoice, nil)
+	subRepo.On("FindByStripeSubscriptionID", ctx, "sub_test123").Return(sub, nil)
+	subRepo.On("Save", ctx, sub).Return(nil)
+	userRepo.On("FindByID", ctx, userID).Return(user, nil)
+	userRepo.On("Save", ctx, user).Return(nil)
+	referrals.On("QualifyReferral", ctx, userID, domain.ReferralEventFirstPayment).Return(nil)
+
+	assert.NoError(t, svc.HandleWebhook(ctx, []byte("trial"), "sig"))
+	referrals.AssertNotCalled(t, "QualifyReferral", mock.Anything, mock.Anything, mock.Anything)
+
+	assert.NoError(t, svc.HandleWebhook(ctx, []byte("paid"), "sig"))
+	referrals.AssertExpectations(t)
+}
+
+func TestStripeService_HandleWebhook_PaidInvoiceHoldsVaultDeposit(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	ledgerRepo := memory.NewVaultLedgerRepository()
+	vault := NewVaultService(userRepo, memory.NewVaultRepository(), ledgerRepo, nil, nil)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
+	svc.SetVaultService(vault)
+
+	userID := uuid.New()
+	sub := &domain.Subscription{ID: uuid.New(), UserID: userID, StripeSubscriptionID: "sub_test123", PlanType: "vault", Status: domain.SubStatusActive}
+	user := &domain.User{ID: userID, SubscriptionID: sub.ID.String(), SubscriptionTier: domain.TierVault, SubscriptionStatus: domain.SubStatusActive}
+
+	paidAt := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	first := stripeEvent("evt_9", "invoice.paid", `{"id":"in_1","subscription":"sub_test123","amount_paid":499,"charge":"ch_1",
+		"status_transitions":{"paid_at":`+strconv.FormatInt(paidAt.Unix(), 10)+`}}`)
+	second := stripeEvent("evt_10", "invoice.paid", `{"id":"in_2","subscription":"sub_test123","amount_paid":299,"charge":"ch_2",
+		"status_transitions":{"paid_at":`+strconv.FormatInt(paidAt.AddDate(0, 0, 10).Unix(), 10)+`}}`)
+	pg.On("ConstructEvent", []byte("first"), "sig").Return(first, nil)
+	pg.On("ConstructEvent", []byte("second"), "sig").Return(second, nil)
+	subRepo.On("FindByStripeSubscriptionID", ctx, "sub_test123").Return(sub, nil)
+	subRepo.On("Save", ctx, sub).Return(nil)
+	userRepo.On("FindByID", ctx, userID).Return(user, nil)
+	userRepo.On("Save", ctx, user).Return(nil)
+
+	assert.NoError(t, svc.HandleWebhook(ctx, []byte("first"), "sig"))
+	assert.NoError(t, svc.HandleWebhook(ctx, []byte("second"), "sig"))
+
+	ledger, err := vault.GetLedger(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 4.99, ledger.Total(domain.LedgerEntryDeposit), "one deposit per month")
+	assert.Equal(t, 4.99, ledger.Balance(domain.LedgerAccountHeld))
+	participation, _ := vault.vaultRepo.FindByUserIDAndMonth(ctx, userID, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	if assert.NotNil(t, participation) {
+		assert.Equal(t, 4.99, participation.DepositAmount)
+		assert.Equal(t, "ch_1", participation.DepositChargeID)
+	}
+	assert.Equal(t, 4.99, user.VaultDeposit)
+}
+
+func TestStripeService_HandleWebhook_IgnoresUpdateAfterCancel(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
+
+	sub := &domain.Subscription{ID: uuid.New(), UserID: uuid.New(), StripeSubscriptionID: "sub_test123", Status: domain.SubStatusCanceled}
+	event := stripeEvent("evt_6", "customer.subscription.updated", `{"id":"sub_test123","status":"active"}`)
+	pg.On("ConstructEvent", []byte("{}"), "sig").Return(event, nil)
+	subRepo.On("FindByStripeSubscriptionID", ctx, "sub_test123").Return(sub, nil)
+
+	err := svc.HandleWebhook(ctx, []byte("{}"), "sig")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SubStatusCanceled, sub.Status)
+	subRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestStripeService_HandleWebhook_OldSubscriptionDoesNotDowngrade(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, nil)
+
+	userID := uuid.New()
+	oldSub := &domain.Subscription{ID: uuid.New(), UserID: userID, StripeSubscriptionID: "sub_old", Status: domain.SubStatusActive}
+	user := &domain.User{ID: userID, SubscriptionID: uuid.New().String(), SubscriptionTier: domain.TierAICoach, SubscriptionStatus: domain.SubStatusActive}
+
+	event := stripeEvent("evt_7", "customer.subscription.deleted", `{"id":"sub_old","status":"canceled"}`)
+	pg.On("ConstructEvent", []byte("{}"), "sig").Return(event, nil)
+	subRepo.On("FindByStripeSubscriptionID", ctx, "sub_old").Return(oldSub, nil)
+	subRepo.On("Save", ctx, oldSub).Return(nil)
+	userRepo.On("FindByID", ctx, userID).Return(user, nil)
+
+	err := svc.HandleWebhook(ctx, []byte("{}"), "sig")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TierAICoach, user.SubscriptionTier)
+	userRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestStripeService_HandleWebhook_TrialWillEnd(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	notifications := new(MockNotificationService)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), notifications, testBillingConfig, nil, nil, nil)
+
+	userID := uuid.New()
+	sub := &domain.Subscription{ID: uuid.New(), UserID: userID, StripeSubscriptionID: "sub_trial", Status: domain.SubStatusTrialing}
+	event := stripeEvent("evt_8", "customer.subscription.trial_will_end", `{"id":"sub_trial","status":"trialing","trial_end":1767225600}`)
+	pg.On("ConstructEvent", []byte("{}"), "sig").Return(event, nil)
+	subRepo.On("FindByStripeSubscriptionID", ctx, "sub_trial").Return(sub, nil)
+	notifications.On("SendNotification", ctx, userID, "Your free trial ends soon", mock.Anything, domain.NotificationTypeTrialEnding,
+		map[string]string{"trial_ends_at": "2026-01-01T00:00:00Z"}).Return(nil)
+
+	err := svc.HandleWebhook(ctx, []byte("{}"), "sig")
+
+	assert.NoError(t, err)
+	notifications.AssertExpectations(t)
+}
+
+func TestStripeService_HandleWebhook_ChargeRefunded(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	notifications := new(MockNotificationService)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), notifications, testBillingConfig, nil, nil, nil)
+
+	user := &domain.User{ID: uuid.New(), StripeCustomerID: "cus_123"}
+	event := stripeEvent("evt_9", "charge.refunded", `{"id":"ch_1","customer":"cus_123","amount_refunded":1250,"refunded":false}`)
+	pg.On("ConstructEvent", []byte("{}"), "sig").Return(event, nil)
+	userRepo.On("FindByStripeCustomerID", ctx, "cus_123").Return(user, nil)
+	notifications.On("SendNotification", ctx, user.ID, "Refund on its way", "$12.50 has been refunded to your card.",
+		domain.NotificationTypeVaultRefund, mock.Anything).Return(errors.New("push failed"))
+
+	err := svc.HandleWebhook(ctx, []byte("{}"), "sig")
+
+	assert.NoError(t, err) // A failed push doesn't fail the webhook
+	notifications.AssertExpectations(t)
+}
@@ -22,8 +22,16 @@ func (m *MockPaymentGatewayForSubscription) CreateCustomer(email, name string) (
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockPaymentGatewayForSubscription) CreateSubscription(customerID, priceID string) (string, error) {
-	args := m.Called(customerID, priceID)
+func (m *MockPaymentGatewayForSubscription) CreateCheckoutSession(req domain.CheckoutSessionRequest) (*domain.CheckoutSession, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CheckoutSession), args.Error(1)
+}
+
+func (m *MockPaymentGatewayForSubscription) CreatePortalSession(customerID, returnURL string) (string, error) {
+	args := m.Called(customerID, returnURL)
 	return args.String(0), args.Error(1)
 }
 
@@ -37,149 +45,34 @@ func (m *MockPaymentGatewayForSubscription) ConstructEvent(payload []byte, heade
 	return args.Get(0), args.Error(1)
 }
 
-// --- Tests for SubscriptionService ---
-
-func TestNewSubscriptionService(t *testing.T) {
-	userRepo := new(MockUserRepository)
-	pg := new(MockPaymentGatewayForSubscription)
-
-	svc := NewSubscriptionService(userRepo, pg)
-	assert.NotNil(t, svc)
+func (m *MockPaymentGatewayForSubscription) CreateRefund(chargeID string, amount float64, idempotencyKey string) (string, float64, error) {
+	args := m.Called(chargeID, amount, idempotencyKey)
+	return args.String(0), args.Get(1).(float64), args.Error(2)
 }
 
-func TestSubscriptionService_UpgradeToVault_Success(t *testing.T) {
-	userRepo := new(MockUserRepository)
-	pg := new(MockPaymentGatewayForSubscription)
-	ctx := context.Background()
-
-	userID := uuid.New()
-	user := &domain.User{
-		ID:               userID,
-		Email:            "test@example.com",
-		SubscriptionTier: domain.TierFree,
-	}
-
-	svc := NewSubscriptionService(userRepo, pg)
-
-	userRepo.On("FindByID", ctx, userID).Return(user, nil)
-	pg.On("CreateCustomer", user.Email, user.Email).Return("cus_new123", nil)
-	pg.On("CreateSubscription", "cus_new123", mock.AnythingOfType("string")).Return("sub_new123", nil)
-	userRepo.On("Save", ctx, mock.AnythingOfType("*domain.User")).Return(nil)
-
-	err := svc.UpgradeToVault(ctx, userID, "pm_test123")
-	assert.NoError(t, err)
-	userRepo.AssertExpectations(t)
-	pg.AssertExpectations(t)
-}
-
-func TestSubscriptionService_UpgradeToVault_UserNotFound(t *testing.T) {
-	userRepo := new(MockUserRepository)
-	pg := new(MockPaymentGatewayForSubscription)
-	ctx := context.Background()
-
-	svc := NewSubscriptionService(userRepo, pg)
-
-	userRepo.On("FindByID", ctx, mock.AnythingOfType("uuid.UUID")).Return(nil, errors.New("user not found"))
-
-	err := svc.UpgradeToVault(ctx, uuid.New(), "pm_test123")
-	assert.Error(t, err)
-	userRepo.AssertExpectations(t)
+func (m *MockPaymentGatewayForSubscription) ChargeCustomer(customerID string, amount float64, description, idempotencyKey string) (string, error) {
+	args := m.Called(customerID, amount, description, idempotencyKey)
+	return args.String(0), args.Error(1)
 }
 
-func TestSubscriptionService_UpgradeToVault_AlreadyVaultMember(t *testing.T) {
-	userRepo := new(MockUserRepository)
-	pg := new(MockPaymentGatewayForSubscription)
-	ctx := context.Background()
-
-	userID := uuid.New()
-	user := &domain.User{
-		ID:                 userID,
-		Email:              "test@example.com",
-		SubscriptionTier:   domain.TierVault,
-		SubscriptionStatus: domain.SubStatusActive, // IsVaultMember() requires both TierVault AND SubStatusActive
-	}
-
-	svc := NewSubscriptionService(userRepo, pg)
-
-	userRepo.On("FindByID", ctx, userID).Return(user, nil)
-
-	err := svc.UpgradeToVault(ctx, userID, "pm_test123")
-	assert.Error(t, err)
-	assert.Equal(t, "user is already a vault member", err.Error())
-	// Only assert userRepo expectations as pg is not called when user is already vault member
-	userRepo.AssertExpectations(t)
+func (m *MockPaymentGatewayForSubscription) PaymentMethodFingerprint(paymentMethodID string) (string, error) {
+	args := m.Called(paymentMethodID)
+	return args.String(0), args.Error(1)
 }
 
-func TestSubscriptionService_UpgradeToVault_CreateCustomerError(t *testing.T) {
-	userRepo := new(MockUserRepository)
-	pg := new(MockPaymentGatewayForSubscription)
-	ctx := context.Background()
-
-	userID := uuid.New()
-	user := &domain.User{
-		ID:               userID,
-		Email:            "test@example.com",
-		SubscriptionTier: domain.TierFree,
-	}
-
-	svc := NewSubscriptionService(userRepo, pg)
-
-	userRepo.On("FindByID", ctx, userID).Return(user, nil)
-	pg.On("CreateCustomer", user.Email, user.Email).Return("", errors.New("payment gateway error"))
-
-	err := svc.UpgradeToVault(ctx, userID, "pm_test123")
-	assert.Error(t, err)
-	userRepo.AssertExpectations(t)
-	pg.AssertExpectations(t)
+func (m *MockPaymentGatewayForSubscription) CreateCoupon(req domain.CouponRequest) (string, error) {
+	args := m.Called(req)
+	return args.String(0), args.Error(1)
 }
 
-func TestSubscriptionService_UpgradeToVault_CreateSubscriptionError(t *testing.T) {
-	userRepo := new(MockUserRepository)
-	pg := new(MockPaymentGatewayForSubscription)
-	ctx := context.Background()
-
-	userID := uuid.New()
-	user := &domain.User{
-		ID:               userID,
-		Email:            "test@example.com",
-		SubscriptionTier: domain.TierFree,
-	}
-
-	svc := NewSubscriptionService(userRepo, pg)
-
-	userRepo.On("FindByID", ctx, userID).Return(user, nil)
-	pg.On("CreateCustomer", user.Email, user.Email).Return("cus_new123", nil)
-	pg.On("CreateSubscription", "cus_new123", mock.AnythingOfType("string")).Return("", errors.New("subscription creation failed"))
-
-	err := svc.UpgradeToVault(ctx, userID, "pm_test123")
-	assert.Error(t, err)
-	userRepo.AssertExpectations(t)
-	pg.AssertExpectations(t)
-}
+// --- Tests for SubscriptionService ---
 
-func TestSubscriptionService_UpgradeToVault_SaveUserError(t *testing.T) {
+func TestNewSubscriptionService(t *testing.T) {
 	userRepo := new(MockUserRepository)
 	pg := new(MockPaymentGatewayForSubscription)
-	ctx := context.Background()
-
-	userID := uuid.New()
-	user := &domain.User{
-		ID:               userID,
-		Email:            "test@example.com",
-		SubscriptionTier: domain.TierFree,
-	}
 
 	svc := NewSubscriptionService(userRepo, pg)
-
-	userRepo.On("FindByID", ctx, userID).Return(user, nil)
-	pg.On("CreateCustomer", user.Email, user.Email).Return("cus_new123", nil)
-	pg.On("CreateSubscription", "cus_new123", mock.AnythingOfType("string")).Return("sub_new123", nil)
-	userRepo.On("Save", ctx, mock.AnythingOfType("*domain.User")).Return(errors.New("database error"))
-
-	err := svc.UpgradeToVault(ctx, userID, "pm_test123")
-	assert.Error(t, err)
-	userRepo.AssertExpectations(t)
-	pg.AssertExpectations(t)
+	assert.NotNil(t, svc)
 }
 
 func TestSubscriptionService_DowngradeToFree_Success(t *testing.T) {
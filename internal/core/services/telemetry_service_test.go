@@ -38,6 +38,14 @@ func (m *MockTelemetryRepository) ListConnections(ctx context.Context, userID uu
 	return args.Get(0).([]domain.DeviceConnection), args.Error(1)
 }
 
+func (m *MockTelemetryRepository) FindByRange(ctx context.Context, userID uuid.UUID, metricType domain.MetricType, from, to time.Time) ([]domain.TelemetryData, error) {
+	args := m.Called(ctx, userID, metricType, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.TelemetryData), args.Error(1)
+}
+
 func (m *MockTelemetryRepository) SaveData(ctx context.Context, data *domain.TelemetryData) error {
 	args := m.Called(ctx, data)
 	return args.Error(0)
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrialConfig is what free trials grant
+type TrialConfig struct {
+	Registration domain.TrialOffer            // Offered at sign-up; zero Days disables it
+	Campaigns    map[string]domain.TrialOffer // Offers redeemable with a campaign code
+	ReminderLead time.Duration                // How long before the end of a trial the reminder goes out
+}
+
+// TrialService grants free trials of a paid plan through User.TrialEndsAt. A trial user has the
+// plan's capabilities with status trialing and no subscription. Subscribing through checkout
+// during the trial converts it; otherwise the user is moved back to TierFree when it ends.
+type TrialService struct {
+	trialRepo      ports.TrialRepository
+	userRepo       ports.UserRepository
+	paymentGateway ports.PaymentGateway
+	notifications  ports.NotificationService
+	config         TrialConfig
+}
+
+func NewTrialService(trialRepo ports.TrialRepository, userRepo ports.UserRepository, pg ports.PaymentGateway, notifications ports.NotificationService, config TrialConfig) *TrialService {
+	return &TrialService{
+		trialRepo:      trialRepo,
+		userRepo:       userRepo,
+		paymentGateway: pg,
+		notifications:  notifications,
+		config:         config,
+	}
+}
+
+// StartTrial grants the registration offer, or the campaign's offer when a campaign is given.
+// Users who have had a subscription can't start a trial, and each email and card only gets one.
+func (s *TrialService) StartTrial(ctx context.Context, userID uuid.UUID, campaign, paymentMethodID string) (*domain.Trial, error) {
+	offer, source, err := s.offer(campaign)
+	if err != nil {
+		return nil, err
+	}
+	if offer.RequirePaymentMethod && paymentMethodID == "" {
+		return nil, domain.ErrPaymentMethodRequired
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.SubscriptionID != "" || user.SubscriptionStatus.GrantsAccess() {
+		return nil, domain.ErrTrialNotAvailable
+	}
+
+	var fingerprint string
+	if paymentMethodID != "" {
+		fingerprint, err = s.paymentGateway.PaymentMethodFingerprint(paymentMethodID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify payment method: %w", err)
+		}
+	}
+
+	now := time.Now()
+	trial := &domain.Trial{
+		ID:                 uuid.New(),
+		UserID:             user.ID,
+		Plan:               offer.Plan,
+		Source:             source,
+		Campaign:           campaign,
+		EmailKey:           domain.NormalizeTrialEmail(user.Email),
+		PaymentFingerprint: fingerprint,
+		Status:             domain.TrialStatusActive,
+		StartedAt:          now,
+		EndsAt:             now.AddDate(0, 0, offer.Days),
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	created, err := s.trialRepo.Create(ctx, trial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start trial: %w", err)
+	}
+	if !created {
+		logger.Warn().Str("user_id", user.ID.String()).Bool("card", fingerprint != "").Msg("Free trial refused: email or card already had one")
+		return nil, domain.ErrTrialAlreadyUsed
+	}
+
+	user.StartTrial(trial)
+	user.UpdatedAt = now
+	if err := s.userRepo.Save(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to start trial: %w", err)
+	}
+	logger.Info().Str("user_id", user.ID.String()).Str("plan", string(trial.Plan)).Str("source", string(source)).Msg("Free trial started")
+	return trial, nil
+}
+
+func (s *TrialService) GetTrial(ctx context.Context, userID uuid.UUID) (*domain.Trial, error) {
+	trial, err := s.trialRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if trial == nil {
+		return nil, domain.ErrTrialNotFound
+	}
+	return trial, nil
+}
+
+// SendTrialReminders notifies users whose trial ends within the reminder lead. Each trial is
+// reminded once; users who already subscribed aren't reminded.
+func (s *TrialService) SendTrialReminders(ctx context.Context, now time.Time) (int, error) {
+	trials, err := s.trialRepo.ListActiveEndingBefore(ctx, now.Add(s.config.ReminderLead))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list ending trials: %w", err)
+	}
+	reminded := 0
+	for _, trial := range trials {
+		if trial.ReminderSentAt != nil || !now.Before(trial.EndsAt) {
+			continue
+		}
+		user, err := s.userRepo.FindByID(ctx, trial.UserID)
+		if err != nil || user == nil || !user.OnInAppTrial() {
+			continue
+		}
+
+		s.notify(ctx, user.ID, "Your free trial ends soon",
+			fmt.Sprintf("Your trial ends on %s. Subscribe to keep your premium features.", trial.EndsAt.Format("January 2")),
+			domain.NotificationTypeTrialEnding, map[string]string{"trial_ends_at": trial.EndsAt.Format(time.RFC3339)})
+
+		trial.ReminderSentAt = &now
+		trial.UpdatedAt = now
+		if err := s.trialRepo.Update(ctx, trial); err != nil {
+			logger.Error().Err(err).Str("trial_id", trial.ID.String()).Msg("Failed to record trial reminder")
+			continue
+		}
+		reminded++
+	}
+	return reminded, nil
+}
+
+// EndExpiredTrials closes trials that have run out. A user who subscribed during the trial
+// counts as converted; anyone else is moved back to TierFree.
+func (s *TrialService) EndExpiredTrials(ctx context.Context, now time.Time) (int, error) {
+	trials, err := s.trialRepo.ListActiveEndingBefore(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired trials: %w", err)
+	}
+	ended := 0
+	for _, trial := range trials {
+		if err := s.endTrial(ctx, trial, now); err != nil {
+			logger.Error().Err(err).Str("trial_id", trial.ID.String()).Msg("Failed to end trial")
+			continue
+		}
+		ended++
+	}
+	return ended, nil
+}
+
+func (s *TrialService) endTrial(ctx context.Context, trial *domain.Trial, now time.Time) error {
+	user, err := s.userRepo.FindByID(ctx, trial.UserID)
+	if err != nil {
+		return err
+	}
+
+	trial.Status = domain.TrialStatusExpired
+	if user != nil && user.SubscriptionID != "" {
+		trial.Status = domain.TrialStatusConverted
+	} else if user != nil && user.OnInAppTrial() {
+		user.EndTrial()
+		user.UpdatedAt = now
+		if err := s.userRepo.Save(ctx, user); err != nil {
+			return err
+		}
+	}
+
+	trial.EndedAt = &now
+	trial.UpdatedAt = now
+	if err := s.trialRepo.Update(ctx, trial); err != nil {
+		return err
+	}
+
+	if trial.Status == domain.TrialStatusExpired && user != nil {
+		s.notify(ctx, user.ID, "Your free trial has ended",
+			"Your premium features are paused. Subscribe any time to pick up where you left off.",
+			domain.NotificationTypeTrialEnded, map[string]string{"plan": string(trial.Plan)})
+	}
+	return nil
+}
+
+func (s *TrialService) offer(campaign string) (domain.TrialOffer, domain.TrialSource, error) {
+	if campaign == "" {
+		if !s.config.Registration.IsValid() {
+			return domain.TrialOffer{}, "", domain.ErrTrialNotAvailable
+		}
+		return s.config.Registration, domain.TrialSourceRegistration, nil
+	}
+	offer, ok := s.config.Campaigns[campaign]
+	if !ok || !offer.IsValid() {
+		return domain.TrialOffer{}, "", domain.ErrUnknownTrialCampaign
+	}
+	return offer, domain.TrialSourceCampaign, nil
+}
+
+// notify sends a best-effort notification; a failed push never fails the trial job
+func (s *TrialService) notify(ctx context.Context, userID uuid.UUID, title, body string, notifType domain.NotificationType, data map[string]string) {
+	if s.notifications == nil {
+		return
+	}
+	if err := s.notifications.SendNotification(ctx, userID, title, body, notifType, data); err != nil {
+		logger.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to send trial notification")
+	}
+}
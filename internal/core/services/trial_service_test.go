@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mock TrialRepository ---
+
+type MockTrialRepository struct {
+	mock.Mock
+}
+
+func (m *MockTrialRepository) Create(ctx context.Context, trial *domain.Trial) (bool, error) {
+	args := m.Called(ctx, trial)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTrialRepository) Update(ctx context.Context, trial *domain.Trial) error {
+	args := m.Called(ctx, trial)
+	return args.Error(0)
+}
+
+func (m *MockTrialRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*domain.Trial, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Trial), args.Error(1)
+}
+
+func (m *MockTrialRepository) ListActiveEndingBefore(ctx context.Context, before time.Time) ([]*domain.Trial, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Trial), args.Error(1)
+}
+
+func testTrialConfig() TrialConfig {
+	return TrialConfig{
+		Registration: domain.TrialOffer{Plan: domain.TierVault, Days: 7},
+		Campaigns: map[string]domain.TrialOffer{
+			"SUMMER": {Plan: domain.TierAICoach, Days: 14, RequirePaymentMethod: true},
+		},
+		ReminderLead: 72 * time.Hour,
+	}
+}
+
+func trialUser(endsAt time.Time) *domain.User {
+	return &domain.User{
+		ID:                 uuid.New(),
+		Email:              "jane@example.com",
+		SubscriptionTier:   domain.TierVault,
+		SubscriptionStatus: domain.SubStatusTrialing,
+		TrialEndsAt:        &endsAt,
+	}
+}
+
+func TestTrialService_StartTrial_Registration(t *testing.T) {
+	trialRepo := new(MockTrialRepository)
+	userRepo := new(MockUserRepository)
+	service := NewTrialService(trialRepo, userRepo, nil, nil, testTrialConfig())
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Email: "Jane.Doe+promo@googlemail.com", SubscriptionTier: domain.TierFree}
+
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	trialRepo.On("Create", ctx, mock.MatchedBy(func(trial *domain.Trial) bool {
+		return trial.EmailKey == "janedoe@gmail.com" && trial.PaymentFingerprint == ""
+	})).Return(true, nil)
+	userRepo.On("Save", ctx, mock.AnythingOfType("*domain.User")).Return(nil)
+
+	trial, err := service.StartTrial(ctx, user.ID, "", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TierVault, trial.Plan)
+	assert.Equal(t, domain.TrialSourceRegistration, trial.Source)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 7), trial.EndsAt, time.Minute)
+	assert.Equal(t, domain.TierVault, user.SubscriptionTier)
+	assert.Equal(t, domain.SubStatusTrialing, user.SubscriptionStatus)
+	assert.True(t, user.OnInAppTrial())
+	assert.True(t, user.Entitlements().Has(domain.CapabilityVault))
+}
+
+func TestTrialService_StartTrial_OncePerEmail(t *testing.T) {
+	trialRepo := new(MockTrialRepository)
+	userRepo := new(MockUserRepository)
+	service := NewTrialService(trialRepo, userRepo, nil, nil, testTrialConfig())
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Email: "jane+second@example.com", SubscriptionTier: domain.TierFree}
+
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	trialRepo.On("Create", ctx, mock.AnythingOfType("*domain.Trial")).Return(false, nil)
+
+	_, err := service.StartTrial(ctx, user.ID, "", "")
+
+	assert.ErrorIs(t, err, domain.ErrTrialAlreadyUsed)
+	assert.Equal(t, domain.TierFree, user.SubscriptionTier)
+	userRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestTrialService_StartTrial_CampaignRequiresCard(t *testing.T) {
+	trialRepo := new(MockTrialRepository)
+	userRepo := new(MockUserRepository)
+	pg := new(MockPaymentGatewayForStripe)
+	service := NewTrialService(trialRepo, userRepo, pg, nil, testTrialConfig())
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Email: "jane@example.com", SubscriptionTier: domain.TierFree}
+
+	_, err := service.StartTrial(ctx, user.ID, "SUMMER", "")
+	assert.ErrorIs(t, err, domain.ErrPaymentMethodRequired)
+
+	_, err = service.StartTrial(ctx, user.ID, "WINTER", "pm_1")
+	assert.ErrorIs(t, err, domain.ErrUnknownTrialCampaign)
+
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	pg.On("PaymentMethodFingerprint", "pm_1").Return("fp_card", nil)
+	trialRepo.On("Create", ctx, mock.MatchedBy(func(trial *domain.Trial) bool {
+		return trial.PaymentFingerprint == "fp_card" && trial.Campaign == "SUMMER"
+	})).Return(true, nil)
+	userRepo.On("Save", ctx, mock.AnythingOfType("*domain.User")).Return(nil)
+
+	trial, err := service.StartTrial(ctx, user.ID, "SUMMER", "pm_1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TierAICoach, trial.Plan)
+	assert.Equal(t, domain.TrialSourceCampaign, trial.Source)
+}
+
+func TestTrialService_StartTrial_NotForSubscribers(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service := NewTrialService(new(MockTrialRepository), userRepo, nil, nil, testTrialConfig())
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Email: "jane@example.com", SubscriptionTier: domain.TierFree, SubscriptionID: "sub_old", SubscriptionStatus: domain.SubStatusCanceled}
+
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+
+	_, err := service.StartTrial(ctx, user.ID, "", "")
+
+	assert.ErrorIs(t, err, domain.ErrTrialNotAvailable)
+}
+
+func TestTrialService_SendTrialReminders(t *testing.T) {
+	trialRepo := new(MockTrialRepository)
+	userRepo := new(MockUserRepository)
+	notifications := new(MockNotificationService)
+	service := NewTrialService(trialRepo, userRepo, nil, notifications, testTrialConfig())
+	ctx := context.Background()
+	now := time.Now()
+
+	user := trialUser(now.Add(48 * time.Hour))
+	due := &domain.Trial{ID: uuid.New(), UserID: user.ID, Status: domain.TrialStatusActive, EndsAt: *user.TrialEndsAt}
+	reminded := &domain.Trial{ID: uuid.New(), UserID: uuid.New(), Status: domain.TrialStatusActive, EndsAt: now.Add(24 * time.Hour), ReminderSentAt: &now}
+
+	trialRepo.On("ListActiveEndingBefore", ctx, now.Add(72*time.Hour)).Return([]*domain.Trial{due, reminded}, nil)
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	notifications.On("SendNotification", ctx, user.ID, mock.Anything, mock.Anything, domain.NotificationTypeTrialEnding, mock.Anything).Return(nil)
+	trialRepo.On("Update", ctx, due).Return(nil)
+
+	n, err := service.SendTrialReminders(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.NotNil(t, due.ReminderSentAt)
+	notifications.AssertNumberOfCalls(t, "SendNotification", 1)
+}
+
+func TestTrialService_EndExpiredTrials(t *testing.T) {
+	trialRepo := new(MockTrialRepository)
+	userRepo := new(MockUserRepository)
+	notifications := new(MockNotificationService)
+	service := NewTrialService(trialRepo, userRepo, nil, notifications, testTrialConfig())
+	ctx := context.Background()
+	now := time.Now()
+
+	lapsed := trialUser(now.Add(-time.Hour))
+	subscribed := trialUser(now.Add(-time.Hour))
+	subscribed.SubscriptionID = "sub_123"
+	lapsedTrial := &domain.Trial{ID: uuid.New(), UserID: lapsed.ID, Plan: domain.TierVault, Status: domain.TrialStatusActive, EndsAt: *lapsed.TrialEndsAt}
+	convertedTrial := &domain.Trial{ID: uuid.New(), UserID: subscribed.ID, Plan: domain.TierVault, Status: domain.TrialStatusActive, EndsAt: *subscribed.TrialEndsAt}
+
+	// An expired trial grants nothing even before the job downgrades it
+	assert.False(t, lapsed.Entitlements().Has(domain.CapabilityVault))
+
+	trialRepo.On("ListActiveEndingBefore", ctx, now).Return([]*domain.Trial{lapsedTrial, convertedTrial}, nil)
+	userRepo.On("FindByID", ctx, lapsed.ID).Return(lapsed, nil)
+	userRepo.On("FindByID", ctx, subscribed.ID).Return(subscribed, nil)
+	userRepo.On("Save", ctx, lapsed).Return(nil)
+	trialRepo.On("Update", ctx, mock.AnythingOfType("*domain.Trial")).Return(nil)
+	notifications.On("SendNotification", ctx, lapsed.ID, mock.Anything, mock.Anything, domain.NotificationTypeTrialEnded, mock.Anything).Return(nil)
+
+	n, err := service.EndExpiredTrials(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, domain.TrialStatusExpired, lapsedTrial.Status)
+	assert.Equal(t, domain.TierFree, lapsed.SubscriptionTier)
+	assert.Equal(t, domain.SubStatusNone, lapsed.SubscriptionStatus)
+	assert.Equal(t, domain.TrialStatusConverted, convertedTrial.Status)
+	assert.Equal(t, domain.TierVault, subscribed.SubscriptionTier)
+	userRepo.AssertNumberOfCalls(t, "Save", 1)
+	notifications.AssertNumberOfCalls(t, "SendNotification", 1)
+}
+
+func TestNormalizeTrialEmail(t *testing.T) {
+	assert.Equal(t, "janedoe@gmail.com", domain.NormalizeTrialEmail(" Jane.Doe+fast@GoogleMail.com "))
+	assert.Equal(t, "jane.doe@example.com", domain.NormalizeTrialEmail("jane.doe+x@example.com"))
+	assert.Equal(t, "not-an-email", domain.NormalizeTrialEmail("not-an-email"))
+}
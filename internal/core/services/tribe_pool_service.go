@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TribePoolService runs monthly tribe prize pools: members pay a deposit into the pot,
+// fasts completed during the month decide the ranking, and the top three are paid the pot.
+type TribePoolService struct {
+	poolRepo     ports.TribePoolRepository
+	tribeRepo    ports.TribeRepository
+	fastingRepo  ports.FastingRepository
+	entitlements ports.EntitlementService
+	vault        ports.VaultService
+}
+
+func NewTribePoolService(poolRepo ports.TribePoolRepository, tribeRepo ports.TribeRepository, fastingRepo ports.FastingRepository, entitlements ports.EntitlementService, vault ports.VaultService) *TribePoolService {
+	return &TribePoolService{
+		poolRepo:     poolRepo,
+		tribeRepo:    tribeRepo,
+		fastingRepo:  fastingRepo,
+		entitlements: entitlements,
+		vault:        vault,
+	}
+}
+
+// OpenPool opens the tribe's pool for a month. Only the tribe creator or a moderator can
+// open one, and only for the current or a future month.
+func (s *TribePoolService) OpenPool(ctx context.Context, tribeID, userID string, month time.Time) (*domain.TribePool, error) {
+	tribeUUID, err := s.findTribe(ctx, tribeID)
+	if err != nil {
+		return nil, err
+	}
+	membership, err := s.activeMembership(ctx, tribeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if membership.Role != "creator" && membership.Role != "moderator" {
+		return nil, domain.ErrTribePoolNotPermitted
+	}
+
+	now := time.Now()
+	monthStart := domain.PoolMonth(month)
+	if monthStart.Before(domain.PoolMonth(now)) {
+		return nil, fmt.Errorf("cannot open a pool for %s, it has already ended", monthStart.Format("2006-01"))
+	}
+
+	pool := &domain.TribePool{
+		ID:         uuid.New(),
+		TribeID:    tribeUUID,
+		MonthStart: monthStart,
+		MonthEnd:   monthStart.AddDate(0, 1, -1),
+		Status:     domain.TribePoolStatusOpen,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	created, err := s.poolRepo.CreatePool(ctx, pool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pool: %w", err)
+	}
+	if !created {
+		return nil, domain.ErrTribePoolExists
+	}
+	return pool, nil
+}
+
+// JoinPool charges the member's deposit and puts it into the tribe's pool for the month; if the
+// charge fails they don't join. Joining needs the group pots capability and closes when the
+// month starts.
+func (s *TribePoolService) JoinPool(ctx context.Context, tribeID, userID string, month time.Time) (*domain.TribePoolParticipant, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	if err := s.entitlements.Require(ctx, userUUID, domain.CapabilityGroupPots); err != nil {
+		return nil, err
+	}
+	if _, err := s.activeMembership(ctx, tribeID, userID); err != nil {
+		return nil, err
+	}
+	pool, err := s.findPool(ctx, tribeID, month)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !pool.AcceptsParticipants(now) {
+		return nil, domain.ErrTribePoolNotOpen
+	}
+	// Check before charging; AddParticipant still guards against a concurrent join
+	existing, err := s.poolRepo.ListParticipants(ctx, pool.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load participants: %w", err)
+	}
+	for _, p := range existing {
+		if p.UserID == userUUID {
+			return nil, domain.ErrAlreadyInTribePool
+		}
+	}
+
+	participant := &domain.TribePoolParticipant{
+		ID:            uuid.New(),
+		PoolID:        pool.ID,
+		UserID:        userUUID,
+		DepositAmount: domain.TribePoolDeposit,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	participant.DepositChargeID, err = s.vault.CollectStake(ctx, userUUID, participant.DepositAmount,
+		fmt.Sprintf("Tribe pool deposit for %s", pool.MonthStart.Format("January 2006")),
+		"tribe-pool-stake-"+participant.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	added, err := s.poolRepo.AddParticipant(ctx, participant)
+	if err != nil || !added {
+		if payErr := s.vault.PayOutStake(ctx, userUUID, tribePoolPayout(pool, participant, participant.DepositAmount)); payErr != nil {
+			log.Printf("tribe pool: failed to return deposit %s for %s: %v", participant.DepositChargeID, userUUID, payErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		return nil, domain.ErrAlreadyInTribePool
+	}
+	return participant, nil
+}
+
+// GetStandings returns the pool for the month with participants in rank order. Until the pool
+// completes, fast counts are recomputed on every call.
+func (s *TribePoolService) GetStandings(ctx context.Context, tribeID string, month time.Time) (*domain.TribePoolStandings, error) {
+	pool, err := s.findPool(ctx, tribeID, month)
+	if err != nil {
+		return nil, err
+	}
+	participants, err := s.poolRepo.ListParticipants(ctx, pool.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load participants: %w", err)
+	}
+	if participants == nil {
+		participants = []*domain.TribePoolParticipant{}
+	}
+
+	if pool.Status != domain.TribePoolStatusCompleted {
+		if err := s.countFasts(ctx, pool, participants); err != nil {
+			return nil, err
+		}
+		// Rank a copy so live standings never show payouts the pool hasn't made
+		preview := *pool
+		domain.RankTribePool(&preview, participants)
+		for _, p := range participants {
+			p.PayoutAmount = 0
+		}
+	} else {
+		sort.SliceStable(participants, func(i, j int) bool {
+			return participants[i].FinalRank < participants[j].FinalRank
+		})
+	}
+	return &domain.TribePoolStandings{Pool: pool, Participants: participants}, nil
+}
+
+func (s *TribePoolService) ListPools(ctx context.Context, tribeID string) ([]*domain.TribePool, error) {
+	tribeUUID, err := s.findTribe(ctx, tribeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.poolRepo.ListPoolsByTribe(ctx, tribeUUID)
+}
+
+// ActivateStartedPools stops joins for pools whose month has started
+func (s *TribePoolService) ActivateStartedPools(ctx context.Context, now time.Time) (int, error) {
+	pools, err := s.poolRepo.ListPoolsByStatus(ctx, domain.TribePoolStatusOpen)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list open pools: %w", err)
+	}
+	activated := 0
+	for _, pool := range pools {
+		if now.Before(pool.MonthStart) {
+			continue
+		}
+		pool.Status = domain.TribePoolStatusActive
+		pool.UpdatedAt = now
+		if err := s.poolRepo.UpdatePool(ctx, pool); err != nil {
+			log.Printf("tribe pool: failed to activate pool %s: %v", pool.ID, err)
+			continue
+		}
+		activated++
+	}
+	return activated, nil
+}
+
+// SettleEndedPools ranks and pays out every pool whose month has ended. Settling is deterministic
+// and participants already paid are skipped, so a pool that failed halfway is settled again on
+// the next run.
+func (s *TribePoolService) SettleEndedPools(ctx context.Context, now time.Time) (int, error) {
+	settled := 0
+	for _, status := range []string{domain.TribePoolStatusOpen, domain.TribePoolStatusActive} {
+		pools, err := s.poolRepo.ListPoolsByStatus(ctx, status)
+		if err != nil {
+			return settled, fmt.Errorf("failed to list %s pools: %w", status, err)
+		}
+		for _, pool := range pools {
+			if !pool.HasEnded(now) {
+				continue
+			}
+			if err := s.settlePool(ctx, pool, now); err != nil {
+				log.Printf("tribe pool: failed to settle pool %s: %v", pool.ID, err)
+				continue
+			}
+			settled++
+		}
+	}
+	return settled, nil
+}
+
+func (s *TribePoolService) settlePool(ctx context.Context, pool *domain.TribePool, now time.Time) error {
+	// Stop joins before the final count
+	if pool.Status == domain.TribePoolStatusOpen {
+		pool.Status = domain.TribePoolStatusActive
+		pool.UpdatedAt = now
+		if err := s.poolRepo.UpdatePool(ctx, pool); err != nil {
+			return err
+		}
+	}
+
+	participants, err := s.poolRepo.ListParticipants(ctx, pool.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.countFasts(ctx, pool, participants); err != nil {
+		return err
+	}
+
+	// Rank against the deposits actually recorded; the pool may have been listed before a last join
+	pool.TotalPot = 0
+	for _, p := range participants {
+		pool.TotalPot += p.DepositAmount
+	}
+	pool.ParticipantCount = len(participants)
+	domain.RankTribePool(pool, participants)
+
+	for _, p := range participants {
+		p.UpdatedAt = now
+		if err := s.poolRepo.UpdateParticipant(ctx, p); err != nil {
+			return fmt.Errorf("failed to record payout for %s: %w", p.UserID, err)
+		}
+	}
+
+	for _, p := range participants {
+		if p.PayoutProcessed {
+			continue
+		}
+		// Deposits from before joins were charged were never collected, so there is nothing to pay
+		if p.PayoutAmount > 0 && p.DepositChargeID != "" {
+			if err := s.vault.PayOutStake(ctx, p.UserID, tribePoolPayout(pool, p, p.PayoutAmount)); err != nil {
+				return fmt.Errorf("failed to pay out %s: %w", p.UserID, err)
+			}
+		}
+		p.PayoutProcessed = true
+		if err := s.poolRepo.UpdateParticipant(ctx, p); err != nil {
+			return fmt.Errorf("failed to record payout for %s: %w", p.UserID, err)
+		}
+	}
+
+	pool.Status = domain.TribePoolStatusCompleted
+	pool.UpdatedAt = now
+	return s.poolRepo.UpdatePool(ctx, pool)
+}
+
+func tribePoolPayout(pool *domain.TribePool, p *domain.TribePoolParticipant, amount float64) domain.StakePayout {
+	return domain.StakePayout{
+		SourceType: domain.LedgerSourceTribePool,
+		SourceID:   p.ID.String(),
+		ChargeID:   p.DepositChargeID,
+		Stake:      p.DepositAmount,
+		Amount:     amount,
+		Reason:     fmt.Sprintf("Tribe pool winnings for %s (rank %d)", pool.MonthStart.Format("January 2006"), p.FinalRank),
+	}
+}
+
+// countFasts sets each participant's FastsCompleted for the pool's month
+func (s *TribePoolService) countFasts(ctx context.Context, pool *domain.TribePool, participants []*domain.TribePoolParticipant) error {
+	for _, p := range participants {
+		sessions, err := s.fastingRepo.FindByUserID(ctx, p.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to load fasts for %s: %w", p.UserID, err)
+		}
+		p.FastsCompleted = domain.CountPoolFasts(sessions, p.CountingStart(pool), pool.NextMonthStart())
+	}
+	return nil
+}
+
+func (s *TribePoolService) findTribe(ctx context.Context, tribeID string) (uuid.UUID, error) {
+	tribeUUID, err := uuid.Parse(tribeID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid tribe ID: %w", err)
+	}
+	if _, err := s.tribeRepo.FindByID(ctx, tribeID); err != nil {
+		return uuid.Nil, err
+	}
+	return tribeUUID, nil
+}
+
+func (s *TribePoolService) findPool(ctx context.Context, tribeID string, month time.Time) (*domain.TribePool, error) {
+	tribeUUID, err := uuid.Parse(tribeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tribe ID: %w", err)
+	}
+	pool, err := s.poolRepo.FindPoolByMonth(ctx, tribeUUID, domain.PoolMonth(month))
+	if err != nil {
+		return nil, err
+	}
+	if pool == nil {
+		return nil, domain.ErrTribePoolNotFound
+	}
+	return pool, nil
+}
+
+func (s *TribePoolService) activeMembership(ctx context.Context, tribeID, userID string) (*domain.TribeMembership, error) {
+	membership, _ := s.tribeRepo.FindMembership(ctx, tribeID, userID)
+	if membership == nil || membership.Status != "active" {
+		return nil, domain.ErrNotTribeMember
+	}
+	return membership, nil
+}
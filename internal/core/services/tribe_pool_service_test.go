@@ -0,0 +1,439 @@
+package services
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTribePoolRepository is a mock implementation of ports.TribePoolRepository
+type MockTribePoolRepository struct {
+	mock.Mock
+}
+
+func (m *MockTribePoolRepository) CreatePool(ctx context.Context, pool *domain.TribePool) (bool, error) {
+	args := m.Called(ctx, pool)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTribePoolRepository) UpdatePool(ctx context.Context, pool *domain.TribePool) error {
+	args := m.Called(ctx, pool)
+	return args.Error(0)
+}
+
+func (m *MockTribePoolRepository) FindPoolByID(ctx context.Context, id uuid.UUID) (*domain.TribePool, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TribePool), args.Error(1)
+}
+
+func (m *MockTribePoolRepository) FindPoolByMonth(ctx context.Context, tribeID uuid.UUID, monthStart time.Time) (*domain.TribePool, error) {
+	args := m.Called(ctx, tribeID, monthStart)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TribePool), args.Error(1)
+}
+
+func (m *MockTribePoolRepository) ListPoolsByTribe(ctx context.Context, tribeID uuid.UUID) ([]*domain.TribePool, error) {
+	args := m.Called(ctx, tribeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.TribePool), args.Error(1)
+}
+
+func (m *MockTribePoolRepository) ListPoolsByStatus(ctx context.Context, status string) ([]*domain.TribePool, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.TribePool), args.Error(1)
+}
+
+func (m *MockTribePoolRepository) AddParticipant(ctx context.Context, participant *domain.TribePoolParticipant) (bool, error) {
+	args := m.Called(ctx, participant)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTribePoolRepository) UpdateParticipant(ctx context.Context, participant *domain.TribePoolParticipant) error {
+	args := m.Called(ctx, participant)
+	return args.Error(0)
+}
+
+func (m *MockTribePoolRepository) ListParticipants(ctx context.Context, poolID uuid.UUID) ([]*domain.TribePoolParticipant, error) {
+	args := m.Called(ctx, poolID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.TribePoolParticipant), args.Error(1)
+}
+
+func completedFast(end time.Time, hours int) domain.FastingSession {
+	return domain.FastingSession{
+		ID:        uuid.New(),
+		StartTime: end.Add(-time.Duration(hours) * time.Hour),
+		EndTime:   &end,
+		GoalHours: hours,
+		Status:    domain.StatusCompleted,
+	}
+}
+
+func TestTribePoolService_OpenPool_Success(t *testing.T) {
+	poolRepo := new(MockTribePoolRepository)
+	tribeRepo := new(MockTribeRepository)
+	service := NewTribePoolService(poolRepo, tribeRepo, new(MockFastingRepository), allowAllEntitlements(), collectAllStakes())
+	ctx := context.Background()
+	tribeID := uuid.New().String()
+	userID := uuid.New().String()
+	nextMonth := domain.PoolMonth(time.Now()).AddDate(0, 1, 0)
+
+	tribeRepo.On("FindByID", ctx, tribeID).Return(&domain.Tribe{ID: tribeID}, nil)
+	tribeRepo.On("FindMembership", ctx, tribeID, userID).Return(&domain.TribeMembership{Role: "creator", Status: "active"}, nil)
+	poolRepo.On("CreatePool", ctx, mock.AnythingOfType("*domain.TribePool")).Return(true, nil)
+
+	pool, err := service.OpenPool(ctx, tribeID, userID, nextMonth.AddDate(0, 0, 14))
+
+	assert.NoError(t, err)
+	assert.Equal(t, nextMonth, pool.MonthStart)
+	assert.Equal(t, nextMonth.AddDate(0, 1, -1), pool.MonthEnd)
+	assert.Equal(t, domain.TribePoolStatusOpen, pool.Status)
+	assert.Equal(t, tribeID, pool.TribeID.String())
+}
+
+func TestTribePoolService_OpenPool_MemberNotPermitted(t *testing.T) {
+	tribeRepo := new(MockTribeRepository)
+	service := NewTribePoolService(new(MockTribePoolRepository), tribeRepo, new(MockFastingRepository), allowAllEntitlements(), collectAllStakes())
+	ctx := context.Background()
+	tribeID := uuid.New().String()
+	userID := uuid.New().String()
+
+	tribeRepo.On("FindByID", ctx, tribeID).Return(&domain.Tribe{ID: tribeID}, nil)
+	tribeRepo.On("FindMembership", ctx, tribeID, userID).Return(&domain.TribeMembership{Role: "member", Status: "active"}, nil)
+
+	_, err := service.OpenPool(ctx, tribeID, userID, time.Now())
+
+	assert.ErrorIs(t, err, domain.ErrTribePoolNotPermitted)
+}
+
+func TestTribePoolService_OpenPool_PastMonthAndDuplicate(t *testing.T) {
+	poolRepo := new(MockTribePoolRepository)
+	tribeRepo := new(MockTribeRepository)
+	service := NewTribePoolService(poolRepo, tribeRepo, new(MockFastingRepository), allowAllEntitlements(), collectAllStakes())
+	ctx := context.Background()
+	tribeID := uuid.New().String()
+	userID := uuid.New().String()
+
+	tribeRepo.On("FindByID", ctx, tribeID).Return(&domain.Tribe{ID: tribeID}, nil)
+	tribeRepo.On("FindMembership", ctx, tribeID, userID).Return(&domain.TribeMembership{Role: "moderator", Status: "active"}, nil)
+	poolRepo.On("CreatePool", ctx, mock.Anything).Return(false, nil)
+
+	_, err := service.OpenPool(ctx, tribeID, userID, time.Now().AddDate(0, -1, 0))
+	assert.Error(t, err)
+
+	_, err = service.OpenPool(ctx, tribeID, userID, time.Now())
+	assert.ErrorIs(t, err, domain.ErrTribePoolExists)
+}
+
+func TestTribePoolService_JoinPool_Success(t *testing.T) {
+	poolRepo := new(MockTribePoolRepository)
+	tribeRepo := new(MockTribeRepository)
+	service := NewTribePoolService(poolRepo, tribeRepo, new(MockFastingRepository), allowAllEntitlements(), collectAllStakes())
+	ctx := context.Background()
+	tribeID := uuid.New()
+	userID := uuid.New()
+	month := domain.PoolMonth(time.Now()).AddDate(0, 1, 0)
+	pool := &domain.TribePool{ID: uuid.New(), TribeID: tribeID, MonthStart: month, Status: domain.TribePoolStatusOpen}
+
+	tribeRepo.On("FindMembership", ctx, tribeID.String(), userID.String()).Return(&domain.TribeMembership{Role: "member", Status: "active"}, nil)
+	poolRepo.On("FindPoolByMonth", ctx, tribeID, month).Return(pool, nil)
+	poolRepo.On("ListParticipants", ctx, pool.ID).Return([]*domain.TribePoolParticipant{}, nil).Once()
+	poolRepo.On("AddParticipant", ctx, mock.MatchedBy(func(p *domain.TribePoolParticipant) bool {
+		return p.PoolID == pool.ID && p.UserID == userID && p.DepositAmount == domain.TribePoolDeposit && p.DepositChargeID == "ch_stake"
+	})).Return(true, nil).Once()
+	poolRepo.On("ListParticipants", ctx, pool.ID).Return([]*domain.TribePoolParticipant{{PoolID: pool.ID, UserID: userID}}, nil).Once()
+
+	participant, err := service.JoinPool(ctx, tribeID.String(), userID.String(), month)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.00, participant.DepositAmount)
+
+	// Already joined: refused before charging again
+	_, err = service.JoinPool(ctx, tribeID.String(), userID.String(), month)
+	assert.ErrorIs(t, err, domain.ErrAlreadyInTribePool)
+	poolRepo.AssertNumberOfCalls(t, "AddParticipant", 1)
+}
+
+func TestTribePoolService_JoinPool_StakeNotCharged(t *testing.T) {
+	poolRepo := new(MockTribePoolRepository)
+	tribeRepo := new(MockTribeRepository)
+	vault := new(MockVaultService)
+	service := NewTribePoolService(poolRepo, tribeRepo, new(MockFastingRepository), allowAllEntitlements(), vault)
+	ctx := context.Background()
+	tribeID := uuid.New()
+	userID := uuid.New()
+	month := domain.PoolMonth(time.Now()).AddDate(0, 1, 0)
+	pool := &domain.TribePool{ID: uuid.New(), TribeID: tribeID, MonthStart: month, Status: domain.TribePoolStatusOpen}
+
+	tribeRepo.On("FindMembership", ctx, tribeID.String(), userID.String()).Return(&domain.TribeMembership{Role: "member", Status: "active"}, nil)
+	poolRepo.On("FindPoolByMonth", ctx, tribeID, month).Return(pool, nil)
+	poolRepo.On("ListParticipants", ctx, pool.ID).Return([]*domain.TribePoolParticipant{}, nil)
+	vault.On("CollectStake", ctx, userID, domain.TribePoolDeposit, mock.Anything, mock.Anything).Return("", domain.ErrStakeNotCharged)
+
+	_, err := service.JoinPool(ctx, tribeID.String(), userID.String(), month)
+
+	assert.ErrorIs(t, err, domain.ErrStakeNotCharged)
+	poolRepo.AssertNotCalled(t, "AddParticipant", mock.Anything, mock.Anything)
+}
+
+func TestTribePoolService_JoinPool_ConcurrentJoinReturnsDeposit(t *testing.T) {
+	poolRepo := new(MockTribePoolRepository)
+	tribeRepo := new(MockTribeRepository)
+	vault := new(MockVaultService)
+	service := NewTribePoolService(poolRepo, tribeRepo, new(MockFastingRepository), allowAllEntitlements(), vault)
+	ctx := context.Background()
+	tribeID := uuid.New()
+	userID := uuid.New()
+	month := domain.PoolMonth(time.Now()).AddDate(0, 1, 0)
+	pool := &domain.TribePool{ID: uuid.New(), TribeID: tribeID, MonthStart: month, Status: domain.TribePoolStatusOpen}
+
+	tribeRepo.On("FindMembership", ctx, tribeID.String(), userID.String()).Return(&domain.TribeMembership{Role: "member", Status: "active"}, nil)
+	poolRepo.On("FindPoolByMonth", ctx, tribeID, month).Return(pool, nil)
+	poolRepo.On("ListParticipants", ctx, pool.ID).Return([]*domain.TribePoolParticipant{}, nil)
+	poolRepo.On("AddParticipant", ctx, mock.Anything).Return(false, nil)
+	vault.On("CollectStake", ctx, userID, domain.TribePoolDeposit, mock.Anything, mock.Anything).Return("ch_stake", nil)
+	vault.On("PayOutStake", ctx, userID, mock.MatchedBy(func(p domain.StakePayout) bool {
+		return p.ChargeID == "ch_stake" && p.Amount == domain.TribePoolDeposit
+	})).Return(nil).Once()
+
+	_, err := service.JoinPool(ctx, tribeID.String(), userID.String(), month)
+
+	assert.ErrorIs(t, err, domain.ErrAlreadyInTribePool)
+	vault.AssertExpectations(t)
+}
+
+func TestTribePoolService_JoinPool_RequiresGroupPots(t *testing.T) {
+	entitlements := new(MockEntitlementService)
+	service := NewTribePoolService(new(MockTribePoolRepository), new(MockTribeRepository), new(MockFastingRepository), entitlements, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	entitlements.On("Require", ctx, userID, domain.CapabilityGroupPots).
+		Return(&domain.EntitlementError{Capability: domain.CapabilityGroupPots})
+
+	_, err := service.JoinPool(ctx, uuid.New().String(), userID.String(), time.Now())
+
+	assert.ErrorIs(t, err, domain.ErrCapabilityRequired)
+}
+
+func TestTribePoolService_JoinPool_NotOpen(t *testing.T) {
+	poolRepo := new(MockTribePoolRepository)
+	tribeRepo := new(MockTribeRepository)
+	service := NewTribePoolService(poolRepo, tribeRepo, new(MockFastingRepository), allowAllEntitlements(), collectAllStakes())
+	ctx := context.Background()
+	tribeID := uuid.New()
+	userID := uuid.New()
+	month := domain.PoolMonth(time.Now())
+
+	tribeRepo.On("FindMembership", ctx, tribeID.String(), userID.String()).Return(&domain.TribeMembership{Status: "active"}, nil)
+	poolRepo.On("FindPoolByMonth", ctx, tribeID, month).
+		Return(&domain.TribePool{ID: uuid.New(), MonthStart: month, Status: domain.TribePoolStatusActive}, nil)
+
+	_, err := service.JoinPool(ctx, tribeID.String(), userID.String(), month)
+
+	assert.ErrorIs(t, err, domain.ErrTribePoolNotOpen)
+	poolRepo.AssertNotCalled(t, "AddParticipant", mock.Anything, mock.Anything)
+}
+
+func TestTribePoolService_JoinPool_AfterMonthStarted(t *testing.T) {
+	poolRepo := new(MockTribePoolRepository)
+	tribeRepo := new(MockTribeRepository)
+	service := NewTribePoolService(poolRepo, tribeRepo, new(MockFastingRepository), allowAllEntitlements(), collectAllStakes())
+	ctx := context.Background()
+	tribeID := uuid.New()
+	userID := uuid.New()
+	month := domain.PoolMonth(time.Now())
+
+	// The daily job hasn't activated the pool yet, but the month has started
+	tribeRepo.On("FindMembership", ctx, tribeID.String(), userID.String()).Return(&domain.TribeMembership{Status: "active"}, nil)
+	poolRepo.On("FindPoolByMonth", ctx, tribeID, month).
+		Return(&domain.TribePool{ID: uuid.New(), MonthStart: month, Status: domain.TribePoolStatusOpen}, nil)
+
+	_, err := service.JoinPool(ctx, tribeID.String(), userID.String(), month)
+
+	assert.ErrorIs(t, err, domain.ErrTribePoolNotOpen)
+	poolRepo.AssertNotCalled(t, "AddParticipant", mock.Anything, mock.Anything)
+}
+
+func TestTribePoolService_JoinPool_NotMember(t *testing.T) {
+	tribeRepo := new(MockTribeRepository)
+	service := NewTribePoolService(new(MockTribePoolRepository), tribeRepo, new(MockFastingRepository), allowAllEntitlements(), collectAllStakes())
+	ctx := context.Background()
+	tribeID := uuid.New().String()
+	userID := uuid.New().String()
+
+	tribeRepo.On("FindMembership", ctx, tribeID, userID).Return(nil, nil)
+
+	_, err := service.JoinPool(ctx, tribeID, userID, time.Now())
+
+	assert.ErrorIs(t, err, domain.ErrNotTribeMember)
+}
+
+func TestTribePoolService_SettleEndedPools_RanksAndSplitsPot(t *testing.T) {
+	poolRepo := new(MockTribePoolRepository)
+	fastingRepo := new(MockFastingRepository)
+	vault := new(MockVaultService)
+	service := NewTribePoolService(poolRepo, new(MockTribeRepository), fastingRepo, allowAllEntitlements(), vault)
+	ctx := context.Background()
+
+	month := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	pool := &domain.TribePool{ID: uuid.New(), MonthStart: month, Status: domain.TribePoolStatusActive, TotalPot: 80}
+	joined := month.AddDate(0, 0, -5)
+	leader := &domain.TribePoolParticipant{ID: uuid.New(), PoolID: pool.ID, UserID: uuid.New(), DepositAmount: 20, DepositChargeID: "ch_leader", CreatedAt: joined}
+	tiedA := &domain.TribePoolParticipant{ID: uuid.New(), PoolID: pool.ID, UserID: uuid.New(), DepositAmount: 20, DepositChargeID: "ch_tied_a", CreatedAt: joined.Add(time.Hour)}
+	tiedB := &domain.TribePoolParticipant{ID: uuid.New(), PoolID: pool.ID, UserID: uuid.New(), DepositAmount: 20, DepositChargeID: "ch_tied_b", CreatedAt: joined.Add(2 * time.Hour)}
+	idle := &domain.TribePoolParticipant{ID: uuid.New(), PoolID: pool.ID, UserID: uuid.New(), DepositAmount: 20, DepositChargeID: "ch_idle", CreatedAt: joined}
+
+	inMonth := month.AddDate(0, 0, 10)
+	missedGoal := completedFast(inMonth, 16)
+	missedGoal.GoalHours = 18
+	fastingRepo.On("FindByUserID", ctx, leader.UserID).Return([]domain.FastingSession{
+		completedFast(inMonth, 16), completedFast(inMonth.AddDate(0, 0, 1), 16), completedFast(inMonth.AddDate(0, 0, 2), 18),
+	}, nil)
+	fastingRepo.On("FindByUserID", ctx, tiedA.UserID).Return([]domain.FastingSession{
+		completedFast(inMonth, 16), completedFast(month.AddDate(0, 0, -1), 16), missedGoal,
+	}, nil)
+	fastingRepo.On("FindByUserID", ctx, tiedB.UserID).Return([]domain.FastingSession{
+		completedFast(inMonth, 16), completedFast(month.AddDate(0, 1, 0), 16),
+	}, nil)
+	fastingRepo.On("FindByUserID", ctx, idle.UserID).Return([]domain.FastingSession{}, nil)
+
+	poolRepo.On("ListPoolsByStatus", ctx, domain.TribePoolStatusOpen).Return([]*domain.TribePool{}, nil)
+	poolRepo.On("ListPoolsByStatus", ctx, domain.TribePoolStatusActive).Return([]*domain.TribePool{pool}, nil)
+	poolRepo.On("ListParticipants", ctx, pool.ID).Return([]*domain.TribePoolParticipant{idle, tiedB, leader, tiedA}, nil)
+	poolRepo.On("UpdateParticipant", ctx, mock.Anything).Return(nil)
+	poolRepo.On("UpdatePool", ctx, pool).Return(nil)
+	for _, paid := range []struct {
+		participant *domain.TribePoolParticipant
+		rank        int
+		amount      float64
+	}{{leader, 1, 40}, {tiedA, 2, 20}, {tiedB, 2, 20}} {
+		vault.On("PayOutStake", ctx, paid.participant.UserID, domain.StakePayout{
+			SourceType: domain.LedgerSourceTribePool,
+			SourceID:   paid.participant.ID.String(),
+			ChargeID:   paid.participant.DepositChargeID,
+			Stake:      20,
+			Amount:     paid.amount,
+			Reason:     fmt.Sprintf("Tribe pool winnings for September 2026 (rank %d)", paid.rank),
+		}).Return(nil).Once()
+	}
+
+	settled, err := service.SettleEndedPools(ctx, month.AddDate(0, 1, 1))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, settled)
+	assert.Equal(t, domain.TribePoolStatusCompleted, pool.Status)
+	assert.Equal(t, 40.00, pool.FirstPlacePayout)
+	assert.Equal(t, 24.00, pool.SecondPlacePayout)
+	assert.Equal(t, 16.00, pool.ThirdPlacePayout)
+
+	assert.Equal(t, 3, leader.FastsCompleted)
+	assert.Equal(t, 1, leader.FinalRank)
+	assert.Equal(t, 40.00, leader.PayoutAmount)
+	assert.Equal(t, 1, tiedA.FastsCompleted)
+	assert.Equal(t, 2, tiedA.FinalRank)
+	assert.Equal(t, 20.00, tiedA.PayoutAmount)
+	assert.Equal(t, 2, tiedB.FinalRank)
+	assert.Equal(t, 20.00, tiedB.PayoutAmount)
+	assert.Equal(t, 4, idle.FinalRank)
+	assert.Equal(t, 0.0, idle.PayoutAmount)
+	for _, p := range []*domain.TribePoolParticipant{leader, tiedA, tiedB, idle} {
+		assert.True(t, p.PayoutProcessed)
+	}
+	vault.AssertExpectations(t)
+	vault.AssertNotCalled(t, "PayOutStake", ctx, idle.UserID, mock.Anything)
+}
+
+func TestTribePoolService_SettleEndedPools_SkipsRunningMonth(t *testing.T) {
+	poolRepo := new(MockTribePoolRepository)
+	service := NewTribePoolService(poolRepo, new(MockTribeRepository), new(MockFastingRepository), allowAllEntitlements(), collectAllStakes())
+	ctx := context.Background()
+	month := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	poolRepo.On("ListPoolsByStatus", ctx, domain.TribePoolStatusOpen).Return([]*domain.TribePool{}, nil)
+	poolRepo.On("ListPoolsByStatus", ctx, domain.TribePoolStatusActive).
+		Return([]*domain.TribePool{{ID: uuid.New(), MonthStart: month, Status: domain.TribePoolStatusActive}}, nil)
+
+	settled, err := service.SettleEndedPools(ctx, month.AddDate(0, 0, 29))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, settled)
+	poolRepo.AssertNotCalled(t, "ListParticipants", mock.Anything, mock.Anything)
+}
+
+func TestTribePoolService_ActivateStartedPools(t *testing.T) {
+	poolRepo := new(MockTribePoolRepository)
+	service := NewTribePoolService(poolRepo, new(MockTribeRepository), new(MockFastingRepository), allowAllEntitlements(), collectAllStakes())
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 0, 10, 0, 0, time.UTC)
+	started := &domain.TribePool{ID: uuid.New(), MonthStart: domain.PoolMonth(now), Status: domain.TribePoolStatusOpen}
+	upcoming := &domain.TribePool{ID: uuid.New(), MonthStart: domain.PoolMonth(now).AddDate(0, 1, 0), Status: domain.TribePoolStatusOpen}
+
+	poolRepo.On("ListPoolsByStatus", ctx, domain.TribePoolStatusOpen).Return([]*domain.TribePool{started, upcoming}, nil)
+	poolRepo.On("UpdatePool", ctx, started).Return(nil)
+
+	activated, err := service.ActivateStartedPools(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, activated)
+	assert.Equal(t, domain.TribePoolStatusActive, started.Status)
+	assert.Equal(t, domain.TribePoolStatusOpen, upcoming.Status)
+}
+
+func TestRankTribePool_PotRules(t *testing.T) {
+	t.Run("fewer winners than places share the whole pot", func(t *testing.T) {
+		pool := &domain.TribePool{TotalPot: 60}
+		first := &domain.TribePoolParticipant{FastsCompleted: 4, DepositAmount: 20}
+		second := &domain.TribePoolParticipant{FastsCompleted: 2, DepositAmount: 20}
+		none := &domain.TribePoolParticipant{FastsCompleted: 0, DepositAmount: 20}
+
+		domain.RankTribePool(pool, []*domain.TribePoolParticipant{none, second, first})
+
+		assert.Equal(t, 37.50, first.PayoutAmount)
+		assert.Equal(t, 22.50, second.PayoutAmount)
+		assert.Equal(t, 0.0, none.PayoutAmount)
+		assert.Equal(t, 0.0, pool.ThirdPlacePayout)
+	})
+
+	t.Run("deposits are returned when nobody fasted", func(t *testing.T) {
+		pool := &domain.TribePool{TotalPot: 40}
+		a := &domain.TribePoolParticipant{DepositAmount: 20}
+		b := &domain.TribePoolParticipant{DepositAmount: 20}
+
+		domain.RankTribePool(pool, []*domain.TribePoolParticipant{a, b})
+
+		assert.Equal(t, 20.00, a.PayoutAmount)
+		assert.Equal(t, 20.00, b.PayoutAmount)
+		assert.Equal(t, 1, a.FinalRank)
+		assert.Equal(t, 1, b.FinalRank)
+	})
+
+	t.Run("odd cents go to the earliest joiner", func(t *testing.T) {
+		pool := &domain.TribePool{TotalPot: 20.03}
+		now := time.Now()
+		early := &domain.TribePoolParticipant{FastsCompleted: 1, CreatedAt: now}
+		late := &domain.TribePoolParticipant{FastsCompleted: 1, CreatedAt: now.Add(time.Minute)}
+
+		domain.RankTribePool(pool, []*domain.TribePoolParticipant{late, early})
+
+		assert.Equal(t, 10.02, early.PayoutAmount)
+		assert.Equal(t, 10.01, late.PayoutAmount)
+	})
+}
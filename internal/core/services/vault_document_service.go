@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fastinghero/internal/core/domain"
+	"fastinghero/internal/core/ports"
+	"fastinghero/pkg/logger"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VaultDocumentService builds monthly vault statements from the ledger, the month's
+// participation and the payment history, and payment receipts, and renders them for download
+type VaultDocumentService struct {
+	vaultService ports.VaultService
+	vaultRepo    ports.VaultRepository
+	paymentRepo  ports.PaymentRepository
+	userRepo     ports.UserRepository
+	renderer     ports.DocumentRenderer
+}
+
+func NewVaultDocumentService(vaultService ports.VaultService, vaultRepo ports.VaultRepository, paymentRepo ports.PaymentRepository, userRepo ports.UserRepository, renderer ports.DocumentRenderer) *VaultDocumentService {
+	return &VaultDocumentService{
+		vaultService: vaultService,
+		vaultRepo:    vaultRepo,
+		paymentRepo:  paymentRepo,
+		userRepo:     userRepo,
+		renderer:     renderer,
+	}
+}
+
+// ListPayments returns the user's payments and refunds made in [from, to)
+func (s *VaultDocumentService) ListPayments(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.Payment, error) {
+	payments, err := s.paymentRepo.ListByUserID(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if payments == nil {
+		payments = []domain.Payment{}
+	}
+	return payments, nil
+}
+
+// GetStatementDocument lays out the user's statement for the month containing monthStart
+func (s *VaultDocumentService) GetStatementDocument(ctx context.Context, userID uuid.UUID, monthStart time.Time) (*domain.Document, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := time.Date(monthStart.Year(), monthStart.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	statement, err := s.vaultService.GetStatement(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	participation, err := s.vaultRepo.FindByUserIDAndMonth(ctx, userID, from)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.paymentRepo.ListByUserID(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewVaultStatementDocument(user, statement, participation, payments, time.Now().UTC()), nil
+}
+
+// RenderStatement renders the user's statement for the month containing monthStart
+func (s *VaultDocumentService) RenderStatement(ctx context.Context, userID uuid.UUID, monthStart time.Time, format domain.DocumentFormat) (*domain.RenderedDocument, error) {
+	if !format.IsValid() {
+		return nil, domain.ErrUnsupportedDocumentFormat
+	}
+	doc, err := s.GetStatementDocument(ctx, userID, monthStart)
+	if err != nil {
+		return nil, err
+	}
+	return s.render(doc, format)
+}
+
+// RenderReceipt renders the receipt for one of the user's payments
+func (s *VaultDocumentService) RenderReceipt(ctx context.Context, userID, paymentID uuid.UUID, format domain.DocumentFormat) (*domain.RenderedDocument, error) {
+	if !format.IsValid() {
+		return nil, domain.ErrUnsupportedDocumentFormat
+	}
+	payment, err := s.paymentRepo.FindByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil || payment.UserID != userID {
+		return nil, domain.ErrPaymentNotFound
+	}
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.render(domain.NewReceiptDocument(user, payment, time.Now().UTC()), format)
+}
+
+func (s *VaultDocumentService) render(doc *domain.Document, format domain.DocumentFormat) (*domain.RenderedDocument, error) {
+	body, err := s.renderer.Render(doc, format)
+	if err != nil {
+		logger.Error().Err(err).Str("document_id", doc.ID).Str("format", string(format)).Msg("Failed to render document")
+		return nil, fmt.Errorf("failed to render document %s: %w", doc.ID, err)
+	}
+	return &domain.RenderedDocument{
+		ID:          doc.ID,
+		Filename:    doc.ID + "." + string(format),
+		ContentType: format.ContentType(),
+		Body:        body,
+	}, nil
+}
+
+func (s *VaultDocumentService) findUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
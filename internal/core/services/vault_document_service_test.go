@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"fastinghero/internal/core/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mock PaymentRepository ---
+
+type MockPaymentRepository struct {
+	mock.Mock
+}
+
+func (m *MockPaymentRepository) Record(ctx context.Context, payment *domain.Payment) (bool, error) {
+	args := m.Called(ctx, payment)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPaymentRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) ListByUserID(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]domain.Payment, error) {
+	args := m.Called(ctx, userID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Payment), args.Error(1)
+}
+
+// --- Mock DocumentRenderer ---
+
+type MockDocumentRenderer struct {
+	mock.Mock
+}
+
+func (m *MockDocumentRenderer) Render(doc *domain.Document, format domain.DocumentFormat) ([]byte, error) {
+	args := m.Called(doc, format)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+type vaultDocumentFixture struct {
+	vault     *MockVaultService
+	vaultRepo *MockVaultRepository
+	payments  *MockPaymentRepository
+	users     *MockUserRepository
+	renderer  *MockDocumentRenderer
+	service   *VaultDocumentService
+}
+
+func newVaultDocumentFixture() *vaultDocumentFixture {
+	f := &vaultDocumentFixture{
+		vault:     new(MockVaultService),
+		vaultRepo: new(MockVaultRepository),
+		payments:  new(MockPaymentRepository),
+		users:     new(MockUserRepository),
+		renderer:  new(MockDocumentRenderer),
+	}
+	f.service = NewVaultDocumentService(f.vault, f.vaultRepo, f.payments, f.users, f.renderer)
+	return f
+}
+
+func TestVaultDocumentService_GetStatementDocument(t *testing.T) {
+	f := newVaultDocumentFixture()
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Email: "ada@example.com", Name: "Ada"}
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	deposit, _ := domain.NewVaultLedgerEntry(user.ID, domain.LedgerEntryDeposit, 20, "Vault deposit for March", domain.LedgerSourceParticipation, "p1", from)
+	earning, _ := domain.NewVaultLedgerEntry(user.ID, domain.LedgerEntryDailyEarning, 1.5, "Daily earnings for 2026-03-02", domain.LedgerSourceParticipation, "p1:2026-03-02", from.AddDate(0, 0, 1))
+	forfeit, _ := domain.NewVaultLedgerEntry(user.ID, domain.LedgerEntryForfeit, 18.5, "Unearned deposit", domain.LedgerSourceParticipation, "p1", to.Add(-time.Second))
+	refund, _ := domain.NewVaultLedgerEntry(user.ID, domain.LedgerEntryRefund, 1.5, "Vault refund", domain.LedgerSourceParticipation, "p1", to.Add(-time.Second))
+	statement := &domain.VaultStatement{
+		UserID:         user.ID,
+		PeriodStart:    from,
+		PeriodEnd:      to,
+		ClosingBalance: domain.VaultBalance{Deposited: 20, Forfeited: 18.5, Refunded: 1.5},
+		Entries:        []domain.VaultLedgerEntry{*deposit, *earning, *forfeit, *refund},
+	}
+	payments := []domain.Payment{
+		{ID: uuid.New(), UserID: user.ID, Kind: domain.PaymentKindCharge, Amount: 30, Currency: "usd", ProviderID: "in_1", Reference: "FH-0001", Description: "FastingHero vault subscription", PaidAt: from},
+		{ID: uuid.New(), UserID: user.ID, Kind: domain.PaymentKindRefund, Amount: 1.5, Currency: "usd", ProviderID: "re_1", Description: "Refund to card", PaidAt: to.Add(-time.Hour)},
+	}
+
+	f.users.On("FindByID", ctx, user.ID).Return(user, nil)
+	f.vault.On("GetStatement", ctx, user.ID, from, to).Return(statement, nil)
+	f.vaultRepo.On("FindByUserIDAndMonth", ctx, user.ID, from).Return(&domain.VaultParticipation{UserID: user.ID, FastsCompleted: 3}, nil)
+	f.payments.On("ListByUserID", ctx, user.ID, from, to).Return(payments, nil)
+
+	doc, err := f.service.GetStatementDocument(ctx, user.ID, from.AddDate(0, 0, 14))
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.VaultStatementDocumentID(user.ID, from), doc.ID)
+	assert.Regexp(t, `^VS-202603-[0-9A-F]{10}$`, doc.ID)
+	assert.Equal(t, "March 2026", doc.Period)
+	assert.Equal(t, "Ada <ada@example.com>", doc.IssuedTo)
+	assert.Contains(t, doc.Summary, domain.DocumentLine{Label: "Earned back", Value: "$1.50"})
+	assert.Contains(t, doc.Summary, domain.DocumentLine{Label: "Forfeited", Value: "$18.50"})
+	assert.Contains(t, doc.Summary, domain.DocumentLine{Label: "Fasts completed", Value: "3"})
+	assert.Len(t, doc.Sections, 2)
+	assert.Len(t, doc.Sections[0].Rows, 4)
+	assert.Equal(t, []string{"2 Mar 2026", "Earned back", "Daily earnings for 2026-03-02", "$1.50"}, doc.Sections[0].Rows[1])
+	assert.Equal(t, []string{"1 Mar 2026", "FH-0001", "FastingHero vault subscription", "$30.00"}, doc.Sections[1].Rows[0])
+	assert.Equal(t, "-$1.50", doc.Sections[1].Rows[1][3])
+}
+
+func TestVaultDocumentService_RenderStatement(t *testing.T) {
+	f := newVaultDocumentFixture()
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Email: "ada@example.com"}
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	docID := domain.VaultStatementDocumentID(user.ID, from)
+
+	f.users.On("FindByID", ctx, user.ID).Return(user, nil)
+	f.vault.On("GetStatement", ctx, user.ID, from, to).Return(&domain.VaultStatement{UserID: user.ID, PeriodStart: from, PeriodEnd: to}, nil)
+	f.vaultRepo.On("FindByUserIDAndMonth", ctx, user.ID, from).Return(nil, nil)
+	f.payments.On("ListByUserID", ctx, user.ID, from, to).Return(nil, nil)
+	f.renderer.On("Render", mock.MatchedBy(func(doc *domain.Document) bool { return doc.ID == docID }), domain.DocumentFormatPDF).Return([]byte("%PDF-1.4"), nil)
+
+	rendered, err := f.service.RenderStatement(ctx, user.ID, from, domain.DocumentFormatPDF)
+
+	assert.NoError(t, err)
+	assert.Equal(t, docID, rendered.ID)
+	assert.Equal(t, docID+".pdf", rendered.Filename)
+	assert.Equal(t, "application/pdf", rendered.ContentType)
+	assert.Equal(t, []byte("%PDF-1.4"), rendered.Body)
+
+	_, err = f.service.RenderStatement(ctx, user.ID, from, "docx")
+	assert.ErrorIs(t, err, domain.ErrUnsupportedDocumentFormat)
+}
+
+func TestVaultDocumentService_RenderReceipt(t *testing.T) {
+	f := newVaultDocumentFixture()
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Email: "ada@example.com"}
+	payment := &domain.Payment{ID: uuid.New(), UserID: user.ID, Kind: domain.PaymentKindCharge, Amount: 30, Currency: "usd", ProviderID: "in_1", PaidAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	other := &domain.Payment{ID: uuid.New(), UserID: uuid.New(), Kind: domain.PaymentKindCharge, Amount: 30, ProviderID: "in_2"}
+
+	f.payments.On("FindByID", ctx, payment.ID).Return(payment, nil)
+	f.payments.On("FindByID", ctx, other.ID).Return(other, nil)
+	f.users.On("FindByID", ctx, user.ID).Return(user, nil)
+	f.renderer.On("Render", mock.MatchedBy(func(doc *domain.Document) bool {
+		return doc.Kind == domain.DocumentKindReceipt && doc.Summary[0].Value == "$30.00"
+	}), domain.DocumentFormatHTML).Return([]byte("<html>"), nil)
+
+	rendered, err := f.service.RenderReceipt(ctx, user.ID, payment.ID, domain.DocumentFormatHTML)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ReceiptDocumentID(payment), rendered.ID)
+	assert.Regexp(t, `^RC-20260301-[0-9A-F]{10}$`, rendered.ID)
+
+	_, err = f.service.RenderReceipt(ctx, user.ID, other.ID, domain.DocumentFormatHTML)
+	assert.ErrorIs(t, err, domain.ErrPaymentNotFound)
+}
+
+func TestStripeService_HandleWebhook_RecordsPaymentHistory(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	subRepo := new(MockSubscriptionRepository)
+	userRepo := new(MockUserRepository)
+	payments := new(MockPaymentRepository)
+	ctx := context.Background()
+
+	svc := NewStripeService(pg, subRepo, userRepo, unprocessedWebhookEvents(), nil, testBillingConfig, nil, nil, payments)
+
+	userID := uuid.New()
+	sub := &domain.Subscription{ID: uuid.New(), UserID: userID, StripeSubscriptionID: "sub_test123", PlanType: "vault", Status: domain.SubStatusActive}
+	user := &domain.User{ID: userID, StripeCustomerID: "cus_1", SubscriptionID: sub.ID.String(), SubscriptionTier: domain.TierVault, SubscriptionStatus: domain.SubStatusActive}
+
+	invoice := stripeEvent("evt_9", "invoice.paid", `{"id":"in_1","number":"FH-0001","subscription":"sub_test123","amount_paid":3000,"currency":"usd","status_transitions":{"paid_at":1772355600}}`)
+	refunded := stripeEvent("evt_10", "charge.refunded", `{"id":"ch_1","customer":"cus_1","amount_refunded":250,"currency":"usd","refunds":{"data":[{"id":"re_1","amount":100,"currency":"usd","created":1772442000},{"id":"re_2","amount":150,"currency":"usd","created":1772528400}]}}`)
+	pg.On("ConstructEvent", []byte("invoice"), "sig").Return(invoice, nil)
+	pg.On("ConstructEvent", []byte("refund"), "sig").Return(refunded, nil)
+	subRepo.On("FindByStripeSubscriptionID", ctx, "sub_test123").Return(sub, nil)
+	subRepo.On("Save", ctx, sub).Return(nil)
+	userRepo.On("FindByID", ctx, userID).Return(user, nil)
+	userRepo.On("Save", ctx, user).Return(nil)
+	userRepo.On("FindByStripeCustomerID", ctx, "cus_1").Return(user, nil)
+	payments.On("Record", ctx, mock.MatchedBy(func(p *domain.Payment) bool {
+		return p.Kind == domain.PaymentKindCharge && p.ProviderID == "in_1" && p.Amount == 30 && p.Reference == "FH-0001" &&
+			p.PaidAt.Equal(time.Unix(1772355600, 0)) && p.UserID == userID
+	})).Return(true, nil).Once()
+	payments.On("Record", ctx, mock.MatchedBy(func(p *domain.Payment) bool {
+		return p.Kind == domain.PaymentKindRefund && p.ProviderID == "re_1" && p.Amount == 1
+	})).Return(true, nil).Once()
+	payments.On("Record", ctx, mock.MatchedBy(func(p *domain.Payment) bool {
+		return p.Kind == domain.PaymentKindRefund && p.ProviderID == "re_2" && p.Amount == 1.5
+	})).Return(false, nil).Once()
+
+	assert.NoError(t, svc.HandleWebhook(ctx, []byte("invoice"), "sig"))
+	assert.NoError(t, svc.HandleWebhook(ctx, []byte("refund"), "sig"))
+	payments.AssertExpectations(t)
+}
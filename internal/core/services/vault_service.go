@@ -2,8 +2,12 @@ package services
 
 import (
 	"context"
+	"encoding/csv"
 	"fastinghero/internal/core/domain"
 	"fastinghero/internal/core/ports"
+	"fmt"
+	"io"
+	"log"
 	"math"
 	"time"
 
@@ -24,21 +28,29 @@ const (
 	DailyMax      = 2.0  // Deprecated: vault daily earning cap
 )
 
+// dailySettlementBatchSize is how many vault members are loaded per page by the daily job
+const dailySettlementBatchSize = 100
+
 type VaultService struct {
 	userRepo       ports.UserRepository
 	vaultRepo      ports.VaultRepository
+	ledgerRepo     ports.VaultLedgerRepository
+	earningRules   ports.EarningRulesEngine
 	paymentGateway ports.PaymentGateway
 }
 
-func NewVaultService(userRepo ports.UserRepository, vaultRepo ports.VaultRepository, paymentGateway ports.PaymentGateway) *VaultService {
+func NewVaultService(userRepo ports.UserRepository, vaultRepo ports.VaultRepository, ledgerRepo ports.VaultLedgerRepository, earningRules ports.EarningRulesEngine, paymentGateway ports.PaymentGateway) *VaultService {
 	return &VaultService{
 		userRepo:       userRepo,
 		vaultRepo:      vaultRepo,
+		ledgerRepo:     ledgerRepo,
+		earningRules:   earningRules,
 		paymentGateway: paymentGateway,
 	}
 }
 
-// CalculateDailyEarning calculates how much a user earns based on their discipline score
+// CalculateDailyEarning calculates how much a user earns based on their discipline score.
+// Deprecated: daily settlement uses the earning rules engine instead.
 func (s *VaultService) CalculateDailyEarning(disciplineIndex int) float64 {
 	// Formula: $2.00 * (DisciplineIndex / 100)
 	earning := DailyMax * (float64(disciplineIndex) / 100.0)
@@ -48,15 +60,104 @@ func (s *VaultService) CalculateDailyEarning(disciplineIndex int) float64 {
 	return earning
 }
 
-// ProcessDailyEarnings iterates over all users and updates their earnings
+// ProcessDailyEarnings settles vault earnings for the previous calendar day (UTC).
+// It is wired to the @daily cron job.
 func (s *VaultService) ProcessDailyEarnings(ctx context.Context) error {
-	// In a real app, we would stream users or process in batches
-	// For MVP, we'll just fetch all users (assuming low volume)
-	// TODO: Add GetAllUsers to UserRepository
-	return nil
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	_, err := s.ProcessDailyEarningsForDay(ctx, yesterday)
+	return err
+}
+
+// ProcessDailyEarningsForDay pages through all vault members and credits each one's
+// earnings for the given calendar day. A settlement marker is claimed per user and day
+// before anything is credited, so re-runs and overlapping instances skip users that are
+// already settled. Returns the number of users settled by this run.
+func (s *VaultService) ProcessDailyEarningsForDay(ctx context.Context, day time.Time) (int, error) {
+	dayStart := startOfDay(day)
+	settled := 0
+	failed := 0
+
+	afterID := uuid.Nil
+	for {
+		users, err := s.userRepo.ListVaultMembers(ctx, afterID, dailySettlementBatchSize)
+		if err != nil {
+			return settled, fmt.Errorf("failed to list vault members: %w", err)
+		}
+
+		for _, user := range users {
+			ok, err := s.settleUserDay(ctx, user, dayStart)
+			if err != nil {
+				// One bad user must not block everyone else's settlement
+				log.Printf("vault: failed to settle %s for user %s: %v", dayStart.Format("2006-01-02"), user.ID, err)
+				failed++
+				continue
+			}
+			if ok {
+				settled++
+			}
+		}
+
+		if len(users) < dailySettlementBatchSize {
+			break
+		}
+		afterID = users[len(users)-1].ID
+	}
+
+	if failed > 0 {
+		return settled, fmt.Errorf("daily settlement for %s failed for %d users", dayStart.Format("2006-01-02"), failed)
+	}
+	return settled, nil
+}
+
+// settleUserDay claims the settlement marker for the user and day and credits what the
+// earning rules awarded. Returns false if the day was already settled.
+func (s *VaultService) settleUserDay(ctx context.Context, user *domain.User, dayStart time.Time) (bool, error) {
+	earnings, err := s.earningRules.EvaluateDay(ctx, user.ID, dayStart)
+	if err != nil {
+		return false, err
+	}
+
+	settlement := &domain.VaultDailySettlement{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		SettlementDate: dayStart,
+		Amount:         earnings.Total(),
+		RuleVersion:    earnings.RuleVersion,
+		CreatedAt:      time.Now(),
+	}
+	claimed, err := s.vaultRepo.ClaimDailySettlement(ctx, settlement)
+	if err != nil {
+		return false, err
+	}
+	if !claimed {
+		return false, nil
+	}
+
+	if settlement.Amount > 0 {
+		var credits []ledgerCredit
+		for _, item := range earnings.Items {
+			credits = append(credits, ledgerCredit{domain.LedgerEntryDailyEarning, item})
+		}
+		for _, item := range earnings.Bonuses {
+			credits = append(credits, ledgerCredit{domain.LedgerEntryStreakBonus, item})
+		}
+		if err := s.creditEarning(ctx, user, dayStart, credits...); err != nil {
+			// Give the day back so the next run can retry it
+			if releaseErr := s.vaultRepo.ReleaseDailySettlement(ctx, user.ID, dayStart); releaseErr != nil {
+				log.Printf("vault: failed to release settlement for user %s: %v", user.ID, releaseErr)
+			}
+			return false, err
+		}
+		if err := s.userRepo.UpdateEarnedRefund(ctx, user.ID, user.EarnedRefund); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
 }
 
-// CalculateVaultStatus returns the current vault status for the user
+// CalculateVaultStatus returns the current vault status for the user.
+// EarnedRefund is a projection of the ledger's earned balance, refreshed on every credit.
 func (s *VaultService) CalculateVaultStatus(user *domain.User) (deposit float64, earned float64, potentialRefund float64) {
 	if !user.IsVaultMember() {
 		return 0, 0, 0
@@ -70,45 +171,273 @@ func (s *VaultService) CalculateVaultStatus(user *domain.User) (deposit float64,
 	return
 }
 
-func (s *VaultService) AddDailyEarnings(ctx context.Context, user *domain.User, amount float64) {
+// AddReferralReward credits a referral reward to a vault member's ledger and saves the user's
+// earned refund.
+// Rewarding the same referral twice is a no-op.
+func (s *VaultService) AddReferralReward(ctx context.Context, user *domain.User, referralID uuid.UUID, amount float64) error {
 	if !user.IsVaultMember() {
-		return
+		return nil
 	}
+	err := s.creditEarning(ctx, user, time.Now(), ledgerCredit{domain.LedgerEntryReferralReward, domain.EarningItem{
+		Amount:     amount,
+		Reason:     "Referral reward",
+		SourceType: domain.LedgerSourceReferral,
+		SourceID:   referralID.String(),
+	}})
+	if err != nil {
+		return err
+	}
+	return s.userRepo.UpdateEarnedRefund(ctx, user.ID, user.EarnedRefund)
+}
 
-	// 1. Update User Record
-	user.EarnedRefund += amount
-	if user.EarnedRefund > user.VaultDeposit {
-		user.EarnedRefund = user.VaultDeposit
+// CollectStake charges a friend challenge or tribe pool stake to the user's card on file and
+// returns the charge ID. Failing to charge, including having no card on file, is ErrStakeNotCharged.
+func (s *VaultService) CollectStake(ctx context.Context, userID uuid.UUID, amount float64, description, idempotencyKey string) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
 	}
+	if user.StripeCustomerID == "" {
+		return "", fmt.Errorf("%w: no card on file", domain.ErrStakeNotCharged)
+	}
+	chargeID, err := s.paymentGateway.ChargeCustomer(user.StripeCustomerID, amount, description, idempotencyKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrStakeNotCharged, err)
+	}
+	return chargeID, nil
+}
 
-	// 2. Update VaultParticipation Record
-	// Find current month's participation
-	now := time.Now()
-	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+// PayOutStake refunds up to the stake against the charge that collected it and credits anything
+// won on top to the earned balance. The refund is keyed by the charge and the credit by the
+// payout's source, so paying the same payout again changes nothing.
+func (s *VaultService) PayOutStake(ctx context.Context, userID uuid.UUID, payout domain.StakePayout) error {
+	refund := roundCents(math.Min(payout.Amount, payout.Stake))
+	if refund > 0 {
+		if payout.ChargeID == "" {
+			return fmt.Errorf("no stake payment recorded to refund")
+		}
+		if _, _, err := s.paymentGateway.CreateRefund(payout.ChargeID, refund, "stake-refund-"+payout.ChargeID); err != nil {
+			return err
+		}
+	}
 
+	winnings := roundCents(payout.Amount - refund)
+	if winnings <= 0 {
+		return nil
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = s.creditEarning(ctx, user, time.Now(), ledgerCredit{domain.LedgerEntryStakeWinnings, domain.EarningItem{
+		Amount:     winnings,
+		Reason:     payout.Reason,
+		SourceType: payout.SourceType,
+		SourceID:   payout.SourceID,
+	}})
+	if err != nil {
+		return err
+	}
+	return s.userRepo.UpdateEarnedRefund(ctx, user.ID, user.EarnedRefund)
+}
+
+// ledgerCredit is an earning to post to the ledger as an entry of the given type
+type ledgerCredit struct {
+	entryType domain.LedgerEntryType
+	item      domain.EarningItem
+}
+
+// creditEarning posts earning entries to the ledger for the month containing at, then refreshes
+// the user's EarnedRefund and the month's VaultParticipation from the ledger. Daily earnings move
+// money out of the month's held deposit, so they are capped at what is still held.
+// The user record is updated in memory only; callers are responsible for saving it.
+func (s *VaultService) creditEarning(ctx context.Context, user *domain.User, at time.Time, credits ...ledgerCredit) error {
+	at = at.UTC()
+	monthStart := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	vault, err := s.findOrCreateParticipation(ctx, user, monthStart)
+	if err != nil {
+		return err
+	}
+	if vault.RefundProcessed {
+		return fmt.Errorf("vault month %s is already closed", monthStart.Format("2006-01"))
+	}
+
+	ledger, err := s.GetLedger(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureMonthDeposit(ctx, ledger, vault); err != nil {
+		return err
+	}
+
+	for _, credit := range credits {
+		amount := credit.item.Amount
+		if credit.entryType == domain.LedgerEntryDailyEarning {
+			held := ledger.Between(monthStart, monthEnd).Balance(domain.LedgerAccountHeld)
+			amount = roundCents(math.Min(amount, held))
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		entry, err := domain.NewVaultLedgerEntry(user.ID, credit.entryType, amount,
+			credit.item.Reason, credit.item.SourceType, credit.item.SourceID, at)
+		if err != nil {
+			return err
+		}
+		appended, err := s.ledgerRepo.Append(ctx, entry)
+		if err != nil {
+			return err
+		}
+		if appended {
+			ledger.Entries = append(ledger.Entries, *entry)
+		}
+		// Not appended means this source was already credited
+	}
+
+	// Refresh projections from the ledger
+	user.EarnedRefund = ledger.Balance(domain.LedgerAccountEarned)
+	recovered := ledger.Between(monthStart, monthEnd).Total(
+		domain.LedgerEntryDailyEarning, domain.LedgerEntryReferralReward, domain.LedgerEntryStreakBonus, domain.LedgerEntryStakeWinnings)
+	vault.AmountRecovered = math.Min(recovered, vault.DepositAmount)
+	vault.UpdatedAt = time.Now()
+	return s.vaultRepo.Save(ctx, vault)
+}
+
+// findOrCreateParticipation returns the user's participation for the month, creating it lazily
+func (s *VaultService) findOrCreateParticipation(ctx context.Context, user *domain.User, monthStart time.Time) (*domain.VaultParticipation, error) {
 	vault, err := s.vaultRepo.FindByUserIDAndMonth(ctx, user.ID, monthStart)
-	if err == nil && vault != nil {
-		vault.AmountRecovered += amount
-		if vault.AmountRecovered > vault.DepositAmount {
-			vault.AmountRecovered = vault.DepositAmount
+	if err != nil {
+		return nil, err
+	}
+	if vault != nil {
+		return vault, nil
+	}
+
+	vault = &domain.VaultParticipation{
+		ID:            uuid.New(),
+		UserID:        user.ID,
+		MonthStart:    monthStart,
+		MonthEnd:      monthStart.AddDate(0, 1, 0).Add(-time.Second),
+		DepositAmount: user.VaultDeposit,
+		OptedIn:       true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := s.vaultRepo.Save(ctx, vault); err != nil {
+		return nil, err
+	}
+	return vault, nil
+}
+
+// RecordDeposit holds a paid subscription invoice as the vault deposit for the month it was paid in
+// and posts it to the ledger. chargeID is the charge that paid it; the month's refund goes against it.
+// A month whose deposit is already posted keeps it, so replays and a second invoice in the same month
+// change nothing. The user's VaultDeposit is set to the month's deposit.
+// The user record is updated in memory only; callers are responsible for saving it.
+func (s *VaultService) RecordDeposit(ctx context.Context, user *domain.User, amount float64, chargeID string, paidAt time.Time) error {
+	amount = roundCents(amount)
+	if amount <= 0 {
+		return nil
+	}
+
+	paidAt = paidAt.UTC()
+	monthStart := time.Date(paidAt.Year(), paidAt.Month(), 1, 0, 0, 0, 0, time.UTC)
+	vault, err := s.findOrCreateParticipation(ctx, user, monthStart)
+	if err != nil {
+		return err
+	}
+	if vault.RefundProcessed {
+		return fmt.Errorf("vault month %s is already closed", monthStart.Format("2006-01"))
+	}
+
+	ledger, err := s.GetLedger(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if hasMonthDeposit(ledger, vault) {
+		user.VaultDeposit = vault.DepositAmount
+		if vault.DepositChargeID != "" || chargeID == "" {
+			return nil
 		}
-		vault.UpdatedAt = time.Now()
-		_ = s.vaultRepo.Save(ctx, vault)
+		// Deposit backfilled from the user record before its payment arrived
+		vault.DepositChargeID = chargeID
 	} else {
-		// Create if not exists (lazy creation)
-		vault = &domain.VaultParticipation{
-			ID:              uuid.New(),
-			UserID:          user.ID,
-			MonthStart:      monthStart,
-			MonthEnd:        monthStart.AddDate(0, 1, 0).Add(-time.Second),
-			DepositAmount:   user.VaultDeposit,
-			AmountRecovered: amount, // Start with this amount
-			OptedIn:         true,
-			CreatedAt:       time.Now(),
-			UpdatedAt:       time.Now(),
+		vault.DepositAmount = amount
+		vault.DepositChargeID = chargeID
+		user.VaultDeposit = amount
+		if err := s.ensureMonthDeposit(ctx, ledger, vault); err != nil {
+			return err
+		}
+	}
+	vault.UpdatedAt = time.Now()
+	return s.vaultRepo.Save(ctx, vault)
+}
+
+// ensureMonthDeposit posts the deposit for a participation if the ledger doesn't have it yet.
+// Participations created before the ledger existed get their deposit backfilled this way.
+func (s *VaultService) ensureMonthDeposit(ctx context.Context, ledger *domain.VaultLedger, vault *domain.VaultParticipation) error {
+	if vault.DepositAmount <= 0 || hasMonthDeposit(ledger, vault) {
+		return nil
+	}
+
+	entry, err := domain.NewVaultLedgerEntry(vault.UserID, domain.LedgerEntryDeposit, vault.DepositAmount,
+		fmt.Sprintf("Vault deposit for %s", vault.MonthStart.Format("January 2006")),
+		domain.LedgerSourceParticipation, vault.ID.String(), vault.MonthStart)
+	if err != nil {
+		return err
+	}
+	appended, err := s.ledgerRepo.Append(ctx, entry)
+	if err != nil {
+		return err
+	}
+	if appended {
+		ledger.Entries = append(ledger.Entries, *entry)
+	}
+	return nil
+}
+
+// hasMonthDeposit reports whether the ledger already holds the participation's deposit
+func hasMonthDeposit(ledger *domain.VaultLedger, vault *domain.VaultParticipation) bool {
+	for _, e := range ledger.Entries {
+		if e.Type == domain.LedgerEntryDeposit && e.SourceID == vault.ID.String() {
+			return true
 		}
-		_ = s.vaultRepo.Save(ctx, vault)
 	}
+	return false
+}
+
+// GetLedger loads all of a user's ledger entries
+func (s *VaultService) GetLedger(ctx context.Context, userID uuid.UUID) (*domain.VaultLedger, error) {
+	entries, err := s.ledgerRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.VaultLedger{UserID: userID, Entries: entries}, nil
+}
+
+// GetStatement returns the user's ledger entries effective in [from, to) with the
+// balances before and after the period
+func (s *VaultService) GetStatement(ctx context.Context, userID uuid.UUID, from, to time.Time) (*domain.VaultStatement, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("statement period end must be after start")
+	}
+
+	ledger, err := s.GetLedger(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.VaultStatement{
+		UserID:         userID,
+		PeriodStart:    from,
+		PeriodEnd:      to,
+		OpeningBalance: ledger.Before(from).Summary(),
+		ClosingBalance: ledger.Before(to).Summary(),
+		Entries:        ledger.Between(from, to).Entries,
+	}, nil
 }
 
 // Deprecated: Kept for backward compatibility until full migration
@@ -117,35 +446,213 @@ func (s *VaultService) CalculatePrice(ctx context.Context, user *domain.User) fl
 	return MonthlyCharge - potentialRefund
 }
 
-func (s *VaultService) UpdateDisciplineIndex(ctx context.Context, user *domain.User, completedFast bool, verifiedKetosis bool) {
-	if completedFast {
-		user.DisciplineIndex += 1.0
+// ProcessMonthlyRefunds closes the previous calendar month (UTC). It is wired to the monthly cron job.
+func (s *VaultService) ProcessMonthlyRefunds(ctx context.Context) error {
+	now := time.Now().UTC()
+	lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+
+	report, err := s.CloseMonth(ctx, lastMonth, false)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, line := range report.Lines {
+		if line.Status == domain.VaultRefundFailed {
+			failed++
+		}
 	}
-	if verifiedKetosis {
-		user.DisciplineIndex += 2.0
+	if failed > 0 {
+		return fmt.Errorf("monthly refunds for %s failed for %d participations", lastMonth.Format("2006-01"), failed)
 	}
+	return nil
+}
 
-	if user.DisciplineIndex > 100 {
-		user.DisciplineIndex = 100
+// CloseMonth refunds each open participation of the month MIN(earned, deposit) per LAZY_TAX_RULES.md,
+// forfeits the deposit that was not earned back and marks the participation closed (RefundProcessed).
+// Participations that fail are left open so the next run retries them; refunds are idempotent per
+// participation. With dryRun nothing is written and no money moves, the report shows what would happen.
+func (s *VaultService) CloseMonth(ctx context.Context, monthStart time.Time, dryRun bool) (*domain.VaultRefundReport, error) {
+	monthStart = monthStart.UTC()
+	monthStart = time.Date(monthStart.Year(), monthStart.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if !dryRun && monthStart.AddDate(0, 1, 0).After(time.Now()) {
+		return nil, fmt.Errorf("cannot close %s before the month has ended", monthStart.Format("2006-01"))
 	}
-	if user.DisciplineIndex < 0 {
-		user.DisciplineIndex = 0
+
+	vaults, err := s.vaultRepo.ListOpenByMonth(ctx, monthStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open participations: %w", err)
 	}
 
-	// Calculate and add earnings if user is a vault member
-	if user.IsVaultMember() {
-		earning := s.CalculateDailyEarning(int(user.DisciplineIndex))
-		s.AddDailyEarnings(ctx, user, earning)
+	report := &domain.VaultRefundReport{
+		MonthStart: monthStart,
+		DryRun:     dryRun,
+		Lines:      []domain.VaultRefundLine{},
 	}
+	for _, vault := range vaults {
+		line := s.closeParticipation(ctx, vault, dryRun)
+		if line.Status == domain.VaultRefundFailed {
+			log.Printf("vault: failed to close participation %s for user %s: %s", vault.ID, vault.UserID, line.Error)
+		} else {
+			report.TotalRefund += line.Refund
+			report.TotalForfeit += line.Forfeit
+		}
+		report.Lines = append(report.Lines, line)
+	}
+	return report, nil
 }
 
-func (s *VaultService) ProcessMonthlyRefunds(ctx context.Context) error {
-	// Logic to process refunds at the end of the billing cycle
+// closeParticipation computes and, unless dryRun, applies the month-end refund and forfeit for one participation
+func (s *VaultService) closeParticipation(ctx context.Context, vault *domain.VaultParticipation, dryRun bool) domain.VaultRefundLine {
+	line := domain.VaultRefundLine{
+		ParticipationID: vault.ID,
+		UserID:          vault.UserID,
+		Deposit:         vault.DepositAmount,
+	}
+	fail := func(err error) domain.VaultRefundLine {
+		line.Status = domain.VaultRefundFailed
+		line.Error = err.Error()
+		return line
+	}
+
+	user, err := s.userRepo.FindByID(ctx, vault.UserID)
+	if err != nil {
+		return fail(err)
+	}
+	line.Email = user.Email
+
+	ledger, err := s.GetLedger(ctx, user.ID)
+	if err != nil {
+		return fail(err)
+	}
+	monthEnd := vault.MonthStart.AddDate(0, 1, 0)
+	month := ledger.Between(vault.MonthStart, monthEnd)
+
+	// Refund Amount = MIN(earned_refund, vault_deposit)
+	line.Earned = roundCents(month.Total(
+		domain.LedgerEntryDailyEarning, domain.LedgerEntryReferralReward, domain.LedgerEntryStreakBonus, domain.LedgerEntryStakeWinnings))
+	line.Refund = roundCents(math.Min(math.Min(line.Earned, vault.DepositAmount), ledger.Balance(domain.LedgerAccountEarned)))
+	line.Forfeit = roundCents(math.Max(0, vault.DepositAmount-
+		month.Total(domain.LedgerEntryDailyEarning)-month.Total(domain.LedgerEntryForfeit)))
+
+	if dryRun {
+		line.Status = domain.VaultRefundPlanned
+		return line
+	}
+
+	if err := s.ensureMonthDeposit(ctx, ledger, vault); err != nil {
+		return fail(err)
+	}
+
+	closedAt := monthEnd.Add(-time.Second)
+	monthName := vault.MonthStart.Format("January 2006")
+
+	if line.Refund > 0 {
+		if vault.DepositChargeID == "" {
+			return fail(fmt.Errorf("no deposit payment recorded to refund"))
+		}
+		refundID, refunded, err := s.paymentGateway.CreateRefund(vault.DepositChargeID, line.Refund, "vault-refund-"+vault.ID.String())
+		if err != nil {
+			return fail(err)
+		}
+		line.RefundID = refundID
+		line.Refund = roundCents(refunded)
+
+		if err := s.appendEntry(ctx, ledger, user.ID, domain.LedgerEntryRefund, line.Refund,
+			fmt.Sprintf("Vault refund for %s (%s)", monthName, refundID), vault.ID.String(), closedAt); err != nil {
+			return fail(err)
+		}
+	}
+
+	if line.Forfeit > 0 {
+		if err := s.appendEntry(ctx, ledger, user.ID, domain.LedgerEntryForfeit, line.Forfeit,
+			fmt.Sprintf("Unearned vault deposit for %s", monthName), vault.ID.String(), closedAt); err != nil {
+			return fail(err)
+		}
+	}
+
+	now := time.Now()
+	vault.AmountRecovered = line.Refund
+	vault.ForfeitedAmount = line.Forfeit
+	vault.RefundProcessed = true
+	vault.RefundDate = &now
+	vault.UpdatedAt = now
+	if err := s.vaultRepo.Save(ctx, vault); err != nil {
+		return fail(err)
+	}
+
+	user.EarnedRefund = ledger.Balance(domain.LedgerAccountEarned)
+	if err := s.userRepo.Save(ctx, user); err != nil {
+		return fail(err)
+	}
+
+	if line.Refund > 0 {
+		line.Status = domain.VaultRefundRefunded
+	} else {
+		line.Status = domain.VaultRefundClosed
+	}
+	return line
+}
+
+// appendEntry posts a month-close entry referencing the participation and adds it to the in-memory ledger
+func (s *VaultService) appendEntry(ctx context.Context, ledger *domain.VaultLedger, userID uuid.UUID, entryType domain.LedgerEntryType, amount float64, reason, participationID string, at time.Time) error {
+	entry, err := domain.NewVaultLedgerEntry(userID, entryType, amount, reason, domain.LedgerSourceParticipation, participationID, at)
+	if err != nil {
+		return err
+	}
+	appended, err := s.ledgerRepo.Append(ctx, entry)
+	if err != nil {
+		return err
+	}
+	if appended {
+		ledger.Entries = append(ledger.Entries, *entry)
+	}
 	return nil
 }
 
+// WriteRefundReportCSV writes a monthly refund report as CSV, one row per participation
+func WriteRefundReportCSV(w io.Writer, report *domain.VaultRefundReport) error {
+	cw := csv.NewWriter(w)
+	header := []string{"month", "participation_id", "user_id", "email", "deposit", "earned", "refund", "forfeit", "refund_id", "status", "error"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	month := report.MonthStart.Format("2006-01")
+	for _, line := range report.Lines {
+		record := []string{
+			month,
+			line.ParticipationID.String(),
+			line.UserID.String(),
+			line.Email,
+			fmt.Sprintf("%.2f", line.Deposit),
+			fmt.Sprintf("%.2f", line.Earned),
+			fmt.Sprintf("%.2f", line.Refund),
+			fmt.Sprintf("%.2f", line.Forfeit),
+			line.RefundID,
+			string(line.Status),
+			line.Error,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
 func (s *VaultService) GetCurrentParticipation(ctx context.Context, userID uuid.UUID) (*domain.VaultParticipation, error) {
 	now := time.Now()
 	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
 	return s.vaultRepo.FindByUserIDAndMonth(ctx, userID, monthStart)
 }
+
+// roundCents rounds an amount to whole cents
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// startOfDay truncates t to midnight UTC
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -1,12 +1,55 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"fastinghero/internal/core/domain"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+// MockVaultRepository is a mock implementation of ports.VaultRepository
+type MockVaultRepository struct {
+	mock.Mock
+}
+
+func (m *MockVaultRepository) Save(ctx context.Context, vault *domain.VaultParticipation) error {
+	args := m.Called(ctx, vault)
+	return args.Error(0)
+}
+
+func (m *MockVaultRepository) FindByUserIDAndMonth(ctx context.Context, userID uuid.UUID, monthStart time.Time) (*domain.VaultParticipation, error) {
+	args := m.Called(ctx, userID, monthStart)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VaultParticipation), args.Error(1)
+}
+
+func (m *MockVaultRepository) ListOpenByMonth(ctx context.Context, monthStart time.Time) ([]*domain.VaultParticipation, error) {
+	args := m.Called(ctx, monthStart)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.VaultParticipation), args.Error(1)
+}
+
+func (m *MockVaultRepository) ClaimDailySettlement(ctx context.Context, settlement *domain.VaultDailySettlement) (bool, error) {
+	args := m.Called(ctx, settlement)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockVaultRepository) ReleaseDailySettlement(ctx context.Context, userID uuid.UUID, day time.Time) error {
+	args := m.Called(ctx, userID, day)
+	return args.Error(0)
+}
+
 func TestVaultService_CalculateVaultStatus(t *testing.T) {
-	service := NewVaultService(nil, nil, nil)
+	service := NewVaultService(nil, nil, nil, nil, nil)
 
 	tests := []struct {
 		name            string
@@ -70,7 +113,7 @@ func TestVaultService_CalculateVaultStatus(t *testing.T) {
 }
 
 func TestVaultService_CalculateDailyEarning(t *testing.T) {
-	service := NewVaultService(nil, nil, nil)
+	service := NewVaultService(nil, nil, nil, nil, nil)
 
 	tests := []struct {
 		name            string
@@ -92,3 +135,571 @@ func TestVaultService_CalculateDailyEarning(t *testing.T) {
 		})
 	}
 }
+
+// MockVaultLedgerRepository is a mock implementation of ports.VaultLedgerRepository
+type MockVaultLedgerRepository struct {
+	mock.Mock
+}
+
+func (m *MockVaultLedgerRepository) Append(ctx context.Context, entry *domain.VaultLedgerEntry) (bool, error) {
+	args := m.Called(ctx, entry)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockVaultLedgerRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]domain.VaultLedgerEntry, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.VaultLedgerEntry), args.Error(1)
+}
+
+func ledgerEntry(t *testing.T, userID uuid.UUID, entryType domain.LedgerEntryType, amount float64, sourceID string, at time.Time) domain.VaultLedgerEntry {
+	entry, err := domain.NewVaultLedgerEntry(userID, entryType, amount, "test", "test", sourceID, at)
+	assert.NoError(t, err)
+	return *entry
+}
+
+func isLedgerEntry(entryType domain.LedgerEntryType, amount float64) interface{} {
+	return mock.MatchedBy(func(e *domain.VaultLedgerEntry) bool {
+		return e.Type == entryType && e.Amount == amount
+	})
+}
+
+func newVaultMember() *domain.User {
+	return &domain.User{
+		ID:                 uuid.New(),
+		Email:              "vault@example.com",
+		SubscriptionTier:   domain.TierVault,
+		SubscriptionStatus: domain.SubStatusActive,
+		VaultDeposit:       20.0,
+		DisciplineIndex:    50,
+	}
+}
+
+// MockEarningRulesEngine is a mock implementation of ports.EarningRulesEngine
+type MockEarningRulesEngine struct {
+	mock.Mock
+}
+
+func (m *MockEarningRulesEngine) EvaluateDay(ctx context.Context, userID uuid.UUID, day time.Time) (*domain.DayEarnings, error) {
+	args := m.Called(ctx, userID, day)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DayEarnings), args.Error(1)
+}
+
+func dayEarnings(day time.Time, items ...domain.EarningItem) *domain.DayEarnings {
+	result := &domain.DayEarnings{Date: day, RuleVersion: 1, Items: []domain.EarningItem{}, Bonuses: []domain.EarningItem{}}
+	for _, item := range items {
+		if item.Kind == domain.EarningRuleStreakBonus || item.Kind == domain.EarningRulePerfectMonth {
+			result.Bonuses = append(result.Bonuses, item)
+			continue
+		}
+		result.Items = append(result.Items, item)
+		result.DailyTotal += item.Amount
+	}
+	return result
+}
+
+func earningItem(kind domain.EarningRuleKind, amount float64) domain.EarningItem {
+	return domain.EarningItem{RuleID: string(kind), Kind: kind, Amount: amount, Reason: "test", SourceType: "test", SourceID: uuid.NewString()}
+}
+
+func TestVaultService_ProcessDailyEarningsForDay_CreditsQualifyingDay(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	ledgerRepo := new(MockVaultLedgerRepository)
+	rules := new(MockEarningRulesEngine)
+	service := NewVaultService(userRepo, vaultRepo, ledgerRepo, rules, nil)
+	ctx := context.Background()
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	user := newVaultMember()
+	participation := &domain.VaultParticipation{ID: uuid.New(), UserID: user.ID, MonthStart: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), DepositAmount: 20.0}
+
+	userRepo.On("ListVaultMembers", ctx, uuid.Nil, dailySettlementBatchSize).Return([]*domain.User{user}, nil)
+	rules.On("EvaluateDay", ctx, user.ID, day).Return(dayEarnings(day,
+		earningItem(domain.EarningRuleMealPhoto, 0.5),
+		earningItem(domain.EarningRuleSteps, 0.5),
+		earningItem(domain.EarningRuleStreakBonus, 5.0),
+	), nil)
+	vaultRepo.On("ClaimDailySettlement", ctx, mock.MatchedBy(func(s *domain.VaultDailySettlement) bool {
+		return s.UserID == user.ID && s.SettlementDate.Equal(day) && s.Amount == 6.0 && s.RuleVersion == 1
+	})).Return(true, nil)
+	vaultRepo.On("FindByUserIDAndMonth", ctx, user.ID, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)).Return(participation, nil)
+	ledgerRepo.On("FindByUserID", ctx, user.ID).Return([]domain.VaultLedgerEntry{}, nil)
+	ledgerRepo.On("Append", ctx, isLedgerEntry(domain.LedgerEntryDeposit, 20.0)).Return(true, nil)
+	ledgerRepo.On("Append", ctx, isLedgerEntry(domain.LedgerEntryDailyEarning, 0.5)).Return(true, nil).Twice()
+	ledgerRepo.On("Append", ctx, isLedgerEntry(domain.LedgerEntryStreakBonus, 5.0)).Return(true, nil)
+	vaultRepo.On("Save", ctx, participation).Return(nil)
+	userRepo.On("UpdateEarnedRefund", ctx, user.ID, 6.0).Return(nil)
+
+	settled, err := service.ProcessDailyEarningsForDay(ctx, day.Add(5*time.Hour))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, settled)
+	assert.Equal(t, 6.0, user.EarnedRefund)
+	assert.Equal(t, 6.0, participation.AmountRecovered)
+	userRepo.AssertExpectations(t)
+	vaultRepo.AssertExpectations(t)
+	ledgerRepo.AssertExpectations(t)
+}
+
+func TestVaultService_ProcessDailyEarningsForDay_AlreadySettled(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	ledgerRepo := new(MockVaultLedgerRepository)
+	rules := new(MockEarningRulesEngine)
+	service := NewVaultService(userRepo, vaultRepo, ledgerRepo, rules, nil)
+	ctx := context.Background()
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	user := newVaultMember()
+
+	userRepo.On("ListVaultMembers", ctx, uuid.Nil, dailySettlementBatchSize).Return([]*domain.User{user}, nil)
+	rules.On("EvaluateDay", ctx, user.ID, day).Return(dayEarnings(day, earningItem(domain.EarningRuleFastCompleted, 0.5)), nil)
+	vaultRepo.On("ClaimDailySettlement", ctx, mock.AnythingOfType("*domain.VaultDailySettlement")).Return(false, nil)
+
+	settled, err := service.ProcessDailyEarningsForDay(ctx, day)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, settled)
+	assert.Equal(t, 0.0, user.EarnedRefund)
+	userRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	vaultRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	ledgerRepo.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+}
+
+func TestVaultService_ProcessDailyEarningsForDay_NothingEarned(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	rules := new(MockEarningRulesEngine)
+	service := NewVaultService(userRepo, vaultRepo, nil, rules, nil)
+	ctx := context.Background()
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	user := newVaultMember()
+
+	userRepo.On("ListVaultMembers", ctx, uuid.Nil, dailySettlementBatchSize).Return([]*domain.User{user}, nil)
+	rules.On("EvaluateDay", ctx, user.ID, day).Return(dayEarnings(day), nil)
+	vaultRepo.On("ClaimDailySettlement", ctx, mock.MatchedBy(func(s *domain.VaultDailySettlement) bool {
+		return s.Amount == 0
+	})).Return(true, nil)
+
+	settled, err := service.ProcessDailyEarningsForDay(ctx, day)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, settled)
+	assert.Equal(t, 0.0, user.EarnedRefund)
+	userRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestVaultService_ProcessDailyEarningsForDay_CappedAtHeldDeposit(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	ledgerRepo := new(MockVaultLedgerRepository)
+	rules := new(MockEarningRulesEngine)
+	service := NewVaultService(userRepo, vaultRepo, ledgerRepo, rules, nil)
+	ctx := context.Background()
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	user := newVaultMember()
+	participation := &domain.VaultParticipation{ID: uuid.New(), UserID: user.ID, MonthStart: monthStart, DepositAmount: 20.0}
+
+	// Only 0.40 of the deposit is still held
+	existing := []domain.VaultLedgerEntry{
+		ledgerEntry(t, user.ID, domain.LedgerEntryDeposit, 20.0, participation.ID.String(), monthStart),
+		ledgerEntry(t, user.ID, domain.LedgerEntryDailyEarning, 19.6, uuid.NewString(), day.AddDate(0, 0, -1)),
+	}
+
+	userRepo.On("ListVaultMembers", ctx, uuid.Nil, dailySettlementBatchSize).Return([]*domain.User{user}, nil)
+	rules.On("EvaluateDay", ctx, user.ID, day).Return(dayEarnings(day,
+		earningItem(domain.EarningRuleMealPhoto, 0.5),
+		earningItem(domain.EarningRuleSteps, 0.5),
+	), nil)
+	vaultRepo.On("ClaimDailySettlement", ctx, mock.AnythingOfType("*domain.VaultDailySettlement")).Return(true, nil)
+	vaultRepo.On("FindByUserIDAndMonth", ctx, user.ID, monthStart).Return(participation, nil)
+	ledgerRepo.On("FindByUserID", ctx, user.ID).Return(existing, nil)
+	ledgerRepo.On("Append", ctx, isLedgerEntry(domain.LedgerEntryDailyEarning, 0.4)).Return(true, nil).Once()
+	vaultRepo.On("Save", ctx, participation).Return(nil)
+	userRepo.On("UpdateEarnedRefund", ctx, user.ID, mock.AnythingOfType("float64")).Return(nil)
+
+	_, err := service.ProcessDailyEarningsForDay(ctx, day)
+
+	assert.NoError(t, err)
+	assert.InDelta(t, 20.0, user.EarnedRefund, 0.001)
+	assert.InDelta(t, 20.0, participation.AmountRecovered, 0.001)
+	ledgerRepo.AssertExpectations(t)
+}
+
+func TestVaultService_ProcessDailyEarningsForDay_ClosedMonthReleasesMarker(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	rules := new(MockEarningRulesEngine)
+	service := NewVaultService(userRepo, vaultRepo, nil, rules, nil)
+	ctx := context.Background()
+
+	day := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	user := newVaultMember()
+	participation := &domain.VaultParticipation{ID: uuid.New(), UserID: user.ID, MonthStart: monthStart, DepositAmount: 20.0, RefundProcessed: true}
+
+	userRepo.On("ListVaultMembers", ctx, uuid.Nil, dailySettlementBatchSize).Return([]*domain.User{user}, nil)
+	rules.On("EvaluateDay", ctx, user.ID, day).Return(dayEarnings(day, earningItem(domain.EarningRuleSteps, 0.5)), nil)
+	vaultRepo.On("ClaimDailySettlement", ctx, mock.AnythingOfType("*domain.VaultDailySettlement")).Return(true, nil)
+	vaultRepo.On("FindByUserIDAndMonth", ctx, user.ID, monthStart).Return(participation, nil)
+	vaultRepo.On("ReleaseDailySettlement", ctx, user.ID, day).Return(nil)
+
+	settled, err := service.ProcessDailyEarningsForDay(ctx, day)
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, settled)
+	vaultRepo.AssertExpectations(t)
+}
+
+func TestVaultService_AddReferralReward(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	ledgerRepo := new(MockVaultLedgerRepository)
+	service := NewVaultService(userRepo, vaultRepo, ledgerRepo, nil, nil)
+	ctx := context.Background()
+
+	user := newVaultMember()
+	referralID := uuid.New()
+
+	vaultRepo.On("FindByUserIDAndMonth", ctx, user.ID, mock.AnythingOfType("time.Time")).Return(nil, nil)
+	vaultRepo.On("Save", ctx, mock.AnythingOfType("*domain.VaultParticipation")).Return(nil)
+	ledgerRepo.On("FindByUserID", ctx, user.ID).Return([]domain.VaultLedgerEntry{}, nil)
+	ledgerRepo.On("Append", ctx, isLedgerEntry(domain.LedgerEntryDeposit, 20.0)).Return(true, nil)
+	ledgerRepo.On("Append", ctx, mock.MatchedBy(func(e *domain.VaultLedgerEntry) bool {
+		return e.Type == domain.LedgerEntryReferralReward && e.Amount == 5.0 &&
+			e.SourceType == domain.LedgerSourceReferral && e.SourceID == referralID.String()
+	})).Return(true, nil)
+	userRepo.On("UpdateEarnedRefund", ctx, user.ID, 5.0).Return(nil)
+
+	err := service.AddReferralReward(ctx, user, referralID, 5.0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, user.EarnedRefund)
+	ledgerRepo.AssertExpectations(t)
+	userRepo.AssertExpectations(t)
+}
+
+func TestVaultService_AddReferralReward_PropagatesSaveError(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	ledgerRepo := new(MockVaultLedgerRepository)
+	service := NewVaultService(userRepo, vaultRepo, ledgerRepo, nil, nil)
+	ctx := context.Background()
+
+	user := newVaultMember()
+	vaultRepo.On("FindByUserIDAndMonth", ctx, user.ID, mock.AnythingOfType("time.Time")).Return(nil, nil)
+	vaultRepo.On("Save", ctx, mock.AnythingOfType("*domain.VaultParticipation")).Return(assert.AnError)
+
+	err := service.AddReferralReward(ctx, user, uuid.New(), 5.0)
+
+	assert.ErrorIs(t, err, assert.AnError)
+	ledgerRepo.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+}
+
+func TestVaultService_CollectStake(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	pg := new(MockPaymentGatewayForStripe)
+	service := NewVaultService(userRepo, nil, nil, nil, pg)
+	ctx := context.Background()
+
+	user := newVaultMember()
+	user.StripeCustomerID = "cus_123"
+	noCard := newVaultMember()
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	userRepo.On("FindByID", ctx, noCard.ID).Return(noCard, nil)
+	pg.On("ChargeCustomer", "cus_123", 20.0, "Stake", "key-1").Return("ch_stake", nil).Once()
+	pg.On("ChargeCustomer", "cus_123", 20.0, "Stake", "key-2").Return("", assert.AnError).Once()
+
+	chargeID, err := service.CollectStake(ctx, user.ID, 20.0, "Stake", "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ch_stake", chargeID)
+
+	_, err = service.CollectStake(ctx, user.ID, 20.0, "Stake", "key-2")
+	assert.ErrorIs(t, err, domain.ErrStakeNotCharged)
+
+	_, err = service.CollectStake(ctx, noCard.ID, 20.0, "Stake", "key-3")
+	assert.ErrorIs(t, err, domain.ErrStakeNotCharged)
+	pg.AssertExpectations(t)
+}
+
+func TestVaultService_PayOutStake_RefundsStakeAndCreditsWinnings(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	ledgerRepo := new(MockVaultLedgerRepository)
+	pg := new(MockPaymentGatewayForStripe)
+	service := NewVaultService(userRepo, vaultRepo, ledgerRepo, nil, pg)
+	ctx := context.Background()
+
+	user := newVaultMember()
+	participantID := uuid.NewString()
+	payout := domain.StakePayout{
+		SourceType: domain.LedgerSourceChallenge,
+		SourceID:   participantID,
+		ChargeID:   "ch_stake",
+		Stake:      20.0,
+		Amount:     40.0,
+		Reason:     "Won challenge",
+	}
+
+	pg.On("CreateRefund", "ch_stake", 20.0, "stake-refund-ch_stake").Return("re_stake", 20.0, nil)
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	vaultRepo.On("FindByUserIDAndMonth", ctx, user.ID, mock.AnythingOfType("time.Time")).Return(nil, nil)
+	vaultRepo.On("Save", ctx, mock.AnythingOfType("*domain.VaultParticipation")).Return(nil)
+	ledgerRepo.On("FindByUserID", ctx, user.ID).Return([]domain.VaultLedgerEntry{}, nil)
+	ledgerRepo.On("Append", ctx, isLedgerEntry(domain.LedgerEntryDeposit, 20.0)).Return(true, nil)
+	ledgerRepo.On("Append", ctx, mock.MatchedBy(func(e *domain.VaultLedgerEntry) bool {
+		return e.Type == domain.LedgerEntryStakeWinnings && e.Amount == 20.0 &&
+			e.SourceType == domain.LedgerSourceChallenge && e.SourceID == participantID
+	})).Return(true, nil)
+	userRepo.On("UpdateEarnedRefund", ctx, user.ID, 20.0).Return(nil)
+
+	err := service.PayOutStake(ctx, user.ID, payout)
+
+	assert.NoError(t, err)
+	pg.AssertExpectations(t)
+	ledgerRepo.AssertExpectations(t)
+	userRepo.AssertExpectations(t)
+}
+
+func TestVaultService_PayOutStake_PartialStakeIsOnlyRefunded(t *testing.T) {
+	pg := new(MockPaymentGatewayForStripe)
+	service := NewVaultService(nil, nil, nil, nil, pg)
+	ctx := context.Background()
+
+	pg.On("CreateRefund", "ch_stake", 16.0, "stake-refund-ch_stake").Return("re_stake", 16.0, nil)
+
+	err := service.PayOutStake(ctx, uuid.New(), domain.StakePayout{ChargeID: "ch_stake", Stake: 20.0, Amount: 16.0})
+
+	assert.NoError(t, err)
+	pg.AssertExpectations(t)
+}
+
+func TestVaultService_GetStatement(t *testing.T) {
+	ledgerRepo := new(MockVaultLedgerRepository)
+	service := NewVaultService(nil, nil, ledgerRepo, nil, nil)
+	ctx := context.Background()
+
+	userID := uuid.New()
+	feb := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	apr := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	ledgerRepo.On("FindByUserID", ctx, userID).Return([]domain.VaultLedgerEntry{
+		ledgerEntry(t, userID, domain.LedgerEntryDeposit, 20.0, "feb", feb),
+		ledgerEntry(t, userID, domain.LedgerEntryDailyEarning, 12.0, "s1", feb.AddDate(0, 0, 5)),
+		ledgerEntry(t, userID, domain.LedgerEntryForfeit, 8.0, "feb-forfeit", mar.Add(-time.Second)),
+		ledgerEntry(t, userID, domain.LedgerEntryRefund, 12.0, "feb-refund", mar),
+		ledgerEntry(t, userID, domain.LedgerEntryDeposit, 20.0, "mar", mar),
+		ledgerEntry(t, userID, domain.LedgerEntryDailyEarning, 2.0, "s2", mar.AddDate(0, 0, 1)),
+		ledgerEntry(t, userID, domain.LedgerEntryDeposit, 20.0, "apr", apr),
+	}, nil)
+
+	statement, err := service.GetStatement(ctx, userID, mar, apr)
+
+	assert.NoError(t, err)
+	assert.Len(t, statement.Entries, 3)
+	assert.Equal(t, domain.VaultBalance{Deposited: 20, Held: 0, Earned: 12, Forfeited: 8, Refunded: 0}, statement.OpeningBalance)
+	assert.Equal(t, domain.VaultBalance{Deposited: 40, Held: 18, Earned: 2, Forfeited: 8, Refunded: 12}, statement.ClosingBalance)
+
+	_, err = service.GetStatement(ctx, userID, apr, mar)
+	assert.Error(t, err)
+}
+
+func closeMonthFixture(t *testing.T) (*domain.User, *domain.VaultParticipation, []domain.VaultLedgerEntry) {
+	feb := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	user := newVaultMember()
+	participation := &domain.VaultParticipation{
+		ID:              uuid.New(),
+		UserID:          user.ID,
+		MonthStart:      feb,
+		MonthEnd:        feb.AddDate(0, 1, 0).Add(-time.Second),
+		DepositAmount:   20.0,
+		DepositChargeID: "ch_123",
+	}
+	entries := []domain.VaultLedgerEntry{
+		ledgerEntry(t, user.ID, domain.LedgerEntryDeposit, 20.0, participation.ID.String(), feb),
+		ledgerEntry(t, user.ID, domain.LedgerEntryDailyEarning, 12.5, uuid.NewString(), feb.AddDate(0, 0, 3)),
+		ledgerEntry(t, user.ID, domain.LedgerEntryReferralReward, 5.0, uuid.NewString(), feb.AddDate(0, 0, 9)),
+	}
+	return user, participation, entries
+}
+
+func TestVaultService_CloseMonth_DryRun(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	ledgerRepo := new(MockVaultLedgerRepository)
+	pg := new(MockPaymentGatewayForStripe)
+	service := NewVaultService(userRepo, vaultRepo, ledgerRepo, nil, pg)
+	ctx := context.Background()
+
+	user, participation, entries := closeMonthFixture(t)
+	vaultRepo.On("ListOpenByMonth", ctx, participation.MonthStart).Return([]*domain.VaultParticipation{participation}, nil)
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	ledgerRepo.On("FindByUserID", ctx, user.ID).Return(entries, nil)
+
+	report, err := service.CloseMonth(ctx, participation.MonthStart.AddDate(0, 0, 14), true)
+
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Len(t, report.Lines, 1)
+	line := report.Lines[0]
+	assert.Equal(t, domain.VaultRefundPlanned, line.Status)
+	assert.Equal(t, 17.5, line.Earned)
+	assert.Equal(t, 17.5, line.Refund)
+	assert.Equal(t, 7.5, line.Forfeit)
+	assert.False(t, participation.RefundProcessed)
+	pg.AssertNotCalled(t, "CreateRefund", mock.Anything, mock.Anything, mock.Anything)
+	ledgerRepo.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+	vaultRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestVaultService_CloseMonth_RefundsAndForfeits(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	ledgerRepo := new(MockVaultLedgerRepository)
+	pg := new(MockPaymentGatewayForStripe)
+	service := NewVaultService(userRepo, vaultRepo, ledgerRepo, nil, pg)
+	ctx := context.Background()
+
+	user, participation, entries := closeMonthFixture(t)
+	user.EarnedRefund = 17.5
+	vaultRepo.On("ListOpenByMonth", ctx, participation.MonthStart).Return([]*domain.VaultParticipation{participation}, nil)
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	ledgerRepo.On("FindByUserID", ctx, user.ID).Return(entries, nil)
+	pg.On("CreateRefund", "ch_123", 17.5, "vault-refund-"+participation.ID.String()).Return("re_123", 17.5, nil)
+	ledgerRepo.On("Append", ctx, isLedgerEntry(domain.LedgerEntryRefund, 17.5)).Return(true, nil)
+	ledgerRepo.On("Append", ctx, isLedgerEntry(domain.LedgerEntryForfeit, 7.5)).Return(true, nil)
+	vaultRepo.On("Save", ctx, participation).Return(nil)
+	userRepo.On("Save", ctx, user).Return(nil)
+
+	report, err := service.CloseMonth(ctx, participation.MonthStart, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.VaultRefundRefunded, report.Lines[0].Status)
+	assert.Equal(t, "re_123", report.Lines[0].RefundID)
+	assert.Equal(t, 17.5, report.TotalRefund)
+	assert.Equal(t, 7.5, report.TotalForfeit)
+	assert.True(t, participation.RefundProcessed)
+	assert.NotNil(t, participation.RefundDate)
+	assert.Equal(t, 7.5, participation.ForfeitedAmount)
+	assert.Equal(t, 0.0, user.EarnedRefund)
+	pg.AssertExpectations(t)
+	ledgerRepo.AssertExpectations(t)
+}
+
+func TestVaultService_CloseMonth_RefundCappedByDepositCharge(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	ledgerRepo := new(MockVaultLedgerRepository)
+	pg := new(MockPaymentGatewayForStripe)
+	service := NewVaultService(userRepo, vaultRepo, ledgerRepo, nil, pg)
+	ctx := context.Background()
+
+	user, participation, entries := closeMonthFixture(t)
+	vaultRepo.On("ListOpenByMonth", ctx, participation.MonthStart).Return([]*domain.VaultParticipation{participation}, nil)
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	ledgerRepo.On("FindByUserID", ctx, user.ID).Return(entries, nil)
+	// Part of the charge was already refunded by support
+	pg.On("CreateRefund", "ch_123", 17.5, mock.Anything).Return("re_123", 12.0, nil)
+	ledgerRepo.On("Append", ctx, isLedgerEntry(domain.LedgerEntryRefund, 12.0)).Return(true, nil)
+	ledgerRepo.On("Append", ctx, isLedgerEntry(domain.LedgerEntryForfeit, 7.5)).Return(true, nil)
+	vaultRepo.On("Save", ctx, participation).Return(nil)
+	userRepo.On("Save", ctx, user).Return(nil)
+
+	report, err := service.CloseMonth(ctx, participation.MonthStart, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.VaultRefundRefunded, report.Lines[0].Status)
+	assert.Equal(t, 12.0, report.Lines[0].Refund)
+	assert.Equal(t, 12.0, participation.AmountRecovered)
+	ledgerRepo.AssertExpectations(t)
+}
+
+func TestVaultService_CloseMonth_NoDepositChargeLeavesParticipationOpen(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	ledgerRepo := new(MockVaultLedgerRepository)
+	pg := new(MockPaymentGatewayForStripe)
+	service := NewVaultService(userRepo, vaultRepo, ledgerRepo, nil, pg)
+	ctx := context.Background()
+
+	user, participation, entries := closeMonthFixture(t)
+	participation.DepositChargeID = ""
+	vaultRepo.On("ListOpenByMonth", ctx, participation.MonthStart).Return([]*domain.VaultParticipation{participation}, nil)
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	ledgerRepo.On("FindByUserID", ctx, user.ID).Return(entries, nil)
+
+	report, err := service.CloseMonth(ctx, participation.MonthStart, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.VaultRefundFailed, report.Lines[0].Status)
+	assert.False(t, participation.RefundProcessed)
+	pg.AssertNotCalled(t, "CreateRefund", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestVaultService_CloseMonth_RefundFailureLeavesParticipationOpen(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	vaultRepo := new(MockVaultRepository)
+	ledgerRepo := new(MockVaultLedgerRepository)
+	pg := new(MockPaymentGatewayForStripe)
+	service := NewVaultService(userRepo, vaultRepo, ledgerRepo, nil, pg)
+	ctx := context.Background()
+
+	user, participation, entries := closeMonthFixture(t)
+	vaultRepo.On("ListOpenByMonth", ctx, participation.MonthStart).Return([]*domain.VaultParticipation{participation}, nil)
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	ledgerRepo.On("FindByUserID", ctx, user.ID).Return(entries, nil)
+	pg.On("CreateRefund", "ch_123", 17.5, mock.Anything).Return("", 0.0, assert.AnError)
+
+	report, err := service.CloseMonth(ctx, participation.MonthStart, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.VaultRefundFailed, report.Lines[0].Status)
+	assert.Equal(t, 0.0, report.TotalRefund)
+	assert.False(t, participation.RefundProcessed)
+	ledgerRepo.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+	vaultRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestVaultService_CloseMonth_RejectsOpenMonth(t *testing.T) {
+	service := NewVaultService(nil, nil, nil, nil, nil)
+
+	_, err := service.CloseMonth(context.Background(), time.Now(), false)
+
+	assert.Error(t, err)
+}
+
+func TestWriteRefundReportCSV(t *testing.T) {
+	report := &domain.VaultRefundReport{
+		MonthStart: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		DryRun:     true,
+		Lines: []domain.VaultRefundLine{{
+			ParticipationID: uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+			UserID:          uuid.MustParse("22222222-2222-2222-2222-222222222222"),
+			Email:           "vault@example.com",
+			Deposit:         20,
+			Earned:          17.5,
+			Refund:          17.5,
+			Forfeit:         7.5,
+			Status:          domain.VaultRefundPlanned,
+		}},
+	}
+
+	var buf bytes.Buffer
+	err := WriteRefundReportCSV(&buf, report)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"month,participation_id,user_id,email,deposit,earned,refund,forfeit,refund_id,status,error\n"+
+			"2025-02,11111111-1111-1111-1111-111111111111,22222222-2222-2222-2222-222222222222,vault@example.com,20.00,17.50,17.50,7.50,,planned,\n",
+		buf.String())
+}
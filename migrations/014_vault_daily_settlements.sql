@@ -0,0 +1,11 @@
+-- Per-user, per-day markers for the daily vault settlement job.
+-- The unique constraint guarantees a day can only be credited once.
+CREATE TABLE IF NOT EXISTS vault_daily_settlements (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    settlement_date DATE NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, settlement_date)
+);
+CREATE INDEX IF NOT EXISTS idx_vault_daily_settlements_date ON vault_daily_settlements(settlement_date);
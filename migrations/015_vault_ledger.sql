@@ -0,0 +1,19 @@
+-- Append-only double-entry ledger for vault money movements.
+-- Balances are derived from these rows; entries are never updated or deleted.
+CREATE TABLE IF NOT EXISTS vault_ledger_entries (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    entry_type VARCHAR(30) NOT NULL,
+    from_account VARCHAR(20) NOT NULL,
+    to_account VARCHAR(20) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL DEFAULT '',
+    source_type VARCHAR(30) NOT NULL DEFAULT '',
+    source_id VARCHAR(100) NOT NULL DEFAULT '',
+    effective_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_vault_ledger_user ON vault_ledger_entries(user_id, effective_at);
+-- The same source event can only be posted once per user and entry type
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vault_ledger_source ON vault_ledger_entries(user_id, entry_type, source_type, source_id)
+WHERE source_id <> '';
@@ -0,0 +1,2 @@
+-- Record which earning rule set version each settled day was evaluated with
+ALTER TABLE vault_daily_settlements ADD COLUMN IF NOT EXISTS rule_version INTEGER NOT NULL DEFAULT 0;
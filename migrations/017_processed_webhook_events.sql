@@ -0,0 +1,8 @@
+-- Log of processed payment webhook events. Stripe retries deliver the same event ID,
+-- so a row here makes the retry a no-op.
+CREATE TABLE IF NOT EXISTS processed_webhook_events (
+    id VARCHAR(255) PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    processed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_processed_webhook_events_processed_at ON processed_webhook_events(processed_at);
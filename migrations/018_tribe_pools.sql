@@ -0,0 +1,12 @@
+-- Tribe monthly prize pools. Migration 010 recreated tribes with CASCADE, which dropped the
+-- tribe_pools foreign key from 007; restore it and index what the pool jobs query.
+ALTER TABLE tribe_pools DROP CONSTRAINT IF EXISTS tribe_pools_tribe_id_fkey;
+DELETE FROM tribe_pool_participants WHERE pool_id IN (
+    SELECT id FROM tribe_pools WHERE tribe_id NOT IN (SELECT id FROM tribes)
+);
+DELETE FROM tribe_pools WHERE tribe_id NOT IN (SELECT id FROM tribes);
+ALTER TABLE tribe_pools
+    ADD CONSTRAINT tribe_pools_tribe_id_fkey FOREIGN KEY (tribe_id) REFERENCES tribes(id) ON DELETE CASCADE;
+ALTER TABLE tribe_pools ALTER COLUMN status SET DEFAULT 'open';
+CREATE INDEX IF NOT EXISTS idx_tribe_pools_status ON tribe_pools(status);
+CREATE INDEX IF NOT EXISTS idx_tribe_pool_participants_pool ON tribe_pool_participants(pool_id);
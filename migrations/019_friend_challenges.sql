@@ -0,0 +1,35 @@
+-- Friend challenges with any number of participants. The 007 table only knew the legacy
+-- one-on-one columns; add the ones the repository writes and relax the legacy NOT NULLs.
+ALTER TABLE friend_challenges
+    ADD COLUMN IF NOT EXISTS creator_id UUID REFERENCES users(id),
+    ADD COLUMN IF NOT EXISTS name VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS challenge_type VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS goal INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS start_date TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS end_date TIMESTAMP WITH TIME ZONE;
+ALTER TABLE friend_challenges ALTER COLUMN challenger_id DROP NOT NULL;
+ALTER TABLE friend_challenges ALTER COLUMN challenged_id DROP NOT NULL;
+ALTER TABLE friend_challenges ALTER COLUMN month_start DROP NOT NULL;
+ALTER TABLE friend_challenges ALTER COLUMN month_end DROP NOT NULL;
+ALTER TABLE friend_challenges ALTER COLUMN pot_amount SET DEFAULT 0;
+ALTER TABLE friend_challenges ALTER COLUMN status SET DEFAULT 'active';
+CREATE INDEX IF NOT EXISTS idx_friend_challenges_creator ON friend_challenges(creator_id);
+CREATE INDEX IF NOT EXISTS idx_friend_challenges_end_date ON friend_challenges(status, end_date);
+
+CREATE TABLE IF NOT EXISTS friend_challenge_participants (
+    id UUID PRIMARY KEY,
+    challenge_id UUID NOT NULL REFERENCES friend_challenges(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id),
+    invited_by UUID REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'invited',
+    deposit_amount DECIMAL(10, 2) DEFAULT 0,
+    progress DECIMAL(12, 2) DEFAULT 0,
+    is_winner BOOLEAN DEFAULT FALSE,
+    payout_amount DECIMAL(10, 2) DEFAULT 0,
+    payout_processed BOOLEAN DEFAULT FALSE,
+    responded_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(challenge_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_friend_challenge_participants_user ON friend_challenge_participants(user_id);
@@ -0,0 +1,23 @@
+-- Free trials granted through users.trial_ends_at. The unique indexes are the anti-abuse rule:
+-- one trial per user, per normalized email and per card fingerprint.
+CREATE TABLE IF NOT EXISTS trials (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan VARCHAR(50) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    campaign VARCHAR(100),
+    email_key VARCHAR(255) NOT NULL,
+    payment_fingerprint VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reminder_sent_at TIMESTAMP WITH TIME ZONE,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trials_user ON trials(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trials_email_key ON trials(email_key);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trials_payment_fingerprint ON trials(payment_fingerprint)
+    WHERE payment_fingerprint IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_trials_active_ends_at ON trials(ends_at) WHERE status = 'active';
@@ -0,0 +1,34 @@
+-- Promo codes marketing creates through the admin API. Each code is mirrored to a Stripe coupon
+-- (coupon_id), which applies the discount at checkout.
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id UUID PRIMARY KEY,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    description TEXT,
+    discount_type VARCHAR(20) NOT NULL,
+    percent_off DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    amount_off DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    duration VARCHAR(20) NOT NULL,
+    duration_months INTEGER NOT NULL DEFAULT 0,
+    max_redemptions INTEGER NOT NULL DEFAULT 0,
+    times_redeemed INTEGER NOT NULL DEFAULT 0,
+    plans TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    coupon_id VARCHAR(255) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Redemption history; a user redeems each code once
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id UUID PRIMARY KEY,
+    promo_code_id UUID NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan VARCHAR(50) NOT NULL,
+    checkout_session_id VARCHAR(255) NOT NULL,
+    subscription_id VARCHAR(255) NOT NULL,
+    redeemed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (promo_code_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_code_redeemed_at ON promo_redemptions(promo_code_id, redeemed_at DESC);
@@ -0,0 +1,29 @@
+-- Referrals pay out on a qualifying event and can be held for fraud review.
+-- status is now pending, held, completed or rejected.
+ALTER TABLE referrals
+ADD COLUMN IF NOT EXISTS referee_reward DECIMAL(10, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS qualified_by VARCHAR(20),
+ADD COLUMN IF NOT EXISTS qualified_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS fraud_signals TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN IF NOT EXISTS reviewed_by UUID REFERENCES users(id),
+ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS review_note TEXT,
+ADD COLUMN IF NOT EXISTS referrer_paid_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS referee_paid_at TIMESTAMP WITH TIME ZONE;
+
+-- Referrals completed before this migration were paid through the old flat reward
+UPDATE referrals
+SET referee_reward = reward_value, referrer_paid_at = completed_at, referee_paid_at = completed_at
+WHERE status = 'completed';
+
+CREATE INDEX IF NOT EXISTS idx_referrals_status ON referrals(status);
+
+-- Where each user signed up from, compared across a referrer's referrals
+CREATE TABLE IF NOT EXISTS signup_signals (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    device_id VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_signup_signals_ip ON signup_signals(ip) WHERE ip <> '';
+CREATE INDEX IF NOT EXISTS idx_signup_signals_device ON signup_signals(device_id) WHERE device_id <> '';
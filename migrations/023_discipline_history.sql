@@ -0,0 +1,24 @@
+-- Every change to users.discipline_index, with the reason shown to the user. source_id is the
+-- fasting session, or the day for decay, so the same change can't be recorded twice.
+CREATE TABLE IF NOT EXISTS discipline_events (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_type VARCHAR(20) NOT NULL,
+    -- fast_completed, fast_broken, decay
+    delta FLOAT NOT NULL,
+    score_after FLOAT NOT NULL,
+    reason TEXT NOT NULL,
+    source_id VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE(user_id, event_type, source_id)
+);
+CREATE INDEX IF NOT EXISTS idx_discipline_events_user_created ON discipline_events(user_id, created_at DESC);
+
+-- End-of-day (UTC) scores written by the daily discipline job. Users with a zero score are skipped.
+CREATE TABLE IF NOT EXISTS discipline_snapshots (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    score FLOAT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, day)
+);
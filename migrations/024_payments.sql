@@ -0,0 +1,17 @@
+-- Card payments and refunds reported by Stripe webhooks: the payment history behind vault
+-- statements and receipts. provider_id is the invoice (charges) or refund ID.
+CREATE TABLE IF NOT EXISTS payments (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL,
+    -- charge, refund
+    amount DECIMAL(10, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    provider_id VARCHAR(255) NOT NULL,
+    reference VARCHAR(255),
+    description TEXT NOT NULL,
+    paid_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(kind, provider_id)
+);
+CREATE INDEX IF NOT EXISTS idx_payments_user_paid_at ON payments(user_id, paid_at);
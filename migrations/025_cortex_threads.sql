@@ -0,0 +1,25 @@
+-- Persisted conversations with the Cortex coach. Messages up to summarized_through are only
+-- sent to the LLM through summary, which keeps the prompt bounded on long threads.
+CREATE TABLE IF NOT EXISTS cortex_threads (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(100) NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    summarized_through INT NOT NULL DEFAULT 0,
+    message_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_cortex_threads_user_updated ON cortex_threads(user_id, updated_at DESC);
+
+-- seq numbers a thread's messages from 1; a request that loses the race for a number is rejected
+CREATE TABLE IF NOT EXISTS cortex_messages (
+    id UUID PRIMARY KEY,
+    thread_id UUID NOT NULL REFERENCES cortex_threads(id) ON DELETE CASCADE,
+    seq INT NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    -- user, assistant
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE(thread_id, seq)
+);
@@ -0,0 +1,2 @@
+-- Streamed replies cut short by a client disconnect keep the text generated so far
+ALTER TABLE cortex_messages ADD COLUMN IF NOT EXISTS interrupted BOOLEAN NOT NULL DEFAULT FALSE;
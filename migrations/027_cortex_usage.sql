@@ -0,0 +1,8 @@
+-- Cortex requests and estimated LLM tokens per user and UTC day, checked against plan budgets
+CREATE TABLE IF NOT EXISTS cortex_usage (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    requests INT NOT NULL DEFAULT 0,
+    tokens BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day)
+);
@@ -0,0 +1,11 @@
+-- Which prompt template variant each Cortex response was generated from, for A/B experiments
+CREATE TABLE IF NOT EXISTS prompt_exposures (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    prompt VARCHAR(64) NOT NULL,
+    version INT NOT NULL,
+    variant VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_exposures_variant ON prompt_exposures (prompt, version, variant, user_id);
@@ -0,0 +1,19 @@
+-- Eating-disorder and medical risk signals the Cortex safety guard found, waiting for human review.
+-- stage is input (the user's words or profile) or reply (advice Cortex gave); status is open or reviewed.
+CREATE TABLE IF NOT EXISTS safety_flags (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL,
+    stage VARCHAR(10) NOT NULL,
+    signals TEXT[] NOT NULL DEFAULT '{}',
+    bmi DECIMAL(5, 2),
+    excerpt TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    reviewed_by UUID REFERENCES users(id),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    review_note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_safety_flags_status ON safety_flags (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_safety_flags_user_open ON safety_flags (user_id) WHERE status = 'open';
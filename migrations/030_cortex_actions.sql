@@ -0,0 +1,18 @@
+-- Actions Cortex proposed on a user's data through tool calls, and what became of them.
+-- Rows are never deleted: they are the audit trail of every change Cortex made or was refused.
+-- status is proposed, executed, failed, rejected or expired.
+CREATE TABLE IF NOT EXISTS cortex_actions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tool VARCHAR(30) NOT NULL,
+    arguments JSONB NOT NULL DEFAULT '{}',
+    summary TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'proposed',
+    result JSONB,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    decided_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_cortex_actions_user ON cortex_actions (user_id, created_at DESC);
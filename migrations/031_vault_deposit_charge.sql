@@ -0,0 +1,3 @@
+-- The payment charge each month's vault deposit was paid with. Month-end refunds go against
+-- this charge rather than whatever the customer paid most recently.
+ALTER TABLE vault_participations ADD COLUMN IF NOT EXISTS deposit_charge_id VARCHAR(255);
@@ -0,0 +1,3 @@
+-- The user agent each user signed up with. Together with the network it identifies a device
+-- when the client leaves out or changes its X-Device-ID header.
+ALTER TABLE signup_signals ADD COLUMN IF NOT EXISTS user_agent VARCHAR(512) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- The payment charge each challenge participant's stake was collected with. Payouts refund
+-- against it.
+ALTER TABLE friend_challenge_participants ADD COLUMN IF NOT EXISTS deposit_charge_id VARCHAR(255);
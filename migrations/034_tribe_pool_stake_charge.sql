@@ -0,0 +1,3 @@
+-- The payment charge each tribe pool participant's deposit was collected with. Payouts refund
+-- against it.
+ALTER TABLE tribe_pool_participants ADD COLUMN IF NOT EXISTS deposit_charge_id VARCHAR(255);